@@ -1,7 +1,6 @@
 package apis
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -233,7 +232,7 @@ func handleFilterOnlyMode(e *core.RequestEvent, collection *core.Collection, que
 	client := ai.NewOpenAIClient(settings.AI)
 
 	// Call LLM
-	ctx := context.Background()
+	ctx := e.Request.Context()
 	filter, err := client.SendCompletion(ctx, systemPrompt, userPrompt)
 	
 	// DEBUG: Log response
@@ -290,7 +289,7 @@ func handleDualOutputMode(e *core.RequestEvent, collection *core.Collection, que
 	client := ai.NewOpenAIClient(settings.AI)
 
 	// Call LLM
-	ctx := context.Background()
+	ctx := e.Request.Context()
 	llmResponse, err := client.SendCompletion(ctx, systemPrompt, userPrompt)
 	
 	// DEBUG: Log response
@@ -367,7 +366,7 @@ func handleSQLOnlyMode(e *core.RequestEvent, query string) (AIQueryResponse, err
 	client := ai.NewOpenAIClient(settings.AI)
 
 	// Call LLM
-	ctx := context.Background()
+	ctx := e.Request.Context()
 	sqlQuery, err := client.SendCompletion(ctx, systemPrompt, userPrompt)
 	
 	// DEBUG: Log response
@@ -10,6 +10,7 @@ import (
 
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/tracing"
 )
 
 // StaticWildcardParam is the name of Static handler wildcard parameter.
@@ -23,6 +24,10 @@ func NewRouter(app core.App) (*router.Router[*core.RequestEvent], error) {
 		event.Request = r
 		event.App = app
 
+		if tracer := app.Tracer(); tracer != nil {
+			return event, traceRequest(tracer, event)
+		}
+
 		return event, nil
 	})
 
@@ -38,6 +43,7 @@ func NewRouter(app core.App) (*router.Router[*core.RequestEvent], error) {
 	bindSettingsApi(app, apiGroup)
 	bindCollectionApi(app, apiGroup)
 	bindRecordCrudApi(app, apiGroup)
+	bindRecordExportApi(app, apiGroup)
 	bindRecordAuthApi(app, apiGroup)
 	bindLogsApi(app, apiGroup)
 	bindBackupApi(app, apiGroup)
@@ -51,9 +57,43 @@ func NewRouter(app core.App) (*router.Router[*core.RequestEvent], error) {
 	bindMetricsApi(app, apiGroup)
 	bindImportApi(app, apiGroup)
 
+	bindJWKSApi(app, pbRouter.RouterGroup)
+
 	return pbRouter, nil
 }
 
+// traceRequest starts a new server span for the request event
+// (continuing the trace of the W3C traceparent header, if any)
+// and returns a cleanup function that ends it.
+func traceRequest(tracer *tracing.Tracer, e *core.RequestEvent) router.EventCleanupFunc {
+	name := e.Request.Pattern
+	if name == "" {
+		name = e.Request.Method + " " + e.Request.URL.Path
+	}
+
+	ctx, span := tracer.Start(
+		tracing.Extract(e.Request.Context(), e.Request.Header),
+		name,
+		tracing.WithKind(tracing.SpanKindServer),
+		tracing.WithAttributes(map[string]any{
+			"http.request.method": e.Request.Method,
+			"url.path":            e.Request.URL.Path,
+			"http.route":          e.Request.Pattern,
+		}),
+	)
+
+	e.Request = e.Request.WithContext(ctx)
+
+	return func() {
+		status := e.Status()
+		span.SetAttribute("http.response.status_code", status)
+		if status >= 500 {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+		span.End()
+	}
+}
+
 // WrapStdHandler wraps Go [http.Handler] into a PocketBase handler func.
 func WrapStdHandler(h http.Handler) func(*core.RequestEvent) error {
 	return func(e *core.RequestEvent) error {
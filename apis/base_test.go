@@ -1,6 +1,7 @@
 package apis_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +13,7 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tests"
 	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/tracing"
 )
 
 func TestWrapStdHandler(t *testing.T) {
@@ -311,3 +313,59 @@ func createTestDir(t *testing.T) string {
 
 	return dir
 }
+
+func TestRequestTracing(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracing.NewInMemoryExporter()
+
+	scenario := tests.ApiScenario{
+		Name:   "request span continuing the traceparent trace",
+		Method: http.MethodGet,
+		URL:    "/api/health",
+		Headers: map[string]string{
+			tracing.TraceParentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		ExpectedStatus:  200,
+		ExpectedContent: []string{`"code":200`},
+		TestAppFactory: func(t testing.TB) *tests.TestApp {
+			app, err := tests.NewTestAppWithConfig(core.BaseAppConfig{
+				Tracer: tracing.New(tracing.Config{Exporter: exporter}),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return app
+		},
+		AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+			if err := app.Tracer().Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			spans := exporter.FindByName("GET /api/health")
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 request span, got %d", len(spans))
+			}
+
+			span := spans[0]
+
+			if span.Kind() != tracing.SpanKindServer {
+				t.Fatalf("Expected server span kind, got %d", span.Kind())
+			}
+
+			if span.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Fatalf("Expected the remote trace to be continued, got %s", span.TraceID())
+			}
+
+			if span.ParentSpanID().String() != "00f067aa0ba902b7" {
+				t.Fatalf("Expected the remote parent span, got %s", span.ParentSpanID())
+			}
+
+			if status := span.Attributes()["http.response.status_code"]; status != 200 {
+				t.Fatalf("Expected status code attribute 200, got %v", status)
+			}
+		},
+	}
+
+	scenario.Test(t)
+}
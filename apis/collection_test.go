@@ -47,13 +47,24 @@ func TestCollectionsList(t *testing.T) {
 			ExpectedContent: []string{
 				`"page":1`,
 				`"perPage":30`,
-				`"totalItems":16`,
+				`"totalItems":27`,
 				`"items":[{`,
 				`"name":"` + core.CollectionNameSuperusers + `"`,
 				`"name":"` + core.CollectionNameAuthOrigins + `"`,
 				`"name":"` + core.CollectionNameExternalAuths + `"`,
 				`"name":"` + core.CollectionNameMFAs + `"`,
 				`"name":"` + core.CollectionNameOTPs + `"`,
+				`"name":"` + core.CollectionNameWebAuthnCredentials + `"`,
+				`"name":"` + core.CollectionNameTOTPs + `"`,
+				`"name":"` + core.CollectionNameJWTKeys + `"`,
+				`"name":"` + core.CollectionNameOIDCClients + `"`,
+				`"name":"` + core.CollectionNameOIDCConsents + `"`,
+				`"name":"` + core.CollectionNameOIDCTokens + `"`,
+				`"name":"` + core.CollectionNameAuthSessions + `"`,
+				`"name":"` + core.CollectionNameAPIKeys + `"`,
+				`"name":"` + core.CollectionNameAuthLockouts + `"`,
+				`"name":"` + core.CollectionNamePasswordHistory + `"`,
+				`"name":"` + core.CollectionNameAuthChallenges + `"`,
 				`"name":"users"`,
 				`"name":"nologin"`,
 				`"name":"clients"`,
@@ -85,9 +96,10 @@ func TestCollectionsList(t *testing.T) {
 			ExpectedContent: []string{
 				`"page":2`,
 				`"perPage":2`,
-				`"totalItems":16`,
+				`"totalItems":27`,
 				`"items":[{`,
-				`"name":"` + core.CollectionNameMFAs + `"`,
+				`"name":"` + core.CollectionNameAuthLockouts + `"`,
+				`"name":"` + core.CollectionNameAPIKeys + `"`,
 			},
 			ExpectedEvents: map[string]int{
 				"*":                        0,
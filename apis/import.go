@@ -2,9 +2,12 @@ package apis
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -14,17 +17,34 @@ import (
 
 // bindImportApi registers the data import api endpoints.
 func bindImportApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	rg.GET("/import/rejected/{id}", importRejectedDownload) // relies on superuser file token
+
 	sub := rg.Group("/import").Bind(RequireSuperuserAuth())
 	sub.POST("/preview", importPreview)
 	sub.POST("/validate", importValidate)
 	sub.POST("/execute", importExecute)
+
+	jobs := sub.Group("/jobs")
+	jobs.GET("", importJobList)
+	jobs.POST("", importJobCreate).Unbind(DefaultBodyLimitMiddlewareId) // the body limit is inlined
+	jobs.GET("/{id}", importJobView)
+	jobs.POST("/{id}/cancel", importJobCancel)
+
+	profiles := sub.Group("/profiles/{collection}")
+	profiles.GET("", importProfileList)
+	profiles.POST("", importProfileSave)
+	profiles.GET("/{name}", importProfileView)
+	profiles.DELETE("/{name}", importProfileDelete)
 }
 
 // PreviewRequest represents the request body for preview endpoint.
 type PreviewRequest struct {
 	Data      string `json:"data"`      // Base64 encoded file content or raw text
-	Format    string `json:"format"`    // "csv" or "json"
+	Format    string `json:"format"`    // "csv", "json", "ndjson", "xlsx" or "zip"
 	Delimiter string `json:"delimiter"` // For CSV: "," or "\t" (default: ",")
+	Sheet     string `json:"sheet"`     // For XLSX: sheet name (default: the first sheet)
+	HeaderRow int    `json:"headerRow"` // For XLSX: 1-based header row number (default: 1)
+	DataFile  string `json:"dataFile"`  // For ZIP: bundle data file path (default: the single root data file)
 }
 
 // PreviewResponse represents the preview endpoint response.
@@ -33,13 +53,19 @@ type PreviewResponse struct {
 	SampleRows [][]string        `json:"sampleRows"`
 	TotalRows  int               `json:"totalRows"`
 	Format     string            `json:"format"`
+	Sheets     []string          `json:"sheets,omitempty"` // For XLSX: all workbook sheets
 	Errors     []string          `json:"errors,omitempty"`
 }
 
 // ValidateRequest represents the request body for validation endpoint.
 type ValidateRequest struct {
 	Collection string            `json:"collection"`
-	Mapping    map[string]string `json:"mapping"` // source column -> target field
+	Mapping    map[string]string `json:"mapping"`  // source column -> target field
+	Mode       string            `json:"mode"`     // "insert", "update" or "upsert"
+	KeyField   string            `json:"keyField"` // For "update" and "upsert"
+
+	// Transforms is the optional target field -> value transform map.
+	Transforms map[string]ImportTransform `json:"transforms"`
 }
 
 // ValidateResponse represents the validation endpoint response.
@@ -54,10 +80,36 @@ type ValidateResponse struct {
 type ExecuteRequest struct {
 	Collection string            `json:"collection"`
 	Data       string            `json:"data"`      // Base64 encoded file content or raw text
-	Format     string            `json:"format"`    // "csv" or "json"
+	Format     string            `json:"format"`    // "csv", "json", "ndjson", "xlsx" or "zip"
 	Delimiter  string            `json:"delimiter"` // For CSV
+	Sheet      string            `json:"sheet"`     // For XLSX
+	HeaderRow  int               `json:"headerRow"` // For XLSX
+	DataFile   string            `json:"dataFile"`  // For ZIP
 	Mapping    map[string]string `json:"mapping"`   // source column -> target field
 	SkipHeader bool              `json:"skipHeader"` // Skip first row for CSV (default: true)
+
+	// Profile is the name of a saved collection import profile
+	// whose options are used for the empty request options.
+	Profile string `json:"profile"`
+
+	// Mode is one of "insert" (default), "update" or "upsert".
+	Mode string `json:"mode"`
+
+	// KeyField is the field used to match the existing records ("id" or a field with unique index).
+	KeyField string `json:"keyField"`
+
+	// SkipConflicts reports the rows conflicting with the import mode as skipped instead of failed.
+	SkipConflicts bool `json:"skipConflicts"`
+
+	// Transforms is the optional target field -> value transform map
+	// (eg. relation lookup, split, date, bool or json parsing).
+	Transforms map[string]ImportTransform `json:"transforms"`
+
+	// Atomic imports all rows in a single transaction that is rolled back if any of the rows fails.
+	Atomic bool `json:"atomic"`
+
+	// ValidateOnly runs the full record validation of each row without persisting it.
+	ValidateOnly bool `json:"validateOnly"`
 }
 
 // ExecuteResponse represents the import execution response.
@@ -65,7 +117,23 @@ type ExecuteResponse struct {
 	TotalRows    int            `json:"totalRows"`
 	SuccessCount int            `json:"successCount"`
 	FailureCount int            `json:"failureCount"`
+	CreatedCount int            `json:"createdCount"`
+	UpdatedCount int            `json:"updatedCount"`
+	SkippedCount int            `json:"skippedCount"`
 	Errors       []ImportError  `json:"errors,omitempty"`
+
+	// Rows is the per-row report (available only for the synchronous import execution).
+	Rows []ImportRowReport `json:"rows,omitempty"`
+
+	// RolledBack reports whether the atomic import was rolled back because of failed rows.
+	RolledBack bool `json:"rolledBack,omitempty"`
+
+	// ValidateOnly reports whether the rows were only validated without persisting them.
+	ValidateOnly bool `json:"validateOnly,omitempty"`
+
+	// RejectedId is the id of the failed rows CSV file (if any)
+	// that can be downloaded from /api/import/rejected/{id}.
+	RejectedId string `json:"rejectedId,omitempty"`
 }
 
 // ImportError represents an error for a specific row during import.
@@ -113,8 +181,33 @@ func importPreview(e *core.RequestEvent) error {
 			response.SampleRows = rows
 			response.TotalRows = total
 		}
+	case ImportFormatNDJSON, ImportFormatXLSX, ImportFormatZIP:
+		source, err := newImportDataSource(req.Data, importSourceOptions{
+			Format:    req.Format,
+			Sheet:     req.Sheet,
+			HeaderRow: req.HeaderRow,
+			DataFile:  req.DataFile,
+		})
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+			break
+		}
+		defer closeImportSource(source)
+
+		if s, ok := source.(interface{ Sheets() []string }); ok {
+			response.Sheets = s.Sheets()
+		}
+
+		headers, rows, total, err := parseImportSource(source, 5)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+		} else {
+			response.Headers = headers
+			response.SampleRows = rows
+			response.TotalRows = total
+		}
 	default:
-		return e.BadRequestError("Unsupported format. Use 'csv' or 'json'", nil)
+		return e.BadRequestError("Unsupported format. Use 'csv', 'json', 'ndjson', 'xlsx' or 'zip'", nil)
 	}
 
 	return e.JSON(http.StatusOK, response)
@@ -150,6 +243,18 @@ func importValidate(e *core.RequestEvent) error {
 		response.FieldTypes[field.GetName()] = field.Type()
 	}
 
+	// Validate import mode
+	if _, _, err := normalizeImportMode(collection, req.Mode, req.KeyField, req.Mapping); err != nil {
+		response.Valid = false
+		response.Errors = append(response.Errors, err.Error())
+	}
+
+	// Validate field transforms
+	if err := validateImportTransforms(e.App, collection, req.Transforms); err != nil {
+		response.Valid = false
+		response.Errors = append(response.Errors, "Invalid field transforms: "+err.Error())
+	}
+
 	// Validate mapping
 	if len(req.Mapping) > 0 {
 		for sourceCol, targetField := range req.Mapping {
@@ -184,89 +289,84 @@ func importExecute(e *core.RequestEvent) error {
 		return e.BadRequestError("Data is required", nil)
 	}
 
+	if req.Profile != "" {
+		if err := req.applyProfile(e.App); err != nil {
+			return e.BadRequestError("Failed to load the import profile: "+err.Error(), err)
+		}
+	}
+
 	if len(req.Mapping) == 0 {
 		return e.BadRequestError("Mapping is required", nil)
 	}
 
+	if req.Format == "" {
+		req.Format = detectFormat(req.Data)
+	}
+
 	// Find the collection
 	collection, err := e.App.FindCollectionByNameOrId(req.Collection)
 	if err != nil {
 		return e.BadRequestError("Collection not found: "+req.Collection, err)
 	}
 
+	mode, keyField, err := normalizeImportMode(collection, req.Mode, req.KeyField, req.Mapping)
+	if err != nil {
+		return e.BadRequestError("Invalid import mode: "+err.Error(), err)
+	}
+
+	if err := validateImportTransforms(e.App, collection, req.Transforms); err != nil {
+		return e.BadRequestError("Invalid field transforms.", err)
+	}
+
 	// Parse the data
-	var headers []string
-	var allRows [][]string
+	var source importSource
 
 	switch strings.ToLower(req.Format) {
-	case "csv":
-		h, rows, _, err := parseCSV(req.Data, req.Delimiter, -1) // -1 means all rows
+	case ImportFormatCSV:
+		headers, rows, _, err := parseCSV(req.Data, req.Delimiter, -1) // -1 means all rows
 		if err != nil {
 			return e.BadRequestError("Failed to parse CSV: "+err.Error(), err)
 		}
-		headers = h
-		allRows = rows
-	case "json":
-		h, rows, _, err := parseJSON(req.Data, -1) // -1 means all rows
+		source = newRowsImportSource(headers, rows)
+	case ImportFormatJSON:
+		headers, rows, _, err := parseJSON(req.Data, -1) // -1 means all rows
 		if err != nil {
 			return e.BadRequestError("Failed to parse JSON: "+err.Error(), err)
 		}
-		headers = h
-		allRows = rows
+		source = newRowsImportSource(headers, rows)
+	case ImportFormatNDJSON, ImportFormatXLSX, ImportFormatZIP:
+		source, err = newImportDataSource(req.Data, importSourceOptions{
+			Format:    req.Format,
+			Sheet:     req.Sheet,
+			HeaderRow: req.HeaderRow,
+			DataFile:  req.DataFile,
+		})
+		if err != nil {
+			return e.BadRequestError("Failed to parse the data: "+err.Error(), err)
+		}
+		defer closeImportSource(source)
 	default:
-		return e.BadRequestError("Unsupported format. Use 'csv' or 'json'", nil)
+		return e.BadRequestError("Unsupported format. Use 'csv', 'json', 'ndjson', 'xlsx' or 'zip'", nil)
 	}
 
-	// Create header index map
-	headerIndex := make(map[string]int)
-	for i, h := range headers {
-		headerIndex[h] = i
-	}
+	var response ExecuteResponse
 
-	response := ExecuteResponse{
-		TotalRows: len(allRows),
+	runner := &importRunner{
+		app:           e.App,
+		collection:    collection,
+		mapping:       req.Mapping,
+		transforms:    req.Transforms,
+		mode:          mode,
+		keyField:      keyField,
+		skipConflicts: req.SkipConflicts,
+		atomic:        req.Atomic,
+		validateOnly:  req.ValidateOnly,
+		report:        true,
+		rejected:      true,
 	}
 
-	// Process each row
-	for rowNum, row := range allRows {
-		// Create record data
-		recordData := make(map[string]any)
-		
-		for sourceCol, targetField := range req.Mapping {
-			if targetField == "" || targetField == "-" {
-				continue
-			}
-
-			idx, exists := headerIndex[sourceCol]
-			if !exists {
-				continue
-			}
-
-			if idx < len(row) {
-				value := strings.TrimSpace(row[idx])
-				if value != "" {
-					recordData[targetField] = value
-				}
-			}
-		}
-
-		// Create the record
-		record := core.NewRecord(collection)
-		for key, value := range recordData {
-			record.Set(key, value)
-		}
-
-		// Save the record
-		if err := e.App.Save(record); err != nil {
-			response.FailureCount++
-			response.Errors = append(response.Errors, ImportError{
-				Row:     rowNum + 1, // 1-indexed for user display
-				Message: err.Error(),
-				Data:    recordData,
-			})
-		} else {
-			response.SuccessCount++
-		}
+	if err := runner.run(e.Request.Context(), source, &response); err != nil {
+		return e.BadRequestError("Failed to import the data", err)
 	}
 
 	return e.JSON(http.StatusOK, response)
@@ -374,11 +474,127 @@ func parseJSON(data string, sampleSize int) ([]string, [][]string, int, error) {
 
 // detectFormat tries to detect the file format from content.
 func detectFormat(data string) string {
+	// zip archive (raw or base64 encoded)
+	switch {
+	case strings.HasPrefix(data, "PK\x03\x04"):
+		return detectZIPFormat([]byte(data))
+	case strings.HasPrefix(data, "data:application/vnd.openxmlformats-officedocument.spreadsheetml"):
+		return ImportFormatXLSX
+	case strings.HasPrefix(data, "UEsDB"), strings.HasPrefix(data, "data:application/zip"):
+		return detectZIPFormat(decodeBase64Head(data, importDetectSize))
+	}
+
 	trimmed := strings.TrimSpace(data)
-	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
-		return "json"
+	if strings.HasPrefix(trimmed, "[") {
+		return ImportFormatJSON
+	}
+	if strings.HasPrefix(trimmed, "{") {
+		// NDJSON only if the first line is a complete object
+		// (eg. to allow pretty printed JSON objects)
+		firstLine, _, _ := strings.Cut(trimmed, "\n")
+		if json.Valid([]byte(firstLine)) {
+			return ImportFormatNDJSON
+		}
+		return ImportFormatJSON
+	}
+	return ImportFormatCSV
+}
+
+// decodeBase64Head decodes up to the first n characters
+// of a base64 (optionally data URL) encoded string.
+//
+// Invalid characters stop the decoding and the already decoded bytes are returned.
+func decodeBase64Head(data string, n int) []byte {
+	if i := strings.Index(data, ";base64,"); i >= 0 && strings.HasPrefix(data, "data:") {
+		data = data[i+len(";base64,"):]
+	}
+
+	if len(data) > n {
+		data = data[:n-n%4]
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(data)
+
+	return raw
+}
+
+// newImportDataSource creates a new importSource from the request data string.
+//
+// XLSX and ZIP data is expected to be base64 encoded (optionally as data URL).
+func newImportDataSource(data string, options importSourceOptions) (importSource, error) {
+	if strings.EqualFold(options.Format, ImportFormatXLSX) || strings.EqualFold(options.Format, ImportFormatZIP) {
+		if i := strings.Index(data, ";base64,"); i >= 0 && strings.HasPrefix(data, "data:") {
+			data = data[i+len(";base64,"):]
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s data must be base64 encoded: %w", strings.ToUpper(options.Format), err)
+		}
+
+		return newImportSource(bytes.NewReader(raw), int64(len(raw)), options)
 	}
-	return "csv"
+
+	return newImportSource(strings.NewReader(data), int64(len(data)), options)
+}
+
+// parseImportSource reads all source rows and returns the headers,
+// up to sampleSize sample rows (or all if -1) and the total rows count.
+//
+// If the source doesn't have predefined headers, all unique row keys are used (sorted alphabetically).
+func parseImportSource(source importSource, sampleSize int) ([]string, [][]string, int, error) {
+	var headers []string
+	if s, ok := source.(interface{ Headers() []string }); ok {
+		headers = s.Headers()
+	}
+
+	headerSet := map[string]bool{}
+	var sample []map[string]string
+	var total int
+
+	for {
+		row, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		total++
+
+		if sampleSize == -1 || len(sample) < sampleSize {
+			sample = append(sample, row)
+		}
+
+		if headers == nil {
+			for key := range row {
+				headerSet[key] = true
+			}
+		}
+	}
+
+	if headers == nil {
+		if total == 0 {
+			return nil, nil, 0, errors.New("no data rows found")
+		}
+
+		headers = make([]string, 0, len(headerSet))
+		for key := range headerSet {
+			headers = append(headers, key)
+		}
+		sortStrings(headers)
+	}
+
+	rows := make([][]string, len(sample))
+	for i, row := range sample {
+		rows[i] = make([]string, len(headers))
+		for j, h := range headers {
+			rows[i][j] = row[h]
+		}
+	}
+
+	return headers, rows, total, nil
 }
 
 // valueToString converts any value to string for display.
@@ -0,0 +1,366 @@
+package apis
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+// ImportBundleAssetsDir is the ZIP bundle directory with the files
+// that could be referenced by the data file cells.
+const ImportBundleAssetsDir = "assets"
+
+// importFileDownloadTimeout is the max duration of a single file url download.
+const importFileDownloadTimeout = 1 * time.Minute
+
+// importAssets defines an importSource with bundled asset files.
+type importAssets interface {
+	// OpenAsset loads the bundled file with the specified relative path.
+	OpenAsset(name string, maxSize int64) (*filesystem.File, error)
+}
+
+// resolveImportFiles replaces in place the recordData file field
+// references with their downloaded or bundled files.
+//
+// A reference could be either an HTTP(S) url or a relative asset path
+// (if assets is not nil). Multiple references could be specified with the split transform.
+//
+// It returns [validation.Errors] with the fields that couldn't be resolved.
+func resolveImportFiles(ctx context.Context, collection *core.Collection, recordData map[string]any, assets importAssets) error {
+	errs := validation.Errors{}
+
+	for fieldName, value := range recordData {
+		field, ok := collection.Fields.GetByName(fieldName).(*core.FileField)
+		if !ok {
+			continue
+		}
+
+		var refs []string
+		switch v := value.(type) {
+		case string:
+			refs = []string{v}
+		case []string:
+			refs = v
+		default:
+			continue // already resolved or unsupported
+		}
+
+		refs = slices.DeleteFunc(slices.Clone(refs), func(ref string) bool {
+			return strings.TrimSpace(ref) == ""
+		})
+		if len(refs) == 0 {
+			continue // leave the empty value as it is
+		}
+
+		files := make([]*filesystem.File, 0, len(refs))
+		for _, ref := range refs {
+			file, err := resolveImportFile(ctx, field, strings.TrimSpace(ref), assets)
+			if err != nil {
+				errs[fieldName] = err
+				break
+			}
+			files = append(files, file)
+		}
+
+		if errs[fieldName] != nil {
+			continue
+		}
+
+		if field.IsMultiple() {
+			recordData[fieldName] = files
+		} else if len(files) > 1 {
+			errs[fieldName] = errors.New("multiple files for a single file field")
+		} else {
+			recordData[fieldName] = files[0]
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// storeImportFiles replaces the in memory content of the files loaded
+// with [resolveImportFiles] with a file in dir (if not empty).
+func storeImportFiles(dir string, files map[string]any) error {
+	if dir == "" {
+		return nil
+	}
+
+	for _, value := range files {
+		switch v := value.(type) {
+		case *filesystem.File:
+			if err := storeImportFile(dir, v); err != nil {
+				return err
+			}
+		case []*filesystem.File:
+			for _, f := range v {
+				if err := storeImportFile(dir, f); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func storeImportFile(dir string, file *filesystem.File) error {
+	src, err := file.Reader.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp(dir, "file_*")
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	file.Reader = &filesystem.PathReader{Path: dst.Name()}
+
+	return nil
+}
+
+func resolveImportFile(ctx context.Context, field *core.FileField, ref string, assets importAssets) (*filesystem.File, error) {
+	maxSize := field.MaxSize
+	if maxSize <= 0 {
+		maxSize = core.DefaultFileFieldMaxSize
+	}
+
+	lowerRef := strings.ToLower(ref)
+	if strings.HasPrefix(lowerRef, "https://") || strings.HasPrefix(lowerRef, "http://") {
+		ctx, cancel := context.WithTimeout(ctx, importFileDownloadTimeout)
+		defer cancel()
+
+		return filesystem.NewFileFromURLWithOptions(ctx, ref, filesystem.URLFileOptions{
+			MaxSize:   maxSize,
+			MimeTypes: field.MimeTypes,
+		})
+	}
+
+	if assets == nil {
+		return nil, fmt.Errorf("%q must be an HTTP(S) url or a ZIP bundle asset path", ref)
+	}
+
+	return assets.OpenAsset(ref, maxSize)
+}
+
+// -------------------------------------------------------------------
+
+// bundleImportSource is an importSource for ZIP bundles with a
+// single data file and an optional assets directory.
+type bundleImportSource struct {
+	importSource
+
+	dataFile io.Closer
+	files    map[string]*zip.File
+}
+
+func newBundleImportSource(r io.ReaderAt, size int64, options importSourceOptions) (*bundleImportSource, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ZIP bundle: %w", err)
+	}
+
+	bundle := &bundleImportSource{files: make(map[string]*zip.File, len(zr.File))}
+
+	var dataFiles []*zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(f.Name, "/"))
+		bundle.files[name] = f
+
+		if options.DataFile != "" {
+			if name == path.Clean(options.DataFile) {
+				dataFiles = append(dataFiles, f)
+			}
+			continue
+		}
+
+		// data file candidates are only the supported root files
+		if !strings.Contains(name, "/") && !strings.HasPrefix(name, ".") && importFormatFromFilename(name) != "" {
+			dataFiles = append(dataFiles, f)
+		}
+	}
+
+	switch {
+	case len(dataFiles) == 0 && options.DataFile != "":
+		return nil, fmt.Errorf("missing ZIP bundle data file %q", options.DataFile)
+	case len(dataFiles) == 0:
+		return nil, errors.New("missing ZIP bundle data file (csv, json, ndjson or xlsx in the archive root)")
+	case len(dataFiles) > 1:
+		return nil, errors.New("multiple ZIP bundle data files found, specify the one to import with the dataFile option")
+	}
+
+	dataFile := dataFiles[0]
+
+	dataOptions := options
+	dataOptions.Format = importFormatFromFilename(dataFile.Name)
+	if dataOptions.Format == "" {
+		dataOptions.Format = options.Format
+	}
+	if dataOptions.Delimiter == "" {
+		dataOptions.Delimiter = importDelimiterFromFilename(dataFile.Name)
+	}
+
+	if dataOptions.Format == ImportFormatZIP {
+		return nil, errors.New("nested ZIP bundles are not supported")
+	}
+
+	rc, err := dataFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the ZIP bundle data file: %w", err)
+	}
+
+	bundle.importSource, err = newImportSource(rc, int64(dataFile.UncompressedSize64), dataOptions)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	bundle.dataFile = rc
+
+	return bundle, nil
+}
+
+// Headers returns the data file header columns (if any).
+func (s *bundleImportSource) Headers() []string {
+	if h, ok := s.importSource.(interface{ Headers() []string }); ok {
+		return h.Headers()
+	}
+	return nil
+}
+
+// OpenAsset implements [importAssets.OpenAsset] interface method.
+//
+// The name is resolved relative to the bundle assets directory and then to the bundle root.
+func (s *bundleImportSource) OpenAsset(name string, maxSize int64) (*filesystem.File, error) {
+	clean := path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))[1:]
+	if clean == "" {
+		return nil, errors.New("empty ZIP bundle asset path")
+	}
+
+	f, ok := s.files[path.Join(ImportBundleAssetsDir, clean)]
+	if !ok {
+		f, ok = s.files[clean]
+	}
+	if !ok {
+		return nil, fmt.Errorf("missing ZIP bundle asset %q", name)
+	}
+
+	if maxSize > 0 && f.UncompressedSize64 > uint64(maxSize) {
+		return nil, fmt.Errorf("the ZIP bundle asset %q size exceeds the %d bytes limit", name, maxSize)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var buf bytes.Buffer
+
+	var reader io.Reader = rc
+	if maxSize > 0 {
+		reader = io.LimitReader(rc, maxSize+1)
+	}
+
+	if _, err := io.Copy(&buf, reader); err != nil {
+		return nil, err
+	}
+
+	if maxSize > 0 && int64(buf.Len()) > maxSize {
+		return nil, fmt.Errorf("the ZIP bundle asset %q size exceeds the %d bytes limit", name, maxSize)
+	}
+
+	return filesystem.NewFileFromBytes(buf.Bytes(), path.Base(clean))
+}
+
+// Close implements [io.Closer] interface method.
+func (s *bundleImportSource) Close() error {
+	err := closeImportSource(s.importSource)
+
+	if closeErr := s.dataFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// isXLSXArchive reports whether the zip archive is an XLSX workbook (and not a bundle).
+func isXLSXArchive(r io.ReaderAt, size int64) bool {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return true // let the xlsx reader report the error
+	}
+
+	for _, f := range zr.File {
+		if strings.EqualFold(f.Name, "xl/workbook.xml") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectZIPFormat reports whether the head (aka. the first bytes) of a zip
+// archive is of an XLSX workbook or of a ZIP bundle.
+//
+// Only the entries whose local file headers are within head are checked
+// and an archive without a known workbook entry among them is considered a bundle.
+func detectZIPFormat(head []byte) string {
+	for len(head) >= 30 && bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		nameLen := int(binary.LittleEndian.Uint16(head[26:]))
+		if len(head) < 30+nameLen {
+			break
+		}
+
+		name := string(head[30 : 30+nameLen])
+		if name == "[Content_Types].xml" || strings.HasPrefix(name, "xl/") {
+			return ImportFormatXLSX
+		}
+
+		// the entry sizes are stored after its data
+		flags := binary.LittleEndian.Uint16(head[6:])
+		if flags&0x8 != 0 {
+			break
+		}
+
+		extraLen := int(binary.LittleEndian.Uint16(head[28:]))
+		dataLen := int64(binary.LittleEndian.Uint32(head[18:]))
+
+		next := int64(30+nameLen+extraLen) + dataLen
+		if next > int64(len(head)) {
+			break
+		}
+		head = head[next:]
+	}
+
+	return ImportFormatZIP
+}
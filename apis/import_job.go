@@ -0,0 +1,665 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/store"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	importJobsStoreKey      = "__pbImportJobs__"
+	importJobsTerminateHook = "__pbImportJobsTerminate__"
+)
+
+// ImportJobTopicPrefix is the realtime topic prefix of the import job progress messages
+// (eg. "@import/JOB_ID"). Only superusers can receive them.
+const ImportJobTopicPrefix = "@import/"
+
+// DefaultImportBatchSize is the default number of rows committed in a single import job transaction.
+const DefaultImportBatchSize = 500
+
+// DefaultMaxImportFileSize is the default max size of a single uploaded
+// import job file (see [core.ImportConfig.MaxFileSize]).
+const DefaultMaxImportFileSize int64 = 1 << 30
+
+// maxImportJobErrors limits the number of row errors stored per import job.
+const maxImportJobErrors = 100
+
+// finishedImportJobsTTL is the duration after which finished jobs are removed from the registry.
+const finishedImportJobsTTL = 24 * time.Hour
+
+// Import job statuses.
+const (
+	ImportJobStatusRunning   = "running"
+	ImportJobStatusCompleted = "completed"
+	ImportJobStatusFailed    = "failed"
+	ImportJobStatusCanceled  = "canceled"
+)
+
+// ImportJobOptions defines the options of a single background import job.
+type ImportJobOptions struct {
+	Collection string            `json:"collection"`
+	Format     string            `json:"format"`    // "csv", "json", "ndjson", "xlsx" or "zip" (auto detected if empty)
+	Delimiter  string            `json:"delimiter"` // For CSV
+	Sheet      string            `json:"sheet"`     // For XLSX
+	HeaderRow  int               `json:"headerRow"` // For XLSX
+	DataFile   string            `json:"dataFile"`  // For ZIP
+	Mapping    map[string]string `json:"mapping"`   // source column -> target field
+	BatchSize  int               `json:"batchSize"` // Rows per transaction (default: 500)
+
+	// Profile is the name of a saved collection import profile
+	// whose options are used for the empty job options.
+	Profile string `json:"profile"`
+
+	// The options below have the same meaning as in [ExecuteRequest].
+	Mode          string                     `json:"mode"`
+	KeyField      string                     `json:"keyField"`
+	SkipConflicts bool                       `json:"skipConflicts"`
+	Transforms    map[string]ImportTransform `json:"transforms"`
+	Atomic        bool                       `json:"atomic"`
+	ValidateOnly  bool                       `json:"validateOnly"`
+}
+
+func (options ImportJobOptions) importSourceOptions() importSourceOptions {
+	return importSourceOptions{
+		Format:    options.Format,
+		Delimiter: options.Delimiter,
+		Sheet:     options.Sheet,
+		HeaderRow: options.HeaderRow,
+		DataFile:  options.DataFile,
+	}
+}
+
+// ImportJob represents the state of a single background import job.
+type ImportJob struct {
+	ExecuteResponse
+
+	Id         string         `json:"id"`
+	Collection string         `json:"collection"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	TotalBytes int64          `json:"totalBytes"`
+	ReadBytes  int64          `json:"readBytes"`
+	Progress   float64        `json:"progress"` // Read bytes percentage
+	Created    types.DateTime `json:"created"`
+	Updated    types.DateTime `json:"updated"`
+}
+
+// IsFinished reports whether the job is no longer running.
+func (job ImportJob) IsFinished() bool {
+	return job.Status != ImportJobStatusRunning
+}
+
+type importJobEntry struct {
+	cancel    context.CancelFunc
+	done      chan struct{}
+	job       ImportJob
+	readBytes atomic.Int64
+	mu        sync.RWMutex
+}
+
+func (entry *importJobEntry) snapshot() ImportJob {
+	entry.mu.RLock()
+	defer entry.mu.RUnlock()
+
+	job := entry.job
+	job.Errors = slices.Clone(job.Errors)
+
+	job.ReadBytes = entry.readBytes.Load()
+	if job.Status == ImportJobStatusCompleted ||
+		// random access formats (eg. XLSX) could read some parts more than once
+		(job.TotalBytes > 0 && job.ReadBytes > job.TotalBytes) {
+		job.ReadBytes = job.TotalBytes
+	}
+	if job.TotalBytes > 0 {
+		job.Progress = float64(job.ReadBytes) / float64(job.TotalBytes) * 100
+	}
+
+	return job
+}
+
+func (entry *importJobEntry) update(fn func(job *ImportJob)) ImportJob {
+	entry.mu.Lock()
+	fn(&entry.job)
+	entry.job.Updated = types.NowDateTime()
+	entry.mu.Unlock()
+
+	return entry.snapshot()
+}
+
+// importJobCountingReader is an [io.Reader] that tracks the number of read bytes.
+type importJobCountingReader struct {
+	reader io.Reader
+	total  *atomic.Int64
+}
+
+func (r *importJobCountingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.total.Add(int64(n))
+	return n, err
+}
+
+// importJobCountingReaderAt is an [io.ReaderAt] that tracks the number of read bytes
+// (eg. for random access formats like XLSX).
+type importJobCountingReaderAt struct {
+	*importJobCountingReader
+	readerAt io.ReaderAt
+}
+
+func (r *importJobCountingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.readerAt.ReadAt(p, off)
+	r.total.Add(int64(n))
+	return n, err
+}
+
+// importTempFile is an upload temp file that is deleted on Close.
+type importTempFile struct {
+	*os.File
+}
+
+func (f importTempFile) Close() error {
+	err := f.File.Close()
+	_ = os.Remove(f.Name())
+	return err
+}
+
+// StartImportJob starts a new background job that imports the data from
+// the source reader into options.Collection.
+//
+// size is the total source size in bytes and it is used only for the progress report.
+// The source is closed once the job finishes.
+//
+// The job progress is available through [FindImportJob] and the
+// ImportJobTopicPrefix+jobId realtime topic.
+func StartImportJob(app core.App, source io.ReadCloser, size int64, options ImportJobOptions) (ImportJob, error) {
+	collection, err := app.FindCollectionByNameOrId(options.Collection)
+	if err != nil {
+		source.Close()
+		return ImportJob{}, errors.New("collection not found: " + options.Collection)
+	}
+
+	if options.Profile != "" {
+		profile, err := FindImportProfile(app, collection, options.Profile)
+		if err != nil {
+			source.Close()
+			return ImportJob{}, fmt.Errorf("failed to load import profile %q: %w", options.Profile, err)
+		}
+		profile.applyTo(&options)
+	}
+
+	if len(options.Mapping) == 0 {
+		source.Close()
+		return ImportJob{}, errors.New("mapping is required")
+	}
+
+	mode, keyField, err := normalizeImportMode(collection, options.Mode, options.KeyField, options.Mapping)
+	if err != nil {
+		source.Close()
+		return ImportJob{}, err
+	}
+
+	if err := validateImportTransforms(app, collection, options.Transforms); err != nil {
+		source.Close()
+		return ImportJob{}, fmt.Errorf("invalid field transforms: %w", err)
+	}
+
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultImportBatchSize
+	}
+
+	jobs := importJobs(app)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	now := types.NowDateTime()
+
+	entry := &importJobEntry{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		job: ImportJob{
+			Id:         security.RandomString(15),
+			Collection: collection.Name,
+			Status:     ImportJobStatusRunning,
+			TotalBytes: size,
+			Created:    now,
+			Updated:    now,
+		},
+	}
+
+	jobs.Set(entry.job.Id, entry)
+
+	routine.FireAndForget(func() {
+		defer close(entry.done)
+		defer cancel()
+		defer source.Close()
+
+		runner := &importRunner{
+			app:           app,
+			collection:    collection,
+			mapping:       options.Mapping,
+			transforms:    options.Transforms,
+			mode:          mode,
+			keyField:      keyField,
+			skipConflicts: options.SkipConflicts,
+			atomic:        options.Atomic,
+			validateOnly:  options.ValidateOnly,
+			rejected:      true,
+			batchSize:     options.BatchSize,
+			maxErrors:     maxImportJobErrors,
+			onBatch: func(result ExecuteResponse) {
+				job := entry.update(func(job *ImportJob) {
+					job.ExecuteResponse = result
+				})
+				broadcastImportJob(app, job)
+			},
+		}
+
+		var result ExecuteResponse
+
+		var reader io.Reader = &importJobCountingReader{reader: source, total: &entry.readBytes}
+		if ra, ok := source.(io.ReaderAt); ok {
+			reader = &importJobCountingReaderAt{importJobCountingReader: reader.(*importJobCountingReader), readerAt: ra}
+		}
+
+		var runErr error
+		if src, err := newImportSource(reader, size, options.importSourceOptions()); err != nil {
+			runErr = err
+		} else {
+			runErr = runner.run(ctx, src, &result)
+			closeImportSource(src)
+		}
+
+		job := entry.update(func(job *ImportJob) {
+			job.ExecuteResponse = result
+
+			switch {
+			case runErr == nil:
+				job.Status = ImportJobStatusCompleted
+			case errors.Is(runErr, context.Canceled):
+				job.Status = ImportJobStatusCanceled
+			default:
+				job.Status = ImportJobStatusFailed
+				job.Error = runErr.Error()
+			}
+		})
+
+		if job.Status == ImportJobStatusFailed {
+			app.Logger().Warn(
+				"Import job failed",
+				slog.String("id", job.Id),
+				slog.String("collection", job.Collection),
+				slog.String("error", job.Error),
+			)
+		}
+
+		broadcastImportJob(app, job)
+	})
+
+	return entry.snapshot(), nil
+}
+
+// FindImportJob returns the state of a single import job.
+func FindImportJob(app core.App, id string) (ImportJob, bool) {
+	entry, ok := importJobs(app).GetOk(id)
+	if !ok {
+		return ImportJob{}, false
+	}
+
+	return entry.snapshot(), true
+}
+
+// WaitImportJob blocks until the specified import job finishes or ctx is done.
+func WaitImportJob(ctx context.Context, app core.App, id string) (ImportJob, error) {
+	entry, ok := importJobs(app).GetOk(id)
+	if !ok {
+		return ImportJob{}, errors.New("missing import job " + id)
+	}
+
+	select {
+	case <-entry.done:
+		return entry.snapshot(), nil
+	case <-ctx.Done():
+		return entry.snapshot(), ctx.Err()
+	}
+}
+
+// CancelImportJob stops the specified running import job.
+//
+// The rows of the already committed batches are not reverted.
+func CancelImportJob(app core.App, id string) (ImportJob, error) {
+	entry, ok := importJobs(app).GetOk(id)
+	if !ok {
+		return ImportJob{}, errors.New("missing import job " + id)
+	}
+
+	entry.cancel()
+
+	<-entry.done
+
+	return entry.snapshot(), nil
+}
+
+// importJobs returns the app import jobs registry (initializing it if missing).
+func importJobs(app core.App) *store.Store[string, *importJobEntry] {
+	return app.Store().GetOrSet(importJobsStoreKey, func() any {
+		jobs := store.New[string, *importJobEntry](nil)
+
+		app.OnTerminate().Bind(&hook.Handler[*core.TerminateEvent]{
+			Id: importJobsTerminateHook,
+			Func: func(e *core.TerminateEvent) error {
+				for _, entry := range jobs.GetAll() {
+					entry.cancel()
+				}
+
+				return e.Next()
+			},
+		})
+
+		return jobs
+	}).(*store.Store[string, *importJobEntry])
+}
+
+// cleanupImportJobs removes the finished jobs older than [finishedImportJobsTTL].
+func cleanupImportJobs(app core.App) {
+	jobs := importJobs(app)
+
+	threshold := time.Now().Add(-finishedImportJobsTTL)
+
+	for id, entry := range jobs.GetAll() {
+		job := entry.snapshot()
+		if job.IsFinished() && job.Updated.Time().Before(threshold) {
+			jobs.Remove(id)
+		}
+	}
+}
+
+// broadcastImportJob sends the job state to the superuser realtime
+// clients subscribed to the job topic.
+func broadcastImportJob(app core.App, job ImportJob) {
+	topic := ImportJobTopicPrefix + job.Id
+
+	var rawJob []byte
+
+	for _, chunk := range app.SubscriptionsBroker().ChunkedClients(clientsChunkSize) {
+		for _, client := range chunk {
+			if !client.HasSubscription(topic) {
+				continue
+			}
+
+			clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
+			if clientAuth == nil || !clientAuth.IsSuperuser() {
+				continue
+			}
+
+			if rawJob == nil {
+				var err error
+				rawJob, err = json.Marshal(job)
+				if err != nil {
+					app.Logger().Warn("Failed to serialize import job", slog.String("id", job.Id), slog.String("error", err.Error()))
+					return
+				}
+			}
+
+			msg := subscriptions.Message{
+				Name: topic,
+				Data: rawJob,
+			}
+
+			client := client
+
+			routine.FireAndForget(func() {
+				client.Send(msg)
+			})
+		}
+	}
+}
+
+// -------------------------------------------------------------------
+
+// importJobCreate streams the uploaded multipart file into a temp file
+// and starts a new background import job for it.
+//
+// Expects the multipart fields "file", "collection", "mapping" (JSON object)
+// or "profile", and optionally "format", "delimiter" and "batchSize".
+//
+// POST /api/import/jobs
+func importJobCreate(e *core.RequestEvent) error {
+	cleanupImportJobs(e.App)
+
+	maxFileSize := e.App.Settings().Import.MaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultMaxImportFileSize
+	}
+
+	// note: the limit is for the entire multipart body
+	// (the other form fields are small and limited to 1MB each)
+	err := applyBodyLimit(e, maxFileSize)
+	if err != nil {
+		return err
+	}
+
+	reader, err := e.Request.MultipartReader()
+	if err != nil {
+		return e.BadRequestError("Invalid multipart/form-data request", err)
+	}
+
+	tempDir := filepath.Join(e.App.DataDir(), core.LocalTempDirName)
+	if err := os.MkdirAll(tempDir, os.ModePerm); err != nil {
+		return e.InternalServerError("Failed to create temp import dir", err)
+	}
+
+	var options ImportJobOptions
+	var file *os.File
+	var size int64
+	var filename string
+
+	defer func() {
+		// not handed to the job (eg. on validation error)
+		if file != nil {
+			importTempFile{file}.Close()
+		}
+	}()
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return firstApiError(err, e.BadRequestError("Failed to read multipart data", err))
+		}
+
+		name := part.FormName()
+
+		if name == "file" {
+			if file != nil {
+				part.Close()
+				return e.BadRequestError("Only a single file can be imported", nil)
+			}
+
+			file, err = os.CreateTemp(tempDir, "import_*")
+			if err != nil {
+				part.Close()
+				return e.InternalServerError("Failed to create temp import file", err)
+			}
+
+			size, err = io.Copy(file, part)
+			part.Close()
+			if err != nil {
+				return firstApiError(err, e.BadRequestError("Failed to read the uploaded file", err))
+			}
+
+			filename = part.FileName()
+
+			if options.Format == "" {
+				options.Format = importFormatFromFilename(filename)
+			}
+
+			continue
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, 1<<20))
+		part.Close()
+		if err != nil {
+			return firstApiError(err, e.BadRequestError("Failed to read multipart data", err))
+		}
+
+		switch name {
+		case "collection":
+			options.Collection = string(value)
+		case "format":
+			options.Format = string(value)
+		case "delimiter":
+			options.Delimiter = string(value)
+		case "sheet":
+			options.Sheet = string(value)
+		case "headerRow":
+			options.HeaderRow, _ = strconv.Atoi(string(value))
+		case "dataFile":
+			options.DataFile = string(value)
+		case "batchSize":
+			options.BatchSize, _ = strconv.Atoi(string(value))
+		case "profile":
+			options.Profile = string(value)
+		case "mode":
+			options.Mode = string(value)
+		case "keyField":
+			options.KeyField = string(value)
+		case "skipConflicts":
+			options.SkipConflicts, _ = strconv.ParseBool(string(value))
+		case "atomic":
+			options.Atomic, _ = strconv.ParseBool(string(value))
+		case "validateOnly":
+			options.ValidateOnly, _ = strconv.ParseBool(string(value))
+		case "transforms":
+			if err := json.Unmarshal(value, &options.Transforms); err != nil {
+				return e.BadRequestError("Invalid transforms JSON", err)
+			}
+		case "mapping":
+			if err := json.Unmarshal(value, &options.Mapping); err != nil {
+				return e.BadRequestError("Invalid mapping JSON", err)
+			}
+		}
+	}
+
+	if file == nil {
+		return e.BadRequestError("File is required", nil)
+	}
+
+	if options.Delimiter == "" {
+		options.Delimiter = importDelimiterFromFilename(filename)
+	}
+
+	if options.Collection == "" {
+		return e.BadRequestError("Collection is required", nil)
+	}
+
+	if len(options.Mapping) == 0 && options.Profile == "" {
+		return e.BadRequestError("Mapping is required", nil)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return e.InternalServerError("Failed to read the uploaded file", err)
+	}
+
+	source := importTempFile{file}
+	file = nil // the job takes the temp file ownership
+
+	job, err := StartImportJob(e.App, source, size, options)
+	if err != nil {
+		return e.BadRequestError(err.Error(), err)
+	}
+
+	return e.JSON(http.StatusOK, job)
+}
+
+// importJobList returns all import jobs sorted by their creation date (newest first).
+// GET /api/import/jobs
+func importJobList(e *core.RequestEvent) error {
+	entries := importJobs(e.App).Values()
+
+	result := make([]ImportJob, 0, len(entries))
+	for _, entry := range entries {
+		job := entry.snapshot()
+		job.Errors = nil // omit the errors to keep the list response small
+		result = append(result, job)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created.Time().After(result[j].Created.Time())
+	})
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// importJobView returns the state of a single import job.
+// GET /api/import/jobs/{id}
+func importJobView(e *core.RequestEvent) error {
+	job, ok := FindImportJob(e.App, e.Request.PathValue("id"))
+	if !ok {
+		return e.NotFoundError("", nil)
+	}
+
+	return e.JSON(http.StatusOK, job)
+}
+
+// importJobCancel stops a running import job.
+// POST /api/import/jobs/{id}/cancel
+func importJobCancel(e *core.RequestEvent) error {
+	job, err := CancelImportJob(e.App, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	return e.JSON(http.StatusOK, job)
+}
+
+// importFormatFromFilename returns the import format based on the file extension
+// (or empty string if unknown).
+func importFormatFromFilename(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".tsv", ".txt":
+		return ImportFormatCSV
+	case ".json":
+		return ImportFormatJSON
+	case ".ndjson", ".jsonl":
+		return ImportFormatNDJSON
+	case ".xlsx":
+		return ImportFormatXLSX
+	case ".zip":
+		return ImportFormatZIP
+	default:
+		return ""
+	}
+}
+
+// importDelimiterFromFilename returns the default CSV delimiter based on the
+// file extension (or empty string to fallback to the comma one).
+func importDelimiterFromFilename(filename string) string {
+	if strings.EqualFold(filepath.Ext(filename), ".tsv") {
+		return "\t"
+	}
+
+	return ""
+}
@@ -0,0 +1,117 @@
+package apis
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/dbutils"
+	"github.com/spf13/cast"
+)
+
+// Supported import modes.
+const (
+	// ImportModeInsert creates a new record for each row.
+	// Rows matching an existing record by the key field are treated as conflicts.
+	ImportModeInsert = "insert"
+
+	// ImportModeUpdate updates the existing record matching the row key field.
+	// Rows without a matching record are treated as conflicts.
+	ImportModeUpdate = "update"
+
+	// ImportModeUpsert updates the existing record matching the row key field
+	// or creates a new one if there is no match.
+	ImportModeUpsert = "upsert"
+)
+
+// Import row report actions.
+const (
+	ImportActionCreated = "created"
+	ImportActionUpdated = "updated"
+	ImportActionSkipped = "skipped"
+	ImportActionFailed  = "failed"
+)
+
+// ImportRowReport describes the outcome of a single imported row.
+type ImportRowReport struct {
+	Row      int    `json:"row"`
+	Action   string `json:"action"`
+	RecordId string `json:"recordId,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// normalizeImportMode validates the import mode and key field for the
+// specified collection and returns them with their defaults applied.
+//
+// The key field must be either "id" or a field with a single column unique index.
+// For the update and upsert modes the key field must be also one of the mapping targets.
+func normalizeImportMode(collection *core.Collection, mode string, keyField string, mapping map[string]string) (string, string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		mode = ImportModeInsert
+	}
+
+	switch mode {
+	case ImportModeInsert, ImportModeUpdate, ImportModeUpsert:
+	default:
+		return "", "", errors.New("invalid import mode " + mode + ", use 'insert', 'update' or 'upsert'")
+	}
+
+	keyField = strings.TrimSpace(keyField)
+	if keyField == "" {
+		keyField = core.FieldNameId
+	}
+
+	if keyField != core.FieldNameId {
+		if collection.Fields.GetByName(keyField) == nil {
+			return "", "", errors.New("key field " + keyField + " does not exist in collection")
+		}
+
+		if _, ok := dbutils.FindSingleColumnUniqueIndex(collection.Indexes, keyField); !ok {
+			return "", "", errors.New("key field " + keyField + " must have a single column unique index")
+		}
+	}
+
+	if mode != ImportModeInsert {
+		var mapped bool
+		for _, target := range mapping {
+			if target == keyField {
+				mapped = true
+				break
+			}
+		}
+		if !mapped {
+			return "", "", errors.New("key field " + keyField + " must be mapped for the " + mode + " mode")
+		}
+	}
+
+	return mode, keyField, nil
+}
+
+// findImportRecordByKey returns the collection record with the specified key field value.
+//
+// The key field index collation is taken into account (eg. case-insensitive lookup for COLLATE NOCASE).
+func findImportRecordByKey(app core.App, collection *core.Collection, keyField string, value any) (*core.Record, error) {
+	if keyField == core.FieldNameId {
+		return app.FindRecordById(collection, cast.ToString(value))
+	}
+
+	var expr dbx.Expression
+
+	index, ok := dbutils.FindSingleColumnUniqueIndex(collection.Indexes, keyField)
+	if ok && strings.EqualFold(index.Columns[0].Collate, "nocase") {
+		expr = dbx.NewExp("[["+keyField+"]] = {:key} COLLATE NOCASE", dbx.Params{"key": value})
+	} else {
+		expr = dbx.HashExp{keyField: value}
+	}
+
+	record := &core.Record{}
+
+	err := app.RecordQuery(collection).AndWhere(expr).Limit(1).One(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
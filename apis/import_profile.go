@@ -0,0 +1,426 @@
+package apis
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// importProfilesParamPrefix is the prefix of the app params
+// that store the import profiles of a single collection
+// (eg. "importProfiles_COLLECTION_ID").
+const importProfilesParamPrefix = "importProfiles_"
+
+var importProfileNameRegex = regexp.MustCompile(`^[\w\-]+$`)
+
+// ErrImportProfileNotFound is returned when the requested import profile doesn't exist.
+var ErrImportProfileNotFound = errors.New("import profile not found")
+
+// ImportProfile is a named and reusable set of import options stored per collection.
+type ImportProfile struct {
+	Name      string            `json:"name"`
+	Format    string            `json:"format"`    // "csv", "json", "ndjson", "xlsx" or "zip" (auto detected if empty)
+	Delimiter string            `json:"delimiter"` // For CSV
+	Sheet     string            `json:"sheet"`     // For XLSX
+	HeaderRow int               `json:"headerRow"` // For XLSX
+	DataFile  string            `json:"dataFile"`  // For ZIP
+	Mapping   map[string]string `json:"mapping"`   // source column -> target field
+
+	// The options below have the same meaning as in [ExecuteRequest].
+	Mode          string                     `json:"mode"`
+	KeyField      string                     `json:"keyField"`
+	SkipConflicts bool                       `json:"skipConflicts"`
+	Transforms    map[string]ImportTransform `json:"transforms"`
+
+	Created types.DateTime `json:"created"`
+	Updated types.DateTime `json:"updated"`
+}
+
+// validate checks whether the profile options are applicable to the specified collection.
+func (profile ImportProfile) validate(app core.App, collection *core.Collection) error {
+	err := validation.ValidateStruct(&profile,
+		validation.Field(&profile.Name, validation.Required, validation.Length(1, 100), validation.Match(importProfileNameRegex)),
+		validation.Field(
+			&profile.Format,
+			validation.In(ImportFormatCSV, ImportFormatJSON, ImportFormatNDJSON, ImportFormatXLSX, ImportFormatZIP).
+				Error("Must be one of csv, json, ndjson, xlsx or zip."),
+		),
+		validation.Field(&profile.Delimiter, validation.RuneLength(0, 1)),
+		validation.Field(&profile.HeaderRow, validation.Min(0)),
+		validation.Field(&profile.Mapping, validation.Required),
+	)
+
+	errs, _ := err.(validation.Errors)
+	if err != nil && errs == nil {
+		return err
+	}
+	if errs == nil {
+		errs = validation.Errors{}
+	}
+
+	if _, _, err := normalizeImportMode(collection, profile.Mode, profile.KeyField, profile.Mapping); err != nil {
+		errs["mode"] = validation.NewError("validation_invalid_import_mode", err.Error())
+	}
+
+	if err := validateImportTransforms(app, collection, profile.Transforms); err != nil {
+		errs["transforms"] = err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// applyTo fills the empty import job options with the profile ones.
+func (profile ImportProfile) applyTo(options *ImportJobOptions) {
+	if options.Format == "" {
+		options.Format = profile.Format
+	}
+	if options.Delimiter == "" {
+		options.Delimiter = profile.Delimiter
+	}
+	if options.Sheet == "" {
+		options.Sheet = profile.Sheet
+	}
+	if options.HeaderRow == 0 {
+		options.HeaderRow = profile.HeaderRow
+	}
+	if options.DataFile == "" {
+		options.DataFile = profile.DataFile
+	}
+	if len(options.Mapping) == 0 {
+		options.Mapping = profile.Mapping
+	}
+	if options.Mode == "" {
+		options.Mode = profile.Mode
+	}
+	if options.KeyField == "" {
+		options.KeyField = profile.KeyField
+	}
+	if len(options.Transforms) == 0 {
+		options.Transforms = profile.Transforms
+	}
+	options.SkipConflicts = options.SkipConflicts || profile.SkipConflicts
+}
+
+// applyProfile fills the empty request options with the ones of the req.Profile import profile.
+func (req *ExecuteRequest) applyProfile(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId(req.Collection)
+	if err != nil {
+		return errors.New("collection not found: " + req.Collection)
+	}
+
+	profile, err := FindImportProfile(app, collection, req.Profile)
+	if err != nil {
+		return err
+	}
+
+	options := ImportJobOptions{
+		Format:        req.Format,
+		Delimiter:     req.Delimiter,
+		Sheet:         req.Sheet,
+		HeaderRow:     req.HeaderRow,
+		DataFile:      req.DataFile,
+		Mapping:       req.Mapping,
+		Mode:          req.Mode,
+		KeyField:      req.KeyField,
+		SkipConflicts: req.SkipConflicts,
+		Transforms:    req.Transforms,
+	}
+
+	profile.applyTo(&options)
+
+	req.Format = options.Format
+	req.Delimiter = options.Delimiter
+	req.Sheet = options.Sheet
+	req.HeaderRow = options.HeaderRow
+	req.DataFile = options.DataFile
+	req.Mapping = options.Mapping
+	req.Mode = options.Mode
+	req.KeyField = options.KeyField
+	req.SkipConflicts = options.SkipConflicts
+	req.Transforms = options.Transforms
+
+	return nil
+}
+
+// FindImportProfiles returns all import profiles of the specified collection sorted by their name.
+func FindImportProfiles(app core.App, collection *core.Collection) ([]ImportProfile, error) {
+	param := &core.Param{}
+
+	err := app.ModelQuery(param).Model(importProfilesParamPrefix+collection.Id, param)
+	if errors.Is(err, sql.ErrNoRows) {
+		return []ImportProfile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeImportProfiles(param)
+}
+
+// FindImportProfile returns a single import profile of the specified collection.
+//
+// Returns [ErrImportProfileNotFound] if the collection doesn't have a profile with the specified name.
+func FindImportProfile(app core.App, collection *core.Collection, name string) (ImportProfile, error) {
+	profiles, err := FindImportProfiles(app, collection)
+	if err != nil {
+		return ImportProfile{}, err
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+
+	return ImportProfile{}, ErrImportProfileNotFound
+}
+
+// FindImportProfileByName looks up an import profile by its name in all collections
+// and returns it together with its collection.
+//
+// Returns an error if there is no such profile or if more than one
+// collection has a profile with the specified name.
+func FindImportProfileByName(app core.App, name string) (*core.Collection, ImportProfile, error) {
+	params := []*core.Param{}
+
+	// note: "_" is a LIKE wildcard and needs to be escaped
+	err := app.ModelQuery(&core.Param{}).
+		AndWhere(dbx.NewExp("[[id]] LIKE {:prefix} ESCAPE '\\'", dbx.Params{"prefix": `importProfiles\_%`})).
+		All(&params)
+	if err != nil {
+		return nil, ImportProfile{}, err
+	}
+
+	var foundCollection *core.Collection
+	var foundProfile ImportProfile
+
+	for _, param := range params {
+		profiles, err := decodeImportProfiles(param)
+		if err != nil {
+			return nil, ImportProfile{}, err
+		}
+
+		for _, profile := range profiles {
+			if profile.Name != name {
+				continue
+			}
+
+			collection, err := app.FindCachedCollectionByNameOrId(strings.TrimPrefix(param.Id, importProfilesParamPrefix))
+			if err != nil {
+				continue // orphaned profile of a deleted collection
+			}
+
+			if foundCollection != nil {
+				return nil, ImportProfile{}, fmt.Errorf("import profile %q exists in more than one collection (%s, %s)", name, foundCollection.Name, collection.Name)
+			}
+
+			foundCollection = collection
+			foundProfile = profile
+		}
+	}
+
+	if foundCollection == nil {
+		return nil, ImportProfile{}, ErrImportProfileNotFound
+	}
+
+	return foundCollection, foundProfile, nil
+}
+
+// SaveImportProfile validates and creates or replaces (by name)
+// the specified import profile of the collection.
+func SaveImportProfile(app core.App, collection *core.Collection, profile ImportProfile) (ImportProfile, error) {
+	if err := profile.validate(app, collection); err != nil {
+		return ImportProfile{}, err
+	}
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		profiles, err := FindImportProfiles(txApp, collection)
+		if err != nil {
+			return err
+		}
+
+		now := types.NowDateTime()
+		profile.Created = now
+		profile.Updated = now
+
+		replaced := false
+		for i, existing := range profiles {
+			if existing.Name == profile.Name {
+				profile.Created = existing.Created
+				profiles[i] = profile
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			profiles = append(profiles, profile)
+		}
+
+		return saveImportProfiles(txApp, collection, profiles)
+	})
+	if err != nil {
+		return ImportProfile{}, err
+	}
+
+	return profile, nil
+}
+
+// DeleteImportProfile deletes a single import profile of the specified collection.
+//
+// Returns [ErrImportProfileNotFound] if the collection doesn't have a profile with the specified name.
+func DeleteImportProfile(app core.App, collection *core.Collection, name string) error {
+	return app.RunInTransaction(func(txApp core.App) error {
+		profiles, err := FindImportProfiles(txApp, collection)
+		if err != nil {
+			return err
+		}
+
+		for i, profile := range profiles {
+			if profile.Name == name {
+				return saveImportProfiles(txApp, collection, append(profiles[:i], profiles[i+1:]...))
+			}
+		}
+
+		return ErrImportProfileNotFound
+	})
+}
+
+// saveImportProfiles persists the full list of the collection import profiles
+// (the param is deleted if there are no profiles).
+func saveImportProfiles(app core.App, collection *core.Collection, profiles []ImportProfile) error {
+	param := &core.Param{}
+
+	err := app.ModelQuery(param).Model(importProfilesParamPrefix+collection.Id, param)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if len(profiles) == 0 {
+		if param.IsNew() {
+			return nil
+		}
+		return app.Delete(param)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+
+	raw, err := json.Marshal(profiles)
+	if err != nil {
+		return err
+	}
+
+	now := types.NowDateTime()
+
+	if param.IsNew() {
+		param.Id = importProfilesParamPrefix + collection.Id
+		param.Created = now
+	}
+	param.Updated = now
+	param.Value = raw
+
+	return app.Save(param)
+}
+
+func decodeImportProfiles(param *core.Param) ([]ImportProfile, error) {
+	profiles := []ImportProfile{}
+
+	if len(param.Value) == 0 {
+		return profiles, nil
+	}
+
+	if err := json.Unmarshal(param.Value, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid %s import profiles data: %w", param.Id, err)
+	}
+
+	return profiles, nil
+}
+
+// -------------------------------------------------------------------
+
+// importProfileList returns all import profiles of a single collection.
+// GET /api/import/profiles/{collection}
+func importProfileList(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	profiles, err := FindImportProfiles(e.App, collection)
+	if err != nil {
+		return e.InternalServerError("Failed to load the import profiles.", err)
+	}
+
+	return e.JSON(http.StatusOK, profiles)
+}
+
+// importProfileView returns a single collection import profile.
+// GET /api/import/profiles/{collection}/{name}
+func importProfileView(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	profile, err := FindImportProfile(e.App, collection, e.Request.PathValue("name"))
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	return e.JSON(http.StatusOK, profile)
+}
+
+// importProfileSave creates or replaces (by name) a single collection import profile.
+// POST /api/import/profiles/{collection}
+func importProfileSave(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	var profile ImportProfile
+	if err := e.BindBody(&profile); err != nil {
+		return e.BadRequestError("Invalid request body", err)
+	}
+
+	profile, err = SaveImportProfile(e.App, collection, profile)
+	if err != nil {
+		return e.BadRequestError("Failed to save the import profile.", err)
+	}
+
+	return e.JSON(http.StatusOK, profile)
+}
+
+// importProfileDelete deletes a single collection import profile.
+// DELETE /api/import/profiles/{collection}/{name}
+func importProfileDelete(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	err = DeleteImportProfile(e.App, collection, e.Request.PathValue("name"))
+	if errors.Is(err, ErrImportProfileNotFound) {
+		return e.NotFoundError("", err)
+	}
+	if err != nil {
+		return e.BadRequestError("Failed to delete the import profile.", err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,178 @@
+package apis
+
+import (
+	"encoding/csv"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/store"
+)
+
+const (
+	importRejectedStoreKey      = "__pbImportRejected__"
+	importRejectedTerminateHook = "__pbImportRejectedTerminate__"
+)
+
+// Extra columns appended to the source columns of the rejected rows CSV.
+const (
+	ImportRejectedRowColumn   = "_row"
+	ImportRejectedErrorColumn = "_error"
+)
+
+type importRejectedEntry struct {
+	path    string
+	created time.Time
+}
+
+// importRejectedFile is a CSV temp file with the rejected import rows
+// and their error messages.
+type importRejectedFile struct {
+	id      string
+	file    *os.File
+	writer  *csv.Writer
+	columns []string
+}
+
+// newImportRejectedFile creates a new rejected rows CSV file with the specified source columns.
+func newImportRejectedFile(app core.App, columns []string) (*importRejectedFile, error) {
+	tempDir := filepath.Join(app.DataDir(), core.LocalTempDirName)
+	if err := os.MkdirAll(tempDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(tempDir, "import_rejected_*.csv")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &importRejectedFile{
+		id:      security.RandomString(15),
+		file:    file,
+		writer:  csv.NewWriter(file),
+		columns: columns,
+	}
+
+	header := append(slices.Clone(columns), ImportRejectedRowColumn, ImportRejectedErrorColumn)
+	if err := f.writer.Write(header); err != nil {
+		f.discard()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// add writes a single rejected source row.
+func (f *importRejectedFile) add(row map[string]string, rowNum int, message string) error {
+	record := make([]string, 0, len(f.columns)+2)
+	for _, col := range f.columns {
+		record = append(record, row[col])
+	}
+	record = append(record, strconv.Itoa(rowNum), message)
+
+	return f.writer.Write(record)
+}
+
+// save flushes and closes the file and registers it for download.
+func (f *importRejectedFile) save(app core.App) error {
+	f.writer.Flush()
+
+	if err := f.writer.Error(); err != nil {
+		f.discard()
+		return err
+	}
+
+	if err := f.file.Close(); err != nil {
+		_ = os.Remove(f.file.Name())
+		return err
+	}
+
+	cleanupImportRejected(app)
+
+	importRejected(app).Set(f.id, &importRejectedEntry{
+		path:    f.file.Name(),
+		created: time.Now(),
+	})
+
+	return nil
+}
+
+// discard closes and deletes the file without registering it.
+func (f *importRejectedFile) discard() {
+	_ = f.file.Close()
+	_ = os.Remove(f.file.Name())
+}
+
+// importRejected returns the app registry of the rejected rows files (initializing it if missing).
+func importRejected(app core.App) *store.Store[string, *importRejectedEntry] {
+	return app.Store().GetOrSet(importRejectedStoreKey, func() any {
+		files := store.New[string, *importRejectedEntry](nil)
+
+		app.OnTerminate().Bind(&hook.Handler[*core.TerminateEvent]{
+			Id: importRejectedTerminateHook,
+			Func: func(e *core.TerminateEvent) error {
+				for id, entry := range files.GetAll() {
+					_ = os.Remove(entry.path)
+					files.Remove(id)
+				}
+
+				return e.Next()
+			},
+		})
+
+		return files
+	}).(*store.Store[string, *importRejectedEntry])
+}
+
+// cleanupImportRejected deletes the rejected rows files older than [finishedImportJobsTTL].
+func cleanupImportRejected(app core.App) {
+	files := importRejected(app)
+
+	threshold := time.Now().Add(-finishedImportJobsTTL)
+
+	for id, entry := range files.GetAll() {
+		if entry.created.Before(threshold) {
+			_ = os.Remove(entry.path)
+			files.Remove(id)
+		}
+	}
+}
+
+// importRejectedDownload serves the CSV file with the rejected rows of an import.
+// GET /api/import/rejected/{id}
+//
+// It relies on a superuser file token so that it could be used as a regular download link.
+func importRejectedDownload(e *core.RequestEvent) error {
+	fileToken := e.Request.URL.Query().Get("token")
+
+	authRecord, err := e.App.FindAuthRecordByToken(fileToken, core.TokenTypeFile)
+	if err != nil || !authRecord.IsSuperuser() {
+		return e.ForbiddenError("Insufficient permissions to access the resource.", err)
+	}
+
+	id := e.Request.PathValue("id")
+
+	entry, ok := importRejected(e.App).GetOk(id)
+	if !ok {
+		return e.NotFoundError("", nil)
+	}
+
+	file, err := os.Open(entry.path)
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+	defer file.Close()
+
+	e.Response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	e.Response.Header().Set("Content-Disposition", `attachment; filename="rejected_`+id+`.csv"`)
+
+	http.ServeContent(e.Response, e.Request, "rejected_"+id+".csv", entry.created, file)
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package apis_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestImportRejectedDownload(t *testing.T) {
+	t.Parallel()
+
+	startRejectedImport := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		content := "name,active\nnew_title,true\nx,false\ntest1,true"
+
+		job, err := apis.StartImportJob(app, io.NopCloser(strings.NewReader(content)), int64(len(content)), apis.ImportJobOptions{
+			Collection: "demo2",
+			Format:     "csv",
+			Mapping:    map[string]string{"name": "title"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job, err = apis.WaitImportJob(context.Background(), app, job.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.RejectedId == "" {
+			t.Fatal("Expected the job to have rejected rows file")
+		}
+
+		// replace the placeholder id since the file id is not known in advance
+		e.Router.BindFunc(func(re *core.RequestEvent) error {
+			re.Request.SetPathValue("id", job.RejectedId)
+			return re.Next()
+		})
+	}
+
+	fileToken := func(t testing.TB, app *tests.TestApp, collection string, email string) string {
+		record, err := app.FindAuthRecordByEmail(collection, email)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := record.NewFileToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	scenarios := []struct {
+		name            string
+		token           func(t testing.TB, app *tests.TestApp) string
+		expectedStatus  int
+		expectedContent []string
+	}{
+		{
+			name:            "no token",
+			token:           func(t testing.TB, app *tests.TestApp) string { return "" },
+			expectedStatus:  403,
+			expectedContent: []string{`"data":{}`},
+		},
+		{
+			name: "regular user file token",
+			token: func(t testing.TB, app *tests.TestApp) string {
+				return fileToken(t, app, "users", "test@example.com")
+			},
+			expectedStatus:  403,
+			expectedContent: []string{`"data":{}`},
+		},
+		{
+			name: "superuser file token",
+			token: func(t testing.TB, app *tests.TestApp) string {
+				return fileToken(t, app, core.CollectionNameSuperusers, "test@example.com")
+			},
+			expectedStatus: 200,
+			expectedContent: []string{
+				"name,active,_row,_error\n",
+				"x,false,2,",
+				"test1,true,3,",
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		var token string
+
+		scenario := tests.ApiScenario{
+			Name:   s.name,
+			Method: http.MethodGet,
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				startRejectedImport(t, app, e)
+				token = s.token(t, app)
+
+				e.Router.BindFunc(func(re *core.RequestEvent) error {
+					query := re.Request.URL.Query()
+					query.Set("token", token)
+					re.Request.URL.RawQuery = query.Encode()
+					return re.Next()
+				})
+			},
+			ExpectedStatus:     s.expectedStatus,
+			ExpectedContent:    s.expectedContent,
+			NotExpectedContent: []string{"new_title"},
+			ExpectedEvents:     map[string]int{"*": 0},
+		}
+		scenario.URL = "/api/import/rejected/FILE_ID"
+		scenario.Test(t)
+	}
+
+	missingScenario := tests.ApiScenario{
+		Name:   "missing rejected file",
+		Method: http.MethodGet,
+		URL:    "/api/import/rejected/missing",
+		BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			token := fileToken(t, app, core.CollectionNameSuperusers, "test@example.com")
+
+			e.Router.BindFunc(func(re *core.RequestEvent) error {
+				query := re.Request.URL.Query()
+				query.Set("token", token)
+				re.Request.URL.RawQuery = query.Encode()
+				return re.Next()
+			})
+		},
+		ExpectedStatus:  404,
+		ExpectedContent: []string{`"data":{}`},
+		ExpectedEvents:  map[string]int{"*": 0},
+	}
+	missingScenario.Test(t)
+}
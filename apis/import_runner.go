@@ -0,0 +1,556 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/dbutils"
+)
+
+// errImportRollback is used to rollback the atomic import transaction.
+var errImportRollback = errors.New("atomic import rollback")
+
+// importRunner imports the rows of an importSource into a single collection.
+type importRunner struct {
+	app        core.App
+	collection *core.Collection
+	mapping    map[string]string
+
+	// transforms is the optional target field -> value transform map.
+	transforms map[string]ImportTransform
+
+	// mode is one of the ImportMode* constants (default to ImportModeInsert).
+	mode string
+
+	// keyField is the field used to match the existing records (default to "id").
+	keyField string
+
+	// skipConflicts reports the rows conflicting with the import mode
+	// as skipped instead of failed.
+	skipConflicts bool
+
+	// report enables the per-row result report.
+	report bool
+
+	// atomic imports all rows in a single transaction that is
+	// rolled back if any of the rows fails (batchSize is ignored).
+	atomic bool
+
+	// validateOnly runs the full record validation without persisting the rows.
+	validateOnly bool
+
+	// rejected enables writing the failed source rows in a downloadable CSV file.
+	rejected bool
+
+	rejectedFile *importRejectedFile
+
+	// uniqueValues tracks the single column unique index values of the
+	// already validated rows (index key -> record id) so that the in-file
+	// duplicates could be detected in validate-only mode.
+	uniqueValues map[string]string
+
+	// assets is the optional bundle of the file field assets
+	// (populated on run if the source implements it).
+	assets importAssets
+
+	// rowTransforms are the non file field transforms
+	// (populated on run from transforms).
+	rowTransforms map[string]ImportTransform
+
+	// filesDir is the optional directory where the loaded row files are
+	// stored until their record is saved (otherwise they are kept in memory).
+	filesDir string
+
+	// batchSize specifies how many rows to save in a single transaction.
+	//
+	// If batchSize <= 0, each row is saved on its own without a transaction.
+	batchSize int
+
+	// maxErrors limits the number of stored row errors (<= 0 means no limit).
+	maxErrors int
+
+	// onBatch is an optional callback that is invoked after each committed batch.
+	onBatch func(result ExecuteResponse)
+}
+
+// run reads and imports all source rows and accumulates the outcome in result.
+//
+// The import stops with an error on ctx cancellation or on source read error.
+// Note that in this case the already committed batches are not reverted
+// while the rows of the failed batch are rolled back.
+func (r *importRunner) run(ctx context.Context, source importSource, result *ExecuteResponse) error {
+	result.ValidateOnly = r.validateOnly
+
+	r.assets, _ = source.(importAssets)
+
+	err := r.runRows(ctx, source, result)
+
+	if r.rejectedFile != nil {
+		if saveErr := r.rejectedFile.save(r.app); saveErr != nil {
+			r.app.Logger().Warn("Failed to save the rejected import rows", "error", saveErr)
+		} else {
+			result.RejectedId = r.rejectedFile.id
+		}
+		r.rejectedFile = nil
+	}
+
+	return err
+}
+
+func (r *importRunner) runRows(ctx context.Context, source importSource, result *ExecuteResponse) error {
+	// the file field transforms are applied when the row files are loaded
+	r.rowTransforms = make(map[string]ImportTransform, len(r.transforms))
+	for name, transform := range r.transforms {
+		if _, ok := r.collection.Fields.GetByName(name).(*core.FileField); !ok {
+			r.rowTransforms[name] = transform
+		}
+	}
+
+	if r.atomic {
+		// all rows are prepared upfront so the loaded files
+		// are stored on disk until the transaction completes
+		filesDir := filepath.Join(r.app.DataDir(), core.LocalTempDirName)
+		if err := os.MkdirAll(filesDir, os.ModePerm); err != nil {
+			return err
+		}
+
+		var err error
+		r.filesDir, err = os.MkdirTemp(filesDir, "import_files_*")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			os.RemoveAll(r.filesDir)
+			r.filesDir = ""
+		}()
+
+		rows, _, err := r.prepareRows(ctx, source, -1)
+		if err != nil {
+			return err
+		}
+
+		err = r.app.RunInTransaction(func(txApp core.App) error {
+			if err := r.processRows(ctx, txApp, source, rows, result); err != nil {
+				return err
+			}
+
+			if result.FailureCount > 0 {
+				return errImportRollback
+			}
+
+			return nil
+		})
+		if errors.Is(err, errImportRollback) {
+			result.RolledBack = true
+			return nil
+		}
+
+		return err
+	}
+
+	if r.batchSize <= 0 {
+		for {
+			rows, eof, err := r.prepareRows(ctx, source, 1)
+			if err != nil {
+				return err
+			}
+
+			if err := r.processRows(ctx, r.app, source, rows, result); err != nil {
+				return err
+			}
+
+			if eof {
+				return nil
+			}
+		}
+	}
+
+	for {
+		// the row files are loaded before the batch transaction
+		// to avoid blocking the db writes during the downloads
+		rows, eof, err := r.prepareRows(ctx, source, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		// work on a copy to discard the counters of a rolled back batch
+		batch := *result
+
+		err = r.app.RunInTransaction(func(txApp core.App) error {
+			return r.processRows(ctx, txApp, source, rows, &batch)
+		})
+		if err != nil {
+			return err
+		}
+
+		*result = batch
+
+		if r.onBatch != nil {
+			r.onBatch(*result)
+		}
+
+		if eof {
+			return nil
+		}
+	}
+}
+
+// importPreparedRow is a read source row with its already loaded file field values.
+type importPreparedRow struct {
+	row        map[string]string
+	recordData map[string]any
+
+	// files holds the loaded file field values (field name -> file or files).
+	files map[string]any
+
+	// convertErr is the file field transforms error (if any).
+	convertErr error
+
+	// filesErr is the file field values loading error (if any).
+	filesErr error
+}
+
+// prepareRows reads up to limit source rows (or all if limit < 0),
+// loads their file field values and reports whether the end of the
+// source was reached.
+//
+// Note that the files of the rows that are later skipped because of
+// a conflict are also loaded since the conflicts can be checked only
+// within the import transaction.
+func (r *importRunner) prepareRows(ctx context.Context, source importSource, limit int) ([]importPreparedRow, bool, error) {
+	var rows []importPreparedRow
+
+	for i := 0; limit < 0 || i < limit; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+
+		row, err := source.Next()
+		if err == io.EOF {
+			return rows, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		prepared := importPreparedRow{
+			row:        row,
+			recordData: mapImportRow(row, r.mapping),
+		}
+
+		files := map[string]any{}
+		for name, value := range prepared.recordData {
+			if _, ok := r.collection.Fields.GetByName(name).(*core.FileField); ok {
+				files[name] = value
+			}
+		}
+
+		if len(files) > 0 {
+			if err := applyImportTransforms(r.app, r.collection, files, r.transforms); err != nil {
+				prepared.convertErr = err
+			} else if err := resolveImportFiles(ctx, r.collection, files, r.assets); err != nil {
+				prepared.filesErr = err
+			} else if err := storeImportFiles(r.filesDir, files); err != nil {
+				return nil, false, err
+			} else {
+				prepared.files = files
+			}
+		}
+
+		rows = append(rows, prepared)
+	}
+
+	return rows, false, nil
+}
+
+// processRows imports the prepared rows and accumulates the outcome in result.
+func (r *importRunner) processRows(ctx context.Context, app core.App, source importSource, rows []importPreparedRow, result *ExecuteResponse) error {
+	for _, prepared := range rows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result.TotalRows++
+
+		rowReport := r.importRow(app, prepared)
+		rowReport.Row = result.TotalRows // 1-indexed for user display
+
+		switch rowReport.Action {
+		case ImportActionCreated:
+			result.SuccessCount++
+			result.CreatedCount++
+		case ImportActionUpdated:
+			result.SuccessCount++
+			result.UpdatedCount++
+		case ImportActionSkipped:
+			result.SkippedCount++
+		default:
+			result.FailureCount++
+			if r.maxErrors <= 0 || len(result.Errors) < r.maxErrors {
+				result.Errors = append(result.Errors, ImportError{
+					Row:     rowReport.Row,
+					Message: rowReport.Message,
+					Data:    prepared.recordData,
+				})
+			}
+
+			if err := r.reject(source, prepared.row, rowReport); err != nil {
+				return err
+			}
+		}
+
+		if r.report {
+			result.Rows = append(result.Rows, rowReport)
+		}
+	}
+
+	return nil
+}
+
+// importRow creates or updates a single record from the prepared row
+// based on the runner import mode.
+//
+// The returned report Row is left for the caller to populate.
+func (r *importRunner) importRow(app core.App, prepared importPreparedRow) ImportRowReport {
+	recordData := prepared.recordData
+
+	convertErrs := validation.Errors{}
+	for _, err := range []error{prepared.convertErr, applyImportTransforms(app, r.collection, recordData, r.rowTransforms)} {
+		if errs, ok := err.(validation.Errors); ok {
+			maps.Copy(convertErrs, errs)
+		}
+	}
+	if len(convertErrs) > 0 {
+		return ImportRowReport{Action: ImportActionFailed, Message: "Failed to convert the row values: " + convertErrs.Error()}
+	}
+
+	mode := r.mode
+	if mode == "" {
+		mode = ImportModeInsert
+	}
+
+	keyField := r.keyField
+	if keyField == "" {
+		keyField = core.FieldNameId
+	}
+
+	var record *core.Record
+
+	keyValue, hasKey := recordData[keyField]
+	if hasKey {
+		existing, err := findImportRecordByKey(app, r.collection, keyField, keyValue)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return ImportRowReport{Action: ImportActionFailed, Message: err.Error()}
+		}
+		record = existing
+	}
+
+	switch {
+	case mode == ImportModeUpdate && !hasKey:
+		return r.conflict("", "Missing "+keyField+" key value.")
+	case mode == ImportModeInsert && record != nil:
+		return r.conflict(record.Id, fmt.Sprintf("A record with %s %q already exists.", keyField, keyValue))
+	case mode == ImportModeUpdate && record == nil:
+		return r.conflict("", fmt.Sprintf("Missing record with %s %q.", keyField, keyValue))
+	}
+
+	if prepared.filesErr != nil {
+		return ImportRowReport{Action: ImportActionFailed, Message: "Failed to load the row files: " + prepared.filesErr.Error()}
+	}
+
+	for key, value := range prepared.files {
+		recordData[key] = value
+	}
+
+	action := ImportActionUpdated
+	if record == nil {
+		action = ImportActionCreated
+		record = core.NewRecord(r.collection)
+	}
+
+	for key, value := range recordData {
+		record.Set(key, value)
+	}
+
+	var err error
+	if r.validateOnly {
+		err = app.Validate(record)
+		if err == nil {
+			if r.uniqueValues == nil {
+				r.uniqueValues = map[string]string{}
+			}
+			err = validateImportUniqueIndexes(app, record, r.uniqueValues)
+		}
+	} else {
+		err = app.Save(record)
+	}
+
+	report := ImportRowReport{Action: action}
+	if action == ImportActionUpdated || !r.validateOnly {
+		report.RecordId = record.Id
+	}
+
+	if err != nil {
+		report.Action = ImportActionFailed
+		report.Message = err.Error()
+		if action == ImportActionCreated {
+			report.RecordId = ""
+		}
+	}
+
+	return report
+}
+
+// validateImportUniqueIndexes checks the record values against the
+// collection single column unique indexes.
+//
+// This is needed for the validate-only imports because the unique
+// constraints are otherwise enforced by the db only on save.
+//
+// seen holds the unique values of the previously validated rows
+// (index key -> record id) and it is updated with the record values
+// on success so that the duplicates within the same import are also rejected.
+// Partial indexes are checked only against the db because their
+// WHERE clause can't be evaluated for the not persisted rows.
+func validateImportUniqueIndexes(app core.App, record *core.Record, seen map[string]string) error {
+	errs := validation.Errors{}
+
+	seenKeys := []string{}
+
+	for _, raw := range record.Collection().Indexes {
+		index := dbutils.ParseIndex(raw)
+		if !index.Unique || len(index.Columns) != 1 {
+			continue
+		}
+
+		column := index.Columns[0].Name
+
+		value := record.Get(column)
+		if value == nil || value == "" {
+			continue
+		}
+
+		nocase := strings.EqualFold(index.Columns[0].Collate, "nocase")
+
+		if index.Where == "" {
+			key := fmt.Sprint(value)
+			if nocase {
+				key = strings.ToLower(key)
+			}
+			key = index.IndexName + "\x00" + key
+
+			if id, ok := seen[key]; ok && id != record.Id {
+				errs[column] = validation.NewError("validation_not_unique", "Value must be unique")
+				continue
+			}
+
+			seenKeys = append(seenKeys, key)
+		}
+
+		var expr dbx.Expression
+		if nocase {
+			expr = dbx.NewExp("[["+column+"]] = {:value} COLLATE NOCASE", dbx.Params{"value": value})
+		} else {
+			expr = dbx.HashExp{column: value}
+		}
+
+		query := app.RecordQuery(record.Collection()).
+			Select("(1)").
+			AndWhere(expr).
+			AndWhere(dbx.Not(dbx.HashExp{core.FieldNameId: record.Id})).
+			Limit(1)
+
+		if index.Where != "" {
+			query.AndWhere(dbx.NewExp(index.Where))
+		}
+
+		var exists int
+		if err := query.Row(&exists); err == nil && exists > 0 {
+			errs[column] = validation.NewError("validation_not_unique", "Value must be unique")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for _, key := range seenKeys {
+		seen[key] = record.Id
+	}
+
+	return nil
+}
+
+// reject writes the failed source row in the runner rejected rows file (if enabled).
+func (r *importRunner) reject(source importSource, row map[string]string, report ImportRowReport) error {
+	if !r.rejected {
+		return nil
+	}
+
+	if r.rejectedFile == nil {
+		var columns []string
+		if s, ok := source.(interface{ Headers() []string }); ok {
+			columns = s.Headers()
+		} else {
+			// the source columns are not known upfront (eg. JSON)
+			for sourceCol := range r.mapping {
+				columns = append(columns, sourceCol)
+			}
+			sortStrings(columns)
+		}
+
+		file, err := newImportRejectedFile(r.app, columns)
+		if err != nil {
+			return fmt.Errorf("failed to create the rejected rows file: %w", err)
+		}
+		r.rejectedFile = file
+	}
+
+	return r.rejectedFile.add(row, report.Row, report.Message)
+}
+
+// conflict returns a skipped or failed row report depending on the runner skipConflicts option.
+func (r *importRunner) conflict(recordId string, message string) ImportRowReport {
+	action := ImportActionFailed
+	if r.skipConflicts {
+		action = ImportActionSkipped
+	}
+
+	return ImportRowReport{Action: action, RecordId: recordId, Message: message}
+}
+
+// mapImportRow converts a single source row into record data
+// based on the source column -> target field mapping.
+//
+// Unmapped columns and empty values are skipped.
+func mapImportRow(row map[string]string, mapping map[string]string) map[string]any {
+	recordData := make(map[string]any)
+
+	for sourceCol, targetField := range mapping {
+		if targetField == "" || targetField == "-" {
+			continue
+		}
+
+		raw, exists := row[sourceCol]
+		if !exists {
+			continue
+		}
+
+		value := strings.TrimSpace(raw)
+		if value != "" {
+			recordData[targetField] = value
+		}
+	}
+
+	return recordData
+}
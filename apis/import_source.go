@@ -0,0 +1,422 @@
+package apis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/pocketbase/pocketbase/tools/xlsx"
+)
+
+// Supported import formats.
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatJSON   = "json"
+	ImportFormatNDJSON = "ndjson"
+	ImportFormatXLSX   = "xlsx"
+
+	// ImportFormatZIP is a bundle with a single data file in one of the
+	// other formats and an optional assets directory for the file fields.
+	ImportFormatZIP = "zip"
+)
+
+// importDetectSize is the max number of the source first bytes
+// that are used for the format detection.
+const importDetectSize = 64 << 10
+
+// importSource is a streaming reader of import data rows.
+type importSource interface {
+	// Next returns the next data row as source column -> raw value map.
+	//
+	// It returns [io.EOF] when there are no more rows.
+	Next() (map[string]string, error)
+}
+
+// importSourceOptions defines the parsing options of an importSource.
+type importSourceOptions struct {
+	Format    string // "csv", "json", "ndjson", "xlsx" or "zip" (auto detected if empty)
+	Delimiter string // For CSV
+	Sheet     string // For XLSX (default to the first sheet)
+	HeaderRow int    // For XLSX, 1-based (default to 1)
+	DataFile  string // For ZIP, the bundle data file path (default to the single root data file)
+}
+
+// newImportSource creates a new streaming importSource for the specified format.
+//
+// If options.Format is empty, it is detected from the first bytes of r.
+//
+// size is the total r size and it is required only for the XLSX and ZIP formats
+// if r implements [io.ReaderAt] (otherwise r is read entirely in memory).
+//
+// Sources that hold resources implement [io.Closer] and should be
+// released with closeImportSource.
+func newImportSource(r io.Reader, size int64, options importSourceOptions) (importSource, error) {
+	format := options.Format
+
+	ra, isReaderAt := r.(io.ReaderAt)
+
+	var br *bufio.Reader
+	if !isReaderAt {
+		br = bufio.NewReaderSize(r, importDetectSize)
+		r = br
+	}
+
+	if format == "" {
+		if isReaderAt {
+			peek := make([]byte, importDetectSize)
+			n, _ := ra.ReadAt(peek, 0)
+			format = detectFormat(string(peek[:n]))
+		} else {
+			peek, _ := br.Peek(importDetectSize)
+			format = detectFormat(string(peek))
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case ImportFormatCSV:
+		return newCSVImportSource(r, options.Delimiter)
+	case ImportFormatJSON:
+		return newJSONImportSource(r)
+	case ImportFormatNDJSON:
+		return newNDJSONImportSource(r), nil
+	case ImportFormatXLSX, ImportFormatZIP:
+		if !isReaderAt || size <= 0 {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			ra = bytes.NewReader(data)
+			size = int64(len(data))
+		}
+
+		// zip archives that are not workbooks are bundles
+		if strings.EqualFold(format, ImportFormatXLSX) && !isXLSXArchive(ra, size) {
+			format = ImportFormatZIP
+		}
+
+		if strings.EqualFold(format, ImportFormatZIP) {
+			return newBundleImportSource(ra, size, options)
+		}
+
+		return newXLSXImportSource(ra, size, options.Sheet, options.HeaderRow)
+	default:
+		return nil, errors.New("unsupported format, use 'csv', 'json', 'ndjson', 'xlsx' or 'zip'")
+	}
+}
+
+// closeImportSource releases the source resources (if any).
+func closeImportSource(source importSource) error {
+	if c, ok := source.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+type csvImportSource struct {
+	reader  *csv.Reader
+	headers []string
+}
+
+func newCSVImportSource(r io.Reader, delimiter string) (*csvImportSource, error) {
+	reader := csv.NewReader(r)
+
+	if delimiter == "\\t" || delimiter == "\t" {
+		reader.Comma = '\t'
+	} else if delimiter != "" && len(delimiter) == 1 {
+		reader.Comma = rune(delimiter[0])
+	}
+
+	headers, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	return &csvImportSource{reader: reader, headers: headers}, nil
+}
+
+// Headers returns the CSV header columns.
+func (s *csvImportSource) Headers() []string {
+	return s.headers
+}
+
+// Next implements [importSource.Next] interface method.
+func (s *csvImportSource) Next() (map[string]string, error) {
+	record, err := s.reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	row := make(map[string]string, len(s.headers))
+	for i, h := range s.headers {
+		if i < len(record) {
+			row[h] = record[i]
+		}
+	}
+
+	return row, nil
+}
+
+// -------------------------------------------------------------------
+
+type jsonImportSource struct {
+	decoder *json.Decoder
+}
+
+func newJSONImportSource(r io.Reader) (*jsonImportSource, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: expected array of objects: %w", err)
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("failed to parse JSON: expected array of objects")
+	}
+
+	return &jsonImportSource{decoder: decoder}, nil
+}
+
+// Next implements [importSource.Next] interface method.
+func (s *jsonImportSource) Next() (map[string]string, error) {
+	if !s.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var obj map[string]any
+	if err := s.decoder.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: expected array of objects: %w", err)
+	}
+
+	row := make(map[string]string, len(obj))
+	for k, v := range obj {
+		row[k] = valueToString(v)
+	}
+
+	return row, nil
+}
+
+// -------------------------------------------------------------------
+
+// ndjsonImportSource is a newline-delimited JSON (one object per line) importSource.
+type ndjsonImportSource struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func newNDJSONImportSource(r io.Reader) *ndjsonImportSource {
+	return &ndjsonImportSource{reader: bufio.NewReader(r)}
+}
+
+// Next implements [importSource.Next] interface method.
+func (s *ndjsonImportSource) Next() (map[string]string, error) {
+	for {
+		line, readErr := s.reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			s.line++
+
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.UseNumber()
+
+			var obj map[string]any
+			if err := decoder.Decode(&obj); err != nil {
+				return nil, fmt.Errorf("failed to parse NDJSON line %d: expected JSON object: %w", s.line, err)
+			}
+
+			row := make(map[string]string, len(obj))
+			for k, v := range obj {
+				row[k] = valueToString(v)
+			}
+
+			return row, nil
+		}
+
+		s.line++
+
+		if readErr == io.EOF {
+			return nil, io.EOF
+		}
+	}
+}
+
+// -------------------------------------------------------------------
+
+// xlsxImportSource is a single sheet XLSX importSource.
+type xlsxImportSource struct {
+	rows    *xlsx.Rows
+	sheets  []string
+	headers []string
+}
+
+func newXLSXImportSource(r io.ReaderAt, size int64, sheet string, headerRow int) (*xlsxImportSource, error) {
+	reader, err := xlsx.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+	}
+
+	rows, err := reader.Rows(sheet)
+	if err != nil {
+		if errors.Is(err, xlsx.ErrSheetNotFound) {
+			return nil, fmt.Errorf("XLSX sheet %q not found (available sheets: %s)", sheet, strings.Join(reader.Sheets(), ", "))
+		}
+		return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+	}
+
+	if headerRow <= 0 {
+		headerRow = 1
+	}
+
+	// skip the rows before the header
+	var header []any
+	for i := 1; i <= headerRow; i++ {
+		header, err = rows.Next()
+		if err != nil {
+			rows.Close()
+			if err == io.EOF {
+				return nil, errors.New("XLSX sheet is empty")
+			}
+			return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+		}
+	}
+
+	headers := make([]string, len(header))
+	for i, v := range header {
+		headers[i] = strings.TrimSpace(xlsxCellToString(v))
+		if headers[i] == "" {
+			// fallback to the column letter
+			headers[i] = xlsx.ColumnName(i)
+		}
+	}
+
+	if len(headers) == 0 {
+		rows.Close()
+		return nil, fmt.Errorf("XLSX header row %d is empty", headerRow)
+	}
+
+	return &xlsxImportSource{rows: rows, sheets: reader.Sheets(), headers: headers}, nil
+}
+
+// Headers returns the sheet header columns.
+func (s *xlsxImportSource) Headers() []string {
+	return s.headers
+}
+
+// Sheets returns the names of all workbook sheets.
+func (s *xlsxImportSource) Sheets() []string {
+	return s.sheets
+}
+
+// Next implements [importSource.Next] interface method.
+//
+// Empty rows are skipped.
+func (s *xlsxImportSource) Next() (map[string]string, error) {
+	for {
+		values, err := s.rows.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+		}
+
+		row := make(map[string]string, len(s.headers))
+
+		var hasValue bool
+		for i, h := range s.headers {
+			if i >= len(values) {
+				break
+			}
+
+			v := xlsxCellToString(values[i])
+			if v != "" {
+				hasValue = true
+			}
+			row[h] = v
+		}
+
+		if hasValue {
+			return row, nil
+		}
+	}
+}
+
+// Close implements [io.Closer] interface method.
+func (s *xlsxImportSource) Close() error {
+	return s.rows.Close()
+}
+
+// xlsxCellToString converts a typed XLSX cell value to its import string representation.
+func xlsxCellToString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.UTC().Format(types.DefaultDateLayout)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// -------------------------------------------------------------------
+
+// rowsImportSource is an importSource for already parsed headers and rows.
+type rowsImportSource struct {
+	headers []string
+	rows    [][]string
+	pos     int
+}
+
+func newRowsImportSource(headers []string, rows [][]string) *rowsImportSource {
+	return &rowsImportSource{headers: headers, rows: rows}
+}
+
+// Headers returns the source header columns.
+func (s *rowsImportSource) Headers() []string {
+	return s.headers
+}
+
+// Next implements [importSource.Next] interface method.
+func (s *rowsImportSource) Next() (map[string]string, error) {
+	if s.pos >= len(s.rows) {
+		return nil, io.EOF
+	}
+
+	record := s.rows[s.pos]
+	s.pos++
+
+	row := make(map[string]string, len(s.headers))
+	for i, h := range s.headers {
+		if i < len(record) {
+			row[h] = record[i]
+		}
+	}
+
+	return row, nil
+}
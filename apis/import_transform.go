@@ -0,0 +1,300 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/spf13/cast"
+)
+
+// Supported import field transform types.
+const (
+	// ImportTransformRelation resolves the relation record ids by the
+	// value of a related collection field (eg. users.email).
+	ImportTransformRelation = "relation"
+
+	// ImportTransformSplit splits a delimited value into a list
+	// (eg. for multi-select or multi-file fields).
+	ImportTransformSplit = "split"
+
+	// ImportTransformDate parses a date with a custom Go time layout.
+	ImportTransformDate = "date"
+
+	// ImportTransformBool parses a boolean from a list of truthy/falsy values.
+	ImportTransformBool = "bool"
+
+	// ImportTransformJSON parses the value as JSON.
+	ImportTransformJSON = "json"
+)
+
+// DefaultImportSeparator is the default split separator of the multiple values.
+const DefaultImportSeparator = ","
+
+var (
+	defaultImportTrueValues  = []string{"true", "1", "yes", "y", "on"}
+	defaultImportFalseValues = []string{"false", "0", "no", "n", "off"}
+)
+
+// ImportTransform defines how a single mapped value is converted
+// before it is assigned to its target record field.
+type ImportTransform struct {
+	// Type is one of the ImportTransform* constants.
+	Type string `json:"type"`
+
+	// LookupField is the related collection field to resolve the
+	// relation records by (relation transform only, default to "id").
+	LookupField string `json:"lookupField,omitempty"`
+
+	// Separator is the delimiter of the multiple values
+	// (split and multiple relation transforms, default to ",").
+	Separator string `json:"separator,omitempty"`
+
+	// Layout is the Go time layout of the date value,
+	// eg. "02/01/2006" or "2006-01-02 15:04" (date transform only).
+	Layout string `json:"layout,omitempty"`
+
+	// Timezone is an optional IANA timezone name used for dates without
+	// explicit offset (date transform only, default to UTC).
+	Timezone string `json:"timezone,omitempty"`
+
+	// TrueValues and FalseValues are the case-insensitive values
+	// recognized as true or false (bool transform only, default to
+	// "true/1/yes/y/on" and "false/0/no/n/off").
+	TrueValues  []string `json:"trueValues,omitempty"`
+	FalseValues []string `json:"falseValues,omitempty"`
+}
+
+// validateImportTransforms checks whether the transforms (target field -> transform)
+// are applicable to the fields of the specified collection.
+func validateImportTransforms(app core.App, collection *core.Collection, transforms map[string]ImportTransform) error {
+	errs := validation.Errors{}
+
+	for fieldName, transform := range transforms {
+		if err := validateImportTransform(app, collection, fieldName, transform); err != nil {
+			errs[fieldName] = validation.NewError("validation_invalid_import_transform", err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func validateImportTransform(app core.App, collection *core.Collection, fieldName string, transform ImportTransform) error {
+	field := collection.Fields.GetByName(fieldName)
+	if field == nil {
+		return errors.New("the field does not exist in collection")
+	}
+
+	switch transform.Type {
+	case ImportTransformRelation:
+		relField, ok := field.(*core.RelationField)
+		if !ok {
+			return errors.New("the relation transform can be used only with relation fields")
+		}
+
+		if transform.LookupField == "" || transform.LookupField == core.FieldNameId {
+			return nil
+		}
+
+		relCollection, err := app.FindCachedCollectionByNameOrId(relField.CollectionId)
+		if err != nil {
+			return errors.New("missing related collection")
+		}
+
+		if relCollection.Fields.GetByName(transform.LookupField) == nil {
+			return fmt.Errorf("lookup field %q does not exist in collection %q", transform.LookupField, relCollection.Name)
+		}
+	case ImportTransformSplit:
+		if _, ok := field.(*core.JSONField); ok {
+			return nil
+		}
+
+		multiple, ok := field.(interface{ IsMultiple() bool })
+		if !ok || !multiple.IsMultiple() {
+			return errors.New("the split transform can be used only with multiple value or json fields")
+		}
+	case ImportTransformDate:
+		if transform.Layout == "" {
+			return errors.New("the date transform layout is required")
+		}
+
+		if transform.Timezone != "" {
+			if _, err := time.LoadLocation(transform.Timezone); err != nil {
+				return fmt.Errorf("invalid timezone %q", transform.Timezone)
+			}
+		}
+	case ImportTransformBool, ImportTransformJSON:
+	default:
+		return fmt.Errorf("unknown transform type %q", transform.Type)
+	}
+
+	return nil
+}
+
+// applyImportTransforms converts in place the recordData values that have a transform.
+//
+// It returns [validation.Errors] with the fields that couldn't be converted.
+func applyImportTransforms(app core.App, collection *core.Collection, recordData map[string]any, transforms map[string]ImportTransform) error {
+	errs := validation.Errors{}
+
+	for fieldName, transform := range transforms {
+		raw, ok := recordData[fieldName]
+		if !ok {
+			continue
+		}
+
+		value, err := applyImportTransform(app, collection, fieldName, transform, cast.ToString(raw))
+		if err != nil {
+			errs[fieldName] = err
+			continue
+		}
+
+		recordData[fieldName] = value
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func applyImportTransform(app core.App, collection *core.Collection, fieldName string, transform ImportTransform, value string) (any, error) {
+	switch transform.Type {
+	case ImportTransformRelation:
+		relField, ok := collection.Fields.GetByName(fieldName).(*core.RelationField)
+		if !ok {
+			return nil, errors.New("not a relation field")
+		}
+
+		values := []string{value}
+		if relField.IsMultiple() {
+			values = splitImportValue(value, transform.Separator)
+		}
+
+		return resolveImportRelation(app, relField, transform.LookupField, values)
+	case ImportTransformSplit:
+		return splitImportValue(value, transform.Separator), nil
+	case ImportTransformDate:
+		loc := time.UTC
+		if transform.Timezone != "" {
+			var err error
+			if loc, err = time.LoadLocation(transform.Timezone); err != nil {
+				return nil, err
+			}
+		}
+
+		t, err := time.ParseInLocation(transform.Layout, value, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%q doesn't match the date layout %q", value, transform.Layout)
+		}
+
+		return types.ParseDateTime(t)
+	case ImportTransformBool:
+		trueValues := transform.TrueValues
+		if len(trueValues) == 0 {
+			trueValues = defaultImportTrueValues
+		}
+
+		falseValues := transform.FalseValues
+		if len(falseValues) == 0 {
+			falseValues = defaultImportFalseValues
+		}
+
+		match := func(v string) bool { return strings.EqualFold(v, value) }
+
+		switch {
+		case slices.ContainsFunc(trueValues, match):
+			return true, nil
+		case slices.ContainsFunc(falseValues, match):
+			return false, nil
+		default:
+			return nil, fmt.Errorf("%q is not a recognized boolean value", value)
+		}
+	case ImportTransformJSON:
+		if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("%q is not a valid JSON", value)
+		}
+
+		return types.JSONRaw(value), nil
+	default:
+		return nil, fmt.Errorf("unknown transform type %q", transform.Type)
+	}
+}
+
+// resolveImportRelation returns the ids of the related records matching the lookup values.
+//
+// Each lookup value must match exactly one related record.
+func resolveImportRelation(app core.App, relField *core.RelationField, lookupField string, values []string) (any, error) {
+	if lookupField == "" {
+		lookupField = core.FieldNameId
+	}
+
+	relCollection, err := app.FindCachedCollectionByNameOrId(relField.CollectionId)
+	if err != nil {
+		return nil, errors.New("missing related collection")
+	}
+
+	ids := make([]string, 0, len(values))
+
+	for _, v := range values {
+		records := []*core.Record{}
+
+		err := app.RecordQuery(relCollection).
+			AndWhere(dbx.HashExp{lookupField: v}).
+			Limit(2).
+			All(&records)
+		if err != nil {
+			return nil, err
+		}
+
+		switch len(records) {
+		case 0:
+			return nil, fmt.Errorf("missing %s record with %s %q", relCollection.Name, lookupField, v)
+		case 1:
+			ids = append(ids, records[0].Id)
+		default:
+			return nil, fmt.Errorf("multiple %s records match %s %q", relCollection.Name, lookupField, v)
+		}
+	}
+
+	if relField.IsMultiple() {
+		return ids, nil
+	}
+
+	if len(ids) == 0 {
+		return "", nil
+	}
+
+	return ids[0], nil
+}
+
+// splitImportValue splits a delimited value into a list of trimmed non-empty items.
+func splitImportValue(value string, separator string) []string {
+	if separator == "" {
+		separator = DefaultImportSeparator
+	}
+
+	parts := strings.Split(value, separator)
+
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
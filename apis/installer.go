@@ -22,7 +22,7 @@ import (
 //
 // See https://github.com/pocketbase/pocketbase/discussions/5814.
 func DefaultInstallerFunc(app core.App, systemSuperuser *core.Record, baseURL string) error {
-	token, err := systemSuperuser.NewStaticAuthToken(30 * time.Minute)
+	token, err := app.NewRecordStaticAuthToken(systemSuperuser, 30*time.Minute)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,52 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// bindJWKSApi registers the public JWK set endpoint.
+//
+// Note that the endpoint is registered in the root router group
+// and not under /api since its path is defined by convention.
+func bindJWKSApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	rg.GET("/.well-known/jwks.json", jwksList)
+}
+
+// jwksList returns the public keys of the asymmetric auth tokens
+// signing keyset (including the retired keys that are still accepted for verification).
+func jwksList(e *core.RequestEvent) error {
+	keys, err := e.App.FindAllJWTKeys()
+	if err != nil {
+		return e.InternalServerError("Failed to load the signing keys.", err)
+	}
+
+	overlap := e.App.Settings().JWT.OverlapDuration()
+
+	result := struct {
+		Keys []*security.JWK `json:"keys"`
+	}{
+		Keys: make([]*security.JWK, 0, len(keys)),
+	}
+
+	for _, key := range keys {
+		if key.HasExpired(overlap) {
+			continue
+		}
+
+		jwk, err := key.JWK()
+		if err != nil {
+			e.App.Logger().Warn("Failed to export JWT signing key", "kid", key.Id, "error", err)
+			continue
+		}
+
+		result.Keys = append(result.Keys, jwk)
+	}
+
+	e.Response.Header().Set("Cache-Control", "public, max-age=300")
+
+	return e.JSON(http.StatusOK, result)
+}
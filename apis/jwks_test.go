@@ -0,0 +1,73 @@
+package apis_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestJWKSList(t *testing.T) {
+	t.Parallel()
+
+	createKey := func(t testing.TB, app *tests.TestApp, id string, alg string, retired types.DateTime) {
+		key := core.NewJWTKey(app)
+		key.Id = id
+		key.SetRetired(retired)
+		if err := key.RefreshKeyPair(alg); err != nil {
+			t.Fatal(err)
+		}
+		if err := app.Save(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "no keys",
+			Method:         http.MethodGet,
+			URL:            "/.well-known/jwks.json",
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`{"keys":[]}`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "active, retired and expired keys",
+			Method: http.MethodGet,
+			URL:    "/.well-known/jwks.json",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.Settings().JWT.Algorithm = "ES256"
+				app.Settings().JWT.OverlapDays = 1
+
+				createKey(t, app, "jwkactive000000", "ES256", types.DateTime{})
+				createKey(t, app, "jwkretired00000", "EdDSA", types.NowDateTime().Add(-1*time.Hour))
+				createKey(t, app, "jwkexpired00000", "RS256", types.NowDateTime().Add(-25*time.Hour))
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"kid":"jwkactive000000"`,
+				`"kty":"EC"`,
+				`"alg":"ES256"`,
+				`"kid":"jwkretired00000"`,
+				`"kty":"OKP"`,
+				`"alg":"EdDSA"`,
+				`"use":"sig"`,
+			},
+			NotExpectedContent: []string{
+				"jwkexpired00000",
+				"privateKey",
+				`"d":`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -1,9 +1,13 @@
 package apis
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pocketbase/dbx"
@@ -21,8 +25,24 @@ func bindMetricsApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	sub.GET("/errors", metricsErrors)
 	sub.GET("/endpoints", metricsEndpoints)
 	sub.GET("/collections", metricsCollections)
+	sub.GET("/export", metricsExport)
 }
 
+// supported metrics export series
+const (
+	MetricsSeriesRequests    = "requests"
+	MetricsSeriesLatency     = "latency"
+	MetricsSeriesErrors      = "errors"
+	MetricsSeriesEndpoints   = "endpoints"
+	MetricsSeriesCollections = "collections"
+)
+
+// supported metrics export formats
+const (
+	MetricsExportFormatCSV  = "csv"
+	MetricsExportFormatJSON = "json"
+)
+
 // OverviewResponse represents the overview metrics response.
 type OverviewResponse struct {
 	TotalRequests int64   `json:"totalRequests"`
@@ -31,6 +51,10 @@ type OverviewResponse struct {
 	DatabaseSize  int64   `json:"databaseSize"`
 	TotalErrors   int64   `json:"totalErrors"`
 	Period        string  `json:"period"`
+
+	From     types.DateTime `json:"from"`
+	To       types.DateTime `json:"to"`
+	Timezone string         `json:"timezone"`
 }
 
 // RequestsTimeSeriesItem represents a single point in the requests time-series.
@@ -72,28 +96,23 @@ type CollectionStats struct {
 
 // metricsOverview returns overview statistics.
 // GET /api/metrics/overview?period=24h
+// GET /api/metrics/overview?from=2024-01-01&to=2024-02-01&tz=Europe/Sofia
 func metricsOverview(e *core.RequestEvent) error {
-	period := e.Request.URL.Query().Get("period")
-	if period == "" {
-		period = "24h"
-	}
-
-	duration, err := parsePeriod(period)
+	r, err := parseMetricsRange(e)
 	if err != nil {
-		return e.BadRequestError("Invalid period format. Use: 1h, 6h, 24h, 7d", err)
+		return e.BadRequestError("Invalid period format. Use: 1h, 6h, 24h, 7d or from/to dates", err)
 	}
 
-	since := time.Now().UTC().Add(-duration)
-	sinceStr := since.Format(types.DefaultDateLayout)
+	params := r.params()
 
 	// Get total requests count
 	var totalRequests int64
 	err = e.App.AuxDB().NewQuery(`
 		SELECT COUNT(*) 
 		FROM {{_logs}} 
-		WHERE [[created]] >= {:since}
+		WHERE [[created]] >= {:from} AND [[created]] < {:to}
 		AND json_extract([[data]], '$.type') = 'request'
-	`).Bind(dbx.Params{"since": sinceStr}).Row(&totalRequests)
+	`).Bind(params).Row(&totalRequests)
 	if err != nil {
 		totalRequests = 0
 	}
@@ -103,10 +122,10 @@ func metricsOverview(e *core.RequestEvent) error {
 	err = e.App.AuxDB().NewQuery(`
 		SELECT COALESCE(AVG(json_extract([[data]], '$.execTime')), 0)
 		FROM {{_logs}} 
-		WHERE [[created]] >= {:since}
+		WHERE [[created]] >= {:from} AND [[created]] < {:to}
 		AND json_extract([[data]], '$.type') = 'request'
 		AND json_extract([[data]], '$.execTime') IS NOT NULL
-	`).Bind(dbx.Params{"since": sinceStr}).Row(&avgLatency)
+	`).Bind(params).Row(&avgLatency)
 	if err != nil {
 		avgLatency = 0
 	}
@@ -116,10 +135,10 @@ func metricsOverview(e *core.RequestEvent) error {
 	err = e.App.AuxDB().NewQuery(`
 		SELECT COUNT(*)
 		FROM {{_logs}} 
-		WHERE [[created]] >= {:since}
+		WHERE [[created]] >= {:from} AND [[created]] < {:to}
 		AND json_extract([[data]], '$.type') = 'request'
 		AND json_extract([[data]], '$.status') >= 400
-	`).Bind(dbx.Params{"since": sinceStr}).Row(&totalErrors)
+	`).Bind(params).Row(&totalErrors)
 	if err != nil {
 		totalErrors = 0
 	}
@@ -142,13 +161,19 @@ func metricsOverview(e *core.RequestEvent) error {
 	}
 	databaseSize := pageCount * pageSize
 
+	from, _ := types.ParseDateTime(r.From.UTC())
+	to, _ := types.ParseDateTime(r.To.UTC())
+
 	response := OverviewResponse{
 		TotalRequests: totalRequests,
 		AvgLatency:    avgLatency,
 		ErrorRate:     errorRate,
 		DatabaseSize:  databaseSize,
 		TotalErrors:   totalErrors,
-		Period:        period,
+		Period:        r.Period,
+		From:          from,
+		To:            to,
+		Timezone:      r.Location.String(),
 	}
 
 	return e.JSON(http.StatusOK, response)
@@ -157,92 +182,331 @@ func metricsOverview(e *core.RequestEvent) error {
 // metricsRequests returns time-series data of requests.
 // GET /api/metrics/requests?period=24h&interval=1h
 func metricsRequests(e *core.RequestEvent) error {
-	period := e.Request.URL.Query().Get("period")
-	if period == "" {
-		period = "24h"
+	r, err := parseMetricsRange(e)
+	if err != nil {
+		return e.BadRequestError("Invalid period format", err)
 	}
 
-	interval := e.Request.URL.Query().Get("interval")
-	if interval == "" {
-		interval = "1h"
+	interval, err := metricsInterval(e, r)
+	if err != nil {
+		return e.BadRequestError("Invalid interval", err)
 	}
 
-	duration, err := parsePeriod(period)
+	result, err := findRequestsSeries(e.App, r, interval)
+	if err != nil {
+		return e.BadRequestError("Failed to fetch requests data", err)
+	}
+
+	return e.JSON(http.StatusOK, r.info(map[string]any{
+		"data":     result,
+		"interval": interval,
+	}))
+}
+
+// metricsLatency returns latency percentiles over time.
+// GET /api/metrics/latency?period=24h&interval=1h
+func metricsLatency(e *core.RequestEvent) error {
+	r, err := parseMetricsRange(e)
 	if err != nil {
 		return e.BadRequestError("Invalid period format", err)
 	}
 
-	since := time.Now().UTC().Add(-duration)
-	sinceStr := since.Format(types.DefaultDateLayout)
+	interval, err := metricsInterval(e, r)
+	if err != nil {
+		return e.BadRequestError("Invalid interval", err)
+	}
 
-	// Determine grouping format based on interval
-	groupFormat := getGroupFormat(interval)
+	result, err := findLatencySeries(e.App, r, interval)
+	if err != nil {
+		return e.BadRequestError("Failed to fetch latency data", err)
+	}
 
-	var result []RequestsTimeSeriesItem
-	err = e.App.AuxDB().NewQuery(`
-		SELECT 
-			strftime('` + groupFormat + `', [[created]]) as date,
-			COUNT(*) as total
-		FROM {{_logs}}
-		WHERE [[created]] >= {:since}
-		AND json_extract([[data]], '$.type') = 'request'
-		GROUP BY date
-		ORDER BY date ASC
-	`).Bind(dbx.Params{"since": sinceStr}).All(&result)
+	return e.JSON(http.StatusOK, r.info(map[string]any{
+		"data":     result,
+		"interval": interval,
+	}))
+}
 
+// metricsErrors returns error counts by status code over time.
+// GET /api/metrics/errors?period=24h&interval=1h
+func metricsErrors(e *core.RequestEvent) error {
+	r, err := parseMetricsRange(e)
 	if err != nil {
-		return e.BadRequestError("Failed to fetch requests data", err)
+		return e.BadRequestError("Invalid period format", err)
 	}
 
-	return e.JSON(http.StatusOK, map[string]any{
+	interval, err := metricsInterval(e, r)
+	if err != nil {
+		return e.BadRequestError("Invalid interval", err)
+	}
+
+	result, err := findErrorsSeries(e.App, r, interval)
+	if err != nil {
+		return e.BadRequestError("Failed to fetch errors data", err)
+	}
+
+	return e.JSON(http.StatusOK, r.info(map[string]any{
 		"data":     result,
-		"period":   period,
 		"interval": interval,
-	})
+	}))
 }
 
-// metricsLatency returns latency percentiles over time.
-// GET /api/metrics/latency?period=24h&interval=1h
-func metricsLatency(e *core.RequestEvent) error {
-	period := e.Request.URL.Query().Get("period")
-	if period == "" {
-		period = "24h"
+// metricsEndpoints returns top endpoints by request count.
+// GET /api/metrics/endpoints?period=24h&limit=10
+func metricsEndpoints(e *core.RequestEvent) error {
+	r, err := parseMetricsRange(e)
+	if err != nil {
+		return e.BadRequestError("Invalid period format", err)
 	}
 
-	interval := e.Request.URL.Query().Get("interval")
-	if interval == "" {
-		interval = "1h"
+	limit := metricsLimit(e)
+
+	result, err := findEndpointsStats(e.App, r, limit)
+	if err != nil {
+		return e.BadRequestError("Failed to fetch endpoints data", err)
 	}
 
-	duration, err := parsePeriod(period)
+	return e.JSON(http.StatusOK, r.info(map[string]any{
+		"data":  result,
+		"limit": limit,
+	}))
+}
+
+// metricsCollections returns record counts for all collections.
+// GET /api/metrics/collections
+func metricsCollections(e *core.RequestEvent) error {
+	result, err := findCollectionsStats(e.App)
+	if err != nil {
+		return e.BadRequestError("Failed to fetch collections", err)
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"data":  result,
+		"total": len(result),
+	})
+}
+
+// metricsExport exports a single metrics series as CSV or JSON file.
+//
+// The series data is the same as the one returned by the related
+// series endpoint, and the time range, interval and limit query
+// parameters are also the same.
+//
+// GET /api/metrics/export?series=requests&format=csv&from=2024-01-01&to=2024-02-01&tz=Europe/Sofia&interval=1d
+func metricsExport(e *core.RequestEvent) error {
+	query := e.Request.URL.Query()
+
+	series := query.Get("series")
+
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = MetricsExportFormatCSV
+	}
+	if format != MetricsExportFormatCSV && format != MetricsExportFormatJSON {
+		return e.BadRequestError("Invalid export format. Use: csv or json", nil)
+	}
+
+	r, err := parseMetricsRange(e)
 	if err != nil {
 		return e.BadRequestError("Invalid period format", err)
 	}
 
-	since := time.Now().UTC().Add(-duration)
-	sinceStr := since.Format(types.DefaultDateLayout)
+	interval, err := metricsInterval(e, r)
+	if err != nil {
+		return e.BadRequestError("Invalid interval", err)
+	}
+
+	var data any
+	switch series {
+	case MetricsSeriesRequests:
+		data, err = findRequestsSeries(e.App, r, interval)
+	case MetricsSeriesLatency:
+		data, err = findLatencySeries(e.App, r, interval)
+	case MetricsSeriesErrors:
+		data, err = findErrorsSeries(e.App, r, interval)
+	case MetricsSeriesEndpoints:
+		data, err = findEndpointsStats(e.App, r, metricsLimit(e))
+	case MetricsSeriesCollections:
+		data, err = findCollectionsStats(e.App)
+	default:
+		return e.BadRequestError("Invalid series. Use: requests, latency, errors, endpoints or collections", nil)
+	}
+	if err != nil {
+		return e.BadRequestError("Failed to fetch "+series+" data", err)
+	}
+
+	filename := fmt.Sprintf(
+		"metrics_%s_%s_%s.%s",
+		series,
+		r.From.In(r.Location).Format("20060102"),
+		r.To.In(r.Location).Format("20060102"),
+		format,
+	)
+	e.Response.Header().Set("Content-Disposition", "attachment; filename="+filename)
+
+	if format == MetricsExportFormatJSON {
+		return e.JSON(http.StatusOK, r.info(map[string]any{
+			"series":   series,
+			"interval": interval,
+			"data":     data,
+		}))
+	}
+
+	e.Response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	e.Response.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(e.Response)
+
+	if err := writeMetricsCSV(w, data, r.Location); err != nil {
+		return err
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+// writeMetricsCSV writes the provided metrics series data as CSV
+// header and rows with dates formatted as RFC3339 in loc.
+//
+// The rows are written one by one (and periodically flushed to the client)
+// instead of building the entire CSV document in memory.
+func writeMetricsCSV(w *csv.Writer, data any, loc *time.Location) error {
+	formatDate := func(d types.DateTime) string {
+		if d.IsZero() {
+			return ""
+		}
+		return d.Time().In(loc).Format(time.RFC3339)
+	}
+
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	var rows int
+	write := func(record ...string) error {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+
+		// periodically flush the buffered rows to the client
+		rows++
+		if rows%500 == 0 {
+			w.Flush()
+			return w.Error()
+		}
+
+		return nil
+	}
+
+	switch v := data.(type) {
+	case []RequestsTimeSeriesItem:
+		if err := write("date", "total"); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := write(formatDate(item.Date), strconv.Itoa(item.Total)); err != nil {
+				return err
+			}
+		}
+	case []LatencyTimeSeriesItem:
+		if err := write("date", "avg", "p50", "p95", "p99"); err != nil {
+			return err
+		}
+		for _, item := range v {
+			err := write(
+				formatDate(item.Date),
+				formatFloat(item.Avg),
+				formatFloat(item.P50),
+				formatFloat(item.P95),
+				formatFloat(item.P99),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	case []ErrorsTimeSeriesItem:
+		if err := write("date", "status4xx", "status5xx", "totalErrors"); err != nil {
+			return err
+		}
+		for _, item := range v {
+			err := write(
+				formatDate(item.Date),
+				strconv.Itoa(item.Status4xx),
+				strconv.Itoa(item.Status5xx),
+				strconv.Itoa(item.TotalErrors),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	case []EndpointStats:
+		if err := write("endpoint", "count", "avgLatency"); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := write(item.Endpoint, strconv.Itoa(item.Count), formatFloat(item.AvgLatency)); err != nil {
+				return err
+			}
+		}
+	case []CollectionStats:
+		if err := write("name", "type", "recordCount"); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := write(item.Name, item.Type, strconv.FormatInt(item.RecordCount, 10)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// findRequestsSeries returns the number of requests within r grouped by interval.
+func findRequestsSeries(app core.App, r metricsRange, interval string) ([]RequestsTimeSeriesItem, error) {
+	var result []RequestsTimeSeriesItem
+	err := app.AuxDB().NewQuery(`
+		SELECT 
+			` + getGroupExpr(interval, r) + ` as date,
+			COUNT(*) as total
+		FROM {{_logs}}
+		WHERE [[created]] >= {:from} AND [[created]] < {:to}
+		AND json_extract([[data]], '$.type') = 'request'
+		GROUP BY date
+		ORDER BY date ASC
+	`).Bind(r.params()).All(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result {
+		result[i].Date = r.bucketDate(result[i].Date)
+	}
 
-	groupFormat := getGroupFormat(interval)
+	return result, nil
+}
 
+// findLatencySeries returns the request latency percentiles within r grouped by interval.
+func findLatencySeries(app core.App, r metricsRange, interval string) ([]LatencyTimeSeriesItem, error) {
 	// First, get all latency values grouped by time period
 	type rawLatency struct {
 		Date     string  `db:"date"`
 		ExecTime float64 `db:"exec_time"`
 	}
 	var rawData []rawLatency
-	err = e.App.AuxDB().NewQuery(`
+	err := app.AuxDB().NewQuery(`
 		SELECT 
-			strftime('` + groupFormat + `', [[created]]) as date,
+			` + getGroupExpr(interval, r) + ` as date,
 			json_extract([[data]], '$.execTime') as exec_time
 		FROM {{_logs}}
-		WHERE [[created]] >= {:since}
+		WHERE [[created]] >= {:from} AND [[created]] < {:to}
 		AND json_extract([[data]], '$.type') = 'request'
 		AND json_extract([[data]], '$.execTime') IS NOT NULL
 		ORDER BY date ASC
-	`).Bind(dbx.Params{"since": sinceStr}).All(&rawData)
-
+	`).Bind(r.params()).All(&rawData)
 	if err != nil {
-		return e.BadRequestError("Failed to fetch latency data", err)
+		return nil, err
 	}
 
 	// Group by date and calculate percentiles
@@ -273,7 +537,7 @@ func metricsLatency(e *core.RequestEvent) error {
 
 		parsedDate, _ := types.ParseDateTime(date)
 		result = append(result, LatencyTimeSeriesItem{
-			Date: parsedDate,
+			Date: r.bucketDate(parsedDate),
 			Avg:  avg,
 			P50:  p50,
 			P95:  p95,
@@ -286,117 +550,66 @@ func metricsLatency(e *core.RequestEvent) error {
 		return result[i].Date.Time().Before(result[j].Date.Time())
 	})
 
-	return e.JSON(http.StatusOK, map[string]any{
-		"data":     result,
-		"period":   period,
-		"interval": interval,
-	})
+	return result, nil
 }
 
-// metricsErrors returns error counts by status code over time.
-// GET /api/metrics/errors?period=24h&interval=1h
-func metricsErrors(e *core.RequestEvent) error {
-	period := e.Request.URL.Query().Get("period")
-	if period == "" {
-		period = "24h"
-	}
-
-	interval := e.Request.URL.Query().Get("interval")
-	if interval == "" {
-		interval = "1h"
-	}
-
-	duration, err := parsePeriod(period)
-	if err != nil {
-		return e.BadRequestError("Invalid period format", err)
-	}
-
-	since := time.Now().UTC().Add(-duration)
-	sinceStr := since.Format(types.DefaultDateLayout)
-
-	groupFormat := getGroupFormat(interval)
-
+// findErrorsSeries returns the 4xx and 5xx request counts within r grouped by interval.
+func findErrorsSeries(app core.App, r metricsRange, interval string) ([]ErrorsTimeSeriesItem, error) {
 	var result []ErrorsTimeSeriesItem
-	err = e.App.AuxDB().NewQuery(`
+	err := app.AuxDB().NewQuery(`
 		SELECT 
-			strftime('` + groupFormat + `', [[created]]) as date,
+			` + getGroupExpr(interval, r) + ` as date,
 			SUM(CASE WHEN json_extract([[data]], '$.status') >= 400 AND json_extract([[data]], '$.status') < 500 THEN 1 ELSE 0 END) as status4xx,
 			SUM(CASE WHEN json_extract([[data]], '$.status') >= 500 THEN 1 ELSE 0 END) as status5xx,
 			SUM(CASE WHEN json_extract([[data]], '$.status') >= 400 THEN 1 ELSE 0 END) as total_errors
 		FROM {{_logs}}
-		WHERE [[created]] >= {:since}
+		WHERE [[created]] >= {:from} AND [[created]] < {:to}
 		AND json_extract([[data]], '$.type') = 'request'
 		GROUP BY date
 		ORDER BY date ASC
-	`).Bind(dbx.Params{"since": sinceStr}).All(&result)
-
+	`).Bind(r.params()).All(&result)
 	if err != nil {
-		return e.BadRequestError("Failed to fetch errors data", err)
-	}
-
-	return e.JSON(http.StatusOK, map[string]any{
-		"data":     result,
-		"period":   period,
-		"interval": interval,
-	})
-}
-
-// metricsEndpoints returns top endpoints by request count.
-// GET /api/metrics/endpoints?period=24h&limit=10
-func metricsEndpoints(e *core.RequestEvent) error {
-	period := e.Request.URL.Query().Get("period")
-	if period == "" {
-		period = "24h"
+		return nil, err
 	}
 
-	limitStr := e.Request.URL.Query().Get("limit")
-	limit := 10
-	if limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
-			limit = parsed
-		}
+	for i := range result {
+		result[i].Date = r.bucketDate(result[i].Date)
 	}
 
-	duration, err := parsePeriod(period)
-	if err != nil {
-		return e.BadRequestError("Invalid period format", err)
-	}
+	return result, nil
+}
 
-	since := time.Now().UTC().Add(-duration)
-	sinceStr := since.Format(types.DefaultDateLayout)
+// findEndpointsStats returns the top limit endpoints by request count within r.
+func findEndpointsStats(app core.App, r metricsRange, limit int) ([]EndpointStats, error) {
+	params := r.params()
+	params["limit"] = limit
 
 	var result []EndpointStats
-	err = e.App.AuxDB().NewQuery(`
+	err := app.AuxDB().NewQuery(`
 		SELECT 
 			json_extract([[data]], '$.url') as endpoint,
 			COUNT(*) as count,
 			COALESCE(AVG(json_extract([[data]], '$.execTime')), 0) as avg_latency
 		FROM {{_logs}}
-		WHERE [[created]] >= {:since}
+		WHERE [[created]] >= {:from} AND [[created]] < {:to}
 		AND json_extract([[data]], '$.type') = 'request'
 		AND json_extract([[data]], '$.url') IS NOT NULL
 		GROUP BY endpoint
 		ORDER BY count DESC
 		LIMIT {:limit}
-	`).Bind(dbx.Params{"since": sinceStr, "limit": limit}).All(&result)
-
+	`).Bind(params).All(&result)
 	if err != nil {
-		return e.BadRequestError("Failed to fetch endpoints data", err)
+		return nil, err
 	}
 
-	return e.JSON(http.StatusOK, map[string]any{
-		"data":   result,
-		"period": period,
-		"limit":  limit,
-	})
+	return result, nil
 }
 
-// metricsCollections returns record counts for all collections.
-// GET /api/metrics/collections
-func metricsCollections(e *core.RequestEvent) error {
-	collections, err := e.App.FindAllCollections()
+// findCollectionsStats returns the record counts of all non-system collections.
+func findCollectionsStats(app core.App) ([]CollectionStats, error) {
+	collections, err := app.FindAllCollections()
 	if err != nil {
-		return e.BadRequestError("Failed to fetch collections", err)
+		return nil, err
 	}
 
 	var result []CollectionStats
@@ -406,7 +619,7 @@ func metricsCollections(e *core.RequestEvent) error {
 			continue
 		}
 
-		count, err := e.App.CountRecords(col.Id)
+		count, err := app.CountRecords(col.Id)
 		if err != nil {
 			count = 0
 		}
@@ -423,10 +636,225 @@ func metricsCollections(e *core.RequestEvent) error {
 		return result[i].RecordCount > result[j].RecordCount
 	})
 
-	return e.JSON(http.StatusOK, map[string]any{
-		"data":  result,
-		"total": len(result),
-	})
+	return result, nil
+}
+
+// metricsInterval returns the "interval" query parameter (default to "1h").
+//
+// It returns an error if the interval results in more than
+// [MetricsMaxBuckets] time buckets for the r range.
+func metricsInterval(e *core.RequestEvent, r metricsRange) (string, error) {
+	interval := e.Request.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "1h"
+	}
+
+	if buckets := r.To.Sub(r.From) / getGroupDuration(interval); buckets > MetricsMaxBuckets {
+		return interval, fmt.Errorf("the %s interval results in more than %d time buckets, use a larger interval or a shorter range", interval, MetricsMaxBuckets)
+	}
+
+	return interval, nil
+}
+
+// metricsLimit returns the "limit" query parameter (default to 10).
+func metricsLimit(e *core.RequestEvent) int {
+	limit := 10
+	if limitStr := e.Request.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	return limit
+}
+
+// metricsRangeCustomPeriod is the period value of ranges with explicit from/to dates.
+const metricsRangeCustomPeriod = "custom"
+
+const (
+	// MetricsMaxRange is the max allowed duration of a metrics time range.
+	MetricsMaxRange = 366 * 24 * time.Hour
+
+	// MetricsMaxBuckets is the max allowed number of time buckets
+	// (aka. range duration / interval) of a single metrics series.
+	MetricsMaxBuckets = 10000
+)
+
+// metricsRange describes the [From, To) time window and the
+// timezone used for bucketing of a metrics query.
+type metricsRange struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+	Period   string
+}
+
+// parseMetricsRange resolves the metrics time range from the request query parameters:
+//
+//   - "period" - relative window ending now (eg. "1h", "24h", "7d"; default to "24h")
+//   - "from", "to" - absolute range dates (RFC3339, "2006-01-02 15:04:05" or "2006-01-02");
+//     dates without an explicit offset are in the "tz" timezone and "to" is exclusive
+//   - "tz" - IANA timezone name used for the dates and the time buckets (default to "UTC")
+//
+// When only "from" is set the range ends now, and when only "to" is set
+// the range starts "period" before it.
+//
+// The range must not be longer than [MetricsMaxRange].
+func parseMetricsRange(e *core.RequestEvent) (metricsRange, error) {
+	query := e.Request.URL.Query()
+
+	r := metricsRange{
+		Location: time.UTC,
+		Period:   query.Get("period"),
+	}
+
+	if tz := query.Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return r, err
+		}
+		r.Location = loc
+	}
+
+	rawFrom := query.Get("from")
+	rawTo := query.Get("to")
+
+	r.To = time.Now().UTC()
+	if rawTo != "" {
+		to, err := parseMetricsDate(rawTo, r.Location)
+		if err != nil {
+			return r, err
+		}
+		r.To = to
+	}
+
+	if rawFrom != "" {
+		from, err := parseMetricsDate(rawFrom, r.Location)
+		if err != nil {
+			return r, err
+		}
+		r.From = from
+		r.Period = metricsRangeCustomPeriod
+	} else {
+		if r.Period == "" {
+			r.Period = "24h"
+		}
+
+		duration, err := parsePeriod(r.Period)
+		if err != nil {
+			return r, err
+		}
+		r.From = r.To.Add(-duration)
+
+		if rawTo != "" {
+			r.Period = metricsRangeCustomPeriod
+		}
+	}
+
+	if !r.From.Before(r.To) {
+		return r, errors.New("the range from date must be before the to date")
+	}
+
+	if r.To.Sub(r.From) > MetricsMaxRange {
+		return r, fmt.Errorf("the range must not be longer than %d days", MetricsMaxRange/(24*time.Hour))
+	}
+
+	return r, nil
+}
+
+// parseMetricsDate parses a single metrics range date.
+//
+// Dates without explicit offset are interpreted in the loc timezone.
+func parseMetricsDate(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.UTC(), nil
+	}
+
+	if t, err := time.Parse(types.DefaultDateLayout, value); err == nil {
+		return t.UTC(), nil
+	}
+
+	layouts := []string{
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02T15:04",
+		"2006-01-02",
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// params returns the range db query params.
+func (r metricsRange) params() dbx.Params {
+	return dbx.Params{
+		"from": r.From.UTC().Format(types.DefaultDateLayout),
+		"to":   r.To.UTC().Format(types.DefaultDateLayout),
+	}
+}
+
+// info returns a shallow copy of data extended with the range response fields.
+func (r metricsRange) info(data map[string]any) map[string]any {
+	result := make(map[string]any, len(data)+4)
+	for k, v := range data {
+		result[k] = v
+	}
+
+	from, _ := types.ParseDateTime(r.From.UTC())
+	to, _ := types.ParseDateTime(r.To.UTC())
+
+	result["period"] = r.Period
+	result["from"] = from
+	result["to"] = to
+	result["timezone"] = r.Location.String()
+
+	return result
+}
+
+// bucketDate converts a local time bucket date (as returned by the
+// [getGroupExpr] expression) to its UTC equivalent.
+func (r metricsRange) bucketDate(local types.DateTime) types.DateTime {
+	if r.Location == time.UTC || local.IsZero() {
+		return local
+	}
+
+	t := local.Time()
+
+	result, _ := types.ParseDateTime(
+		time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, r.Location).UTC(),
+	)
+
+	return result
+}
+
+// metricsZone describes a single r.Location UTC offset period within the range.
+type metricsZone struct {
+	end    time.Time // zero for the last zone
+	offset int       // in seconds
+}
+
+// zones returns the r.Location UTC offset periods (eg. DST changes) within the range.
+func (r metricsRange) zones() []metricsZone {
+	var result []metricsZone
+
+	t := r.From
+	for {
+		local := t.In(r.Location)
+		_, offset := local.Zone()
+		_, end := local.ZoneBounds()
+
+		if end.IsZero() || !end.Before(r.To) {
+			return append(result, metricsZone{offset: offset})
+		}
+
+		result = append(result, metricsZone{end: end, offset: offset})
+		t = end
+	}
 }
 
 // parsePeriod parses a period string like "1h", "24h", "7d" into a time.Duration.
@@ -455,10 +883,57 @@ func parsePeriod(period string) (time.Duration, error) {
 	}
 }
 
+// getGroupExpr returns the SQLite expression that groups the log [[created]]
+// column into interval buckets aligned to the range timezone.
+//
+// Because SQLite doesn't have a timezone database, the UTC offsets
+// of the timezone within the range are precomputed and applied as a strftime
+// modifier (for UTC the modifier is omitted).
+//
+// Each offset change (eg. DST) adds a CASE branch to the modifier but since
+// the range is limited to [MetricsMaxRange] there are only a few of them.
+func getGroupExpr(interval string, r metricsRange) string {
+	modifier := func(offset int) string {
+		return fmt.Sprintf("'%+d seconds'", offset)
+	}
+
+	var sb strings.Builder
+
+	zones := r.zones()
+	if len(zones) == 1 {
+		if zones[0].offset != 0 {
+			sb.WriteString(", ")
+			sb.WriteString(modifier(zones[0].offset))
+		}
+	} else {
+		sb.WriteString(", CASE")
+		for _, z := range zones[:len(zones)-1] {
+			sb.WriteString(" WHEN [[created]] < '")
+			sb.WriteString(z.end.UTC().Format(types.DefaultDateLayout))
+			sb.WriteString("' THEN ")
+			sb.WriteString(modifier(z.offset))
+		}
+		sb.WriteString(" ELSE ")
+		sb.WriteString(modifier(zones[len(zones)-1].offset))
+		sb.WriteString(" END")
+	}
+
+	// multi-minute buckets can't be expressed with a strftime format
+	// so the (local) unix timestamp is floored to the bucket step instead
+	if step := getGroupEpochStep(interval); step > 0 {
+		return fmt.Sprintf(
+			"datetime((CAST(strftime('%%s', [[created]]%s) AS INTEGER) / %d) * %d, 'unixepoch')",
+			sb.String(), step, step,
+		)
+	}
+
+	return "strftime('" + getGroupFormat(interval) + "', [[created]]" + sb.String() + ")"
+}
+
 // getGroupFormat returns the SQLite strftime format based on the interval.
 func getGroupFormat(interval string) string {
 	switch interval {
-	case "1m", "5m", "15m":
+	case "1m":
 		return "%Y-%m-%d %H:%M:00"
 	case "1h":
 		return "%Y-%m-%d %H:00:00"
@@ -469,6 +944,35 @@ func getGroupFormat(interval string) string {
 	}
 }
 
+// getGroupEpochStep returns the bucket size in seconds of the intervals
+// that are grouped by their unix timestamp (0 for the strftime ones).
+func getGroupEpochStep(interval string) int {
+	switch interval {
+	case "5m":
+		return 300
+	case "15m":
+		return 900
+	default:
+		return 0
+	}
+}
+
+// getGroupDuration returns the duration of a single [getGroupExpr] time bucket.
+func getGroupDuration(interval string) time.Duration {
+	if step := getGroupEpochStep(interval); step > 0 {
+		return time.Duration(step) * time.Second
+	}
+
+	switch interval {
+	case "1m":
+		return time.Minute
+	case "1d":
+		return 24 * time.Hour
+	default:
+		return time.Hour
+	}
+}
+
 // percentile calculates the percentile value from a sorted slice.
 func percentile(sorted []float64, p float64) float64 {
 	if len(sorted) == 0 {
@@ -16,6 +16,7 @@ import (
 	"github.com/pocketbase/pocketbase/tools/list"
 	"github.com/pocketbase/pocketbase/tools/router"
 	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/tracing"
 	"github.com/spf13/cast"
 )
 
@@ -193,6 +194,14 @@ func loadAuthToken() *hook.Handler[*core.RequestEvent] {
 				return e.Next()
 			}
 
+			if strings.HasPrefix(token, core.APIKeyPrefix) {
+				if err := loadAPIKeyAuth(e, token); err != nil {
+					e.App.Logger().Debug("loadAuthToken API key failure", "error", err)
+				}
+
+				return e.Next()
+			}
+
 			record, err := e.App.FindAuthRecordByToken(token, core.TokenTypeAuth)
 			if err != nil {
 				e.App.Logger().Debug("loadAuthToken failure", "error", err)
@@ -355,6 +364,10 @@ func logRequest(event *core.RequestEvent, err error) {
 		attrs = append(attrs, slog.Float64("execTime", float64(time.Since(started))/float64(time.Millisecond)))
 	}
 
+	if span := tracing.SpanFromContext(event.Request.Context()); span != nil && span.TraceID().IsValid() {
+		attrs = append(attrs, slog.String("traceId", span.TraceID().String()))
+	}
+
 	if meta := event.Get(RequestEventKeyLogMeta); meta != nil {
 		attrs = append(attrs, slog.Any("meta", meta))
 	}
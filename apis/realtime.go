@@ -33,8 +33,11 @@ func bindRealtimeApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	sub := rg.Group("/realtime")
 	sub.GET("", realtimeConnect).Bind(SkipSuccessActivityLog())
 	sub.POST("", realtimeSetSubscriptions)
+	sub.GET("/ws", realtimeWebSocketConnect).Bind(SkipSuccessActivityLog())
+	bindRealtimeChannelsApi(app, sub)
 
 	bindRealtimeEvents(app)
+	bindRealtimeBackplane(app)
 }
 
 func realtimeConnect(e *core.RequestEvent) error {
@@ -67,9 +70,13 @@ func realtimeConnect(e *core.RequestEvent) error {
 	connectEvent.IdleTimeout = 5 * time.Minute
 
 	return e.App.OnRealtimeConnectRequest().Trigger(connectEvent, func(ce *core.RealtimeConnectRequestEvent) error {
+		// the missed events are replayed once the client subscriptions are set
+		realtimeSetClientLastEventId(ce.Client, ce.Request.Header.Get("Last-Event-ID"))
+
 		// register new subscription client
 		ce.App.SubscriptionsBroker().Register(ce.Client)
 		defer func() {
+			realtimePresenceLeaveAll(e.App, ce.Client)
 			e.App.SubscriptionsBroker().Unregister(ce.Client.Id())
 		}()
 
@@ -122,7 +129,12 @@ func realtimeConnect(e *core.RequestEvent) error {
 				msgEvent.Client = ce.Client
 				msgEvent.Message = &msg
 				msgErr := ce.App.OnRealtimeMessageSend().Trigger(msgEvent, func(me *core.RealtimeMessageEvent) error {
-					err := me.Message.WriteSSE(me.Response, me.Client.Id())
+					eventId := me.Message.Id
+					if eventId == "" {
+						eventId = me.Client.Id()
+					}
+
+					err := me.Message.WriteSSE(me.Response, eventId)
 					if err != nil {
 						return err
 					}
@@ -167,6 +179,7 @@ func (form *realtimeSubscribeForm) validate() error {
 }
 
 // note: in case of reconnect, clients will have to resubmit all subscriptions again
+// (the events missed since the connect Last-Event-ID are replayed after the first submit)
 func realtimeSetSubscriptions(e *core.RequestEvent) error {
 	form := new(realtimeSubscribeForm)
 
@@ -214,6 +227,8 @@ func realtimeSetSubscriptions(e *core.RequestEvent) error {
 		)
 
 		return execAfterSuccessTx(true, e.App, func() error {
+			realtimeReplayClientHistory(e.App, e.Client)
+
 			return e.NoContent(http.StatusNoContent)
 		})
 	})
@@ -322,6 +337,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecord(e.App, realtimeBackplaneCreate, record)
 			}
 
 			return e.Next()
@@ -342,6 +359,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecord(e.App, realtimeBackplaneUpdate, record)
 			}
 
 			return e.Next()
@@ -366,6 +385,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecord(e.App, realtimeBackplaneDelete, record)
 			}
 
 			return e.Next()
@@ -381,7 +402,11 @@ func bindRealtimeEvents(app core.App) {
 			// custom model it'll fail to resolve since the record is already deleted
 			collection := realtimeResolveRecordCollection(e.App, e.Model)
 			if collection != nil {
-				err := realtimeBroadcastDryCacheKey(e.App, getDryCacheKey("delete", e.Model))
+				deleted := realtimeResolveDeletedRecord(e.Model, collection)
+
+				eventId := realtimeHistoryPush(e.App, "delete", deleted)
+
+				err := realtimeBroadcastDryCacheKey(e.App, getDryCacheKey("delete", e.Model), eventId)
 				if err != nil {
 					app.Logger().Debug(
 						"Failed to broadcast record delete",
@@ -390,6 +415,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecord(e.App, realtimeBackplaneDeleteCommit, deleted)
 			}
 
 			return e.Next()
@@ -411,6 +438,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecord(e.App, realtimeBackplaneDeleteRollback, record)
 			}
 
 			return e.Next()
@@ -474,6 +503,23 @@ func realtimeResolveRecordCollection(app core.App, model core.Model) (collection
 	return nil
 }
 
+// realtimeResolveDeletedRecord converts the already deleted model to a Record without querying the db.
+//
+// For custom Record model structs only the record id is populated.
+func realtimeResolveDeletedRecord(model core.Model, collection *core.Collection) *core.Record {
+	switch m := model.(type) {
+	case *core.Record:
+		return m
+	case core.RecordProxy:
+		return m.ProxyRecord()
+	}
+
+	deleted := core.NewRecord(collection)
+	deleted.Id = fmt.Sprint(model.PK())
+
+	return deleted
+}
+
 // recordData represents the broadcasted record subscrition message data.
 type recordData struct {
 	Record any    `json:"record"` /* map or core.Record */
@@ -490,21 +536,18 @@ func realtimeBroadcastRecord(app core.App, action string, record *core.Record, d
 		return errors.New("[broadcastRecord] Record collection not set")
 	}
 
+	// note: the delete events are stored in the history on commit
+	var eventId string
+	if !dryCache {
+		eventId = realtimeHistoryPush(app, action, record)
+	}
+
 	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
 	if len(chunks) == 0 {
 		return nil // no subscribers
 	}
 
-	subscriptionRuleMap := map[string]*string{
-		(collection.Name + "/" + record.Id + "?"): collection.ViewRule,
-		(collection.Id + "/" + record.Id + "?"):   collection.ViewRule,
-		(collection.Name + "/*?"):                 collection.ListRule,
-		(collection.Id + "/*?"):                   collection.ListRule,
-
-		// @deprecated: the same as the wildcard topic but kept for backward compatibility
-		(collection.Name + "?"): collection.ListRule,
-		(collection.Id + "?"):   collection.ListRule,
-	}
+	subscriptionRuleMap := realtimeSubscriptionRuleMap(record)
 
 	dryCacheKey := getDryCacheKey(action, record)
 
@@ -517,8 +560,6 @@ func realtimeBroadcastRecord(app core.App, action string, record *core.Record, d
 
 	for _, chunk := range chunks {
 		group.Go(func() error {
-			var clientAuth *core.Record
-
 			for _, client := range chunk {
 				// note: not executed concurrently to avoid races and to ensure
 				// that the access checks are applied for the current record db state
@@ -528,116 +569,30 @@ func realtimeBroadcastRecord(app core.App, action string, record *core.Record, d
 						continue
 					}
 
-					clientAuth, _ = client.Get(RealtimeClientAuthKey).(*core.Record)
-
 					for sub, options := range subs {
-						// mock request data
-						requestInfo := &core.RequestInfo{
-							Context: core.RequestInfoContextRealtime,
-							Method:  "GET",
-							Query:   options.Query,
-							Headers: options.Headers,
-							Auth:    clientAuth,
-						}
+						requestInfo := realtimeSubscriptionRequestInfo(client, options)
 
 						if !realtimeCanAccessRecord(accessCheckApp, record, requestInfo, rule) {
 							continue
 						}
 
-						// create a clean record copy without expand and unknown fields because we don't know yet
-						// which exact fields the client subscription requested or has permissions to access
-						cleanRecord := record.Fresh()
-
-						// trigger the enrich hooks
-						enrichErr := triggerRecordEnrichHooks(app, requestInfo, []*core.Record{cleanRecord}, func() error {
-							// apply expand
-							rawExpand := options.Query[expandQueryParam]
-							if rawExpand != "" {
-								expandErrs := app.ExpandRecord(cleanRecord, strings.Split(rawExpand, ","), expandFetch(app, requestInfo))
-								if len(expandErrs) > 0 {
-									app.Logger().Debug(
-										"[broadcastRecord] expand errors",
-										slog.String("id", cleanRecord.Id),
-										slog.String("collectionName", cleanRecord.Collection().Name),
-										slog.String("sub", sub),
-										slog.String("expand", rawExpand),
-										slog.Any("errors", expandErrs),
-									)
-								}
-							}
-
-							// ignore the auth record email visibility checks
-							// for auth owner, superuser or manager
-							if collection.IsAuth() {
-								if isSameAuth(clientAuth, cleanRecord) ||
-									realtimeCanAccessRecord(accessCheckApp, cleanRecord, requestInfo, collection.ManageRule) {
-									cleanRecord.IgnoreEmailVisibility(true)
-								}
-							}
-
-							return nil
-						})
-						if enrichErr != nil {
-							app.Logger().Debug(
-								"[broadcastRecord] record enrich error",
-								slog.String("id", cleanRecord.Id),
-								slog.String("collectionName", cleanRecord.Collection().Name),
-								slog.String("sub", sub),
-								slog.Any("error", enrichErr),
-							)
-							continue
-						}
-
-						data := &recordData{
-							Action: action,
-							Record: cleanRecord,
-						}
-
-						// check fields
-						rawFields := options.Query[fieldsQueryParam]
-						if rawFields != "" {
-							decoded, err := picker.Pick(cleanRecord, rawFields)
-							if err == nil {
-								data.Record = decoded
-							} else {
-								app.Logger().Debug(
-									"[broadcastRecord] pick fields error",
-									slog.String("id", cleanRecord.Id),
-									slog.String("collectionName", cleanRecord.Collection().Name),
-									slog.String("sub", sub),
-									slog.String("fields", rawFields),
-									slog.String("error", err.Error()),
-								)
-							}
-						}
-
-						dataBytes, err := json.Marshal(data)
-						if err != nil {
-							app.Logger().Debug(
-								"[broadcastRecord] data marshal error",
-								slog.String("id", cleanRecord.Id),
-								slog.String("collectionName", cleanRecord.Collection().Name),
-								slog.String("error", err.Error()),
-							)
+						msg := realtimeRecordMessage(app, accessCheckApp, action, record, requestInfo, sub, options)
+						if msg == nil {
 							continue
 						}
-
-						msg := subscriptions.Message{
-							Name: sub,
-							Data: dataBytes,
-						}
+						msg.Id = eventId
 
 						if dryCache {
 							messages, ok := client.Get(dryCacheKey).([]subscriptions.Message)
 							if !ok {
-								messages = []subscriptions.Message{msg}
+								messages = []subscriptions.Message{*msg}
 							} else {
-								messages = append(messages, msg)
+								messages = append(messages, *msg)
 							}
 							client.Set(dryCacheKey, messages)
 						} else {
 							routine.FireAndForget(func() {
-								client.Send(msg)
+								client.Send(*msg)
 							})
 						}
 					}
@@ -651,8 +606,155 @@ func realtimeBroadcastRecord(app core.App, action string, record *core.Record, d
 	return group.Wait()
 }
 
+// realtimeSubscriptionRuleMap returns the record subscription topic
+// prefixes mapped to their collection API rule.
+func realtimeSubscriptionRuleMap(record *core.Record) map[string]*string {
+	collection := record.Collection()
+
+	return map[string]*string{
+		(collection.Name + "/" + record.Id + "?"): collection.ViewRule,
+		(collection.Id + "/" + record.Id + "?"):   collection.ViewRule,
+		(collection.Name + "/*?"):                 collection.ListRule,
+		(collection.Id + "/*?"):                   collection.ListRule,
+
+		// @deprecated: the same as the wildcard topic but kept for backward compatibility
+		(collection.Name + "?"): collection.ListRule,
+		(collection.Id + "?"):   collection.ListRule,
+	}
+}
+
+// realtimeSubscriptionRequestInfo mocks the request data of a single client subscription.
+func realtimeSubscriptionRequestInfo(client subscriptions.Client, options subscriptions.SubscriptionOptions) *core.RequestInfo {
+	clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
+
+	return &core.RequestInfo{
+		Context: core.RequestInfoContextRealtime,
+		Method:  "GET",
+		Query:   options.Query,
+		Headers: options.Headers,
+		Auth:    clientAuth,
+	}
+}
+
+// realtimeRecordMessage prepares the record subscription message of a single client subscription.
+//
+// It is expected that the client access to the record is already checked.
+// Returns nil if the record couldn't be enriched or serialized.
+func realtimeRecordMessage(
+	app core.App,
+	accessCheckApp core.App,
+	action string,
+	record *core.Record,
+	requestInfo *core.RequestInfo,
+	sub string,
+	options subscriptions.SubscriptionOptions,
+) *subscriptions.Message {
+	collection := record.Collection()
+
+	// create a clean record copy without expand and unknown fields because we don't know yet
+	// which exact fields the client subscription requested or has permissions to access
+	cleanRecord := record.Fresh()
+
+	// trigger the enrich hooks
+	enrichErr := triggerRecordEnrichHooks(app, requestInfo, []*core.Record{cleanRecord}, func() error {
+		// apply expand
+		rawExpand := options.Query[expandQueryParam]
+		if rawExpand != "" {
+			expandErrs := app.ExpandRecord(cleanRecord, strings.Split(rawExpand, ","), expandFetch(app, requestInfo))
+			if len(expandErrs) > 0 {
+				app.Logger().Debug(
+					"[broadcastRecord] expand errors",
+					slog.String("id", cleanRecord.Id),
+					slog.String("collectionName", cleanRecord.Collection().Name),
+					slog.String("sub", sub),
+					slog.String("expand", rawExpand),
+					slog.Any("errors", expandErrs),
+				)
+			}
+		}
+
+		// ignore the auth record email visibility checks
+		// for auth owner, superuser or manager
+		if collection.IsAuth() {
+			if isSameAuth(requestInfo.Auth, cleanRecord) ||
+				realtimeCanAccessRecord(accessCheckApp, cleanRecord, requestInfo, collection.ManageRule) {
+				cleanRecord.IgnoreEmailVisibility(true)
+			}
+		}
+
+		return nil
+	})
+	if enrichErr != nil {
+		app.Logger().Debug(
+			"[broadcastRecord] record enrich error",
+			slog.String("id", cleanRecord.Id),
+			slog.String("collectionName", cleanRecord.Collection().Name),
+			slog.String("sub", sub),
+			slog.Any("error", enrichErr),
+		)
+		return nil
+	}
+
+	data := &recordData{
+		Action: action,
+		Record: cleanRecord,
+	}
+
+	// check fields
+	rawFields := options.Query[fieldsQueryParam]
+	if rawFields != "" {
+		decoded, err := picker.Pick(cleanRecord, rawFields)
+		if err == nil {
+			data.Record = decoded
+		} else {
+			app.Logger().Debug(
+				"[broadcastRecord] pick fields error",
+				slog.String("id", cleanRecord.Id),
+				slog.String("collectionName", cleanRecord.Collection().Name),
+				slog.String("sub", sub),
+				slog.String("fields", rawFields),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	// send only the changed fields
+	if action == "update" && realtimeIsDiffSubscription(options) {
+		diff, err := realtimeRecordDiff(cleanRecord, data.Record)
+		if err != nil {
+			app.Logger().Debug(
+				"[broadcastRecord] record diff error",
+				slog.String("id", cleanRecord.Id),
+				slog.String("collectionName", cleanRecord.Collection().Name),
+				slog.String("sub", sub),
+				slog.String("error", err.Error()),
+			)
+			return nil
+		}
+		data.Record = diff
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		app.Logger().Debug(
+			"[broadcastRecord] data marshal error",
+			slog.String("id", cleanRecord.Id),
+			slog.String("collectionName", cleanRecord.Collection().Name),
+			slog.String("error", err.Error()),
+		)
+		return nil
+	}
+
+	return &subscriptions.Message{
+		Name: sub,
+		Data: dataBytes,
+	}
+}
+
 // realtimeBroadcastDryCacheKey broadcasts the dry cached key related messages.
-func realtimeBroadcastDryCacheKey(app core.App, key string) error {
+//
+// The optional eventId is assigned to all of the broadcasted messages.
+func realtimeBroadcastDryCacheKey(app core.App, key string, eventId string) error {
 	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
 	if len(chunks) == 0 {
 		return nil // no subscribers
@@ -674,6 +776,7 @@ func realtimeBroadcastDryCacheKey(app core.App, key string) error {
 
 				routine.FireAndForget(func() {
 					for _, msg := range messages {
+						msg.Id = eventId
 						client.Send(msg)
 					}
 				})
@@ -0,0 +1,241 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+// Realtime backplane event kinds.
+//
+// The delete is split in 3 events to mirror the local "dry cache"
+// flow so that the remote nodes could perform the access checks
+// while the record still exists.
+const (
+	realtimeBackplaneCreate         = "record.create"
+	realtimeBackplaneUpdate         = "record.update"
+	realtimeBackplaneDelete         = "record.delete"
+	realtimeBackplaneDeleteCommit   = "record.delete.commit"
+	realtimeBackplaneDeleteRollback = "record.delete.rollback"
+)
+
+const (
+	realtimeBackplanePublishTimeout = 5 * time.Second
+	realtimeBackplaneRetryInterval  = 5 * time.Second
+)
+
+// realtimeBackplaneRecord is the payload of the realtime record backplane events.
+//
+// Original is the record db state before the change and it is
+// sent only with the update events (for the field-level diffs).
+type realtimeBackplaneRecord struct {
+	CollectionId string         `json:"collectionId"`
+	Record       map[string]any `json:"record"`
+	Original     map[string]any `json:"original,omitempty"`
+}
+
+// bindRealtimeBackplane starts listening for the realtime events of the other
+// app nodes on serve (if the app subscriptions broker has a backplane).
+func bindRealtimeBackplane(app core.App) {
+	app.OnServe().Bind(&hook.Handler[*core.ServeEvent]{
+		Id: "__pbRealtimeBackplane__",
+		Func: func(e *core.ServeEvent) error {
+			if e.App.SubscriptionsBroker().Backplane() == nil {
+				return e.Next()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			e.App.OnTerminate().Bind(&hook.Handler[*core.TerminateEvent]{
+				Id: "__pbRealtimeBackplaneOnTerminate__",
+				Func: func(te *core.TerminateEvent) error {
+					cancel()
+
+					if backplane := te.App.SubscriptionsBroker().Backplane(); backplane != nil {
+						if err := backplane.Close(); err != nil {
+							te.App.Logger().Warn("Failed to close the realtime backplane", slog.String("error", err.Error()))
+						}
+					}
+
+					return te.Next()
+				},
+			})
+
+			go realtimeListenBackplane(ctx, e.App)
+
+			return e.Next()
+		},
+	})
+}
+
+// realtimeListenBackplane listens for the realtime backplane events
+// until ctx is done, retrying on listen failure.
+func realtimeListenBackplane(ctx context.Context, app core.App) {
+	for {
+		err := app.SubscriptionsBroker().Listen(ctx, func(event subscriptions.BackplaneEvent) {
+			if err := realtimeHandleBackplaneEvent(app, event); err != nil {
+				app.Logger().Debug(
+					"Failed to handle realtime backplane event",
+					slog.String("kind", event.Kind),
+					slog.String("node", event.Node),
+					slog.String("error", err.Error()),
+				)
+			}
+		})
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		app.Logger().Warn(
+			"Realtime backplane listen error, retrying...",
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(realtimeBackplaneRetryInterval):
+		}
+	}
+}
+
+// realtimePublishRecord publishes the record event to the other app nodes
+// (it does nothing if the app subscriptions broker doesn't have a backplane).
+func realtimePublishRecord(app core.App, kind string, record *core.Record) {
+	broker := app.SubscriptionsBroker()
+	if broker.Backplane() == nil {
+		return
+	}
+
+	data, err := realtimeBackplaneExport(app, record)
+	if err != nil {
+		app.Logger().Debug(
+			"Failed to export record for the realtime backplane",
+			slog.String("id", record.Id),
+			slog.String("collectionName", record.Collection().Name),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	var original map[string]any
+	if kind == realtimeBackplaneUpdate {
+		original, err = realtimeBackplaneExport(app, record.Original())
+		if err != nil {
+			app.Logger().Debug(
+				"Failed to export original record for the realtime backplane",
+				slog.String("id", record.Id),
+				slog.String("collectionName", record.Collection().Name),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), realtimeBackplanePublishTimeout)
+	defer cancel()
+
+	err = broker.Publish(ctx, kind, realtimeBackplaneRecord{
+		CollectionId: record.Collection().Id,
+		Record:       data,
+		Original:     original,
+	})
+	if err != nil {
+		app.Logger().Debug(
+			"Failed to publish realtime backplane event",
+			slog.String("kind", kind),
+			slog.String("id", record.Id),
+			slog.String("collectionName", record.Collection().Name),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// realtimeBackplaneExport exports the record db data for the realtime backplane.
+func realtimeBackplaneExport(app core.App, record *core.Record) (map[string]any, error) {
+	data, err := record.DBExport(app)
+	if err != nil {
+		return nil, err
+	}
+
+	// the hidden fields (incl. the password hash and tokenKey) are never sent to the clients
+	// and the remote nodes perform the access checks against the shared db
+	for _, field := range record.Collection().Fields {
+		if field.GetHidden() {
+			delete(data, field.GetName())
+		}
+	}
+	delete(data, core.FieldNameTokenKey)
+
+	return data, nil
+}
+
+// realtimeHandleBackplaneEvent applies a single record event from another
+// app node to the local subscription clients.
+//
+// The access checks are performed locally in the same way as for the local record changes.
+func realtimeHandleBackplaneEvent(app core.App, event subscriptions.BackplaneEvent) error {
+	payload := realtimeBackplaneRecord{}
+	if err := json.Unmarshal(event.Data, &payload); err != nil {
+		return err
+	}
+
+	collection, err := app.FindCachedCollectionByNameOrId(payload.CollectionId)
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(collection)
+	if payload.Original != nil {
+		// restore the original state so that the field-level diffs could be computed
+		record.Load(payload.Original)
+		if err := record.PostScan(); err != nil {
+			return err
+		}
+		record.Load(payload.Record)
+	} else {
+		record.Load(payload.Record)
+		if err := record.PostScan(); err != nil {
+			return err
+		}
+	}
+
+	switch event.Kind {
+	case realtimeBackplaneCreate:
+		return realtimeBroadcastRecord(app, "create", record, false)
+	case realtimeBackplaneUpdate:
+		if collection.IsAuth() {
+			// reload the auth record from the shared db because
+			// the event record doesn't have the hidden fields
+			authRecord, err := app.FindRecordById(collection, record.Id)
+			if err != nil {
+				return err
+			}
+
+			if err := realtimeUpdateClientsAuth(app, authRecord); err != nil {
+				return err
+			}
+		}
+		return realtimeBroadcastRecord(app, "update", record, false)
+	case realtimeBackplaneDelete:
+		return realtimeBroadcastRecord(app, "delete", record, true)
+	case realtimeBackplaneDeleteCommit:
+		if collection.IsAuth() {
+			if err := realtimeUnsetClientsAuthState(app, record); err != nil {
+				return err
+			}
+		}
+		eventId := realtimeHistoryPush(app, "delete", record)
+		return realtimeBroadcastDryCacheKey(app, getDryCacheKey("delete", record), eventId)
+	case realtimeBackplaneDeleteRollback:
+		return realtimeUnsetDryCacheKey(app, getDryCacheKey("delete", record))
+	}
+
+	return errors.New("unknown realtime backplane event kind " + event.Kind)
+}
@@ -0,0 +1,286 @@
+package apis_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+func TestRealtimeBackplane(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	addrA := "unix:" + filepath.Join(dir, "a.sock")
+	addrB := "unix:" + filepath.Join(dir, "b.sock")
+
+	newNode := func(listenAddr string, peer string) *tests.TestApp {
+		app, err := tests.NewTestApp()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		backplane, err := subscriptions.NewPeerBackplane(subscriptions.PeerBackplaneConfig{
+			ListenAddr: listenAddr,
+			Peers:      []string{peer},
+			Secret:     "test",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		app.SubscriptionsBroker().SetBackplane(backplane)
+
+		pbRouter, err := apis.NewRouter(app)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		serveEvent := new(core.ServeEvent)
+		serveEvent.App = app
+		serveEvent.Router = pbRouter
+		err = app.OnServe().Trigger(serveEvent, func(e *core.ServeEvent) error {
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return app
+	}
+
+	nodeA := newNode(addrA, addrB)
+	defer nodeA.Cleanup()
+
+	nodeB := newNode(addrB, addrA)
+	defer nodeB.Cleanup()
+
+	// wait for the listeners to start
+	time.Sleep(100 * time.Millisecond)
+
+	user, err := nodeB.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// node B clients
+	guestB := subscriptions.NewDefaultClient()
+	guestB.Subscribe("demo2/*", "users/"+user.Id)
+	nodeB.SubscriptionsBroker().Register(guestB)
+
+	authB := subscriptions.NewDefaultClient()
+	authB.Set(apis.RealtimeClientAuthKey, user)
+	authB.Subscribe("users/" + user.Id)
+	nodeB.SubscriptionsBroker().Register(authB)
+
+	// node A client
+	clientA := subscriptions.NewDefaultClient()
+	clientA.Subscribe("demo2/*")
+	nodeA.SubscriptionsBroker().Register(clientA)
+
+	diffA := subscriptions.NewDefaultClient()
+	diffA.Subscribe(`demo2/*?options={"query":{"diff":"1"}}`)
+	nodeA.SubscriptionsBroker().Register(diffA)
+
+	expectMessage := func(client subscriptions.Client, name string, contains ...string) {
+		t.Helper()
+
+		select {
+		case msg := <-client.Channel():
+			if msg.Name != name {
+				t.Fatalf("Expected message %q, got %q (%s)", name, msg.Name, msg.Data)
+			}
+			for _, str := range contains {
+				if !strings.Contains(string(msg.Data), str) {
+					t.Fatalf("Expected message %q to contain %s, got %s", name, str, msg.Data)
+				}
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected message %q, got none", name)
+		}
+	}
+
+	expectNoMessage := func(client subscriptions.Client) {
+		t.Helper()
+
+		select {
+		case msg := <-client.Channel():
+			t.Fatalf("Expected no message, got %q (%s)", msg.Name, msg.Data)
+		case <-time.After(300 * time.Millisecond):
+		}
+	}
+
+	// public create on node A
+	// ---
+	demo2, err := nodeA.FindCollectionByNameOrId("demo2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := core.NewRecord(demo2)
+	record.Id = "backplanerec001"
+	record.Set("title", "backplane_create")
+	if err := nodeA.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	expectMessage(clientA, "demo2/*", `"action":"create"`, `"title":"backplane_create"`)
+	expectMessage(guestB, "demo2/*", `"action":"create"`, `"title":"backplane_create"`, `"id":"`+record.Id+`"`)
+	expectMessage(diffA, `demo2/*?options={"query":{"diff":"1"}}`, `"action":"create"`, `"active":false`)
+	expectNoMessage(clientA) // no duplicates from the backplane
+
+	// public update on node B
+	// ---
+	existingB, err := nodeB.FindRecordById("demo2", "achvryl401bhse3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	existingB.Set("title", "backplane_update")
+	if err := nodeB.Save(existingB); err != nil {
+		t.Fatal(err)
+	}
+
+	expectMessage(guestB, "demo2/*", `"action":"update"`, `"title":"backplane_update"`)
+	expectMessage(clientA, "demo2/*", `"action":"update"`, `"title":"backplane_update"`)
+
+	// the field-level diffs are computed from the original record state sent by node B
+	select {
+	case msg := <-diffA.Channel():
+		data := string(msg.Data)
+		if !strings.Contains(data, `"title":"backplane_update"`) || strings.Contains(data, `"active"`) {
+			t.Fatalf("Expected only the changed fields in the diff message, got %s", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected diff message, got none")
+	}
+
+	// rule protected update on node A (the access checks are performed on node B)
+	// ---
+	userA, err := nodeA.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userA.Set("name", "backplane_user")
+	if err := nodeA.Save(userA); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate the shared db (node B reloads the client auth record from it)
+	_, err = nodeB.DB().Update("users", dbx.Params{"name": "backplane_user"}, dbx.HashExp{"id": user.Id}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectMessage(authB, "users/"+user.Id, `"action":"update"`, `"name":"backplane_user"`)
+	expectNoMessage(guestB)
+
+	// the client auth state is synced too
+	clientAuth, _ := authB.Get(apis.RealtimeClientAuthKey).(*core.Record)
+	if clientAuth == nil || clientAuth.GetString("name") != "backplane_user" {
+		t.Fatalf("Expected the node B client auth record to be updated, got %v", clientAuth)
+	}
+
+	// delete on node A
+	// ---
+	existingA, err := nodeA.FindRecordById("demo2", "achvryl401bhse3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nodeA.Delete(existingA); err != nil {
+		t.Fatal(err)
+	}
+
+	expectMessage(clientA, "demo2/*", `"action":"delete"`)
+	expectMessage(diffA, `demo2/*?options={"query":{"diff":"1"}}`, `"action":"delete"`, `"title":"test2"`)
+	expectMessage(guestB, "demo2/*", `"action":"delete"`, `"id":"achvryl401bhse3"`)
+}
+
+func TestRealtimeBackplaneRecordPayload(t *testing.T) {
+	t.Parallel()
+
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users.Fields.Add(&core.TextField{Name: "secretNote", Hidden: true})
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	backplane := &captureBackplane{}
+	app.SubscriptionsBroker().SetBackplane(backplane)
+
+	if _, err := apis.NewRouter(app); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Set("name", "backplane_payload")
+	user.Set("secretNote", "backplane_secret")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	events := backplane.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 published event, got %d", len(events))
+	}
+
+	data := string(events[0].Data)
+
+	if !strings.Contains(data, `"name":"backplane_payload"`) {
+		t.Fatalf("Expected the published record data, got %s", data)
+	}
+
+	for _, str := range []string{`"tokenKey"`, `"password"`, `"secretNote"`, "backplane_secret"} {
+		if strings.Contains(data, str) {
+			t.Fatalf("Expected %s to be excluded from the published event, got %s", str, data)
+		}
+	}
+}
+
+// captureBackplane is a test backplane that only stores the published events.
+type captureBackplane struct {
+	mu     sync.Mutex
+	events []subscriptions.BackplaneEvent
+}
+
+func (b *captureBackplane) Publish(ctx context.Context, event subscriptions.BackplaneEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = append(b.events, event)
+
+	return nil
+}
+
+func (b *captureBackplane) Listen(ctx context.Context, handler func(event subscriptions.BackplaneEvent)) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (b *captureBackplane) Close() error {
+	return nil
+}
+
+func (b *captureBackplane) Events() []subscriptions.BackplaneEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]subscriptions.BackplaneEvent(nil), b.events...)
+}
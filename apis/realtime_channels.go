@@ -0,0 +1,528 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/search"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	"golang.org/x/sync/errgroup"
+)
+
+// RealtimeChannelTopicPrefix is the subscription topic prefix of the custom
+// realtime channels (e.g. "@channel/documents/abc" for the "documents/abc" channel).
+const RealtimeChannelTopicPrefix = "@channel/"
+
+// RealtimePresenceTimeout is the max duration after the last join or
+// heartbeat request before a channel presence member is considered gone.
+const RealtimePresenceTimeout = time.Minute
+
+// Custom realtime channel message events.
+const (
+	realtimeChannelEventMessage = "message"
+)
+
+const realtimePresenceCronKey = "__pbRealtimePresenceCleanup__"
+
+var realtimeChannelRegex = regexp.MustCompile(`^[\w\-\.\:\/]+$`)
+
+// bindRealtimeChannelsApi registers the custom realtime channels and presence api endpoints.
+func bindRealtimeChannelsApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	rg.POST("/publish", realtimeChannelPublish).Bind(RequireAuth())
+	rg.GET("/presence", realtimePresenceList)
+	rg.POST("/presence/join", realtimePresenceJoin)
+	rg.POST("/presence/heartbeat", realtimePresenceHeartbeat)
+	rg.POST("/presence/leave", realtimePresenceLeave)
+
+	app.Cron().Add(realtimePresenceCronKey, "* * * * *", func() {
+		realtimePresenceCleanup(app)
+	})
+}
+
+// realtimeChannelData represents the custom channel subscription message data.
+type realtimeChannelData struct {
+	Event    string                        `json:"event"`
+	Channel  string                        `json:"channel"`
+	ClientId string                        `json:"clientId,omitempty"`
+	Auth     map[string]any                `json:"auth,omitempty"`
+	Data     any                           `json:"data,omitempty"`
+	Member   *subscriptions.PresenceMember `json:"member,omitempty"`
+}
+
+// -------------------------------------------------------------------
+
+type realtimePublishForm struct {
+	ClientId string `form:"clientId" json:"clientId"`
+	Channel  string `form:"channel" json:"channel"`
+	Data     any    `form:"data" json:"data"`
+}
+
+func (form *realtimePublishForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.ClientId, validation.Length(0, 255)),
+		validation.Field(&form.Channel, validation.Required, validation.Length(1, 255), validation.Match(realtimeChannelRegex)),
+	)
+}
+
+func realtimeChannelPublish(e *core.RequestEvent) error {
+	form := new(realtimePublishForm)
+
+	err := e.BindBody(form)
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	err = form.validate()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	rules, ok := e.App.SubscriptionsBroker().FindChannelRules(form.Channel)
+	if !ok {
+		return e.NotFoundError("Missing or invalid channel.", nil)
+	}
+
+	var client subscriptions.Client
+	if form.ClientId != "" {
+		client, err = realtimeFindRequestClient(e, form.ClientId)
+		if err != nil {
+			return err
+		}
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	if !realtimeCanAccessChannel(e.App, form.Channel, rules.Prefix, requestInfo, rules.PublishRule) {
+		return e.ForbiddenError("You are not allowed to publish to the channel.", nil)
+	}
+
+	event := new(core.RealtimePublishRequestEvent)
+	event.RequestEvent = e
+	event.Client = client
+	event.Channel = form.Channel
+	event.Data = form.Data
+
+	return e.App.OnRealtimePublishRequest().Trigger(event, func(e *core.RealtimePublishRequestEvent) error {
+		data := &realtimeChannelData{
+			Event:   realtimeChannelEventMessage,
+			Channel: e.Channel,
+			Auth:    realtimePresenceAuth(e.Auth),
+			Data:    e.Data,
+		}
+		if e.Client != nil {
+			data.ClientId = e.Client.Id()
+		}
+
+		if err := realtimeBroadcastChannel(e.App, e.Channel, rules, data); err != nil {
+			return e.InternalServerError("Failed to publish the channel message.", err)
+		}
+
+		return execAfterSuccessTx(true, e.App, func() error {
+			return e.NoContent(http.StatusNoContent)
+		})
+	})
+}
+
+// -------------------------------------------------------------------
+
+type realtimePresenceForm struct {
+	ClientId string         `form:"clientId" json:"clientId"`
+	Channel  string         `form:"channel" json:"channel"`
+	Meta     map[string]any `form:"meta" json:"meta"`
+}
+
+func (form *realtimePresenceForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.ClientId, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.Channel, validation.Required, validation.Length(1, 255), validation.Match(realtimeChannelRegex)),
+	)
+}
+
+func realtimePresenceList(e *core.RequestEvent) error {
+	channel := e.Request.URL.Query().Get("channel")
+	if channel == "" {
+		return e.BadRequestError("Missing required channel query parameter.", nil)
+	}
+
+	rules, ok := e.App.SubscriptionsBroker().FindChannelRules(channel)
+	if !ok {
+		return e.NotFoundError("Missing or invalid channel.", nil)
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	if !realtimeCanAccessChannel(e.App, channel, rules.Prefix, requestInfo, rules.ListenRule) {
+		return e.ForbiddenError("You are not allowed to access the channel.", nil)
+	}
+
+	members := e.App.SubscriptionsBroker().PresenceMembers(channel, RealtimePresenceTimeout)
+	if members == nil {
+		members = []subscriptions.PresenceMember{}
+	}
+
+	return e.JSON(http.StatusOK, members)
+}
+
+func realtimePresenceJoin(e *core.RequestEvent) error {
+	form := new(realtimePresenceForm)
+
+	err := e.BindBody(form)
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	err = form.validate()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	rules, ok := e.App.SubscriptionsBroker().FindChannelRules(form.Channel)
+	if !ok {
+		return e.NotFoundError("Missing or invalid channel.", nil)
+	}
+
+	client, err := realtimeFindRequestClient(e, form.ClientId)
+	if err != nil {
+		return err
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	if !realtimeCanAccessChannel(e.App, form.Channel, rules.Prefix, requestInfo, rules.PresenceRule) {
+		return e.ForbiddenError("You are not allowed to join the channel.", nil)
+	}
+
+	now := time.Now()
+
+	action := core.RealtimePresenceJoin
+	member := &subscriptions.PresenceMember{
+		ClientId: client.Id(),
+		Channel:  form.Channel,
+		Auth:     realtimePresenceAuth(e.Auth),
+		Meta:     form.Meta,
+		JoinedAt: now,
+		LastSeen: now,
+	}
+
+	if existing, ok := subscriptions.ClientPresence(client)[form.Channel]; ok {
+		action = core.RealtimePresenceUpdate
+		member.JoinedAt = existing.JoinedAt
+	}
+
+	event := new(core.RealtimePresenceEvent)
+	event.App = e.App
+	event.Client = client
+	event.Member = member
+	event.Action = action
+
+	err = e.App.OnRealtimePresenceChange().Trigger(event, func(pe *core.RealtimePresenceEvent) error {
+		subscriptions.SetClientPresence(pe.Client, *pe.Member)
+
+		return realtimeBroadcastPresence(pe.App, pe.Action, *pe.Member)
+	})
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to join the channel.", err))
+	}
+
+	return execAfterSuccessTx(true, e.App, func() error {
+		return e.JSON(http.StatusOK, event.Member)
+	})
+}
+
+func realtimePresenceHeartbeat(e *core.RequestEvent) error {
+	form := new(realtimePresenceForm)
+
+	err := e.BindBody(form)
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	err = form.validate()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	client, err := realtimeFindRequestClient(e, form.ClientId)
+	if err != nil {
+		return err
+	}
+
+	member, ok := subscriptions.ClientPresence(client)[form.Channel]
+	if !ok {
+		return e.NotFoundError("The client is not a member of the channel.", nil)
+	}
+
+	member.LastSeen = time.Now()
+	subscriptions.SetClientPresence(client, member)
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+func realtimePresenceLeave(e *core.RequestEvent) error {
+	form := new(realtimePresenceForm)
+
+	err := e.BindBody(form)
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	err = form.validate()
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	client, err := realtimeFindRequestClient(e, form.ClientId)
+	if err != nil {
+		return err
+	}
+
+	err = realtimePresenceLeaveChannels(e.App, client, form.Channel)
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to leave the channel.", err))
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// -------------------------------------------------------------------
+
+// realtimeFindRequestClient returns the subscription client with the specified id
+// ensuring that it has the same auth state as the current request.
+func realtimeFindRequestClient(e *core.RequestEvent, clientId string) (subscriptions.Client, error) {
+	client, err := e.App.SubscriptionsBroker().ClientById(clientId)
+	if err != nil {
+		return nil, e.NotFoundError("Missing or invalid client id.", err)
+	}
+
+	clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
+	if !isSameAuth(clientAuth, e.Auth) {
+		return nil, e.ForbiddenError("The current and the client authorization don't match.", nil)
+	}
+
+	return client, nil
+}
+
+// realtimePresenceAuth returns the auth record metadata shared with the other channel members.
+func realtimePresenceAuth(auth *core.Record) map[string]any {
+	if auth == nil {
+		return nil
+	}
+
+	return map[string]any{
+		"id":             auth.Id,
+		"collectionId":   auth.Collection().Id,
+		"collectionName": auth.Collection().Name,
+	}
+}
+
+// realtimePresenceLeaveChannels removes the client from the specified channels presence
+// (or from all joined channels if none is specified) and notifies the other members.
+func realtimePresenceLeaveChannels(app core.App, client subscriptions.Client, channels ...string) error {
+	presence := subscriptions.ClientPresence(client)
+
+	var errs []error
+
+	for channel, member := range presence {
+		if len(channels) > 0 && !slices.Contains(channels, channel) {
+			continue
+		}
+
+		event := new(core.RealtimePresenceEvent)
+		event.App = app
+		event.Client = client
+		event.Member = &member
+		event.Action = core.RealtimePresenceLeave
+
+		err := app.OnRealtimePresenceChange().Trigger(event, func(pe *core.RealtimePresenceEvent) error {
+			subscriptions.UnsetClientPresence(pe.Client, pe.Member.Channel)
+
+			return realtimeBroadcastPresence(pe.App, pe.Action, *pe.Member)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// realtimePresenceLeaveAll removes the client from all joined channels
+// (e.g. on disconnect) logging the failures.
+func realtimePresenceLeaveAll(app core.App, client subscriptions.Client) {
+	err := realtimePresenceLeaveChannels(app, client)
+	if err != nil {
+		app.Logger().Debug(
+			"Failed to leave the realtime channels presence",
+			slog.String("clientId", client.Id()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// realtimePresenceCleanup removes the timed out presence members of all clients.
+func realtimePresenceCleanup(app core.App) {
+	for _, client := range app.SubscriptionsBroker().Clients() {
+		var stale []string
+		for channel, member := range subscriptions.ClientPresence(client) {
+			if member.IsStale(RealtimePresenceTimeout) {
+				stale = append(stale, channel)
+			}
+		}
+
+		if len(stale) == 0 {
+			continue
+		}
+
+		err := realtimePresenceLeaveChannels(app, client, stale...)
+		if err != nil {
+			app.Logger().Debug(
+				"Failed to remove the timed out realtime presence members",
+				slog.String("clientId", client.Id()),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// realtimeBroadcastPresence notifies the channel listeners for the presence member change.
+func realtimeBroadcastPresence(app core.App, action string, member subscriptions.PresenceMember) error {
+	rules, ok := app.SubscriptionsBroker().FindChannelRules(member.Channel)
+	if !ok {
+		return nil // the channel rules were removed
+	}
+
+	return realtimeBroadcastChannel(app, member.Channel, rules, &realtimeChannelData{
+		Event:   action,
+		Channel: member.Channel,
+		Member:  &member,
+	})
+}
+
+// realtimeBroadcastChannel sends the data to all clients subscribed
+// to the channel topic that satisfy the channel listen rule.
+func realtimeBroadcastChannel(app core.App, channel string, rules subscriptions.ChannelRules, data *realtimeChannelData) error {
+	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
+	if len(chunks) == 0 {
+		return nil // no subscribers
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	topicPrefix := RealtimeChannelTopicPrefix + channel + "?"
+
+	group := new(errgroup.Group)
+
+	for _, chunk := range chunks {
+		group.Go(func() error {
+			for _, client := range chunk {
+				for sub, options := range client.Subscriptions(topicPrefix) {
+					requestInfo := realtimeSubscriptionRequestInfo(client, options)
+
+					if !realtimeCanAccessChannel(app, channel, rules.Prefix, requestInfo, rules.ListenRule) {
+						continue
+					}
+
+					msg := subscriptions.Message{
+						Name: sub,
+						Data: dataBytes,
+					}
+
+					routine.FireAndForget(func() {
+						client.Send(msg)
+					})
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+// realtimeCanAccessChannel checks if the request info satisfies the custom channel access rule.
+//
+// The rule is evaluated against a single virtual row with the following fields:
+//   - id   - the channel name
+//   - name - the channel name
+//   - key  - the channel name without the rules prefix (e.g. "abc" for "documents/abc")
+func realtimeCanAccessChannel(
+	app core.App,
+	channel string,
+	prefix string,
+	requestInfo *core.RequestInfo,
+	accessRule *string,
+) bool {
+	// superusers can access everything
+	if requestInfo.HasSuperuserAuth() {
+		return true
+	}
+
+	// only superusers can access the channel
+	if accessRule == nil {
+		return false
+	}
+
+	// empty public rule, aka. everyone can access
+	if *accessRule == "" {
+		return true
+	}
+
+	dummyCollection := core.NewBaseCollection("__pb_channel__" + security.PseudorandomString(6))
+	dummyCollection.Fields.Add(
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "key"},
+	)
+
+	withFrom := "WITH {{" + dummyCollection.Name + "}} as (SELECT {:__pb_channel_id} AS [[id]], {:__pb_channel_name} AS [[name]], {:__pb_channel_key} AS [[key]])"
+
+	query := app.ConcurrentDB().Select("(1)").
+		PreFragment(withFrom).
+		From(dummyCollection.Name).
+		AndBind(dbx.Params{
+			"__pb_channel_id":   channel,
+			"__pb_channel_name": channel,
+			"__pb_channel_key":  channel[len(prefix):],
+		})
+
+	resolver := core.NewRecordFieldResolver(app, dummyCollection, requestInfo, true)
+	expr, err := search.FilterData(*accessRule).BuildExpr(resolver)
+	if err != nil {
+		return false
+	}
+
+	query.AndWhere(expr)
+
+	err = resolver.UpdateQuery(query)
+	if err != nil {
+		return false
+	}
+
+	var exists int
+	err = query.Limit(1).Row(&exists)
+
+	return err == nil && exists > 0
+}
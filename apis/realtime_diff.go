@@ -0,0 +1,110 @@
+package apis
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	"github.com/spf13/cast"
+)
+
+// realtimeDiffQueryParam is the subscription options query parameter
+// that enables the field-level diffs for the record update messages.
+//
+// Example:
+//
+//	?options={"query":{"diff":"1"}}
+const realtimeDiffQueryParam = "diff"
+
+// realtimeIsDiffSubscription checks whether the subscription
+// requested to receive only the changed record fields.
+func realtimeIsDiffSubscription(options subscriptions.SubscriptionOptions) bool {
+	return cast.ToBool(options.Query[realtimeDiffQueryParam])
+}
+
+// realtimeChangedFields returns the names of the record collection fields
+// that have different value from the record original db state.
+//
+// The id and the autodate on update fields (e.g. "updated") are always included.
+func realtimeChangedFields(record *core.Record) map[string]struct{} {
+	original := record.Original()
+
+	result := map[string]struct{}{
+		core.FieldNameId: {},
+	}
+
+	for _, field := range record.Collection().Fields {
+		name := field.GetName()
+
+		if f, ok := field.(*core.AutodateField); ok && f.OnUpdate {
+			result[name] = struct{}{}
+			continue
+		}
+
+		oldRaw, oldErr := json.Marshal(original.GetRaw(name))
+		newRaw, newErr := json.Marshal(record.GetRaw(name))
+		if oldErr != nil || newErr != nil || !bytes.Equal(oldRaw, newRaw) {
+			result[name] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+// realtimeRecordDiff filters the serialized record message data
+// (either *core.Record or the fields projection map) to only the
+// changed record fields and their expanded relations.
+//
+// Because the filtering is applied on the already serialized data,
+// the hidden fields and the fields projection remain in effect.
+func realtimeRecordDiff(record *core.Record, data any) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &exported); err != nil {
+		return nil, err
+	}
+
+	changed := realtimeChangedFields(record)
+
+	result := make(map[string]json.RawMessage, len(changed))
+
+	for key, value := range exported {
+		if key == "expand" {
+			continue
+		}
+
+		if _, ok := changed[key]; ok {
+			result[key] = value
+		}
+	}
+
+	// keep only the expands of the changed relation fields
+	if rawExpand, ok := exported["expand"]; ok {
+		expand := map[string]json.RawMessage{}
+		if err := json.Unmarshal(rawExpand, &expand); err != nil {
+			return nil, err
+		}
+
+		for key := range expand {
+			// note: the back-relations (e.g. "comments_via_post") are never part of the diff
+			if _, ok := changed[key]; !ok {
+				delete(expand, key)
+			}
+		}
+
+		if len(expand) > 0 {
+			rawExpand, err = json.Marshal(expand)
+			if err != nil {
+				return nil, err
+			}
+			result["expand"] = rawExpand
+		}
+	}
+
+	return result, nil
+}
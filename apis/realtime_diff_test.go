@@ -0,0 +1,218 @@
+package apis_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+func TestRealtimeRecordDiff(t *testing.T) {
+	t.Parallel()
+
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	// init realtime handlers
+	apis.NewRouter(testApp)
+
+	full := subscriptions.NewDefaultClient()
+	full.Subscribe("demo2/*")
+	testApp.SubscriptionsBroker().Register(full)
+
+	diff := subscriptions.NewDefaultClient()
+	diff.Subscribe(`demo2/*?options={"query":{"diff":"1","expand":"missing"}}`)
+	testApp.SubscriptionsBroker().Register(diff)
+
+	diffFields := subscriptions.NewDefaultClient()
+	diffFields.Subscribe(`demo2/*?options={"query":{"diff":"true","fields":"id,title"}}`)
+	testApp.SubscriptionsBroker().Register(diffFields)
+
+	diffDisabled := subscriptions.NewDefaultClient()
+	diffDisabled.Subscribe(`demo2/*?options={"query":{"diff":"0"}}`)
+	testApp.SubscriptionsBroker().Register(diffDisabled)
+
+	expectRecord := func(client subscriptions.Client, expectedAction string, expectedKeys ...string) map[string]any {
+		t.Helper()
+
+		select {
+		case msg := <-client.Channel():
+			data := struct {
+				Action string         `json:"action"`
+				Record map[string]any `json:"record"`
+			}{}
+			if err := json.Unmarshal(msg.Data, &data); err != nil {
+				t.Fatal(err)
+			}
+
+			if data.Action != expectedAction {
+				t.Fatalf("Expected action %q, got %q", expectedAction, data.Action)
+			}
+
+			keys := make([]string, 0, len(data.Record))
+			for k := range data.Record {
+				keys = append(keys, k)
+			}
+			slices.Sort(keys)
+			slices.Sort(expectedKeys)
+
+			if !slices.Equal(keys, expectedKeys) {
+				t.Fatalf("Expected record keys %v, got %v", expectedKeys, keys)
+			}
+
+			return data.Record
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected %q message, got none", expectedAction)
+		}
+
+		return nil
+	}
+
+	allKeys := []string{"id", "collectionId", "collectionName", "title", "active", "created", "updated"}
+
+	record, err := testApp.FindRecordById("demo2", "achvryl401bhse3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// single field change
+	// ---
+	record.Set("active", false)
+	if err := testApp.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRecord(full, "update", allKeys...)
+	expectRecord(diffDisabled, "update", allKeys...)
+	diffRecord := expectRecord(diff, "update", "id", "active", "updated")
+	if diffRecord["active"] != false {
+		t.Fatalf("Expected active false, got %v", diffRecord["active"])
+	}
+	if diffRecord["updated"] != record.GetDateTime("updated").String() {
+		t.Fatalf("Expected updated %q, got %v", record.GetDateTime("updated").String(), diffRecord["updated"])
+	}
+	expectRecord(diffFields, "update", "id")
+
+	// the fields projection still applies
+	// ---
+	record, err = testApp.FindRecordById("demo2", "achvryl401bhse3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record.Set("title", "diff_title")
+	if err := testApp.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRecord(full, "update", allKeys...)
+	expectRecord(diffDisabled, "update", allKeys...)
+	expectRecord(diff, "update", "id", "title", "updated")
+	diffRecord = expectRecord(diffFields, "update", "id", "title")
+	if diffRecord["title"] != "diff_title" {
+		t.Fatalf("Expected title %q, got %v", "diff_title", diffRecord["title"])
+	}
+
+	// hidden fields are not included even if changed
+	// ---
+	collection, err := testApp.FindCollectionByNameOrId("demo2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection.Fields.GetByName("title").SetHidden(true)
+	if err := testApp.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err = testApp.FindRecordById("demo2", "achvryl401bhse3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record.Set("title", "diff_hidden")
+	record.Set("active", true)
+	if err := testApp.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRecord(full, "update", slices.DeleteFunc(slices.Clone(allKeys), func(k string) bool { return k == "title" })...)
+	expectRecord(diffDisabled, "update", slices.DeleteFunc(slices.Clone(allKeys), func(k string) bool { return k == "title" })...)
+	expectRecord(diff, "update", "id", "active", "updated")
+	expectRecord(diffFields, "update", "id")
+
+	// create and delete events are always sent in full
+	// ---
+	newRecord := core.NewRecord(collection)
+	newRecord.Set("title", "diff_create")
+	if err := testApp.Save(newRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	createKeys := slices.DeleteFunc(slices.Clone(allKeys), func(k string) bool { return k == "title" })
+	expectRecord(diff, "create", createKeys...)
+
+	if err := testApp.Delete(newRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRecord(diff, "delete", createKeys...)
+}
+
+func TestRealtimeRecordDiffExpand(t *testing.T) {
+	t.Parallel()
+
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	// init realtime handlers
+	apis.NewRouter(testApp)
+
+	superuser, err := testApp.FindAuthRecordByEmail(core.CollectionNameSuperusers, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := subscriptions.NewDefaultClient()
+	client.Set(apis.RealtimeClientAuthKey, superuser)
+	client.Subscribe(`demo1/*?options={"query":{"diff":"1","expand":"rel_one,rel_many"}}`)
+	testApp.SubscriptionsBroker().Register(client)
+
+	record, err := testApp.FindRecordById("demo1", "84nmscqy84lsi1t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record.Set("rel_one", "al1h9ijdeojtsjy")
+	if err := testApp.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-client.Channel():
+		data := struct {
+			Record struct {
+				RelOne  string                     `json:"rel_one"`
+				RelMany []string                   `json:"rel_many"`
+				Expand  map[string]json.RawMessage `json:"expand"`
+			} `json:"record"`
+		}{}
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			t.Fatal(err)
+		}
+
+		if data.Record.RelOne != "al1h9ijdeojtsjy" {
+			t.Fatalf("Expected rel_one %q, got %q", "al1h9ijdeojtsjy", data.Record.RelOne)
+		}
+		if data.Record.RelMany != nil {
+			t.Fatalf("Expected no rel_many field, got %v", data.Record.RelMany)
+		}
+		if len(data.Record.Expand) != 1 || data.Record.Expand["rel_one"] == nil {
+			t.Fatalf("Expected only rel_one expand, got %v", data.Record.Expand)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected update message, got none")
+	}
+}
@@ -0,0 +1,174 @@
+package apis
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+// RealtimeGapMessageName is the name of the realtime message sent on replay
+// when some of the events after the client Last-Event-ID are no longer available
+// (e.g. the history buffer was exceeded or the server was restarted).
+//
+// Clients that receive it should refetch the state of their subscriptions.
+const RealtimeGapMessageName = "PB_GAP"
+
+// RealtimeClientLastEventIdKey is the name of the realtime client store key
+// that holds the Last-Event-ID of the reconnected client until its replay.
+const RealtimeClientLastEventIdKey = "lastEventId"
+
+// realtimeHistoryRecord is the realtime history event payload.
+type realtimeHistoryRecord struct {
+	record *core.Record
+	action string
+}
+
+// realtimeGapData represents the gap message data.
+type realtimeGapData struct {
+	LastEventId   string   `json:"lastEventId"`
+	Subscriptions []string `json:"subscriptions"`
+}
+
+// realtimeHistoryPush stores a snapshot of the record event in the app
+// subscriptions broker history (if any) and returns the new event id.
+//
+// Returns empty string if the broker doesn't have a history.
+func realtimeHistoryPush(app core.App, action string, record *core.Record) string {
+	history := app.SubscriptionsBroker().History()
+	if history == nil {
+		return ""
+	}
+
+	id := history.Push(record.Collection().Id, &realtimeHistoryRecord{
+		action: action,
+		record: record.Fresh(),
+	})
+
+	return subscriptions.FormatHistoryId(id)
+}
+
+// realtimeSetClientLastEventId stores the reconnected client Last-Event-ID (if valid)
+// so that the missed events could be replayed once its subscriptions are set.
+func realtimeSetClientLastEventId(client subscriptions.Client, rawLastEventId string) {
+	if _, ok := subscriptions.ParseHistoryId(rawLastEventId); ok {
+		client.Set(RealtimeClientLastEventIdKey, rawLastEventId)
+	}
+}
+
+// realtimeReplayClientHistory sends to the client the buffered events after its
+// stored Last-Event-ID (if any) that match its current subscriptions.
+//
+// The subscription access rules are re-checked against the current db state.
+// A [RealtimeGapMessageName] message is sent before the replayed events if some
+// of the missed events are no longer available or their access couldn't be verified
+// (e.g. the delete events of records with non-public API rules).
+//
+// The Last-Event-ID is unset after the first call so the replay happens only once per connection.
+func realtimeReplayClientHistory(app core.App, client subscriptions.Client) {
+	rawLastEventId, _ := client.Get(RealtimeClientLastEventIdKey).(string)
+	if rawLastEventId == "" {
+		return
+	}
+	client.Unset(RealtimeClientLastEventIdKey)
+
+	history := app.SubscriptionsBroker().History()
+	if history == nil {
+		return
+	}
+
+	lastEventId, ok := subscriptions.ParseHistoryId(rawLastEventId)
+	if !ok {
+		return
+	}
+
+	// resolve the subscribed collections
+	clientSubs := client.Subscriptions()
+	topics := make([]string, 0, len(clientSubs))
+	for sub := range clientSubs {
+		name, _, _ := strings.Cut(sub, "?")
+		name, _, _ = strings.Cut(name, "/")
+
+		collection, err := app.FindCachedCollectionByNameOrId(name)
+		if err != nil {
+			continue
+		}
+
+		topics = append(topics, collection.Id)
+	}
+
+	if len(topics) == 0 {
+		return
+	}
+
+	events, gap := history.Since(lastEventId, topics...)
+
+	var gapSubs []string
+	messages := make([]subscriptions.Message, 0, len(events))
+
+	for _, event := range events {
+		data, ok := event.Data.(*realtimeHistoryRecord)
+		if !ok {
+			continue
+		}
+
+		eventId := subscriptions.FormatHistoryId(event.Id)
+
+		for prefix, rule := range realtimeSubscriptionRuleMap(data.record) {
+			for sub, options := range client.Subscriptions(prefix) {
+				requestInfo := realtimeSubscriptionRequestInfo(client, options)
+
+				if !realtimeCanAccessRecord(app, data.record, requestInfo, rule) {
+					// the deleted records can no longer be checked against the db
+					if data.action == "delete" && !slices.Contains(gapSubs, sub) {
+						gapSubs = append(gapSubs, sub)
+					}
+					continue
+				}
+
+				msg := realtimeRecordMessage(app, app, data.action, data.record, requestInfo, sub, options)
+				if msg == nil {
+					continue
+				}
+				msg.Id = eventId
+
+				messages = append(messages, *msg)
+			}
+		}
+	}
+
+	if gap {
+		gapSubs = gapSubs[:0]
+		for sub := range clientSubs {
+			gapSubs = append(gapSubs, sub)
+		}
+	}
+
+	if len(gapSubs) > 0 {
+		slices.Sort(gapSubs)
+
+		gapData, err := json.Marshal(realtimeGapData{
+			LastEventId:   rawLastEventId,
+			Subscriptions: gapSubs,
+		})
+		if err == nil {
+			messages = slices.Insert(messages, 0, subscriptions.Message{
+				Name: RealtimeGapMessageName,
+				Data: gapData,
+			})
+		}
+	}
+
+	if len(messages) == 0 {
+		return
+	}
+
+	routine.FireAndForget(func() {
+		for _, msg := range messages {
+			client.Send(msg)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package apis_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+func TestRealtimeConnectLastEventId(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"missing", "", ""},
+		{"invalid", "abc", ""},
+		{"valid", "123", "123"},
+	}
+
+	for _, s := range scenarios {
+		scenario := tests.ApiScenario{
+			Name:           "Last-Event-ID " + s.name,
+			Method:         http.MethodGet,
+			URL:            "/api/realtime",
+			Timeout:        100 * time.Millisecond,
+			Headers:        map[string]string{"Last-Event-ID": s.header},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`event:PB_CONNECT`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                        0,
+				"OnRealtimeConnectRequest": 1,
+				"OnRealtimeMessageSend":    1,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				app.OnRealtimeMessageSend().BindFunc(func(e *core.RealtimeMessageEvent) error {
+					v, _ := e.Client.Get(apis.RealtimeClientLastEventIdKey).(string)
+					if v != s.expected {
+						t.Errorf("Expected client Last-Event-ID %q, got %q", s.expected, v)
+					}
+					return e.Next()
+				})
+			},
+		}
+
+		scenario.Test(t)
+	}
+}
+
+func TestRealtimeReplay(t *testing.T) {
+	client := subscriptions.NewDefaultClient()
+
+	saveDemo2 := func(t testing.TB, app core.App, id string) {
+		collection, err := app.FindCollectionByNameOrId("demo2")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record := core.NewRecord(collection)
+		record.Id = id
+		record.Set("title", "replay_"+id)
+		if err := app.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectMessages := func(t testing.TB, expected ...string) {
+		for _, name := range expected {
+			select {
+			case msg := <-client.Channel():
+				if !strings.HasPrefix(msg.Name, name) {
+					t.Fatalf("Expected message %q, got %q (%s)", name, msg.Name, msg.Data)
+				}
+				if name != apis.RealtimeGapMessageName && msg.Id == "" {
+					t.Fatalf("Expected message %q to have an event id", msg.Name)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Expected message %q, got none", name)
+			}
+		}
+
+		select {
+		case msg := <-client.Channel():
+			t.Fatalf("Expected no more messages, got %q (%s)", msg.Name, msg.Data)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		if client.Get(apis.RealtimeClientLastEventIdKey) != nil {
+			t.Fatal("Expected the client Last-Event-ID to be unset after the replay")
+		}
+	}
+
+	var body *strings.Reader
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "replay of the missed accessible events",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				history := subscriptions.NewHistory(10)
+				app.SubscriptionsBroker().SetHistory(history)
+				body.Reset(`{"clientId":"` + client.Id() + `","subscriptions":["demo2/*","users/*"]}`)
+				client.Set(apis.RealtimeClientLastEventIdKey, subscriptions.FormatHistoryId(history.LastId()))
+				app.SubscriptionsBroker().Register(client)
+
+				saveDemo2(t, app, "replay000000001")
+				saveDemo2(t, app, "replay000000002")
+
+				// not accessible for guests
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+				user.Set("name", "replay")
+				if err := app.Save(user); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+				"OnRecordEnrich":             2,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectMessages(t, "demo2/*", "demo2/*")
+			},
+		},
+		{
+			Name:   "buffer exceeded",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				history := subscriptions.NewHistory(1)
+				app.SubscriptionsBroker().SetHistory(history)
+				body.Reset(`{"clientId":"` + client.Id() + `","subscriptions":["demo2/*"]}`)
+				client.Set(apis.RealtimeClientLastEventIdKey, subscriptions.FormatHistoryId(history.LastId()))
+				app.SubscriptionsBroker().Register(client)
+
+				saveDemo2(t, app, "replay000000001")
+				saveDemo2(t, app, "replay000000002")
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+				"OnRecordEnrich":             1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectMessages(t, apis.RealtimeGapMessageName, "demo2/*")
+			},
+		},
+		{
+			Name:   "stale Last-Event-ID",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				history := subscriptions.NewHistory(10)
+				app.SubscriptionsBroker().SetHistory(history)
+				body.Reset(`{"clientId":"` + client.Id() + `","subscriptions":["demo2/*"]}`)
+				client.Set(apis.RealtimeClientLastEventIdKey, "1")
+				app.SubscriptionsBroker().Register(client)
+
+				saveDemo2(t, app, "replay000000001")
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectMessages(t, apis.RealtimeGapMessageName)
+			},
+		},
+		{
+			Name:   "unverifiable delete event",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				history := subscriptions.NewHistory(10)
+				app.SubscriptionsBroker().SetHistory(history)
+				body.Reset(`{"clientId":"` + client.Id() + `","subscriptions":["demo3/*","demo2/*"]}`)
+				client.Set(apis.RealtimeClientLastEventIdKey, subscriptions.FormatHistoryId(history.LastId()))
+				app.SubscriptionsBroker().Register(client)
+
+				demo3, err := app.FindCollectionByNameOrId("demo3")
+				if err != nil {
+					t.Fatal(err)
+				}
+				record := core.NewRecord(demo3)
+				record.Set("title", "replay")
+				if err := app.Save(record); err != nil {
+					t.Fatal(err)
+				}
+				if err := app.Delete(record); err != nil {
+					t.Fatal(err)
+				}
+
+				saveDemo2(t, app, "replay000000001")
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+				"OnRecordEnrich":             1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectMessages(t, apis.RealtimeGapMessageName, "demo2/*")
+			},
+		},
+		{
+			Name:   "without Last-Event-ID",
+			Method: http.MethodPost,
+			URL:    "/api/realtime",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				body.Reset(`{"clientId":"` + client.Id() + `","subscriptions":["demo2/*"]}`)
+				client.Unset(apis.RealtimeClientLastEventIdKey)
+				app.SubscriptionsBroker().Register(client)
+
+				saveDemo2(t, app, "replay000000001")
+			},
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectMessages(t)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		client.Unsubscribe()
+		body = strings.NewReader("")
+		scenario.Body = body
+		scenario.Test(t)
+	}
+}
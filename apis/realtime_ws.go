@@ -0,0 +1,355 @@
+package apis
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	"github.com/pocketbase/pocketbase/tools/websocket"
+)
+
+// In-band WebSocket realtime message types.
+const (
+	RealtimeWSTypeSubscribe   = "subscribe"
+	RealtimeWSTypeUnsubscribe = "unsubscribe"
+	RealtimeWSTypeAuth        = "auth"
+	RealtimeWSTypePing        = "ping"
+	RealtimeWSTypePong        = "pong"
+	RealtimeWSTypeAck         = "ack"
+	RealtimeWSTypeError       = "error"
+	RealtimeWSTypeMessage     = "message"
+)
+
+const (
+	realtimeWSMaxPingInterval = 30 * time.Second
+	realtimeWSWriteTimeout    = 10 * time.Second
+	realtimeWSReadLimit       = 1 << 20
+	realtimeWSMaxSubs         = 1000
+)
+
+// realtimeWSRequest defines a single client->server WebSocket message.
+type realtimeWSRequest struct {
+	Type          string   `json:"type"`
+	Id            string   `json:"id"`
+	Token         string   `json:"token"`
+	Subscriptions []string `json:"subscriptions"`
+}
+
+func (req *realtimeWSRequest) validate() error {
+	return validation.ValidateStruct(req,
+		validation.Field(&req.Type, validation.Required, validation.In(
+			RealtimeWSTypeSubscribe,
+			RealtimeWSTypeUnsubscribe,
+			RealtimeWSTypeAuth,
+			RealtimeWSTypePing,
+		)),
+		validation.Field(&req.Id, validation.Length(0, 255)),
+		validation.Field(&req.Subscriptions,
+			validation.When(req.Type == RealtimeWSTypeSubscribe, validation.Required),
+			validation.Length(0, realtimeWSMaxSubs),
+			validation.Each(validation.Length(1, 2500)),
+		),
+		validation.Field(&req.Token, validation.When(req.Type == RealtimeWSTypeAuth, validation.Required)),
+	)
+}
+
+// realtimeWSResponse defines a single server->client WebSocket message.
+type realtimeWSResponse struct {
+	Type  string           `json:"type"`
+	Id    string           `json:"id,omitempty"`
+	Name  string           `json:"name,omitempty"`
+	Data  any              `json:"data,omitempty"`
+	Error *router.ApiError `json:"error,omitempty"`
+}
+
+// realtimeWebSocketConnect upgrades the request to a WebSocket connection
+// and registers it as a regular realtime subscriptions client.
+//
+// Contrary to the SSE connection, the client subscriptions and auth state
+// are managed in-band with "subscribe", "unsubscribe" and "auth" messages.
+func realtimeWebSocketConnect(e *core.RequestEvent) error {
+	if !websocket.IsUpgradeRequest(e.Request) {
+		return router.NewApiError(http.StatusUpgradeRequired, "Expected WebSocket upgrade request.", nil)
+	}
+
+	connectEvent := new(core.RealtimeConnectRequestEvent)
+	connectEvent.RequestEvent = e
+	connectEvent.Client = subscriptions.NewDefaultClient()
+	connectEvent.IdleTimeout = 5 * time.Minute
+
+	return e.App.OnRealtimeConnectRequest().Trigger(connectEvent, func(ce *core.RealtimeConnectRequestEvent) error {
+		conn, err := websocket.Upgrade(ce.Response, ce.Request)
+		if err != nil {
+			ce.App.Logger().Debug("Failed to upgrade the realtime WebSocket connection.", slog.String("error", err.Error()))
+			return nil // the error response is already written
+		}
+		defer conn.Close()
+
+		idleTimeout := ce.IdleTimeout
+		if idleTimeout <= 0 {
+			idleTimeout = 5 * time.Minute
+		}
+
+		conn.SetReadLimit(realtimeWSReadLimit)
+		conn.SetWriteTimeout(realtimeWSWriteTimeout)
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		conn.SetPongHandler(func(data []byte) {
+			conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		})
+
+		// register new subscription client
+		ce.Client.Set(RealtimeClientAuthKey, ce.Auth)
+		ce.App.SubscriptionsBroker().Register(ce.Client)
+		defer func() {
+			realtimePresenceLeaveAll(e.App, ce.Client)
+			e.App.SubscriptionsBroker().Unregister(ce.Client.Id())
+		}()
+
+		ce.App.Logger().Debug("Realtime WebSocket connection established.", slog.String("clientId", ce.Client.Id()))
+
+		// signalize established connection (aka. fire "connect" message)
+		connectMsgErr := realtimeWSSendMessage(conn, ce, subscriptions.Message{
+			Name: "PB_CONNECT",
+			Data: []byte(`{"clientId":"` + ce.Client.Id() + `"}`),
+		})
+		if connectMsgErr != nil {
+			ce.App.Logger().Debug(
+				"Realtime WebSocket connection closed (failed to deliver PB_CONNECT)",
+				slog.String("clientId", ce.Client.Id()),
+				slog.String("error", connectMsgErr.Error()),
+			)
+			return nil
+		}
+
+		// read the client messages in a separate goroutine and
+		// process them in the main loop together with the broadcasts
+		requests := make(chan []byte)
+		readDone := make(chan error, 1)
+		loopDone := make(chan struct{})
+		defer close(loopDone)
+		go func() {
+			for {
+				messageType, data, err := conn.ReadMessage()
+				if err != nil {
+					readDone <- err
+					return
+				}
+
+				conn.SetReadDeadline(time.Now().Add(idleTimeout))
+
+				if messageType != websocket.TextMessage {
+					continue
+				}
+
+				select {
+				case requests <- data:
+				case <-loopDone:
+					return
+				}
+			}
+		}()
+
+		pingTicker := time.NewTicker(min(realtimeWSMaxPingInterval, idleTimeout/2))
+		defer pingTicker.Stop()
+
+		for {
+			select {
+			case <-pingTicker.C:
+				if err := conn.WritePing(nil); err != nil {
+					ce.App.Logger().Debug(
+						"Realtime WebSocket connection closed (failed to send ping)",
+						slog.String("clientId", ce.Client.Id()),
+						slog.String("error", err.Error()),
+					)
+					return nil
+				}
+			case data := <-requests:
+				if err := realtimeWSHandleRequest(conn, ce, data); err != nil {
+					ce.App.Logger().Debug(
+						"Realtime WebSocket connection closed (failed to reply)",
+						slog.String("clientId", ce.Client.Id()),
+						slog.String("error", err.Error()),
+					)
+					return nil
+				}
+			case msg, ok := <-ce.Client.Channel():
+				if !ok {
+					// channel is closed
+					conn.WriteClose(websocket.CloseGoingAway, "")
+					ce.App.Logger().Debug(
+						"Realtime WebSocket connection closed (closed channel)",
+						slog.String("clientId", ce.Client.Id()),
+					)
+					return nil
+				}
+
+				if err := realtimeWSSendMessage(conn, ce, msg); err != nil {
+					ce.App.Logger().Debug(
+						"Realtime WebSocket connection closed (failed to deliver message)",
+						slog.String("clientId", ce.Client.Id()),
+						slog.String("error", err.Error()),
+					)
+					return nil
+				}
+			case err := <-readDone:
+				// connection is closed or idle
+				ce.App.Logger().Debug(
+					"Realtime WebSocket connection closed",
+					slog.String("clientId", ce.Client.Id()),
+					slog.String("reason", err.Error()),
+				)
+				return nil
+			case <-ce.Request.Context().Done():
+				conn.WriteClose(websocket.CloseGoingAway, "")
+				ce.App.Logger().Debug(
+					"Realtime WebSocket connection closed (cancelled request)",
+					slog.String("clientId", ce.Client.Id()),
+				)
+				return nil
+			}
+		}
+	})
+}
+
+// realtimeWSSendMessage delivers a single broker message through the
+// OnRealtimeMessageSend hook as a WebSocket text frame.
+func realtimeWSSendMessage(conn *websocket.Conn, ce *core.RealtimeConnectRequestEvent, msg subscriptions.Message) error {
+	msgEvent := new(core.RealtimeMessageEvent)
+	msgEvent.RequestEvent = ce.RequestEvent
+	msgEvent.Client = ce.Client
+	msgEvent.Message = &msg
+
+	return ce.App.OnRealtimeMessageSend().Trigger(msgEvent, func(me *core.RealtimeMessageEvent) error {
+		var data any = string(me.Message.Data)
+		if json.Valid(me.Message.Data) {
+			data = json.RawMessage(me.Message.Data)
+		}
+
+		return realtimeWSWrite(conn, realtimeWSResponse{
+			Type: RealtimeWSTypeMessage,
+			Name: me.Message.Name,
+			Data: data,
+		})
+	})
+}
+
+// realtimeWSHandleRequest processes a single in-band client message and writes its reply.
+//
+// Only the connection write errors are returned.
+// The request errors are reported to the client as "error" messages.
+func realtimeWSHandleRequest(conn *websocket.Conn, ce *core.RealtimeConnectRequestEvent, data []byte) error {
+	req := new(realtimeWSRequest)
+
+	if err := json.Unmarshal(data, req); err != nil {
+		return realtimeWSWriteError(conn, "", router.NewBadRequestError("Invalid message format.", err))
+	}
+
+	if err := req.validate(); err != nil {
+		return realtimeWSWriteError(conn, req.Id, router.NewBadRequestError("", err))
+	}
+
+	var reqErr error
+
+	switch req.Type {
+	case RealtimeWSTypePing:
+		return realtimeWSWrite(conn, realtimeWSResponse{Type: RealtimeWSTypePong, Id: req.Id})
+	case RealtimeWSTypeAuth:
+		reqErr = realtimeWSAuth(ce, req.Token)
+	case RealtimeWSTypeSubscribe:
+		reqErr = realtimeWSSubscribe(ce, req.Subscriptions)
+	case RealtimeWSTypeUnsubscribe:
+		ce.Client.Unsubscribe(req.Subscriptions...)
+
+		ce.App.Logger().Debug(
+			"Realtime subscriptions removed.",
+			slog.String("clientId", ce.Client.Id()),
+			slog.Any("subscriptions", req.Subscriptions),
+		)
+	}
+
+	if reqErr != nil {
+		return realtimeWSWriteError(conn, req.Id, reqErr)
+	}
+
+	return realtimeWSWrite(conn, realtimeWSResponse{Type: RealtimeWSTypeAck, Id: req.Id})
+}
+
+// realtimeWSAuth authenticates the WebSocket client with the provided auth token.
+//
+// Similar to the SSE subscriptions, only guest->auth upgrades
+// (or repeated auth with the same record) are allowed.
+func realtimeWSAuth(ce *core.RealtimeConnectRequestEvent, token string) error {
+	authRecord, err := ce.App.FindAuthRecordByToken(token, core.TokenTypeAuth)
+	if err != nil {
+		return router.NewUnauthorizedError("Missing or invalid auth token.", err)
+	}
+
+	clientAuth, _ := ce.Client.Get(RealtimeClientAuthKey).(*core.Record)
+	if clientAuth != nil && !isSameAuth(clientAuth, authRecord) {
+		return router.NewForbiddenError("The current and the previous client authorization don't match.", nil)
+	}
+
+	ce.Auth = authRecord
+	ce.Client.Set(RealtimeClientAuthKey, authRecord)
+
+	ce.App.Logger().Debug(
+		"Realtime WebSocket client authenticated.",
+		slog.String("clientId", ce.Client.Id()),
+		slog.String("authId", authRecord.Id),
+	)
+
+	return nil
+}
+
+// realtimeWSSubscribe adds the provided subscriptions to the WebSocket client
+// through the OnRealtimeSubscribeRequest hook.
+//
+// Note that unlike the SSE POST /api/realtime, the existing client
+// subscriptions are preserved.
+func realtimeWSSubscribe(ce *core.RealtimeConnectRequestEvent, subs []string) error {
+	if len(ce.Client.Subscriptions())+len(subs) > realtimeWSMaxSubs {
+		return router.NewBadRequestError("Too many subscriptions.", nil)
+	}
+
+	event := new(core.RealtimeSubscribeRequestEvent)
+	event.RequestEvent = ce.RequestEvent
+	event.Client = ce.Client
+	event.Subscriptions = subs
+
+	return ce.App.OnRealtimeSubscribeRequest().Trigger(event, func(e *core.RealtimeSubscribeRequestEvent) error {
+		// sync the auth state in case it was changed by a hook
+		e.Client.Set(RealtimeClientAuthKey, e.Auth)
+
+		e.Client.Subscribe(e.Subscriptions...)
+
+		e.App.Logger().Debug(
+			"Realtime subscriptions added.",
+			slog.String("clientId", e.Client.Id()),
+			slog.Any("subscriptions", e.Subscriptions),
+		)
+
+		return nil
+	})
+}
+
+func realtimeWSWriteError(conn *websocket.Conn, id string, err error) error {
+	return realtimeWSWrite(conn, realtimeWSResponse{
+		Type:  RealtimeWSTypeError,
+		Id:    id,
+		Error: router.ToApiError(err),
+	})
+}
+
+func realtimeWSWrite(conn *websocket.Conn, res realtimeWSResponse) error {
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	return conn.WriteMessage(websocket.TextMessage, raw)
+}
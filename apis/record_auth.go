@@ -28,14 +28,52 @@ func bindRecordAuthApi(app core.App, rg *router.RouterGroup[*core.RequestEvent])
 		RequireSameCollectionContextAuth(""),
 	)
 
+	sub.POST("/auth-with-refresh-token", recordAuthWithRefreshToken).Bind(
+		collectionPathRateLimit("", "authWithRefreshToken", "auth"),
+	)
+	sub.POST("/api-keys", recordCreateAPIKey).Bind(
+		collectionPathRateLimit("", "createAPIKey"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.GET("/sessions", recordAuthSessionsList).Bind(
+		collectionPathRateLimit("", "listSessions"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.DELETE("/sessions/{id}", recordAuthSessionDelete).Bind(
+		collectionPathRateLimit("", "deleteSession"),
+		RequireSameCollectionContextAuth(""),
+	)
+
+	sub.GET("/lockouts", recordAuthLockoutsList).Bind(RequireSuperuserAuth())
+	sub.DELETE("/lockouts/{id}", recordAuthLockoutDelete).Bind(RequireSuperuserAuth())
+
 	sub.POST("/auth-with-password", recordAuthWithPassword).Bind(
 		collectionPathRateLimit("", "authWithPassword", "auth"),
 	)
+	sub.POST("/change-expired-password", recordChangeExpiredPassword).Bind(
+		collectionPathRateLimit("", "changeExpiredPassword", "auth"),
+	)
 
 	sub.POST("/auth-with-oauth2", recordAuthWithOAuth2).Bind(
 		collectionPathRateLimit("", "authWithOAuth2", "auth"),
 	)
 
+	sub.POST("/auth-with-ldap", recordAuthWithLDAP).Bind(
+		collectionPathRateLimit("", "authWithLDAP", "auth"),
+	)
+
+	sub.GET("/saml/metadata", recordSAMLMetadata)
+	sub.GET("/saml/login", recordSAMLLogin).Bind(
+		collectionPathRateLimit("", "samlLogin"),
+	)
+	sub.POST("/saml/acs", recordSAMLACS).Bind(
+		collectionPathRateLimit("", "samlACS"),
+		BodyLimit(samlMaxResponseLength*2),
+	)
+	sub.POST("/auth-with-saml", recordAuthWithSAML).Bind(
+		collectionPathRateLimit("", "authWithSAML", "auth"),
+	)
+
 	sub.POST("/request-otp", recordRequestOTP).Bind(
 		collectionPathRateLimit("", "requestOTP"),
 	)
@@ -43,6 +81,61 @@ func bindRecordAuthApi(app core.App, rg *router.RouterGroup[*core.RequestEvent])
 		collectionPathRateLimit("", "authWithOTP", "auth"),
 	)
 
+	sub.POST("/webauthn-auth-options", recordWebAuthnAuthOptions).Bind(
+		collectionPathRateLimit("", "webauthnAuthOptions"),
+	)
+	sub.POST("/auth-with-webauthn", recordAuthWithWebAuthn).Bind(
+		collectionPathRateLimit("", "authWithWebAuthn", "auth"),
+	)
+	sub.POST("/webauthn-register-options", recordWebAuthnRegisterOptions).Bind(
+		collectionPathRateLimit("", "webauthnRegisterOptions"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.POST("/webauthn-register", recordWebAuthnRegister).Bind(
+		collectionPathRateLimit("", "webauthnRegister"),
+		RequireSameCollectionContextAuth(""),
+	)
+
+	sub.POST("/auth-with-totp", recordAuthWithTOTP).Bind(
+		collectionPathRateLimit("", "authWithTOTP", "auth"),
+	)
+	sub.POST("/totp-setup", recordTOTPSetup).Bind(
+		collectionPathRateLimit("", "totpSetup"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.POST("/totp-confirm", recordTOTPConfirm).Bind(
+		collectionPathRateLimit("", "totpConfirm"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.POST("/totp-recovery-codes", recordTOTPRecoveryCodes).Bind(
+		collectionPathRateLimit("", "totpRecoveryCodes"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.POST("/totp-disable", recordTOTPDisable).Bind(
+		collectionPathRateLimit("", "totpDisable"),
+		RequireSameCollectionContextAuth(""),
+	)
+
+	oidc := sub.Group("/oidc")
+	oidc.GET("/.well-known/openid-configuration", recordOIDCDiscovery)
+	oidc.GET("/authorize", recordOIDCAuthorizeRedirect).Bind(
+		collectionPathRateLimit("", "oidcAuthorize"),
+		SkipSuccessActivityLog(), // skip success log as it could contain sensitive information in the url
+	)
+	oidc.GET("/consent", recordOIDCConsent).Bind(
+		collectionPathRateLimit("", "oidcConsent"),
+		RequireSameCollectionContextAuth(""),
+	)
+	oidc.POST("/authorize", recordOIDCAuthorize).Bind(
+		collectionPathRateLimit("", "oidcAuthorize"),
+		RequireSameCollectionContextAuth(""),
+	)
+	oidc.POST("/token", recordOIDCToken).Bind(
+		collectionPathRateLimit("", "oidcToken", "auth"),
+	)
+	oidc.GET("/userinfo", recordOIDCUserInfo)
+	oidc.POST("/userinfo", recordOIDCUserInfo)
+
 	sub.POST("/request-password-reset", recordRequestPasswordReset).Bind(
 		collectionPathRateLimit("", "requestPasswordReset"),
 	)
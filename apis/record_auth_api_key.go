@@ -0,0 +1,198 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// apiKeyUsageUpdateInterval is the min interval between
+// the API key "lastUsed" updates for the same IP.
+const apiKeyUsageUpdateInterval = 1 * time.Minute
+
+func recordCreateAPIKey(e *core.RequestEvent) error {
+	collection := e.Auth.Collection()
+
+	if !collection.APIKeys.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow API keys.", nil)
+	}
+
+	form := &createAPIKeyForm{app: e.App, collection: collection}
+	if err := e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err := form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	apiKey := core.NewAPIKey(e.App)
+	apiKey.SetCollectionRef(collection.Id)
+	apiKey.SetRecordRef(e.Auth.Id)
+	apiKey.SetName(form.Name)
+	apiKey.SetScopes(form.Scopes)
+	apiKey.SetExpires(form.Expires)
+	plain := apiKey.RefreshValue()
+
+	event := new(core.RecordCreateAPIKeyRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+	event.Record = e.Auth
+	event.APIKey = apiKey
+
+	return e.App.OnRecordCreateAPIKeyRequest().Trigger(event, func(e *core.RecordCreateAPIKeyRequestEvent) error {
+		if err := e.App.Save(e.APIKey); err != nil {
+			return firstApiError(err, e.BadRequestError("Failed to create API key.", err))
+		}
+
+		return execAfterSuccessTx(true, e.App, func() error {
+			// note: the plain key is returned only once
+			return e.JSON(http.StatusOK, map[string]any{
+				"key":    plain,
+				"apiKey": e.APIKey,
+			})
+		})
+	})
+}
+
+// -------------------------------------------------------------------
+
+// isAPIKeyAuthRoute checks whether the current request route
+// accepts API keys as auth credentials (aka. the records CRUD and the batch routes).
+func isAPIKeyAuthRoute(e *core.RequestEvent) bool {
+	pattern := e.Request.Pattern
+
+	// strip the method part (if any)
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = path
+	}
+
+	return pattern == "/api/batch" ||
+		pattern == "/api/collections/{collection}/records" ||
+		strings.HasPrefix(pattern, "/api/collections/{collection}/records/")
+}
+
+// loadAPIKeyAuth loads the auth record of the provided plain API key
+// into the request event and updates the API key usage info.
+func loadAPIKeyAuth(e *core.RequestEvent, plain string) error {
+	if !isAPIKeyAuthRoute(e) {
+		return errors.New("API keys are not allowed for the current route")
+	}
+
+	apiKey, err := e.App.FindAPIKeyByValue(plain)
+	if err != nil {
+		return err
+	}
+
+	if apiKey.HasExpired() {
+		return errors.New("the API key is expired")
+	}
+
+	record, err := e.App.FindRecordById(apiKey.CollectionRef(), apiKey.RecordRef())
+	if err != nil {
+		return err
+	}
+
+	if !record.Collection().APIKeys.Enabled {
+		return errors.New("the API keys are disabled for the record collection")
+	}
+
+	// track the key usage (throttled to avoid a write on each request)
+	ip := e.RealIP()
+	if ip != apiKey.LastUsedIP() || time.Since(apiKey.LastUsed().Time()) > apiKeyUsageUpdateInterval {
+		apiKey.SetLastUsed(types.NowDateTime())
+		apiKey.SetLastUsedIP(ip)
+		if err := e.App.Save(apiKey); err != nil {
+			e.App.Logger().Warn("Failed to update the API key usage", "error", err, "apiKeyId", apiKey.Id)
+		}
+	}
+
+	e.Auth = record
+	e.Set(core.RequestEventKeyAPIKey, apiKey)
+
+	return nil
+}
+
+// checkAPIKeyScope checks whether the API key of the current request
+// (if any) allows the specified collection action.
+func checkAPIKeyScope(e *core.RequestEvent, collection *core.Collection, action string) error {
+	apiKey := e.APIKey()
+	if apiKey == nil || apiKey.HasScope(collection, action) {
+		return nil
+	}
+
+	return e.ForbiddenError("The API key is not allowed to perform the requested action.", nil)
+}
+
+// -------------------------------------------------------------------
+
+type createAPIKeyForm struct {
+	app        core.App
+	collection *core.Collection
+
+	Name    string         `form:"name" json:"name"`
+	Scopes  []string       `form:"scopes" json:"scopes"`
+	Expires types.DateTime `form:"expires" json:"expires"`
+}
+
+func (form *createAPIKeyForm) validate() error {
+	maxDuration := form.collection.APIKeys.MaxDurationTime()
+
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&form.Scopes, validation.Required, validation.Length(1, 100), validation.By(form.checkScopes)),
+		validation.Field(
+			&form.Expires,
+			validation.When(maxDuration > 0, validation.Required),
+			validation.By(form.checkExpires(maxDuration)),
+		),
+	)
+}
+
+func (form *createAPIKeyForm) checkScopes(value any) error {
+	scopes, _ := value.([]string)
+
+	for _, scope := range scopes {
+		collection, _, ok := core.ParseAPIKeyScope(scope)
+		if !ok {
+			return validation.NewError("validation_invalid_api_key_scope", "Invalid scope {{.scope}} - must be in the format collection:action.").
+				SetParams(map[string]any{"scope": scope})
+		}
+
+		if collection == "*" {
+			continue
+		}
+
+		if _, err := form.app.FindCachedCollectionByNameOrId(collection); err != nil {
+			return validation.NewError("validation_missing_api_key_scope_collection", "Missing scope collection {{.collection}}.").
+				SetParams(map[string]any{"collection": collection})
+		}
+	}
+
+	return nil
+}
+
+func (form *createAPIKeyForm) checkExpires(maxDuration time.Duration) validation.RuleFunc {
+	return func(value any) error {
+		v, _ := value.(types.DateTime)
+		if v.IsZero() {
+			return nil // nothing to check
+		}
+
+		now := time.Now()
+
+		if !v.Time().After(now) {
+			return validation.NewError("validation_expires_in_the_past", "The expiration date must be in the future.")
+		}
+
+		if maxDuration > 0 && v.Time().After(now.Add(maxDuration)) {
+			return validation.NewError("validation_expires_too_far", "The expiration date exceeds the max allowed API key duration.")
+		}
+
+		return nil
+	}
+}
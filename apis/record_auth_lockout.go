@@ -0,0 +1,239 @@
+package apis
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/mails"
+	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// authLockoutItem is the public representation of a single auth lockout.
+type authLockoutItem struct {
+	Id          string         `json:"id"`
+	Type        string         `json:"type"`
+	Subject     string         `json:"subject"`
+	Failures    int            `json:"failures"`
+	Lockouts    int            `json:"lockouts"`
+	LastFailure types.DateTime `json:"lastFailure"`
+	LastIP      string         `json:"lastIP"`
+	LockedUntil types.DateTime `json:"lockedUntil"`
+	Locked      bool           `json:"locked"`
+}
+
+func recordAuthLockoutsList(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	lockouts, err := e.App.FindAllAuthLockoutsByCollection(collection)
+	if err != nil {
+		return e.InternalServerError("Failed to load the auth lockouts.", err)
+	}
+
+	result := make([]authLockoutItem, 0, len(lockouts))
+	for _, l := range lockouts {
+		result = append(result, authLockoutItem{
+			Id:          l.Id,
+			Type:        l.Type(),
+			Subject:     l.Subject(),
+			Failures:    l.Failures(),
+			Lockouts:    l.Lockouts(),
+			LastFailure: l.LastFailure(),
+			LastIP:      l.LastIP(),
+			LockedUntil: l.LockedUntil(),
+			Locked:      l.IsLocked(),
+		})
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func recordAuthLockoutDelete(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	lockout := &core.AuthLockout{}
+
+	err = e.App.RecordQuery(core.CollectionNameAuthLockouts).
+		AndWhere(dbx.HashExp{"id": e.Request.PathValue("id")}).
+		Limit(1).
+		One(lockout)
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	if lockout.CollectionRef() != collection.Id {
+		return e.NotFoundError("", errors.New("the lockout belongs to a different collection"))
+	}
+
+	if err := e.App.Delete(lockout); err != nil {
+		return firstApiError(err, e.InternalServerError("Failed to clear the auth lockout.", err))
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// -------------------------------------------------------------------
+
+// authLockoutIdentity returns the lockout subject of an auth identity
+// (the auth record id if it exists, otherwise the normalized submitted identity).
+//
+// Note that the identity lockouts are tracked even for non-existing
+// records to avoid leaking whether an account exists.
+func authLockoutIdentity(authRecord *core.Record, identity string) string {
+	if authRecord != nil {
+		return authRecord.Id
+	}
+
+	return strings.ToLower(strings.TrimSpace(identity))
+}
+
+// checkAuthLockout returns a 429 error if the specified identity or
+// the current request IP are temporarily locked.
+func checkAuthLockout(e *core.RequestEvent, collection *core.Collection, identity string) error {
+	if !collection.Lockout.Enabled {
+		return nil
+	}
+
+	subjects := map[string]string{
+		core.AuthLockoutTypeIdentity: identity,
+		core.AuthLockoutTypeIP:       e.RealIP(),
+	}
+
+	var lockedUntil time.Time
+	for lockoutType, subject := range subjects {
+		if subject == "" {
+			continue
+		}
+
+		lockout, err := e.App.FindAuthLockout(collection.Id, lockoutType, subject)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				e.App.Logger().Warn("Failed to load auth lockout", "error", err, "type", lockoutType)
+			}
+			continue
+		}
+
+		if lockout.IsLocked() && lockout.LockedUntil().Time().After(lockedUntil) {
+			lockedUntil = lockout.LockedUntil().Time()
+		}
+	}
+
+	if lockedUntil.IsZero() {
+		return nil
+	}
+
+	retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+	e.Response.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+
+	return e.TooManyRequestsError("Too many failed attempts, please try again later.", nil)
+}
+
+// registerAuthFailure increments the failed auth attempts counters of
+// the specified identity and the current request IP and locks them if
+// their collection lockout limits are reached.
+//
+// authRecord is optional and it is used to send the lockout alert
+// (if the collection auth alerts are enabled).
+func registerAuthFailure(e *core.RequestEvent, collection *core.Collection, identity string, authRecord *core.Record) {
+	if !collection.Lockout.Enabled {
+		return
+	}
+
+	ip := e.RealIP()
+
+	identityLockout, identityLocked := saveAuthFailure(e, collection, core.AuthLockoutTypeIdentity, identity, collection.Lockout.MaxFailures)
+	saveAuthFailure(e, collection, core.AuthLockoutTypeIP, ip, collection.Lockout.MaxIPFailures)
+
+	if !identityLocked || authRecord == nil || authRecord.Email() == "" || !collection.AuthAlert.Enabled {
+		return
+	}
+
+	info := fmt.Sprintf(
+		"%s - %d failed login attempts (last from %s). The account is locked until %s.",
+		types.NowDateTime().String(),
+		collection.Lockout.MaxFailures,
+		ip,
+		identityLockout.LockedUntil().String(),
+	)
+
+	// send the alert in the background to not delay the auth failure
+	// response (and to not reveal the lockout through the response time)
+	app := e.App
+	routine.FireAndForget(func() {
+		if err := mails.SendRecordLockoutAlert(app, authRecord, info); err != nil {
+			app.Logger().Warn("Failed to send lockout alert", "error", err, "recordId", authRecord.Id)
+		}
+	})
+}
+
+func saveAuthFailure(e *core.RequestEvent, collection *core.Collection, lockoutType string, subject string, maxFailures int) (*core.AuthLockout, bool) {
+	if subject == "" || maxFailures <= 0 {
+		return nil, false
+	}
+
+	var lockout *core.AuthLockout
+	var locked bool
+
+	// the lockout is loaded and updated (or created) in a single write transaction
+	// so that the concurrent failures of the same subject are serialized
+	// and none of them is lost (or fails on the unique subject index)
+	err := e.App.RunInTransaction(func(txApp core.App) error {
+		var err error
+
+		lockout, err = txApp.FindAuthLockout(collection.Id, lockoutType, subject)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			lockout = core.NewAuthLockout(txApp)
+			lockout.SetCollectionRef(collection.Id)
+			lockout.SetType(lockoutType)
+			lockout.SetSubject(subject)
+		}
+
+		locked = lockout.RegisterFailure(collection.Lockout, maxFailures, e.RealIP())
+
+		return txApp.Save(lockout)
+	})
+	if err != nil {
+		e.App.Logger().Warn("Failed to save auth failure", "error", err, "type", lockoutType)
+		return lockout, false
+	}
+
+	return lockout, locked
+}
+
+// clearAuthFailures resets the failed auth attempts of the specified identity
+// (usually after successful credentials check).
+//
+// The current request IP failures are not reset to prevent attackers
+// from resetting them by periodically logging into their own account.
+func clearAuthFailures(e *core.RequestEvent, collection *core.Collection, identity string) {
+	if !collection.Lockout.Enabled || identity == "" {
+		return
+	}
+
+	lockout, err := e.App.FindAuthLockout(collection.Id, core.AuthLockoutTypeIdentity, identity)
+	if err != nil {
+		return // nothing to clear
+	}
+
+	if err := e.App.Delete(lockout); err != nil {
+		e.App.Logger().Warn("Failed to clear auth failures", "error", err, "lockoutId", lockout.Id)
+	}
+}
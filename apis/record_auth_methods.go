@@ -21,6 +21,24 @@ type mfaResponse struct {
 	Duration int64 `json:"duration"` // in seconds
 }
 
+type webauthnResponse struct {
+	Enabled  bool  `json:"enabled"`
+	Duration int64 `json:"duration"` // in seconds
+}
+
+type totpResponse struct {
+	Enabled bool `json:"enabled"`
+	Digits  int  `json:"digits"`
+}
+
+type ldapResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+type samlResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
 type passwordResponse struct {
 	IdentityFields []string `json:"identityFields"`
 	Enabled        bool     `json:"enabled"`
@@ -54,6 +72,10 @@ type authMethodsResponse struct {
 	OAuth2   oauth2Response   `json:"oauth2"`
 	MFA      mfaResponse      `json:"mfa"`
 	OTP      otpResponse      `json:"otp"`
+	WebAuthn webauthnResponse `json:"webauthn"`
+	TOTP     totpResponse     `json:"totp"`
+	LDAP     ldapResponse     `json:"ldap"`
+	SAML     samlResponse     `json:"saml"`
 
 	// legacy fields
 	// @todo remove after dropping v0.22 support
@@ -91,6 +113,18 @@ func recordAuthMethods(e *core.RequestEvent) error {
 		MFA: mfaResponse{
 			Enabled: collection.MFA.Enabled,
 		},
+		WebAuthn: webauthnResponse{
+			Enabled: collection.WebAuthn.Enabled,
+		},
+		TOTP: totpResponse{
+			Enabled: collection.TOTP.Enabled,
+		},
+		LDAP: ldapResponse{
+			Enabled: collection.LDAP.Enabled,
+		},
+		SAML: samlResponse{
+			Enabled: collection.SAML.Enabled,
+		},
 	}
 
 	if collection.PasswordAuth.Enabled {
@@ -106,6 +140,14 @@ func recordAuthMethods(e *core.RequestEvent) error {
 		result.MFA.Duration = collection.MFA.Duration
 	}
 
+	if collection.WebAuthn.Enabled {
+		result.WebAuthn.Duration = collection.WebAuthn.Duration
+	}
+
+	if collection.TOTP.Enabled {
+		result.TOTP.Digits = collection.TOTP.Digits
+	}
+
 	if !collection.OAuth2.Enabled {
 		result.fillLegacyFields()
 
@@ -38,6 +38,10 @@ func TestRecordAuthMethodsList(t *testing.T) {
 				`"oauth2":{"providers":[],"enabled":false}`,
 				`"mfa":{"enabled":false,"duration":0}`,
 				`"otp":{"enabled":false,"duration":0}`,
+				`"webauthn":{"enabled":false,"duration":0}`,
+				`"totp":{"enabled":false,"digits":0}`,
+				`"ldap":{"enabled":false}`,
+				`"saml":{"enabled":false}`,
 			},
 			ExpectedEvents: map[string]int{"*": 0},
 		},
@@ -50,6 +54,10 @@ func TestRecordAuthMethodsList(t *testing.T) {
 				`"password":{"identityFields":["email","username"],"enabled":true}`,
 				`"mfa":{"enabled":true,"duration":1800}`,
 				`"otp":{"enabled":true,"duration":300}`,
+				`"webauthn":{"enabled":false,"duration":0}`,
+				`"totp":{"enabled":false,"digits":0}`,
+				`"ldap":{"enabled":false}`,
+				`"saml":{"enabled":false}`,
 				`"oauth2":{`,
 				`"providers":[{`,
 				`"name":"google"`,
@@ -0,0 +1,440 @@
+package apis
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/spf13/cast"
+)
+
+// OAuth2 error codes (see https://www.rfc-editor.org/rfc/rfc6749#section-4.1.2.1).
+const (
+	oidcErrorInvalidRequest          = "invalid_request"
+	oidcErrorInvalidClient           = "invalid_client"
+	oidcErrorInvalidGrant            = "invalid_grant"
+	oidcErrorInvalidScope            = "invalid_scope"
+	oidcErrorAccessDenied            = "access_denied"
+	oidcErrorUnsupportedResponseType = "unsupported_response_type"
+	oidcErrorUnsupportedGrantType    = "unsupported_grant_type"
+)
+
+// oidcError defines an OAuth2 protocol error that is returned
+// to the client as redirect_uri or token endpoint error response.
+type oidcError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// Error implements the [error] interface.
+func (err *oidcError) Error() string {
+	return err.Code + ": " + err.Description
+}
+
+func checkOIDCProvider(e *core.RequestEvent) (*core.Collection, error) {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if !collection.OIDC.Enabled {
+		return nil, e.NotFoundError("The collection is not configured to act as OIDC provider.", nil)
+	}
+
+	if !e.App.Settings().JWT.IsAsymmetric() {
+		return nil, e.InternalServerError("The OIDC provider requires asymmetric JWT settings algorithm.", nil)
+	}
+
+	return collection, nil
+}
+
+// -------------------------------------------------------------------
+
+func recordOIDCDiscovery(e *core.RequestEvent) error {
+	collection, err := checkOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	issuer := core.OIDCIssuer(e.App, collection)
+
+	scopes := slices.Clone(core.OIDCScopes)
+	if collection.OIDC.RefreshTokenDuration <= 0 {
+		scopes = list.SubtractSlice(scopes, []string{core.OIDCScopeOfflineAccess})
+	}
+
+	grantTypes := []string{"authorization_code"}
+	if collection.OIDC.RefreshTokenDuration > 0 {
+		grantTypes = append(grantTypes, "refresh_token")
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"userinfo_endpoint":                     issuer + "/userinfo",
+		"jwks_uri":                              strings.TrimRight(e.App.Settings().Meta.AppURL, "/") + "/.well-known/jwks.json",
+		"scopes_supported":                      scopes,
+		"response_types_supported":              []string{"code"},
+		"response_modes_supported":              []string{"query"},
+		"grant_types_supported":                 grantTypes,
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{e.App.Settings().JWT.Algorithm},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported": []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce",
+			"name", "preferred_username", "updated_at", "email", "email_verified",
+		},
+		"authorization_response_iss_parameter_supported": true,
+	})
+}
+
+// -------------------------------------------------------------------
+
+type oidcAuthorizeForm struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientId            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+
+	// Approve is used only with the POST authorize request
+	// and specifies whether the user has approved the consent.
+	Approve bool `form:"approve" json:"approve"`
+}
+
+// resolveClient loads and validates the form client and its redirect_uri.
+//
+// The returned error is not safe to be returned as redirect
+// since the redirect_uri couldn't be verified.
+func (form *oidcAuthorizeForm) resolveClient(e *core.RequestEvent, collection *core.Collection) (*core.OIDCClient, error) {
+	err := validation.ValidateStruct(form,
+		validation.Field(&form.ClientId, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.RedirectURI, validation.Required, validation.Length(1, 2000)),
+	)
+	if err != nil {
+		return nil, firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	client, err := e.App.FindOIDCClient(collection, form.ClientId)
+	if err != nil {
+		return nil, e.BadRequestError("Invalid or unknown OIDC client.", err)
+	}
+
+	if !client.HasRedirectURI(form.RedirectURI) {
+		return nil, e.BadRequestError("The redirect_uri is not registered for the OIDC client.", nil)
+	}
+
+	return client, nil
+}
+
+// resolveScopes validates the rest of the authorization request
+// parameters and returns the normalized list of the requested scopes.
+func (form *oidcAuthorizeForm) resolveScopes(collection *core.Collection, client *core.OIDCClient) ([]string, *oidcError) {
+	if form.ResponseType != "code" {
+		return nil, &oidcError{oidcErrorUnsupportedResponseType, `Only the "code" response type is supported.`}
+	}
+
+	scopes := list.NonzeroUniques(strings.Fields(form.Scope))
+
+	if !slices.Contains(scopes, core.OIDCScopeOpenId) {
+		return nil, &oidcError{oidcErrorInvalidScope, `The "openid" scope is required.`}
+	}
+
+	if unknown := list.SubtractSlice(scopes, core.OIDCScopes); len(unknown) > 0 {
+		return nil, &oidcError{oidcErrorInvalidScope, "Unsupported scope(s): " + strings.Join(unknown, ", ") + "."}
+	}
+
+	if collection.OIDC.RefreshTokenDuration <= 0 {
+		scopes = list.SubtractSlice(scopes, []string{core.OIDCScopeOfflineAccess})
+	}
+
+	if form.CodeChallenge != "" && form.CodeChallengeMethod != "S256" {
+		return nil, &oidcError{oidcErrorInvalidRequest, `Only the "S256" code_challenge_method is supported.`}
+	}
+
+	if form.CodeChallenge == "" && client.Public() {
+		return nil, &oidcError{oidcErrorInvalidRequest, "PKCE code_challenge is required for public clients."}
+	}
+
+	if len(form.State) > 2000 || len(form.Nonce) > 2000 || len(form.CodeChallenge) > 255 {
+		return nil, &oidcError{oidcErrorInvalidRequest, "Too long state, nonce or code_challenge parameter."}
+	}
+
+	return scopes, nil
+}
+
+// redirectURL returns the client redirect URL with the specified response params.
+func (form *oidcAuthorizeForm) redirectURL(params url.Values) string {
+	if form.State != "" {
+		params.Set("state", form.State)
+	}
+
+	u, err := url.Parse(form.RedirectURI)
+	if err != nil {
+		return form.RedirectURI
+	}
+
+	query := u.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func (form *oidcAuthorizeForm) errorRedirectURL(issuer string, err *oidcError) string {
+	return form.redirectURL(url.Values{
+		"error":             {err.Code},
+		"error_description": {err.Description},
+		"iss":               {issuer},
+	})
+}
+
+// recordOIDCAuthorizeRedirect handles the OIDC client authorization request
+// and redirects to the configured collection login page.
+func recordOIDCAuthorizeRedirect(e *core.RequestEvent) error {
+	collection, err := checkOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	form := &oidcAuthorizeForm{}
+	if err = router.UnmarshalRequestData(e.Request.URL.Query(), form, "", ""); err != nil {
+		return e.BadRequestError("An error occurred while loading the submitted data.", err)
+	}
+
+	client, err := form.resolveClient(e, collection)
+	if err != nil {
+		return err
+	}
+
+	if _, oErr := form.resolveScopes(collection, client); oErr != nil {
+		return e.Redirect(http.StatusFound, form.errorRedirectURL(core.OIDCIssuer(e.App, collection), oErr))
+	}
+
+	loginURL, err := url.Parse(collection.OIDC.LoginURL)
+	if err != nil {
+		return e.InternalServerError("Invalid OIDC login URL.", err)
+	}
+
+	// forward the authorization request params
+	query := loginURL.Query()
+	for k, v := range e.Request.URL.Query() {
+		query[k] = v
+	}
+	query.Set("collection", collection.Name)
+	loginURL.RawQuery = query.Encode()
+
+	return e.Redirect(http.StatusFound, loginURL.String())
+}
+
+// recordOIDCConsent returns the consent screen data of the authorization request.
+func recordOIDCConsent(e *core.RequestEvent) error {
+	collection, err := checkOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	form := &oidcAuthorizeForm{}
+	if err = router.UnmarshalRequestData(e.Request.URL.Query(), form, "", ""); err != nil {
+		return e.BadRequestError("An error occurred while loading the submitted data.", err)
+	}
+
+	client, err := form.resolveClient(e, collection)
+	if err != nil {
+		return err
+	}
+
+	scopes, oErr := form.resolveScopes(collection, client)
+	if oErr != nil {
+		return e.BadRequestError(oErr.Description, oErr)
+	}
+
+	consent, err := e.App.FindOIDCConsent(e.Auth, client.Id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.InternalServerError("Failed to load the OIDC consent.", err)
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"client": map[string]any{
+			"id":   client.Id,
+			"name": client.Name(),
+		},
+		"scopes":          scopes,
+		"consentRequired": consent == nil || !consent.HasScopes(scopes),
+	})
+}
+
+// recordOIDCAuthorize approves or denies the authorization request
+// of the authenticated record and returns the client redirect URL.
+func recordOIDCAuthorize(e *core.RequestEvent) error {
+	collection, err := checkOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	form := &oidcAuthorizeForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+
+	client, err := form.resolveClient(e, collection)
+	if err != nil {
+		return err
+	}
+
+	issuer := core.OIDCIssuer(e.App, collection)
+
+	scopes, oErr := form.resolveScopes(collection, client)
+	if oErr != nil {
+		return e.JSON(http.StatusOK, map[string]string{
+			"redirectURL": form.errorRedirectURL(issuer, oErr),
+		})
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextOIDC)
+
+	event := new(core.RecordOIDCAuthorizeRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+	event.Record = e.Auth
+	event.Client = client
+	event.Scopes = scopes
+	event.Approved = form.Approve
+
+	var code *core.OIDCToken
+
+	if event.Approved {
+		code = core.NewOIDCToken(e.App)
+		code.SetCollectionRef(collection.Id)
+		code.SetRecordRef(e.Auth.Id)
+		code.SetClientRef(client.Id)
+		code.SetKind(core.OIDCTokenKindCode)
+		code.SetScopes(scopes)
+		code.SetRedirectURI(form.RedirectURI)
+		code.SetCodeChallenge(form.CodeChallenge)
+		code.SetNonce(form.Nonce)
+		code.SetAuthTime(oidcAuthTime(e))
+		code.SetExpires(types.NowDateTime().Add(collection.OIDC.CodeDurationTime()))
+
+		event.RedirectURL = form.redirectURL(url.Values{
+			"code": {code.RefreshValue()},
+			"iss":  {issuer},
+		})
+	} else {
+		event.RedirectURL = form.errorRedirectURL(issuer, &oidcError{oidcErrorAccessDenied, "The user has denied the authorization request."})
+	}
+
+	return e.App.OnRecordOIDCAuthorizeRequest().Trigger(event, func(e *core.RecordOIDCAuthorizeRequestEvent) error {
+		if e.Approved && code != nil {
+			err := e.App.RunInTransaction(func(txApp core.App) error {
+				consent, err := txApp.FindOIDCConsent(e.Record, e.Client.Id)
+				if err != nil {
+					if !errors.Is(err, sql.ErrNoRows) {
+						return err
+					}
+
+					consent = core.NewOIDCConsent(txApp)
+					consent.SetCollectionRef(e.Collection.Id)
+					consent.SetRecordRef(e.Record.Id)
+					consent.SetClientRef(e.Client.Id)
+				}
+
+				if !consent.HasScopes(e.Scopes) || consent.IsNew() {
+					consent.SetScopes(list.NonzeroUniques(append(consent.Scopes(), e.Scopes...)))
+					if err := txApp.Save(consent); err != nil {
+						return err
+					}
+				}
+
+				return txApp.Save(code)
+			})
+			if err != nil {
+				return firstApiError(err, e.BadRequestError("Failed to create the authorization code.", err))
+			}
+		}
+
+		return execAfterSuccessTx(true, e.App, func() error {
+			return e.JSON(http.StatusOK, map[string]string{
+				"redirectURL": e.RedirectURL,
+			})
+		})
+	})
+}
+
+// oidcAuthTime returns the time of the user authentication
+// based on the "iat" claim of the request auth token (if any).
+func oidcAuthTime(e *core.RequestEvent) types.DateTime {
+	// note: the token is already verified by the auth middleware
+	claims, _ := security.ParseUnverifiedJWT(getAuthTokenFromRequest(e))
+
+	if iat := cast.ToInt64(claims["iat"]); iat > 0 {
+		if authTime, err := types.ParseDateTime(time.Unix(iat, 0)); err == nil {
+			return authTime
+		}
+	}
+
+	return types.NowDateTime()
+}
+
+// -------------------------------------------------------------------
+
+// recordOIDCUserInfo returns the claims of the OIDC access token auth record.
+//
+// The OIDC access token is verified here (and not by the auth middleware)
+// because it is accepted only by the OIDC userinfo endpoint.
+func recordOIDCUserInfo(e *core.RequestEvent) error {
+	collection, err := checkOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	token := getAuthTokenFromRequest(e)
+
+	authRecord, err := e.App.FindAuthRecordByToken(token, core.TokenTypeOIDCAccess)
+	if err != nil || authRecord.Collection().Id != collection.Id {
+		return e.UnauthorizedError("The request requires valid OIDC access token.", err)
+	}
+
+	// note: the token is already verified
+	claims, _ := security.ParseUnverifiedJWT(token)
+
+	clientId := cast.ToString(claims[core.TokenClaimClientId])
+	if clientId == "" {
+		return e.UnauthorizedError("The request requires valid OIDC access token.", nil)
+	}
+
+	// the client or the consent could have been deleted
+	if _, err := e.App.FindOIDCClient(collection, clientId); err != nil {
+		return e.UnauthorizedError("The OIDC client is missing or has been deleted.", err)
+	}
+
+	consent, err := e.App.FindOIDCConsent(authRecord, clientId)
+	if err != nil {
+		return e.UnauthorizedError("The OIDC client consent has been revoked.", err)
+	}
+
+	// the consent could have been narrowed since the token was issued
+	scopes := strings.Fields(cast.ToString(claims[core.TokenClaimScope]))
+	if !slices.Contains(scopes, core.OIDCScopeOpenId) || !consent.HasScopes(scopes) {
+		return e.UnauthorizedError("The OIDC access token scopes are no longer granted.", nil)
+	}
+
+	return e.JSON(http.StatusOK, core.OIDCUserClaims(authRecord, scopes))
+}
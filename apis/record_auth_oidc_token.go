@@ -0,0 +1,278 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	oidcGrantTypeAuthorizationCode = "authorization_code"
+	oidcGrantTypeRefreshToken      = "refresh_token"
+)
+
+// errOIDCGrantUsed is returned when the grant was already exchanged
+// by another (concurrent) token request.
+var errOIDCGrantUsed = errors.New("the OIDC grant was already used")
+
+type oidcTokenForm struct {
+	GrantType    string `form:"grant_type" json:"grant_type"`
+	Code         string `form:"code" json:"code"`
+	RedirectURI  string `form:"redirect_uri" json:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier" json:"code_verifier"`
+	RefreshToken string `form:"refresh_token" json:"refresh_token"`
+	Scope        string `form:"scope" json:"scope"`
+	ClientId     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+}
+
+// recordOIDCToken handles the OIDC token endpoint requests
+// (authorization_code and refresh_token grants).
+//
+// Note that the errors are returned in the OAuth2 error response format
+// and not as regular ApiError because that is what the OIDC clients expect.
+func recordOIDCToken(e *core.RequestEvent) error {
+	collection, err := checkOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	e.Response.Header().Set("Cache-Control", "no-store")
+	e.Response.Header().Set("Pragma", "no-cache")
+
+	form := &oidcTokenForm{}
+	if err = e.BindBody(form); err != nil {
+		return oidcTokenErrorResponse(e, &oidcError{oidcErrorInvalidRequest, "Failed to load the submitted data."})
+	}
+
+	client, oErr := oidcAuthenticateClient(e, collection, form)
+	if oErr != nil {
+		return oidcTokenErrorResponse(e, oErr)
+	}
+
+	var grant *core.OIDCToken
+	var scopes []string
+
+	switch form.GrantType {
+	case oidcGrantTypeAuthorizationCode:
+		grant, oErr = oidcFindCodeGrant(e, collection, client, form)
+		if oErr == nil {
+			scopes = grant.Scopes()
+		}
+	case oidcGrantTypeRefreshToken:
+		grant, scopes, oErr = oidcFindRefreshGrant(e, collection, client, form)
+	default:
+		oErr = &oidcError{oidcErrorUnsupportedGrantType, `Only the "authorization_code" and "refresh_token" grant types are supported.`}
+	}
+	if oErr != nil {
+		return oidcTokenErrorResponse(e, oErr)
+	}
+
+	record, err := e.App.FindRecordById(collection, grant.RecordRef())
+	if err != nil {
+		return oidcTokenErrorResponse(e, &oidcError{oidcErrorInvalidGrant, "Missing or deleted grant auth record."})
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return err
+	}
+
+	canAuth, _ := e.App.CanAccessRecord(record, requestInfo, collection.AuthRule)
+	if !canAuth {
+		return oidcTokenErrorResponse(e, &oidcError{oidcErrorInvalidGrant, "The auth record is not allowed to authenticate."})
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextOIDC)
+
+	event := new(core.RecordOIDCTokenRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+	event.Record = record
+	event.Client = client
+	event.GrantType = form.GrantType
+	event.Scopes = scopes
+
+	return e.App.OnRecordOIDCTokenRequest().Trigger(event, func(e *core.RecordOIDCTokenRequestEvent) error {
+		var refreshToken string
+
+		// the codes and refresh tokens are single-use
+		// (a new refresh token with the original grant scopes is issued on each exchange)
+		err := e.App.RunInTransaction(func(txApp core.App) error {
+			// the grant is claimed with a conditional delete so that only
+			// one of the concurrent exchanges of the same grant can succeed
+			result, err := txApp.NonconcurrentDB().Delete(core.CollectionNameOIDCTokens, dbx.HashExp{"id": grant.Id}).Execute()
+			if err != nil {
+				return err
+			}
+			if deleted, err := result.RowsAffected(); err != nil || deleted != 1 {
+				return errOIDCGrantUsed
+			}
+
+			if !slices.Contains(grant.Scopes(), core.OIDCScopeOfflineAccess) || e.Collection.OIDC.RefreshTokenDuration <= 0 {
+				return nil
+			}
+
+			refresh := core.NewOIDCToken(txApp)
+			refresh.SetCollectionRef(e.Collection.Id)
+			refresh.SetRecordRef(e.Record.Id)
+			refresh.SetClientRef(e.Client.Id)
+			refresh.SetKind(core.OIDCTokenKindRefresh)
+			refresh.SetScopes(grant.Scopes())
+			refresh.SetAuthTime(grant.AuthTime())
+			refresh.SetExpires(types.NowDateTime().Add(e.Collection.OIDC.RefreshTokenDurationTime()))
+			refreshToken = refresh.RefreshValue()
+
+			return txApp.Save(refresh)
+		})
+		if errors.Is(err, errOIDCGrantUsed) {
+			return oidcTokenErrorResponse(e.RequestEvent, &oidcError{oidcErrorInvalidGrant, "The grant was already used."})
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to exchange the OIDC grant.", err)
+		}
+
+		accessToken, err := e.App.NewRecordOIDCAccessToken(e.Record, e.Client.Id, e.Scopes)
+		if err != nil {
+			return e.InternalServerError("Failed to create OIDC access token.", err)
+		}
+
+		// the nonce is included only in the ID token of the authorization code exchange
+		var nonce string
+		if e.GrantType == oidcGrantTypeAuthorizationCode {
+			nonce = grant.Nonce()
+		}
+
+		idToken, err := e.App.NewRecordOIDCIdToken(e.Record, e.Client.Id, nonce, grant.AuthTime().Time(), e.Scopes)
+		if err != nil {
+			return e.InternalServerError("Failed to create OIDC ID token.", err)
+		}
+
+		result := map[string]any{
+			"access_token": accessToken,
+			"token_type":   "Bearer",
+			"expires_in":   e.Collection.AuthToken.Duration,
+			"id_token":     idToken,
+			"scope":        strings.Join(e.Scopes, " "),
+		}
+
+		if refreshToken != "" {
+			result["refresh_token"] = refreshToken
+		}
+
+		return execAfterSuccessTx(true, e.App, func() error {
+			return e.JSON(http.StatusOK, result)
+		})
+	})
+}
+
+// oidcAuthenticateClient authenticates the OIDC client from the
+// Authorization header (client_secret_basic) or the request body (client_secret_post).
+//
+// Public clients are identified only by their client_id.
+func oidcAuthenticateClient(e *core.RequestEvent, collection *core.Collection, form *oidcTokenForm) (*core.OIDCClient, *oidcError) {
+	clientId, secret := form.ClientId, form.ClientSecret
+
+	if basicId, basicSecret, ok := e.Request.BasicAuth(); ok {
+		// the client credentials are form-urlencoded before the base64 encoding
+		// (https://www.rfc-editor.org/rfc/rfc6749#section-2.3.1)
+		clientId, _ = url.QueryUnescape(basicId)
+		secret, _ = url.QueryUnescape(basicSecret)
+	}
+
+	invalidErr := &oidcError{oidcErrorInvalidClient, "Invalid client credentials."}
+
+	if clientId == "" {
+		return nil, invalidErr
+	}
+
+	client, err := e.App.FindOIDCClient(collection, clientId)
+	if err != nil {
+		return nil, invalidErr
+	}
+
+	if client.Public() {
+		if secret != "" {
+			return nil, invalidErr
+		}
+	} else if !client.ValidateSecret(secret) {
+		return nil, invalidErr
+	}
+
+	return client, nil
+}
+
+func oidcFindCodeGrant(e *core.RequestEvent, collection *core.Collection, client *core.OIDCClient, form *oidcTokenForm) (*core.OIDCToken, *oidcError) {
+	if form.Code == "" {
+		return nil, &oidcError{oidcErrorInvalidRequest, "Missing code."}
+	}
+
+	invalidErr := &oidcError{oidcErrorInvalidGrant, "Invalid or expired authorization code."}
+
+	code, err := e.App.FindOIDCTokenByValue(core.OIDCTokenKindCode, form.Code)
+	if err != nil ||
+		code.CollectionRef() != collection.Id ||
+		code.ClientRef() != client.Id ||
+		code.HasExpired() {
+		return nil, invalidErr
+	}
+
+	if code.RedirectURI() != form.RedirectURI {
+		return nil, &oidcError{oidcErrorInvalidGrant, "The redirect_uri doesn't match with the authorization request."}
+	}
+
+	if !code.ValidateCodeVerifier(form.CodeVerifier) {
+		return nil, &oidcError{oidcErrorInvalidGrant, "Invalid PKCE code_verifier."}
+	}
+
+	return code, nil
+}
+
+func oidcFindRefreshGrant(e *core.RequestEvent, collection *core.Collection, client *core.OIDCClient, form *oidcTokenForm) (*core.OIDCToken, []string, *oidcError) {
+	if collection.OIDC.RefreshTokenDuration <= 0 {
+		return nil, nil, &oidcError{oidcErrorUnsupportedGrantType, "The refresh tokens are disabled."}
+	}
+
+	if form.RefreshToken == "" {
+		return nil, nil, &oidcError{oidcErrorInvalidRequest, "Missing refresh_token."}
+	}
+
+	refresh, err := e.App.FindOIDCTokenByValue(core.OIDCTokenKindRefresh, form.RefreshToken)
+	if err != nil ||
+		refresh.CollectionRef() != collection.Id ||
+		refresh.ClientRef() != client.Id ||
+		refresh.HasExpired() {
+		return nil, nil, &oidcError{oidcErrorInvalidGrant, "Invalid or expired refresh token."}
+	}
+
+	scopes := refresh.Scopes()
+
+	// optionally narrow down the access token scopes
+	if form.Scope != "" {
+		requested := list.NonzeroUniques(strings.Fields(form.Scope))
+		if len(list.SubtractSlice(requested, scopes)) > 0 {
+			return nil, nil, &oidcError{oidcErrorInvalidScope, "The requested scope exceeds the originally granted scopes."}
+		}
+		scopes = requested
+	}
+
+	return refresh, scopes, nil
+}
+
+func oidcTokenErrorResponse(e *core.RequestEvent, err *oidcError) error {
+	status := http.StatusBadRequest
+
+	if err.Code == oidcErrorInvalidClient {
+		status = http.StatusUnauthorized
+		e.Response.Header().Set("WWW-Authenticate", `Basic realm="oidc"`)
+	}
+
+	return e.JSON(status, err)
+}
@@ -0,0 +1,147 @@
+package apis
+
+import (
+	"database/sql"
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/core/validators"
+	"github.com/pocketbase/pocketbase/tools/list"
+)
+
+// recordChangeExpiredPassword handles the forced password change of
+// auth records whose password is older than the collection PasswordPolicy.MaxAge.
+//
+// On success it returns the regular auth response (or the MFA response if enabled).
+func recordChangeExpiredPassword(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.PasswordAuth.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow password authentication.", nil)
+	}
+
+	form := &changeExpiredPasswordForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(collection); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextPasswordAuth)
+
+	record, err := findRecordByPasswordIdentity(e.App, collection, form.IdentityField, form.Identity)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.InternalServerError("", err)
+	}
+
+	lockoutIdentity := authLockoutIdentity(record, form.Identity)
+	if err := checkAuthLockout(e, collection, lockoutIdentity); err != nil {
+		return err
+	}
+
+	if record == nil || !record.ValidatePassword(form.Password) {
+		registerAuthFailure(e, collection, lockoutIdentity, record)
+		return e.BadRequestError("Failed to authenticate.", errors.New("invalid login credentials"))
+	}
+
+	clearAuthFailures(e, collection, lockoutIdentity)
+
+	latest, err := e.App.FindLatestPasswordHistoryByRecord(record)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.InternalServerError("", err)
+	}
+	if latest == nil || !latest.HasExpired(collection.PasswordPolicy.MaxAgeTime()) {
+		return e.BadRequestError("The password has not expired.", nil)
+	}
+
+	if record.ValidatePassword(form.NewPassword) {
+		return e.BadRequestError("An error occurred while validating the submitted data.", validation.Errors{
+			"newPassword": validation.NewError("validation_password_unchanged", "Must be different from the current password."),
+		})
+	}
+
+	record.SetPassword(form.NewPassword)
+
+	err = e.App.Save(record)
+	if err != nil {
+		var validationErrs validation.Errors
+		if errors.As(err, &validationErrs) {
+			if passErr, ok := validationErrs[core.FieldNamePassword]; ok {
+				err = validation.Errors{"newPassword": passErr}
+			}
+		}
+
+		return firstApiError(err, e.BadRequestError("Failed to set new password.", err))
+	}
+
+	return RecordAuthResponse(e, record, core.MFAMethodPassword, nil)
+}
+
+// checkPasswordExpiration returns a 403 error if the password of the
+// provided auth record is older than its collection PasswordPolicy.MaxAge.
+//
+// If the record doesn't have a password history yet (e.g. created before
+// enabling the max age option), its current password is stored as baseline.
+func checkPasswordExpiration(e *core.RequestEvent, authRecord *core.Record) error {
+	maxAge := authRecord.Collection().PasswordPolicy.MaxAgeTime()
+	if maxAge <= 0 {
+		return nil
+	}
+
+	latest, err := e.App.FindLatestPasswordHistoryByRecord(authRecord)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return e.InternalServerError("", err)
+		}
+
+		baseline := core.NewPasswordHistory(e.App)
+		baseline.SetCollectionRef(authRecord.Collection().Id)
+		baseline.SetRecordRef(authRecord.Id)
+		baseline.SetHash(authRecord.GetString(core.FieldNamePassword + ":hash"))
+		if err := e.App.Save(baseline); err != nil {
+			e.App.Logger().Warn("Failed to store the password history baseline", "error", err, "recordId", authRecord.Id)
+		}
+
+		return nil
+	}
+
+	if latest.HasExpired(maxAge) {
+		return e.ForbiddenError("The password has expired and must be changed.", validation.Errors{
+			core.FieldNamePassword: validation.NewError("validation_password_expired", "The password has expired."),
+		})
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+type changeExpiredPasswordForm struct {
+	Identity           string `form:"identity" json:"identity"`
+	Password           string `form:"password" json:"password"`
+	NewPassword        string `form:"newPassword" json:"newPassword"`
+	NewPasswordConfirm string `form:"newPasswordConfirm" json:"newPasswordConfirm"`
+
+	// IdentityField specifies the field to use to search for the identity
+	// (leave it empty for "auto" detection).
+	IdentityField string `form:"identityField" json:"identityField"`
+}
+
+func (form *changeExpiredPasswordForm) validate(collection *core.Collection) error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Identity, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.Password, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.NewPassword, validation.Required, validation.Length(1, 255)), // the FieldPassword and the policy validators will check further the specific constraints
+		validation.Field(&form.NewPasswordConfirm, validation.Required, validation.By(validators.Equal(form.NewPassword))),
+		validation.Field(
+			&form.IdentityField,
+			validation.Length(1, 255),
+			validation.In(list.ToInterfaceSlice(collection.PasswordAuth.IdentityFields)...),
+		),
+	)
+}
@@ -0,0 +1,273 @@
+package apis_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// enablePasswordMaxAge enables the users collection password max age
+// policy (+ the digit requirement) and disables the MFA to simplify the checks.
+//
+// If expired is set, an old password history entry is also created for test@example.com.
+func enablePasswordMaxAge(t testing.TB, app *tests.TestApp, expired bool) {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.MFA.Enabled = false
+	collection.PasswordPolicy = core.PasswordPolicyConfig{
+		RequireDigit: true,
+		History:      2,
+		MaxAge:       3600,
+	}
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if !expired {
+		return
+	}
+
+	user, err := app.FindAuthRecordByEmail(collection, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := core.NewPasswordHistory(app)
+	entry.SetCollectionRef(collection.Id)
+	entry.SetRecordRef(user.Id)
+	entry.SetHash(user.GetString(core.FieldNamePassword + ":hash"))
+	entry.SetRaw("created", types.NowDateTime().Add(-2*time.Hour))
+	if err := app.Save(entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordAuthWithPasswordMaxAge(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "without password history",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enablePasswordMaxAge(t, app, false)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				entry, err := app.FindLatestPasswordHistoryByRecord(user)
+				if err != nil {
+					t.Fatalf("Expected baseline password history entry, got %v", err)
+				}
+				if entry.Hash() != user.GetString(core.FieldNamePassword+":hash") {
+					t.Fatalf("Expected the baseline to be the current password hash, got %q", entry.Hash())
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"email":"test@example.com"`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithPasswordRequest": 1,
+				"OnRecordAuthRequest":             1,
+			},
+		},
+		{
+			Name:   "with expired password",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enablePasswordMaxAge(t, app, true)
+			},
+			ExpectedStatus: 403,
+			ExpectedContent: []string{
+				`"password":{"code":"validation_password_expired"`,
+			},
+			NotExpectedContent: []string{
+				`"token"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                               0,
+				"OnRecordAuthWithPasswordRequest": 1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestRecordChangeExpiredPassword(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "empty body",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/change-expired-password",
+			Body:            strings.NewReader(`{}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"identity":`, `"password":`, `"newPassword":`, `"newPasswordConfirm":`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "non-auth collection",
+			Method: http.MethodPost,
+			URL:    "/api/collections/demo1/change-expired-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890",
+				"newPassword":"new_password_1",
+				"newPasswordConfirm":"new_password_1"
+			}`),
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid password",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/change-expired-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"invalid",
+				"newPassword":"new_password_1",
+				"newPasswordConfirm":"new_password_1"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enablePasswordMaxAge(t, app, true)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "non-expired password",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/change-expired-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890",
+				"newPassword":"new_password_1",
+				"newPasswordConfirm":"new_password_1"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enablePasswordMaxAge(t, app, false)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "expired password + unchanged new password",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/change-expired-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890",
+				"newPassword":"1234567890",
+				"newPasswordConfirm":"1234567890"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enablePasswordMaxAge(t, app, true)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"newPassword":{"code":"validation_password_unchanged"`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "expired password + new password policy violation",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/change-expired-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890",
+				"newPassword":"new_password",
+				"newPasswordConfirm":"new_password"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enablePasswordMaxAge(t, app, true)
+			},
+			ExpectedStatus:     400,
+			ExpectedContent:    []string{`"newPassword":{"code":"validation_password_digit"`},
+			NotExpectedContent: []string{`"password":`},
+			ExpectedEvents: map[string]int{
+				"*":                0,
+				"OnModelUpdate":    1,
+				"OnModelValidate":  1,
+				"OnRecordUpdate":   1,
+				"OnRecordValidate": 1,
+				// errors
+				"OnModelAfterUpdateError":  1,
+				"OnRecordAfterUpdateError": 1,
+			},
+		},
+		{
+			Name:   "expired password + valid new password",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/change-expired-password",
+			Body: strings.NewReader(`{
+				"identity":"test@example.com",
+				"password":"1234567890",
+				"newPassword":"new_password_1",
+				"newPasswordConfirm":"new_password_1"
+			}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enablePasswordMaxAge(t, app, true)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !user.ValidatePassword("new_password_1") {
+					t.Fatal("Expected the password to be changed")
+				}
+
+				entry, err := app.FindLatestPasswordHistoryByRecord(user)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if entry.HasExpired(user.Collection().PasswordPolicy.MaxAgeTime()) {
+					t.Fatal("Expected the latest password history entry to be non-expired")
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"email":"test@example.com"`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordUpdate":      1,
+				"OnRecordAuthRequest": 1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -24,12 +24,12 @@ func recordAuthRefresh(e *core.RequestEvent) error {
 		claims, _ := security.ParseUnverifiedJWT(token) //
 		if v, ok := claims[core.TokenClaimRefreshable]; ok && cast.ToBool(v) {
 			var tokenErr error
-			token, tokenErr = e.Record.NewAuthToken()
+			token, tokenErr = e.App.NewRecordAuthToken(e.Record)
 			if tokenErr != nil {
 				return e.InternalServerError("Failed to refresh auth token.", tokenErr)
 			}
 		}
 
-		return recordAuthResponse(e.RequestEvent, e.Record, token, "", nil)
+		return recordAuthResponse(e.RequestEvent, e.Record, token, "", nil, nil, "")
 	})
 }
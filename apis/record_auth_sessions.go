@@ -0,0 +1,83 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// authSessionItem is the public representation of a single auth session.
+type authSessionItem struct {
+	Id        string         `json:"id"`
+	OriginRef string         `json:"originRef"`
+	UserAgent string         `json:"userAgent"`
+	IP        string         `json:"ip"`
+	LastUsed  types.DateTime `json:"lastUsed"`
+	Expires   types.DateTime `json:"expires"`
+	Created   types.DateTime `json:"created"`
+	Current   bool           `json:"current"`
+}
+
+func recordAuthSessionsList(e *core.RequestEvent) error {
+	sessions, err := e.App.FindAllAuthSessionsByRecord(e.Auth)
+	if err != nil {
+		return e.InternalServerError("Failed to load the auth sessions.", err)
+	}
+
+	currentSessionId := currentAuthSessionId(e)
+
+	result := make([]authSessionItem, 0, len(sessions))
+	for _, s := range sessions {
+		if s.HasExpired() {
+			continue
+		}
+
+		result = append(result, authSessionItem{
+			Id:        s.Id,
+			OriginRef: s.OriginRef(),
+			UserAgent: s.UserAgent(),
+			IP:        s.IP(),
+			LastUsed:  s.LastUsed(),
+			Expires:   s.Expires(),
+			Created:   s.Created(),
+			Current:   currentSessionId != "" && s.Id == currentSessionId,
+		})
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func recordAuthSessionDelete(e *core.RequestEvent) error {
+	session := &core.AuthSession{}
+
+	err := e.App.RecordQuery(core.CollectionNameAuthSessions).
+		AndWhere(dbx.HashExp{"id": e.Request.PathValue("id")}).
+		Limit(1).
+		One(session)
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	if session.CollectionRef() != e.Auth.Collection().Id || session.RecordRef() != e.Auth.Id {
+		return e.NotFoundError("", errors.New("the session belongs to a different auth record"))
+	}
+
+	if err := e.App.Delete(session); err != nil {
+		return firstApiError(err, e.InternalServerError("Failed to revoke the auth session.", err))
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// currentAuthSessionId returns the session id of the current request auth token (if any).
+func currentAuthSessionId(e *core.RequestEvent) string {
+	claims, _ := security.ParseUnverifiedJWT(getAuthTokenFromRequest(e))
+
+	sessionId, _ := claims[core.TokenClaimSessionId].(string)
+
+	return sessionId
+}
@@ -0,0 +1,257 @@
+package apis
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/totp"
+)
+
+func recordTOTPSetup(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.TOTP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow TOTP authentication.", nil)
+	}
+
+	existing, err := e.App.FindTOTPByRecord(e.Auth)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.InternalServerError("Failed to load the existing TOTP enrollment.", err)
+	}
+	if existing != nil {
+		return e.BadRequestError("TOTP is already enabled for the authenticated record.", nil)
+	}
+
+	secret := totp.NewSecret()
+
+	encrypted, err := core.EncryptTOTPSecret(collection, secret)
+	if err != nil {
+		return e.InternalServerError("Failed to encrypt the TOTP secret.", err)
+	}
+
+	token, err := e.Auth.NewTOTPSetupToken(encrypted)
+	if err != nil {
+		return e.InternalServerError("Failed to create TOTP setup token.", err)
+	}
+
+	issuer := collection.TOTP.Issuer
+	if issuer == "" {
+		issuer = e.App.Settings().Meta.AppName
+	}
+
+	account := e.Auth.Email()
+	if account == "" {
+		account = e.Auth.GetString("username")
+	}
+	if account == "" {
+		account = e.Auth.Id
+	}
+
+	opts := collection.TOTP.Options()
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"token":  token,
+		"secret": secret,
+		"uri":    totp.ProvisioningURI(secret, issuer, account, opts),
+		"digits": collection.TOTP.Digits,
+		"period": collection.TOTP.Period,
+	})
+}
+
+func recordTOTPConfirm(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.TOTP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow TOTP authentication.", nil)
+	}
+
+	form := &totpConfirmForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	encrypted, err := e.Auth.ParseTOTPSetupToken(form.Token)
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("Invalid or expired TOTP setup token.", err))
+	}
+
+	existing, err := e.App.FindTOTPByRecord(e.Auth)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.InternalServerError("Failed to load the existing TOTP enrollment.", err)
+	}
+	if existing != nil {
+		return e.BadRequestError("TOTP is already enabled for the authenticated record.", nil)
+	}
+
+	enrollment := core.NewTOTP(e.App)
+	enrollment.SetCollectionRef(collection.Id)
+	enrollment.SetRecordRef(e.Auth.Id)
+	enrollment.SetSecret(encrypted)
+
+	if !enrollment.ValidateCode(collection, form.Code) {
+		return e.BadRequestError("Invalid TOTP code.", nil)
+	}
+
+	recoveryCodes := core.NewTOTPRecoveryCodes(collection.TOTP.RecoveryCodes)
+	enrollment.SetRecoveryCodes(recoveryCodes)
+
+	if err = e.App.Save(enrollment); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to enable TOTP.", err))
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+func recordTOTPRecoveryCodes(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.TOTP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow TOTP authentication.", nil)
+	}
+
+	form := &totpCodeForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(false); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	enrollment, err := findVerifiedTOTP(e, collection, form)
+	if err != nil {
+		return err
+	}
+
+	recoveryCodes := core.NewTOTPRecoveryCodes(collection.TOTP.RecoveryCodes)
+	enrollment.SetRecoveryCodes(recoveryCodes)
+
+	if err = e.App.Save(enrollment); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to regenerate the TOTP recovery codes.", err))
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+func recordTOTPDisable(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	// note: intentionally allowed even when the collection TOTP option
+	// is disabled so that the users could still remove their enrollment
+
+	form := &totpCodeForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(true); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	enrollment, err := findVerifiedTOTP(e, collection, form)
+	if err != nil {
+		return err
+	}
+
+	if err = e.App.Delete(enrollment); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to disable TOTP.", err))
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// findVerifiedTOTP loads the TOTP enrollment of the authenticated record
+// and verifies the submitted form code (or recovery code).
+func findVerifiedTOTP(e *core.RequestEvent, collection *core.Collection, form *totpCodeForm) (*core.TOTP, error) {
+	enrollment, err := e.App.FindTOTPByRecord(e.Auth)
+	if err != nil {
+		return nil, e.BadRequestError("TOTP is not enabled for the authenticated record.", err)
+	}
+
+	// basic brute-force protection for the short numeric codes
+	err = checkRateLimit(e, "@pb_totp_"+e.Auth.Id, core.RateLimitRule{MaxRequests: 5, Duration: 180})
+	if err != nil {
+		return nil, e.TooManyRequestsError("Too many attempts, please try again later.", nil)
+	}
+
+	if !form.verify(collection, enrollment) {
+		return nil, e.BadRequestError("Invalid TOTP or recovery code.", nil)
+	}
+
+	// claim the verified code so that it can't be reused by a concurrent request
+	err = core.SaveTOTPUse(e.App, enrollment)
+	if errors.Is(err, core.ErrTOTPCodeUsed) {
+		return nil, e.BadRequestError("Invalid TOTP or recovery code.", err)
+	}
+	if err != nil {
+		return nil, e.InternalServerError("Failed to update TOTP state.", err)
+	}
+
+	return enrollment, nil
+}
+
+// -------------------------------------------------------------------
+
+type totpConfirmForm struct {
+	Token string `form:"token" json:"token"`
+	Code  string `form:"code" json:"code"`
+}
+
+func (form *totpConfirmForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Token, validation.Required),
+		validation.Field(&form.Code, validation.Required, validation.Length(1, 20)),
+	)
+}
+
+type totpCodeForm struct {
+	Code         string `form:"code" json:"code"`
+	RecoveryCode string `form:"recoveryCode" json:"recoveryCode"`
+}
+
+func (form *totpCodeForm) validate(allowRecoveryCode bool) error {
+	return validation.ValidateStruct(form,
+		validation.Field(
+			&form.Code,
+			validation.When(form.RecoveryCode == "", validation.Required),
+			validation.Length(1, 20),
+		),
+		validation.Field(
+			&form.RecoveryCode,
+			validation.When(!allowRecoveryCode, validation.Empty),
+			validation.Length(1, 50),
+		),
+	)
+}
+
+// verify checks the form code or recovery code against the specified
+// TOTP enrollment and updates its state on success.
+//
+// Note: the caller is responsible to persist the TOTP changes with [core.SaveTOTPUse].
+func (form *totpCodeForm) verify(collection *core.Collection, enrollment *core.TOTP) bool {
+	if form.RecoveryCode != "" {
+		return enrollment.UseRecoveryCode(form.RecoveryCode)
+	}
+
+	return enrollment.ValidateCode(collection, form.Code)
+}
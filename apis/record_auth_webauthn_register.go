@@ -0,0 +1,163 @@
+package apis
+
+import (
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/webauthn"
+)
+
+func recordWebAuthnRegisterOptions(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.WebAuthn.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow WebAuthn authentication.", nil)
+	}
+
+	rp := core.WebAuthnRelyingParty(e.App, collection)
+	if rp.Id == "" || len(rp.Origins) == 0 {
+		return e.InternalServerError("Missing WebAuthn relying party configuration.", nil)
+	}
+
+	existing, err := e.App.FindAllWebAuthnCredentialsByRecord(e.Auth)
+	if err != nil {
+		return e.InternalServerError("Failed to load the existing WebAuthn credentials.", err)
+	}
+
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(existing))
+	for _, credential := range existing {
+		exclude = append(exclude, credential.Descriptor())
+	}
+
+	challenge := webauthn.NewChallenge()
+
+	token, err := core.NewWebAuthnToken(e.App, collection, webauthn.URLEncodedBase64(challenge).String(), e.Auth.Id)
+	if err != nil {
+		return e.InternalServerError("Failed to create WebAuthn token.", err)
+	}
+
+	name := e.Auth.Email()
+	if name == "" {
+		name = e.Auth.GetString("username")
+	}
+	if name == "" {
+		name = e.Auth.Id
+	}
+
+	displayName := e.Auth.GetString("name")
+	if displayName == "" {
+		displayName = name
+	}
+
+	user := webauthn.UserEntity{
+		Id:          []byte(e.Auth.Id),
+		Name:        name,
+		DisplayName: displayName,
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"token":   token,
+		"options": rp.CreationOptions(challenge, user, exclude, collection.WebAuthn.DurationTime().Milliseconds()),
+	})
+}
+
+func recordWebAuthnRegister(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.WebAuthn.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow WebAuthn authentication.", nil)
+	}
+
+	form := &webauthnRegisterForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	challenge, recordId, err := core.ParseWebAuthnToken(collection, form.Token)
+	if err != nil || recordId != e.Auth.Id {
+		return e.BadRequestError("Invalid or expired WebAuthn token.", err)
+	}
+
+	if !core.ConsumeWebAuthnChallenge(e.App, collection, challenge) {
+		return e.BadRequestError("Invalid or expired WebAuthn token.", nil)
+	}
+
+	rawChallenge, err := webauthn.DecodeBase64(challenge)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired WebAuthn token.", err)
+	}
+
+	rp := core.WebAuthnRelyingParty(e.App, collection)
+
+	verified, err := rp.VerifyRegistration(rawChallenge, form.Credential)
+	if err != nil {
+		return e.BadRequestError("Failed to verify the WebAuthn credential.", err)
+	}
+
+	credentialId := webauthn.URLEncodedBase64(verified.Id).String()
+
+	_, err = e.App.FindWebAuthnCredentialByCredentialId(collection, credentialId)
+	if err == nil {
+		return e.BadRequestError("The WebAuthn credential is already registered.", nil)
+	}
+
+	credential := core.NewWebAuthnCredential(e.App)
+	credential.SetCollectionRef(collection.Id)
+	credential.SetRecordRef(e.Auth.Id)
+	credential.SetName(form.Name)
+	credential.SetCredentialId(credentialId)
+	credential.SetPublicKey(webauthn.URLEncodedBase64(verified.PublicKey).String())
+	credential.SetSignCount(verified.SignCount)
+	credential.SetTransports(verified.Transports)
+	credential.SetAAGUID(webauthn.FormatAAGUID(verified.AAGUID))
+
+	event := new(core.RecordWebAuthnRegisterRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+	event.Record = e.Auth
+	event.Credential = credential
+
+	return e.App.OnRecordWebAuthnRegisterRequest().Trigger(event, func(e *core.RecordWebAuthnRegisterRequestEvent) error {
+		if err := e.App.Save(e.Credential); err != nil {
+			return firstApiError(err, e.BadRequestError("Failed to save the WebAuthn credential.", err))
+		}
+
+		return execAfterSuccessTx(true, e.App, func() error {
+			return e.JSON(http.StatusOK, e.Credential)
+		})
+	})
+}
+
+// -------------------------------------------------------------------
+
+type webauthnRegisterForm struct {
+	Credential *webauthn.RegistrationResponse `form:"credential" json:"credential"`
+	Token      string                         `form:"token" json:"token"`
+	Name       string                         `form:"name" json:"name"`
+}
+
+func (form *webauthnRegisterForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Token, validation.Required),
+		validation.Field(&form.Name, validation.Length(0, 255)),
+		validation.Field(&form.Credential, validation.Required, validation.By(func(value any) error {
+			credential, _ := value.(*webauthn.RegistrationResponse)
+			if credential == nil ||
+				len(credential.Response.ClientDataJSON) == 0 ||
+				len(credential.Response.AttestationObject) == 0 {
+				return validation.NewError("validation_invalid_webauthn_credential", "Missing client data or attestation object.")
+			}
+			return nil
+		})),
+	)
+}
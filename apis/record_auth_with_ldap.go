@@ -0,0 +1,297 @@
+package apis
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/ldap"
+)
+
+func recordAuthWithLDAP(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.LDAP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow LDAP authentication.", nil)
+	}
+
+	form := &authWithLDAPForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextLDAP)
+
+	lockoutIdentity := authLockoutIdentity(nil, form.Username)
+	if err := checkAuthLockout(e, collection, lockoutIdentity); err != nil {
+		return err
+	}
+
+	// verify the credentials against the directory server
+	// ---------------------------------------------------------------
+
+	entry, err := collection.LDAP.Authenticate(form.Username, form.Password)
+	if err != nil {
+		if errors.Is(err, ldap.ErrInvalidCredentials) {
+			registerAuthFailure(e, collection, lockoutIdentity, nil)
+			return e.BadRequestError("Failed to authenticate.", errors.New("invalid login credentials"))
+		}
+
+		return e.InternalServerError("Failed to authenticate with the LDAP server.", err)
+	}
+
+	clearAuthFailures(e, collection, lockoutIdentity)
+
+	// locate existing ExternalAuth rel or auth record
+	// ---------------------------------------------------------------
+
+	externalId := collection.LDAP.ExternalId(entry)
+
+	var authRecord *core.Record
+
+	externalAuthRel, err := e.App.FindFirstExternalAuthByExpr(dbx.HashExp{
+		"collectionRef": collection.Id,
+		"provider":      core.ExternalAuthProviderLDAP,
+		"providerId":    externalId,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.InternalServerError("Failed LDAP relation check.", err)
+	}
+
+	email := ldapEntryEmail(collection, entry)
+
+	switch {
+	case err == nil && externalAuthRel != nil:
+		authRecord, err = e.App.FindRecordById(collection, externalAuthRel.RecordRef())
+		if err != nil {
+			return err
+		}
+	case email != "":
+		// look for an existing auth record by the directory entry email
+		authRecord, err = e.App.FindAuthRecordByEmail(collection.Id, email)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return e.InternalServerError("Failed LDAP auth record check.", err)
+		}
+	}
+
+	// ---------------------------------------------------------------
+
+	event := new(core.RecordAuthWithLDAPRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+	event.Username = form.Username
+	event.LDAPEntry = entry
+	event.CreateData = ldapMappedData(collection, entry)
+	event.Record = authRecord
+	event.IsNewRecord = authRecord == nil
+
+	return e.App.OnRecordAuthWithLDAPRequest().Trigger(event, func(e *core.RecordAuthWithLDAPRequestEvent) error {
+		if err := ldapSubmit(e, externalAuthRel); err != nil {
+			return firstApiError(err, e.BadRequestError("Failed to authenticate.", err))
+		}
+
+		meta := map[string]any{
+			"isNew": e.IsNewRecord,
+			"dn":    e.LDAPEntry.DN,
+		}
+
+		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodLDAP, meta)
+	})
+}
+
+// -------------------------------------------------------------------
+
+type authWithLDAPForm struct {
+	Username string `form:"username" json:"username"`
+	Password string `form:"password" json:"password"`
+}
+
+func (form *authWithLDAPForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Username, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.Password, validation.Required, validation.Length(1, 255)),
+	)
+}
+
+func ldapSubmit(e *core.RecordAuthWithLDAPRequestEvent, optExternalAuth *core.ExternalAuth) error {
+	return e.App.RunInTransaction(func(txApp core.App) error {
+		email := ldapEntryEmail(e.Collection, e.LDAPEntry)
+
+		if e.Record == nil {
+			// extra check to prevent creating a superuser record via
+			// LDAP in case the method is used by another action
+			if e.Collection.Name == core.CollectionNameSuperusers {
+				return errors.New("superusers are not allowed to sign-up with LDAP")
+			}
+
+			payload := maps.Clone(e.CreateData)
+			if payload == nil {
+				payload = map[string]any{}
+			}
+
+			createdRecord, err := sendLDAPRecordCreateRequest(txApp, e, payload)
+			if err != nil {
+				return err
+			}
+
+			e.Record = createdRecord
+		} else {
+			isLoggedAuthRecord := e.Auth != nil &&
+				e.Auth.Id == e.Record.Id &&
+				e.Auth.Collection().Id == e.Record.Collection().Id
+
+			// set random password for users with unverified email
+			// (this is in case a malicious actor has registered previously with the user email)
+			if !isLoggedAuthRecord && e.Record.Email() != "" && !e.Record.Verified() {
+				e.Record.SetRandomPassword()
+			}
+
+			for field, value := range ldapMappedData(e.Collection, e.LDAPEntry) {
+				// update the email only if the record doesn't have one
+				if field == core.FieldNameEmail && e.Record.Email() != "" {
+					continue
+				}
+
+				e.Record.Set(field, value)
+			}
+		}
+
+		// mark as verified as long as it matches the directory entry email
+		if !e.Record.Verified() && (email == "" || e.Record.Email() == email) {
+			e.Record.SetVerified(true)
+		}
+
+		// sync the group roles (intentionally not part of the create
+		// payload to avoid being restricted by the collection create rule)
+		if rolesField := e.Collection.LDAP.RolesField; rolesField != "" {
+			e.Record.Set(rolesField, ldapFieldValue(
+				e.Collection.Fields.GetByName(rolesField),
+				e.Collection.LDAP.Roles(e.LDAPEntry),
+			))
+		}
+
+		if hasRecordChanges(e.Record) {
+			if err := txApp.Save(e.Record); err != nil {
+				return err
+			}
+		}
+
+		// create ExternalAuth relation if missing
+		if optExternalAuth == nil {
+			optExternalAuth = core.NewExternalAuth(txApp)
+			optExternalAuth.SetCollectionRef(e.Record.Collection().Id)
+			optExternalAuth.SetRecordRef(e.Record.Id)
+			optExternalAuth.SetProvider(core.ExternalAuthProviderLDAP)
+			optExternalAuth.SetProviderId(e.Collection.LDAP.ExternalId(e.LDAPEntry))
+
+			if err := txApp.Save(optExternalAuth); err != nil {
+				return fmt.Errorf("failed to save linked rel: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func sendLDAPRecordCreateRequest(txApp core.App, e *core.RecordAuthWithLDAPRequestEvent, payload map[string]any) (*core.Record, error) {
+	ir := &core.InternalRequest{
+		Method: http.MethodPost,
+		URL:    "/api/collections/" + e.Collection.Name + "/records",
+		Body:   payload,
+	}
+
+	var createdRecord *core.Record
+	response, err := processInternalRequest(txApp, e.RequestEvent, ir, core.RequestInfoContextLDAP, func(data any) error {
+		createdRecord, _ = data.(*core.Record)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Status != http.StatusOK || createdRecord == nil {
+		return nil, errors.New("failed to create LDAP auth record")
+	}
+
+	return createdRecord, nil
+}
+
+// ldapEntryEmail returns the directory entry email based on the collection email mapped attribute.
+func ldapEntryEmail(collection *core.Collection, entry *ldap.Entry) string {
+	attr := collection.LDAP.MappedFields[core.FieldNameEmail]
+	if attr == "" {
+		return ""
+	}
+
+	return entry.Value(attr)
+}
+
+// ldapMappedData returns the collection fields data mapped from the directory entry attributes.
+func ldapMappedData(collection *core.Collection, entry *ldap.Entry) map[string]any {
+	data := make(map[string]any, len(collection.LDAP.MappedFields))
+
+	for name, attr := range collection.LDAP.MappedFields {
+		field := collection.Fields.GetByName(name)
+		if field == nil {
+			continue
+		}
+
+		values := entry.Values(attr)
+		if len(values) == 0 {
+			continue
+		}
+
+		data[name] = ldapFieldValue(field, values)
+	}
+
+	return data
+}
+
+// ldapFieldValue normalizes the directory attribute values for the specified field
+// (multiple and json fields receive all values, the others only the first one).
+func ldapFieldValue(field core.Field, values []string) any {
+	if _, ok := field.(*core.JSONField); ok {
+		return values
+	}
+
+	if mv, ok := field.(core.MultiValuer); ok && mv.IsMultiple() {
+		return values
+	}
+
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// hasRecordChanges reports whether the record is new or
+// any of its field values differs from the last saved state.
+func hasRecordChanges(record *core.Record) bool {
+	if record.IsNew() {
+		return true
+	}
+
+	original := record.Original()
+
+	for _, field := range record.Collection().Fields {
+		if !reflect.DeepEqual(record.GetRaw(field.GetName()), original.GetRaw(field.GetName())) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,557 @@
+package apis_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	ldapTestBindDN   = "cn=admin,dc=example,dc=com"
+	ldapTestBindPass = "admin_pass"
+	ldapTestJohnDN   = "uid=john,ou=people,dc=example,dc=com"
+	ldapTestLinkedDN = "uid=test,ou=people,dc=example,dc=com"
+)
+
+// newLDAPTestServer starts a new in-process LDAP server with a few test entries.
+func newLDAPTestServer(t testing.TB, useTLS bool) *tests.TestLDAPServer {
+	server, err := tests.NewTestLDAPServer(useTLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	server.AddEntry(ldapTestBindDN, ldapTestBindPass, nil)
+	server.AddEntry(ldapTestJohnDN, "john_pass", map[string][]string{
+		"uid":      {"john"},
+		"cn":       {"John Doe"},
+		"mail":     {"john@example.com"},
+		"memberOf": {"cn=Admins,ou=groups,dc=example,dc=com", "cn=devs,ou=groups,dc=example,dc=com"},
+	})
+	server.AddEntry(ldapTestLinkedDN, "test_pass", map[string][]string{
+		"uid":  {"test"},
+		"cn":   {"Test LDAP"},
+		"mail": {"test@example.com"},
+	})
+	server.AddEntry("uid=duplicated1,ou=people,dc=example,dc=com", "dup_pass", map[string][]string{
+		"uid": {"duplicated"},
+	})
+	server.AddEntry("uid=duplicated2,ou=people,dc=example,dc=com", "dup_pass", map[string][]string{
+		"uid": {"duplicated"},
+	})
+
+	return server
+}
+
+// enableLDAP enables the LDAP auth for the test "users" collection
+// with a "roles" json field synced from the entry groups.
+func enableLDAP(t testing.TB, app *tests.TestApp, serverURL string, startTLS bool) *core.Collection {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.Fields.Add(&core.JSONField{Name: "roles"})
+
+	collection.MFA.Enabled = false
+	collection.LDAP = core.LDAPConfig{
+		Enabled:            true,
+		URL:                serverURL,
+		StartTLS:           startTLS,
+		InsecureSkipVerify: true,
+		BindDN:             ldapTestBindDN,
+		BindPassword:       ldapTestBindPass,
+		BaseDN:             "ou=people,dc=example,dc=com",
+		UserFilter:         "(&(uid={username})(uid=*))",
+		MappedFields: map[string]string{
+			"email": "mail",
+			"name":  "cn",
+		},
+		GroupAttribute: "memberOf",
+		RolesField:     "roles",
+		GroupRoles: map[string]string{
+			"cn=admins,ou=groups,dc=example,dc=com": "admin",
+			"cn=devs,ou=groups,dc=example,dc=com":   "developer",
+			"cn=other,ou=groups,dc=example,dc=com":  "other",
+		},
+		Timeout: 5,
+	}
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	return collection
+}
+
+func TestRecordAuthWithLDAP(t *testing.T) {
+	t.Parallel()
+
+	server := newLDAPTestServer(t, false)
+	tlsServer := newLDAPTestServer(t, true)
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/demo1/auth-with-ldap",
+			Body:            strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled LDAP",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-ldap",
+			Body:            strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "empty body",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"username":{"code":"validation_required"`,
+				`"password":{"code":"validation_required"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid password",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"invalid"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid password with enabled lockout",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"John","password":"invalid"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+				enableLockout(t, app, 5, 20)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnModelCreate":              2, // identity + ip lockout records
+				"OnModelCreateExecute":       2,
+				"OnModelAfterCreateSuccess":  2,
+				"OnRecordCreate":             2,
+				"OnRecordCreateExecute":      2,
+				"OnRecordAfterCreateSuccess": 2,
+				"OnModelValidate":            2,
+				"OnRecordValidate":           2,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				lockout, err := app.FindAuthLockout("_pb_users_auth_", core.AuthLockoutTypeIdentity, "john")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if lockout.Failures() != 1 {
+					t.Fatalf("Expected 1 registered failure, got %d", lockout.Failures())
+				}
+			},
+		},
+		{
+			Name:   "missing directory entry",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"missing","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "ambiguous directory entry",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"duplicated","password":"dup_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "filter injection attempt",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"*","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid service account credentials",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				collection := enableLDAP(t, app, server.URL(), false)
+				collection.LDAP.BindPassword = "invalid"
+				if err := app.Save(collection); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  500,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "unavailable directory server",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, "ldap://127.0.0.1:1", false)
+			},
+			ExpectedStatus:  500,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "OnRecordAuthWithLDAPRequest tx body write check",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+
+				app.OnRecordAuthWithLDAPRequest().BindFunc(func(e *core.RecordAuthWithLDAPRequestEvent) error {
+					original := e.App
+					return e.App.RunInTransaction(func(txApp core.App) error {
+						e.App = txApp
+						defer func() { e.App = original }()
+
+						if err := e.Next(); err != nil {
+							return err
+						}
+
+						return e.BadRequestError("TX_ERROR", nil)
+					})
+				})
+			},
+			ExpectedStatus:  400,
+			ExpectedEvents:  map[string]int{"OnRecordAuthWithLDAPRequest": 1},
+			ExpectedContent: []string{"TX_ERROR"},
+		},
+		{
+			Name:   "creating a new user",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"isNew":true`,
+				`"dn":"` + ldapTestJohnDN + `"`,
+				`"email":"john@example.com"`,
+				`"name":"John Doe"`,
+				`"roles":["admin","developer"]`,
+				`"verified":true`,
+			},
+			NotExpectedContent: []string{
+				// hidden fields
+				`"tokenKey"`,
+				`"password"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                           0,
+				"OnRecordAuthWithLDAPRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordCreateRequest":       1,
+				"OnRecordEnrich":              2, // the auth response and from the create request
+				// ---
+				"OnModelCreate":              3, // record + authOrigins + externalAuths
+				"OnModelCreateExecute":       3,
+				"OnModelAfterCreateSuccess":  3,
+				"OnRecordCreate":             3,
+				"OnRecordCreateExecute":      3,
+				"OnRecordAfterCreateSuccess": 3,
+				// ---
+				"OnModelUpdate":              1, // created record verified and roles change
+				"OnModelUpdateExecute":       1,
+				"OnModelAfterUpdateSuccess":  1,
+				"OnRecordUpdate":             1,
+				"OnRecordUpdateExecute":      1,
+				"OnRecordAfterUpdateSuccess": 1,
+				// ---
+				"OnModelValidate":  4,
+				"OnRecordValidate": 4,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindAuthRecordByEmail("users", "john@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				externalAuth, err := app.FindFirstExternalAuthByExpr(dbx.HashExp{
+					"collectionRef": user.Collection().Id,
+					"recordRef":     user.Id,
+					"provider":      core.ExternalAuthProviderLDAP,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if externalAuth.ProviderId() != ldapTestJohnDN {
+					t.Fatalf("Expected providerId %q, got %q", ldapTestJohnDN, externalAuth.ProviderId())
+				}
+			},
+		},
+		{
+			Name:   "linking an existing user by email",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"test","password":"test_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"isNew":false`,
+				`"id":"4q1xlclmfloku33"`,
+				`"email":"test@example.com"`,
+				`"name":"Test LDAP"`,
+				`"roles":[]`,
+				`"verified":true`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                           0,
+				"OnRecordAuthWithLDAPRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordEnrich":              1,
+				// ---
+				"OnModelCreate":              2, // authOrigins + externalAuths
+				"OnModelCreateExecute":       2,
+				"OnModelAfterCreateSuccess":  2,
+				"OnRecordCreate":             2,
+				"OnRecordCreateExecute":      2,
+				"OnRecordAfterCreateSuccess": 2,
+				// ---
+				"OnModelUpdate":              1, // name and roles sync
+				"OnModelUpdateExecute":       1,
+				"OnModelAfterUpdateSuccess":  1,
+				"OnRecordUpdate":             1,
+				"OnRecordUpdateExecute":      1,
+				"OnRecordAfterUpdateSuccess": 1,
+				// ---
+				"OnModelValidate":  3,
+				"OnRecordValidate": 3,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindRecordById("users", "4q1xlclmfloku33")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// the user was unverified so a random password should have been set
+				// (in case a malicious actor has registered previously with the same email)
+				if user.ValidatePassword("1234567890") {
+					t.Fatal("Expected the unverified user password to be changed")
+				}
+
+				if !user.Verified() {
+					t.Fatal("Expected the user to be marked as verified")
+				}
+
+				_, err = app.FindFirstExternalAuthByExpr(dbx.HashExp{
+					"recordRef":  user.Id,
+					"provider":   core.ExternalAuthProviderLDAP,
+					"providerId": ldapTestLinkedDN,
+				})
+				if err != nil {
+					t.Fatalf("Expected the LDAP external auth to be created: %v", err)
+				}
+			},
+		},
+		{
+			Name:   "existing linked user with custom id attribute (StartTLS)",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				collection := enableLDAP(t, app, server.URL(), true)
+				collection.LDAP.IdAttribute = "uid"
+				if err := app.Save(collection); err != nil {
+					t.Fatal(err)
+				}
+
+				user, err := app.FindRecordById("users", "oap640cot4yru2s") // test2@example.com
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				externalAuth := core.NewExternalAuth(app)
+				externalAuth.SetCollectionRef(collection.Id)
+				externalAuth.SetRecordRef(user.Id)
+				externalAuth.SetProvider(core.ExternalAuthProviderLDAP)
+				externalAuth.SetProviderId("john")
+				if err := app.Save(externalAuth); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"isNew":false`,
+				`"id":"oap640cot4yru2s"`,
+				`"email":"test2@example.com"`, // existing emails are not overwritten
+				`"name":"John Doe"`,
+				`"roles":["admin","developer"]`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                           0,
+				"OnRecordAuthWithLDAPRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordEnrich":              1,
+				// ---
+				"OnModelCreate":              1, // authOrigins
+				"OnModelCreateExecute":       1,
+				"OnModelAfterCreateSuccess":  1,
+				"OnRecordCreate":             1,
+				"OnRecordCreateExecute":      1,
+				"OnRecordAfterCreateSuccess": 1,
+				// ---
+				"OnModelUpdate":              1, // name and roles sync
+				"OnModelUpdateExecute":       1,
+				"OnModelAfterUpdateSuccess":  1,
+				"OnRecordUpdate":             1,
+				"OnRecordUpdateExecute":      1,
+				"OnRecordAfterUpdateSuccess": 1,
+				// ---
+				"OnModelValidate":  2,
+				"OnRecordValidate": 2,
+			},
+		},
+		{
+			Name:   "ldaps connection",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"test","password":"test_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, tlsServer.URL(), false)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"isNew":false`,
+				`"id":"4q1xlclmfloku33"`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithLDAPRequest": 1,
+				"OnRecordAuthRequest":         1,
+			},
+		},
+		{
+			Name:   "MFA enabled",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"test","password":"test_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				collection := enableLDAP(t, app, server.URL(), false)
+				collection.MFA.Enabled = true
+				if err := app.Save(collection); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"mfaId":"`},
+			NotExpectedContent: []string{
+				`"token":`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithLDAPRequest": 1,
+				"OnRecordAuthRequest":         1,
+			},
+		},
+		{
+			Name:   "locked identity",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+				enableLockout(t, app, 5, 20)
+				createTestLockout(t, app, core.AuthLockoutTypeIdentity, "john", types.NowDateTime().Add(time.Hour))
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+
+		// rate limit checks
+		// -----------------------------------------------------------
+		{
+			Name:   "RateLimit rule - users:authWithLDAP",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+
+				app.Settings().RateLimits.Enabled = true
+				app.Settings().RateLimits.Rules = []core.RateLimitRule{
+					{MaxRequests: 100, Label: "abc"},
+					{MaxRequests: 100, Label: "*:authWithLDAP"},
+					{MaxRequests: 0, Label: "users:authWithLDAP"},
+				}
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "RateLimit rule - *:authWithLDAP",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-ldap",
+			Body:   strings.NewReader(`{"username":"john","password":"john_pass"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableLDAP(t, app, server.URL(), false)
+
+				app.Settings().RateLimits.Enabled = true
+				app.Settings().RateLimits.Rules = []core.RateLimitRule{
+					{MaxRequests: 100, Label: "abc"},
+					{MaxRequests: 0, Label: "*:authWithLDAP"},
+				}
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
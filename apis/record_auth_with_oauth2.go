@@ -18,6 +18,7 @@ import (
 	"github.com/pocketbase/pocketbase/tools/auth"
 	"github.com/pocketbase/pocketbase/tools/dbutils"
 	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"github.com/pocketbase/pocketbase/tools/tracing"
 	"golang.org/x/oauth2"
 )
 
@@ -70,6 +71,11 @@ func recordAuthWithOAuth2(e *core.RequestEvent) error {
 	ctx, cancel := context.WithTimeout(e.Request.Context(), 30*time.Second)
 	defer cancel()
 
+	// trace the outgoing provider requests
+	if e.App.Tracer() != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, tracing.NewClient(nil))
+	}
+
 	provider.SetContext(ctx)
 	provider.SetRedirectURL(form.RedirectURL)
 
@@ -213,16 +219,16 @@ func (form *recordOAuth2LoginForm) checkProviderName(value any) error {
 	return nil
 }
 
-func oldCanAssignUsername(txApp core.App, collection *core.Collection, username string) bool {
+func oldCanAssignUsername(txApp core.App, collection *core.Collection, fieldName string, username string) bool {
 	// ensure that username is unique
-	index, hasUniqueue := dbutils.FindSingleColumnUniqueIndex(collection.Indexes, collection.OAuth2.MappedFields.Username)
+	index, hasUniqueue := dbutils.FindSingleColumnUniqueIndex(collection.Indexes, fieldName)
 	if hasUniqueue {
 		var expr dbx.Expression
 		if strings.EqualFold(index.Columns[0].Collate, "nocase") {
 			// case-insensitive search
-			expr = dbx.NewExp("username = {:username} COLLATE NOCASE", dbx.Params{"username": username})
+			expr = dbx.NewExp("[["+fieldName+"]] = {:username} COLLATE NOCASE", dbx.Params{"username": username})
 		} else {
-			expr = dbx.HashExp{"username": username}
+			expr = dbx.HashExp{fieldName: username}
 		}
 
 		var exists int
@@ -233,11 +239,34 @@ func oldCanAssignUsername(txApp core.App, collection *core.Collection, username
 	}
 
 	// ensure that the value matches the pattern of the username field (if text)
-	txtField, _ := collection.Fields.GetByName(collection.OAuth2.MappedFields.Username).(*core.TextField)
+	txtField, _ := collection.Fields.GetByName(fieldName).(*core.TextField)
 
 	return txtField != nil && txtField.ValidatePlainValue(username) == nil
 }
 
+// mappedAvatarValue returns the avatarURL value for the specified mapped field
+// (the avatar is downloaded if the field is a file field).
+func mappedAvatarValue(txApp core.App, collection *core.Collection, fieldName string, avatarURL string) (any, bool) {
+	mappedField := collection.Fields.GetByName(fieldName)
+	if mappedField == nil || mappedField.Type() != core.FieldTypeFile {
+		// assign the url string
+		return avatarURL, true
+	}
+
+	// download the avatar if the mapped field is a file
+	avatarFile, err := func() (*filesystem.File, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		return filesystem.NewFileFromURL(ctx, avatarURL)
+	}()
+	if err != nil {
+		txApp.Logger().Warn("Failed to retrieve the mapped avatar", slog.String("error", err.Error()))
+		return nil, false
+	}
+
+	return avatarFile, true
+}
+
 func oauth2Submit(e *core.RecordAuthWithOAuth2RequestEvent, optExternalAuth *core.ExternalAuth) error {
 	return e.App.RunInTransaction(func(txApp core.App) error {
 		if e.Record == nil {
@@ -269,7 +298,7 @@ func oauth2Submit(e *core.RecordAuthWithOAuth2RequestEvent, optExternalAuth *cor
 				// no explicit username payload value and existing OAuth2 mapping
 				e.Collection.OAuth2.MappedFields.Username != "" &&
 				// extra checks for backward compatibility with earlier versions
-				oldCanAssignUsername(txApp, e.Collection, e.OAuth2User.Username) {
+				oldCanAssignUsername(txApp, e.Collection, e.Collection.OAuth2.MappedFields.Username, e.OAuth2User.Username) {
 				payload[e.Collection.OAuth2.MappedFields.Username] = e.OAuth2User.Username
 			}
 			if _, ok := payload[e.Collection.OAuth2.MappedFields.AvatarURL]; !ok &&
@@ -277,22 +306,9 @@ func oauth2Submit(e *core.RecordAuthWithOAuth2RequestEvent, optExternalAuth *cor
 				e.Collection.OAuth2.MappedFields.AvatarURL != "" &&
 				// non-empty OAuth2 avatar url
 				e.OAuth2User.AvatarURL != "" {
-				mappedField := e.Collection.Fields.GetByName(e.Collection.OAuth2.MappedFields.AvatarURL)
-				if mappedField != nil && mappedField.Type() == core.FieldTypeFile {
-					// download the avatar if the mapped field is a file
-					avatarFile, err := func() (*filesystem.File, error) {
-						ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-						defer cancel()
-						return filesystem.NewFileFromURL(ctx, e.OAuth2User.AvatarURL)
-					}()
-					if err != nil {
-						txApp.Logger().Warn("Failed to retrieve OAuth2 avatar", slog.String("error", err.Error()))
-					} else {
-						payload[e.Collection.OAuth2.MappedFields.AvatarURL] = avatarFile
-					}
-				} else {
-					// otherwise - assign the url string
-					payload[e.Collection.OAuth2.MappedFields.AvatarURL] = e.OAuth2User.AvatarURL
+				avatar, ok := mappedAvatarValue(txApp, e.Collection, e.Collection.OAuth2.MappedFields.AvatarURL, e.OAuth2User.AvatarURL)
+				if ok {
+					payload[e.Collection.OAuth2.MappedFields.AvatarURL] = avatar
 				}
 			}
 
@@ -53,6 +53,10 @@ func recordAuthWithOTP(e *core.RequestEvent) error {
 		return e.BadRequestError("Invalid or expired OTP", fmt.Errorf("missing auth record: %w", err))
 	}
 
+	if err := checkAuthLockout(e, collection, event.Record.Id); err != nil {
+		return err
+	}
+
 	// since otps are usually simple digit numbers, enforce an extra rate limit rule as basic enumaration protection
 	err = checkRateLimit(e, "@pb_otp_"+event.Record.Id, core.RateLimitRule{MaxRequests: 5, Duration: 180})
 	if err != nil {
@@ -60,8 +64,11 @@ func recordAuthWithOTP(e *core.RequestEvent) error {
 	}
 
 	if !event.OTP.ValidatePassword(form.Password) {
+		registerAuthFailure(e, collection, event.Record.Id, event.Record)
 		return e.BadRequestError("Invalid or expired OTP", errors.New("incorrect password"))
 	}
+
+	clearAuthFailures(e, collection, event.Record.Id)
 	// ---
 
 	return e.App.OnRecordAuthWithOTPRequest().Trigger(event, func(e *core.RecordAuthWithOTPRequestEvent) error {
@@ -34,47 +34,18 @@ func recordAuthWithPassword(e *core.RequestEvent) error {
 
 	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextPasswordAuth)
 
-	var foundRecord *core.Record
-	var foundErr error
-
-	if form.IdentityField != "" {
-		foundRecord, foundErr = findRecordByIdentityField(e.App, collection, form.IdentityField, form.Identity)
-	} else {
-		identityFields := collection.PasswordAuth.IdentityFields
-
-		// @todo consider removing with the stable release or moving it in the collection save
-		//
-		// prioritize email lookup to minimize breaking changes with earlier versions
-		if len(identityFields) > 1 && identityFields[0] != core.FieldNameEmail {
-			identityFields = slices.Clone(identityFields)
-			slices.SortStableFunc(identityFields, func(a, b string) int {
-				if a == "email" {
-					return -1
-				}
-				if b == "email" {
-					return 1
-				}
-				return 0
-			})
-		}
-
-		for _, name := range identityFields {
-			if name == core.FieldNameEmail && is.EmailFormat.Validate(form.Identity) != nil {
-				continue // no need to query the database if we know that the submitted value is not an email
-			}
-
-			foundRecord, foundErr = findRecordByIdentityField(e.App, collection, name, form.Identity)
-			if foundErr == nil {
-				break
-			}
-		}
-	}
+	foundRecord, foundErr := findRecordByPasswordIdentity(e.App, collection, form.IdentityField, form.Identity)
 
 	// ignore not found errors to allow custom record find implementations
 	if foundErr != nil && !errors.Is(foundErr, sql.ErrNoRows) {
 		return e.InternalServerError("", foundErr)
 	}
 
+	lockoutIdentity := authLockoutIdentity(foundRecord, form.Identity)
+	if err := checkAuthLockout(e, collection, lockoutIdentity); err != nil {
+		return err
+	}
+
 	event := new(core.RecordAuthWithPasswordRequestEvent)
 	event.RequestEvent = e
 	event.Collection = collection
@@ -85,9 +56,16 @@ func recordAuthWithPassword(e *core.RequestEvent) error {
 
 	return e.App.OnRecordAuthWithPasswordRequest().Trigger(event, func(e *core.RecordAuthWithPasswordRequestEvent) error {
 		if e.Record == nil || !e.Record.ValidatePassword(e.Password) {
+			registerAuthFailure(e.RequestEvent, e.Collection, lockoutIdentity, e.Record)
 			return e.BadRequestError("Failed to authenticate.", errors.New("invalid login credentials"))
 		}
 
+		clearAuthFailures(e.RequestEvent, e.Collection, lockoutIdentity)
+
+		if err := checkPasswordExpiration(e.RequestEvent, e.Record); err != nil {
+			return err
+		}
+
 		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodPassword, nil)
 	})
 }
@@ -115,6 +93,49 @@ func (form *authWithPasswordForm) validate(collection *core.Collection) error {
 	)
 }
 
+// findRecordByPasswordIdentity searches for an auth record matching
+// the provided identity value in the specified identityField or in
+// any of the collection password auth identity fields (if empty).
+func findRecordByPasswordIdentity(app core.App, collection *core.Collection, identityField string, identity string) (*core.Record, error) {
+	if identityField != "" {
+		return findRecordByIdentityField(app, collection, identityField, identity)
+	}
+
+	identityFields := collection.PasswordAuth.IdentityFields
+
+	// @todo consider removing with the stable release or moving it in the collection save
+	//
+	// prioritize email lookup to minimize breaking changes with earlier versions
+	if len(identityFields) > 1 && identityFields[0] != core.FieldNameEmail {
+		identityFields = slices.Clone(identityFields)
+		slices.SortStableFunc(identityFields, func(a, b string) int {
+			if a == "email" {
+				return -1
+			}
+			if b == "email" {
+				return 1
+			}
+			return 0
+		})
+	}
+
+	var foundRecord *core.Record
+	var foundErr error
+
+	for _, name := range identityFields {
+		if name == core.FieldNameEmail && is.EmailFormat.Validate(identity) != nil {
+			continue // no need to query the database if we know that the submitted value is not an email
+		}
+
+		foundRecord, foundErr = findRecordByIdentityField(app, collection, name, identity)
+		if foundErr == nil {
+			break
+		}
+	}
+
+	return foundRecord, foundErr
+}
+
 func findRecordByIdentityField(app core.App, collection *core.Collection, field string, value any) (*core.Record, error) {
 	if !slices.Contains(collection.PasswordAuth.IdentityFields, field) {
 		return nil, errors.New("invalid identity field " + field)
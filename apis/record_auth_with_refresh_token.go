@@ -0,0 +1,164 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// errRefreshTokenReused is returned when the submitted refresh token
+// was rotated by another (concurrent) request.
+var errRefreshTokenReused = errors.New("the refresh token was already rotated")
+
+func recordAuthWithRefreshToken(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.Sessions.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow refresh token authentication.", nil)
+	}
+
+	form := &authWithRefreshTokenForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextRefreshToken)
+
+	event := new(core.RecordAuthWithRefreshTokenRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+
+	event.Session, err = e.App.FindAuthSessionByRefreshToken(form.RefreshToken)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired refresh token.", err)
+	}
+
+	if event.Session.CollectionRef() != collection.Id {
+		return e.BadRequestError("Invalid or expired refresh token.", errors.New("the session is for a different collection"))
+	}
+
+	if event.Session.HasExpired() {
+		return e.BadRequestError("Invalid or expired refresh token.", errors.New("the session is expired"))
+	}
+
+	if !event.Session.ValidateRefreshToken(form.RefreshToken) {
+		// an already rotated (and possibly stolen) refresh token was reused
+		if event.Session.IsRotatedRefreshToken(form.RefreshToken) {
+			return rejectRotatedRefreshToken(e, event.Session, form.RefreshToken)
+		}
+
+		// any other secret (eg. guessed from the session id of an access token)
+		// is rejected without touching the session
+		return e.BadRequestError("Invalid or expired refresh token.", errors.New("invalid refresh token secret"))
+	}
+
+	event.Record, err = e.App.FindRecordById(collection, event.Session.RecordRef())
+	if err != nil {
+		return e.BadRequestError("Invalid or expired refresh token.", fmt.Errorf("missing auth record: %w", err))
+	}
+
+	return e.App.OnRecordAuthWithRefreshTokenRequest().Trigger(event, func(e *core.RecordAuthWithRefreshTokenRequestEvent) error {
+		submittedHash := e.Session.TokenHash()
+
+		refreshToken := e.Session.RefreshValue()
+		e.Session.SetIP(e.RealIP())
+		e.Session.SetLastUsed(types.NowDateTime())
+		e.Session.SetExpires(types.NowDateTime().Add(e.Collection.Sessions.RefreshTokenDurationTime()))
+
+		// persist the rotated token before the auth response to invalidate the submitted one
+		err := e.App.RunInTransaction(func(txApp core.App) error {
+			// compare-and-swap the token hash so that only one of the
+			// concurrent requests with the same refresh token can rotate it
+			result, err := txApp.NonconcurrentDB().Update(
+				core.CollectionNameAuthSessions,
+				dbx.Params{"tokenHash": e.Session.TokenHash()},
+				dbx.HashExp{"id": e.Session.Id, "tokenHash": submittedHash},
+			).Execute()
+			if err != nil {
+				return err
+			}
+			if updated, err := result.RowsAffected(); err != nil || updated != 1 {
+				return errRefreshTokenReused
+			}
+
+			return txApp.Save(e.Session)
+		})
+		if errors.Is(err, errRefreshTokenReused) {
+			// the submitted token was valid when loaded but it was rotated
+			// in the meantime by another (concurrent) request
+			current, findErr := e.App.FindAuthSessionByRefreshToken(form.RefreshToken)
+			if findErr != nil {
+				return e.BadRequestError("Invalid or expired refresh token.", findErr)
+			}
+
+			if current.IsRotatedRefreshToken(form.RefreshToken) {
+				return rejectRotatedRefreshToken(e.RequestEvent, current, form.RefreshToken)
+			}
+
+			return e.BadRequestError("Invalid or expired refresh token.", err)
+		}
+		if err != nil {
+			return firstApiError(err, e.InternalServerError("Failed to rotate the refresh token.", err))
+		}
+
+		token, err := e.App.NewRecordSessionAuthToken(e.Record, e.Session.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to create auth token.", err)
+		}
+
+		return recordAuthResponse(e.RequestEvent, e.Record, token, "", nil, nil, refreshToken)
+	})
+}
+
+// rejectRotatedRefreshToken rejects the use of an already rotated refresh token.
+//
+// The last rotated refresh token is rejected without touching the session
+// within [core.AuthSessionRotationGracePeriod] after its rotation because it is
+// usually a concurrent refresh (eg. another browser tab or a retried request).
+// Any other reuse revokes the entire session.
+func rejectRotatedRefreshToken(e *core.RequestEvent, session *core.AuthSession, refreshToken string) error {
+	if session.IsRecentlyRotatedRefreshToken(refreshToken) {
+		return e.BadRequestError("Invalid or expired refresh token.", errors.New("the refresh token was just rotated by another request"))
+	}
+
+	return revokeReusedAuthSession(e, session)
+}
+
+// revokeReusedAuthSession deletes the auth session (aka. the entire
+// refresh token family) after a reuse of an already rotated refresh token.
+func revokeReusedAuthSession(e *core.RequestEvent, session *core.AuthSession) error {
+	if err := e.App.Delete(session); err != nil {
+		return e.InternalServerError("Failed to revoke the auth session.", err)
+	}
+
+	e.App.Logger().Warn(
+		"Refresh token reuse detected - the auth session was revoked",
+		"sessionId", session.Id,
+		"recordId", session.RecordRef(),
+		"collectionId", session.CollectionRef(),
+	)
+
+	return e.BadRequestError("Invalid or expired refresh token.", nil)
+}
+
+// -------------------------------------------------------------------
+
+type authWithRefreshTokenForm struct {
+	RefreshToken string `form:"refreshToken" json:"refreshToken"`
+}
+
+func (form *authWithRefreshTokenForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.RefreshToken, validation.Required, validation.Length(1, 255)),
+	)
+}
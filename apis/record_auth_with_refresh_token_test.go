@@ -0,0 +1,454 @@
+package apis_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	sessionTestId           = "session00000001"
+	sessionTestSecret       = "test_refresh_token_secret"
+	sessionTestRefreshToken = sessionTestId + "." + sessionTestSecret
+)
+
+// enableSessions enables the sessions for the test "users" collection
+// and optionally creates a [sessionTestRefreshToken] session for the specified user.
+func enableSessions(t testing.TB, app *tests.TestApp, sessionEmail string) *core.AuthSession {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.Sessions.Enabled = true
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if sessionEmail == "" {
+		return nil
+	}
+
+	user, err := app.FindAuthRecordByEmail(collection, sessionEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := core.NewAuthSession(app)
+	session.Id = sessionTestId
+	session.SetCollectionRef(collection.Id)
+	session.SetRecordRef(user.Id)
+	session.Set("tokenHash", security.SHA256(sessionTestSecret))
+	session.SetUserAgent("test_ua")
+	session.SetIP("1.2.3.4")
+	session.SetExpires(types.NowDateTime().Add(time.Hour))
+	if err := app.Save(session); err != nil {
+		t.Fatal(err)
+	}
+
+	return session
+}
+
+func TestRecordAuthWithRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	body := func() *strings.Reader {
+		return strings.NewReader(`{"refreshToken":"` + sessionTestRefreshToken + `"}`)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/demo1/auth-with-refresh-token",
+			Body:            body(),
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled sessions",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-refresh-token",
+			Body:            body(),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "empty body",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableSessions(t, app, "test@example.com")
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"refreshToken":{"code":"validation_required"`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "missing session",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   body(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableSessions(t, app, "")
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "session from a different collection",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   body(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				session := enableSessions(t, app, "test@example.com")
+				session.SetCollectionRef("v851q4r790rhknl") // clients
+				session.SetRecordRef("gk390qegs4y47wn")
+				if err := app.Save(session); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "expired session",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   body(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				session := enableSessions(t, app, "test@example.com")
+				session.SetExpires(types.NowDateTime().Add(-time.Minute))
+				if err := app.Save(session); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "random secret with an existing session id",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   strings.NewReader(`{"refreshToken":"` + sessionTestId + `.random_secret"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				session := enableSessions(t, app, "test@example.com")
+				session.Set("previousHashes", []string{security.SHA256("old_secret")})
+				if err := app.Save(session); err != nil {
+					t.Fatal(err)
+				}
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				session, err := app.FindAuthSessionByRefreshToken(sessionTestRefreshToken)
+				if err != nil {
+					t.Fatalf("Expected the session to remain, got %v", err)
+				}
+
+				if !session.ValidateRefreshToken(sessionTestRefreshToken) {
+					t.Fatal("Expected the current refresh token to remain valid")
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "reused (already rotated) refresh token",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   strings.NewReader(`{"refreshToken":"` + sessionTestId + `.old_secret"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				session := enableSessions(t, app, "test@example.com")
+				session.Set("previousHashes", []string{security.SHA256("old_secret")})
+				if err := app.Save(session); err != nil {
+					t.Fatal(err)
+				}
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if _, err := app.FindAuthSessionByRefreshToken(sessionTestRefreshToken); err == nil {
+					t.Fatal("Expected the entire session to be revoked")
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnModelDelete":              1,
+				"OnModelDeleteExecute":       1,
+				"OnModelAfterDeleteSuccess":  1,
+				"OnRecordDelete":             1,
+				"OnRecordDeleteExecute":      1,
+				"OnRecordAfterDeleteSuccess": 1,
+			},
+		},
+		{
+			Name:   "refresh token rotated in the meantime by another request",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   body(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableSessions(t, app, "test@example.com")
+
+				// simulate a concurrent rotation after the session lookup
+				app.OnRecordAuthWithRefreshTokenRequest().BindFunc(func(e *core.RecordAuthWithRefreshTokenRequestEvent) error {
+					_, err := e.App.NonconcurrentDB().Update(
+						core.CollectionNameAuthSessions,
+						dbx.Params{"tokenHash": security.SHA256("other_secret")},
+						dbx.HashExp{"id": sessionTestId},
+					).Execute()
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					return e.Next()
+				})
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				session, err := app.FindAuthSessionByRefreshToken(sessionTestRefreshToken)
+				if err != nil {
+					t.Fatalf("Expected the session to remain, got %v", err)
+				}
+
+				if session.TokenHash() != security.SHA256("other_secret") {
+					t.Fatal("Expected the concurrently rotated token hash to remain")
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			NotExpectedContent: []string{
+				`"token":`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                                   0,
+				"OnRecordAuthWithRefreshTokenRequest": 1,
+			},
+		},
+		{
+			Name:   "last rotated refresh token within the rotation grace period",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   strings.NewReader(`{"refreshToken":"` + sessionTestId + `.old_secret"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				session := enableSessions(t, app, "test@example.com")
+				session.Set("previousHashes", []string{security.SHA256("old_secret")})
+				session.SetLastUsed(types.NowDateTime())
+				if err := app.Save(session); err != nil {
+					t.Fatal(err)
+				}
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				session, err := app.FindAuthSessionByRefreshToken(sessionTestRefreshToken)
+				if err != nil {
+					t.Fatalf("Expected the session to remain, got %v", err)
+				}
+
+				if !session.ValidateRefreshToken(sessionTestRefreshToken) {
+					t.Fatal("Expected the current refresh token to remain valid")
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "OnRecordAuthWithRefreshTokenRequest tx body write check",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   body(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableSessions(t, app, "test@example.com")
+
+				app.OnRecordAuthWithRefreshTokenRequest().BindFunc(func(e *core.RecordAuthWithRefreshTokenRequestEvent) error {
+					original := e.App
+					return e.App.RunInTransaction(func(txApp core.App) error {
+						e.App = txApp
+						defer func() { e.App = original }()
+
+						if err := e.Next(); err != nil {
+							return err
+						}
+
+						return e.BadRequestError("TX_ERROR", nil)
+					})
+				})
+			},
+			ExpectedStatus:  400,
+			ExpectedEvents:  map[string]int{"OnRecordAuthWithRefreshTokenRequest": 1},
+			ExpectedContent: []string{"TX_ERROR"},
+		},
+		{
+			Name:   "valid refresh token",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   body(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableSessions(t, app, "test@example.com")
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				session, err := app.FindAuthSessionByRefreshToken(sessionTestRefreshToken)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if session.ValidateRefreshToken(sessionTestRefreshToken) {
+					t.Fatal("Expected the refresh token to be rotated")
+				}
+
+				if session.LastUsed().IsZero() {
+					t.Fatal("Expected lastUsed to be updated")
+				}
+
+				if session.IP() == "1.2.3.4" {
+					t.Fatal("Expected the session ip to be updated")
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"refreshToken":"` + sessionTestId + `.`,
+				`"record":{`,
+				`"email":"test@example.com"`,
+			},
+			NotExpectedContent: []string{
+				`"meta":`,
+				`"mfaId":`,
+				`"refreshToken":"` + sessionTestRefreshToken + `"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                                   0,
+				"OnRecordAuthWithRefreshTokenRequest": 1,
+				"OnRecordAuthRequest":                 1,
+				"OnRecordEnrich":                      1,
+				// ---
+				"OnModelValidate":           1,
+				"OnModelUpdate":             1, // session rotation
+				"OnModelUpdateExecute":      1,
+				"OnModelAfterUpdateSuccess": 1,
+				// ---
+				"OnRecordValidate":           1,
+				"OnRecordUpdate":             1,
+				"OnRecordUpdateExecute":      1,
+				"OnRecordAfterUpdateSuccess": 1,
+			},
+		},
+
+		// rate limit checks
+		// -----------------------------------------------------------
+		{
+			Name:   "RateLimit rule - users:authWithRefreshToken",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-refresh-token",
+			Body:   body(),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableSessions(t, app, "test@example.com")
+
+				app.Settings().RateLimits.Enabled = true
+				app.Settings().RateLimits.Rules = []core.RateLimitRule{
+					{MaxRequests: 100, Label: "abc"},
+					{MaxRequests: 100, Label: "*:authWithRefreshToken"},
+					{MaxRequests: 0, Label: "users:authWithRefreshToken"},
+				}
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestRecordAuthWithRefreshTokenConcurrentRotation(t *testing.T) {
+	t.Parallel()
+
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	enableSessions(t, app, "test@example.com")
+
+	router, err := apis.NewRouter(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux, err := router.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	var newRefreshToken atomic.Value
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/collections/users/auth-with-refresh-token",
+				strings.NewReader(`{"refreshToken":"`+sessionTestRefreshToken+`"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			switch rec.Code {
+			case http.StatusOK:
+				succeeded.Add(1)
+
+				result := struct {
+					RefreshToken string `json:"refreshToken"`
+				}{}
+				if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+					t.Errorf("Failed to unmarshal the auth response: %v", err)
+				}
+				newRefreshToken.Store(result.RefreshToken)
+			case http.StatusBadRequest:
+			default:
+				t.Errorf("Unexpected response %d: %s", rec.Code, rec.Body.String())
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if v := succeeded.Load(); v != 1 {
+		t.Fatalf("Expected exactly 1 successful rotation, got %d", v)
+	}
+
+	// the concurrent refreshes with the same valid token shouldn't revoke the session
+	session, err := app.FindAuthSessionByRefreshToken(sessionTestRefreshToken)
+	if err != nil {
+		t.Fatalf("Expected the session to remain, got %v", err)
+	}
+
+	token, _ := newRefreshToken.Load().(string)
+	if !session.ValidateRefreshToken(token) {
+		t.Fatalf("Expected the rotated refresh token %q to be valid", token)
+	}
+}
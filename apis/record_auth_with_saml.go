@@ -0,0 +1,454 @@
+package apis
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/saml"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+const (
+	// samlCodeDuration is the lifetime of the one-time login codes
+	// that are exchanged for an auth token with auth-with-saml.
+	samlCodeDuration = 1 * time.Minute
+
+	// samlMaxResponseLength is the max allowed length of the encoded SAMLResponse.
+	samlMaxResponseLength = 1 << 20
+)
+
+// samlState holds the data of a pending SP-initiated login request.
+type samlState struct {
+	RequestId   string `json:"requestId"`
+	RedirectURL string `json:"redirectURL"`
+}
+
+// samlCodeData holds the verified assertion of a completed login
+// until it is exchanged with auth-with-saml.
+type samlCodeData struct {
+	Assertion *saml.Assertion `json:"assertion"`
+}
+
+func checkSAMLCollection(e *core.RequestEvent) (*core.Collection, *saml.ServiceProvider, error) {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !collection.SAML.Enabled {
+		return nil, nil, e.ForbiddenError("The collection is not configured to allow SAML authentication.", nil)
+	}
+
+	sp, err := core.NewSAMLServiceProvider(e.App, collection)
+	if err != nil {
+		return nil, nil, e.InternalServerError("Failed to initialize the SAML service provider.", err)
+	}
+
+	return collection, sp, nil
+}
+
+// -------------------------------------------------------------------
+
+func recordSAMLMetadata(e *core.RequestEvent) error {
+	_, sp, err := checkSAMLCollection(e)
+	if err != nil {
+		return err
+	}
+
+	return e.Blob(http.StatusOK, "application/samlmetadata+xml", sp.Metadata())
+}
+
+// recordSAMLLogin starts a new SP-initiated login by redirecting
+// to the IdP with a signed AuthnRequest.
+func recordSAMLLogin(e *core.RequestEvent) error {
+	collection, sp, err := checkSAMLCollection(e)
+	if err != nil {
+		return err
+	}
+
+	redirectURL := e.Request.URL.Query().Get("redirectURL")
+	if !collection.SAML.IsAllowedRedirectURL(redirectURL) {
+		return e.BadRequestError("Missing or invalid redirectURL.", nil)
+	}
+
+	state := &samlState{
+		RequestId:   saml.NewRequestId(),
+		RedirectURL: redirectURL,
+	}
+
+	// short random RelayState (the spec recommends up to 80 bytes)
+	relayState := security.RandomString(40)
+
+	loginURL, err := sp.AuthnRequestURL(state.RequestId, relayState)
+	if err != nil {
+		return e.InternalServerError("Failed to create SAML AuthnRequest.", err)
+	}
+
+	_, err = core.CreateAuthChallenge(e.App, collection, core.AuthChallengeKindSAMLState, relayState, state, collection.SAML.DurationTime())
+	if err != nil {
+		return e.InternalServerError("Failed to store the SAML login request.", err)
+	}
+
+	return e.Redirect(http.StatusFound, loginURL)
+}
+
+type samlACSForm struct {
+	SAMLResponse string `form:"SAMLResponse" json:"SAMLResponse"`
+	RelayState   string `form:"RelayState" json:"RelayState"`
+}
+
+// recordSAMLACS is the AssertionConsumerService (HTTP-POST binding).
+//
+// On success it redirects back to the client redirect URL with a
+// one-time "code" query parameter that could be exchanged for an
+// auth token with the auth-with-saml endpoint.
+func recordSAMLACS(e *core.RequestEvent) error {
+	collection, sp, err := checkSAMLCollection(e)
+	if err != nil {
+		return err
+	}
+
+	form := &samlACSForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+
+	if form.SAMLResponse == "" || len(form.SAMLResponse) > samlMaxResponseLength {
+		return e.BadRequestError("Missing or invalid SAMLResponse.", nil)
+	}
+
+	var requestId string
+	var redirectURL string
+
+	// the login request state is single-use so that only one of the
+	// concurrent responses to the same request could be accepted
+	state := &samlState{}
+	stateChallenge, _ := core.ConsumeAuthChallenge(e.App, collection, core.AuthChallengeKindSAMLState, form.RelayState)
+	if stateChallenge != nil && stateChallenge.UnmarshalJSONField("data", state) == nil {
+		// SP-initiated
+		requestId = state.RequestId
+		redirectURL = state.RedirectURL
+	} else {
+		// IdP-initiated (aka. unsolicited response)
+		if !collection.SAML.AllowIdPInitiated {
+			return e.BadRequestError("Missing or expired SAML login request.", nil)
+		}
+
+		if collection.SAML.IsAllowedRedirectURL(form.RelayState) {
+			redirectURL = form.RelayState
+		} else {
+			redirectURL = collection.SAML.RedirectURLs[0]
+		}
+	}
+
+	assertion, err := sp.ParseResponse(form.SAMLResponse, requestId)
+	if err != nil {
+		e.App.Logger().Debug("Invalid SAML response", "error", err, "collectionId", collection.Id)
+		return e.Redirect(http.StatusSeeOther, samlRedirectURL(redirectURL, "error", "invalid_response"))
+	}
+
+	// prevent replay of the same assertion while it is still valid
+	// (the insert fails on the unique challenge index if it was already accepted)
+	_, err = core.CreateAuthChallenge(
+		e.App,
+		collection,
+		core.AuthChallengeKindSAMLAssertion,
+		assertion.Issuer+"_"+assertion.ID,
+		nil,
+		time.Until(assertion.NotOnOrAfter)+saml.DefaultClockSkew,
+	)
+	if err != nil {
+		e.App.Logger().Debug("Reused SAML assertion", "id", assertion.ID, "collectionId", collection.Id, "error", err)
+		return e.Redirect(http.StatusSeeOther, samlRedirectURL(redirectURL, "error", "invalid_response"))
+	}
+
+	code := security.RandomString(40)
+
+	_, err = core.CreateAuthChallenge(e.App, collection, core.AuthChallengeKindSAMLCode, code, &samlCodeData{
+		Assertion: assertion,
+	}, samlCodeDuration)
+	if err != nil {
+		e.App.Logger().Error("Failed to store the SAML login code", "error", err, "collectionId", collection.Id)
+		return e.Redirect(http.StatusSeeOther, samlRedirectURL(redirectURL, "error", "server_error"))
+	}
+
+	return e.Redirect(http.StatusSeeOther, samlRedirectURL(redirectURL, "code", code))
+}
+
+// samlRedirectURL appends the specified query parameter to the redirect url.
+func samlRedirectURL(redirectURL string, param string, value string) string {
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		return redirectURL
+	}
+
+	query := u.Query()
+	query.Set(param, value)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// -------------------------------------------------------------------
+
+func recordAuthWithSAML(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.SAML.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow SAML authentication.", nil)
+	}
+
+	var fallbackAuthRecord *core.Record
+	if e.Auth != nil && e.Auth.Collection().Id == collection.Id {
+		fallbackAuthRecord = e.Auth
+	}
+
+	form := &authWithSAMLForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextSAML)
+
+	// consume the one-time code
+	// ---------------------------------------------------------------
+
+	codeChallenge, err := core.ConsumeAuthChallenge(e.App, collection, core.AuthChallengeKindSAMLCode, form.Code)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired SAML code.", err)
+	}
+
+	data := &samlCodeData{}
+	if err := codeChallenge.UnmarshalJSONField("data", data); err != nil || data.Assertion == nil {
+		return e.BadRequestError("Invalid or expired SAML code.", err)
+	}
+
+	externalId := collection.SAML.ExternalId(data.Assertion)
+	if externalId == "" {
+		return e.BadRequestError("Missing SAML user id.", nil)
+	}
+
+	// locate existing ExternalAuth rel or auth record
+	// ---------------------------------------------------------------
+
+	var authRecord *core.Record
+
+	externalAuthRel, err := e.App.FindFirstExternalAuthByExpr(dbx.HashExp{
+		"collectionRef": collection.Id,
+		"provider":      core.ExternalAuthProviderSAML,
+		"providerId":    externalId,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.InternalServerError("Failed SAML relation check.", err)
+	}
+
+	email := collection.SAML.Email(data.Assertion)
+
+	switch {
+	case err == nil && externalAuthRel != nil:
+		authRecord, err = e.App.FindRecordById(collection, externalAuthRel.RecordRef())
+		if err != nil {
+			return err
+		}
+	case fallbackAuthRecord != nil:
+		// fallback to the logged auth record (if any)
+		authRecord = fallbackAuthRecord
+	case email != "":
+		// look for an existing auth record by the assertion email
+		authRecord, err = e.App.FindAuthRecordByEmail(collection.Id, email)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return e.InternalServerError("Failed SAML auth record check.", err)
+		}
+	}
+
+	// ---------------------------------------------------------------
+
+	event := new(core.RecordAuthWithSAMLRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+	event.Assertion = data.Assertion
+	event.CreateData = form.CreateData
+	event.Record = authRecord
+	event.IsNewRecord = authRecord == nil
+
+	return e.App.OnRecordAuthWithSAMLRequest().Trigger(event, func(e *core.RecordAuthWithSAMLRequestEvent) error {
+		if err := samlSubmit(e, externalAuthRel); err != nil {
+			return firstApiError(err, e.BadRequestError("Failed to authenticate.", err))
+		}
+
+		meta := map[string]any{
+			"isNew":        e.IsNewRecord,
+			"nameId":       e.Assertion.NameID,
+			"sessionIndex": e.Assertion.SessionIndex,
+			"attributes":   e.Assertion.Attributes,
+		}
+
+		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodSAML, meta)
+	})
+}
+
+// -------------------------------------------------------------------
+
+type authWithSAMLForm struct {
+	// Additional data that will be used for creating a new auth record
+	// if an existing SAML account doesn't exist.
+	CreateData map[string]any `form:"createData" json:"createData"`
+
+	// The one-time code returned with the ACS redirect.
+	Code string `form:"code" json:"code"`
+}
+
+func (form *authWithSAMLForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Code, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+func samlSubmit(e *core.RecordAuthWithSAMLRequestEvent, optExternalAuth *core.ExternalAuth) error {
+	return e.App.RunInTransaction(func(txApp core.App) error {
+		config := e.Collection.SAML
+		email := config.Email(e.Assertion)
+		externalId := config.ExternalId(e.Assertion)
+
+		if e.Record == nil {
+			// extra check to prevent creating a superuser record via
+			// SAML in case the method is used by another action
+			if e.Collection.Name == core.CollectionNameSuperusers {
+				return errors.New("superusers are not allowed to sign-up with SAML")
+			}
+
+			payload := maps.Clone(e.CreateData)
+			if payload == nil {
+				payload = map[string]any{}
+			}
+
+			// assign the assertion email only if the user hasn't submitted one
+			if v, _ := payload[core.FieldNameEmail].(string); v == "" {
+				payload[core.FieldNameEmail] = email
+			}
+
+			// map known fields (unless the field was explicitly submitted as part of CreateData)
+			if _, ok := payload[config.MappedFields.Id]; !ok && config.MappedFields.Id != "" {
+				payload[config.MappedFields.Id] = externalId
+			}
+			if _, ok := payload[config.MappedFields.Name]; !ok && config.MappedFields.Name != "" && config.Attributes.Name != "" {
+				payload[config.MappedFields.Name] = e.Assertion.Value(config.Attributes.Name)
+			}
+			if username := e.Assertion.Value(config.Attributes.Username); config.Attributes.Username != "" && username != "" {
+				if _, ok := payload[config.MappedFields.Username]; !ok &&
+					config.MappedFields.Username != "" &&
+					oldCanAssignUsername(txApp, e.Collection, config.MappedFields.Username, username) {
+					payload[config.MappedFields.Username] = username
+				}
+			}
+			if avatarURL := e.Assertion.Value(config.Attributes.AvatarURL); config.Attributes.AvatarURL != "" && avatarURL != "" {
+				if _, ok := payload[config.MappedFields.AvatarURL]; !ok && config.MappedFields.AvatarURL != "" {
+					avatar, ok := mappedAvatarValue(txApp, e.Collection, config.MappedFields.AvatarURL, avatarURL)
+					if ok {
+						payload[config.MappedFields.AvatarURL] = avatar
+					}
+				}
+			}
+
+			createdRecord, err := sendSAMLRecordCreateRequest(txApp, e, payload)
+			if err != nil {
+				return err
+			}
+
+			e.Record = createdRecord
+
+			if e.Record.Email() == email && !e.Record.Verified() {
+				// mark as verified as long as it matches the assertion email (even if the email is empty)
+				e.Record.SetVerified(true)
+				if err := txApp.Save(e.Record); err != nil {
+					return err
+				}
+			}
+		} else {
+			var needUpdate bool
+
+			isLoggedAuthRecord := e.Auth != nil &&
+				e.Auth.Id == e.Record.Id &&
+				e.Auth.Collection().Id == e.Record.Collection().Id
+
+			// set random password for users with unverified email
+			// (this is in case a malicious actor has registered previously with the user email)
+			if !isLoggedAuthRecord && e.Record.Email() != "" && !e.Record.Verified() {
+				e.Record.SetRandomPassword()
+				needUpdate = true
+			}
+
+			// update the existing auth record empty email if the assertion has one
+			if e.Record.Email() == "" && email != "" {
+				e.Record.SetEmail(email)
+				needUpdate = true
+			}
+
+			// update the existing auth record verified state
+			// (only if the auth record doesn't have an email or the auth record email match with the assertion one)
+			if !e.Record.Verified() && (e.Record.Email() == "" || e.Record.Email() == email) {
+				e.Record.SetVerified(true)
+				needUpdate = true
+			}
+
+			if needUpdate {
+				if err := txApp.Save(e.Record); err != nil {
+					return err
+				}
+			}
+		}
+
+		// create ExternalAuth relation if missing
+		if optExternalAuth == nil {
+			optExternalAuth = core.NewExternalAuth(txApp)
+			optExternalAuth.SetCollectionRef(e.Record.Collection().Id)
+			optExternalAuth.SetRecordRef(e.Record.Id)
+			optExternalAuth.SetProvider(core.ExternalAuthProviderSAML)
+			optExternalAuth.SetProviderId(externalId)
+
+			if err := txApp.Save(optExternalAuth); err != nil {
+				return fmt.Errorf("failed to save linked rel: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func sendSAMLRecordCreateRequest(txApp core.App, e *core.RecordAuthWithSAMLRequestEvent, payload map[string]any) (*core.Record, error) {
+	ir := &core.InternalRequest{
+		Method: http.MethodPost,
+		URL:    "/api/collections/" + e.Collection.Name + "/records",
+		Body:   payload,
+	}
+
+	var createdRecord *core.Record
+	response, err := processInternalRequest(txApp, e.RequestEvent, ir, core.RequestInfoContextSAML, func(data any) error {
+		createdRecord, _ = data.(*core.Record)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Status != http.StatusOK || createdRecord == nil {
+		return nil, errors.New("failed to create SAML auth record")
+	}
+
+	return createdRecord, nil
+}
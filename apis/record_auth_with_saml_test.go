@@ -0,0 +1,1053 @@
+package apis_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/saml"
+)
+
+const (
+	samlTestAppURL       = "https://example.com"
+	samlTestEntityId     = samlTestAppURL + "/api/collections/users/saml/metadata"
+	samlTestACSURL       = samlTestAppURL + "/api/collections/users/saml/acs"
+	samlTestRedirectURL  = "https://example.com/callback"
+	samlTestRedirectURL2 = "https://example.com/callback2"
+)
+
+// samlTestEnv holds the test IdP and service provider key pair.
+type samlTestEnv struct {
+	idp     *tests.TestSAMLIdP
+	certPEM string
+	keyPEM  string
+}
+
+func newSAMLTestEnv(t testing.TB) *samlTestEnv {
+	idp, err := tests.NewTestSAMLIdP("https://idp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, keyPEM, err := tests.NewTestSAMLKeyPair("sp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &samlTestEnv{idp: idp, certPEM: certPEM, keyPEM: keyPEM}
+}
+
+// enableSAML enables the SAML auth for the test "users" collection.
+func (env *samlTestEnv) enableSAML(t testing.TB, app *tests.TestApp) *core.Collection {
+	app.Settings().Meta.AppURL = samlTestAppURL
+
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.MFA.Enabled = false
+	collection.SAML = core.SAMLConfig{
+		Enabled:           true,
+		IdPMetadata:       env.idp.Metadata(),
+		Certificate:       env.certPEM,
+		PrivateKey:        env.keyPEM,
+		RedirectURLs:      []string{samlTestRedirectURL, samlTestRedirectURL2},
+		AllowIdPInitiated: true,
+		Attributes: core.SAMLKnownAttributes{
+			Email:    "mail",
+			Name:     "displayName",
+			Username: "uid",
+		},
+		MappedFields: core.OAuth2KnownFields{
+			Name:     "name",
+			Username: "username",
+		},
+		Duration: 300,
+	}
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	return collection
+}
+
+// login executes a SP-initiated login request and returns
+// the generated RelayState and AuthnRequest id.
+func (env *samlTestEnv) login(t testing.TB, e *core.ServeEvent) (string, string) {
+	req := httptest.NewRequest(http.MethodGet, "/api/collections/users/saml/login?redirectURL="+url.QueryEscape(samlTestRedirectURL), nil)
+
+	res := serveSAMLTestRequest(t, e, req)
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("Expected login redirect, got %d", res.StatusCode)
+	}
+
+	loc, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deflated, err := base64.StdEncoding.DecodeString(loc.Query().Get("SAMLRequest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authnRequest, err := saml.ParseXML(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return loc.Query().Get("RelayState"), authnRequest.Attr("ID")
+}
+
+// acs submits the SAMLResponse to the AssertionConsumerService
+// and returns the redirect location.
+func (env *samlTestEnv) acs(t testing.TB, e *core.ServeEvent, samlResponse string, relayState string) string {
+	form := url.Values{"SAMLResponse": {samlResponse}, "RelayState": {relayState}}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/collections/users/saml/acs", strings.NewReader(form.Encode()))
+	req.Header.Set("content-type", "application/x-www-form-urlencoded")
+
+	res := serveSAMLTestRequest(t, e, req)
+	if res.StatusCode != http.StatusSeeOther {
+		t.Fatalf("Expected ACS redirect, got %d", res.StatusCode)
+	}
+
+	return res.Header.Get("Location")
+}
+
+// response generates a new signed SAMLResponse for the test "users" collection.
+func (env *samlTestEnv) response(t testing.TB, opts tests.TestSAMLResponse) string {
+	if opts.ACSURL == "" {
+		opts.ACSURL = samlTestACSURL
+	}
+	if opts.Audience == "" {
+		opts.Audience = samlTestEntityId
+	}
+
+	response, err := env.idp.NewResponse(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return response
+}
+
+// loginCode executes the full SP-initiated flow and returns the auth-with-saml code.
+func (env *samlTestEnv) loginCode(t testing.TB, e *core.ServeEvent, opts tests.TestSAMLResponse) string {
+	relayState, requestId := env.login(t, e)
+
+	opts.InResponseTo = requestId
+	opts.SignAssertion = true
+
+	loc, err := url.Parse(env.acs(t, e, env.response(t, opts), relayState))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := loc.Query().Get("code")
+	if code == "" {
+		t.Fatalf("Missing SAML code in %q", loc)
+	}
+
+	return code
+}
+
+func serveSAMLTestRequest(t testing.TB, e *core.ServeEvent, req *http.Request) *http.Response {
+	mux, err := e.Router.BuildMux()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, req)
+
+	return recorder.Result()
+}
+
+// samlTestBody is a request body that is resolved during the scenario
+// BeforeTestFunc (the SAML login data is generated by the running app).
+type samlTestBody struct {
+	strings.Reader
+}
+
+func (b *samlTestBody) set(data string) {
+	b.Reader = *strings.NewReader(data)
+}
+
+func countSAMLChallenges(t testing.TB, app core.App, kind string) int {
+	var total int
+
+	err := app.RecordQuery(core.CollectionNameAuthChallenges).
+		Select("count(*)").
+		AndWhere(dbx.HashExp{"kind": kind}).
+		Row(&total)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return total
+}
+
+// samlChallengeEvents returns the expected events of a request
+// that creates the specified number of auth challenges.
+func samlChallengeEvents(total int) map[string]int {
+	return map[string]int{
+		"*":                          0,
+		"OnModelValidate":            total,
+		"OnModelCreate":              total,
+		"OnModelCreateExecute":       total,
+		"OnModelAfterCreateSuccess":  total,
+		"OnRecordValidate":           total,
+		"OnRecordCreate":             total,
+		"OnRecordCreateExecute":      total,
+		"OnRecordAfterCreateSuccess": total,
+	}
+}
+
+// -------------------------------------------------------------------
+
+func TestRecordSAMLMetadata(t *testing.T) {
+	t.Parallel()
+
+	env := newSAMLTestEnv(t)
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodGet,
+			URL:             "/api/collections/demo1/saml/metadata",
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled SAML",
+			Method:          http.MethodGet,
+			URL:             "/api/collections/users/saml/metadata",
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "auth collection with enabled SAML",
+			Method: http.MethodGet,
+			URL:    "/api/collections/users/saml/metadata",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				env.enableSAML(t, app)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`entityID="` + samlTestEntityId + `"`,
+				`Location="` + samlTestACSURL + `"`,
+				`AuthnRequestsSigned="true"`,
+				`<ds:X509Certificate>`,
+			},
+			NotExpectedContent: []string{
+				"PRIVATE KEY",
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestRecordSAMLLogin(t *testing.T) {
+	t.Parallel()
+
+	env := newSAMLTestEnv(t)
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodGet,
+			URL:             "/api/collections/demo1/saml/login?redirectURL=" + url.QueryEscape(samlTestRedirectURL),
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled SAML",
+			Method:          http.MethodGet,
+			URL:             "/api/collections/users/saml/login?redirectURL=" + url.QueryEscape(samlTestRedirectURL),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "missing redirectURL",
+			Method: http.MethodGet,
+			URL:    "/api/collections/users/saml/login",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				env.enableSAML(t, app)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "not allowed redirectURL",
+			Method: http.MethodGet,
+			URL:    "/api/collections/users/saml/login?redirectURL=" + url.QueryEscape("https://evil.com/callback"),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				env.enableSAML(t, app)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "valid redirectURL",
+			Method: http.MethodGet,
+			URL:    "/api/collections/users/saml/login?redirectURL=" + url.QueryEscape(samlTestRedirectURL2),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				env.enableSAML(t, app)
+			},
+			ExpectedStatus: 302,
+			ExpectedEvents: samlChallengeEvents(1), // state
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				loc, err := url.Parse(res.Header.Get("Location"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !strings.HasPrefix(loc.String(), env.idp.SSOURL+"?") {
+					t.Fatalf("Expected redirect to the IdP SSO url, got %q", loc)
+				}
+
+				for _, param := range []string{"SAMLRequest", "RelayState", "SigAlg", "Signature"} {
+					if loc.Query().Get(param) == "" {
+						t.Fatalf("Missing %q query parameter in %q", param, loc)
+					}
+				}
+
+				if total := countSAMLChallenges(t, app, core.AuthChallengeKindSAMLState); total != 1 {
+					t.Fatalf("Expected 1 stored login state, got %d", total)
+				}
+			},
+		},
+		{
+			Name:   "RateLimit rule - users:samlLogin",
+			Method: http.MethodGet,
+			URL:    "/api/collections/users/saml/login?redirectURL=" + url.QueryEscape(samlTestRedirectURL),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				env.enableSAML(t, app)
+
+				app.Settings().RateLimits.Enabled = true
+				app.Settings().RateLimits.Rules = []core.RateLimitRule{
+					{MaxRequests: 100, Label: "abc"},
+					{MaxRequests: 0, Label: "users:samlLogin"},
+				}
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestRecordSAMLACS(t *testing.T) {
+	t.Parallel()
+
+	env := newSAMLTestEnv(t)
+
+	formHeaders := map[string]string{
+		"content-type": "application/x-www-form-urlencoded",
+	}
+
+	// acsScenario resolves the ACS form body after enabling SAML
+	acsScenario := func(s tests.ApiScenario, form func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values) tests.ApiScenario {
+		body := &samlTestBody{}
+
+		s.Method = http.MethodPost
+		s.URL = "/api/collections/users/saml/acs"
+		s.Body = body
+		s.Headers = formHeaders
+		s.BeforeTestFunc = func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			env.enableSAML(t, app)
+			body.set(form(t, app, e).Encode())
+		}
+		if s.ExpectedEvents == nil {
+			s.ExpectedEvents = map[string]int{"*": 0}
+		}
+
+		return s
+	}
+
+	checkRedirect := func(expectedURL string, expectedParam string) func(t testing.TB, app *tests.TestApp, res *http.Response) {
+		return func(t testing.TB, app *tests.TestApp, res *http.Response) {
+			loc, err := url.Parse(res.Header.Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if base := strings.Split(loc.String(), "?")[0]; base != expectedURL {
+				t.Fatalf("Expected redirect to %q, got %q", expectedURL, loc)
+			}
+
+			if loc.Query().Get(expectedParam) == "" {
+				t.Fatalf("Expected %q query parameter, got %q", expectedParam, loc)
+			}
+
+			expectedCodes := 0
+			if expectedParam == "code" {
+				expectedCodes = 1
+			}
+			if total := countSAMLChallenges(t, app, core.AuthChallengeKindSAMLCode); total != expectedCodes {
+				t.Fatalf("Expected %d stored codes, got %d", expectedCodes, total)
+			}
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/demo1/saml/acs",
+			Body:            strings.NewReader("SAMLResponse=abc"),
+			Headers:         formHeaders,
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled SAML",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/saml/acs",
+			Body:            strings.NewReader("SAMLResponse=abc"),
+			Headers:         formHeaders,
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		acsScenario(tests.ApiScenario{
+			Name:            "missing SAMLResponse",
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			return url.Values{"RelayState": {"abc"}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:            "unknown RelayState with disabled IdP-initiated login",
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			collection, err := app.FindCollectionByNameOrId("users")
+			if err != nil {
+				t.Fatal(err)
+			}
+			collection.SAML.AllowIdPInitiated = false
+			if err := app.Save(collection); err != nil {
+				t.Fatal(err)
+			}
+
+			response := env.response(t, tests.TestSAMLResponse{NameID: "john", SignAssertion: true})
+
+			return url.Values{"SAMLResponse": {response}, "RelayState": {"abc"}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:           "SP-initiated with valid response",
+			ExpectedStatus: 303,
+			ExpectedEvents: samlChallengeEvents(2), // assertion + code
+			AfterTestFunc:  checkRedirect(samlTestRedirectURL, "code"),
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			relayState, requestId := env.login(t, e)
+
+			response := env.response(t, tests.TestSAMLResponse{
+				NameID:        "john",
+				InResponseTo:  requestId,
+				SignAssertion: true,
+			})
+
+			return url.Values{"SAMLResponse": {response}, "RelayState": {relayState}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:           "SP-initiated with InResponseTo mismatch",
+			ExpectedStatus: 303,
+			AfterTestFunc:  checkRedirect(samlTestRedirectURL, "error"),
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			relayState, _ := env.login(t, e)
+
+			response := env.response(t, tests.TestSAMLResponse{
+				NameID:        "john",
+				InResponseTo:  "_other",
+				SignAssertion: true,
+			})
+
+			return url.Values{"SAMLResponse": {response}, "RelayState": {relayState}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:           "SP-initiated with unsigned response",
+			ExpectedStatus: 303,
+			AfterTestFunc:  checkRedirect(samlTestRedirectURL, "error"),
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			relayState, requestId := env.login(t, e)
+
+			response := env.response(t, tests.TestSAMLResponse{
+				NameID:       "john",
+				InResponseTo: requestId,
+			})
+
+			return url.Values{"SAMLResponse": {response}, "RelayState": {relayState}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:           "SP-initiated with wrong audience",
+			ExpectedStatus: 303,
+			AfterTestFunc:  checkRedirect(samlTestRedirectURL, "error"),
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			relayState, requestId := env.login(t, e)
+
+			response := env.response(t, tests.TestSAMLResponse{
+				NameID:        "john",
+				Audience:      "https://other.example.com",
+				InResponseTo:  requestId,
+				SignResponse:  true,
+				SignAssertion: true,
+			})
+
+			return url.Values{"SAMLResponse": {response}, "RelayState": {relayState}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:           "IdP-initiated with default redirect url",
+			ExpectedStatus: 303,
+			ExpectedEvents: samlChallengeEvents(2), // assertion + code
+			AfterTestFunc:  checkRedirect(samlTestRedirectURL, "code"),
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			response := env.response(t, tests.TestSAMLResponse{NameID: "john", SignResponse: true})
+
+			return url.Values{"SAMLResponse": {response}, "RelayState": {"https://evil.com/callback"}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:           "IdP-initiated with allowed RelayState redirect url",
+			ExpectedStatus: 303,
+			ExpectedEvents: samlChallengeEvents(2), // assertion + code
+			AfterTestFunc:  checkRedirect(samlTestRedirectURL2, "code"),
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			response := env.response(t, tests.TestSAMLResponse{NameID: "john", SignAssertion: true})
+
+			return url.Values{"SAMLResponse": {response}, "RelayState": {samlTestRedirectURL2}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:           "IdP-initiated with response to a SP-initiated request",
+			ExpectedStatus: 303,
+			AfterTestFunc:  checkRedirect(samlTestRedirectURL, "error"),
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			response := env.response(t, tests.TestSAMLResponse{
+				NameID:        "john",
+				InResponseTo:  "_123",
+				SignAssertion: true,
+			})
+
+			return url.Values{"SAMLResponse": {response}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:           "reused RelayState of an already completed SP-initiated login",
+			ExpectedStatus: 303,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				loc := res.Header.Get("Location")
+				if !strings.Contains(loc, "error=invalid_response") {
+					t.Fatalf("Expected error redirect, got %q", loc)
+				}
+
+				if total := countSAMLChallenges(t, app, core.AuthChallengeKindSAMLState); total != 0 {
+					t.Fatalf("Expected no stored login states, got %d", total)
+				}
+			},
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			relayState, requestId := env.login(t, e)
+
+			first := env.response(t, tests.TestSAMLResponse{
+				NameID:        "john",
+				InResponseTo:  requestId,
+				SignAssertion: true,
+			})
+			if loc := env.acs(t, e, first, relayState); !strings.Contains(loc, "code=") {
+				t.Fatalf("Expected the first submit to succeed, got %q", loc)
+			}
+
+			// new assertion for the same request
+			second := env.response(t, tests.TestSAMLResponse{
+				NameID:        "john",
+				InResponseTo:  requestId,
+				SignAssertion: true,
+			})
+
+			return url.Values{"SAMLResponse": {second}, "RelayState": {relayState}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:           "replayed assertion",
+			ExpectedStatus: 303,
+			ExpectedEvents: map[string]int{
+				"*": 0,
+				// failed assertion insert
+				"OnModelValidate":          1,
+				"OnModelCreate":            1,
+				"OnModelCreateExecute":     1,
+				"OnModelAfterCreateError":  1,
+				"OnRecordValidate":         1,
+				"OnRecordCreate":           1,
+				"OnRecordCreateExecute":    1,
+				"OnRecordAfterCreateError": 1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				loc := res.Header.Get("Location")
+				if !strings.Contains(loc, "error=invalid_response") {
+					t.Fatalf("Expected error redirect, got %q", loc)
+				}
+
+				// only the first submit code
+				if total := countSAMLChallenges(t, app, core.AuthChallengeKindSAMLCode); total != 1 {
+					t.Fatalf("Expected 1 stored code, got %d", total)
+				}
+			},
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			response := env.response(t, tests.TestSAMLResponse{NameID: "john", SignAssertion: true})
+
+			if loc := env.acs(t, e, response, ""); !strings.Contains(loc, "code=") {
+				t.Fatalf("Expected the first submit to succeed, got %q", loc)
+			}
+
+			return url.Values{"SAMLResponse": {response}}
+		}),
+		acsScenario(tests.ApiScenario{
+			Name:            "RateLimit rule - users:samlACS",
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+		}, func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) url.Values {
+			app.Settings().RateLimits.Enabled = true
+			app.Settings().RateLimits.Rules = []core.RateLimitRule{
+				{MaxRequests: 100, Label: "abc"},
+				{MaxRequests: 0, Label: "users:samlACS"},
+			}
+
+			return url.Values{"SAMLResponse": {"abc"}}
+		}),
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestRecordAuthWithSAML(t *testing.T) {
+	t.Parallel()
+
+	env := newSAMLTestEnv(t)
+
+	// authScenario executes the SAML login flow before the test
+	// and submits the generated code together with the createData
+	authScenario := func(
+		s tests.ApiScenario,
+		opts tests.TestSAMLResponse,
+		createData string,
+		setup func(t testing.TB, app *tests.TestApp, collection *core.Collection),
+	) tests.ApiScenario {
+		body := &samlTestBody{}
+
+		s.Method = http.MethodPost
+		s.URL = "/api/collections/users/auth-with-saml"
+		s.Body = body
+		s.BeforeTestFunc = func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			collection := env.enableSAML(t, app)
+			if setup != nil {
+				setup(t, app, collection)
+			}
+
+			code := env.loginCode(t, e, opts)
+
+			if createData == "" {
+				createData = "null"
+			}
+			body.set(`{"code":"` + code + `","createData":` + createData + `}`)
+		}
+
+		return s
+	}
+
+	johnAttributes := map[string][]string{
+		"mail":        {"john@example.com"},
+		"displayName": {"John Doe"},
+		"uid":         {"john"},
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/demo1/auth-with-saml",
+			Body:            strings.NewReader(`{"code":"abc"}`),
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled SAML",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-saml",
+			Body:            strings.NewReader(`{"code":"abc"}`),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "empty body",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-saml",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				env.enableSAML(t, app)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"code":{"code":"validation_required"`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid code",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-saml",
+			Body:   strings.NewReader(`{"code":"abc"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				env.enableSAML(t, app)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		func() tests.ApiScenario {
+			body := &samlTestBody{}
+
+			return tests.ApiScenario{
+				Name:   "already used code",
+				Method: http.MethodPost,
+				URL:    "/api/collections/users/auth-with-saml",
+				Body:   body,
+				BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+					env.enableSAML(t, app)
+
+					code := env.loginCode(t, e, tests.TestSAMLResponse{NameID: "john", Attributes: johnAttributes})
+					body.set(`{"code":"` + code + `"}`)
+
+					req := httptest.NewRequest(http.MethodPost, "/api/collections/users/auth-with-saml", strings.NewReader(`{"code":"`+code+`"}`))
+					req.Header.Set("content-type", "application/json")
+					if res := serveSAMLTestRequest(t, e, req); res.StatusCode != http.StatusOK {
+						t.Fatalf("Expected the first code exchange to succeed, got %d", res.StatusCode)
+					}
+				},
+				ExpectedStatus:  400,
+				ExpectedContent: []string{`"data":{}`},
+				ExpectedEvents:  map[string]int{"*": 0},
+			}
+		}(),
+		authScenario(tests.ApiScenario{
+			Name:           "OnRecordAuthWithSAMLRequest tx body write check",
+			ExpectedStatus: 400,
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithSAMLRequest": 1,
+			},
+			ExpectedContent: []string{"TX_ERROR"},
+		}, tests.TestSAMLResponse{NameID: "john", Attributes: johnAttributes}, "", func(t testing.TB, app *tests.TestApp, collection *core.Collection) {
+			app.OnRecordAuthWithSAMLRequest().BindFunc(func(e *core.RecordAuthWithSAMLRequestEvent) error {
+				original := e.App
+				return e.App.RunInTransaction(func(txApp core.App) error {
+					e.App = txApp
+					defer func() { e.App = original }()
+
+					if err := e.Next(); err != nil {
+						return err
+					}
+
+					return e.BadRequestError("TX_ERROR", nil)
+				})
+			})
+		}),
+		authScenario(tests.ApiScenario{
+			Name:           "creating a new user",
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"isNew":true`,
+				`"nameId":"john_id"`,
+				`"sessionIndex":"_session123"`,
+				`"displayName":["John Doe"]`,
+				`"email":"john@example.com"`,
+				`"name":"John Doe"`,
+				`"username":"john"`,
+				`"emailVisibility":true`,
+				`"verified":true`,
+			},
+			NotExpectedContent: []string{
+				// hidden fields
+				`"tokenKey"`,
+				`"password"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                           0,
+				"OnRecordAuthWithSAMLRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordCreateRequest":       1,
+				"OnRecordEnrich":              2, // the auth response and from the create request
+				// ---
+				"OnModelCreate":              3, // record + authOrigins + externalAuths
+				"OnModelCreateExecute":       3,
+				"OnModelAfterCreateSuccess":  3,
+				"OnRecordCreate":             3,
+				"OnRecordCreateExecute":      3,
+				"OnRecordAfterCreateSuccess": 3,
+				// ---
+				"OnModelUpdate":              1, // created record verified state change
+				"OnModelUpdateExecute":       1,
+				"OnModelAfterUpdateSuccess":  1,
+				"OnRecordUpdate":             1,
+				"OnRecordUpdateExecute":      1,
+				"OnRecordAfterUpdateSuccess": 1,
+				// ---
+				"OnModelValidate":  4,
+				"OnRecordValidate": 4,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindAuthRecordByEmail("users", "john@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				externalAuth, err := app.FindFirstExternalAuthByExpr(dbx.HashExp{
+					"collectionRef": user.Collection().Id,
+					"recordRef":     user.Id,
+					"provider":      core.ExternalAuthProviderSAML,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if externalAuth.ProviderId() != "john_id" {
+					t.Fatalf("Expected providerId %q, got %q", "john_id", externalAuth.ProviderId())
+				}
+			},
+		}, tests.TestSAMLResponse{
+			NameID:       "john_id",
+			SessionIndex: "_session123",
+			Attributes:   johnAttributes,
+		}, `{"emailVisibility":true}`, nil),
+		authScenario(tests.ApiScenario{
+			Name:           "creating a new user with createData overwrites and email NameID",
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"isNew":true`,
+				`"email":"new@example.com"`,
+				`"name":"custom"`,
+				`"verified":true`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithSAMLRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordCreateRequest":       1,
+			},
+		}, tests.TestSAMLResponse{
+			NameID:       "new@example.com",
+			NameIDFormat: saml.NameIDFormatEmail,
+			Attributes:   map[string][]string{"displayName": {"New"}},
+		}, `{"name":"custom"}`, nil),
+		authScenario(tests.ApiScenario{
+			Name:           "linking an existing user by email",
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"isNew":false`,
+				`"id":"4q1xlclmfloku33"`,
+				`"email":"test@example.com"`,
+				`"verified":true`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                           0,
+				"OnRecordAuthWithSAMLRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordEnrich":              1,
+				// ---
+				"OnModelCreate":              2, // authOrigins + externalAuths
+				"OnModelCreateExecute":       2,
+				"OnModelAfterCreateSuccess":  2,
+				"OnRecordCreate":             2,
+				"OnRecordCreateExecute":      2,
+				"OnRecordAfterCreateSuccess": 2,
+				// ---
+				"OnModelUpdate":              1, // random password and verified state change
+				"OnModelUpdateExecute":       1,
+				"OnModelAfterUpdateSuccess":  1,
+				"OnRecordUpdate":             1,
+				"OnRecordUpdateExecute":      1,
+				"OnRecordAfterUpdateSuccess": 1,
+				// ---
+				"OnModelValidate":  3,
+				"OnRecordValidate": 3,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindRecordById("users", "4q1xlclmfloku33")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// the user was unverified so a random password should have been set
+				// (in case a malicious actor has registered previously with the same email)
+				if user.ValidatePassword("1234567890") {
+					t.Fatal("Expected the unverified user password to be changed")
+				}
+
+				_, err = app.FindFirstExternalAuthByExpr(dbx.HashExp{
+					"recordRef":  user.Id,
+					"provider":   core.ExternalAuthProviderSAML,
+					"providerId": "test_id",
+				})
+				if err != nil {
+					t.Fatalf("Expected the SAML external auth to be created: %v", err)
+				}
+			},
+		}, tests.TestSAMLResponse{
+			NameID:     "test_id",
+			Attributes: map[string][]string{"mail": {"test@example.com"}},
+		}, "", nil),
+		authScenario(tests.ApiScenario{
+			Name:           "existing linked user with custom id attribute",
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"isNew":false`,
+				`"id":"oap640cot4yru2s"`,
+				`"email":"test2@example.com"`, // existing emails are not overwritten
+			},
+			ExpectedEvents: map[string]int{
+				"*":                           0,
+				"OnRecordAuthWithSAMLRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordEnrich":              1,
+				// ---
+				"OnModelCreate":              1, // authOrigins
+				"OnModelCreateExecute":       1,
+				"OnModelAfterCreateSuccess":  1,
+				"OnRecordCreate":             1,
+				"OnRecordCreateExecute":      1,
+				"OnRecordAfterCreateSuccess": 1,
+				// ---
+				"OnModelValidate":  1,
+				"OnRecordValidate": 1,
+			},
+		}, tests.TestSAMLResponse{
+			NameID:     "transient_123",
+			Attributes: johnAttributes,
+		}, "", func(t testing.TB, app *tests.TestApp, collection *core.Collection) {
+			collection.SAML.IdAttribute = "uid"
+			if err := app.Save(collection); err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := app.FindRecordById("users", "oap640cot4yru2s") // test2@example.com
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			externalAuth := core.NewExternalAuth(app)
+			externalAuth.SetCollectionRef(collection.Id)
+			externalAuth.SetRecordRef(user.Id)
+			externalAuth.SetProvider(core.ExternalAuthProviderSAML)
+			externalAuth.SetProviderId("john")
+			if err := app.Save(externalAuth); err != nil {
+				t.Fatal(err)
+			}
+		}),
+		authScenario(tests.ApiScenario{
+			Name:            "missing custom id attribute",
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		}, tests.TestSAMLResponse{
+			NameID: "john",
+		}, "", func(t testing.TB, app *tests.TestApp, collection *core.Collection) {
+			collection.SAML.IdAttribute = "uid"
+			if err := app.Save(collection); err != nil {
+				t.Fatal(err)
+			}
+		}),
+		authScenario(tests.ApiScenario{
+			Name:            "MFA enabled",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"mfaId":"`},
+			NotExpectedContent: []string{
+				`"token":`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithSAMLRequest": 1,
+				"OnRecordAuthRequest":         1,
+			},
+		}, tests.TestSAMLResponse{
+			NameID:     "test_id",
+			Attributes: map[string][]string{"mail": {"test@example.com"}},
+		}, "", func(t testing.TB, app *tests.TestApp, collection *core.Collection) {
+			collection.MFA.Enabled = true
+			if err := app.Save(collection); err != nil {
+				t.Fatal(err)
+			}
+		}),
+
+		// rate limit checks
+		// -----------------------------------------------------------
+		{
+			Name:   "RateLimit rule - users:authWithSAML",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-saml",
+			Body:   strings.NewReader(`{"code":"abc"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				env.enableSAML(t, app)
+
+				app.Settings().RateLimits.Enabled = true
+				app.Settings().RateLimits.Rules = []core.RateLimitRule{
+					{MaxRequests: 100, Label: "abc"},
+					{MaxRequests: 100, Label: "*:authWithSAML"},
+					{MaxRequests: 0, Label: "users:authWithSAML"},
+				}
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "RateLimit rule - *:authWithSAML",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-saml",
+			Body:   strings.NewReader(`{"code":"abc"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				env.enableSAML(t, app)
+
+				app.Settings().RateLimits.Enabled = true
+				app.Settings().RateLimits.Rules = []core.RateLimitRule{
+					{MaxRequests: 100, Label: "abc"},
+					{MaxRequests: 0, Label: "*:authWithSAML"},
+				}
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
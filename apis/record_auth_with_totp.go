@@ -0,0 +1,116 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func recordAuthWithTOTP(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.TOTP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow TOTP authentication.", nil)
+	}
+
+	form := &authWithTOTPForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextTOTP)
+
+	event := new(core.RecordAuthWithTOTPRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+
+	// TOTP is allowed only as second factor of an already started MFA session
+	// (note: the mfaId is further checked and consumed on RecordAuthResponse)
+	// ---
+	mfa, err := e.App.FindMFAById(form.MFAId)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired MFA session.", err)
+	}
+
+	if mfa.CollectionRef() != collection.Id {
+		return e.BadRequestError("Invalid or expired MFA session.", errors.New("the MFA is for a different collection"))
+	}
+
+	if mfa.HasExpired(collection.MFA.DurationTime()) {
+		return e.BadRequestError("Invalid or expired MFA session.", errors.New("the MFA is expired"))
+	}
+
+	event.Record, err = e.App.FindRecordById(mfa.CollectionRef(), mfa.RecordRef())
+	if err != nil {
+		return e.BadRequestError("Invalid or expired MFA session.", fmt.Errorf("missing auth record: %w", err))
+	}
+
+	if err := checkAuthLockout(e, collection, event.Record.Id); err != nil {
+		return err
+	}
+
+	// since the codes are short digit numbers, enforce an extra rate limit rule as basic brute-force protection
+	err = checkRateLimit(e, "@pb_totp_"+event.Record.Id, core.RateLimitRule{MaxRequests: 5, Duration: 180})
+	if err != nil {
+		return e.TooManyRequestsError("Too many attempts, please try again later.", nil)
+	}
+
+	event.TOTP, err = e.App.FindTOTPByRecord(event.Record)
+	if err != nil {
+		return e.BadRequestError("Invalid TOTP or recovery code.", fmt.Errorf("missing TOTP enrollment: %w", err))
+	}
+
+	codeForm := &totpCodeForm{Code: form.Code, RecoveryCode: form.RecoveryCode}
+	if !codeForm.verify(collection, event.TOTP) {
+		registerAuthFailure(e, collection, event.Record.Id, event.Record)
+		return e.BadRequestError("Invalid TOTP or recovery code.", nil)
+	}
+
+	clearAuthFailures(e, collection, event.Record.Id)
+
+	event.TOTP.SetLastUsed(types.NowDateTime())
+	// ---
+
+	return e.App.OnRecordAuthWithTOTPRequest().Trigger(event, func(e *core.RecordAuthWithTOTPRequestEvent) error {
+		// persist the used code step (or the remaining recovery codes)
+		// before the auth response to prevent replays
+		err := core.SaveTOTPUse(e.App, e.TOTP)
+		if errors.Is(err, core.ErrTOTPCodeUsed) {
+			return e.BadRequestError("Invalid TOTP or recovery code.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to update TOTP state.", err)
+		}
+
+		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodTOTP, nil)
+	})
+}
+
+// -------------------------------------------------------------------
+
+type authWithTOTPForm struct {
+	MFAId        string `form:"mfaId" json:"mfaId"`
+	Code         string `form:"code" json:"code"`
+	RecoveryCode string `form:"recoveryCode" json:"recoveryCode"`
+}
+
+func (form *authWithTOTPForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.MFAId, validation.Required, validation.Length(1, 255)),
+		validation.Field(
+			&form.Code,
+			validation.When(form.RecoveryCode == "", validation.Required),
+			validation.Length(1, 20),
+		),
+		validation.Field(&form.RecoveryCode, validation.Length(1, 50)),
+	)
+}
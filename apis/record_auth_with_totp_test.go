@@ -0,0 +1,390 @@
+package apis_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/totp"
+)
+
+const (
+	totpTestKey    = "abcdefghijklmnopqrstuvwxyz123456"
+	totpTestSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+	totpTestMFAId  = "totpmfa00000001"
+)
+
+var totpTestRecoveryCodes = []string{"aaaaa-bbbbb", "ccccc-ddddd"}
+
+// totpTestCode returns the current TOTP code of [totpTestSecret].
+func totpTestCode(t testing.TB) string {
+	code, err := totp.Code(totpTestSecret, time.Now(), totp.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return code
+}
+
+// enableTOTP enables the TOTP second factor for the test "users" collection
+// and optionally enrolls the specified user with [totpTestSecret].
+func enableTOTP(t testing.TB, app *tests.TestApp, enrollEmail string) *core.TOTP {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.TOTP.Enabled = true
+	collection.TOTP.Secret = totpTestKey
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if enrollEmail == "" {
+		return nil
+	}
+
+	user, err := app.FindAuthRecordByEmail(collection, enrollEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enrollment := core.NewTOTP(app)
+	enrollment.Id = "totp00000000001"
+	enrollment.SetCollectionRef(collection.Id)
+	enrollment.SetRecordRef(user.Id)
+	enrollment.SetRecoveryCodes(totpTestRecoveryCodes)
+	if err := enrollment.SetPlainSecret(collection, totpTestSecret); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Save(enrollment); err != nil {
+		t.Fatal(err)
+	}
+
+	return enrollment
+}
+
+// createTOTPTestMFA creates a new password MFA session for the specified user.
+func createTOTPTestMFA(t testing.TB, app *tests.TestApp, email string) {
+	user, err := app.FindAuthRecordByEmail("users", email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mfa := core.NewMFA(app)
+	mfa.Id = totpTestMFAId
+	mfa.SetCollectionRef(user.Collection().Id)
+	mfa.SetRecordRef(user.Id)
+	mfa.SetMethod(core.MFAMethodPassword)
+	if err := app.Save(mfa); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordAuthWithTOTP(t *testing.T) {
+	t.Parallel()
+
+	code := totpTestCode(t)
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/demo1/auth-with-totp",
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled TOTP",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-totp",
+			Body:            strings.NewReader(`{"mfaId":"` + totpTestMFAId + `","code":"` + code + `"}`),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "empty body",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableTOTP(t, app, "test@example.com")
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"mfaId":{"code":"validation_required"`,
+				`"code":{"code":"validation_required"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "missing MFA session",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + totpTestMFAId + `","code":"` + code + `"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableTOTP(t, app, "test@example.com")
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "expired MFA session",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + totpTestMFAId + `","code":"` + code + `"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableTOTP(t, app, "test@example.com")
+				createTOTPTestMFA(t, app, "test@example.com")
+
+				_, err := app.DB().Update(
+					core.CollectionNameMFAs,
+					map[string]any{"created": "2022-01-01 00:00:00.000Z"},
+					nil,
+				).Execute()
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "record without TOTP enrollment",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + totpTestMFAId + `","code":"` + code + `"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableTOTP(t, app, "test2@example.com")
+				createTOTPTestMFA(t, app, "test@example.com")
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid code",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + totpTestMFAId + `","code":"12345x"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableTOTP(t, app, "test@example.com")
+				createTOTPTestMFA(t, app, "test@example.com")
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "already used code",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + totpTestMFAId + `","code":"` + code + `"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enrollment := enableTOTP(t, app, "test@example.com")
+				enrollment.SetLastStep(totp.Step(time.Now(), totp.Options{}) + 1)
+				if err := app.Save(enrollment); err != nil {
+					t.Fatal(err)
+				}
+				createTOTPTestMFA(t, app, "test@example.com")
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "OnRecordAuthWithTOTPRequest tx body write check",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + totpTestMFAId + `","code":"` + code + `"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableTOTP(t, app, "test@example.com")
+				createTOTPTestMFA(t, app, "test@example.com")
+
+				app.OnRecordAuthWithTOTPRequest().BindFunc(func(e *core.RecordAuthWithTOTPRequestEvent) error {
+					original := e.App
+					return e.App.RunInTransaction(func(txApp core.App) error {
+						e.App = txApp
+						defer func() { e.App = original }()
+
+						if err := e.Next(); err != nil {
+							return err
+						}
+
+						return e.BadRequestError("TX_ERROR", nil)
+					})
+				})
+			},
+			ExpectedStatus:  400,
+			ExpectedEvents:  map[string]int{"OnRecordAuthWithTOTPRequest": 1},
+			ExpectedContent: []string{"TX_ERROR"},
+		},
+		{
+			Name:   "valid code",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + totpTestMFAId + `","code":"` + code + `"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableTOTP(t, app, "test@example.com")
+				createTOTPTestMFA(t, app, "test@example.com")
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				enrollment, err := app.FindTOTPByRecord(user)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if enrollment.LastStep() == 0 {
+					t.Fatal("Expected lastStep to be updated")
+				}
+
+				if enrollment.LastUsed().IsZero() {
+					t.Fatal("Expected lastUsed to be updated")
+				}
+
+				if _, err := app.FindMFAById(totpTestMFAId); err == nil {
+					t.Fatal("Expected the MFA session to be deleted")
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"record":{`,
+				`"email":"test@example.com"`,
+			},
+			NotExpectedContent: []string{
+				`"meta":`,
+				`"mfaId":`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                           0,
+				"OnRecordAuthWithTOTPRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordEnrich":              1,
+				// ---
+				"OnModelValidate":           1,
+				"OnModelCreate":             1, // authOrigin
+				"OnModelCreateExecute":      1,
+				"OnModelAfterCreateSuccess": 1,
+				"OnModelDelete":             1, // mfa
+				"OnModelDeleteExecute":      1,
+				"OnModelAfterDeleteSuccess": 1,
+				// ---
+				"OnRecordValidate":           1,
+				"OnRecordCreate":             1,
+				"OnRecordCreateExecute":      1,
+				"OnRecordAfterCreateSuccess": 1,
+				"OnRecordDelete":             1,
+				"OnRecordDeleteExecute":      1,
+				"OnRecordAfterDeleteSuccess": 1,
+			},
+		},
+		{
+			Name:   "valid recovery code",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + totpTestMFAId + `","recoveryCode":"AAAAA-BBBBB"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableTOTP(t, app, "test@example.com")
+				createTOTPTestMFA(t, app, "test@example.com")
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				enrollment, err := app.FindTOTPByRecord(user)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if v := enrollment.RecoveryCodes(); len(v) != 1 {
+					t.Fatalf("Expected 1 remaining recovery code, got %v", v)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"record":{`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithTOTPRequest": 1,
+				"OnRecordAuthRequest":         1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestRecordAuthWithTOTPMFARule(t *testing.T) {
+	t.Parallel()
+
+	setRule := func(t testing.TB, app *tests.TestApp) {
+		enableTOTP(t, app, "test@example.com")
+
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		users.MFA.Rule = "@collection._totps.recordRef ?= id"
+		if err := app.Save(users); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "record with TOTP enrollment",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-password",
+			Body:   strings.NewReader(`{"identity":"test@example.com","password":"1234567890"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				setRule(t, app)
+			},
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"mfaId":"`},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithPasswordRequest": 1,
+				"OnRecordAuthRequest":             1,
+			},
+		},
+		{
+			Name:   "record without TOTP enrollment",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-password",
+			Body:   strings.NewReader(`{"identity":"test2@example.com","password":"1234567890"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				setRule(t, app)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"email":"test2@example.com"`,
+			},
+			NotExpectedContent: []string{`"mfaId":"`},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithPasswordRequest": 1,
+				"OnRecordAuthRequest":             1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,173 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/pocketbase/pocketbase/tools/webauthn"
+)
+
+// recordWebAuthnAuthOptions returns the authentication ceremony options.
+//
+// The options are always with an empty allowCredentials list (aka. discoverable
+// credentials only) so that the endpoint can't be used to check whether an
+// email exists or to load the credential ids of an account.
+func recordWebAuthnAuthOptions(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.WebAuthn.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow WebAuthn authentication.", nil)
+	}
+
+	rp := core.WebAuthnRelyingParty(e.App, collection)
+	if rp.Id == "" || len(rp.Origins) == 0 {
+		return e.InternalServerError("Missing WebAuthn relying party configuration.", nil)
+	}
+
+	challenge := webauthn.NewChallenge()
+
+	token, err := core.NewWebAuthnToken(e.App, collection, webauthn.URLEncodedBase64(challenge).String(), "")
+	if err != nil {
+		return e.InternalServerError("Failed to create WebAuthn token.", err)
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"token":   token,
+		"options": rp.RequestOptions(challenge, nil, collection.WebAuthn.DurationTime().Milliseconds()),
+	})
+}
+
+func recordAuthWithWebAuthn(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.WebAuthn.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow WebAuthn authentication.", nil)
+	}
+
+	form := &authWithWebAuthnForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	e.Set(core.RequestEventKeyInfoContext, core.RequestInfoContextWebAuthn)
+
+	event := new(core.RecordAuthWithWebAuthnRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+
+	// extra validations
+	// (note: returns a generic 400 as a very basic credentials enumeration protection)
+	// ---
+	challenge, recordId, err := core.ParseWebAuthnToken(collection, form.Token)
+	if err != nil {
+		return e.BadRequestError("Failed to authenticate.", fmt.Errorf("invalid WebAuthn token: %w", err))
+	}
+	if recordId != "" {
+		return e.BadRequestError("Failed to authenticate.", errors.New("registration WebAuthn token"))
+	}
+
+	// the challenge is single-use (even if the assertion verification below fails)
+	if !core.ConsumeWebAuthnChallenge(e.App, collection, challenge) {
+		return e.BadRequestError("Failed to authenticate.", errors.New("already used or expired WebAuthn challenge"))
+	}
+
+	rawChallenge, err := webauthn.DecodeBase64(challenge)
+	if err != nil {
+		return e.BadRequestError("Failed to authenticate.", err)
+	}
+
+	credentialId := webauthn.URLEncodedBase64(form.Credential.CredentialId()).String()
+
+	event.Credential, err = e.App.FindWebAuthnCredentialByCredentialId(collection, credentialId)
+	if err != nil {
+		// there is no known identity so only the IP failures are tracked
+		registerAuthFailure(e, collection, "", nil)
+		return e.BadRequestError("Failed to authenticate.", fmt.Errorf("missing WebAuthn credential: %w", err))
+	}
+
+	event.Record, err = e.App.FindRecordById(collection, event.Credential.RecordRef())
+	if err != nil {
+		return e.BadRequestError("Failed to authenticate.", fmt.Errorf("missing auth record: %w", err))
+	}
+
+	if err := checkAuthLockout(e, collection, event.Record.Id); err != nil {
+		return err
+	}
+
+	// the user handle is optional for the non-discoverable credentials
+	if userHandle := form.Credential.Response.UserHandle; len(userHandle) > 0 && string(userHandle) != event.Record.Id {
+		registerAuthFailure(e, collection, event.Record.Id, event.Record)
+		return e.BadRequestError("Failed to authenticate.", errors.New("user handle mismatch"))
+	}
+
+	publicKey, err := webauthn.DecodeBase64(event.Credential.PublicKey())
+	if err != nil {
+		return e.BadRequestError("Failed to authenticate.", fmt.Errorf("invalid stored public key: %w", err))
+	}
+
+	rp := core.WebAuthnRelyingParty(e.App, collection)
+
+	authData, err := rp.VerifyAssertion(rawChallenge, publicKey, event.Credential.SignCount(), form.Credential)
+	if err != nil {
+		registerAuthFailure(e, collection, event.Record.Id, event.Record)
+		return e.BadRequestError("Failed to authenticate.", err)
+	}
+
+	clearAuthFailures(e, collection, event.Record.Id)
+	// ---
+
+	return e.App.OnRecordAuthWithWebAuthnRequest().Trigger(event, func(e *core.RecordAuthWithWebAuthnRequestEvent) error {
+		// update the credential usage state
+		//
+		// note: don't wait for success auth response (it could fail because of MFA)
+		// and because we already validated the assertion above
+		e.Credential.SetSignCount(authData.SignCount)
+		e.Credential.SetLastUsed(types.NowDateTime())
+		if err := e.App.Save(e.Credential); err != nil {
+			e.App.Logger().Error("Failed to update the WebAuthn credential usage state",
+				"error", err,
+				"credentialId", e.Credential.Id,
+				"recordId", e.Record.Id,
+			)
+		}
+
+		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodWebAuthn, nil)
+	})
+}
+
+// -------------------------------------------------------------------
+
+type authWithWebAuthnForm struct {
+	Credential *webauthn.AssertionResponse `form:"credential" json:"credential"`
+	Token      string                      `form:"token" json:"token"`
+}
+
+func (form *authWithWebAuthnForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Token, validation.Required),
+		validation.Field(&form.Credential, validation.Required, validation.By(func(value any) error {
+			credential, _ := value.(*webauthn.AssertionResponse)
+			if credential == nil ||
+				len(credential.CredentialId()) == 0 ||
+				len(credential.Response.ClientDataJSON) == 0 ||
+				len(credential.Response.AuthenticatorData) == 0 ||
+				len(credential.Response.Signature) == 0 {
+				return validation.NewError("validation_invalid_webauthn_credential", "Missing credential id, client data, authenticator data or signature.")
+			}
+			return nil
+		})),
+	)
+}
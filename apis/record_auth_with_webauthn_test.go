@@ -0,0 +1,523 @@
+package apis_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/webauthn"
+)
+
+const (
+	webauthnTestRPId   = "example.com"
+	webauthnTestOrigin = "https://example.com"
+)
+
+// webauthnTestToken generates a new WebAuthn ceremony token for the
+// test "users" collection and the specified challenge.
+func webauthnTestToken(t testing.TB, challenge []byte, recordId string) string {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := core.NewWebAuthnToken(app, collection, webauthn.URLEncodedBase64(challenge).String(), recordId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+// registerWebAuthnTestChallenge registers the specified challenge as
+// issued (and not yet used) in the app of the test "users" collection.
+func registerWebAuthnTestChallenge(t testing.TB, app core.App, challenge []byte) {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := core.NewWebAuthnToken(app, collection, webauthn.URLEncodedBase64(challenge).String(), ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustFindCollection(t testing.TB, app core.App, nameOrId string) *core.Collection {
+	collection, err := app.FindCollectionByNameOrId(nameOrId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return collection
+}
+
+// enableWebAuthn enables the WebAuthn auth for the test "users" collection
+// and optionally registers the authenticator credential for the specified user.
+func enableWebAuthn(t testing.TB, app *tests.TestApp, authenticator *tests.TestAuthenticator, userEmail string, signCount uint32) {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.WebAuthn.Enabled = true
+	collection.WebAuthn.RPId = webauthnTestRPId
+	collection.WebAuthn.Origins = []string{webauthnTestOrigin}
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if authenticator == nil {
+		return
+	}
+
+	user, err := app.FindAuthRecordByEmail(collection, userEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential := core.NewWebAuthnCredential(app)
+	credential.Id = strings.Repeat("a", 15)
+	credential.SetCollectionRef(collection.Id)
+	credential.SetRecordRef(user.Id)
+	credential.SetName("test")
+	credential.SetCredentialId(webauthn.URLEncodedBase64(authenticator.CredentialId()).String())
+	credential.SetPublicKey(webauthn.URLEncodedBase64(authenticator.PublicKey()).String())
+	credential.SetSignCount(signCount)
+	if err := app.Save(credential); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordWebAuthnAuthOptions(t *testing.T) {
+	t.Parallel()
+
+	authenticator, err := tests.NewTestAuthenticator(webauthnTestRPId, webauthnTestOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialId := webauthn.URLEncodedBase64(authenticator.CredentialId()).String()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/demo1/webauthn-auth-options",
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled WebAuthn",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/webauthn-auth-options",
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "without email",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/webauthn-auth-options",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"options":{`,
+				`"challenge":"`,
+				`"rpId":"example.com"`,
+				`"timeout":180000`,
+				`"allowCredentials":[]`,
+			},
+			NotExpectedContent: []string{
+				credentialId,
+			},
+			ExpectedEvents: map[string]int{
+				"*": 0,
+				// auth challenge
+				"OnModelValidate":            1,
+				"OnModelCreate":              1,
+				"OnModelCreateExecute":       1,
+				"OnModelAfterCreateSuccess":  1,
+				"OnRecordValidate":           1,
+				"OnRecordCreate":             1,
+				"OnRecordCreateExecute":      1,
+				"OnRecordAfterCreateSuccess": 1,
+			},
+		},
+		{
+			Name:   "with existing email (ignored)",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/webauthn-auth-options",
+			Body:   strings.NewReader(`{"email":"test@example.com"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"challenge":"`,
+				`"allowCredentials":[]`,
+			},
+			NotExpectedContent: []string{
+				credentialId,
+			},
+			ExpectedEvents: map[string]int{
+				"*": 0,
+				// auth challenge
+				"OnModelValidate":            1,
+				"OnModelCreate":              1,
+				"OnModelCreateExecute":       1,
+				"OnModelAfterCreateSuccess":  1,
+				"OnRecordValidate":           1,
+				"OnRecordCreate":             1,
+				"OnRecordCreateExecute":      1,
+				"OnRecordAfterCreateSuccess": 1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestRecordAuthWithWebAuthn(t *testing.T) {
+	t.Parallel()
+
+	authenticator, err := tests.NewTestAuthenticator(webauthnTestRPId, webauthnTestOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator.SignCount = 1
+
+	challenge := webauthn.NewChallenge()
+	token := webauthnTestToken(t, challenge, "")
+
+	otherChallenge := webauthn.NewChallenge()
+
+	assertion := func(userHandle string) string {
+		raw, err := authenticator.AssertionResponse(challenge, []byte(userHandle))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/demo1/auth-with-webauthn",
+			Body:            strings.NewReader(`{"token":"` + token + `","credential":` + assertion("") + `}`),
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled WebAuthn",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-webauthn",
+			Body:            strings.NewReader(`{"token":"` + token + `","credential":` + assertion("") + `}`),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "empty body",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"token":{"code":"validation_required"`,
+				`"credential":{"code":"validation_required"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "incomplete credential",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + token + `","credential":{"id":"abc","type":"public-key"}}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"credential":{"code":"validation_invalid_webauthn_credential"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid token",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"invalid","credential":` + assertion("") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "registration token",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + webauthnTestToken(t, challenge, "4q1xlclmfloku33") + `","credential":` + assertion("") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "token with different challenge",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + webauthnTestToken(t, otherChallenge, "") + `","credential":` + assertion("") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+				registerWebAuthnTestChallenge(t, app, otherChallenge)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "not issued challenge",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + token + `","credential":` + assertion("4q1xlclmfloku33") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "already used challenge (replay)",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + token + `","credential":` + assertion("4q1xlclmfloku33") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+				registerWebAuthnTestChallenge(t, app, challenge)
+
+				collection := mustFindCollection(t, app, "users")
+				if !core.ConsumeWebAuthnChallenge(app, collection, webauthn.URLEncodedBase64(challenge).String()) {
+					t.Fatal("Expected the challenge to be consumed")
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "unregistered credential",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + token + `","credential":` + assertion("") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, nil, "", 0)
+				registerWebAuthnTestChallenge(t, app, challenge)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "user handle mismatch",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + token + `","credential":` + assertion("oap640cot4yru2s") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+				registerWebAuthnTestChallenge(t, app, challenge)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "sign count regression",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + token + `","credential":` + assertion("4q1xlclmfloku33") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1000)
+				registerWebAuthnTestChallenge(t, app, challenge)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "OnRecordAuthWithWebAuthnRequest tx body write check",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + token + `","credential":` + assertion("4q1xlclmfloku33") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+				registerWebAuthnTestChallenge(t, app, challenge)
+
+				// disable MFA
+				collection := mustFindCollection(t, app, "users")
+				collection.MFA.Enabled = false
+				if err := app.Save(collection); err != nil {
+					t.Fatal(err)
+				}
+
+				app.OnRecordAuthWithWebAuthnRequest().BindFunc(func(e *core.RecordAuthWithWebAuthnRequestEvent) error {
+					original := e.App
+					return e.App.RunInTransaction(func(txApp core.App) error {
+						e.App = txApp
+						defer func() { e.App = original }()
+
+						if err := e.Next(); err != nil {
+							return err
+						}
+
+						return e.BadRequestError("TX_ERROR", nil)
+					})
+				})
+			},
+			ExpectedStatus:  400,
+			ExpectedEvents:  map[string]int{"OnRecordAuthWithWebAuthnRequest": 1},
+			ExpectedContent: []string{"TX_ERROR"},
+		},
+		{
+			Name:   "valid assertion (enabled MFA)",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + token + `","credential":` + assertion("4q1xlclmfloku33") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+				registerWebAuthnTestChallenge(t, app, challenge)
+			},
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"mfaId":"`},
+			ExpectedEvents: map[string]int{
+				"*":                               0,
+				"OnRecordAuthWithWebAuthnRequest": 1,
+				"OnRecordAuthRequest":             1,
+				// ---
+				"OnModelValidate":           2,
+				"OnModelCreate":             1, // mfa record
+				"OnModelCreateExecute":      1,
+				"OnModelAfterCreateSuccess": 1,
+				"OnModelUpdate":             1, // credential usage
+				"OnModelUpdateExecute":      1,
+				"OnModelAfterUpdateSuccess": 1,
+				// ---
+				"OnRecordValidate":           2,
+				"OnRecordCreate":             1,
+				"OnRecordCreateExecute":      1,
+				"OnRecordAfterCreateSuccess": 1,
+				"OnRecordUpdate":             1,
+				"OnRecordUpdateExecute":      1,
+				"OnRecordAfterUpdateSuccess": 1,
+			},
+		},
+		{
+			Name:   "valid assertion without user handle (disabled MFA)",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"token":"` + token + `","credential":` + assertion("") + `}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableWebAuthn(t, app, authenticator, "test@example.com", 1)
+				registerWebAuthnTestChallenge(t, app, challenge)
+
+				// disable MFA
+				collection := mustFindCollection(t, app, "users")
+				collection.MFA.Enabled = false
+				if err := app.Save(collection); err != nil {
+					t.Fatal(err)
+				}
+
+				// test at least once that the correct request info context is properly loaded
+				app.OnRecordAuthRequest().BindFunc(func(e *core.RecordAuthRequestEvent) error {
+					info, err := e.RequestInfo()
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if info.Context != core.RequestInfoContextWebAuthn {
+						t.Fatalf("Expected request context %q, got %q", core.RequestInfoContextWebAuthn, info.Context)
+					}
+
+					return e.Next()
+				})
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				credential, err := app.FindWebAuthnCredentialByCredentialId(
+					mustFindCollection(t, app, "users"),
+					webauthn.URLEncodedBase64(authenticator.CredentialId()).String(),
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if credential.SignCount() <= 1 {
+					t.Fatalf("Expected the credential sign count to be updated, got %d", credential.SignCount())
+				}
+
+				if credential.LastUsed().IsZero() {
+					t.Fatal("Expected the credential lastUsed to be set")
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"record":{`,
+				`"email":"test@example.com"`,
+			},
+			NotExpectedContent: []string{
+				`"meta":`,
+				// hidden fields
+				`"tokenKey"`,
+				`"password"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                               0,
+				"OnRecordAuthWithWebAuthnRequest": 1,
+				"OnRecordAuthRequest":             1,
+				"OnRecordEnrich":                  1,
+				// ---
+				"OnModelValidate":           2,
+				"OnModelCreate":             1, // authOrigin
+				"OnModelCreateExecute":      1,
+				"OnModelAfterCreateSuccess": 1,
+				"OnModelUpdate":             1, // credential usage
+				"OnModelUpdateExecute":      1,
+				"OnModelAfterUpdateSuccess": 1,
+				// ---
+				"OnRecordValidate":           2,
+				"OnRecordCreate":             1,
+				"OnRecordCreateExecute":      1,
+				"OnRecordAfterCreateSuccess": 1,
+				"OnRecordUpdate":             1,
+				"OnRecordUpdateExecute":      1,
+				"OnRecordAfterUpdateSuccess": 1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -44,6 +44,11 @@ func recordsList(e *core.RequestEvent) error {
 		return err
 	}
 
+	err = checkAPIKeyScope(e, collection, core.APIKeyActionList)
+	if err != nil {
+		return err
+	}
+
 	requestInfo, err := e.RequestInfo()
 	if err != nil {
 		return firstApiError(err, e.BadRequestError("", err))
@@ -59,26 +64,11 @@ func recordsList(e *core.RequestEvent) error {
 		return err
 	}
 
-	query := e.App.RecordQuery(collection)
-
-	fieldsResolver := core.NewRecordFieldResolver(e.App, collection, requestInfo, true)
-
-	if !requestInfo.HasSuperuserAuth() && collection.ListRule != nil && *collection.ListRule != "" {
-		expr, err := search.FilterData(*collection.ListRule).BuildExpr(fieldsResolver)
-		if err != nil {
-			return err
-		}
-		query.AndWhere(expr)
-
-		// will be applied by the search provider right before executing the query
-		// fieldsResolver.UpdateQuery(query)
+	searchProvider, err := newRecordsListProvider(e.App, collection, requestInfo)
+	if err != nil {
+		return err
 	}
 
-	// hidden fields are searchable only by superusers
-	fieldsResolver.SetAllowHiddenFields(requestInfo.HasSuperuserAuth())
-
-	searchProvider := search.NewProvider(fieldsResolver).Query(query)
-
 	// use rowid when available to minimize the need of a covering index with the "id" field
 	if !collection.IsView() {
 		searchProvider.CountCol("_rowid_")
@@ -129,6 +119,33 @@ func recordsList(e *core.RequestEvent) error {
 
 var listTimingRateLimitRule = core.RateLimitRule{MaxRequests: 3, Duration: 3}
 
+// newRecordsListProvider creates a new records search provider
+// limited by the collection list rule for the specified request info.
+//
+// Note that the caller is expected to check whether the list rule is
+// accessible and to parse the request filter and sort parameters.
+func newRecordsListProvider(app core.App, collection *core.Collection, requestInfo *core.RequestInfo) (*search.Provider, error) {
+	query := app.RecordQuery(collection)
+
+	fieldsResolver := core.NewRecordFieldResolver(app, collection, requestInfo, true)
+
+	if !requestInfo.HasSuperuserAuth() && collection.ListRule != nil && *collection.ListRule != "" {
+		expr, err := search.FilterData(*collection.ListRule).BuildExpr(fieldsResolver)
+		if err != nil {
+			return nil, err
+		}
+		query.AndWhere(expr)
+
+		// will be applied by the search provider right before executing the query
+		// fieldsResolver.UpdateQuery(query)
+	}
+
+	// hidden fields are searchable only by superusers
+	fieldsResolver.SetAllowHiddenFields(requestInfo.HasSuperuserAuth())
+
+	return search.NewProvider(fieldsResolver).Query(query), nil
+}
+
 func randomizedThrottle(softMax int64) {
 	var timeout int64
 	randRange, err := cryptoRand.Int(cryptoRand.Reader, big.NewInt(softMax))
@@ -152,6 +169,11 @@ func recordView(e *core.RequestEvent) error {
 		return err
 	}
 
+	err = checkAPIKeyScope(e, collection, core.APIKeyActionView)
+	if err != nil {
+		return err
+	}
+
 	recordId := e.Request.PathValue("id")
 	if recordId == "" {
 		return e.NotFoundError("", nil)
@@ -222,6 +244,11 @@ func recordCreate(responseWriteAfterTx bool, optFinalizer func(data any) error)
 			return err
 		}
 
+		err = checkAPIKeyScope(e, collection, core.APIKeyActionCreate)
+		if err != nil {
+			return err
+		}
+
 		requestInfo, err := e.RequestInfo()
 		if err != nil {
 			return firstApiError(err, e.BadRequestError("", err))
@@ -239,9 +266,11 @@ func recordCreate(responseWriteAfterTx bool, optFinalizer func(data any) error)
 			return firstApiError(err, e.BadRequestError("Failed to read the submitted data.", err))
 		}
 
-		// set a random password for the OAuth2 ignoring its plain password validators
+		// set a random password for the OAuth2, LDAP and SAML ignoring its plain password validators
 		var skipPlainPasswordRecordValidators bool
-		if requestInfo.Context == core.RequestInfoContextOAuth2 {
+		if requestInfo.Context == core.RequestInfoContextOAuth2 ||
+			requestInfo.Context == core.RequestInfoContextLDAP ||
+			requestInfo.Context == core.RequestInfoContextSAML {
 			if _, ok := data[core.FieldNamePassword]; !ok {
 				data[core.FieldNamePassword] = security.RandomString(30)
 				data[core.FieldNamePassword+"Confirm"] = data[core.FieldNamePassword]
@@ -405,6 +434,11 @@ func recordUpdate(responseWriteAfterTx bool, optFinalizer func(data any) error)
 			return err
 		}
 
+		err = checkAPIKeyScope(e, collection, core.APIKeyActionUpdate)
+		if err != nil {
+			return err
+		}
+
 		recordId := e.Request.PathValue("id")
 		if recordId == "" {
 			return e.NotFoundError("", nil)
@@ -544,6 +578,11 @@ func recordDelete(responseWriteAfterTx bool, optFinalizer func(data any) error)
 			return err
 		}
 
+		err = checkAPIKeyScope(e, collection, core.APIKeyActionDelete)
+		if err != nil {
+			return err
+		}
+
 		recordId := e.Request.PathValue("id")
 		if recordId == "" {
 			return e.NotFoundError("", nil)
@@ -0,0 +1,440 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/picker"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/search"
+)
+
+// Supported export formats.
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatJSON   = "json"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
+
+// Supported export flatten options.
+const (
+	// ExportFlattenExpand flattens the expanded relation records
+	// into "relField.field" columns.
+	ExportFlattenExpand = "expand"
+
+	// ExportFlattenJSON flattens the object values (eg. json and geoPoint fields)
+	// into "field.key" columns.
+	ExportFlattenJSON = "json"
+
+	// ExportFlattenNone disables the default flattening.
+	ExportFlattenNone = "none"
+)
+
+// exportBatchSize is the number of records loaded and enriched at once.
+const exportBatchSize = 500
+
+// bindRecordExportApi registers the records export api endpoints.
+func bindRecordExportApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	subGroup := rg.Group("/collections/{collection}/export")
+	subGroup.GET("", recordsExport)
+	subGroup.POST("/jobs", exportJobCreate).Bind(RequireSuperuserAuth())
+
+	rg.GET("/export/jobs/{id}/download", exportJobDownload) // relies on superuser file token
+
+	jobs := rg.Group("/export/jobs").Bind(RequireSuperuserAuth())
+	jobs.GET("", exportJobList)
+	jobs.GET("/{id}", exportJobView)
+	jobs.POST("/{id}/cancel", exportJobCancel)
+}
+
+// ExportOptions defines the output options of a records export.
+//
+// The records filter, sort, expand and fields are read from
+// the request query parameters in the same way as the records list API.
+type ExportOptions struct {
+	// Format is one of the ExportFormat* constants (default to CSV).
+	Format string `json:"format"`
+
+	// Flatten is a list with ExportFlatten* options
+	// (default to "expand" for CSV and XLSX and none for JSON and NDJSON).
+	Flatten []string `json:"flatten"`
+
+	// Separator is the delimiter of the multiple values in a single
+	// CSV or XLSX cell (default to ",").
+	Separator string `json:"separator"`
+
+	// Delimiter is the CSV columns delimiter (default to ",").
+	Delimiter string `json:"delimiter"`
+}
+
+// exportOptionsFromQuery parses and validates the export options from the request query parameters.
+func exportOptionsFromQuery(query map[string]string) (ExportOptions, error) {
+	options := ExportOptions{
+		Format:    strings.ToLower(strings.TrimSpace(query["format"])),
+		Separator: query["separator"],
+		Delimiter: query["delimiter"],
+	}
+
+	if options.Format == "" {
+		options.Format = ExportFormatCSV
+	}
+
+	if raw, ok := query["flatten"]; ok {
+		options.Flatten = []string{}
+		for _, f := range strings.Split(raw, ",") {
+			f = strings.ToLower(strings.TrimSpace(f))
+			if f != "" && f != ExportFlattenNone {
+				options.Flatten = append(options.Flatten, f)
+			}
+		}
+	} else if options.isTabular() {
+		options.Flatten = []string{ExportFlattenExpand}
+	}
+
+	if options.Separator == "" {
+		options.Separator = DefaultImportSeparator
+	}
+
+	if options.Delimiter == "" {
+		options.Delimiter = ","
+	}
+
+	return options, validation.ValidateStruct(&options,
+		validation.Field(
+			&options.Format,
+			validation.In(ExportFormatCSV, ExportFormatJSON, ExportFormatNDJSON, ExportFormatXLSX).
+				Error("Must be one of csv, json, ndjson or xlsx."),
+		),
+		validation.Field(
+			&options.Flatten,
+			validation.Each(validation.In(ExportFlattenExpand, ExportFlattenJSON).
+				Error("Must be one of expand, json or none.")),
+		),
+		validation.Field(&options.Delimiter, validation.RuneLength(1, 1)),
+	)
+}
+
+// isTabular reports whether the export format has fixed columns.
+func (options ExportOptions) isTabular() bool {
+	return options.Format == ExportFormatCSV || options.Format == ExportFormatXLSX
+}
+
+func (options ExportOptions) flatten(option string) bool {
+	return slices.Contains(options.Flatten, option)
+}
+
+// extension returns the export file extension (without dot).
+func (options ExportOptions) extension() string {
+	return options.Format
+}
+
+// contentType returns the export file mime type.
+func (options ExportOptions) contentType() string {
+	switch options.Format {
+	case ExportFormatJSON:
+		return "application/json"
+	case ExportFormatNDJSON:
+		return "application/x-ndjson"
+	case ExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// exportFilename returns the default export file name of the collection.
+func exportFilename(collection *core.Collection, options ExportOptions, date time.Time) string {
+	return collection.Name + "_" + date.UTC().Format("20060102150405") + "." + options.extension()
+}
+
+// -------------------------------------------------------------------
+
+// recordExporter writes the records of a single collection that are
+// accessible with the specified request info into an export file.
+type recordExporter struct {
+	app         core.App
+	collection  *core.Collection
+	requestInfo *core.RequestInfo
+	options     ExportOptions
+
+	// event is the optional request event of the records list request hook
+	// that is triggered for each export page (the hook is skipped if nil).
+	event *core.RequestEvent
+
+	// onStart is an optional callback that is invoked after
+	// the first batch is loaded and right before the first write.
+	onStart func() error
+
+	// onBatch is an optional callback that is invoked after each written batch
+	// with the total number of the exported records so far.
+	onBatch func(total int)
+}
+
+// export writes all matching records into w and returns their count.
+//
+// Errors related to the export query (eg. invalid filter) are returned
+// before anything is written to w.
+func (ex *recordExporter) export(ctx context.Context, w io.Writer) (int, error) {
+	provider, err := ex.searchProvider()
+	if err != nil {
+		return 0, err
+	}
+
+	var writer exportWriter
+	var total int
+
+	// the CSV and XLSX rows are spooled until their columns are known
+	var spool *os.File
+	var blankColumns []string
+	if ex.options.isTabular() {
+		tempDir := filepath.Join(ex.app.DataDir(), core.LocalTempDirName)
+		if err := os.MkdirAll(tempDir, os.ModePerm); err != nil {
+			return 0, err
+		}
+
+		spool, err = os.CreateTemp(tempDir, "export_rows_*.ndjson")
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			spool.Close()
+			os.Remove(spool.Name())
+		}()
+
+		// use a blank record to resolve the columns of an empty export
+		blankRows, err := ex.rows([]*core.Record{core.NewRecord(ex.collection)})
+		if err != nil {
+			return 0, err
+		}
+		for _, row := range blankRows {
+			for key := range row {
+				blankColumns = append(blankColumns, key)
+			}
+		}
+	}
+
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		records := []*core.Record{}
+		result, err := provider.Page(page).Exec(&records)
+		if err != nil {
+			return total, err
+		}
+
+		// the page could be modified by the list hooks
+		hasMore := len(records) >= exportBatchSize
+
+		records, err = ex.listRecords(result, records)
+		if err != nil {
+			return total, err
+		}
+
+		rows, err := ex.rows(records)
+		if err != nil {
+			return total, err
+		}
+
+		if writer == nil {
+			if ex.onStart != nil {
+				if err := ex.onStart(); err != nil {
+					return total, err
+				}
+			}
+
+			writer, err = newExportWriter(w, ex.collection, ex.options, spool, blankColumns)
+			if err != nil {
+				return total, err
+			}
+		}
+
+		for _, row := range rows {
+			if err := writer.write(row); err != nil {
+				return total, err
+			}
+		}
+
+		total += len(rows)
+
+		if ex.onBatch != nil {
+			ex.onBatch(total)
+		}
+
+		if !hasMore {
+			break
+		}
+	}
+
+	return total, writer.close()
+}
+
+// searchProvider prepares the records search provider with the
+// collection list rule and the request filter and sort parameters.
+func (ex *recordExporter) searchProvider() (*search.Provider, error) {
+	if ex.collection.ListRule == nil && !ex.requestInfo.HasSuperuserAuth() {
+		return nil, router.NewForbiddenError("Only superusers can perform this action.", nil)
+	}
+
+	provider, err := newRecordsListProvider(ex.app, ex.collection, ex.requestInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.SkipTotal(true).PerPage(exportBatchSize)
+
+	params := url.Values{}
+	if filter := ex.requestInfo.Query[search.FilterQueryParam]; filter != "" {
+		params.Set(search.FilterQueryParam, filter)
+	}
+	if sort := ex.requestInfo.Query[search.SortQueryParam]; sort != "" {
+		params.Set(search.SortQueryParam, sort)
+	}
+
+	if err := provider.Parse(params.Encode()); err != nil {
+		return nil, err
+	}
+
+	// ensure stable pagination for records with equal sort values
+	provider.AddSort(search.SortField{Name: core.FieldNameId, Direction: search.SortAsc})
+
+	return provider, nil
+}
+
+// listRecords triggers the records list request hook for a single
+// export page (if the exporter has a request event) and returns
+// the enriched hook records.
+//
+// If a hook doesn't continue the execution chain, the page is
+// exported without records.
+func (ex *recordExporter) listRecords(result *search.Result, records []*core.Record) ([]*core.Record, error) {
+	if ex.event == nil {
+		return records, enrichRecords(ex.app, ex.requestInfo, records)
+	}
+
+	event := new(core.RecordsListRequestEvent)
+	event.RequestEvent = ex.event
+	event.Collection = ex.collection
+	event.Records = records
+	event.Result = result
+
+	var listed []*core.Record
+
+	err := ex.app.OnRecordsListRequest().Trigger(event, func(e *core.RecordsListRequestEvent) error {
+		if err := enrichRecords(e.App, ex.requestInfo, e.Records); err != nil {
+			return err
+		}
+
+		listed = e.Records
+
+		return nil
+	})
+
+	return listed, err
+}
+
+// rows converts the enriched records into export rows
+// (applying the "fields" query parameter and the flatten options).
+func (ex *recordExporter) rows(records []*core.Record) ([]map[string]any, error) {
+	rawFields := ex.requestInfo.Query["fields"]
+
+	rows := make([]map[string]any, 0, len(records))
+
+	for _, record := range records {
+		var data any = record
+		if rawFields != "" {
+			picked, err := picker.Pick(record, rawFields)
+			if err != nil {
+				return nil, err
+			}
+			data = picked
+		}
+
+		row, err := exportRecordData(data)
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, flattenExportRow(row, ex.options))
+	}
+
+	return rows, nil
+}
+
+// -------------------------------------------------------------------
+
+// recordsExport streams the collection records that are accessible by the
+// current request auth in the requested format.
+//
+// GET /api/collections/{collection}/export
+func recordsExport(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	err = checkCollectionRateLimit(e, collection, "list")
+	if err != nil {
+		return err
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	// forbid users and guests to query special filter/sort fields
+	err = checkForSuperuserOnlyRuleFields(requestInfo)
+	if err != nil {
+		return err
+	}
+
+	options, err := exportOptionsFromQuery(requestInfo.Query)
+	if err != nil {
+		return e.BadRequestError("Invalid export options.", err)
+	}
+
+	var started bool
+
+	exporter := &recordExporter{
+		app:         e.App,
+		collection:  collection,
+		requestInfo: requestInfo,
+		options:     options,
+		event:       e,
+		onStart: func() error {
+			started = true
+
+			e.Response.Header().Set("Content-Type", options.contentType())
+			e.Response.Header().Set("Content-Disposition", `attachment; filename="`+exportFilename(collection, options, time.Now())+`"`)
+			e.Response.WriteHeader(200)
+
+			return nil
+		},
+	}
+
+	_, err = exporter.export(e.Request.Context(), e.Response)
+	if err != nil {
+		if !started {
+			return firstApiError(err, e.BadRequestError("", err))
+		}
+
+		// the response status is already sent
+		if !errors.Is(err, context.Canceled) {
+			e.App.Logger().Warn("Failed to complete the records export", "collection", collection.Name, "error", err)
+		}
+	}
+
+	return nil
+}
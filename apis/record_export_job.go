@@ -0,0 +1,449 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/store"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ExportJobsStorageDir is the app storage directory with the background export files.
+const ExportJobsStorageDir = "_pb_exports_"
+
+const (
+	exportJobsStoreKey      = "__pbExportJobs__"
+	exportJobsTerminateHook = "__pbExportJobsTerminate__"
+
+	// finishedExportJobsTTL is the duration after which the finished
+	// export jobs and their storage files are removed.
+	finishedExportJobsTTL = 24 * time.Hour
+)
+
+// Export job statuses.
+const (
+	ExportJobStatusRunning   = "running"
+	ExportJobStatusCompleted = "completed"
+	ExportJobStatusFailed    = "failed"
+	ExportJobStatusCanceled  = "canceled"
+)
+
+// ExportJob represents the state of a single background export job.
+type ExportJob struct {
+	Id            string         `json:"id"`
+	Collection    string         `json:"collection"`
+	Format        string         `json:"format"`
+	Status        string         `json:"status"`
+	Error         string         `json:"error,omitempty"`
+	ExportedCount int            `json:"exportedCount"`
+	FileName      string         `json:"fileName"`
+	FileSize      int64          `json:"fileSize"`
+	Created       types.DateTime `json:"created"`
+	Updated       types.DateTime `json:"updated"`
+
+	// fileKey is the export file storage key (available once the job is completed).
+	fileKey string
+}
+
+// IsFinished reports whether the job is no longer running.
+func (job ExportJob) IsFinished() bool {
+	return job.Status != ExportJobStatusRunning
+}
+
+type exportJobEntry struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	job    ExportJob
+	mu     sync.RWMutex
+}
+
+func (entry *exportJobEntry) snapshot() ExportJob {
+	entry.mu.RLock()
+	defer entry.mu.RUnlock()
+
+	return entry.job
+}
+
+func (entry *exportJobEntry) update(fn func(job *ExportJob)) ExportJob {
+	entry.mu.Lock()
+	fn(&entry.job)
+	entry.job.Updated = types.NowDateTime()
+	entry.mu.Unlock()
+
+	return entry.snapshot()
+}
+
+// StartExportJob starts a new background job that exports the collection
+// records accessible with requestInfo into the app storage.
+//
+// The records filter, sort, expand and fields are read from the requestInfo query
+// (see [ExportOptions]).
+//
+// The job state is available through [FindExportJob] and the export
+// file could be downloaded once the job is completed.
+//
+// Note that the records list request hook is triggered only for
+// the jobs created with the export jobs API.
+func StartExportJob(app core.App, collection *core.Collection, requestInfo *core.RequestInfo, options ExportOptions) (ExportJob, error) {
+	return startExportJob(app, collection, requestInfo, nil, options)
+}
+
+// startExportJob is similar to [StartExportJob] but also triggers
+// the records list request hook with event for each export page (if not nil).
+func startExportJob(app core.App, collection *core.Collection, requestInfo *core.RequestInfo, event *core.RequestEvent, options ExportOptions) (ExportJob, error) {
+	if requestInfo == nil {
+		requestInfo = &core.RequestInfo{}
+	}
+
+	cleanupExportJobs(app)
+
+	tempDir := filepath.Join(app.DataDir(), core.LocalTempDirName)
+	if err := os.MkdirAll(tempDir, os.ModePerm); err != nil {
+		return ExportJob{}, err
+	}
+
+	file, err := os.CreateTemp(tempDir, "export_*."+options.extension())
+	if err != nil {
+		return ExportJob{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	now := types.NowDateTime()
+
+	entry := &exportJobEntry{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		job: ExportJob{
+			Id:         security.RandomString(15),
+			Collection: collection.Name,
+			Format:     options.Format,
+			Status:     ExportJobStatusRunning,
+			FileName:   exportFilename(collection, options, now.Time()),
+			Created:    now,
+			Updated:    now,
+		},
+	}
+
+	exportJobs(app).Set(entry.job.Id, entry)
+
+	routine.FireAndForget(func() {
+		defer close(entry.done)
+		defer cancel()
+		defer os.Remove(file.Name())
+
+		exporter := &recordExporter{
+			app:         app,
+			collection:  collection,
+			requestInfo: requestInfo,
+			options:     options,
+			event:       event,
+			onBatch: func(total int) {
+				entry.update(func(job *ExportJob) {
+					job.ExportedCount = total
+				})
+			},
+		}
+
+		total, runErr := exporter.export(ctx, file)
+
+		closeErr := file.Close()
+		if runErr == nil {
+			runErr = closeErr
+		}
+
+		var fileKey string
+		var fileSize int64
+		if runErr == nil {
+			fileKey = ExportJobsStorageDir + "/" + entry.job.Id + "." + options.extension()
+			fileSize, runErr = uploadExportFile(ctx, app, file.Name(), fileKey)
+		}
+
+		job := entry.update(func(job *ExportJob) {
+			job.ExportedCount = total
+
+			switch {
+			case runErr == nil:
+				job.Status = ExportJobStatusCompleted
+				job.FileSize = fileSize
+				job.fileKey = fileKey
+			case errors.Is(runErr, context.Canceled):
+				job.Status = ExportJobStatusCanceled
+			default:
+				job.Status = ExportJobStatusFailed
+				job.Error = runErr.Error()
+			}
+		})
+
+		if job.Status == ExportJobStatusFailed {
+			app.Logger().Warn(
+				"Export job failed",
+				slog.String("id", job.Id),
+				slog.String("collection", job.Collection),
+				slog.String("error", job.Error),
+			)
+		}
+	})
+
+	return entry.snapshot(), nil
+}
+
+// uploadExportFile uploads the local export file into the app storage and returns its size.
+func uploadExportFile(ctx context.Context, app core.App, path string, fileKey string) (int64, error) {
+	f, err := filesystem.NewFileFromPath(path)
+	if err != nil {
+		return 0, err
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return 0, err
+	}
+	defer fsys.Close()
+
+	fsys.SetContext(ctx)
+
+	if err := fsys.UploadFile(f, fileKey); err != nil {
+		return 0, err
+	}
+
+	return f.Size, nil
+}
+
+// FindExportJob returns the state of a single export job.
+func FindExportJob(app core.App, id string) (ExportJob, bool) {
+	entry, ok := exportJobs(app).GetOk(id)
+	if !ok {
+		return ExportJob{}, false
+	}
+
+	return entry.snapshot(), true
+}
+
+// WaitExportJob blocks until the specified export job finishes or ctx is done.
+func WaitExportJob(ctx context.Context, app core.App, id string) (ExportJob, error) {
+	entry, ok := exportJobs(app).GetOk(id)
+	if !ok {
+		return ExportJob{}, errors.New("missing export job " + id)
+	}
+
+	select {
+	case <-entry.done:
+		return entry.snapshot(), nil
+	case <-ctx.Done():
+		return entry.snapshot(), ctx.Err()
+	}
+}
+
+// CancelExportJob stops the specified running export job.
+func CancelExportJob(app core.App, id string) (ExportJob, error) {
+	entry, ok := exportJobs(app).GetOk(id)
+	if !ok {
+		return ExportJob{}, errors.New("missing export job " + id)
+	}
+
+	entry.cancel()
+
+	<-entry.done
+
+	return entry.snapshot(), nil
+}
+
+// exportJobs returns the app export jobs registry (initializing it if missing).
+func exportJobs(app core.App) *store.Store[string, *exportJobEntry] {
+	return app.Store().GetOrSet(exportJobsStoreKey, func() any {
+		jobs := store.New[string, *exportJobEntry](nil)
+
+		app.OnTerminate().Bind(&hook.Handler[*core.TerminateEvent]{
+			Id: exportJobsTerminateHook,
+			Func: func(e *core.TerminateEvent) error {
+				for _, entry := range jobs.GetAll() {
+					entry.cancel()
+				}
+
+				return e.Next()
+			},
+		})
+
+		return jobs
+	}).(*store.Store[string, *exportJobEntry])
+}
+
+// cleanupExportJobs removes the finished jobs and the storage
+// export files older than [finishedExportJobsTTL].
+//
+// The storage files are checked separately from the jobs registry
+// to also cleanup the files of the jobs from previous app runs.
+func cleanupExportJobs(app core.App) {
+	jobs := exportJobs(app)
+
+	threshold := time.Now().Add(-finishedExportJobsTTL)
+
+	for id, entry := range jobs.GetAll() {
+		job := entry.snapshot()
+		if job.IsFinished() && job.Updated.Time().Before(threshold) {
+			jobs.Remove(id)
+		}
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		app.Logger().Warn("Failed to load the export files storage", slog.String("error", err.Error()))
+		return
+	}
+	defer fsys.Close()
+
+	objects, err := fsys.List(ExportJobsStorageDir + "/")
+	if err != nil {
+		app.Logger().Warn("Failed to list the export files", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, obj := range objects {
+		if obj.ModTime.Before(threshold) {
+			if err := fsys.Delete(obj.Key); err != nil {
+				app.Logger().Warn("Failed to delete export file", slog.String("key", obj.Key), slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// -------------------------------------------------------------------
+
+// exportJobCreate starts a new background export job for the collection records.
+//
+// It accepts the same query parameters as the records export endpoint.
+//
+// POST /api/collections/{collection}/export/jobs
+func exportJobCreate(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	options, err := exportOptionsFromQuery(requestInfo.Query)
+	if err != nil {
+		return e.BadRequestError("Invalid export options.", err)
+	}
+
+	// validate the list rule, filter and sort upfront
+	provider, err := (&recordExporter{app: e.App, collection: collection, requestInfo: requestInfo}).searchProvider()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+	if _, err := provider.PerPage(1).Exec(&[]*core.Record{}); err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	job, err := startExportJob(e.App, collection, requestInfo, newExportJobEvent(e), options)
+	if err != nil {
+		return e.InternalServerError("Failed to start the export job.", err)
+	}
+
+	return e.JSON(http.StatusOK, job)
+}
+
+// newExportJobEvent returns a detached copy of the e request event that
+// could be used by the export job hooks after the request completes.
+func newExportJobEvent(e *core.RequestEvent) *core.RequestEvent {
+	event := &core.RequestEvent{}
+	event.App = e.App
+	event.Auth = e.Auth
+	event.SetAll(e.GetAll())
+
+	event.Request = e.Request.Clone(context.WithoutCancel(e.Request.Context()))
+	event.Request.Body = http.NoBody
+
+	event.Response = &router.ResponseWriter{ResponseWriter: httptest.NewRecorder()}
+
+	return event
+}
+
+// exportJobList returns all export jobs sorted by their creation date (newest first).
+// GET /api/export/jobs
+func exportJobList(e *core.RequestEvent) error {
+	entries := exportJobs(e.App).Values()
+
+	result := make([]ExportJob, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry.snapshot())
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created.Time().After(result[j].Created.Time())
+	})
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// exportJobView returns the state of a single export job.
+// GET /api/export/jobs/{id}
+func exportJobView(e *core.RequestEvent) error {
+	job, ok := FindExportJob(e.App, e.Request.PathValue("id"))
+	if !ok {
+		return e.NotFoundError("", nil)
+	}
+
+	return e.JSON(http.StatusOK, job)
+}
+
+// exportJobCancel stops a running export job.
+// POST /api/export/jobs/{id}/cancel
+func exportJobCancel(e *core.RequestEvent) error {
+	job, err := CancelExportJob(e.App, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	return e.JSON(http.StatusOK, job)
+}
+
+// exportJobDownload serves the file of a completed export job.
+// GET /api/export/jobs/{id}/download
+//
+// It relies on a superuser file token so that it could be used as a regular download link.
+func exportJobDownload(e *core.RequestEvent) error {
+	fileToken := e.Request.URL.Query().Get("token")
+
+	authRecord, err := e.App.FindAuthRecordByToken(fileToken, core.TokenTypeFile)
+	if err != nil || !authRecord.IsSuperuser() {
+		return e.ForbiddenError("Insufficient permissions to access the resource.", err)
+	}
+
+	job, ok := FindExportJob(e.App, e.Request.PathValue("id"))
+	if !ok || job.Status != ExportJobStatusCompleted || job.fileKey == "" {
+		return e.NotFoundError("", nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	fsys, err := e.App.NewFilesystem()
+	if err != nil {
+		return e.InternalServerError("Failed to load the export files storage.", err)
+	}
+	defer fsys.Close()
+
+	fsys.SetContext(ctx)
+
+	return fsys.Serve(e.Response, e.Request, job.fileKey, job.FileName)
+}
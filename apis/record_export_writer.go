@@ -0,0 +1,465 @@
+package apis
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/pocketbase/pocketbase/tools/xlsx"
+)
+
+// exportWriter writes export rows in a specific format.
+type exportWriter interface {
+	write(row map[string]any) error
+	close() error
+}
+
+// newExportWriter creates a new exportWriter for the options format.
+//
+// spool and blankColumns are used only by the tabular formats (CSV and XLSX)
+// (see [tabularExportWriter]).
+func newExportWriter(w io.Writer, collection *core.Collection, options ExportOptions, spool *os.File, blankColumns []string) (exportWriter, error) {
+	switch options.Format {
+	case ExportFormatJSON:
+		return newJSONExportWriter(w)
+	case ExportFormatNDJSON:
+		return &ndjsonExportWriter{buf: bufio.NewWriter(w)}, nil
+	default:
+		return &tabularExportWriter{
+			w:            w,
+			collection:   collection,
+			options:      options,
+			spool:        spool,
+			buf:          bufio.NewWriter(spool),
+			blankColumns: blankColumns,
+			seen:         map[string]struct{}{},
+		}, nil
+	}
+}
+
+// -------------------------------------------------------------------
+
+// tabularExportWriter writes the CSV and XLSX exports.
+//
+// The header must list the columns of all rows (eg. an expand or a json key
+// could appear for the first time in the last row), so the rows are spooled
+// as NDJSON into the spool file and the actual CSV or XLSX is written on close.
+//
+// blankColumns are the header columns if no rows were written.
+type tabularExportWriter struct {
+	w            io.Writer
+	collection   *core.Collection
+	options      ExportOptions
+	spool        *os.File
+	buf          *bufio.Writer
+	blankColumns []string
+	columns      []string
+	seen         map[string]struct{}
+}
+
+func (w *tabularExportWriter) write(row map[string]any) error {
+	for key := range row {
+		if _, ok := w.seen[key]; !ok {
+			w.seen[key] = struct{}{}
+			w.columns = append(w.columns, key)
+		}
+	}
+
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.buf.Write(raw); err != nil {
+		return err
+	}
+
+	return w.buf.WriteByte('\n')
+}
+
+func (w *tabularExportWriter) close() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+
+	columns := w.columns
+	if len(columns) == 0 {
+		columns = w.blankColumns
+	}
+	columns = sortExportColumns(w.collection, columns)
+
+	var writer exportWriter
+	var err error
+	if w.options.Format == ExportFormatXLSX {
+		writer, err = newXLSXExportWriter(w.w, w.collection, w.options, columns)
+	} else {
+		writer, err = newCSVExportWriter(w.w, w.options, columns)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(w.spool))
+	for {
+		row := map[string]any{}
+
+		err := decoder.Decode(&row)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := writer.write(row); err != nil {
+			return err
+		}
+	}
+
+	return writer.close()
+}
+
+// -------------------------------------------------------------------
+
+type jsonExportWriter struct {
+	buf   *bufio.Writer
+	count int
+}
+
+func newJSONExportWriter(w io.Writer) (*jsonExportWriter, error) {
+	buf := bufio.NewWriter(w)
+
+	if _, err := buf.WriteString("["); err != nil {
+		return nil, err
+	}
+
+	return &jsonExportWriter{buf: buf}, nil
+}
+
+func (w *jsonExportWriter) write(row map[string]any) error {
+	if w.count > 0 {
+		if err := w.buf.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	w.count++
+
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.buf.Write(raw)
+
+	return err
+}
+
+func (w *jsonExportWriter) close() error {
+	if _, err := w.buf.WriteString("]\n"); err != nil {
+		return err
+	}
+
+	return w.buf.Flush()
+}
+
+// -------------------------------------------------------------------
+
+type ndjsonExportWriter struct {
+	buf *bufio.Writer
+}
+
+func (w *ndjsonExportWriter) write(row map[string]any) error {
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.buf.Write(raw); err != nil {
+		return err
+	}
+
+	return w.buf.WriteByte('\n')
+}
+
+func (w *ndjsonExportWriter) close() error {
+	return w.buf.Flush()
+}
+
+// -------------------------------------------------------------------
+
+type csvExportWriter struct {
+	writer    *csv.Writer
+	columns   []string
+	separator string
+}
+
+func newCSVExportWriter(w io.Writer, options ExportOptions, columns []string) (*csvExportWriter, error) {
+	writer := csv.NewWriter(w)
+	writer.Comma, _ = utf8.DecodeRuneInString(options.Delimiter)
+
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+
+	return &csvExportWriter{
+		writer:    writer,
+		columns:   columns,
+		separator: options.Separator,
+	}, nil
+}
+
+func (w *csvExportWriter) write(row map[string]any) error {
+	record := make([]string, len(w.columns))
+	for i, col := range w.columns {
+		record[i] = exportCellString(row[col], w.separator)
+	}
+
+	return w.writer.Write(record)
+}
+
+func (w *csvExportWriter) close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// -------------------------------------------------------------------
+
+type xlsxExportWriter struct {
+	writer      *xlsx.Writer
+	columns     []string
+	dateColumns []bool
+	separator   string
+}
+
+func newXLSXExportWriter(w io.Writer, collection *core.Collection, options ExportOptions, columns []string) (*xlsxExportWriter, error) {
+	writer, err := xlsx.NewWriter(w, collection.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]any, len(columns))
+	dateColumns := make([]bool, len(columns))
+	for i, col := range columns {
+		header[i] = col
+
+		// only the top level collection date fields are exported as date cells
+		switch collection.Fields.GetByName(col).(type) {
+		case *core.DateField, *core.AutodateField:
+			dateColumns[i] = true
+		}
+	}
+
+	if err := writer.WriteRow(header); err != nil {
+		return nil, err
+	}
+
+	return &xlsxExportWriter{
+		writer:      writer,
+		columns:     columns,
+		dateColumns: dateColumns,
+		separator:   options.Separator,
+	}, nil
+}
+
+func (w *xlsxExportWriter) write(row map[string]any) error {
+	values := make([]any, len(w.columns))
+
+	for i, col := range w.columns {
+		switch v := row[col].(type) {
+		case nil:
+		case bool, float64:
+			values[i] = v
+		case string:
+			values[i] = v
+			if w.dateColumns[i] && v != "" {
+				if dt, err := types.ParseDateTime(v); err == nil && !dt.IsZero() {
+					values[i] = dt.Time()
+				}
+			}
+		default:
+			values[i] = exportCellString(v, w.separator)
+		}
+	}
+
+	return w.writer.WriteRow(values)
+}
+
+func (w *xlsxExportWriter) close() error {
+	return w.writer.Close()
+}
+
+// -------------------------------------------------------------------
+
+// exportRecordData converts the record (or its picked fields data)
+// into a plain JSON map (the same as in the records list API response).
+func exportRecordData(data any) (map[string]any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{}
+
+	return result, json.Unmarshal(raw, &result)
+}
+
+// flattenExportRow flattens the row expand and object values based on the export options.
+//
+// The values of a multiple relation expand are grouped per column as list.
+func flattenExportRow(row map[string]any, options ExportOptions) map[string]any {
+	flattenExpand := options.flatten(ExportFlattenExpand)
+	flattenJSON := options.flatten(ExportFlattenJSON)
+
+	if !flattenExpand && !flattenJSON {
+		return row
+	}
+
+	result := make(map[string]any, len(row))
+
+	for key, value := range row {
+		if key == "expand" && flattenExpand {
+			expand, ok := value.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			for relName, rel := range expand {
+				switch v := rel.(type) {
+				case map[string]any:
+					for k, relValue := range flattenExportRow(v, options) {
+						result[relName+"."+k] = relValue
+					}
+				case []any:
+					for i, item := range v {
+						itemData, ok := item.(map[string]any)
+						if !ok {
+							continue
+						}
+
+						for k, relValue := range flattenExportRow(itemData, options) {
+							list, _ := result[relName+"."+k].([]any)
+							if list == nil {
+								list = make([]any, len(v))
+								result[relName+"."+k] = list
+							}
+							list[i] = relValue
+						}
+					}
+				}
+			}
+
+			continue
+		}
+
+		if obj, ok := value.(map[string]any); ok && flattenJSON && key != "expand" {
+			for k, v := range flattenExportObject(obj) {
+				result[key+"."+k] = v
+			}
+			continue
+		}
+
+		result[key] = value
+	}
+
+	return result
+}
+
+func flattenExportObject(obj map[string]any) map[string]any {
+	result := make(map[string]any, len(obj))
+
+	for key, value := range obj {
+		if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+			for k, v := range flattenExportObject(nested) {
+				result[key+"."+k] = v
+			}
+			continue
+		}
+
+		result[key] = value
+	}
+
+	return result
+}
+
+// sortExportColumns sorts the specified unique export columns.
+//
+// The collection fields columns are listed first (in the collection fields order),
+// followed by the other record properties and the expand columns.
+func sortExportColumns(collection *core.Collection, columns []string) []string {
+	columns = slices.Clone(columns)
+
+	fieldNames := collection.Fields.FieldNames()
+
+	rank := func(column string) int {
+		base, _, _ := strings.Cut(column, ".")
+
+		if i := slices.Index(fieldNames, base); i >= 0 {
+			if base == column {
+				return i * 2
+			}
+			return i*2 + 1 // flattened object columns right after their field
+		}
+
+		if base == "expand" {
+			return len(fieldNames)*2 + 1
+		}
+
+		return len(fieldNames) * 2
+	}
+
+	sort.SliceStable(columns, func(i, j int) bool {
+		ri, rj := rank(columns[i]), rank(columns[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return columns[i] < columns[j]
+	})
+
+	return columns
+}
+
+// exportCellString converts a single export value into a CSV cell text.
+//
+// Lists of plain values are joined with separator and all other
+// non-plain values are serialized as JSON.
+func exportCellString(value any, separator string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case nil, string, bool, float64:
+				parts = append(parts, exportCellString(item, separator))
+			default:
+				raw, _ := json.Marshal(v)
+				return string(raw)
+			}
+		}
+		return strings.Join(parts, separator)
+	default:
+		raw, _ := json.Marshal(v)
+		return string(raw)
+	}
+}
@@ -33,16 +33,46 @@ var ErrMFA = errors.New("mfa required")
 //
 // Set authMethod to empty string if you want to ignore the MFA checks and the login alerts
 // (can be also adjusted additionally via the OnRecordAuthRequest hook).
+//
+// If the auth collection has sessions enabled and authMethod is not empty,
+// a new server-side session is created and the response will contain
+// a short-lived access token and a rotating refresh token.
 func RecordAuthResponse(e *core.RequestEvent, authRecord *core.Record, authMethod string, meta any) error {
-	token, tokenErr := authRecord.NewAuthToken()
+	var session *core.AuthSession
+	var refreshToken string
+	var token string
+	var tokenErr error
+
+	if authMethod != "" && authRecord.Collection().Sessions.Enabled {
+		session = core.NewAuthSession(e.App)
+		session.SetCollectionRef(authRecord.Collection().Id)
+		session.SetRecordRef(authRecord.Id)
+		refreshToken = session.RefreshValue()
+
+		token, tokenErr = e.App.NewRecordSessionAuthToken(authRecord, session.Id)
+	} else {
+		token, tokenErr = e.App.NewRecordAuthToken(authRecord)
+	}
 	if tokenErr != nil {
 		return e.InternalServerError("Failed to create auth token.", tokenErr)
 	}
 
-	return recordAuthResponse(e, authRecord, token, authMethod, meta)
+	return recordAuthResponse(e, authRecord, token, authMethod, meta, session, refreshToken)
 }
 
-func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token string, authMethod string, meta any) error {
+// recordAuthResponse writes the record auth response.
+//
+// newSession is the not yet persisted auth session associated with
+// the refreshToken that will be saved after the MFA checks (if any).
+func recordAuthResponse(
+	e *core.RequestEvent,
+	authRecord *core.Record,
+	token string,
+	authMethod string,
+	meta any,
+	newSession *core.AuthSession,
+	refreshToken string,
+) error {
 	originalRequestInfo, err := e.RequestInfo()
 	if err != nil {
 		return err
@@ -58,6 +88,7 @@ func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token str
 	event.Collection = authRecord.Collection()
 	event.Record = authRecord
 	event.Token = token
+	event.RefreshToken = refreshToken
 	event.Meta = meta
 	event.AuthMethod = authMethod
 
@@ -111,19 +142,38 @@ func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token str
 			return err
 		}
 
-		if e.AuthMethod != "" && authRecord.Collection().AuthAlert.Enabled {
-			if err = authAlert(e.RequestEvent, e.Record); err != nil {
-				e.App.Logger().Warn("[recordAuthResponse] Failed to send login alert", "error", err)
+		var origin *core.AuthOrigin
+		alertEnabled := authRecord.Collection().AuthAlert.Enabled
+		if e.AuthMethod != "" && (alertEnabled || newSession != nil) {
+			origin, err = saveAuthOrigin(e.RequestEvent, e.Record, alertEnabled)
+			if err != nil {
+				e.App.Logger().Warn("[recordAuthResponse] Failed to save auth origin or send login alert", "error", err)
+			}
+		}
+
+		if newSession != nil {
+			if origin != nil {
+				newSession.SetOriginRef(origin.Id)
+			}
+			newSession.SetUserAgent(e.Request.UserAgent())
+			newSession.SetIP(e.RealIP())
+			newSession.SetLastUsed(types.NowDateTime())
+			newSession.SetExpires(types.NowDateTime().Add(authRecord.Collection().Sessions.RefreshTokenDurationTime()))
+
+			if err = e.App.Save(newSession); err != nil {
+				return e.InternalServerError("Failed to create auth session.", err)
 			}
 		}
 
 		result := struct {
-			Meta   any          `json:"meta,omitempty"`
-			Record *core.Record `json:"record"`
-			Token  string       `json:"token"`
+			Meta         any          `json:"meta,omitempty"`
+			Record       *core.Record `json:"record"`
+			Token        string       `json:"token"`
+			RefreshToken string       `json:"refreshToken,omitempty"`
 		}{
-			Token:  e.Token,
-			Record: e.Record,
+			Token:        e.Token,
+			RefreshToken: e.RefreshToken,
+			Record:       e.Record,
 		}
 
 		if e.Meta != nil {
@@ -221,6 +271,12 @@ func checkMFA(e *core.RequestEvent, authRecord *core.Record, currentAuthMethod s
 
 	// second-time auth
 	// ---
+	collection := authRecord.Collection()
+
+	if err := checkAuthLockout(e, collection, authRecord.Id); err != nil {
+		return "", err
+	}
+
 	mfa, err := e.App.FindMFAById(mfaId)
 	deleteMFA := func() {
 		// try to delete the expired mfa
@@ -230,12 +286,16 @@ func checkMFA(e *core.RequestEvent, authRecord *core.Record, currentAuthMethod s
 			}
 		}
 	}
-	if err != nil || mfa.HasExpired(authRecord.Collection().MFA.DurationTime()) {
+	if err != nil || mfa.HasExpired(collection.MFA.DurationTime()) {
+		if err != nil {
+			registerAuthFailure(e, collection, authRecord.Id, authRecord)
+		}
 		deleteMFA()
 		return "", e.BadRequestError("Invalid or expired MFA session.", err)
 	}
 
-	if mfa.RecordRef() != authRecord.Id || mfa.CollectionRef() != authRecord.Collection().Id {
+	if mfa.RecordRef() != authRecord.Id || mfa.CollectionRef() != collection.Id {
+		registerAuthFailure(e, collection, authRecord.Id, authRecord)
 		return "", e.BadRequestError("Invalid MFA session.", nil)
 	}
 
@@ -272,16 +332,26 @@ func EnrichRecords(e *core.RequestEvent, records []*core.Record, defaultExpands
 		return err
 	}
 
-	return triggerRecordEnrichHooks(e.App, info, records, func() error {
+	return enrichRecords(e.App, info, records, defaultExpands...)
+}
+
+// enrichRecords is similar to [EnrichRecords] but operates with an already
+// resolved request info (eg. for background tasks that outlive the request).
+func enrichRecords(app core.App, info *core.RequestInfo, records []*core.Record, defaultExpands ...string) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	return triggerRecordEnrichHooks(app, info, records, func() error {
 		expands := defaultExpands
 		if param := info.Query[expandQueryParam]; param != "" {
 			expands = append(expands, strings.Split(param, ",")...)
 		}
 
-		err := defaultEnrichRecords(e.App, info, records, expands...)
+		err := defaultEnrichRecords(app, info, records, expands...)
 		if err != nil {
 			// only log because it is not critical
-			e.App.Logger().Warn("failed to apply default enriching", "error", err)
+			app.Logger().Warn("failed to apply default enriching", "error", err)
 		}
 
 		return nil
@@ -374,6 +444,10 @@ func expandFetch(app core.App, originalRequestInfo *core.RequestInfo) core.Expan
 
 	return func(relCollection *core.Collection, relIds []string) ([]*core.Record, error) {
 		records, findErr := app.FindRecordsByIds(relCollection.Id, relIds, func(q *dbx.SelectQuery) error {
+			if requestInfoPtr.APIKey != nil && !requestInfoPtr.APIKey.HasScope(relCollection, core.APIKeyActionView) {
+				return fmt.Errorf("the API key is not allowed to view collection %q records", relCollection.Name)
+			}
+
 			if requestInfoPtr.Auth != nil && requestInfoPtr.Auth.IsSuperuser() {
 				return nil // superusers can access everything
 			}
@@ -579,7 +653,9 @@ func execAfterSuccessTx(checkTx bool, app core.App, fn func() error) error {
 
 const maxAuthOrigins = 5
 
-func authAlert(e *core.RequestEvent, authRecord *core.Record) error {
+// saveAuthOrigin creates or updates the auth origin of the current request
+// and optionally sends an email alert if the origin is new.
+func saveAuthOrigin(e *core.RequestEvent, authRecord *core.Record, sendAlert bool) (*core.AuthOrigin, error) {
 	// generate fingerprint
 	// ---
 	ip := e.RealIP()
@@ -595,7 +671,7 @@ func authAlert(e *core.RequestEvent, authRecord *core.Record) error {
 
 	origins, err := e.App.FindAllAuthOriginsByRecord(authRecord)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	isFirstLogin := len(origins) == 0
@@ -622,7 +698,7 @@ func authAlert(e *core.RequestEvent, authRecord *core.Record) error {
 	//       mailer and net/smtp package.
 	//       The goroutine technically "leaks" but we assume that the OS will
 	//       terminate the connection after some time (usually after 3-4 mins).
-	if !isFirstLogin && currentOrigin.IsNew() && authRecord.Email() != "" {
+	if sendAlert && !isFirstLogin && currentOrigin.IsNew() && authRecord.Email() != "" {
 		mailSent := make(chan error, 1)
 
 		timer := time.AfterFunc(15*time.Second, func() {
@@ -637,7 +713,7 @@ func authAlert(e *core.RequestEvent, authRecord *core.Record) error {
 
 		err = <-mailSent
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -645,6 +721,19 @@ func authAlert(e *core.RequestEvent, authRecord *core.Record) error {
 	// (pop the last used ones; it is not executed in a transaction to avoid unnecessary locks)
 	if currentOrigin.IsNew() && len(origins) >= maxAuthOrigins {
 		for i := len(origins) - 1; i >= maxAuthOrigins-1; i-- {
+			// detach the origin sessions so that the prune doesn't revoke them
+			// (the origin sessions are revoked only on explicit origin delete)
+			_, err := e.App.NonconcurrentDB().Update(
+				core.CollectionNameAuthSessions,
+				dbx.Params{"originRef": ""},
+				dbx.HashExp{"originRef": origins[i].Id},
+			).Execute()
+			if err != nil {
+				// treat as non-critical error, just log for now
+				e.App.Logger().Warn("Failed to detach the old AuthOrigin sessions", "error", err, "authOriginId", origins[i].Id)
+				continue
+			}
+
 			if err := e.App.Delete(origins[i]); err != nil {
 				// treat as non-critical error, just log for now
 				e.App.Logger().Warn("Failed to delete old AuthOrigin record", "error", err, "authOriginId", origins[i].Id)
@@ -653,5 +742,9 @@ func authAlert(e *core.RequestEvent, authRecord *core.Record) error {
 	}
 
 	// create/update the origin fingerprint
-	return e.App.Save(currentOrigin)
+	if err := e.App.Save(currentOrigin); err != nil {
+		return nil, err
+	}
+
+	return currentOrigin, nil
 }
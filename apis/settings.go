@@ -17,6 +17,7 @@ func bindSettingsApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	subGroup.POST("/test/s3", settingsTestS3)
 	subGroup.POST("/test/email", settingsTestEmail)
 	subGroup.POST("/apple/generate-client-secret", settingsGenerateAppleClientSecret)
+	subGroup.POST("/jwt/rotate", settingsRotateJWTKeys)
 }
 
 func settingsList(e *core.RequestEvent) error {
@@ -141,3 +142,21 @@ func settingsGenerateAppleClientSecret(e *core.RequestEvent) error {
 		"secret": secret,
 	})
 }
+
+func settingsRotateJWTKeys(e *core.RequestEvent) error {
+	if !e.App.Settings().JWT.IsAsymmetric() {
+		return e.BadRequestError("The JWT settings algorithm must be asymmetric in order to rotate the signing keys.", nil)
+	}
+
+	key, err := e.App.RotateJWTKeys()
+	if err != nil {
+		return e.BadRequestError("Failed to rotate the JWT signing keys.", err)
+	}
+
+	jwk, err := key.JWK()
+	if err != nil {
+		return e.InternalServerError("Failed to export the new JWT signing key.", err)
+	}
+
+	return e.JSON(http.StatusOK, jwk)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/spf13/cobra"
+)
+
+// NewImportCommand creates and returns new command for importing
+// a single CSV, JSON, NDJSON, XLSX or ZIP bundle file into a collection.
+//
+// The import options could be loaded from a saved collection import profile
+// so that the same import could be repeated without the dashboard (eg. by a cron job).
+func NewImportCommand(app core.App) *cobra.Command {
+	var options apis.ImportJobOptions
+	var rawMapping string
+
+	command := &cobra.Command{
+		Use:          "import file",
+		Example:      "import --profile monthly_sales sales.csv",
+		Short:        "Imports a data file into a collection",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(command *cobra.Command, args []string) error {
+			if rawMapping != "" {
+				if err := json.Unmarshal([]byte(rawMapping), &options.Mapping); err != nil {
+					return fmt.Errorf("invalid mapping JSON: %w", err)
+				}
+			}
+
+			if options.Collection == "" {
+				if options.Profile == "" {
+					return errors.New("missing --collection or --profile flag")
+				}
+
+				collection, _, err := apis.FindImportProfileByName(app, options.Profile)
+				if err != nil {
+					return fmt.Errorf("failed to find import profile %q: %w", options.Profile, err)
+				}
+				options.Collection = collection.Name
+			}
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+
+			info, err := file.Stat()
+			if err != nil {
+				file.Close()
+				return err
+			}
+
+			// the file is closed by the job
+			job, err := apis.StartImportJob(app, file, info.Size(), options)
+			if err != nil {
+				return err
+			}
+
+			ctx := command.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			jobId := job.Id
+
+			job, err = apis.WaitImportJob(ctx, app, jobId)
+			if err != nil {
+				if ctx.Err() == nil || !errors.Is(err, ctx.Err()) {
+					return err
+				}
+
+				job, err = apis.CancelImportJob(app, jobId)
+				if err != nil {
+					return err
+				}
+			}
+
+			if job.Status == "" {
+				return fmt.Errorf("missing import job %s status", jobId)
+			}
+
+			for _, rowErr := range job.Errors {
+				color.Red("Row %d: %s", rowErr.Row, rowErr.Message)
+			}
+
+			fmt.Printf(
+				"Processed %d rows (created: %d, updated: %d, skipped: %d, failed: %d)\n",
+				job.TotalRows,
+				job.CreatedCount,
+				job.UpdatedCount,
+				job.SkippedCount,
+				job.FailureCount,
+			)
+
+			switch {
+			case job.Status == apis.ImportJobStatusFailed:
+				return fmt.Errorf("import failed: %s", job.Error)
+			case job.Status == apis.ImportJobStatusCanceled:
+				return errors.New("import canceled")
+			case job.FailureCount > 0:
+				return fmt.Errorf("failed to import %d rows", job.FailureCount)
+			}
+
+			if job.ValidateOnly {
+				color.Green("Successfully validated %q into %q!", args[0], job.Collection)
+			} else {
+				color.Green("Successfully imported %q into %q!", args[0], job.Collection)
+			}
+
+			return nil
+		},
+	}
+
+	command.Flags().StringVar(&options.Profile, "profile", "", "the name of a saved collection import profile")
+	command.Flags().StringVar(&options.Collection, "collection", "", "the target collection name or id (optional with --profile)")
+	command.Flags().StringVar(&rawMapping, "mapping", "", `JSON encoded source column -> target field mapping (eg. '{"Name":"name"}')`)
+	command.Flags().StringVar(&options.Format, "format", "", "csv, json, ndjson, xlsx or zip (detected from the file content if empty)")
+	command.Flags().StringVar(&options.Delimiter, "delimiter", "", "the CSV delimiter")
+	command.Flags().StringVar(&options.Mode, "mode", "", "insert, update or upsert")
+	command.Flags().StringVar(&options.KeyField, "key-field", "", "the field used to match the existing records")
+	command.Flags().IntVar(&options.BatchSize, "batch-size", 0, "the number of rows saved in a single transaction")
+	command.Flags().BoolVar(&options.Atomic, "atomic", false, "import all rows in a single transaction")
+	command.Flags().BoolVar(&options.ValidateOnly, "validate-only", false, "validate the rows without saving them")
+
+	return command
+}
@@ -0,0 +1,129 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/cmd"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestImportCommand(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection, err := app.FindCollectionByNameOrId("demo2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = apis.SaveImportProfile(app, collection, apis.ImportProfile{
+		Name:      "monthly_sales",
+		Delimiter: ";",
+		Mapping:   map[string]string{"Name": "title", "Active": "active"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	validFile := filepath.Join(dir, "valid.csv")
+	if err := os.WriteFile(validFile, []byte("Name;Active\nCLI import 1;true\nCLI import 2;false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	invalidFile := filepath.Join(dir, "invalid.csv")
+	if err := os.WriteFile(invalidFile, []byte("Name;Active\nCLI import 3;true\nx;true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name          string
+		args          []string
+		expectError   bool
+		expectRecords []string
+	}{
+		{
+			"missing file argument",
+			[]string{"--profile", "monthly_sales"},
+			true,
+			nil,
+		},
+		{
+			"missing collection and profile",
+			[]string{validFile},
+			true,
+			nil,
+		},
+		{
+			"missing profile",
+			[]string{"--profile", "missing", validFile},
+			true,
+			nil,
+		},
+		{
+			"missing mapping",
+			[]string{"--collection", "demo2", validFile},
+			true,
+			nil,
+		},
+		{
+			"missing file",
+			[]string{"--profile", "monthly_sales", filepath.Join(dir, "missing.csv")},
+			true,
+			nil,
+		},
+		{
+			"validate only",
+			[]string{"--profile", "monthly_sales", "--validate-only", validFile},
+			false,
+			nil,
+		},
+		{
+			"import with profile",
+			[]string{"--profile", "monthly_sales", validFile},
+			false,
+			[]string{"CLI import 1", "CLI import 2"},
+		},
+		{
+			"import with profile and explicit collection and mapping",
+			[]string{"--collection", "demo2", "--profile", "monthly_sales", "--mapping", `{"Name":"title"}`, invalidFile},
+			true, // the "x" row fails the min title length
+			[]string{"CLI import 3"},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			command := cmd.NewImportCommand(app)
+			command.SetArgs(s.args)
+
+			err := command.Execute()
+
+			hasErr := err != nil
+			if s.expectError != hasErr {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			for _, title := range s.expectRecords {
+				if _, err := app.FindFirstRecordByData("demo2", "title", title); err != nil {
+					t.Errorf("Expected record with title %q to be imported: %v", title, err)
+				}
+			}
+		})
+	}
+
+	// the validate only run shouldn't persist any records
+	total, err := app.CountRecords("demo2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 6 {
+		t.Fatalf("Expected 6 demo2 records, got %d", total)
+	}
+}
@@ -0,0 +1,293 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNameAPIKeys = "_apiKeys"
+
+// APIKeyPrefix is the prefix of all plain API key values
+// (used to distinguish them from the regular JWT auth tokens).
+const APIKeyPrefix = "pbk_"
+
+// List of the supported API key scope actions.
+const (
+	APIKeyActionList   = "list"
+	APIKeyActionView   = "view"
+	APIKeyActionCreate = "create"
+	APIKeyActionUpdate = "update"
+	APIKeyActionDelete = "delete"
+	APIKeyActionAll    = "*"
+)
+
+// APIKeyActions is a list with all supported API key scope actions.
+var APIKeyActions = []string{
+	APIKeyActionList,
+	APIKeyActionView,
+	APIKeyActionCreate,
+	APIKeyActionUpdate,
+	APIKeyActionDelete,
+	APIKeyActionAll,
+}
+
+var (
+	_ Model        = (*APIKey)(nil)
+	_ PreValidator = (*APIKey)(nil)
+	_ RecordProxy  = (*APIKey)(nil)
+)
+
+// APIKey defines a Record proxy for working with the apiKeys collection.
+//
+// The API keys are long-lived opaque auth credentials of an auth record
+// restricted to a list of "collection:action" scopes.
+type APIKey struct {
+	*Record
+}
+
+// NewAPIKey instantiates and returns a new blank *APIKey model.
+//
+// Example usage:
+//
+//	apiKey := core.NewAPIKey(app)
+//	apiKey.SetCollectionRef(user.Collection().Id)
+//	apiKey.SetRecordRef(user.Id)
+//	apiKey.SetName("backups")
+//	apiKey.SetScopes([]string{"posts:list", "posts:view"})
+//	plain := apiKey.RefreshValue()
+//	app.Save(apiKey)
+func NewAPIKey(app App) *APIKey {
+	m := &APIKey{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameAPIKeys)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on APIKey.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *APIKey) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameAPIKeys {
+		return errors.New("missing or invalid APIKey ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *APIKey) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *APIKey) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *APIKey) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *APIKey) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *APIKey) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *APIKey) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// Name returns the "name" record field value.
+func (m *APIKey) Name() string {
+	return m.GetString("name")
+}
+
+// SetName updates the "name" record field value.
+func (m *APIKey) SetName(name string) {
+	m.Set("name", name)
+}
+
+// KeyHash returns the "keyHash" record field value.
+func (m *APIKey) KeyHash() string {
+	return m.GetString("keyHash")
+}
+
+// Hint returns the "hint" record field value
+// (the first few characters of the plain key used for its identification).
+func (m *APIKey) Hint() string {
+	return m.GetString("hint")
+}
+
+// RefreshValue generates a new random API key, updates the "keyHash"
+// and "hint" record fields and returns the plain key.
+//
+// The plain key is not stored and can't be recovered later.
+func (m *APIKey) RefreshValue() string {
+	plain := APIKeyPrefix + security.RandomString(40)
+
+	m.Set("keyHash", HashAPIKey(plain))
+	m.Set("hint", plain[:len(APIKeyPrefix)+6])
+
+	return plain
+}
+
+// Scopes returns the "scopes" record field value
+// (list of "collection:action" entries).
+func (m *APIKey) Scopes() []string {
+	return m.GetStringSlice("scopes")
+}
+
+// SetScopes updates the "scopes" record field value.
+func (m *APIKey) SetScopes(scopes []string) {
+	m.Set("scopes", scopes)
+}
+
+// HasScope checks whether the API key allows the specified action for the provided collection.
+//
+// The collection part of the scope could be the collection name or id, or "*" for all collections.
+// The action part could be one of [APIKeyActions] ("*" for all actions).
+func (m *APIKey) HasScope(collection *Collection, action string) bool {
+	for _, scope := range m.Scopes() {
+		scopeCollection, scopeAction, ok := ParseAPIKeyScope(scope)
+		if !ok {
+			continue
+		}
+
+		if scopeCollection != "*" && scopeCollection != collection.Name && scopeCollection != collection.Id {
+			continue
+		}
+
+		if scopeAction == APIKeyActionAll || scopeAction == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LastUsed returns the "lastUsed" record field value.
+func (m *APIKey) LastUsed() types.DateTime {
+	return m.GetDateTime("lastUsed")
+}
+
+// SetLastUsed updates the "lastUsed" record field value.
+func (m *APIKey) SetLastUsed(date types.DateTime) {
+	m.Set("lastUsed", date)
+}
+
+// LastUsedIP returns the "lastUsedIP" record field value.
+func (m *APIKey) LastUsedIP() string {
+	return m.GetString("lastUsedIP")
+}
+
+// SetLastUsedIP updates the "lastUsedIP" record field value.
+func (m *APIKey) SetLastUsedIP(ip string) {
+	m.Set("lastUsedIP", ip)
+}
+
+// Expires returns the "expires" record field value
+// (zero value means that the API key never expires).
+func (m *APIKey) Expires() types.DateTime {
+	return m.GetDateTime("expires")
+}
+
+// SetExpires updates the "expires" record field value.
+func (m *APIKey) SetExpires(date types.DateTime) {
+	m.Set("expires", date)
+}
+
+// Created returns the "created" record field value.
+func (m *APIKey) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *APIKey) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// HasExpired checks if the API key has reached its expiration date
+// (API keys without expiration date never expire).
+func (m *APIKey) HasExpired() bool {
+	expires := m.Expires()
+	if expires.IsZero() {
+		return false
+	}
+
+	return !expires.Time().After(time.Now())
+}
+
+// HashAPIKey returns the hash of a plain API key as stored in the "keyHash" field.
+func HashAPIKey(plain string) string {
+	return security.SHA256(plain)
+}
+
+// ParseAPIKeyScope splits a single "collection:action" API key scope
+// and checks whether the action is one of the supported [APIKeyActions].
+func ParseAPIKeyScope(scope string) (string, string, bool) {
+	collection, action, ok := strings.Cut(scope, ":")
+	if !ok || collection == "" || !slices.Contains(APIKeyActions, action) {
+		return "", "", false
+	}
+
+	return collection, action, true
+}
+
+func (app *BaseApp) registerAPIKeyHooks() {
+	recordRefHooks[*APIKey](app, CollectionNameAPIKeys, CollectionTypeAuth)
+
+	// run on every hour to cleanup the expired API keys
+	app.Cron().Add("__pbAPIKeysCleanup__", "0 * * * *", func() {
+		if err := app.DeleteExpiredAPIKeys(); err != nil {
+			app.Logger().Warn("Failed to delete expired API keys", "error", err)
+		}
+	})
+
+	// revoke all API keys on password or tokenKey change
+	app.OnRecordUpdate().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			err := e.Next()
+			if err != nil || !e.Record.Collection().IsAuth() {
+				return err
+			}
+
+			oldTokenKey := e.Record.Original().TokenKey()
+			newTokenKey := e.Record.TokenKey()
+			if oldTokenKey != newTokenKey {
+				err = e.App.DeleteAllAPIKeysByRecord(e.Record)
+				if err != nil {
+					e.App.Logger().Warn(
+						"Failed to delete all previous API keys",
+						"error", err,
+						"recordId", e.Record.Id,
+						"collectionId", e.Record.Collection().Id,
+					)
+				}
+			}
+
+			return nil
+		},
+		Priority: 99,
+	})
+}
@@ -0,0 +1,222 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAPIKey(app)
+
+	if m.Collection().Name != core.CollectionNameAPIKeys {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameAPIKeys, m.Collection().Name)
+	}
+}
+
+func TestAPIKeyGettersAndSetters(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAPIKey(app)
+	m.SetCollectionRef("test_collection")
+	m.SetRecordRef("test_record")
+	m.SetName("test_name")
+	m.SetScopes([]string{"a:list", "b:*"})
+	m.SetLastUsed(types.NowDateTime())
+	m.SetLastUsedIP("127.0.0.1")
+	m.SetExpires(types.NowDateTime())
+	m.SetRaw("created", types.NowDateTime())
+	m.SetRaw("updated", types.NowDateTime())
+
+	if v := m.CollectionRef(); v != "test_collection" {
+		t.Fatalf("Expected collectionRef %q, got %q", "test_collection", v)
+	}
+	if v := m.RecordRef(); v != "test_record" {
+		t.Fatalf("Expected recordRef %q, got %q", "test_record", v)
+	}
+	if v := m.Name(); v != "test_name" {
+		t.Fatalf("Expected name %q, got %q", "test_name", v)
+	}
+	if v := m.Scopes(); len(v) != 2 || v[0] != "a:list" || v[1] != "b:*" {
+		t.Fatalf("Expected scopes %v, got %v", []string{"a:list", "b:*"}, v)
+	}
+	if v := m.LastUsedIP(); v != "127.0.0.1" {
+		t.Fatalf("Expected lastUsedIP %q, got %q", "127.0.0.1", v)
+	}
+	if v := m.LastUsed().String(); v != m.GetDateTime("lastUsed").String() || v == "" {
+		t.Fatalf("Expected lastUsed %q, got %q", m.GetDateTime("lastUsed").String(), v)
+	}
+	if v := m.Expires().String(); v != m.GetDateTime("expires").String() || v == "" {
+		t.Fatalf("Expected expires %q, got %q", m.GetDateTime("expires").String(), v)
+	}
+	if v := m.Created().String(); v != m.GetDateTime("created").String() || v == "" {
+		t.Fatalf("Expected created %q, got %q", m.GetDateTime("created").String(), v)
+	}
+	if v := m.Updated().String(); v != m.GetDateTime("updated").String() || v == "" {
+		t.Fatalf("Expected updated %q, got %q", m.GetDateTime("updated").String(), v)
+	}
+
+	// key value
+	plain := m.RefreshValue()
+	if !strings.HasPrefix(plain, core.APIKeyPrefix) || len(plain) != len(core.APIKeyPrefix)+40 {
+		t.Fatalf("Expected %q prefixed key with 40 random chars, got %q", core.APIKeyPrefix, plain)
+	}
+	if v := m.KeyHash(); v != core.HashAPIKey(plain) || v == plain {
+		t.Fatalf("Expected keyHash %q, got %q", core.HashAPIKey(plain), v)
+	}
+	if v := m.Hint(); !strings.HasPrefix(plain, v) || len(v) != len(core.APIKeyPrefix)+6 {
+		t.Fatalf("Expected hint to be the key prefix, got %q", v)
+	}
+	if plain2 := m.RefreshValue(); plain2 == plain {
+		t.Fatal("Expected a new random key value")
+	}
+}
+
+func TestAPIKeyHasExpired(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	now := types.NowDateTime()
+
+	scenarios := []struct {
+		expires  types.DateTime
+		expected bool
+	}{
+		{types.DateTime{}, false}, // no expiration
+		{now.Add(-1 * time.Minute), true},
+		{now.Add(1 * time.Minute), false},
+	}
+
+	for i, s := range scenarios {
+		m := core.NewAPIKey(app)
+		m.SetExpires(s.expires)
+
+		if v := m.HasExpired(); v != s.expected {
+			t.Errorf("[%d] Expected %v, got %v", i, s.expected, v)
+		}
+	}
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	demo1, err := app.FindCollectionByNameOrId("demo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name     string
+		scopes   []string
+		action   string
+		expected bool
+	}{
+		{"no scopes", nil, core.APIKeyActionList, false},
+		{"invalid scope", []string{"demo1"}, core.APIKeyActionList, false},
+		{"unknown action", []string{"demo1:read"}, core.APIKeyActionList, false},
+		{"different collection", []string{"demo2:list"}, core.APIKeyActionList, false},
+		{"different action", []string{"demo1:view"}, core.APIKeyActionList, false},
+		{"collection name match", []string{"demo2:*", "demo1:list"}, core.APIKeyActionList, true},
+		{"collection id match", []string{demo1.Id + ":list"}, core.APIKeyActionList, true},
+		{"action wildcard", []string{"demo1:*"}, core.APIKeyActionDelete, true},
+		{"collection wildcard", []string{"*:view"}, core.APIKeyActionView, true},
+		{"collection wildcard with different action", []string{"*:view"}, core.APIKeyActionCreate, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			m := core.NewAPIKey(app)
+			m.SetScopes(s.scopes)
+
+			if v := m.HasScope(demo1, s.action); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestParseAPIKeyScope(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		scope              string
+		expectedCollection string
+		expectedAction     string
+		expectedOk         bool
+	}{
+		{"", "", "", false},
+		{"demo1", "", "", false},
+		{":list", "", "", false},
+		{"demo1:", "", "", false},
+		{"demo1:invalid", "", "", false},
+		{"demo1:list", "demo1", "list", true},
+		{"*:*", "*", "*", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.scope, func(t *testing.T) {
+			collection, action, ok := core.ParseAPIKeyScope(s.scope)
+
+			if collection != s.expectedCollection || action != s.expectedAction || ok != s.expectedOk {
+				t.Fatalf("Expected (%q, %q, %v), got (%q, %q, %v)", s.expectedCollection, s.expectedAction, s.expectedOk, collection, action, ok)
+			}
+		})
+	}
+}
+
+func TestAPIKeyPasswordChangeRevoke(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKey := core.NewAPIKey(app)
+	apiKey.SetCollectionRef(user.Collection().Id)
+	apiKey.SetRecordRef(user.Id)
+	apiKey.SetName("test")
+	apiKey.SetScopes([]string{"*:*"})
+	apiKey.RefreshValue()
+	if err := app.Save(apiKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// non-password change
+	user.Set("name", "new_name")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := app.FindAllAPIKeysByRecord(user); len(keys) != 1 {
+		t.Fatalf("Expected the API key to remain, got %d keys", len(keys))
+	}
+
+	// password change
+	user.SetPassword("1234567890_new")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := app.FindAllAPIKeysByRecord(user); len(keys) != 0 {
+		t.Fatalf("Expected the API key to be revoked, got %d keys", len(keys))
+	}
+}
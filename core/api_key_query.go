@@ -0,0 +1,87 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// FindAPIKeyByValue returns the APIKey model matching the provided plain API key.
+//
+// Note that the returned API key could be expired.
+func (app *BaseApp) FindAPIKeyByValue(plain string) (*APIKey, error) {
+	result := &APIKey{}
+
+	err := app.RecordQuery(CollectionNameAPIKeys).
+		AndWhere(dbx.HashExp{"keyHash": HashAPIKey(plain)}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAllAPIKeysByRecord returns all APIKey models linked to the provided auth record.
+func (app *BaseApp) FindAllAPIKeysByRecord(authRecord *Record) ([]*APIKey, error) {
+	result := []*APIKey{}
+
+	err := app.RecordQuery(CollectionNameAPIKeys).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteAllAPIKeysByRecord deletes all APIKey models associated with the provided record.
+//
+// Returns a combined error with the failed deletes.
+func (app *BaseApp) DeleteAllAPIKeysByRecord(authRecord *Record) error {
+	models, err := app.FindAllAPIKeysByRecord(authRecord)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, m := range models {
+		if err := app.Delete(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// DeleteExpiredAPIKeys deletes the API keys that have reached their expiration date.
+func (app *BaseApp) DeleteExpiredAPIKeys() error {
+	items := []*Record{}
+
+	err := app.RecordQuery(CollectionNameAPIKeys).
+		AndWhere(dbx.NewExp("[[expires]] != '' AND [[expires]] <= {:now}", dbx.Params{"now": types.NowDateTime()})).
+		All(&items)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := app.Delete(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestFindAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plainKeys := map[string]string{}
+	for name, expires := range map[string]types.DateTime{
+		"never":   {},
+		"active":  types.NowDateTime().Add(time.Hour),
+		"expired": types.NowDateTime().Add(-time.Hour),
+	} {
+		m := core.NewAPIKey(app)
+		m.SetCollectionRef(user1.Collection().Id)
+		m.SetRecordRef(user1.Id)
+		m.SetName(name)
+		m.SetScopes([]string{"*:*"})
+		m.SetExpires(expires)
+		plainKeys[name] = m.RefreshValue()
+		if err := app.Save(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err := app.FindAllAPIKeysByRecord(user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("Expected 3 user1 keys, got %d", len(keys))
+	}
+
+	if keys, _ := app.FindAllAPIKeysByRecord(user2); len(keys) != 0 {
+		t.Fatalf("Expected 0 user2 keys, got %d", len(keys))
+	}
+
+	// find by value
+	for _, plain := range []string{"", "missing", core.APIKeyPrefix + "missing"} {
+		if _, err := app.FindAPIKeyByValue(plain); err == nil {
+			t.Fatalf("Expected no key for %q", plain)
+		}
+	}
+
+	key, err := app.FindAPIKeyByValue(plainKeys["active"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Name() != "active" {
+		t.Fatalf("Expected the %q key, got %q", "active", key.Name())
+	}
+
+	// expired keys cleanup
+	if err := app.DeleteExpiredAPIKeys(); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ = app.FindAllAPIKeysByRecord(user1)
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 remaining keys, got %d", len(keys))
+	}
+	for _, k := range keys {
+		if k.Name() == "expired" {
+			t.Fatal("Expected the expired key to be deleted")
+		}
+	}
+
+	// delete all
+	if err := app.DeleteAllAPIKeysByRecord(user1); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := app.FindAllAPIKeysByRecord(user1); len(keys) != 0 {
+		t.Fatalf("Expected 0 user1 keys, got %d", len(keys))
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/pocketbase/pocketbase/tools/mailer"
 	"github.com/pocketbase/pocketbase/tools/store"
 	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	"github.com/pocketbase/pocketbase/tools/tracing"
 )
 
 // App defines the main PocketBase app interface.
@@ -85,6 +86,12 @@ type App interface {
 	// SubscriptionsBroker returns the app realtime subscriptions broker instance.
 	SubscriptionsBroker() *subscriptions.Broker
 
+	// Tracer returns the app spans tracer.
+	//
+	// It could be nil if tracing is not enabled
+	// (all tracer methods are safe to be called on a nil instance).
+	Tracer() *tracing.Tracer
+
 	// NewMailClient creates and returns a new SMTP or Sendmail client
 	// based on the current app settings.
 	NewMailClient() mailer.Mailer
@@ -530,6 +537,82 @@ type App interface {
 
 	// ---------------------------------------------------------------
 
+	// FindAllWebAuthnCredentialsByRecord returns all WebAuthnCredential models
+	// linked to the provided auth record.
+	FindAllWebAuthnCredentialsByRecord(authRecord *Record) ([]*WebAuthnCredential, error)
+
+	// FindWebAuthnCredentialByCredentialId returns a single WebAuthnCredential model
+	// by its auth collection and base64url encoded credential id.
+	FindWebAuthnCredentialByCredentialId(collection *Collection, credentialId string) (*WebAuthnCredential, error)
+
+	// DeleteAllWebAuthnCredentialsByRecord deletes all WebAuthnCredential models associated with the provided record.
+	//
+	// Returns a combined error with the failed deletes.
+	DeleteAllWebAuthnCredentialsByRecord(authRecord *Record) error
+
+	// ---------------------------------------------------------------
+
+	// FindTOTPByRecord returns the TOTP model linked to the provided auth record
+	// (aka. the record TOTP enrollment).
+	FindTOTPByRecord(authRecord *Record) (*TOTP, error)
+
+	// ---------------------------------------------------------------
+
+	// FindAllJWTKeys returns all JWTKey models (in created DESC order).
+	FindAllJWTKeys() ([]*JWTKey, error)
+
+	// FindJWTKeyById returns a single JWTKey model by its id (aka. the JWT "kid").
+	FindJWTKeyById(id string) (*JWTKey, error)
+
+	// FindActiveJWTKey returns the latest non-retired JWTKey model
+	// (aka. the current auth tokens signing key).
+	FindActiveJWTKey() (*JWTKey, error)
+
+	// RotateJWTKeys generates a new signing key with the configured
+	// asymmetric JWT settings algorithm and retires the previous active keys.
+	RotateJWTKeys() (*JWTKey, error)
+
+	// RefreshJWTKeys ensures that there is an active signing key
+	// matching the current JWT settings, rotates it if it is older
+	// than the configured rotation period and deletes the expired keys.
+	RefreshJWTKeys() error
+
+	// DeleteExpiredJWTKeys deletes the retired keys that have exceeded
+	// the configured settings overlap duration.
+	DeleteExpiredJWTKeys() error
+
+	// ---------------------------------------------------------------
+
+	// FindOIDCClient returns the OIDC client with the specified id
+	// registered for the provided auth collection.
+	FindOIDCClient(authCollection *Collection, clientId string) (*OIDCClient, error)
+
+	// FindAllOIDCConsentsByRecord returns all OIDCConsent models linked to the provided auth record.
+	FindAllOIDCConsentsByRecord(authRecord *Record) ([]*OIDCConsent, error)
+
+	// FindOIDCConsent returns the OIDCConsent model of the provided
+	// auth record for the specified OIDC client.
+	FindOIDCConsent(authRecord *Record, clientId string) (*OIDCConsent, error)
+
+	// FindOIDCTokenByValue returns the OIDCToken model of the specified kind
+	// matching the provided plain token value.
+	//
+	// Note that the returned token could be expired.
+	FindOIDCTokenByValue(kind string, plain string) (*OIDCToken, error)
+
+	// FindAllOIDCTokensByRecord returns all OIDCToken models linked to the provided auth record.
+	FindAllOIDCTokensByRecord(authRecord *Record) ([]*OIDCToken, error)
+
+	// DeleteAllOIDCTokensByRecord deletes all OIDCToken models associated with the provided record.
+	//
+	// Returns a combined error with the failed deletes.
+	DeleteAllOIDCTokensByRecord(authRecord *Record) error
+
+	// DeleteExpiredOIDCTokens deletes the expired OIDC authorization codes and refresh tokens.
+	DeleteExpiredOIDCTokens() error
+
+	// ---------------------------------------------------------------
+
 	// FindAllAuthOriginsByRecord returns all AuthOrigin models linked to the provided auth record (in DESC order).
 	FindAllAuthOriginsByRecord(authRecord *Record) ([]*AuthOrigin, error)
 
@@ -550,6 +633,83 @@ type App interface {
 
 	// ---------------------------------------------------------------
 
+	// FindAuthSessionByRefreshToken returns the AuthSession model
+	// referenced by the provided plain refresh token.
+	//
+	// Note that the returned session could be expired and the token is
+	// not required to be the current session refresh token.
+	FindAuthSessionByRefreshToken(refreshToken string) (*AuthSession, error)
+
+	// FindAllAuthSessionsByRecord returns all AuthSession models linked to the provided auth record.
+	FindAllAuthSessionsByRecord(authRecord *Record) ([]*AuthSession, error)
+
+	// DeleteAllAuthSessionsByRecord deletes all AuthSession models associated with the provided record.
+	//
+	// Returns a combined error with the failed deletes.
+	DeleteAllAuthSessionsByRecord(authRecord *Record) error
+
+	// DeleteExpiredAuthSessions deletes the sessions with expired refresh tokens.
+	DeleteExpiredAuthSessions() error
+
+	// ---------------------------------------------------------------
+
+	// FindAPIKeyByValue returns the APIKey model matching the provided plain API key.
+	//
+	// Note that the returned API key could be expired.
+	FindAPIKeyByValue(plain string) (*APIKey, error)
+
+	// FindAllAPIKeysByRecord returns all APIKey models linked to the provided auth record.
+	FindAllAPIKeysByRecord(authRecord *Record) ([]*APIKey, error)
+
+	// DeleteAllAPIKeysByRecord deletes all APIKey models associated with the provided record.
+	//
+	// Returns a combined error with the failed deletes.
+	DeleteAllAPIKeysByRecord(authRecord *Record) error
+
+	// DeleteExpiredAPIKeys deletes the API keys that have reached their expiration date.
+	DeleteExpiredAPIKeys() error
+
+	// ---------------------------------------------------------------
+
+	// FindAuthLockout returns the AuthLockout model of the specified
+	// collection, lockout type and subject.
+	FindAuthLockout(collectionId string, lockoutType string, subject string) (*AuthLockout, error)
+
+	// FindAllAuthLockoutsByCollection returns all AuthLockout models
+	// linked to the provided auth collection (the last failed first).
+	FindAllAuthLockoutsByCollection(collection *Collection) ([]*AuthLockout, error)
+
+	// DeleteStaleAuthLockouts deletes the unlocked auth lockouts whose
+	// last failure is older than their collection lockout MaxDuration.
+	//
+	// The lockouts of collections with disabled lockout option are always deleted.
+	DeleteStaleAuthLockouts() error
+
+	// ---------------------------------------------------------------
+
+	// FindAllPasswordHistoryByRecord returns the PasswordHistory models
+	// linked to the provided auth record (the newest first).
+	//
+	// Set limit to 0 or negative value to return all entries.
+	FindAllPasswordHistoryByRecord(authRecord *Record, limit int) ([]*PasswordHistory, error)
+
+	// FindLatestPasswordHistoryByRecord returns the newest PasswordHistory
+	// model linked to the provided auth record (aka. its last password change).
+	FindLatestPasswordHistoryByRecord(authRecord *Record) (*PasswordHistory, error)
+
+	// ---------------------------------------------------------------
+
+	// FindAuthChallenge returns the AuthChallenge model of the specified
+	// collection, kind and plain challenge value.
+	//
+	// Note that the returned challenge could be expired.
+	FindAuthChallenge(collectionId string, kind string, plain string) (*AuthChallenge, error)
+
+	// DeleteExpiredAuthChallenges deletes the auth challenges that have reached their expiration date.
+	DeleteExpiredAuthChallenges() error
+
+	// ---------------------------------------------------------------
+
 	// RecordQuery returns a new Record select query from a collection model, id or name.
 	//
 	// In case a collection id or name is provided and that collection doesn't
@@ -647,6 +807,30 @@ type App interface {
 	// Returns an error if the JWT is invalid, expired or not associated to an auth collection record.
 	FindAuthRecordByToken(token string, validTypes ...string) (*Record, error)
 
+	// NewRecordAuthToken generates and returns a new record authentication token
+	// signed according to the app JWT settings.
+	NewRecordAuthToken(authRecord *Record) (string, error)
+
+	// NewRecordStaticAuthToken generates and returns a new static (non-refreshable)
+	// record authentication token signed according to the app JWT settings.
+	//
+	// Zero or negative duration will fallback to the duration from the auth collection settings.
+	NewRecordStaticAuthToken(authRecord *Record, duration time.Duration) (string, error)
+
+	// NewRecordSessionAuthToken generates and returns a new short-lived
+	// (non-refreshable) session access token with a "sessionId" claim
+	// signed according to the app JWT settings.
+	NewRecordSessionAuthToken(authRecord *Record, sessionId string) (string, error)
+
+	// NewRecordOIDCAccessToken generates and returns a new OIDC access token
+	// for the provided auth record (aka. a static auth token with
+	// additional "client_id" and "scope" claims).
+	NewRecordOIDCAccessToken(authRecord *Record, clientId string, scopes []string) (string, error)
+
+	// NewRecordOIDCIdToken generates and returns a new OIDC ID token
+	// for the provided auth record signed with the app asymmetric JWT keyset.
+	NewRecordOIDCIdToken(authRecord *Record, clientId string, nonce string, authTime time.Time, scopes []string) (string, error)
+
 	// FindAuthRecordByEmail finds the auth record associated with the provided email.
 	//
 	// Returns an error if it is not an auth collection or the record is not found.
@@ -1262,6 +1446,19 @@ type App interface {
 	// modify the submitted change.
 	OnRealtimeSubscribeRequest() *hook.Hook[*RealtimeSubscribeRequestEvent]
 
+	// OnRealtimePublishRequest hook is triggered when an authenticated
+	// client publishes a message to a custom realtime channel, allowing
+	// you to veto (by returning an error) or enrich the published data.
+	OnRealtimePublishRequest() *hook.Hook[*RealtimePublishRequestEvent]
+
+	// OnRealtimePresenceChange hook is triggered when a client joins,
+	// updates or leaves a realtime channel presence, allowing you to
+	// veto the join (by returning an error) or enrich the member metadata.
+	//
+	// Note that the leave event is triggered also when the client disconnects
+	// or its presence times out, in which case the returned error is only logged.
+	OnRealtimePresenceChange() *hook.Hook[*RealtimePresenceEvent]
+
 	// ---------------------------------------------------------------
 	// Settings API event hooks
 	// ---------------------------------------------------------------
@@ -1436,6 +1633,106 @@ type App interface {
 	// triggered and called only if their event data origin matches the tags.
 	OnRecordAuthWithOTPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithOTPRequestEvent]
 
+	// OnRecordWebAuthnRegisterRequest hook is triggered on each Record
+	// WebAuthn (passkey) registration API request.
+	//
+	// [RecordWebAuthnRegisterRequestEvent.Credential] is the verified
+	// but not yet persisted new credential.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordWebAuthnRegisterRequest(tags ...string) *hook.TaggedHook[*RecordWebAuthnRegisterRequestEvent]
+
+	// OnRecordAuthWithWebAuthnRequest hook is triggered on each Record
+	// auth with WebAuthn (passkey) API request.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordAuthWithWebAuthnRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithWebAuthnRequestEvent]
+
+	// OnRecordAuthWithTOTPRequest hook is triggered on each Record
+	// auth with TOTP (authenticator app) API request.
+	//
+	// [RecordAuthWithTOTPRequestEvent.TOTP] is the record TOTP enrollment
+	// with already updated (but not yet persisted) last used state.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordAuthWithTOTPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithTOTPRequestEvent]
+
+	// OnRecordAuthWithLDAPRequest hook is triggered on each Record
+	// LDAP sign-in/sign-up API request (after the directory bind and before the account linking).
+	//
+	// If [RecordAuthWithLDAPRequestEvent.Record] is not set, then the LDAP
+	// request will try to create a new auth Record from [RecordAuthWithLDAPRequestEvent.CreateData].
+	//
+	// To assign or link a different existing record model you can
+	// change the [RecordAuthWithLDAPRequestEvent.Record] field.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordAuthWithLDAPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithLDAPRequestEvent]
+
+	// OnRecordAuthWithSAMLRequest hook is triggered on each Record
+	// SAML sign-in/sign-up API request (after the assertion verification
+	// and before the account linking).
+	//
+	// If [RecordAuthWithSAMLRequestEvent.Record] is not set, then the SAML
+	// request will try to create a new auth Record from [RecordAuthWithSAMLRequestEvent.CreateData].
+	//
+	// To assign or link a different existing record model you can
+	// change the [RecordAuthWithSAMLRequestEvent.Record] field.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordAuthWithSAMLRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithSAMLRequestEvent]
+
+	// OnRecordOIDCAuthorizeRequest hook is triggered on each OIDC provider
+	// authorization approve or deny API request.
+	//
+	// [RecordOIDCAuthorizeRequestEvent.RedirectURL] is the client
+	// redirect URL that will be returned in the response.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordOIDCAuthorizeRequest(tags ...string) *hook.TaggedHook[*RecordOIDCAuthorizeRequestEvent]
+
+	// OnRecordOIDCTokenRequest hook is triggered on each OIDC provider
+	// token exchange API request (authorization_code or refresh_token grant).
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordOIDCTokenRequest(tags ...string) *hook.TaggedHook[*RecordOIDCTokenRequestEvent]
+
+	// OnRecordAuthWithRefreshTokenRequest hook is triggered on each
+	// session refresh token auth API request (after the refresh token
+	// validation and before the token rotation).
+	//
+	// [RecordAuthWithRefreshTokenRequestEvent.Session] is the auth session
+	// associated with the submitted refresh token.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordAuthWithRefreshTokenRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithRefreshTokenRequestEvent]
+
+	// OnRecordCreateAPIKeyRequest hook is triggered on each API key create API request.
+	//
+	// [RecordCreateAPIKeyRequestEvent.APIKey] is the new API key
+	// that will be persisted after the hook handlers.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordCreateAPIKeyRequest(tags ...string) *hook.TaggedHook[*RecordCreateAPIKeyRequestEvent]
+
 	// ---------------------------------------------------------------
 	// Record CRUD API event hooks
 	// ---------------------------------------------------------------
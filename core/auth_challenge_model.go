@@ -0,0 +1,256 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNameAuthChallenges = "_authChallenges"
+
+// List of the builtin auth challenge kinds.
+const (
+	// AuthChallengeKindWebAuthn is the kind of the issued WebAuthn ceremony challenges.
+	AuthChallengeKindWebAuthn = "webauthn"
+
+	// AuthChallengeKindSAMLState is the kind of the pending SAML
+	// SP-initiated login requests (indexed by their RelayState).
+	AuthChallengeKindSAMLState = "samlState"
+
+	// AuthChallengeKindSAMLAssertion is the kind of the already
+	// accepted SAML assertions (used for the replay detection).
+	AuthChallengeKindSAMLAssertion = "samlAssertion"
+
+	// AuthChallengeKindSAMLCode is the kind of the one-time SAML login
+	// codes that are exchanged for an auth token.
+	AuthChallengeKindSAMLCode = "samlCode"
+)
+
+var (
+	_ Model        = (*AuthChallenge)(nil)
+	_ PreValidator = (*AuthChallenge)(nil)
+	_ RecordProxy  = (*AuthChallenge)(nil)
+)
+
+// AuthChallenge defines a Record proxy for working with the authChallenges collection.
+//
+// An AuthChallenge is a short-lived single-use auth ceremony value
+// (eg. a WebAuthn challenge or a SAML login code) that is stored in
+// the database so that it could be consumed by any app node.
+type AuthChallenge struct {
+	*Record
+}
+
+// NewAuthChallenge instantiates and returns a new blank *AuthChallenge model.
+//
+// Example usage:
+//
+//	challenge := core.NewAuthChallenge(app)
+//	challenge.SetCollectionRef(collection.Id)
+//	challenge.SetKind(core.AuthChallengeKindWebAuthn)
+//	challenge.SetHash(core.HashAuthChallenge("abc"))
+//	challenge.SetExpires(types.NowDateTime().Add(5 * time.Minute))
+//	app.Save(challenge)
+func NewAuthChallenge(app App) *AuthChallenge {
+	m := &AuthChallenge{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameAuthChallenges)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on AuthChallenge.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *AuthChallenge) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameAuthChallenges {
+		return errors.New("missing or invalid AuthChallenge ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *AuthChallenge) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *AuthChallenge) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *AuthChallenge) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *AuthChallenge) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// Kind returns the "kind" record field value.
+func (m *AuthChallenge) Kind() string {
+	return m.GetString("kind")
+}
+
+// SetKind updates the "kind" record field value.
+func (m *AuthChallenge) SetKind(kind string) {
+	m.Set("kind", kind)
+}
+
+// Hash returns the "hash" record field value (the hash of the challenge value).
+func (m *AuthChallenge) Hash() string {
+	return m.GetString("hash")
+}
+
+// SetHash updates the "hash" record field value.
+func (m *AuthChallenge) SetHash(hash string) {
+	m.Set("hash", hash)
+}
+
+// Data returns the "data" record field value.
+func (m *AuthChallenge) Data() types.JSONRaw {
+	raw, _ := m.GetRaw("data").(types.JSONRaw)
+	return raw
+}
+
+// SetData updates the "data" record field value.
+func (m *AuthChallenge) SetData(data any) {
+	m.Set("data", data)
+}
+
+// Expires returns the "expires" record field value.
+func (m *AuthChallenge) Expires() types.DateTime {
+	return m.GetDateTime("expires")
+}
+
+// SetExpires updates the "expires" record field value.
+func (m *AuthChallenge) SetExpires(date types.DateTime) {
+	m.Set("expires", date)
+}
+
+// HasExpired checks whether the challenge has reached its expiration date.
+func (m *AuthChallenge) HasExpired() bool {
+	return !m.Expires().Time().After(time.Now())
+}
+
+// Created returns the "created" record field value.
+func (m *AuthChallenge) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// HashAuthChallenge returns the hash of a plain challenge value as stored in the "hash" field.
+func HashAuthChallenge(plain string) string {
+	return security.SHA256(plain)
+}
+
+// CreateAuthChallenge stores a new single-use challenge of the specified
+// auth collection and kind that expires after the provided duration.
+//
+// data is optional and could be loaded with [AuthChallenge.Data] on consume.
+//
+// Returns an error if the same challenge is already stored.
+func CreateAuthChallenge(app App, collection *Collection, kind string, plain string, data any, duration time.Duration) (*AuthChallenge, error) {
+	if plain == "" {
+		return nil, errors.New("missing auth challenge value")
+	}
+
+	m := NewAuthChallenge(app)
+	m.SetCollectionRef(collection.Id)
+	m.SetKind(kind)
+	m.SetHash(HashAuthChallenge(plain))
+	m.SetExpires(types.NowDateTime().Add(duration))
+	if data != nil {
+		m.SetData(data)
+	}
+
+	if err := app.Save(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ConsumeAuthChallenge deletes the stored not expired challenge of the
+// specified auth collection and kind and returns it.
+//
+// The challenge is claimed with a conditional delete so that only the
+// first of the concurrent calls (from any app node) succeeds.
+//
+// Returns [sql.ErrNoRows] if the challenge is missing, expired or already consumed.
+func ConsumeAuthChallenge(app App, collection *Collection, kind string, plain string) (*AuthChallenge, error) {
+	if plain == "" {
+		return nil, sql.ErrNoRows
+	}
+
+	m, err := app.FindAuthChallenge(collection.Id, kind, plain)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.HasExpired() {
+		return nil, sql.ErrNoRows
+	}
+
+	result, err := app.NonconcurrentDB().Delete(CollectionNameAuthChallenges, dbx.HashExp{"id": m.Id}).Execute()
+	if err != nil {
+		return nil, err
+	}
+	if deleted, err := result.RowsAffected(); err != nil || deleted != 1 {
+		return nil, sql.ErrNoRows
+	}
+
+	return m, nil
+}
+
+func (app *BaseApp) registerAuthChallengeHooks() {
+	// delete on collection ref delete
+	app.OnCollectionDeleteExecute().Bind(&hook.Handler[*CollectionEvent]{
+		Func: func(e *CollectionEvent) error {
+			if !e.Collection.IsAuth() {
+				return e.Next()
+			}
+
+			originalApp := e.App
+			txErr := e.App.RunInTransaction(func(txApp App) error {
+				e.App = txApp
+
+				if err := e.Next(); err != nil {
+					return err
+				}
+
+				_, err := txApp.NonconcurrentDB().Delete(
+					CollectionNameAuthChallenges,
+					dbx.HashExp{"collectionRef": e.Collection.Id},
+				).Execute()
+
+				return err
+			})
+			e.App = originalApp
+
+			return txErr
+		},
+		Priority: 99,
+	})
+
+	// run on every hour to cleanup the expired challenges
+	app.Cron().Add("__pbAuthChallengesCleanup__", "0 * * * *", func() {
+		if err := app.DeleteExpiredAuthChallenges(); err != nil {
+			app.Logger().Warn("Failed to delete expired auth challenges", "error", err)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package core_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewAuthChallenge(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAuthChallenge(app)
+
+	if m.Collection().Name != core.CollectionNameAuthChallenges {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameAuthChallenges, m.Collection().Name)
+	}
+}
+
+func TestAuthChallengeGettersAndSetters(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAuthChallenge(app)
+	m.SetCollectionRef("test_collection")
+	m.SetKind("test_kind")
+	m.SetHash("test_hash")
+	m.SetData(map[string]any{"a": 1})
+	m.SetExpires(types.NowDateTime().Add(1 * time.Hour))
+	m.SetRaw("created", types.NowDateTime())
+
+	if v := m.CollectionRef(); v != "test_collection" {
+		t.Fatalf("Expected collectionRef %q, got %q", "test_collection", v)
+	}
+	if v := m.Kind(); v != "test_kind" {
+		t.Fatalf("Expected kind %q, got %q", "test_kind", v)
+	}
+	if v := m.Hash(); v != "test_hash" {
+		t.Fatalf("Expected hash %q, got %q", "test_hash", v)
+	}
+	if v := m.Data().String(); v != `{"a":1}` {
+		t.Fatalf("Expected data %q, got %q", `{"a":1}`, v)
+	}
+	if v := m.Expires().String(); v != m.GetDateTime("expires").String() || v == "" {
+		t.Fatalf("Expected expires %q, got %q", m.GetDateTime("expires").String(), v)
+	}
+	if v := m.Created().String(); v != m.GetDateTime("created").String() || v == "" {
+		t.Fatalf("Expected created %q, got %q", m.GetDateTime("created").String(), v)
+	}
+	if m.HasExpired() {
+		t.Fatal("Expected the challenge to not be expired")
+	}
+
+	m.SetExpires(types.NowDateTime().Add(-1 * time.Second))
+	if !m.HasExpired() {
+		t.Fatal("Expected the challenge to be expired")
+	}
+}
+
+func TestAuthChallengeCreateAndConsume(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, err := app.FindCollectionByNameOrId("clients")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := core.CreateAuthChallenge(app, users, "test", "", nil, time.Hour); err == nil {
+		t.Fatal("Expected empty challenge error")
+	}
+
+	created, err := core.CreateAuthChallenge(app, users, "test", "abc", map[string]any{"a": 1}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created.Hash() == "abc" || created.Hash() != core.HashAuthChallenge("abc") {
+		t.Fatalf("Expected the challenge to be stored hashed, got %q", created.Hash())
+	}
+
+	if _, err := core.CreateAuthChallenge(app, users, "test", "abc", nil, time.Hour); err == nil {
+		t.Fatal("Expected duplicated challenge error")
+	}
+
+	if _, err := core.CreateAuthChallenge(app, users, "test", "expired", nil, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name       string
+		collection *core.Collection
+		kind       string
+		plain      string
+		expectErr  bool
+	}{
+		{"different collection", clients, "test", "abc", true},
+		{"different kind", users, "other", "abc", true},
+		{"missing", users, "test", "missing", true},
+		{"expired", users, "test", "expired", true},
+		{"empty", users, "test", "", true},
+		{"valid", users, "test", "abc", false},
+		{"already consumed", users, "test", "abc", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			m, err := core.ConsumeAuthChallenge(app, s.collection, s.kind, s.plain)
+
+			hasErr := err != nil
+			if hasErr != s.expectErr {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectErr, hasErr, err)
+			}
+
+			if hasErr {
+				if !errors.Is(err, sql.ErrNoRows) {
+					t.Fatalf("Expected sql.ErrNoRows, got %v", err)
+				}
+				return
+			}
+
+			if v := m.Data().String(); v != `{"a":1}` {
+				t.Fatalf("Expected data %q, got %q", `{"a":1}`, v)
+			}
+		})
+	}
+}
+
+func TestAuthChallengeDeleteOnCollectionDelete(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection := core.NewAuthCollection("test_challenges")
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := core.CreateAuthChallenge(app, collection, "test", "abc", nil, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Delete(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindAuthChallenge(collection.Id, "test", "abc"); err == nil {
+		t.Fatal("Expected the collection challenges to be deleted")
+	}
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// FindAuthChallenge returns the AuthChallenge model of the specified
+// collection, kind and plain challenge value.
+//
+// Note that the returned challenge could be expired.
+func (app *BaseApp) FindAuthChallenge(collectionId string, kind string, plain string) (*AuthChallenge, error) {
+	result := &AuthChallenge{}
+
+	err := app.RecordQuery(CollectionNameAuthChallenges).
+		AndWhere(dbx.HashExp{
+			"collectionRef": collectionId,
+			"kind":          kind,
+			"hash":          HashAuthChallenge(plain),
+		}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteExpiredAuthChallenges deletes the auth challenges that have reached their expiration date.
+//
+// note: the challenges are deleted directly without triggering the record hooks
+// because they are short-lived and could be too many.
+func (app *BaseApp) DeleteExpiredAuthChallenges() error {
+	_, err := app.NonconcurrentDB().Delete(
+		CollectionNameAuthChallenges,
+		dbx.NewExp("[[expires]] <= {:now}", dbx.Params{"now": types.NowDateTime()}),
+	).Execute()
+
+	return err
+}
@@ -0,0 +1,83 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestFindAuthChallenge(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := core.CreateAuthChallenge(app, users, "test", "abc", nil, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		collectionId string
+		kind         string
+		plain        string
+		expectErr    bool
+	}{
+		{"", "test", "abc", true},
+		{users.Id, "", "abc", true},
+		{users.Id, "test", "", true},
+		{users.Id, "test", core.HashAuthChallenge("abc"), true},
+		{users.Id, "test", "abc", false},
+	}
+
+	for i, s := range scenarios {
+		m, err := app.FindAuthChallenge(s.collectionId, s.kind, s.plain)
+
+		hasErr := err != nil
+		if hasErr != s.expectErr {
+			t.Fatalf("[%d] Expected hasErr %v, got %v (%v)", i, s.expectErr, hasErr, err)
+		}
+
+		if !hasErr && m.Hash() != core.HashAuthChallenge(s.plain) {
+			t.Fatalf("[%d] Expected hash %q, got %q", i, core.HashAuthChallenge(s.plain), m.Hash())
+		}
+	}
+}
+
+func TestDeleteExpiredAuthChallenges(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := core.CreateAuthChallenge(app, users, "test", "active", nil, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := core.CreateAuthChallenge(app, users, "test", "expired", nil, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DeleteExpiredAuthChallenges(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindAuthChallenge(users.Id, "test", "active"); err != nil {
+		t.Fatalf("Expected the active challenge to remain, got %v", err)
+	}
+
+	if _, err := app.FindAuthChallenge(users.Id, "test", "expired"); err == nil {
+		t.Fatal("Expected the expired challenge to be deleted")
+	}
+}
@@ -0,0 +1,271 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNameAuthLockouts = "_authLockouts"
+
+// List of the supported auth lockout subject types.
+const (
+	// AuthLockoutTypeIdentity is the type of the lockouts associated with
+	// a single identity (the auth record id or the submitted login identity
+	// if there is no matching record).
+	AuthLockoutTypeIdentity = "identity"
+
+	// AuthLockoutTypeIP is the type of the lockouts associated with a single client IP.
+	AuthLockoutTypeIP = "ip"
+)
+
+var (
+	_ Model        = (*AuthLockout)(nil)
+	_ PreValidator = (*AuthLockout)(nil)
+	_ RecordProxy  = (*AuthLockout)(nil)
+)
+
+// AuthLockout defines a Record proxy for working with the authLockouts collection.
+//
+// An AuthLockout tracks the failed auth attempts of a single
+// identity or IP and whether it is temporarily locked.
+type AuthLockout struct {
+	*Record
+}
+
+// NewAuthLockout instantiates and returns a new blank *AuthLockout model.
+//
+// Example usage:
+//
+//	lockout := core.NewAuthLockout(app)
+//	lockout.SetCollectionRef(user.Collection().Id)
+//	lockout.SetType(core.AuthLockoutTypeIdentity)
+//	lockout.SetSubject(user.Id)
+//	app.Save(lockout)
+func NewAuthLockout(app App) *AuthLockout {
+	m := &AuthLockout{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameAuthLockouts)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on AuthLockout.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *AuthLockout) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameAuthLockouts {
+		return errors.New("missing or invalid AuthLockout ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *AuthLockout) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *AuthLockout) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *AuthLockout) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *AuthLockout) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// Type returns the "type" record field value
+// (one of [AuthLockoutTypeIdentity] or [AuthLockoutTypeIP]).
+func (m *AuthLockout) Type() string {
+	return m.GetString("type")
+}
+
+// SetType updates the "type" record field value.
+func (m *AuthLockout) SetType(lockoutType string) {
+	m.Set("type", lockoutType)
+}
+
+// Subject returns the "subject" record field value
+// (the auth record id, the login identity or the IP depending on the lockout type).
+func (m *AuthLockout) Subject() string {
+	return m.GetString("subject")
+}
+
+// SetSubject updates the "subject" record field value.
+func (m *AuthLockout) SetSubject(subject string) {
+	m.Set("subject", subject)
+}
+
+// Failures returns the "failures" record field value
+// (the failed auth attempts since the last lockout).
+func (m *AuthLockout) Failures() int {
+	return m.GetInt("failures")
+}
+
+// SetFailures updates the "failures" record field value.
+func (m *AuthLockout) SetFailures(failures int) {
+	m.Set("failures", failures)
+}
+
+// Lockouts returns the "lockouts" record field value
+// (the number of consecutive lockouts).
+func (m *AuthLockout) Lockouts() int {
+	return m.GetInt("lockouts")
+}
+
+// SetLockouts updates the "lockouts" record field value.
+func (m *AuthLockout) SetLockouts(lockouts int) {
+	m.Set("lockouts", lockouts)
+}
+
+// LastFailure returns the "lastFailure" record field value.
+func (m *AuthLockout) LastFailure() types.DateTime {
+	return m.GetDateTime("lastFailure")
+}
+
+// SetLastFailure updates the "lastFailure" record field value.
+func (m *AuthLockout) SetLastFailure(date types.DateTime) {
+	m.Set("lastFailure", date)
+}
+
+// LastIP returns the "lastIP" record field value
+// (the client IP of the last failed auth attempt).
+func (m *AuthLockout) LastIP() string {
+	return m.GetString("lastIP")
+}
+
+// SetLastIP updates the "lastIP" record field value.
+func (m *AuthLockout) SetLastIP(ip string) {
+	m.Set("lastIP", ip)
+}
+
+// LockedUntil returns the "lockedUntil" record field value.
+func (m *AuthLockout) LockedUntil() types.DateTime {
+	return m.GetDateTime("lockedUntil")
+}
+
+// SetLockedUntil updates the "lockedUntil" record field value.
+func (m *AuthLockout) SetLockedUntil(date types.DateTime) {
+	m.Set("lockedUntil", date)
+}
+
+// Created returns the "created" record field value.
+func (m *AuthLockout) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *AuthLockout) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// IsLocked checks whether the lockout is still active.
+func (m *AuthLockout) IsLocked() bool {
+	return m.LockedUntil().Time().After(time.Now())
+}
+
+// RegisterFailure increments the failures counter of the lockout and
+// locks it if maxFailures is reached (returns true in that case).
+//
+// The failures and the consecutive lockouts are reset if the last
+// failure is older than the config MaxDuration.
+func (m *AuthLockout) RegisterFailure(config LockoutConfig, maxFailures int, ip string) bool {
+	now := time.Now()
+
+	lastFailure := m.LastFailure()
+	if !m.IsLocked() && !lastFailure.IsZero() && now.Sub(lastFailure.Time()) > config.MaxDurationTime() {
+		m.SetFailures(0)
+		m.SetLockouts(0)
+	}
+
+	m.SetLastFailure(types.NowDateTime())
+	m.SetLastIP(ip)
+	m.SetFailures(m.Failures() + 1)
+
+	if maxFailures <= 0 || m.Failures() < maxFailures {
+		return false
+	}
+
+	m.SetFailures(0)
+	m.SetLockouts(m.Lockouts() + 1)
+
+	lockedUntil, _ := types.ParseDateTime(now.Add(config.LockoutDuration(m.Lockouts())))
+	m.SetLockedUntil(lockedUntil)
+
+	return true
+}
+
+func (app *BaseApp) registerAuthLockoutHooks() {
+	app.OnRecordValidate(CollectionNameAuthLockouts).Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			collectionId := e.Record.GetString("collectionRef")
+			err := validation.Validate(collectionId, validation.Required, validation.By(validateCollectionId(e.App, CollectionTypeAuth)))
+			if err != nil {
+				return validation.Errors{"collectionRef": err}
+			}
+
+			return e.Next()
+		},
+		Priority: 99,
+	})
+
+	// delete on collection ref delete
+	app.OnCollectionDeleteExecute().Bind(&hook.Handler[*CollectionEvent]{
+		Func: func(e *CollectionEvent) error {
+			if !e.Collection.IsAuth() {
+				return e.Next()
+			}
+
+			originalApp := e.App
+			txErr := e.App.RunInTransaction(func(txApp App) error {
+				e.App = txApp
+
+				if err := e.Next(); err != nil {
+					return err
+				}
+
+				rels, err := txApp.FindAllRecords(CollectionNameAuthLockouts, dbx.HashExp{"collectionRef": e.Collection.Id})
+				if err != nil {
+					return err
+				}
+
+				for _, rel := range rels {
+					if err := txApp.Delete(rel); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			e.App = originalApp
+
+			return txErr
+		},
+		Priority: 99,
+	})
+
+	// run on every hour to cleanup the stale lockouts
+	app.Cron().Add("__pbAuthLockoutsCleanup__", "0 * * * *", func() {
+		if err := app.DeleteStaleAuthLockouts(); err != nil {
+			app.Logger().Warn("Failed to delete stale auth lockouts", "error", err)
+		}
+	})
+}
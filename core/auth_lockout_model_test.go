@@ -0,0 +1,159 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewAuthLockout(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAuthLockout(app)
+
+	if m.Collection().Name != core.CollectionNameAuthLockouts {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameAuthLockouts, m.Collection().Name)
+	}
+}
+
+func TestAuthLockoutGettersAndSetters(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAuthLockout(app)
+	m.SetCollectionRef("test_collection")
+	m.SetType(core.AuthLockoutTypeIP)
+	m.SetSubject("1.2.3.4")
+	m.SetFailures(3)
+	m.SetLockouts(2)
+	m.SetLastFailure(types.NowDateTime())
+	m.SetLastIP("5.6.7.8")
+	m.SetLockedUntil(types.NowDateTime())
+	m.SetRaw("created", types.NowDateTime())
+	m.SetRaw("updated", types.NowDateTime())
+
+	if v := m.CollectionRef(); v != "test_collection" {
+		t.Fatalf("Expected collectionRef %q, got %q", "test_collection", v)
+	}
+	if v := m.Type(); v != core.AuthLockoutTypeIP {
+		t.Fatalf("Expected type %q, got %q", core.AuthLockoutTypeIP, v)
+	}
+	if v := m.Subject(); v != "1.2.3.4" {
+		t.Fatalf("Expected subject %q, got %q", "1.2.3.4", v)
+	}
+	if v := m.Failures(); v != 3 {
+		t.Fatalf("Expected failures %d, got %d", 3, v)
+	}
+	if v := m.Lockouts(); v != 2 {
+		t.Fatalf("Expected lockouts %d, got %d", 2, v)
+	}
+	if v := m.LastIP(); v != "5.6.7.8" {
+		t.Fatalf("Expected lastIP %q, got %q", "5.6.7.8", v)
+	}
+	if v := m.LastFailure().String(); v != m.GetDateTime("lastFailure").String() || v == "" {
+		t.Fatalf("Expected lastFailure %q, got %q", m.GetDateTime("lastFailure").String(), v)
+	}
+	if v := m.LockedUntil().String(); v != m.GetDateTime("lockedUntil").String() || v == "" {
+		t.Fatalf("Expected lockedUntil %q, got %q", m.GetDateTime("lockedUntil").String(), v)
+	}
+	if v := m.Created().String(); v != m.GetDateTime("created").String() || v == "" {
+		t.Fatalf("Expected created %q, got %q", m.GetDateTime("created").String(), v)
+	}
+	if v := m.Updated().String(); v != m.GetDateTime("updated").String() || v == "" {
+		t.Fatalf("Expected updated %q, got %q", m.GetDateTime("updated").String(), v)
+	}
+}
+
+func TestAuthLockoutIsLocked(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	now := types.NowDateTime()
+
+	scenarios := []struct {
+		lockedUntil types.DateTime
+		expected    bool
+	}{
+		{types.DateTime{}, false},
+		{now.Add(-1 * time.Minute), false},
+		{now.Add(1 * time.Minute), true},
+	}
+
+	for i, s := range scenarios {
+		m := core.NewAuthLockout(app)
+		m.SetLockedUntil(s.lockedUntil)
+
+		if v := m.IsLocked(); v != s.expected {
+			t.Errorf("[%d] Expected %v, got %v", i, s.expected, v)
+		}
+	}
+}
+
+func TestAuthLockoutRegisterFailure(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	config := core.LockoutConfig{Enabled: true, MaxFailures: 2, Duration: 60, MaxDuration: 3600}
+
+	m := core.NewAuthLockout(app)
+
+	// 1st failure
+	if m.RegisterFailure(config, 2, "1.1.1.1") {
+		t.Fatal("Expected the lockout to not be locked after the 1st failure")
+	}
+	if m.Failures() != 1 || m.Lockouts() != 0 || m.LastIP() != "1.1.1.1" || m.LastFailure().IsZero() {
+		t.Fatalf("Unexpected lockout state after the 1st failure: %v", m.FieldsData())
+	}
+
+	// 2nd failure
+	if !m.RegisterFailure(config, 2, "2.2.2.2") {
+		t.Fatal("Expected the lockout to be locked after the 2nd failure")
+	}
+	if m.Failures() != 0 || m.Lockouts() != 1 || m.LastIP() != "2.2.2.2" || !m.IsLocked() {
+		t.Fatalf("Unexpected lockout state after the 2nd failure: %v", m.FieldsData())
+	}
+	firstLockedUntil := m.LockedUntil()
+	if diff := firstLockedUntil.Time().Sub(time.Now()); diff > 60*time.Second || diff < 55*time.Second {
+		t.Fatalf("Expected ~60s lockout, got %v", diff)
+	}
+
+	// consecutive lockout (doubled duration)
+	m.RegisterFailure(config, 2, "2.2.2.2")
+	if !m.RegisterFailure(config, 2, "2.2.2.2") {
+		t.Fatal("Expected the lockout to be locked again")
+	}
+	if m.Lockouts() != 2 {
+		t.Fatalf("Expected 2 lockouts, got %d", m.Lockouts())
+	}
+	if diff := m.LockedUntil().Time().Sub(time.Now()); diff > 120*time.Second || diff < 115*time.Second {
+		t.Fatalf("Expected ~120s lockout, got %v", diff)
+	}
+
+	// stale failures reset
+	m.SetLockedUntil(types.NowDateTime().Add(-2 * time.Hour))
+	m.SetLastFailure(types.NowDateTime().Add(-2 * time.Hour))
+	m.SetFailures(1)
+	if m.RegisterFailure(config, 2, "3.3.3.3") {
+		t.Fatal("Expected the stale failures to be reset")
+	}
+	if m.Failures() != 1 || m.Lockouts() != 0 {
+		t.Fatalf("Expected reset lockout state, got %v", m.FieldsData())
+	}
+
+	// disabled max failures
+	if m.RegisterFailure(config, 0, "3.3.3.3") {
+		t.Fatal("Expected no lockout with zero max failures")
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// FindAuthLockout returns the AuthLockout model of the specified
+// collection, lockout type and subject.
+func (app *BaseApp) FindAuthLockout(collectionId string, lockoutType string, subject string) (*AuthLockout, error) {
+	result := &AuthLockout{}
+
+	err := app.RecordQuery(CollectionNameAuthLockouts).
+		AndWhere(dbx.HashExp{
+			"collectionRef": collectionId,
+			"type":          lockoutType,
+			"subject":       subject,
+		}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAllAuthLockoutsByCollection returns all AuthLockout models
+// linked to the provided auth collection (the last failed first).
+func (app *BaseApp) FindAllAuthLockoutsByCollection(collection *Collection) ([]*AuthLockout, error) {
+	result := []*AuthLockout{}
+
+	err := app.RecordQuery(CollectionNameAuthLockouts).
+		AndWhere(dbx.HashExp{"collectionRef": collection.Id}).
+		OrderBy("lastFailure DESC", "created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteStaleAuthLockouts deletes the unlocked auth lockouts whose
+// last failure is older than their collection lockout MaxDuration
+// (aka. the lockouts that will be reset on the next failure anyway).
+//
+// The lockouts of collections with disabled lockout option are always deleted.
+func (app *BaseApp) DeleteStaleAuthLockouts() error {
+	collections, err := app.FindAllCollections(CollectionTypeAuth)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, collection := range collections {
+		expr := dbx.HashExp{"collectionRef": collection.Id}
+
+		var staleExpr dbx.Expression
+		if collection.Lockout.Enabled {
+			maxLastFailure, err := types.ParseDateTime(now.Add(-collection.Lockout.MaxDurationTime()))
+			if err != nil {
+				return err
+			}
+
+			staleExpr = dbx.NewExp(
+				"[[lockedUntil]] < {:now} AND [[lastFailure]] < {:maxLastFailure}",
+				dbx.Params{"now": types.NowDateTime(), "maxLastFailure": maxLastFailure},
+			)
+		}
+
+		items := []*Record{}
+
+		err = app.RecordQuery(CollectionNameAuthLockouts).
+			AndWhere(expr).
+			AndWhere(staleExpr).
+			All(&items)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err := app.Delete(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
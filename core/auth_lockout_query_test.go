@@ -0,0 +1,117 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestFindAuthLockouts(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, err := app.FindCollectionByNameOrId("clients")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createLockout := func(collection *core.Collection, lockoutType, subject string, lastFailure types.DateTime) {
+		m := core.NewAuthLockout(app)
+		m.SetCollectionRef(collection.Id)
+		m.SetType(lockoutType)
+		m.SetSubject(subject)
+		m.SetFailures(1)
+		m.SetLastFailure(lastFailure)
+		if err := app.Save(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	createLockout(users, core.AuthLockoutTypeIdentity, "test", types.NowDateTime())
+	createLockout(users, core.AuthLockoutTypeIP, "1.2.3.4", types.NowDateTime().Add(-2*time.Hour))
+	createLockout(clients, core.AuthLockoutTypeIdentity, "test", types.NowDateTime())
+
+	// find single
+	if _, err := app.FindAuthLockout(users.Id, core.AuthLockoutTypeIP, "test"); err == nil {
+		t.Fatal("Expected no lockout for the ip type")
+	}
+
+	lockout, err := app.FindAuthLockout(users.Id, core.AuthLockoutTypeIdentity, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lockout.CollectionRef() != users.Id {
+		t.Fatalf("Expected users lockout, got %q", lockout.CollectionRef())
+	}
+
+	// find all
+	lockouts, err := app.FindAllAuthLockoutsByCollection(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lockouts) != 2 {
+		t.Fatalf("Expected 2 users lockouts, got %d", len(lockouts))
+	}
+	if lockouts[0].Type() != core.AuthLockoutTypeIdentity {
+		t.Fatalf("Expected the last failed lockout first, got %q", lockouts[0].Type())
+	}
+
+	// stale cleanup
+	users.Lockout = core.LockoutConfig{Enabled: true, MaxFailures: 5, Duration: 60, MaxDuration: 3600}
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DeleteStaleAuthLockouts(); err != nil {
+		t.Fatal(err)
+	}
+
+	lockouts, _ = app.FindAllAuthLockoutsByCollection(users)
+	if len(lockouts) != 1 || lockouts[0].Type() != core.AuthLockoutTypeIdentity {
+		t.Fatalf("Expected only the users identity lockout to remain, got %d", len(lockouts))
+	}
+
+	// clients lockout is disabled
+	lockouts, _ = app.FindAllAuthLockoutsByCollection(clients)
+	if len(lockouts) != 0 {
+		t.Fatalf("Expected the clients lockouts to be deleted, got %d", len(lockouts))
+	}
+}
+
+func TestAuthLockoutCollectionDelete(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection := core.NewAuthCollection("test_lockouts")
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	m := core.NewAuthLockout(app)
+	m.SetCollectionRef(collection.Id)
+	m.SetType(core.AuthLockoutTypeIP)
+	m.SetSubject("1.2.3.4")
+	if err := app.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Delete(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindAuthLockout(collection.Id, core.AuthLockoutTypeIP, "1.2.3.4"); err == nil {
+		t.Fatal("Expected the lockout to be deleted together with its collection")
+	}
+}
@@ -0,0 +1,356 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNameAuthSessions = "_authSessions"
+
+// authSessionTokenSeparator separates the session id and
+// the random secret part of a plain refresh token.
+const authSessionTokenSeparator = "."
+
+// authSessionMaxPreviousHashes is the max number of the already rotated
+// refresh token hashes that are kept for the reuse detection.
+const authSessionMaxPreviousHashes = 10
+
+// AuthSessionRotationGracePeriod is the period after a refresh token rotation
+// in which the use of the just rotated refresh token is treated as a concurrent
+// refresh (eg. from another browser tab or a retried request) and not as a reuse.
+const AuthSessionRotationGracePeriod = 30 * time.Second
+
+var (
+	_ Model        = (*AuthSession)(nil)
+	_ PreValidator = (*AuthSession)(nil)
+	_ RecordProxy  = (*AuthSession)(nil)
+)
+
+// AuthSession defines a Record proxy for working with the authSessions collection
+// (aka. the server-side auth sessions with rotating refresh tokens).
+//
+// A single session represents a refresh token family - each refresh
+// token use replaces the stored token hash with a new one and
+// the reuse of an already rotated token (one of the "previousHashes")
+// revokes the entire session.
+type AuthSession struct {
+	*Record
+}
+
+// NewAuthSession instantiates and returns a new blank *AuthSession model.
+//
+// Example usage:
+//
+//	session := core.NewAuthSession(app)
+//	session.SetCollectionRef(user.Collection().Id)
+//	session.SetRecordRef(user.Id)
+//	refreshToken := session.RefreshValue()
+//	session.SetExpires(types.NowDateTime().Add(30 * 24 * time.Hour))
+//	app.Save(session)
+func NewAuthSession(app App) *AuthSession {
+	m := &AuthSession{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameAuthSessions)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on AuthSession.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *AuthSession) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameAuthSessions {
+		return errors.New("missing or invalid AuthSession ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *AuthSession) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *AuthSession) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *AuthSession) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *AuthSession) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *AuthSession) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *AuthSession) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// OriginRef returns the "originRef" record field value
+// (the id of the related [AuthOrigin] record, if any).
+func (m *AuthSession) OriginRef() string {
+	return m.GetString("originRef")
+}
+
+// SetOriginRef updates the "originRef" record field value.
+func (m *AuthSession) SetOriginRef(originId string) {
+	m.Set("originRef", originId)
+}
+
+// UserAgent returns the "userAgent" record field value.
+func (m *AuthSession) UserAgent() string {
+	return m.GetString("userAgent")
+}
+
+// SetUserAgent updates the "userAgent" record field value.
+func (m *AuthSession) SetUserAgent(userAgent string) {
+	if len(userAgent) > 200 {
+		userAgent = userAgent[:200] + "..."
+	}
+
+	m.Set("userAgent", userAgent)
+}
+
+// IP returns the "ip" record field value (the IP of the last session use).
+func (m *AuthSession) IP() string {
+	return m.GetString("ip")
+}
+
+// SetIP updates the "ip" record field value.
+func (m *AuthSession) SetIP(ip string) {
+	m.Set("ip", ip)
+}
+
+// TokenHash returns the "tokenHash" record field value
+// (the hash of the current refresh token secret).
+func (m *AuthSession) TokenHash() string {
+	return m.GetString("tokenHash")
+}
+
+// RefreshValue generates a new refresh token for the session, updates
+// the "tokenHash" record field with its hash and returns the plain token.
+//
+// The replaced token hash (if any) is appended to the "previousHashes" field.
+//
+// The plain refresh token has the format "[sessionId].[secret]".
+//
+// Note that a new session id is generated if the session doesn't have one.
+func (m *AuthSession) RefreshValue() string {
+	if m.Id == "" {
+		m.Id = GenerateDefaultRandomId()
+	}
+
+	if current := m.TokenHash(); current != "" {
+		previous := append(m.PreviousHashes(), current)
+		if len(previous) > authSessionMaxPreviousHashes {
+			previous = previous[len(previous)-authSessionMaxPreviousHashes:]
+		}
+		m.Set("previousHashes", previous)
+	}
+
+	secret := security.RandomString(50)
+
+	m.Set("tokenHash", security.SHA256(secret))
+
+	return m.Id + authSessionTokenSeparator + secret
+}
+
+// ValidateRefreshToken checks whether the provided plain refresh token
+// is the current refresh token of the session.
+func (m *AuthSession) ValidateRefreshToken(refreshToken string) bool {
+	sessionId, secret, ok := strings.Cut(refreshToken, authSessionTokenSeparator)
+	if !ok || sessionId != m.Id || secret == "" {
+		return false
+	}
+
+	return security.Equal(security.SHA256(secret), m.TokenHash())
+}
+
+// PreviousHashes returns the "previousHashes" record field value
+// (the hashes of the last already rotated refresh token secrets, the newest last).
+func (m *AuthSession) PreviousHashes() []string {
+	hashes := []string{}
+
+	_ = m.UnmarshalJSONField("previousHashes", &hashes)
+
+	return hashes
+}
+
+// IsRotatedRefreshToken checks whether the provided plain refresh token
+// is one of the already rotated refresh tokens of the session
+// (aka. a refresh token reuse).
+func (m *AuthSession) IsRotatedRefreshToken(refreshToken string) bool {
+	sessionId, secret, ok := strings.Cut(refreshToken, authSessionTokenSeparator)
+	if !ok || sessionId != m.Id || secret == "" {
+		return false
+	}
+
+	hash := security.SHA256(secret)
+
+	var match bool
+	for _, previous := range m.PreviousHashes() {
+		if security.Equal(hash, previous) {
+			match = true
+		}
+	}
+
+	return match
+}
+
+// IsRecentlyRotatedRefreshToken checks whether the provided plain refresh token
+// is the last rotated refresh token of the session and the rotation happened
+// within the last [AuthSessionRotationGracePeriod].
+func (m *AuthSession) IsRecentlyRotatedRefreshToken(refreshToken string) bool {
+	sessionId, secret, ok := strings.Cut(refreshToken, authSessionTokenSeparator)
+	if !ok || sessionId != m.Id || secret == "" {
+		return false
+	}
+
+	previous := m.PreviousHashes()
+	if len(previous) == 0 || !security.Equal(security.SHA256(secret), previous[len(previous)-1]) {
+		return false
+	}
+
+	lastUsed := m.LastUsed()
+
+	return !lastUsed.IsZero() && time.Since(lastUsed.Time()) < AuthSessionRotationGracePeriod
+}
+
+// LastUsed returns the "lastUsed" record field value.
+func (m *AuthSession) LastUsed() types.DateTime {
+	return m.GetDateTime("lastUsed")
+}
+
+// SetLastUsed updates the "lastUsed" record field value.
+func (m *AuthSession) SetLastUsed(date types.DateTime) {
+	m.Set("lastUsed", date)
+}
+
+// Expires returns the "expires" record field value.
+func (m *AuthSession) Expires() types.DateTime {
+	return m.GetDateTime("expires")
+}
+
+// SetExpires updates the "expires" record field value.
+func (m *AuthSession) SetExpires(date types.DateTime) {
+	m.Set("expires", date)
+}
+
+// Created returns the "created" record field value.
+func (m *AuthSession) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *AuthSession) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// HasExpired checks if the session refresh token has reached its expiration date.
+func (m *AuthSession) HasExpired() bool {
+	return !m.Expires().Time().After(time.Now())
+}
+
+// ParseAuthSessionRefreshToken extracts the session id from a plain refresh token.
+//
+// Returns an empty string if the token is not in the expected format.
+func ParseAuthSessionRefreshToken(refreshToken string) string {
+	sessionId, secret, ok := strings.Cut(refreshToken, authSessionTokenSeparator)
+	if !ok || secret == "" {
+		return ""
+	}
+
+	return sessionId
+}
+
+func (app *BaseApp) registerAuthSessionHooks() {
+	recordRefHooks[*AuthSession](app, CollectionNameAuthSessions, CollectionTypeAuth)
+
+	// run on every hour to cleanup the expired sessions
+	app.Cron().Add("__pbAuthSessionsCleanup__", "0 * * * *", func() {
+		if err := app.DeleteExpiredAuthSessions(); err != nil {
+			app.Logger().Warn("Failed to delete expired auth sessions", "error", err)
+		}
+	})
+
+	// revoke all sessions on password or tokenKey change
+	app.OnRecordUpdate().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			err := e.Next()
+			if err != nil || !e.Record.Collection().IsAuth() {
+				return err
+			}
+
+			oldTokenKey := e.Record.Original().TokenKey()
+			newTokenKey := e.Record.TokenKey()
+			if oldTokenKey != newTokenKey {
+				err = e.App.DeleteAllAuthSessionsByRecord(e.Record)
+				if err != nil {
+					e.App.Logger().Warn(
+						"Failed to delete all previous auth sessions",
+						"error", err,
+						"recordId", e.Record.Id,
+						"collectionId", e.Record.Collection().Id,
+					)
+				}
+			}
+
+			return nil
+		},
+		Priority: 99,
+	})
+
+	// revoke the origin sessions on auth origin delete
+	app.OnRecordDeleteExecute(CollectionNameAuthOrigins).Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			originalApp := e.App
+			txErr := e.App.RunInTransaction(func(txApp App) error {
+				e.App = txApp
+
+				if err := e.Next(); err != nil {
+					return err
+				}
+
+				sessions, err := txApp.FindAllRecords(CollectionNameAuthSessions, dbx.HashExp{"originRef": e.Record.Id})
+				if err != nil {
+					return err
+				}
+
+				for _, session := range sessions {
+					if err := txApp.Delete(session); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			e.App = originalApp
+
+			return txErr
+		},
+		Priority: 99,
+	})
+}
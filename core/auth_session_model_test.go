@@ -0,0 +1,324 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewAuthSession(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAuthSession(app)
+
+	if m.Collection().Name != core.CollectionNameAuthSessions {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameAuthSessions, m.Collection().Name)
+	}
+}
+
+func TestAuthSessionGettersAndSetters(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAuthSession(app)
+	m.SetCollectionRef("test_collection")
+	m.SetRecordRef("test_record")
+	m.SetOriginRef("test_origin")
+	m.SetUserAgent("test_ua")
+	m.SetIP("127.0.0.1")
+	m.SetLastUsed(types.NowDateTime())
+	m.SetExpires(types.NowDateTime())
+	m.SetRaw("created", types.NowDateTime())
+	m.SetRaw("updated", types.NowDateTime())
+
+	if v := m.CollectionRef(); v != "test_collection" {
+		t.Fatalf("Expected collectionRef %q, got %q", "test_collection", v)
+	}
+	if v := m.RecordRef(); v != "test_record" {
+		t.Fatalf("Expected recordRef %q, got %q", "test_record", v)
+	}
+	if v := m.OriginRef(); v != "test_origin" {
+		t.Fatalf("Expected originRef %q, got %q", "test_origin", v)
+	}
+	if v := m.UserAgent(); v != "test_ua" {
+		t.Fatalf("Expected userAgent %q, got %q", "test_ua", v)
+	}
+	if v := m.IP(); v != "127.0.0.1" {
+		t.Fatalf("Expected ip %q, got %q", "127.0.0.1", v)
+	}
+	if v := m.LastUsed().String(); v != m.GetDateTime("lastUsed").String() || v == "" {
+		t.Fatalf("Expected lastUsed %q, got %q", m.GetDateTime("lastUsed").String(), v)
+	}
+	if v := m.Expires().String(); v != m.GetDateTime("expires").String() || v == "" {
+		t.Fatalf("Expected expires %q, got %q", m.GetDateTime("expires").String(), v)
+	}
+	if v := m.Created().String(); v != m.GetDateTime("created").String() || v == "" {
+		t.Fatalf("Expected created %q, got %q", m.GetDateTime("created").String(), v)
+	}
+	if v := m.Updated().String(); v != m.GetDateTime("updated").String() || v == "" {
+		t.Fatalf("Expected updated %q, got %q", m.GetDateTime("updated").String(), v)
+	}
+
+	// long user agent
+	m.SetUserAgent(strings.Repeat("a", 250))
+	if v := m.UserAgent(); len(v) != 203 {
+		t.Fatalf("Expected truncated userAgent, got %d chars", len(v))
+	}
+}
+
+func TestAuthSessionRefreshValue(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAuthSession(app)
+
+	token1 := m.RefreshValue()
+	if m.Id == "" {
+		t.Fatal("Expected the session id to be generated")
+	}
+	if !strings.HasPrefix(token1, m.Id+".") {
+		t.Fatalf("Expected token with %q prefix, got %q", m.Id+".", token1)
+	}
+	if v := core.ParseAuthSessionRefreshToken(token1); v != m.Id {
+		t.Fatalf("Expected parsed session id %q, got %q", m.Id, v)
+	}
+	if m.TokenHash() == "" || strings.Contains(token1, m.TokenHash()) {
+		t.Fatalf("Expected the token hash to be stored, got %q", m.TokenHash())
+	}
+	if !m.ValidateRefreshToken(token1) {
+		t.Fatal("Expected token1 to be valid")
+	}
+
+	id := m.Id
+	token2 := m.RefreshValue()
+	if m.Id != id {
+		t.Fatalf("Expected the session id to remain %q, got %q", id, m.Id)
+	}
+
+	scenarios := []struct {
+		name     string
+		token    string
+		expected bool
+	}{
+		{"empty", "", false},
+		{"missing secret", m.Id + ".", false},
+		{"missing separator", m.Id, false},
+		{"different session id", "abc" + token2, false},
+		{"rotated token", token1, false},
+		{"current token", token2, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			if v := m.ValidateRefreshToken(s.token); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestAuthSessionIsRotatedRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAuthSession(app)
+
+	tokens := make([]string, 12)
+	for i := range tokens {
+		tokens[i] = m.RefreshValue()
+	}
+
+	if v := m.PreviousHashes(); len(v) != 10 {
+		t.Fatalf("Expected 10 previous hashes, got %d", len(v))
+	}
+
+	_, secret, _ := strings.Cut(tokens[10], ".")
+	if v := m.PreviousHashes(); v[len(v)-1] != security.SHA256(secret) {
+		t.Fatalf("Expected the last previous hash to be of the last rotated token, got %q", v[len(v)-1])
+	}
+
+	scenarios := []struct {
+		name     string
+		token    string
+		expected bool
+	}{
+		{"empty", "", false},
+		{"missing secret", m.Id + ".", false},
+		{"random secret", m.Id + ".random", false},
+		{"different session id", "abc" + tokens[10], false},
+		{"current token", tokens[11], false},
+		{"oldest rotated token above the limit", tokens[0], false},
+		{"oldest kept rotated token", tokens[1], true},
+		{"last rotated token", tokens[10], true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			if v := m.IsRotatedRefreshToken(s.token); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestAuthSessionIsRecentlyRotatedRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewAuthSession(app)
+
+	tokens := make([]string, 3)
+	for i := range tokens {
+		tokens[i] = m.RefreshValue()
+	}
+
+	scenarios := []struct {
+		name     string
+		token    string
+		lastUsed types.DateTime
+		expected bool
+	}{
+		{"zero lastUsed", tokens[1], types.DateTime{}, false},
+		{"last rotated token outside the grace period", tokens[1], types.NowDateTime().Add(-core.AuthSessionRotationGracePeriod - time.Second), false},
+		{"older rotated token within the grace period", tokens[0], types.NowDateTime(), false},
+		{"current token within the grace period", tokens[2], types.NowDateTime(), false},
+		{"different session id", "abc" + tokens[1], types.NowDateTime(), false},
+		{"last rotated token within the grace period", tokens[1], types.NowDateTime(), true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			m.SetLastUsed(s.lastUsed)
+
+			if v := m.IsRecentlyRotatedRefreshToken(s.token); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestAuthSessionHasExpired(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	now := types.NowDateTime()
+
+	scenarios := []struct {
+		expires  types.DateTime
+		expected bool
+	}{
+		{types.DateTime{}, true},
+		{now.Add(-1 * time.Minute), true},
+		{now.Add(1 * time.Minute), false},
+	}
+
+	for i, s := range scenarios {
+		m := core.NewAuthSession(app)
+		m.SetExpires(s.expires)
+
+		if v := m.HasExpired(); v != s.expected {
+			t.Errorf("[%d] Expected %v, got %v", i, s.expected, v)
+		}
+	}
+}
+
+func TestAuthSessionPasswordChangeRevoke(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := core.NewAuthSession(app)
+	session.SetCollectionRef(user.Collection().Id)
+	session.SetRecordRef(user.Id)
+	session.RefreshValue()
+	session.SetExpires(types.NowDateTime().Add(time.Hour))
+	if err := app.Save(session); err != nil {
+		t.Fatal(err)
+	}
+
+	// non-password change
+	user.Set("name", "new_name")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := app.FindAllAuthSessionsByRecord(user); len(sessions) != 1 {
+		t.Fatalf("Expected the session to remain, got %d sessions", len(sessions))
+	}
+
+	// password change
+	user.SetPassword("1234567890_new")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := app.FindAllAuthSessionsByRecord(user); len(sessions) != 0 {
+		t.Fatalf("Expected the session to be revoked, got %d sessions", len(sessions))
+	}
+}
+
+func TestAuthSessionOriginDeleteRevoke(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origin := core.NewAuthOrigin(app)
+	origin.SetCollectionRef(user.Collection().Id)
+	origin.SetRecordRef(user.Id)
+	origin.SetFingerprint("test_fingerprint")
+	if err := app.Save(origin); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, originRef := range []string{origin.Id, ""} {
+		session := core.NewAuthSession(app)
+		session.SetCollectionRef(user.Collection().Id)
+		session.SetRecordRef(user.Id)
+		session.SetOriginRef(originRef)
+		session.RefreshValue()
+		session.SetExpires(types.NowDateTime().Add(time.Hour))
+		if err := app.Save(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := app.Delete(origin); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := app.FindAllAuthSessionsByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].OriginRef() != "" {
+		t.Fatalf("Expected only the session without origin to remain, got %v", sessions)
+	}
+}
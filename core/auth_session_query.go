@@ -0,0 +1,97 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// FindAuthSessionByRefreshToken returns the AuthSession model
+// referenced by the provided plain refresh token.
+//
+// Note that the returned session could be expired and the token is
+// not required to be the current session refresh token
+// (use [AuthSession.ValidateRefreshToken] to check it).
+func (app *BaseApp) FindAuthSessionByRefreshToken(refreshToken string) (*AuthSession, error) {
+	sessionId := ParseAuthSessionRefreshToken(refreshToken)
+	if sessionId == "" {
+		return nil, sql.ErrNoRows
+	}
+
+	result := &AuthSession{}
+
+	err := app.RecordQuery(CollectionNameAuthSessions).
+		AndWhere(dbx.HashExp{"id": sessionId}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAllAuthSessionsByRecord returns all AuthSession models linked to the provided auth record
+// (the last used first).
+func (app *BaseApp) FindAllAuthSessionsByRecord(authRecord *Record) ([]*AuthSession, error) {
+	result := []*AuthSession{}
+
+	err := app.RecordQuery(CollectionNameAuthSessions).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("lastUsed DESC", "created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteAllAuthSessionsByRecord deletes all AuthSession models associated with the provided record.
+//
+// Returns a combined error with the failed deletes.
+func (app *BaseApp) DeleteAllAuthSessionsByRecord(authRecord *Record) error {
+	models, err := app.FindAllAuthSessionsByRecord(authRecord)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, m := range models {
+		if err := app.Delete(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// DeleteExpiredAuthSessions deletes the sessions with expired refresh tokens.
+func (app *BaseApp) DeleteExpiredAuthSessions() error {
+	items := []*Record{}
+
+	err := app.RecordQuery(CollectionNameAuthSessions).
+		AndWhere(dbx.NewExp("[[expires]] <= {:now}", dbx.Params{"now": types.NowDateTime()})).
+		All(&items)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := app.Delete(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
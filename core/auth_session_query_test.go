@@ -0,0 +1,85 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestFindAuthSessions(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var refreshToken string
+	for _, expires := range []types.DateTime{
+		types.NowDateTime().Add(time.Hour),
+		types.NowDateTime().Add(-time.Hour),
+	} {
+		m := core.NewAuthSession(app)
+		m.SetCollectionRef(user1.Collection().Id)
+		m.SetRecordRef(user1.Id)
+		refreshToken = m.RefreshValue()
+		m.SetExpires(expires)
+		if err := app.Save(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sessions, err := app.FindAllAuthSessionsByRecord(user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 user1 sessions, got %d", len(sessions))
+	}
+
+	if sessions, _ := app.FindAllAuthSessionsByRecord(user2); len(sessions) != 0 {
+		t.Fatalf("Expected 0 user2 sessions, got %d", len(sessions))
+	}
+
+	// find by refresh token
+	for _, token := range []string{"", "missing", "missing.secret"} {
+		if _, err := app.FindAuthSessionByRefreshToken(token); err == nil {
+			t.Fatalf("Expected no session for token %q", token)
+		}
+	}
+
+	session, err := app.FindAuthSessionByRefreshToken(refreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.ValidateRefreshToken(refreshToken) || !session.HasExpired() {
+		t.Fatalf("Expected the last created (expired) session, got %v", session)
+	}
+
+	// expired sessions cleanup
+	if err := app.DeleteExpiredAuthSessions(); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := app.FindAllAuthSessionsByRecord(user1); len(sessions) != 1 || sessions[0].HasExpired() {
+		t.Fatalf("Expected only the non-expired session to remain, got %v", sessions)
+	}
+
+	// delete all
+	if err := app.DeleteAllAuthSessionsByRecord(user1); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := app.FindAllAuthSessionsByRecord(user1); len(sessions) != 0 {
+		t.Fatalf("Expected 0 user1 sessions, got %d", len(sessions))
+	}
+}
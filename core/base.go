@@ -24,6 +24,7 @@ import (
 	"github.com/pocketbase/pocketbase/tools/routine"
 	"github.com/pocketbase/pocketbase/tools/store"
 	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	"github.com/pocketbase/pocketbase/tools/tracing"
 	"github.com/pocketbase/pocketbase/tools/types"
 	"github.com/spf13/cast"
 	"golang.org/x/sync/semaphore"
@@ -41,6 +42,9 @@ const (
 	LocalTempDirName          string = ".pb_temp_to_delete" // temp pb_data sub directory that will be deleted on each app.Bootstrap()
 	LocalAutocertCacheDirName string = ".autocert_cache"
 
+	// optional sorted SHA1 hashes file used by the password policy breached passwords check
+	LocalBreachedPasswordsFileName string = "breached_passwords.txt"
+
 	// @todo consider removing after backups refactoring
 	lostFoundDirName string = "lost+found"
 )
@@ -65,6 +69,10 @@ type BaseAppConfig struct {
 	AuxMaxOpenConns  int
 	AuxMaxIdleConns  int
 	IsDev            bool
+
+	// Tracer is an optional tracer used to record spans for the
+	// app requests, hook handlers, db queries and filesystem operations.
+	Tracer *tracing.Tracer
 }
 
 // ensures that the BaseApp implements the App interface.
@@ -149,6 +157,8 @@ type BaseApp struct {
 	onRealtimeConnectRequest   *hook.Hook[*RealtimeConnectRequestEvent]
 	onRealtimeMessageSend      *hook.Hook[*RealtimeMessageEvent]
 	onRealtimeSubscribeRequest *hook.Hook[*RealtimeSubscribeRequestEvent]
+	onRealtimePublishRequest   *hook.Hook[*RealtimePublishRequestEvent]
+	onRealtimePresenceChange   *hook.Hook[*RealtimePresenceEvent]
 
 	// settings event hooks
 	onSettingsListRequest   *hook.Hook[*SettingsListRequestEvent]
@@ -172,6 +182,15 @@ type BaseApp struct {
 	onRecordConfirmEmailChangeRequest   *hook.Hook[*RecordConfirmEmailChangeRequestEvent]
 	onRecordRequestOTPRequest           *hook.Hook[*RecordCreateOTPRequestEvent]
 	onRecordAuthWithOTPRequest          *hook.Hook[*RecordAuthWithOTPRequestEvent]
+	onRecordWebAuthnRegisterRequest     *hook.Hook[*RecordWebAuthnRegisterRequestEvent]
+	onRecordAuthWithWebAuthnRequest     *hook.Hook[*RecordAuthWithWebAuthnRequestEvent]
+	onRecordAuthWithTOTPRequest         *hook.Hook[*RecordAuthWithTOTPRequestEvent]
+	onRecordAuthWithLDAPRequest         *hook.Hook[*RecordAuthWithLDAPRequestEvent]
+	onRecordAuthWithSAMLRequest         *hook.Hook[*RecordAuthWithSAMLRequestEvent]
+	onRecordOIDCAuthorizeRequest        *hook.Hook[*RecordOIDCAuthorizeRequestEvent]
+	onRecordOIDCTokenRequest            *hook.Hook[*RecordOIDCTokenRequestEvent]
+	onRecordAuthWithRefreshTokenRequest *hook.Hook[*RecordAuthWithRefreshTokenRequestEvent]
+	onRecordCreateAPIKeyRequest         *hook.Hook[*RecordCreateAPIKeyRequestEvent]
 
 	// record crud API event hooks
 	onRecordsListRequest  *hook.Hook[*RecordsListRequestEvent]
@@ -297,6 +316,8 @@ func (app *BaseApp) initHooks() {
 	app.onRealtimeConnectRequest = &hook.Hook[*RealtimeConnectRequestEvent]{}
 	app.onRealtimeMessageSend = &hook.Hook[*RealtimeMessageEvent]{}
 	app.onRealtimeSubscribeRequest = &hook.Hook[*RealtimeSubscribeRequestEvent]{}
+	app.onRealtimePublishRequest = &hook.Hook[*RealtimePublishRequestEvent]{}
+	app.onRealtimePresenceChange = &hook.Hook[*RealtimePresenceEvent]{}
 
 	// settings event hooks
 	app.onSettingsListRequest = &hook.Hook[*SettingsListRequestEvent]{}
@@ -320,6 +341,15 @@ func (app *BaseApp) initHooks() {
 	app.onRecordConfirmEmailChangeRequest = &hook.Hook[*RecordConfirmEmailChangeRequestEvent]{}
 	app.onRecordRequestOTPRequest = &hook.Hook[*RecordCreateOTPRequestEvent]{}
 	app.onRecordAuthWithOTPRequest = &hook.Hook[*RecordAuthWithOTPRequestEvent]{}
+	app.onRecordWebAuthnRegisterRequest = &hook.Hook[*RecordWebAuthnRegisterRequestEvent]{}
+	app.onRecordAuthWithWebAuthnRequest = &hook.Hook[*RecordAuthWithWebAuthnRequestEvent]{}
+	app.onRecordAuthWithTOTPRequest = &hook.Hook[*RecordAuthWithTOTPRequestEvent]{}
+	app.onRecordAuthWithLDAPRequest = &hook.Hook[*RecordAuthWithLDAPRequestEvent]{}
+	app.onRecordAuthWithSAMLRequest = &hook.Hook[*RecordAuthWithSAMLRequestEvent]{}
+	app.onRecordOIDCAuthorizeRequest = &hook.Hook[*RecordOIDCAuthorizeRequestEvent]{}
+	app.onRecordOIDCTokenRequest = &hook.Hook[*RecordOIDCTokenRequestEvent]{}
+	app.onRecordAuthWithRefreshTokenRequest = &hook.Hook[*RecordAuthWithRefreshTokenRequestEvent]{}
+	app.onRecordCreateAPIKeyRequest = &hook.Hook[*RecordCreateAPIKeyRequestEvent]{}
 
 	// record crud API event hooks
 	app.onRecordsListRequest = &hook.Hook[*RecordsListRequestEvent]{}
@@ -609,6 +639,14 @@ func (app *BaseApp) Cron() *cron.Cron {
 	return app.cron
 }
 
+// Tracer returns the app spans tracer.
+//
+// It could be nil if tracing is not enabled
+// (all tracer methods are safe to be called on a nil instance).
+func (app *BaseApp) Tracer() *tracing.Tracer {
+	return app.config.Tracer
+}
+
 // SubscriptionsBroker returns the app realtime subscriptions broker instance.
 func (app *BaseApp) SubscriptionsBroker() *subscriptions.Broker {
 	return app.subscriptionsBroker
@@ -712,8 +750,11 @@ func (app *BaseApp) NewMailClient() mailer.Mailer {
 // NB! Make sure to call Close() on the returned result
 // after you are done working with it.
 func (app *BaseApp) NewFilesystem() (*filesystem.System, error) {
+	var fsys *filesystem.System
+	var err error
+
 	if app.settings != nil && app.settings.S3.Enabled {
-		return filesystem.NewS3(
+		fsys, err = filesystem.NewS3(
 			app.settings.S3.Bucket,
 			app.settings.S3.Region,
 			app.settings.S3.Endpoint,
@@ -721,10 +762,16 @@ func (app *BaseApp) NewFilesystem() (*filesystem.System, error) {
 			app.settings.S3.Secret,
 			app.settings.S3.ForcePathStyle,
 		)
+	} else {
+		// fallback to local filesystem
+		fsys, err = filesystem.NewLocal(filepath.Join(app.DataDir(), LocalStorageDirName))
 	}
 
-	// fallback to local filesystem
-	return filesystem.NewLocal(filepath.Join(app.DataDir(), LocalStorageDirName))
+	if err == nil {
+		fsys.SetTracer(app.Tracer())
+	}
+
+	return fsys, err
 }
 
 // NewBackupsFilesystem creates a new local or S3 filesystem instance
@@ -733,8 +780,11 @@ func (app *BaseApp) NewFilesystem() (*filesystem.System, error) {
 // NB! Make sure to call Close() on the returned result
 // after you are done working with it.
 func (app *BaseApp) NewBackupsFilesystem() (*filesystem.System, error) {
+	var fsys *filesystem.System
+	var err error
+
 	if app.settings != nil && app.settings.Backups.S3.Enabled {
-		return filesystem.NewS3(
+		fsys, err = filesystem.NewS3(
 			app.settings.Backups.S3.Bucket,
 			app.settings.Backups.S3.Region,
 			app.settings.Backups.S3.Endpoint,
@@ -742,10 +792,16 @@ func (app *BaseApp) NewBackupsFilesystem() (*filesystem.System, error) {
 			app.settings.Backups.S3.Secret,
 			app.settings.Backups.S3.ForcePathStyle,
 		)
+	} else {
+		// fallback to local filesystem
+		fsys, err = filesystem.NewLocal(filepath.Join(app.DataDir(), LocalBackupsDirName))
+	}
+
+	if err == nil {
+		fsys.SetTracer(app.Tracer())
 	}
 
-	// fallback to local filesystem
-	return filesystem.NewLocal(filepath.Join(app.DataDir(), LocalBackupsDirName))
+	return fsys, err
 }
 
 // Restart restarts (aka. replaces) the current running application process.
@@ -1031,6 +1087,14 @@ func (app *BaseApp) OnRealtimeSubscribeRequest() *hook.Hook[*RealtimeSubscribeRe
 	return app.onRealtimeSubscribeRequest
 }
 
+func (app *BaseApp) OnRealtimePublishRequest() *hook.Hook[*RealtimePublishRequestEvent] {
+	return app.onRealtimePublishRequest
+}
+
+func (app *BaseApp) OnRealtimePresenceChange() *hook.Hook[*RealtimePresenceEvent] {
+	return app.onRealtimePresenceChange
+}
+
 // -------------------------------------------------------------------
 // Settings API event hooks
 // -------------------------------------------------------------------
@@ -1111,6 +1175,42 @@ func (app *BaseApp) OnRecordAuthWithOTPRequest(tags ...string) *hook.TaggedHook[
 	return hook.NewTaggedHook(app.onRecordAuthWithOTPRequest, tags...)
 }
 
+func (app *BaseApp) OnRecordWebAuthnRegisterRequest(tags ...string) *hook.TaggedHook[*RecordWebAuthnRegisterRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordWebAuthnRegisterRequest, tags...)
+}
+
+func (app *BaseApp) OnRecordAuthWithWebAuthnRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithWebAuthnRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordAuthWithWebAuthnRequest, tags...)
+}
+
+func (app *BaseApp) OnRecordAuthWithTOTPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithTOTPRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordAuthWithTOTPRequest, tags...)
+}
+
+func (app *BaseApp) OnRecordAuthWithLDAPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithLDAPRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordAuthWithLDAPRequest, tags...)
+}
+
+func (app *BaseApp) OnRecordAuthWithSAMLRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithSAMLRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordAuthWithSAMLRequest, tags...)
+}
+
+func (app *BaseApp) OnRecordOIDCAuthorizeRequest(tags ...string) *hook.TaggedHook[*RecordOIDCAuthorizeRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordOIDCAuthorizeRequest, tags...)
+}
+
+func (app *BaseApp) OnRecordOIDCTokenRequest(tags ...string) *hook.TaggedHook[*RecordOIDCTokenRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordOIDCTokenRequest, tags...)
+}
+
+func (app *BaseApp) OnRecordAuthWithRefreshTokenRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithRefreshTokenRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordAuthWithRefreshTokenRequest, tags...)
+}
+
+func (app *BaseApp) OnRecordCreateAPIKeyRequest(tags ...string) *hook.TaggedHook[*RecordCreateAPIKeyRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordCreateAPIKeyRequest, tags...)
+}
+
 // -------------------------------------------------------------------
 // Record CRUD API event hooks
 // -------------------------------------------------------------------
@@ -1190,15 +1290,8 @@ func (app *BaseApp) initDataDB() error {
 	nonconcurrentDB.DB().SetMaxIdleConns(1)
 	nonconcurrentDB.DB().SetConnMaxIdleTime(3 * time.Minute)
 
-	if app.IsDev() {
-		nonconcurrentDB.QueryLogFunc = func(ctx context.Context, t time.Duration, sql string, rows *sql.Rows, err error) {
-			color.HiBlack("[%.2fms] %v\n", float64(t.Milliseconds()), normalizeSQLLog(sql))
-		}
-		nonconcurrentDB.ExecLogFunc = func(ctx context.Context, t time.Duration, sql string, result sql.Result, err error) {
-			color.HiBlack("[%.2fms] %v\n", float64(t.Milliseconds()), normalizeSQLLog(sql))
-		}
-		concurrentDB.QueryLogFunc = nonconcurrentDB.QueryLogFunc
-		concurrentDB.ExecLogFunc = nonconcurrentDB.ExecLogFunc
+	if app.IsDev() || app.Tracer() != nil {
+		app.bindDBLogFuncs("data", app.IsDev(), concurrentDB, nonconcurrentDB)
 	}
 
 	app.concurrentDB = concurrentDB
@@ -1207,6 +1300,68 @@ func (app *BaseApp) initDataDB() error {
 	return nil
 }
 
+// bindDBLogFuncs registers dbx log funcs that are invoked after each executed statement
+// to optionally print it to the stderr and/or to record it as tracing span.
+func (app *BaseApp) bindDBLogFuncs(dbName string, print bool, dbs ...*dbx.DB) {
+	logFunc := func(ctx context.Context, t time.Duration, sql string, err error) {
+		if print {
+			color.HiBlack("[%.2fms] %v\n", float64(t.Milliseconds()), normalizeSQLLog(sql))
+		}
+
+		tracer := app.Tracer()
+		if tracer == nil {
+			return
+		}
+
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		end := time.Now()
+
+		statement := sanitizeSQLForTracing(normalizeSQLLog(sql))
+
+		operation, _, _ := strings.Cut(strings.TrimSpace(statement), " ")
+
+		_, span := tracer.Start(
+			ctx,
+			"db "+strings.ToUpper(operation),
+			tracing.WithKind(tracing.SpanKindClient),
+			tracing.WithStartTime(end.Add(-t)),
+			tracing.WithAttributes(map[string]any{
+				"db.system":     "sqlite",
+				"db.namespace":  dbName,
+				"db.query.text": statement,
+			}),
+		)
+		span.RecordError(err)
+		span.EndAt(end)
+	}
+
+	for _, db := range dbs {
+		db.QueryLogFunc = func(ctx context.Context, t time.Duration, sql string, rows *sql.Rows, err error) {
+			logFunc(ctx, t, sql, err)
+		}
+		db.ExecLogFunc = func(ctx context.Context, t time.Duration, sql string, result sql.Result, err error) {
+			logFunc(ctx, t, sql, err)
+		}
+	}
+}
+
+var sqlTracingLiterals = regexp.MustCompile(`'(?:[^']|'')*'|\b0x[0-9a-fA-F]+\b`)
+
+// sanitizeSQLForTracing replaces the inlined string and blob literals with "?"
+// to minimize the risk of exporting sensitive data as part of the db spans.
+func sanitizeSQLForTracing(sql string) string {
+	sql = sqlTracingLiterals.ReplaceAllString(sql, "?")
+
+	if len(sql) > 2000 {
+		sql = sql[:2000] + "..."
+	}
+
+	return sql
+}
+
 var sqlLogReplacements = []struct {
 	pattern     *regexp.Regexp
 	replacement string
@@ -1252,6 +1407,10 @@ func (app *BaseApp) initAuxDB() error {
 	nonconcurrentDB.DB().SetMaxIdleConns(1)
 	nonconcurrentDB.DB().SetConnMaxIdleTime(3 * time.Minute)
 
+	if app.Tracer() != nil {
+		app.bindDBLogFuncs("auxiliary", false, concurrentDB, nonconcurrentDB)
+	}
+
 	app.auxConcurrentDB = concurrentDB
 	app.auxNonconcurrentDB = nonconcurrentDB
 
@@ -1356,6 +1515,18 @@ func (app *BaseApp) registerBaseHooks() {
 		Priority: 999,
 	})
 
+	app.OnTerminate().Bind(&hook.Handler[*TerminateEvent]{
+		Id: "__pbTracerFlush__",
+		Func: func(e *TerminateEvent) error {
+			if err := app.Tracer().Flush(context.Background()); err != nil {
+				app.Logger().Warn("Failed to export the remaining tracing spans", slog.String("error", err.Error()))
+			}
+
+			return e.Next()
+		},
+		Priority: -998,
+	})
+
 	app.Cron().Add("__pbDBOptimize__", "0 0 * * *", func() {
 		_, execErr := app.NonconcurrentDB().NewQuery("PRAGMA wal_checkpoint(TRUNCATE)").Execute()
 		if execErr != nil {
@@ -1382,6 +1553,17 @@ func (app *BaseApp) registerBaseHooks() {
 	app.registerMFAHooks()
 	app.registerOTPHooks()
 	app.registerAuthOriginHooks()
+	app.registerWebAuthnCredentialHooks()
+	app.registerTOTPHooks()
+	app.registerJWTKeyHooks()
+	app.registerOIDCClientHooks()
+	app.registerOIDCConsentHooks()
+	app.registerOIDCTokenHooks()
+	app.registerAuthSessionHooks()
+	app.registerAPIKeyHooks()
+	app.registerAuthLockoutHooks()
+	app.registerPasswordHistoryHooks()
+	app.registerAuthChallengeHooks()
 }
 
 // getLoggerMinLevel returns the logger min level based on the
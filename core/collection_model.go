@@ -564,12 +564,28 @@ func (m Collection) MarshalJSON() ([]byte, error) {
 			alias.OAuth2.Providers = []OAuth2ProviderConfig{}
 		}
 
+		// ensure that it is always returned as object
+		if alias.LDAP.MappedFields == nil {
+			alias.LDAP.MappedFields = map[string]string{}
+		}
+		if alias.LDAP.GroupRoles == nil {
+			alias.LDAP.GroupRoles = map[string]string{}
+		}
+
+		// ensure that it is always returned as array
+		if alias.SAML.RedirectURLs == nil {
+			alias.SAML.RedirectURLs = []string{}
+		}
+
 		// hide secret keys from the serialization
 		alias.AuthToken.Secret = ""
 		alias.FileToken.Secret = ""
 		alias.PasswordResetToken.Secret = ""
 		alias.EmailChangeToken.Secret = ""
 		alias.VerificationToken.Secret = ""
+		alias.TOTP.Secret = ""
+		alias.LDAP.BindPassword = ""
+		alias.SAML.PrivateKey = ""
 		for i := range alias.OAuth2.Providers {
 			alias.OAuth2.Providers[i].ClientSecret = ""
 		}
@@ -1,6 +1,10 @@
 package core
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -8,9 +12,13 @@ import (
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/pocketbase/pocketbase/tools/auth"
+	"github.com/pocketbase/pocketbase/tools/ldap"
 	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/saml"
 	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/totp"
 	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/pocketbase/pocketbase/tools/webauthn"
 	"github.com/spf13/cast"
 )
 
@@ -19,27 +27,32 @@ func (m *Collection) unsetMissingOAuth2MappedFields() {
 		return
 	}
 
-	if m.OAuth2.MappedFields.Id != "" {
-		if m.Fields.GetByName(m.OAuth2.MappedFields.Id) == nil {
-			m.OAuth2.MappedFields.Id = ""
+	m.unsetMissingKnownFields(&m.OAuth2.MappedFields)
+	m.unsetMissingKnownFields(&m.SAML.MappedFields)
+}
+
+func (m *Collection) unsetMissingKnownFields(mappedFields *OAuth2KnownFields) {
+	if mappedFields.Id != "" {
+		if m.Fields.GetByName(mappedFields.Id) == nil {
+			mappedFields.Id = ""
 		}
 	}
 
-	if m.OAuth2.MappedFields.Name != "" {
-		if m.Fields.GetByName(m.OAuth2.MappedFields.Name) == nil {
-			m.OAuth2.MappedFields.Name = ""
+	if mappedFields.Name != "" {
+		if m.Fields.GetByName(mappedFields.Name) == nil {
+			mappedFields.Name = ""
 		}
 	}
 
-	if m.OAuth2.MappedFields.Username != "" {
-		if m.Fields.GetByName(m.OAuth2.MappedFields.Username) == nil {
-			m.OAuth2.MappedFields.Username = ""
+	if mappedFields.Username != "" {
+		if m.Fields.GetByName(mappedFields.Username) == nil {
+			mappedFields.Username = ""
 		}
 	}
 
-	if m.OAuth2.MappedFields.AvatarURL != "" {
-		if m.Fields.GetByName(m.OAuth2.MappedFields.AvatarURL) == nil {
-			m.OAuth2.MappedFields.AvatarURL = ""
+	if mappedFields.AvatarURL != "" {
+		if m.Fields.GetByName(mappedFields.AvatarURL) == nil {
+			mappedFields.AvatarURL = ""
 		}
 	}
 }
@@ -51,8 +64,9 @@ func (m *Collection) setDefaultAuthOptions() {
 		ConfirmEmailChangeTemplate: defaultConfirmEmailChangeTemplate,
 		AuthRule:                   types.Pointer(""),
 		AuthAlert: AuthAlertConfig{
-			Enabled:       true,
-			EmailTemplate: defaultAuthAlertTemplate,
+			Enabled:              true,
+			EmailTemplate:        defaultAuthAlertTemplate,
+			LockoutEmailTemplate: defaultLockoutAlertTemplate,
 		},
 		PasswordAuth: PasswordAuthConfig{
 			Enabled:        true,
@@ -68,6 +82,58 @@ func (m *Collection) setDefaultAuthOptions() {
 			Length:        8,
 			EmailTemplate: defaultOTPTemplate,
 		},
+		WebAuthn: WebAuthnConfig{
+			Enabled:          false,
+			Duration:         180, // 3min
+			UserVerification: webauthn.UserVerificationPreferred,
+		},
+		TOTP: TOTPConfig{
+			Enabled:       false,
+			Duration:      600, // 10min
+			Digits:        totp.DefaultDigits,
+			Period:        totp.DefaultPeriod,
+			Skew:          1,
+			RecoveryCodes: 10,
+			Secret:        security.RandomString(32),
+		},
+		OIDC: OIDCConfig{
+			Enabled:              false,
+			CodeDuration:         60,      // 1min
+			IdTokenDuration:      3600,    // 1h
+			RefreshTokenDuration: 2592000, // 30days
+		},
+		Sessions: SessionsConfig{
+			Enabled:              false,
+			AccessTokenDuration:  900,     // 15min
+			RefreshTokenDuration: 2592000, // 30days
+		},
+		APIKeys: APIKeysConfig{
+			Enabled: false,
+		},
+		Lockout: LockoutConfig{
+			Enabled:       false,
+			MaxFailures:   5,
+			MaxIPFailures: 20,
+			Duration:      300,   // 5min
+			MaxDuration:   86400, // 1day
+		},
+		LDAP: LDAPConfig{
+			Enabled:        false,
+			UserFilter:     "(uid={username})",
+			GroupAttribute: "memberOf",
+			MappedFields:   map[string]string{},
+			GroupRoles:     map[string]string{},
+			Timeout:        10,
+		},
+		SAML: SAMLConfig{
+			Enabled:  false,
+			Duration: 300, // 5min
+			Attributes: SAMLKnownAttributes{
+				Email:    "email",
+				Name:     "name",
+				Username: "username",
+			},
+		},
 		AuthToken: TokenConfig{
 			Secret:   security.RandomString(50),
 			Duration: 604800, // 7 days
@@ -131,6 +197,38 @@ type collectionAuthOptions struct {
 	// OTP defines options related to the One-time password authentication (OTP).
 	OTP OTPConfig `form:"otp" json:"otp"`
 
+	// WebAuthn defines options related to the WebAuthn (passkeys) authentication.
+	WebAuthn WebAuthnConfig `form:"webauthn" json:"webauthn"`
+
+	// TOTP defines options related to the authenticator app (TOTP) second factor.
+	TOTP TOTPConfig `form:"totp" json:"totp"`
+
+	// OIDC defines options related to the OpenID Connect provider mode
+	// (aka. "Sign in with PocketBase" for other apps).
+	OIDC OIDCConfig `form:"oidc" json:"oidc"`
+
+	// Sessions defines options related to the short-lived auth tokens
+	// and the server-side sessions with rotating refresh tokens.
+	Sessions SessionsConfig `form:"sessions" json:"sessions"`
+
+	// APIKeys defines options related to the long-lived scoped
+	// API keys that the auth records could create for integrations.
+	APIKeys APIKeysConfig `form:"apiKeys" json:"apiKeys"`
+
+	// Lockout defines options related to the brute-force protection
+	// of the collection auth endpoints (aka. temporary account lockouts).
+	Lockout LockoutConfig `form:"lockout" json:"lockout"`
+
+	// PasswordPolicy defines additional rules for the auth records
+	// password (character classes, reuse, max age, breached passwords).
+	PasswordPolicy PasswordPolicyConfig `form:"passwordPolicy" json:"passwordPolicy"`
+
+	// LDAP defines options related to the LDAP / Active Directory authentication.
+	LDAP LDAPConfig `form:"ldap" json:"ldap"`
+
+	// SAML defines options related to the SAML 2.0 service provider authentication.
+	SAML SAMLConfig `form:"saml" json:"saml"`
+
 	// Various token configurations
 	// ---
 	AuthToken          TokenConfig `form:"authToken" json:"authToken"`
@@ -163,6 +261,15 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 		validation.Field(&o.PasswordAuth),
 		validation.Field(&o.OAuth2),
 		validation.Field(&o.OTP),
+		validation.Field(&o.WebAuthn),
+		validation.Field(&o.TOTP),
+		validation.Field(&o.OIDC),
+		validation.Field(&o.Sessions),
+		validation.Field(&o.APIKeys),
+		validation.Field(&o.Lockout),
+		validation.Field(&o.PasswordPolicy),
+		validation.Field(&o.LDAP),
+		validation.Field(&o.SAML),
 		validation.Field(&o.MFA),
 		validation.Field(&o.AuthToken),
 		validation.Field(&o.PasswordResetToken),
@@ -192,6 +299,18 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 		if o.OTP.Enabled {
 			authsEnabled++
 		}
+		if o.WebAuthn.Enabled {
+			authsEnabled++
+		}
+		if o.TOTP.Enabled {
+			authsEnabled++
+		}
+		if o.LDAP.Enabled {
+			authsEnabled++
+		}
+		if o.SAML.Enabled {
+			authsEnabled++
+		}
 		if authsEnabled < 2 {
 			return validation.Errors{
 				"mfa": validation.Errors{
@@ -219,6 +338,22 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 		}
 	}
 
+	// TOTP could be used only as a second factor
+	if o.TOTP.Enabled && !o.MFA.Enabled {
+		return validation.Errors{
+			"totp": validation.Errors{
+				"enabled": validation.NewError("validation_totp_requires_mfa", "TOTP requires MFA to be enabled."),
+			},
+		}
+	}
+
+	if o.LDAP.Enabled {
+		err = o.validateLDAPFields(cv)
+		if err != nil {
+			return validation.Errors{"ldap": err}
+		}
+	}
+
 	// extra check to ensure that only unique identity fields are used
 	if o.PasswordAuth.Enabled {
 		err = validation.Validate(o.PasswordAuth.IdentityFields, validation.By(cv.checkFieldsForUniqueIndex))
@@ -234,6 +369,31 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 	return nil
 }
 
+// validateLDAPFields checks whether the LDAP mapped and roles fields
+// exist in the collection and are safe to be synced.
+func (o *collectionAuthOptions) validateLDAPFields(cv *collectionValidator) error {
+	forbidden := []string{FieldNameId, FieldNamePassword, FieldNameTokenKey}
+
+	for name, attr := range o.LDAP.MappedFields {
+		if attr == "" || cv.new.Fields.GetByName(name) == nil || list.ExistInSlice(name, forbidden) {
+			return validation.Errors{
+				"mappedFields": validation.NewError("validation_invalid_ldap_mapped_field", "Invalid or missing mapped field {{.name}}.").
+					SetParams(map[string]any{"name": name}),
+			}
+		}
+	}
+
+	if o.LDAP.RolesField != "" {
+		if cv.new.Fields.GetByName(o.LDAP.RolesField) == nil || list.ExistInSlice(o.LDAP.RolesField, forbidden) {
+			return validation.Errors{
+				"rolesField": validation.NewError("validation_invalid_ldap_roles_field", "Invalid or missing roles field."),
+			}
+		}
+	}
+
+	return nil
+}
+
 // -------------------------------------------------------------------
 
 type EmailTemplate struct {
@@ -273,6 +433,13 @@ func (t EmailTemplate) Resolve(placeholders map[string]any) (subject, body strin
 type AuthAlertConfig struct {
 	Enabled       bool          `form:"enabled" json:"enabled"`
 	EmailTemplate EmailTemplate `form:"emailTemplate" json:"emailTemplate"`
+
+	// LockoutEmailTemplate is the email template that is sent to the
+	// auth record when it is temporarily locked due to too many failed
+	// auth attempts (see [LockoutConfig]).
+	//
+	// Leave it empty to use the default lockout template.
+	LockoutEmailTemplate EmailTemplate `form:"lockoutEmailTemplate" json:"lockoutEmailTemplate"`
 }
 
 // Validate makes AuthAlertConfig validatable by implementing [validation.Validatable] interface.
@@ -281,9 +448,20 @@ func (c AuthAlertConfig) Validate() error {
 		// note: for now always run the email template validations even
 		// if not enabled since it could be used separately
 		validation.Field(&c.EmailTemplate),
+		validation.Field(&c.LockoutEmailTemplate, validation.Skip.When(c.LockoutEmailTemplate == EmailTemplate{})),
 	)
 }
 
+// LockoutTemplate returns the configured LockoutEmailTemplate
+// or the default one if it is not set.
+func (c AuthAlertConfig) LockoutTemplate() EmailTemplate {
+	if c.LockoutEmailTemplate == (EmailTemplate{}) {
+		return defaultLockoutAlertTemplate
+	}
+
+	return c.LockoutEmailTemplate
+}
+
 // -------------------------------------------------------------------
 
 type TokenConfig struct {
@@ -342,6 +520,781 @@ func (c OTPConfig) DurationTime() time.Duration {
 
 // -------------------------------------------------------------------
 
+type WebAuthnConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// Duration specifies how long the registration and authentication
+	// ceremonies to be valid (in seconds).
+	Duration int64 `form:"duration" json:"duration"`
+
+	// RPId is the WebAuthn relying party identifier (e.g. "example.com").
+	//
+	// Leave it empty to use the host of the app URL setting.
+	RPId string `form:"rpId" json:"rpId"`
+
+	// RPName is the relying party name shown by the authenticators.
+	//
+	// Leave it empty to use the app name setting.
+	RPName string `form:"rpName" json:"rpName"`
+
+	// Origins is the list of the allowed client origins (e.g. "https://example.com").
+	//
+	// Leave it empty to use the origin of the app URL setting.
+	Origins []string `form:"origins" json:"origins"`
+
+	// UserVerification specifies the authenticator user verification
+	// requirement ("required", "preferred" or "discouraged").
+	UserVerification string `form:"userVerification" json:"userVerification"`
+}
+
+// Validate makes WebAuthnConfig validatable by implementing [validation.Validatable] interface.
+func (c WebAuthnConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Duration, validation.When(c.Enabled, validation.Required, validation.Min(10), validation.Max(86400))),
+		validation.Field(&c.RPId, is.Host),
+		validation.Field(&c.Origins, validation.Each(is.URL)),
+		validation.Field(
+			&c.UserVerification,
+			validation.In(
+				webauthn.UserVerificationRequired,
+				webauthn.UserVerificationPreferred,
+				webauthn.UserVerificationDiscouraged,
+			),
+		),
+	)
+}
+
+// DurationTime returns the current Duration as [time.Duration].
+func (c WebAuthnConfig) DurationTime() time.Duration {
+	return time.Duration(c.Duration) * time.Second
+}
+
+// -------------------------------------------------------------------
+
+type TOTPConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// Duration specifies how long the enrollment setup to be valid (in seconds).
+	Duration int64 `form:"duration" json:"duration"`
+
+	// Issuer is the provisioning URI issuer shown by the authenticator apps.
+	//
+	// Leave it empty to use the app name setting.
+	Issuer string `form:"issuer" json:"issuer"`
+
+	// Digits specifies the code length (6 or 8).
+	Digits int `form:"digits" json:"digits"`
+
+	// Period specifies the code time step (in seconds).
+	Period int64 `form:"period" json:"period"`
+
+	// Skew specifies the number of the allowed time steps before and
+	// after the current one to compensate for clock drift.
+	Skew int `form:"skew" json:"skew"`
+
+	// RecoveryCodes specifies the number of the generated one-time recovery codes.
+	RecoveryCodes int `form:"recoveryCodes" json:"recoveryCodes"`
+
+	// Secret is the key used to encrypt the stored TOTP secrets
+	// (must be exactly 32 characters).
+	//
+	// Note that changing it invalidates all existing TOTP enrollments.
+	Secret string `form:"secret" json:"secret,omitempty"`
+}
+
+// Validate makes TOTPConfig validatable by implementing [validation.Validatable] interface.
+func (c TOTPConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Duration, validation.When(c.Enabled, validation.Required, validation.Min(10), validation.Max(86400))),
+		validation.Field(&c.Issuer, validation.Length(0, 255), validation.By(checkTOTPIssuer)),
+		validation.Field(&c.Digits, validation.When(c.Enabled, validation.Required), validation.In(6, 8)),
+		validation.Field(&c.Period, validation.When(c.Enabled, validation.Required, validation.Min(15), validation.Max(300))),
+		validation.Field(&c.Skew, validation.Min(0), validation.Max(5)),
+		validation.Field(&c.RecoveryCodes, validation.Min(0), validation.Max(50)),
+		validation.Field(&c.Secret, validation.When(c.Enabled, validation.Required), validation.Length(32, 32)),
+	)
+}
+
+// DurationTime returns the current Duration as [time.Duration].
+func (c TOTPConfig) DurationTime() time.Duration {
+	return time.Duration(c.Duration) * time.Second
+}
+
+// Options returns the TOTP codes generation and verification options.
+func (c TOTPConfig) Options() totp.Options {
+	return totp.Options{
+		Digits: c.Digits,
+		Period: c.Period,
+		Skew:   c.Skew,
+	}
+}
+
+func checkTOTPIssuer(value any) error {
+	v, _ := value.(string)
+
+	// the issuer is used as part of the otpauth URI label
+	if strings.Contains(v, ":") {
+		return validation.NewError("validation_invalid_totp_issuer", "The issuer must not contain colon.")
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+type OIDCConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// LoginURL is the app frontend page where the /authorize requests are redirected
+	// for authenticating the user and showing the consent screen.
+	//
+	// The authorization request parameters are forwarded as query parameters.
+	LoginURL string `form:"loginURL" json:"loginURL"`
+
+	// CodeDuration specifies how long an issued authorization code to be valid (in seconds).
+	CodeDuration int64 `form:"codeDuration" json:"codeDuration"`
+
+	// IdTokenDuration specifies how long an issued ID token to be valid (in seconds).
+	IdTokenDuration int64 `form:"idTokenDuration" json:"idTokenDuration"`
+
+	// RefreshTokenDuration specifies how long an issued refresh token to be valid (in seconds).
+	//
+	// Set it to 0 to disable the refresh tokens (aka. the "offline_access" scope).
+	RefreshTokenDuration int64 `form:"refreshTokenDuration" json:"refreshTokenDuration"`
+}
+
+// Validate makes OIDCConfig validatable by implementing [validation.Validatable] interface.
+func (c OIDCConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.LoginURL, validation.When(c.Enabled, validation.Required), is.URL),
+		validation.Field(&c.CodeDuration, validation.When(c.Enabled, validation.Required, validation.Min(10), validation.Max(600))),
+		validation.Field(&c.IdTokenDuration, validation.When(c.Enabled, validation.Required, validation.Min(60), validation.Max(86400))),
+		validation.Field(&c.RefreshTokenDuration, validation.Min(0), validation.Max(31536000)), // 1y max
+	)
+}
+
+// CodeDurationTime returns the current CodeDuration as [time.Duration].
+func (c OIDCConfig) CodeDurationTime() time.Duration {
+	return time.Duration(c.CodeDuration) * time.Second
+}
+
+// IdTokenDurationTime returns the current IdTokenDuration as [time.Duration].
+func (c OIDCConfig) IdTokenDurationTime() time.Duration {
+	return time.Duration(c.IdTokenDuration) * time.Second
+}
+
+// RefreshTokenDurationTime returns the current RefreshTokenDuration as [time.Duration].
+func (c OIDCConfig) RefreshTokenDurationTime() time.Duration {
+	return time.Duration(c.RefreshTokenDuration) * time.Second
+}
+
+// -------------------------------------------------------------------
+
+type SessionsConfig struct {
+	// Enabled specifies whether to issue a short-lived access token and
+	// a rotating refresh token on each new authentication.
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// AccessTokenDuration specifies how long an issued session access token to be valid (in seconds).
+	//
+	// The session access tokens are non-refreshable auth tokens and
+	// are used instead of the collection AuthToken duration.
+	AccessTokenDuration int64 `form:"accessTokenDuration" json:"accessTokenDuration"`
+
+	// RefreshTokenDuration specifies for how long a session could stay
+	// inactive before its refresh token expires (in seconds).
+	//
+	// The expiration is extended on each refresh token use.
+	RefreshTokenDuration int64 `form:"refreshTokenDuration" json:"refreshTokenDuration"`
+}
+
+// Validate makes SessionsConfig validatable by implementing [validation.Validatable] interface.
+func (c SessionsConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.AccessTokenDuration, validation.When(c.Enabled, validation.Required, validation.Min(10), validation.Max(86400))),
+		validation.Field(&c.RefreshTokenDuration, validation.When(c.Enabled, validation.Required, validation.Min(c.AccessTokenDuration), validation.Max(31536000))), // 1y max
+	)
+}
+
+// AccessTokenDurationTime returns the current AccessTokenDuration as [time.Duration].
+func (c SessionsConfig) AccessTokenDurationTime() time.Duration {
+	return time.Duration(c.AccessTokenDuration) * time.Second
+}
+
+// RefreshTokenDurationTime returns the current RefreshTokenDuration as [time.Duration].
+func (c SessionsConfig) RefreshTokenDurationTime() time.Duration {
+	return time.Duration(c.RefreshTokenDuration) * time.Second
+}
+
+// -------------------------------------------------------------------
+
+type APIKeysConfig struct {
+	// Enabled specifies whether the collection records could
+	// create and authenticate with API keys.
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// MaxDuration specifies the max allowed API key lifetime (in seconds).
+	//
+	// Leave it empty (0) to allow API keys without expiration.
+	MaxDuration int64 `form:"maxDuration" json:"maxDuration"`
+}
+
+// Validate makes APIKeysConfig validatable by implementing [validation.Validatable] interface.
+func (c APIKeysConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxDuration, validation.Min(int64(0)), validation.Max(int64(315360000))), // 10y max
+	)
+}
+
+// MaxDurationTime returns the current MaxDuration as [time.Duration].
+func (c APIKeysConfig) MaxDurationTime() time.Duration {
+	return time.Duration(c.MaxDuration) * time.Second
+}
+
+// -------------------------------------------------------------------
+
+type LockoutConfig struct {
+	// Enabled specifies whether to track the failed password, OTP and
+	// MFA auth attempts and to temporarily lock the related identities and IPs.
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// MaxFailures specifies the number of failed auth attempts
+	// for a single identity before it is locked.
+	MaxFailures int `form:"maxFailures" json:"maxFailures"`
+
+	// MaxIPFailures specifies the number of failed auth attempts
+	// from a single IP (for any identity) before it is locked.
+	//
+	// Leave it empty (0) to disable the IP lockouts.
+	MaxIPFailures int `form:"maxIPFailures" json:"maxIPFailures"`
+
+	// Duration specifies the initial lockout duration (in seconds).
+	//
+	// The duration is doubled for each consecutive lockout of the same identity or IP.
+	Duration int64 `form:"duration" json:"duration"`
+
+	// MaxDuration specifies the max lockout duration (in seconds).
+	//
+	// It is also the time after the last failure when the failures
+	// counter and the consecutive lockouts are reset.
+	MaxDuration int64 `form:"maxDuration" json:"maxDuration"`
+}
+
+// Validate makes LockoutConfig validatable by implementing [validation.Validatable] interface.
+func (c LockoutConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxFailures, validation.When(c.Enabled, validation.Required, validation.Min(1), validation.Max(1000))),
+		validation.Field(&c.MaxIPFailures, validation.Min(0), validation.Max(100000)),
+		validation.Field(&c.Duration, validation.When(c.Enabled, validation.Required, validation.Min(int64(1)), validation.Max(int64(86400)))),
+		validation.Field(&c.MaxDuration, validation.When(c.Enabled, validation.Required, validation.Min(c.Duration), validation.Max(int64(31536000)))), // 1y max
+	)
+}
+
+// LockoutDuration returns the lockout duration for the specified
+// consecutive lockout (starting from 1) as [time.Duration].
+//
+// The duration is doubled for each consecutive lockout and it is capped to MaxDuration.
+func (c LockoutConfig) LockoutDuration(lockouts int) time.Duration {
+	duration := c.Duration
+	for i := 1; i < lockouts && duration < c.MaxDuration; i++ {
+		duration *= 2
+	}
+
+	return time.Duration(min(duration, c.MaxDuration)) * time.Second
+}
+
+// MaxDurationTime returns the current MaxDuration as [time.Duration].
+func (c LockoutConfig) MaxDurationTime() time.Duration {
+	return time.Duration(c.MaxDuration) * time.Second
+}
+
+// -------------------------------------------------------------------
+
+type PasswordPolicyConfig struct {
+	// RequireLowercase specifies whether the password must contain
+	// at least one lowercase letter.
+	RequireLowercase bool `form:"requireLowercase" json:"requireLowercase"`
+
+	// RequireUppercase specifies whether the password must contain
+	// at least one uppercase letter.
+	RequireUppercase bool `form:"requireUppercase" json:"requireUppercase"`
+
+	// RequireDigit specifies whether the password must contain at least one digit.
+	RequireDigit bool `form:"requireDigit" json:"requireDigit"`
+
+	// RequireSymbol specifies whether the password must contain at least
+	// one character that is not a letter or a digit.
+	RequireSymbol bool `form:"requireSymbol" json:"requireSymbol"`
+
+	// History specifies the number of previous passwords that cannot be reused.
+	//
+	// Leave it empty (0) to allow reusing previous passwords.
+	History int `form:"history" json:"history"`
+
+	// MaxAge specifies the max password age (in seconds) after which
+	// the user is required to change their password on the next login.
+	//
+	// Leave it empty (0) to disable the password expiration.
+	MaxAge int64 `form:"maxAge" json:"maxAge"`
+
+	// CheckBreached specifies whether to reject passwords found in the
+	// offline breached passwords list (pb_data/breached_passwords.txt).
+	CheckBreached bool `form:"checkBreached" json:"checkBreached"`
+}
+
+// Validate makes PasswordPolicyConfig validatable by implementing [validation.Validatable] interface.
+func (c PasswordPolicyConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.History, validation.Min(0), validation.Max(24)),
+		validation.Field(&c.MaxAge, validation.Min(int64(0)), validation.Max(int64(315360000))), // 10y max
+	)
+}
+
+// MaxAgeTime returns the current MaxAge as [time.Duration].
+func (c PasswordPolicyConfig) MaxAgeTime() time.Duration {
+	return time.Duration(c.MaxAge) * time.Second
+}
+
+// tracksHistory reports whether the password changes need to be recorded.
+func (c PasswordPolicyConfig) tracksHistory() bool {
+	return c.History > 0 || c.MaxAge > 0
+}
+
+// -------------------------------------------------------------------
+
+// LDAPUsernamePlaceholder is the LDAPConfig.UserFilter placeholder
+// that is replaced with the escaped submitted username.
+const LDAPUsernamePlaceholder = "{username}"
+
+type LDAPConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// URL is the directory server address in the format
+	// "ldap://host[:port]" or "ldaps://host[:port]".
+	URL string `form:"url" json:"url"`
+
+	// StartTLS specifies whether to upgrade the plain "ldap://"
+	// connection to TLS before sending any credentials.
+	StartTLS bool `form:"startTLS" json:"startTLS"`
+
+	// InsecureSkipVerify disables the TLS certificate verification
+	// (should be used only for testing).
+	InsecureSkipVerify bool `form:"insecureSkipVerify" json:"insecureSkipVerify"`
+
+	// BindDN and BindPassword are the service account credentials used
+	// to search for the user entry.
+	//
+	// Leave them empty to search anonymously.
+	BindDN       string `form:"bindDN" json:"bindDN"`
+	BindPassword string `form:"bindPassword" json:"bindPassword,omitempty"`
+
+	// BaseDN is the DN of the subtree where to search for the user entries
+	// (e.g. "ou=people,dc=example,dc=com").
+	BaseDN string `form:"baseDN" json:"baseDN"`
+
+	// UserFilter is the search filter used to find the user entry.
+	//
+	// It must contain the {username} placeholder, e.g.
+	// "(&(objectClass=person)(uid={username}))" or
+	// "(sAMAccountName={username})" for Active Directory.
+	UserFilter string `form:"userFilter" json:"userFilter"`
+
+	// IdAttribute is the entry attribute used as stable external id
+	// for linking the directory account with the auth record.
+	//
+	// Leave it empty to use the entry DN.
+	IdAttribute string `form:"idAttribute" json:"idAttribute"`
+
+	// MappedFields is a map with the collection field names as keys
+	// and the entry attribute names as values (e.g. {"name": "cn"}),
+	// that are synced on every successful authentication.
+	//
+	// The "email" field is mapped only when the record doesn't have one.
+	MappedFields map[string]string `form:"mappedFields" json:"mappedFields"`
+
+	// GroupAttribute is the entry attribute that lists the user groups DNs.
+	GroupAttribute string `form:"groupAttribute" json:"groupAttribute"`
+
+	// RolesField is the collection field where the roles from GroupRoles
+	// are synced on every successful authentication.
+	//
+	// Leave it empty to disable the group roles sync.
+	RolesField string `form:"rolesField" json:"rolesField"`
+
+	// GroupRoles is a map with the group DNs as keys and the
+	// related role values as values (the DNs are case-insensitive).
+	GroupRoles map[string]string `form:"groupRoles" json:"groupRoles"`
+
+	// Timeout specifies the connection and single operation timeout (in seconds).
+	Timeout int64 `form:"timeout" json:"timeout"`
+}
+
+// Validate makes LDAPConfig validatable by implementing [validation.Validatable] interface.
+func (c LDAPConfig) Validate() error {
+	if !c.Enabled {
+		return nil // no need to validate
+	}
+
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.URL, validation.Required, validation.Length(1, 2000), validation.By(checkLDAPURL(c.StartTLS))),
+		validation.Field(&c.BindDN, validation.Length(0, 1000)),
+		validation.Field(&c.BindPassword, validation.When(c.BindDN != "", validation.Required), validation.Length(0, 1000)),
+		validation.Field(&c.BaseDN, validation.Required, validation.Length(1, 1000)),
+		validation.Field(&c.UserFilter, validation.Required, validation.Length(1, 1000), validation.By(checkLDAPUserFilter)),
+		validation.Field(&c.IdAttribute, validation.Length(0, 255)),
+		validation.Field(&c.GroupAttribute, validation.When(c.RolesField != "", validation.Required), validation.Length(0, 255)),
+		validation.Field(&c.Timeout, validation.Min(int64(0)), validation.Max(int64(300))),
+	)
+}
+
+func checkLDAPURL(startTLS bool) validation.RuleFunc {
+	return func(value any) error {
+		v, _ := value.(string)
+		if v == "" {
+			return nil // nothing to check
+		}
+
+		u, err := url.Parse(v)
+		if err != nil || u.Hostname() == "" || (u.Scheme != "ldap" && u.Scheme != "ldaps") {
+			return validation.NewError("validation_invalid_ldap_url", "Must be a valid ldap:// or ldaps:// url.")
+		}
+
+		if startTLS && u.Scheme == "ldaps" {
+			return validation.NewError("validation_ldap_starttls_with_ldaps", "StartTLS cannot be used with ldaps:// urls.")
+		}
+
+		return nil
+	}
+}
+
+func checkLDAPUserFilter(value any) error {
+	v, _ := value.(string)
+	if v == "" {
+		return nil // nothing to check
+	}
+
+	if !strings.Contains(v, LDAPUsernamePlaceholder) {
+		return validation.NewError("validation_missing_username_placeholder", "The filter must contain the {username} placeholder.")
+	}
+
+	if _, err := ldap.CompileFilter(strings.ReplaceAll(v, LDAPUsernamePlaceholder, "test")); err != nil {
+		return validation.NewError("validation_invalid_ldap_filter", "Invalid LDAP search filter.")
+	}
+
+	return nil
+}
+
+// TimeoutTime returns the current Timeout as [time.Duration]
+// (fallbacks to [ldap.DefaultTimeout] if not set).
+func (c LDAPConfig) TimeoutTime() time.Duration {
+	if c.Timeout <= 0 {
+		return ldap.DefaultTimeout
+	}
+
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// UserFilterFor returns the UserFilter with the escaped username placeholder.
+func (c LDAPConfig) UserFilterFor(username string) string {
+	return strings.ReplaceAll(c.UserFilter, LDAPUsernamePlaceholder, ldap.EscapeFilter(username))
+}
+
+// ExternalId returns the stable external id of the provided
+// directory entry (the IdAttribute value or the entry DN).
+func (c LDAPConfig) ExternalId(entry *ldap.Entry) string {
+	if c.IdAttribute != "" {
+		return entry.Value(c.IdAttribute)
+	}
+
+	return entry.DN
+}
+
+// Roles returns the unique roles of the provided directory entry
+// based on its GroupAttribute values and the GroupRoles map.
+func (c LDAPConfig) Roles(entry *ldap.Entry) []string {
+	if c.GroupAttribute == "" || len(c.GroupRoles) == 0 {
+		return []string{}
+	}
+
+	roles := make([]string, 0, len(c.GroupRoles))
+
+	for _, group := range entry.Values(c.GroupAttribute) {
+		for groupDN, role := range c.GroupRoles {
+			if strings.EqualFold(strings.TrimSpace(groupDN), strings.TrimSpace(group)) && !list.ExistInSlice(role, roles) {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	return roles
+}
+
+// Authenticate searches for the directory entry of the provided
+// username and verifies its password with a bind operation.
+//
+// Returns an error matching [ldap.ErrInvalidCredentials] if the
+// user entry is missing, not unique or the password is wrong.
+func (c LDAPConfig) Authenticate(username string, password string) (*ldap.Entry, error) {
+	if username == "" || password == "" {
+		return nil, ldap.ErrInvalidCredentials
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+
+	conn, err := ldap.Dial(c.URL, tlsConfig, c.TimeoutTime())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the LDAP server: %w", err)
+	}
+	defer conn.Close()
+
+	if c.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			return nil, fmt.Errorf("failed to initialize StartTLS: %w", err)
+		}
+	}
+
+	if c.BindDN != "" {
+		// intentionally not wrapped to prevent the service account errors
+		// to be treated as invalid user credentials
+		if err := conn.Bind(c.BindDN, c.BindPassword); err != nil {
+			return nil, fmt.Errorf("failed to bind the LDAP service account: %v", err)
+		}
+	}
+
+	attributes := []string{"*"}
+	if c.IdAttribute != "" {
+		attributes = append(attributes, c.IdAttribute)
+	}
+	if c.GroupAttribute != "" {
+		// explicitly requested because it is usually an operational attribute
+		attributes = append(attributes, c.GroupAttribute)
+	}
+
+	entries, err := conn.Search(&ldap.SearchRequest{
+		BaseDN:     c.BaseDN,
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     c.UserFilterFor(username),
+		Attributes: attributes,
+		SizeLimit:  2,
+		TimeLimit:  int(c.TimeoutTime().Seconds()),
+	})
+	if err != nil {
+		var ldapErr *ldap.Error
+		if !errors.As(err, &ldapErr) || ldapErr.ResultCode != ldap.ResultSizeLimitExceeded {
+			return nil, fmt.Errorf("failed to search for the LDAP user entry: %w", err)
+		}
+	}
+
+	if len(entries) != 1 {
+		return nil, ldap.ErrInvalidCredentials
+	}
+
+	entry := entries[0]
+
+	if c.IdAttribute != "" && c.ExternalId(entry) == "" {
+		return nil, fmt.Errorf("missing LDAP user entry %q attribute", c.IdAttribute)
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// -------------------------------------------------------------------
+
+// SAMLKnownAttributes defines the assertion attribute names
+// of the commonly used user properties.
+type SAMLKnownAttributes struct {
+	Email     string `form:"email" json:"email"`
+	Name      string `form:"name" json:"name"`
+	Username  string `form:"username" json:"username"`
+	AvatarURL string `form:"avatarURL" json:"avatarURL"`
+}
+
+type SAMLConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// IdPMetadata is the identity provider metadata XML document.
+	IdPMetadata string `form:"idpMetadata" json:"idpMetadata"`
+
+	// EntityId is the service provider entity id.
+	//
+	// Leave it empty to use the collection SP metadata url.
+	EntityId string `form:"entityId" json:"entityId"`
+
+	// Certificate and PrivateKey are the PEM encoded service provider
+	// key pair used to sign the AuthnRequests (RSA or ECDSA).
+	Certificate string `form:"certificate" json:"certificate"`
+	PrivateKey  string `form:"privateKey" json:"privateKey,omitempty"`
+
+	// RedirectURLs is the list of the allowed client redirect urls
+	// after a successful or failed login (exact match).
+	//
+	// The first url is used for the IdP-initiated logins
+	// that don't specify an allowed url as RelayState.
+	RedirectURLs []string `form:"redirectURLs" json:"redirectURLs"`
+
+	// AllowIdPInitiated specifies whether to accept unsolicited
+	// (aka. IdP-initiated) responses.
+	AllowIdPInitiated bool `form:"allowIdPInitiated" json:"allowIdPInitiated"`
+
+	// IdAttribute is the assertion attribute used as stable external id
+	// for linking the IdP account with the auth record.
+	//
+	// Leave it empty to use the subject NameID.
+	IdAttribute string `form:"idAttribute" json:"idAttribute"`
+
+	// Attributes defines the assertion attribute names of the known user properties.
+	Attributes SAMLKnownAttributes `form:"attributes" json:"attributes"`
+
+	// MappedFields defines the collection fields where to store the
+	// known user properties when creating a new auth record
+	// (same as the OAuth2 mapped fields).
+	MappedFields OAuth2KnownFields `form:"mappedFields" json:"mappedFields"`
+
+	// Duration specifies how long the SP-initiated login requests
+	// to be valid (in seconds).
+	Duration int64 `form:"duration" json:"duration"`
+}
+
+// Validate makes SAMLConfig validatable by implementing [validation.Validatable] interface.
+func (c SAMLConfig) Validate() error {
+	if !c.Enabled {
+		return nil // no need to validate
+	}
+
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.IdPMetadata, validation.Required, validation.Length(1, 1000000), validation.By(checkSAMLIdPMetadata)),
+		validation.Field(&c.EntityId, validation.Length(0, 1000)),
+		validation.Field(&c.Certificate, validation.Required, validation.Length(1, 20000), validation.By(checkSAMLCertificate)),
+		validation.Field(&c.PrivateKey, validation.Required, validation.Length(1, 20000), validation.By(checkSAMLPrivateKey(c.Certificate))),
+		validation.Field(&c.RedirectURLs, validation.Required, validation.Length(1, 20), validation.Each(validation.Required, is.URL)),
+		validation.Field(&c.IdAttribute, validation.Length(0, 255)),
+		validation.Field(&c.Duration, validation.Required, validation.Min(10), validation.Max(3600)),
+	)
+}
+
+func checkSAMLIdPMetadata(value any) error {
+	v, _ := value.(string)
+	if v == "" {
+		return nil // nothing to check
+	}
+
+	if _, err := saml.ParseMetadata([]byte(v)); err != nil {
+		return validation.NewError("validation_invalid_saml_metadata", "Invalid IdP metadata document ({{.error}}).").
+			SetParams(map[string]any{"error": err.Error()})
+	}
+
+	return nil
+}
+
+func checkSAMLCertificate(value any) error {
+	v, _ := value.(string)
+	if v == "" {
+		return nil // nothing to check
+	}
+
+	if _, err := saml.ParseCertificatePEM(v); err != nil {
+		return validation.NewError("validation_invalid_saml_certificate", "Must be a valid PEM encoded certificate.")
+	}
+
+	return nil
+}
+
+func checkSAMLPrivateKey(certificate string) validation.RuleFunc {
+	return func(value any) error {
+		v, _ := value.(string)
+		if v == "" {
+			return nil // nothing to check
+		}
+
+		key, err := saml.ParsePrivateKeyPEM(v)
+		if err != nil {
+			return validation.NewError("validation_invalid_saml_private_key", "Must be a valid PEM encoded RSA or ECDSA private key.")
+		}
+
+		cert, err := saml.ParseCertificatePEM(certificate)
+		if err == nil && !saml.MatchKeyPair(cert, key) {
+			return validation.NewError("validation_saml_key_mismatch", "The private key doesn't match the certificate.")
+		}
+
+		return nil
+	}
+}
+
+// DurationTime returns the current Duration as [time.Duration].
+func (c SAMLConfig) DurationTime() time.Duration {
+	return time.Duration(c.Duration) * time.Second
+}
+
+// ServiceProvider initializes a new SAML service provider from the current config.
+//
+// defaultEntityId is used if EntityId is not set.
+func (c SAMLConfig) ServiceProvider(defaultEntityId string, acsURL string) (*saml.ServiceProvider, error) {
+	idp, err := saml.ParseMetadata([]byte(c.IdPMetadata))
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := saml.ParseCertificatePEM(c.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := saml.ParsePrivateKeyPEM(c.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	entityId := c.EntityId
+	if entityId == "" {
+		entityId = defaultEntityId
+	}
+
+	return &saml.ServiceProvider{
+		EntityId:    entityId,
+		ACSURL:      acsURL,
+		Key:         key,
+		Certificate: cert,
+		IdP:         idp,
+	}, nil
+}
+
+// IsAllowedRedirectURL reports whether the provided url is one of the RedirectURLs.
+func (c SAMLConfig) IsAllowedRedirectURL(redirectURL string) bool {
+	return redirectURL != "" && list.ExistInSlice(redirectURL, c.RedirectURLs)
+}
+
+// ExternalId returns the stable external id of the provided
+// assertion (the IdAttribute value or the subject NameID).
+func (c SAMLConfig) ExternalId(assertion *saml.Assertion) string {
+	if c.IdAttribute != "" {
+		return assertion.Value(c.IdAttribute)
+	}
+
+	return assertion.NameID
+}
+
+// Email returns the email of the provided assertion
+// (the email attribute value or the NameID with email address format).
+func (c SAMLConfig) Email(assertion *saml.Assertion) string {
+	if c.Attributes.Email != "" {
+		if email := assertion.Value(c.Attributes.Email); email != "" {
+			return email
+		}
+	}
+
+	if assertion.NameIDFormat == saml.NameIDFormatEmail {
+		return assertion.NameID
+	}
+
+	return ""
+}
+
+// -------------------------------------------------------------------
+
 type MFAConfig struct {
 	Enabled bool `form:"enabled" json:"enabled"`
 
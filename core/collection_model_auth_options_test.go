@@ -3,6 +3,7 @@ package core_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -11,6 +12,8 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tests"
 	"github.com/pocketbase/pocketbase/tools/auth"
+	"github.com/pocketbase/pocketbase/tools/ldap"
+	"github.com/pocketbase/pocketbase/tools/saml"
 	"github.com/pocketbase/pocketbase/tools/types"
 )
 
@@ -242,6 +245,155 @@ func TestCollectionAuthOptionsValidate(t *testing.T) {
 			expectedErrors: []string{},
 		},
 
+		// totp
+		{
+			name: "trigger totp validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.PasswordAuth.Enabled = true
+				c.MFA.Enabled = true
+				c.TOTP.Enabled = true
+				c.TOTP.Digits = 7
+				return c, nil
+			},
+			expectedErrors: []string{"totp"},
+		},
+		{
+			name: "totp enabled without mfa",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.PasswordAuth.Enabled = true
+				c.MFA.Enabled = false
+				c.TOTP.Enabled = true
+				return c, nil
+			},
+			expectedErrors: []string{"totp"},
+		},
+		{
+			name: "totp enabled with mfa",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.PasswordAuth.Enabled = true
+				c.OTP.Enabled = false
+				c.OAuth2.Enabled = false
+				c.MFA.Enabled = true
+				c.TOTP.Enabled = true
+				return c, nil
+			},
+			expectedErrors: []string{},
+		},
+
+		// sessions
+		{
+			name: "trigger sessions validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.Sessions.Enabled = true
+				c.Sessions.AccessTokenDuration = 0
+				return c, nil
+			},
+			expectedErrors: []string{"sessions"},
+		},
+		{
+			name: "trigger apiKeys validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.APIKeys.MaxDuration = -1
+				return c, nil
+			},
+			expectedErrors: []string{"apiKeys"},
+		},
+		{
+			name: "trigger lockout validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.Lockout.Enabled = true
+				c.Lockout.MaxFailures = 0
+				return c, nil
+			},
+			expectedErrors: []string{"lockout"},
+		},
+		{
+			name: "trigger passwordPolicy validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.PasswordPolicy.History = -1
+				return c, nil
+			},
+			expectedErrors: []string{"passwordPolicy"},
+		},
+		{
+			name: "trigger ldap validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.LDAP.Enabled = true
+				return c, nil
+			},
+			expectedErrors: []string{"ldap"},
+		},
+		{
+			name: "ldap with missing or forbidden mapped fields",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.LDAP.Enabled = true
+				c.LDAP.URL = "ldap://example.com"
+				c.LDAP.BaseDN = "dc=example,dc=com"
+				c.LDAP.MappedFields = map[string]string{"password": "userPassword"}
+				return c, nil
+			},
+			expectedErrors: []string{"ldap"},
+		},
+		{
+			name: "ldap with missing roles field",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.LDAP.Enabled = true
+				c.LDAP.URL = "ldap://example.com"
+				c.LDAP.BaseDN = "dc=example,dc=com"
+				c.LDAP.RolesField = "missing"
+				return c, nil
+			},
+			expectedErrors: []string{"ldap"},
+		},
+		{
+			name: "ldap with valid mapped and roles fields",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.Fields.Add(&core.TextField{Name: "name"})
+				c.Fields.Add(&core.JSONField{Name: "roles"})
+				c.LDAP.Enabled = true
+				c.LDAP.URL = "ldap://example.com"
+				c.LDAP.BaseDN = "dc=example,dc=com"
+				c.LDAP.MappedFields = map[string]string{"email": "mail", "name": "cn"}
+				c.LDAP.RolesField = "roles"
+				return c, nil
+			},
+			expectedErrors: []string{},
+		},
+
+		{
+			name: "trigger saml validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.SAML.Enabled = true
+				return c, nil
+			},
+			expectedErrors: []string{"saml"},
+		},
+		{
+			name: "saml with valid config",
+			collection: func(app core.App) (*core.Collection, error) {
+				c, err := newTestSAMLAuthCollection()
+				if err != nil {
+					return nil, err
+				}
+				c.Fields.Add(&core.TextField{Name: "name"})
+				c.SAML.MappedFields.Name = "name"
+				return c, nil
+			},
+			expectedErrors: []string{},
+		},
+
 		// tokens
 		{
 			name: "trigger authToken validations",
@@ -498,6 +650,14 @@ func TestAuthAlertConfigValidate(t *testing.T) {
 			},
 			[]string{"emailTemplate"},
 		},
+		{
+			"invalid lockout template",
+			core.AuthAlertConfig{
+				EmailTemplate:        core.EmailTemplate{Body: "a", Subject: "b"},
+				LockoutEmailTemplate: core.EmailTemplate{Body: "c", Subject: ""},
+			},
+			[]string{"lockoutEmailTemplate"},
+		},
 		{
 			"valid data",
 			core.AuthAlertConfig{
@@ -505,6 +665,14 @@ func TestAuthAlertConfigValidate(t *testing.T) {
 			},
 			[]string{},
 		},
+		{
+			"valid data (with lockout template)",
+			core.AuthAlertConfig{
+				EmailTemplate:        core.EmailTemplate{Body: "a", Subject: "b"},
+				LockoutEmailTemplate: core.EmailTemplate{Body: "c", Subject: "d"},
+			},
+			[]string{},
+		},
 	}
 
 	for _, s := range scenarios {
@@ -516,6 +684,24 @@ func TestAuthAlertConfigValidate(t *testing.T) {
 	}
 }
 
+func TestAuthAlertConfigLockoutTemplate(t *testing.T) {
+	defaultTemplate := core.NewAuthCollection("test").AuthAlert.LockoutEmailTemplate
+	if defaultTemplate.Subject == "" || defaultTemplate.Body == "" {
+		t.Fatalf("Expected non-empty default lockout template, got %v", defaultTemplate)
+	}
+
+	config := core.AuthAlertConfig{}
+	if v := config.LockoutTemplate(); v != defaultTemplate {
+		t.Fatalf("Expected the default lockout template, got %v", v)
+	}
+
+	custom := core.EmailTemplate{Subject: "a", Body: "b"}
+	config.LockoutEmailTemplate = custom
+	if v := config.LockoutTemplate(); v != custom {
+		t.Fatalf("Expected the custom lockout template, got %v", v)
+	}
+}
+
 func TestOTPConfigValidate(t *testing.T) {
 	scenarios := []struct {
 		name           string
@@ -613,6 +799,872 @@ func TestOTPConfigDurationTime(t *testing.T) {
 	}
 }
 
+func TestWebAuthnConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.WebAuthnConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.WebAuthnConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.WebAuthnConfig{Enabled: true},
+			[]string{"duration"},
+		},
+		{
+			"invalid duration (< 10)",
+			core.WebAuthnConfig{Enabled: true, Duration: 9},
+			[]string{"duration"},
+		},
+		{
+			"invalid duration (> 86400)",
+			core.WebAuthnConfig{Enabled: true, Duration: 86401},
+			[]string{"duration"},
+		},
+		{
+			"invalid rpId, origins and userVerification",
+			core.WebAuthnConfig{
+				Enabled:          true,
+				Duration:         100,
+				RPId:             "https://example.com",
+				Origins:          []string{"invalid"},
+				UserVerification: "invalid",
+			},
+			[]string{"rpId", "origins", "userVerification"},
+		},
+		{
+			"valid data",
+			core.WebAuthnConfig{
+				Enabled:          true,
+				Duration:         100,
+				RPId:             "example.com",
+				RPName:           "test",
+				Origins:          []string{"https://example.com"},
+				UserVerification: "required",
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestWebAuthnConfigDurationTime(t *testing.T) {
+	scenarios := []struct {
+		config   core.WebAuthnConfig
+		expected time.Duration
+	}{
+		{core.WebAuthnConfig{}, 0 * time.Second},
+		{core.WebAuthnConfig{Duration: 1234}, 1234 * time.Second},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("%d_%d", i, s.config.Duration), func(t *testing.T) {
+			result := s.config.DurationTime()
+
+			if result != s.expected {
+				t.Fatalf("Expected duration %d, got %d", s.expected, result)
+			}
+		})
+	}
+}
+
+func TestTOTPConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.TOTPConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.TOTPConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.TOTPConfig{Enabled: true},
+			[]string{"duration", "digits", "period", "secret"},
+		},
+		{
+			"invalid values",
+			core.TOTPConfig{
+				Enabled:       true,
+				Duration:      9,
+				Issuer:        "a:b",
+				Digits:        7,
+				Period:        301,
+				Skew:          6,
+				RecoveryCodes: 51,
+				Secret:        strings.Repeat("a", 31),
+			},
+			[]string{"duration", "issuer", "digits", "period", "skew", "recoveryCodes", "secret"},
+		},
+		{
+			"valid data",
+			core.TOTPConfig{
+				Enabled:       true,
+				Duration:      100,
+				Issuer:        "test",
+				Digits:        8,
+				Period:        30,
+				Skew:          1,
+				RecoveryCodes: 10,
+				Secret:        strings.Repeat("a", 32),
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestTOTPConfigDurationTime(t *testing.T) {
+	scenarios := []struct {
+		config   core.TOTPConfig
+		expected time.Duration
+	}{
+		{core.TOTPConfig{}, 0 * time.Second},
+		{core.TOTPConfig{Duration: 1234}, 1234 * time.Second},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("%d_%d", i, s.config.Duration), func(t *testing.T) {
+			result := s.config.DurationTime()
+
+			if result != s.expected {
+				t.Fatalf("Expected duration %d, got %d", s.expected, result)
+			}
+		})
+	}
+}
+
+func TestSessionsConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.SessionsConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.SessionsConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.SessionsConfig{Enabled: true},
+			[]string{"accessTokenDuration", "refreshTokenDuration"},
+		},
+		{
+			"invalid values",
+			core.SessionsConfig{
+				Enabled:              true,
+				AccessTokenDuration:  86401,
+				RefreshTokenDuration: 31536001,
+			},
+			[]string{"accessTokenDuration", "refreshTokenDuration"},
+		},
+		{
+			"refresh token duration smaller than the access token duration",
+			core.SessionsConfig{
+				Enabled:              true,
+				AccessTokenDuration:  600,
+				RefreshTokenDuration: 599,
+			},
+			[]string{"refreshTokenDuration"},
+		},
+		{
+			"valid data",
+			core.SessionsConfig{
+				Enabled:              true,
+				AccessTokenDuration:  600,
+				RefreshTokenDuration: 600,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestSessionsConfigDurationTime(t *testing.T) {
+	config := core.SessionsConfig{AccessTokenDuration: 12, RefreshTokenDuration: 34}
+
+	if v := config.AccessTokenDurationTime(); v != 12*time.Second {
+		t.Fatalf("Expected access token duration %d, got %d", 12*time.Second, v)
+	}
+
+	if v := config.RefreshTokenDurationTime(); v != 34*time.Second {
+		t.Fatalf("Expected refresh token duration %d, got %d", 34*time.Second, v)
+	}
+}
+
+func TestAPIKeysConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.APIKeysConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.APIKeysConfig{},
+			[]string{},
+		},
+		{
+			"negative max duration",
+			core.APIKeysConfig{Enabled: true, MaxDuration: -1},
+			[]string{"maxDuration"},
+		},
+		{
+			"too large max duration",
+			core.APIKeysConfig{Enabled: true, MaxDuration: 315360001},
+			[]string{"maxDuration"},
+		},
+		{
+			"valid data",
+			core.APIKeysConfig{Enabled: true, MaxDuration: 86400},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestAPIKeysConfigMaxDurationTime(t *testing.T) {
+	config := core.APIKeysConfig{MaxDuration: 12}
+
+	if v := config.MaxDurationTime(); v != 12*time.Second {
+		t.Fatalf("Expected max duration %d, got %d", 12*time.Second, v)
+	}
+}
+
+func TestLockoutConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.LockoutConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.LockoutConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.LockoutConfig{Enabled: true},
+			[]string{"maxFailures", "duration", "maxDuration"},
+		},
+		{
+			"invalid values",
+			core.LockoutConfig{
+				Enabled:       true,
+				MaxFailures:   1001,
+				MaxIPFailures: -1,
+				Duration:      86401,
+				MaxDuration:   31536001,
+			},
+			[]string{"maxFailures", "maxIPFailures", "duration", "maxDuration"},
+		},
+		{
+			"max duration less than duration",
+			core.LockoutConfig{
+				Enabled:     true,
+				MaxFailures: 5,
+				Duration:    60,
+				MaxDuration: 59,
+			},
+			[]string{"maxDuration"},
+		},
+		{
+			"valid data",
+			core.LockoutConfig{
+				Enabled:       true,
+				MaxFailures:   5,
+				MaxIPFailures: 0,
+				Duration:      60,
+				MaxDuration:   3600,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestLockoutConfigLockoutDuration(t *testing.T) {
+	config := core.LockoutConfig{Duration: 60, MaxDuration: 300}
+
+	scenarios := []struct {
+		lockouts int
+		expected time.Duration
+	}{
+		{0, 60 * time.Second},
+		{1, 60 * time.Second},
+		{2, 120 * time.Second},
+		{3, 240 * time.Second},
+		{4, 300 * time.Second},
+		{100, 300 * time.Second},
+	}
+
+	for _, s := range scenarios {
+		t.Run(fmt.Sprintf("%d", s.lockouts), func(t *testing.T) {
+			if v := config.LockoutDuration(s.lockouts); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestLockoutConfigMaxDurationTime(t *testing.T) {
+	config := core.LockoutConfig{MaxDuration: 12}
+
+	if v := config.MaxDurationTime(); v != 12*time.Second {
+		t.Fatalf("Expected max duration %d, got %d", 12*time.Second, v)
+	}
+}
+
+func TestPasswordPolicyConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.PasswordPolicyConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.PasswordPolicyConfig{},
+			[]string{},
+		},
+		{
+			"negative values",
+			core.PasswordPolicyConfig{History: -1, MaxAge: -1},
+			[]string{"history", "maxAge"},
+		},
+		{
+			"too large values",
+			core.PasswordPolicyConfig{History: 25, MaxAge: 315360001},
+			[]string{"history", "maxAge"},
+		},
+		{
+			"valid data",
+			core.PasswordPolicyConfig{
+				RequireLowercase: true,
+				RequireUppercase: true,
+				RequireDigit:     true,
+				RequireSymbol:    true,
+				History:          24,
+				MaxAge:           7776000,
+				CheckBreached:    true,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestPasswordPolicyConfigMaxAgeTime(t *testing.T) {
+	config := core.PasswordPolicyConfig{MaxAge: 12}
+
+	if v := config.MaxAgeTime(); v != 12*time.Second {
+		t.Fatalf("Expected max age %d, got %d", 12*time.Second, v)
+	}
+}
+
+func TestLDAPConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.LDAPConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.LDAPConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.LDAPConfig{Enabled: true},
+			[]string{"url", "baseDN", "userFilter"},
+		},
+		{
+			"invalid values",
+			core.LDAPConfig{
+				Enabled:    true,
+				URL:        "http://example.com",
+				BindDN:     "cn=admin,dc=example,dc=com",
+				BaseDN:     "dc=example,dc=com",
+				UserFilter: "(uid=test)",
+				RolesField: "roles",
+				Timeout:    301,
+			},
+			[]string{"url", "bindPassword", "userFilter", "groupAttribute", "timeout"},
+		},
+		{
+			"invalid filter",
+			core.LDAPConfig{
+				Enabled:    true,
+				URL:        "ldap://example.com",
+				BaseDN:     "dc=example,dc=com",
+				UserFilter: "(uid={username}",
+			},
+			[]string{"userFilter"},
+		},
+		{
+			"StartTLS with ldaps url",
+			core.LDAPConfig{
+				Enabled:    true,
+				URL:        "ldaps://example.com",
+				StartTLS:   true,
+				BaseDN:     "dc=example,dc=com",
+				UserFilter: "(uid={username})",
+			},
+			[]string{"url"},
+		},
+		{
+			"valid data",
+			core.LDAPConfig{
+				Enabled:        true,
+				URL:            "ldap://example.com:1389",
+				StartTLS:       true,
+				BindDN:         "cn=admin,dc=example,dc=com",
+				BindPassword:   "123456",
+				BaseDN:         "dc=example,dc=com",
+				UserFilter:     "(&(objectClass=person)(uid={username}))",
+				GroupAttribute: "memberOf",
+				RolesField:     "roles",
+				Timeout:        300,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestLDAPConfigTimeoutTime(t *testing.T) {
+	if v := (core.LDAPConfig{}).TimeoutTime(); v != ldap.DefaultTimeout {
+		t.Fatalf("Expected default timeout %d, got %d", ldap.DefaultTimeout, v)
+	}
+
+	if v := (core.LDAPConfig{Timeout: 12}).TimeoutTime(); v != 12*time.Second {
+		t.Fatalf("Expected timeout %d, got %d", 12*time.Second, v)
+	}
+}
+
+func TestLDAPConfigUserFilterFor(t *testing.T) {
+	config := core.LDAPConfig{UserFilter: "(|(uid={username})(mail={username}))"}
+
+	result := config.UserFilterFor("*)(cn=*")
+
+	expected := `(|(uid=\2a\29\28cn=\2a)(mail=\2a\29\28cn=\2a))`
+	if result != expected {
+		t.Fatalf("Expected\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestLDAPConfigExternalId(t *testing.T) {
+	entry := &ldap.Entry{
+		DN:         "uid=test,dc=example,dc=com",
+		Attributes: map[string][]string{"entryUUID": {"abc"}},
+	}
+
+	if v := (core.LDAPConfig{}).ExternalId(entry); v != entry.DN {
+		t.Fatalf("Expected the entry DN, got %q", v)
+	}
+
+	if v := (core.LDAPConfig{IdAttribute: "entryuuid"}).ExternalId(entry); v != "abc" {
+		t.Fatalf("Expected the entryUUID value, got %q", v)
+	}
+}
+
+func TestLDAPConfigRoles(t *testing.T) {
+	entry := &ldap.Entry{
+		Attributes: map[string][]string{
+			"memberOf": {"CN=Admins,OU=Groups,DC=example,DC=com", "cn=devs,ou=groups,dc=example,dc=com", "cn=staff,ou=groups,dc=example,dc=com"},
+		},
+	}
+
+	config := core.LDAPConfig{
+		GroupAttribute: "memberOf",
+		GroupRoles: map[string]string{
+			"cn=admins,ou=groups,dc=example,dc=com": "admin",
+			"cn=devs,ou=groups,dc=example,dc=com":   "admin", // duplicated role
+			"cn=other,ou=groups,dc=example,dc=com":  "other",
+		},
+	}
+
+	roles := config.Roles(entry)
+	if len(roles) != 1 || roles[0] != "admin" {
+		t.Fatalf("Expected [admin], got %v", roles)
+	}
+
+	config.GroupAttribute = ""
+	if roles := config.Roles(entry); len(roles) != 0 {
+		t.Fatalf("Expected no roles without GroupAttribute, got %v", roles)
+	}
+}
+
+func TestLDAPConfigAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	server, err := tests.NewTestLDAPServer(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	server.AddEntry("cn=admin,dc=example,dc=com", "admin_pass", nil)
+	server.AddEntry("uid=test,ou=people,dc=example,dc=com", "test_pass", map[string][]string{
+		"uid":      {"test"},
+		"cn":       {"Test"},
+		"memberOf": {"cn=admins,ou=groups,dc=example,dc=com"},
+	})
+
+	config := core.LDAPConfig{
+		Enabled:            true,
+		URL:                server.URL(),
+		StartTLS:           true,
+		InsecureSkipVerify: true,
+		BindDN:             "cn=admin,dc=example,dc=com",
+		BindPassword:       "admin_pass",
+		BaseDN:             "ou=people,dc=example,dc=com",
+		UserFilter:         "(uid={username})",
+		GroupAttribute:     "memberOf",
+	}
+
+	scenarios := []struct {
+		username           string
+		password           string
+		invalidCredentials bool
+	}{
+		{"", "test_pass", true},
+		{"test", "", true},
+		{"missing", "test_pass", true},
+		{"*", "test_pass", true},
+		{"test", "invalid", true},
+		{"test", "test_pass", false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.username+"_"+s.password, func(t *testing.T) {
+			entry, err := config.Authenticate(s.username, s.password)
+
+			if s.invalidCredentials {
+				if !errors.Is(err, ldap.ErrInvalidCredentials) {
+					t.Fatalf("Expected ErrInvalidCredentials, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected nil error, got %v", err)
+			}
+
+			if entry.DN != "uid=test,ou=people,dc=example,dc=com" {
+				t.Fatalf("Expected the test entry, got %q", entry.DN)
+			}
+
+			if v := entry.Value("memberOf"); v != "cn=admins,ou=groups,dc=example,dc=com" {
+				t.Fatalf("Expected memberOf attribute, got %q", v)
+			}
+		})
+	}
+
+	t.Run("invalid service account", func(t *testing.T) {
+		invalidConfig := config
+		invalidConfig.BindPassword = "invalid"
+
+		_, err := invalidConfig.Authenticate("test", "test_pass")
+		if err == nil || errors.Is(err, ldap.ErrInvalidCredentials) {
+			t.Fatalf("Expected a non ErrInvalidCredentials error, got %v", err)
+		}
+	})
+}
+
+func TestSAMLConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	idp, err := tests.NewTestSAMLIdP("https://idp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, keyPEM, err := tests.NewTestSAMLKeyPair("sp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherKeyPEM, err := tests.NewTestSAMLKeyPair("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name           string
+		config         core.SAMLConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.SAMLConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.SAMLConfig{Enabled: true},
+			[]string{"idpMetadata", "certificate", "privateKey", "redirectURLs", "duration"},
+		},
+		{
+			"invalid values",
+			core.SAMLConfig{
+				Enabled:      true,
+				IdPMetadata:  "<invalid",
+				Certificate:  "invalid",
+				PrivateKey:   "invalid",
+				RedirectURLs: []string{"", "invalid"},
+				IdAttribute:  strings.Repeat("a", 256),
+				Duration:     3601,
+			},
+			[]string{"idpMetadata", "certificate", "privateKey", "redirectURLs", "idAttribute", "duration"},
+		},
+		{
+			"mismatched key pair",
+			core.SAMLConfig{
+				Enabled:      true,
+				IdPMetadata:  idp.Metadata(),
+				Certificate:  certPEM,
+				PrivateKey:   otherKeyPEM,
+				RedirectURLs: []string{"https://example.com/callback"},
+				Duration:     300,
+			},
+			[]string{"privateKey"},
+		},
+		{
+			"valid data",
+			core.SAMLConfig{
+				Enabled:      true,
+				IdPMetadata:  idp.Metadata(),
+				EntityId:     "https://example.com/sp",
+				Certificate:  certPEM,
+				PrivateKey:   keyPEM,
+				RedirectURLs: []string{"https://example.com/callback"},
+				Duration:     300,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestSAMLConfigDurationTime(t *testing.T) {
+	t.Parallel()
+
+	if v := (core.SAMLConfig{Duration: 12}).DurationTime(); v != 12*time.Second {
+		t.Fatalf("Expected duration %d, got %d", 12*time.Second, v)
+	}
+}
+
+func TestSAMLConfigServiceProvider(t *testing.T) {
+	t.Parallel()
+
+	c, err := newTestSAMLAuthCollection()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err := c.SAML.ServiceProvider("https://example.com/default", "https://example.com/acs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sp.EntityId != "https://example.com/default" {
+		t.Fatalf("Expected the default entity id, got %q", sp.EntityId)
+	}
+
+	if sp.ACSURL != "https://example.com/acs" {
+		t.Fatalf("Expected ACS url %q, got %q", "https://example.com/acs", sp.ACSURL)
+	}
+
+	if sp.IdP == nil || sp.IdP.EntityId != "https://idp.example.com" {
+		t.Fatalf("Expected the parsed IdP metadata, got %v", sp.IdP)
+	}
+
+	c.SAML.EntityId = "https://example.com/custom"
+	sp, err = c.SAML.ServiceProvider("https://example.com/default", "https://example.com/acs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp.EntityId != "https://example.com/custom" {
+		t.Fatalf("Expected the custom entity id, got %q", sp.EntityId)
+	}
+
+	c.SAML.PrivateKey = ""
+	if _, err := c.SAML.ServiceProvider("https://example.com/default", "https://example.com/acs"); err == nil {
+		t.Fatal("Expected error for missing private key")
+	}
+}
+
+func TestSAMLConfigIsAllowedRedirectURL(t *testing.T) {
+	t.Parallel()
+
+	config := core.SAMLConfig{RedirectURLs: []string{"https://example.com/a", "https://example.com/b"}}
+
+	scenarios := []struct {
+		url      string
+		expected bool
+	}{
+		{"", false},
+		{"https://example.com", false},
+		{"https://example.com/a?x=1", false},
+		{"https://example.com/a", true},
+		{"https://example.com/b", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.url, func(t *testing.T) {
+			if v := config.IsAllowedRedirectURL(s.url); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestSAMLConfigExternalId(t *testing.T) {
+	t.Parallel()
+
+	assertion := &saml.Assertion{
+		NameID:     "test",
+		Attributes: map[string][]string{"uid": {"abc"}},
+	}
+
+	if v := (core.SAMLConfig{}).ExternalId(assertion); v != "test" {
+		t.Fatalf("Expected the NameID, got %q", v)
+	}
+
+	if v := (core.SAMLConfig{IdAttribute: "uid"}).ExternalId(assertion); v != "abc" {
+		t.Fatalf("Expected the uid value, got %q", v)
+	}
+
+	if v := (core.SAMLConfig{IdAttribute: "missing"}).ExternalId(assertion); v != "" {
+		t.Fatalf("Expected empty id, got %q", v)
+	}
+}
+
+func TestSAMLConfigEmail(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name      string
+		config    core.SAMLConfig
+		assertion *saml.Assertion
+		expected  string
+	}{
+		{
+			"no email attribute and non-email NameID",
+			core.SAMLConfig{Attributes: core.SAMLKnownAttributes{Email: "mail"}},
+			&saml.Assertion{NameID: "test@example.com", NameIDFormat: saml.NameIDFormatPersistent},
+			"",
+		},
+		{
+			"email NameID fallback",
+			core.SAMLConfig{Attributes: core.SAMLKnownAttributes{Email: "mail"}},
+			&saml.Assertion{NameID: "test@example.com", NameIDFormat: saml.NameIDFormatEmail},
+			"test@example.com",
+		},
+		{
+			"email attribute",
+			core.SAMLConfig{Attributes: core.SAMLKnownAttributes{Email: "mail"}},
+			&saml.Assertion{
+				NameID:       "test@example.com",
+				NameIDFormat: saml.NameIDFormatEmail,
+				Attributes:   map[string][]string{"mail": {"other@example.com"}},
+			},
+			"other@example.com",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			if v := s.config.Email(s.assertion); v != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, v)
+			}
+		})
+	}
+}
+
+func newTestSAMLAuthCollection() (*core.Collection, error) {
+	idp, err := tests.NewTestSAMLIdP("https://idp.example.com")
+	if err != nil {
+		return nil, err
+	}
+
+	certPEM, keyPEM, err := tests.NewTestSAMLKeyPair("sp")
+	if err != nil {
+		return nil, err
+	}
+
+	c := core.NewAuthCollection("new_auth")
+	c.SAML.Enabled = true
+	c.SAML.IdPMetadata = idp.Metadata()
+	c.SAML.Certificate = certPEM
+	c.SAML.PrivateKey = keyPEM
+	c.SAML.RedirectURLs = []string{"https://example.com/callback"}
+
+	return c, nil
+}
+
 func TestMFAConfigValidate(t *testing.T) {
 	scenarios := []struct {
 		name           string
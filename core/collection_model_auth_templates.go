@@ -63,6 +63,18 @@ var defaultOTPTemplate = EmailTemplate{
 </p>`,
 }
 
+var defaultLockoutAlertTemplate = EmailTemplate{
+	Subject: "Your account was temporarily locked",
+	Body: `<p>Hello,</p>
+<p>Your ` + EmailPlaceholderAppName + ` account was temporarily locked due to too many failed login attempts:</p>
+<p><em>` + EmailPlaceholderAlertInfo + `</em></p>
+<p><strong>If this wasn't you, someone may be trying to guess your password. Consider changing your ` + EmailPlaceholderAppName + ` account password.</strong></p>
+<p>
+  Thanks,<br/>
+  ` + EmailPlaceholderAppName + ` team
+</p>`,
+}
+
 var defaultAuthAlertTemplate = EmailTemplate{
 	Subject: "Login from a new location",
 	Body: `<p>Hello,</p>
@@ -789,7 +789,7 @@ func TestCollectionDBExport(t *testing.T) {
 		},
 		{
 			core.CollectionTypeAuth,
-			`{"createRule":"1=3","created":"2024-07-01 01:02:03.456Z","deleteRule":"1=5","fields":[{"hidden":false,"id":"f1_id","name":"f1","presentable":false,"required":false,"system":true,"type":"bool"},{"hidden":false,"id":"f2_id","name":"f2","presentable":false,"required":true,"system":false,"type":"bool"}],"id":"test_id","indexes":["CREATE INDEX idx1 on test_name(id)","CREATE INDEX idx2 on test_name(id)"],"listRule":"1=1","name":"test_name","options":{"authRule":null,"manageRule":"1=6","authAlert":{"enabled":false,"emailTemplate":{"subject":"","body":""}},"oauth2":{"providers":null,"mappedFields":{"id":"","name":"","username":"","avatarURL":""},"enabled":false},"passwordAuth":{"enabled":false,"identityFields":null},"mfa":{"enabled":false,"duration":0,"rule":""},"otp":{"enabled":false,"duration":0,"length":0,"emailTemplate":{"subject":"","body":""}},"authToken":{"duration":0},"passwordResetToken":{"duration":0},"emailChangeToken":{"duration":0},"verificationToken":{"duration":0},"fileToken":{"duration":0},"verificationTemplate":{"subject":"","body":""},"resetPasswordTemplate":{"subject":"","body":""},"confirmEmailChangeTemplate":{"subject":"","body":""}},"system":true,"type":"auth","updateRule":"1=4","updated":"2024-07-01 01:02:03.456Z","viewRule":"1=7"}`,
+			`{"createRule":"1=3","created":"2024-07-01 01:02:03.456Z","deleteRule":"1=5","fields":[{"hidden":false,"id":"f1_id","name":"f1","presentable":false,"required":false,"system":true,"type":"bool"},{"hidden":false,"id":"f2_id","name":"f2","presentable":false,"required":true,"system":false,"type":"bool"}],"id":"test_id","indexes":["CREATE INDEX idx1 on test_name(id)","CREATE INDEX idx2 on test_name(id)"],"listRule":"1=1","name":"test_name","options":{"authRule":null,"manageRule":"1=6","authAlert":{"enabled":false,"emailTemplate":{"subject":"","body":""},"lockoutEmailTemplate":{"subject":"","body":""}},"oauth2":{"providers":null,"mappedFields":{"id":"","name":"","username":"","avatarURL":""},"enabled":false},"passwordAuth":{"enabled":false,"identityFields":null},"mfa":{"enabled":false,"duration":0,"rule":""},"otp":{"enabled":false,"duration":0,"length":0,"emailTemplate":{"subject":"","body":""}},"webauthn":{"enabled":false,"duration":0,"rpId":"","rpName":"","origins":null,"userVerification":""},"totp":{"enabled":false,"duration":0,"issuer":"","digits":0,"period":0,"skew":0,"recoveryCodes":0},"oidc":{"enabled":false,"loginURL":"","codeDuration":0,"idTokenDuration":0,"refreshTokenDuration":0},"sessions":{"enabled":false,"accessTokenDuration":0,"refreshTokenDuration":0},"apiKeys":{"enabled":false,"maxDuration":0},"lockout":{"enabled":false,"maxFailures":0,"maxIPFailures":0,"duration":0,"maxDuration":0},"passwordPolicy":{"requireLowercase":false,"requireUppercase":false,"requireDigit":false,"requireSymbol":false,"history":0,"maxAge":0,"checkBreached":false},"ldap":{"enabled":false,"url":"","startTLS":false,"insecureSkipVerify":false,"bindDN":"","baseDN":"","userFilter":"","idAttribute":"","mappedFields":null,"groupAttribute":"","rolesField":"","groupRoles":null,"timeout":0},"saml":{"enabled":false,"idpMetadata":"","entityId":"","certificate":"","redirectURLs":null,"allowIdPInitiated":false,"idAttribute":"","attributes":{"email":"","name":"","username":"","avatarURL":""},"mappedFields":{"id":"","name":"","username":"","avatarURL":""},"duration":0},"authToken":{"duration":0},"passwordResetToken":{"duration":0},"emailChangeToken":{"duration":0},"verificationToken":{"duration":0},"fileToken":{"duration":0},"verificationTemplate":{"subject":"","body":""},"resetPasswordTemplate":{"subject":"","body":""},"confirmEmailChangeTemplate":{"subject":"","body":""}},"system":true,"type":"auth","updateRule":"1=4","updated":"2024-07-01 01:02:03.456Z","viewRule":"1=7"}`,
 		},
 	}
 
@@ -13,6 +13,7 @@ import (
 // Common request store keys used by the middlewares and api handlers.
 const (
 	RequestEventKeyInfoContext = "infoContext"
+	RequestEventKeyAPIKey      = "apiKey"
 )
 
 // RequestEvent defines the PocketBase router handler event.
@@ -79,6 +80,12 @@ func (e *RequestEvent) HasSuperuserAuth() bool {
 	return e.Auth != nil && e.Auth.IsSuperuser()
 }
 
+// APIKey returns the API key used to load the current request auth record (if any).
+func (e *RequestEvent) APIKey() *APIKey {
+	apiKey, _ := e.Get(RequestEventKeyAPIKey).(*APIKey)
+	return apiKey
+}
+
 // RequestInfo parses the current request into RequestInfo instance.
 //
 // Note that the returned result is cached to avoid copying the request data multiple times
@@ -89,6 +96,7 @@ func (e *RequestEvent) RequestInfo() (*RequestInfo, error) {
 
 	if e.cachedRequestInfo != nil {
 		e.cachedRequestInfo.Auth = e.Auth
+		e.cachedRequestInfo.APIKey, _ = e.Get(RequestEventKeyAPIKey).(*APIKey)
 
 		infoCtx, _ := e.Get(RequestEventKeyInfoContext).(string)
 		if infoCtx != "" {
@@ -141,6 +149,7 @@ func (e *RequestEvent) initRequestInfo() error {
 	}
 
 	info.Auth = e.Auth
+	info.APIKey, _ = e.Get(RequestEventKeyAPIKey).(*APIKey)
 
 	e.cachedRequestInfo = info
 
@@ -158,6 +167,12 @@ const (
 	RequestInfoContextOAuth2        = "oauth2"
 	RequestInfoContextOTP           = "otp"
 	RequestInfoContextPasswordAuth  = "password"
+	RequestInfoContextWebAuthn      = "webauthn"
+	RequestInfoContextTOTP          = "totp"
+	RequestInfoContextOIDC          = "oidc"
+	RequestInfoContextRefreshToken  = "refreshToken"
+	RequestInfoContextLDAP          = "ldap"
+	RequestInfoContextSAML          = "saml"
 )
 
 // RequestInfo defines a HTTP request data struct, usually used
@@ -171,6 +186,9 @@ type RequestInfo struct {
 	Auth    *Record           `json:"auth"`
 	Method  string            `json:"method"`
 	Context string            `json:"context"`
+
+	// APIKey is the API key used to authenticate the Auth record (if any).
+	APIKey *APIKey `json:"apiKey,omitempty"`
 }
 
 // HasSuperuserAuth checks whether the current RequestInfo instance
@@ -187,6 +205,7 @@ func (info *RequestInfo) Clone() *RequestInfo {
 		Query:   maps.Clone(info.Query),
 		Body:    maps.Clone(info.Body),
 		Headers: maps.Clone(info.Headers),
+		APIKey:  info.APIKey,
 	}
 
 	if info.Auth != nil {
@@ -8,8 +8,10 @@ import (
 
 	"github.com/pocketbase/pocketbase/tools/auth"
 	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/ldap"
 	"github.com/pocketbase/pocketbase/tools/mailer"
 	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/saml"
 	"github.com/pocketbase/pocketbase/tools/search"
 	"github.com/pocketbase/pocketbase/tools/subscriptions"
 	"golang.org/x/crypto/acme/autocert"
@@ -449,6 +451,37 @@ type RealtimeSubscribeRequestEvent struct {
 	Subscriptions []string
 }
 
+type RealtimePublishRequestEvent struct {
+	hook.Event
+	*RequestEvent
+
+	// note: could be nil if the request was sent without client id
+	Client  subscriptions.Client
+	Channel string
+	Data    any
+}
+
+// Realtime presence change actions.
+const (
+	RealtimePresenceJoin   = "join"
+	RealtimePresenceUpdate = "update"
+	RealtimePresenceLeave  = "leave"
+)
+
+type RealtimePresenceEvent struct {
+	hook.Event
+	App App
+
+	Client subscriptions.Client
+	Member *subscriptions.PresenceMember
+
+	// Could be any of the RealtimePresence* constants, like:
+	// - join
+	// - update
+	// - leave
+	Action string
+}
+
 // -------------------------------------------------------------------
 // Record CRUD API events data
 // -------------------------------------------------------------------
@@ -501,15 +534,107 @@ type RecordAuthWithOTPRequestEvent struct {
 	OTP    *OTP
 }
 
-type RecordAuthRequestEvent struct {
+type RecordWebAuthnRegisterRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record     *Record
+	Credential *WebAuthnCredential
+}
+
+type RecordAuthWithTOTPRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record *Record
+	TOTP   *TOTP
+}
+
+type RecordAuthWithLDAPRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Username    string
+	LDAPEntry   *ldap.Entry
+	Record      *Record
+	CreateData  map[string]any
+	IsNewRecord bool
+}
+
+type RecordAuthWithSAMLRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Assertion   *saml.Assertion
+	Record      *Record
+	CreateData  map[string]any
+	IsNewRecord bool
+}
+
+type RecordOIDCAuthorizeRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record      *Record
+	Client      *OIDCClient
+	Scopes      []string
+	Approved    bool
+	RedirectURL string
+}
+
+type RecordOIDCTokenRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record    *Record
+	Client    *OIDCClient
+	GrantType string
+	Scopes    []string
+}
+
+type RecordAuthWithRefreshTokenRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record  *Record
+	Session *AuthSession
+}
+
+type RecordCreateAPIKeyRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record *Record
+	APIKey *APIKey
+}
+
+type RecordAuthWithWebAuthnRequestEvent struct {
 	hook.Event
 	*RequestEvent
 	baseCollectionEventData
 
 	Record     *Record
-	Token      string
-	Meta       any
-	AuthMethod string
+	Credential *WebAuthnCredential
+}
+
+type RecordAuthRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record       *Record
+	Token        string
+	RefreshToken string
+	Meta         any
+	AuthMethod   string
 }
 
 type RecordAuthWithPasswordRequestEvent struct {
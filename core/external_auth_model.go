@@ -18,6 +18,14 @@ var (
 
 const CollectionNameExternalAuths = "_externalAuths"
 
+// ExternalAuthProviderLDAP is the ExternalAuth provider name of the
+// records linked with an LDAP directory entry.
+const ExternalAuthProviderLDAP = "ldap"
+
+// ExternalAuthProviderSAML is the ExternalAuth provider name of the
+// records linked with a SAML identity provider account.
+const ExternalAuthProviderSAML = "saml"
+
 // ExternalAuth defines a Record proxy for working with the externalAuths collection.
 type ExternalAuth struct {
 	*Record
@@ -123,7 +131,8 @@ func (app *BaseApp) registerExternalAuthHooks() {
 
 	app.OnRecordValidate(CollectionNameExternalAuths).Bind(&hook.Handler[*RecordEvent]{
 		Func: func(e *RecordEvent) error {
-			providerNames := make([]any, 0, len(auth.Providers))
+			providerNames := make([]any, 0, len(auth.Providers)+2)
+			providerNames = append(providerNames, ExternalAuthProviderLDAP, ExternalAuthProviderSAML)
 			for name := range auth.Providers {
 				providerNames = append(providerNames, name)
 			}
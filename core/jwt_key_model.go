@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNameJWTKeys = "_jwtKeys"
+
+var (
+	_ Model        = (*JWTKey)(nil)
+	_ PreValidator = (*JWTKey)(nil)
+	_ RecordProxy  = (*JWTKey)(nil)
+)
+
+// JWTKey defines a Record proxy for working with the jwtKeys collection
+// (aka. the asymmetric auth tokens signing keyset).
+//
+// The record id is used as JWT "kid" header.
+type JWTKey struct {
+	*Record
+}
+
+// NewJWTKey instantiates and returns a new blank *JWTKey model.
+//
+// Example usage:
+//
+//	key := core.NewJWTKey(app)
+//	key.RefreshKeyPair("ES256")
+//	app.Save(key)
+func NewJWTKey(app App) *JWTKey {
+	m := &JWTKey{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameJWTKeys)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on JWTKey.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *JWTKey) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameJWTKeys {
+		return errors.New("missing or invalid JWTKey ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *JWTKey) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *JWTKey) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// Algorithm returns the "algorithm" record field value.
+func (m *JWTKey) Algorithm() string {
+	return m.GetString("algorithm")
+}
+
+// SetAlgorithm updates the "algorithm" record field value.
+func (m *JWTKey) SetAlgorithm(alg string) {
+	m.Set("algorithm", alg)
+}
+
+// PrivateKey returns the encoded "privateKey" record field value.
+func (m *JWTKey) PrivateKey() string {
+	return m.GetString("privateKey")
+}
+
+// SetPrivateKey updates the encoded "privateKey" record field value.
+func (m *JWTKey) SetPrivateKey(encoded string) {
+	m.Set("privateKey", encoded)
+}
+
+// PublicKey returns the encoded "publicKey" record field value.
+func (m *JWTKey) PublicKey() string {
+	return m.GetString("publicKey")
+}
+
+// SetPublicKey updates the encoded "publicKey" record field value.
+func (m *JWTKey) SetPublicKey(encoded string) {
+	m.Set("publicKey", encoded)
+}
+
+// Retired returns the "retired" record field value.
+func (m *JWTKey) Retired() types.DateTime {
+	return m.GetDateTime("retired")
+}
+
+// SetRetired updates the "retired" record field value.
+func (m *JWTKey) SetRetired(date types.DateTime) {
+	m.Set("retired", date)
+}
+
+// Created returns the "created" record field value.
+func (m *JWTKey) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *JWTKey) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// IsRetired reports whether the key was retired and it is no longer used for signing.
+func (m *JWTKey) IsRetired() bool {
+	return !m.Retired().IsZero()
+}
+
+// HasExpired checks if the retired key has exceeded the specified overlap duration
+// and it is no longer accepted for verification.
+func (m *JWTKey) HasExpired(overlap time.Duration) bool {
+	return m.IsRetired() && m.Retired().Time().Add(overlap).Before(time.Now())
+}
+
+// RefreshKeyPair generates and loads a new key pair for the specified asymmetric algorithm.
+func (m *JWTKey) RefreshKeyPair(alg string) error {
+	key, err := security.GenerateSigningKey(alg)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := security.MarshalSigningKey(key)
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := security.MarshalPublicKey(key.Public())
+	if err != nil {
+		return err
+	}
+
+	m.SetAlgorithm(alg)
+	m.SetPrivateKey(privateKey)
+	m.SetPublicKey(publicKey)
+
+	return nil
+}
+
+// Signer decodes and returns the key private key.
+func (m *JWTKey) Signer() (crypto.Signer, error) {
+	return security.ParseSigningKey(m.PrivateKey())
+}
+
+// Verifier decodes and returns the key public key.
+func (m *JWTKey) Verifier() (crypto.PublicKey, error) {
+	return security.ParsePublicKey(m.PublicKey())
+}
+
+// JWK returns the public JWK representation of the key.
+func (m *JWTKey) JWK() (*security.JWK, error) {
+	publicKey, err := m.Verifier()
+	if err != nil {
+		return nil, err
+	}
+
+	return security.NewJWK(m.Id, m.Algorithm(), publicKey)
+}
+
+func (app *BaseApp) registerJWTKeyHooks() {
+	// run on every hour to rotate the signing keys and cleanup the expired ones
+	app.Cron().Add("__pbJWTKeysRotate__", "30 * * * *", func() {
+		if err := app.RefreshJWTKeys(); err != nil {
+			app.Logger().Warn("Failed to refresh the JWT signing keys", "error", err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewJWTKey(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	key := core.NewJWTKey(app)
+
+	if key.Collection().Name != core.CollectionNameJWTKeys {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameJWTKeys, key.Collection().Name)
+	}
+}
+
+func TestJWTKeyProxyRecord(t *testing.T) {
+	t.Parallel()
+
+	record := core.NewRecord(core.NewBaseCollection("test"))
+	record.Id = "test_id"
+
+	key := core.JWTKey{}
+	key.SetProxyRecord(record)
+
+	if key.ProxyRecord() == nil || key.ProxyRecord().Id != record.Id {
+		t.Fatalf("Expected proxy record with id %q, got %v", record.Id, key.ProxyRecord())
+	}
+}
+
+func TestJWTKeyRetired(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	key := core.NewJWTKey(app)
+
+	if key.IsRetired() {
+		t.Fatal("Expected the new key to be active")
+	}
+
+	if key.HasExpired(0) {
+		t.Fatal("Expected active key to never expire")
+	}
+
+	key.SetRetired(types.NowDateTime().Add(-2 * time.Hour))
+
+	if !key.IsRetired() {
+		t.Fatal("Expected the key to be retired")
+	}
+
+	if key.HasExpired(3 * time.Hour) {
+		t.Fatal("Expected the key to be still within the overlap duration")
+	}
+
+	if !key.HasExpired(1 * time.Hour) {
+		t.Fatal("Expected the key to be expired")
+	}
+}
+
+func TestJWTKeyRefreshKeyPair(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	key := core.NewJWTKey(app)
+	key.Id = "test_kid"
+
+	if err := key.RefreshKeyPair("HS256"); err == nil {
+		t.Fatal("Expected symmetric algorithm error")
+	}
+
+	for _, alg := range security.AsymmetricJWTAlgorithms {
+		t.Run(alg, func(t *testing.T) {
+			if err := key.RefreshKeyPair(alg); err != nil {
+				t.Fatal(err)
+			}
+
+			if key.Algorithm() != alg {
+				t.Fatalf("Expected algorithm %q, got %q", alg, key.Algorithm())
+			}
+
+			signer, err := key.Signer()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verifier, err := key.Verifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := security.NewAsymmetricJWT(nil, alg, key.Id, signer, 1*time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := security.ParseAsymmetricJWT(token, alg, verifier); err != nil {
+				t.Fatalf("Expected the public key to match the private key, got %v", err)
+			}
+
+			jwk, err := key.JWK()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if jwk.Kid != key.Id || jwk.Alg != alg {
+				t.Fatalf("Unexpected JWK %v", jwk)
+			}
+		})
+	}
+}
+
+func TestJWTKeyPreValidate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	key := core.NewJWTKey(app)
+	if err := key.RefreshKeyPair("ES256"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Validate(key); err != nil {
+		t.Fatalf("Expected valid key, got %v", err)
+	}
+
+	invalid := &core.JWTKey{}
+	invalid.SetProxyRecord(core.NewRecord(core.NewBaseCollection("test")))
+
+	if err := app.Validate(invalid); err == nil {
+		t.Fatal("Expected invalid proxy record error")
+	}
+}
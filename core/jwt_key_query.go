@@ -0,0 +1,168 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// FindAllJWTKeys returns all JWTKey models (in created DESC order).
+func (app *BaseApp) FindAllJWTKeys() ([]*JWTKey, error) {
+	result := []*JWTKey{}
+
+	err := app.RecordQuery(CollectionNameJWTKeys).
+		OrderBy("created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindJWTKeyById returns a single JWTKey model by its id (aka. the JWT "kid").
+func (app *BaseApp) FindJWTKeyById(id string) (*JWTKey, error) {
+	result := &JWTKey{}
+
+	err := app.RecordQuery(CollectionNameJWTKeys).
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindActiveJWTKey returns the latest non-retired JWTKey model
+// (aka. the current auth tokens signing key).
+func (app *BaseApp) FindActiveJWTKey() (*JWTKey, error) {
+	result := &JWTKey{}
+
+	err := app.RecordQuery(CollectionNameJWTKeys).
+		AndWhere(dbx.HashExp{"retired": ""}).
+		OrderBy("created DESC").
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RotateJWTKeys generates a new signing key with the configured
+// asymmetric JWT settings algorithm and retires the previous active keys.
+//
+// The retired keys are still accepted for verification until the
+// configured settings overlap duration expires.
+func (app *BaseApp) RotateJWTKeys() (*JWTKey, error) {
+	alg := app.Settings().JWT.Algorithm
+
+	if !app.Settings().JWT.IsAsymmetric() {
+		return nil, fmt.Errorf("%q is not an asymmetric JWT algorithm", alg)
+	}
+
+	key := NewJWTKey(app)
+	if err := key.RefreshKeyPair(alg); err != nil {
+		return nil, err
+	}
+
+	err := app.RunInTransaction(func(txApp App) error {
+		if err := retireActiveJWTKeys(txApp); err != nil {
+			return err
+		}
+
+		return txApp.Save(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// RefreshJWTKeys ensures that there is an active signing key
+// matching the current JWT settings, rotates it if it is older
+// than the configured rotation period and deletes the expired keys.
+//
+// If the JWT settings algorithm is not asymmetric, all active keys are retired.
+func (app *BaseApp) RefreshJWTKeys() error {
+	config := app.Settings().JWT
+
+	active, err := app.FindActiveJWTKey()
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	switch {
+	case !config.IsAsymmetric():
+		if active != nil {
+			if err := retireActiveJWTKeys(app); err != nil {
+				return err
+			}
+		}
+	case active == nil,
+		active.Algorithm() != config.Algorithm,
+		config.RotationDays > 0 && active.Created().Time().Add(config.RotationDuration()).Before(time.Now()):
+		if _, err := app.RotateJWTKeys(); err != nil {
+			return err
+		}
+	}
+
+	return app.DeleteExpiredJWTKeys()
+}
+
+// DeleteExpiredJWTKeys deletes the retired keys that have exceeded
+// the configured settings overlap duration.
+func (app *BaseApp) DeleteExpiredJWTKeys() error {
+	keys, err := app.FindAllJWTKeys()
+	if err != nil {
+		return err
+	}
+
+	overlap := app.Settings().JWT.OverlapDuration()
+
+	var errs []error
+	for _, key := range keys {
+		if !key.HasExpired(overlap) {
+			continue
+		}
+
+		if err := app.Delete(key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func retireActiveJWTKeys(app App) error {
+	active := []*JWTKey{}
+
+	err := app.RecordQuery(CollectionNameJWTKeys).
+		AndWhere(dbx.HashExp{"retired": ""}).
+		All(&active)
+	if err != nil {
+		return err
+	}
+
+	now := types.NowDateTime()
+
+	for _, key := range active {
+		key.SetRetired(now)
+		if err := app.Save(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
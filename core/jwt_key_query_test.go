@@ -0,0 +1,188 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestRotateJWTKeys(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	// symmetric algorithm
+	if _, err := app.RotateJWTKeys(); err == nil {
+		t.Fatal("Expected rotation error with the default HS256 algorithm")
+	}
+
+	app.Settings().JWT.Algorithm = "ES256"
+
+	key1, err := app.RotateJWTKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key2, err := app.RotateJWTKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := app.FindActiveJWTKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active.Id != key2.Id {
+		t.Fatalf("Expected active key %q, got %q", key2.Id, active.Id)
+	}
+
+	retired, err := app.FindJWTKeyById(key1.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !retired.IsRetired() {
+		t.Fatal("Expected the previous key to be retired")
+	}
+
+	all, err := app.FindAllJWTKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(all))
+	}
+}
+
+func TestRefreshJWTKeys(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	// symmetric algorithm and no keys
+	if err := app.RefreshJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := app.FindAllJWTKeys(); len(all) != 0 {
+		t.Fatalf("Expected no keys, got %d", len(all))
+	}
+
+	app.Settings().JWT.Algorithm = "ES256"
+	app.Settings().JWT.OverlapDays = 1
+	app.Settings().JWT.RotationDays = 1
+
+	// missing active key
+	if err := app.RefreshJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	key1, err := app.FindActiveJWTKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no rotation is due
+	if err := app.RefreshJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := app.FindActiveJWTKey(); active == nil || active.Id != key1.Id {
+		t.Fatalf("Expected active key %q, got %v", key1.Id, active)
+	}
+
+	// algorithm change
+	app.Settings().JWT.Algorithm = "EdDSA"
+	if err := app.RefreshJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	key2, err := app.FindActiveJWTKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key2.Id == key1.Id || key2.Algorithm() != "EdDSA" {
+		t.Fatalf("Expected new EdDSA key, got %q (%s)", key2.Id, key2.Algorithm())
+	}
+
+	// rotation is due
+	_, err = app.DB().NewQuery("UPDATE {{" + core.CollectionNameJWTKeys + "}} SET [[created]] = {:created} WHERE [[id]] = {:id}").Bind(map[string]any{
+		"created": types.NowDateTime().Add(-25 * time.Hour),
+		"id":      key2.Id,
+	}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.RefreshJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	key3, err := app.FindActiveJWTKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key3.Id == key2.Id {
+		t.Fatal("Expected the old key to be rotated")
+	}
+
+	// switch back to symmetric algorithm
+	app.Settings().JWT.Algorithm = "HS256"
+	if err := app.RefreshJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.FindActiveJWTKey(); err == nil {
+		t.Fatal("Expected all keys to be retired")
+	}
+
+	all, err := app.FindAllJWTKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected the retired keys to be kept until the overlap expires, got %d keys", len(all))
+	}
+}
+
+func TestDeleteExpiredJWTKeys(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Settings().JWT.Algorithm = "ES256"
+	app.Settings().JWT.OverlapDays = 1
+
+	scenarios := []struct {
+		id      string
+		retired types.DateTime
+		expired bool
+	}{
+		{"jwtkeyactive000", types.DateTime{}, false},
+		{"jwtkeyretired00", types.NowDateTime().Add(-1 * time.Hour), false},
+		{"jwtkeyexpired00", types.NowDateTime().Add(-25 * time.Hour), true},
+	}
+
+	for _, s := range scenarios {
+		key := core.NewJWTKey(app)
+		key.Id = s.id
+		key.SetRetired(s.retired)
+		if err := key.RefreshKeyPair("ES256"); err != nil {
+			t.Fatal(err)
+		}
+		if err := app.Save(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := app.DeleteExpiredJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range scenarios {
+		_, err := app.FindJWTKeyById(s.id)
+
+		deleted := err != nil
+		if deleted != s.expired {
+			t.Fatalf("[%s] Expected deleted %v, got %v (%v)", s.id, s.expired, deleted, err)
+		}
+	}
+}
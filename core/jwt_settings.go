@@ -0,0 +1,78 @@
+package core
+
+import (
+	"slices"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// JWTAlgorithmHS256 is the default symmetric auth tokens signing algorithm
+// (the tokens are signed with the auth record tokenKey and the collection authToken secret).
+const JWTAlgorithmHS256 = "HS256"
+
+// JWTConfig defines the app auth tokens signing configuration.
+type JWTConfig struct {
+	// Algorithm specifies the auth tokens signing algorithm.
+	//
+	// Valid values: "HS256" (default), "RS256", "ES256", "EdDSA".
+	//
+	// With an asymmetric algorithm the auth tokens are signed with
+	// an app managed private key and could be verified by other services
+	// with the public keys from the /.well-known/jwks.json endpoint.
+	Algorithm string `form:"algorithm" json:"algorithm"`
+
+	// Issuer is an optional "iss" claim of the asymmetric auth tokens.
+	Issuer string `form:"issuer" json:"issuer"`
+
+	// Audience is an optional "aud" claim of the asymmetric auth tokens.
+	Audience []string `form:"audience" json:"audience"`
+
+	// RotationDays specifies how often (in days) a new signing key to be generated.
+	//
+	// Set it to 0 to disable the automatic rotation.
+	RotationDays int `form:"rotationDays" json:"rotationDays"`
+
+	// OverlapDays specifies for how long (in days) the retired signing keys
+	// are still published and accepted for verification.
+	//
+	// It should be larger than the longest auth token duration.
+	OverlapDays int `form:"overlapDays" json:"overlapDays"`
+}
+
+// Validate makes JWTConfig validatable by implementing [validation.Validatable] interface.
+func (c JWTConfig) Validate() error {
+	asymmetric := c.IsAsymmetric()
+
+	return validation.ValidateStruct(&c,
+		validation.Field(
+			&c.Algorithm,
+			validation.In(JWTAlgorithmHS256, security.JWTAlgorithmRS256, security.JWTAlgorithmES256, security.JWTAlgorithmEdDSA),
+		),
+		validation.Field(&c.Issuer, validation.Length(0, 255)),
+		validation.Field(&c.Audience, validation.Each(validation.Required, validation.Length(1, 255))),
+		validation.Field(&c.RotationDays, validation.Min(0), validation.Max(3650)),
+		validation.Field(
+			&c.OverlapDays,
+			validation.When(asymmetric, validation.Required),
+			validation.Min(0),
+			validation.Max(365),
+		),
+	)
+}
+
+// IsAsymmetric reports whether the configured Algorithm is one of the supported asymmetric algorithms.
+func (c JWTConfig) IsAsymmetric() bool {
+	return slices.Contains(security.AsymmetricJWTAlgorithms, c.Algorithm)
+}
+
+// RotationDuration returns the current RotationDays as [time.Duration].
+func (c JWTConfig) RotationDuration() time.Duration {
+	return time.Duration(c.RotationDays) * 24 * time.Hour
+}
+
+// OverlapDuration returns the current OverlapDays as [time.Duration].
+func (c JWTConfig) OverlapDuration() time.Duration {
+	return time.Duration(c.OverlapDays) * 24 * time.Hour
+}
@@ -0,0 +1,251 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNameOIDCClients = "_oidcClients"
+
+var (
+	_ Model        = (*OIDCClient)(nil)
+	_ PreValidator = (*OIDCClient)(nil)
+	_ RecordProxy  = (*OIDCClient)(nil)
+)
+
+// OIDCClient defines a Record proxy for working with the oidcClients collection
+// (aka. the client apps registered for the OpenID Connect provider mode).
+//
+// The record id is used as OAuth2 "client_id".
+type OIDCClient struct {
+	*Record
+}
+
+// NewOIDCClient instantiates and returns a new blank *OIDCClient model.
+//
+// Example usage:
+//
+//	client := core.NewOIDCClient(app)
+//	client.SetCollectionRef(usersCollection.Id)
+//	client.SetName("Example")
+//	client.SetRedirectURIs([]string{"https://example.com/callback"})
+//	client.SetSecret("...")
+//	app.Save(client)
+func NewOIDCClient(app App) *OIDCClient {
+	m := &OIDCClient{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameOIDCClients)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on OIDCClient.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *OIDCClient) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameOIDCClients {
+		return errors.New("missing or invalid OIDCClient ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *OIDCClient) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *OIDCClient) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *OIDCClient) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *OIDCClient) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// Name returns the "name" record field value.
+func (m *OIDCClient) Name() string {
+	return m.GetString("name")
+}
+
+// SetName updates the "name" record field value.
+func (m *OIDCClient) SetName(name string) {
+	m.Set("name", name)
+}
+
+// RedirectURIs returns the "redirectURIs" record field value.
+func (m *OIDCClient) RedirectURIs() []string {
+	return m.GetStringSlice("redirectURIs")
+}
+
+// SetRedirectURIs updates the "redirectURIs" record field value.
+func (m *OIDCClient) SetRedirectURIs(uris []string) {
+	m.Set("redirectURIs", uris)
+}
+
+// Public returns the "public" record field value.
+//
+// Public clients (e.g. SPA or mobile apps) don't have a secret
+// and are required to use PKCE.
+func (m *OIDCClient) Public() bool {
+	return m.GetBool("public")
+}
+
+// SetPublic updates the "public" record field value.
+func (m *OIDCClient) SetPublic(public bool) {
+	m.Set("public", public)
+}
+
+// SetSecret sets the client secret (it is stored as bcrypt hash).
+func (m *OIDCClient) SetSecret(secret string) {
+	m.Set("secret", secret)
+}
+
+// ValidateSecret validates a plain client secret against the stored hash.
+//
+// Always returns false for public clients.
+func (m *OIDCClient) ValidateSecret(secret string) bool {
+	if m.Public() || secret == "" {
+		return false
+	}
+
+	pv, ok := m.GetRaw("secret").(*PasswordFieldValue)
+	if !ok {
+		return false
+	}
+
+	return pv.Validate(secret)
+}
+
+// HasRedirectURI reports whether uri is one of the registered client redirect URIs.
+//
+// The check is exact string match as required by the OAuth2 security best practices.
+func (m *OIDCClient) HasRedirectURI(uri string) bool {
+	return uri != "" && slices.Contains(m.RedirectURIs(), uri)
+}
+
+// Created returns the "created" record field value.
+func (m *OIDCClient) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *OIDCClient) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+func (app *BaseApp) registerOIDCClientHooks() {
+	app.OnRecordValidate(CollectionNameOIDCClients).Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			client := &OIDCClient{e.Record}
+
+			err := validation.Validate(client.CollectionRef(), validation.Required, validation.By(validateCollectionId(e.App, CollectionTypeAuth)))
+			if err != nil {
+				return validation.Errors{"collectionRef": err}
+			}
+
+			err = validation.Validate(client.RedirectURIs(), validation.Required, validation.Each(validation.Required, is.URL))
+			if err != nil {
+				return validation.Errors{"redirectURIs": err}
+			}
+
+			if !client.Public() && client.GetString("secret:hash") == "" {
+				return validation.Errors{"secret": validation.NewError(
+					"validation_oidc_client_secret_required",
+					"The secret is required for non-public clients.",
+				)}
+			}
+
+			return e.Next()
+		},
+		Priority: 99,
+	})
+
+	// delete on collection ref delete
+	app.OnCollectionDeleteExecute().Bind(&hook.Handler[*CollectionEvent]{
+		Func: func(e *CollectionEvent) error {
+			if !e.Collection.IsAuth() {
+				return e.Next()
+			}
+
+			originalApp := e.App
+			txErr := e.App.RunInTransaction(func(txApp App) error {
+				e.App = txApp
+
+				if err := e.Next(); err != nil {
+					return err
+				}
+
+				rels, err := txApp.FindAllRecords(CollectionNameOIDCClients, dbx.HashExp{"collectionRef": e.Collection.Id})
+				if err != nil {
+					return err
+				}
+
+				for _, rel := range rels {
+					if err := txApp.Delete(rel); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			e.App = originalApp
+
+			return txErr
+		},
+		Priority: 99,
+	})
+
+	// delete the client consents and tokens together with the client
+	app.OnRecordDeleteExecute(CollectionNameOIDCClients).Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			originalApp := e.App
+			txErr := e.App.RunInTransaction(func(txApp App) error {
+				e.App = txApp
+
+				if err := e.Next(); err != nil {
+					return err
+				}
+
+				for _, name := range []string{CollectionNameOIDCConsents, CollectionNameOIDCTokens} {
+					rels, err := txApp.FindAllRecords(name, dbx.HashExp{"clientRef": e.Record.Id})
+					if err != nil {
+						return err
+					}
+
+					for _, rel := range rels {
+						if err := txApp.Delete(rel); err != nil {
+							return err
+						}
+					}
+				}
+
+				return nil
+			})
+			e.App = originalApp
+
+			return txErr
+		},
+		Priority: 99,
+	})
+}
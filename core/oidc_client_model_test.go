@@ -0,0 +1,290 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewOIDCClient(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewOIDCClient(app)
+
+	if m.Collection().Name != core.CollectionNameOIDCClients {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameOIDCClients, m.Collection().Name)
+	}
+}
+
+func TestOIDCClientGettersAndSetters(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewOIDCClient(app)
+	m.SetCollectionRef("test_collection")
+	m.SetName("test_name")
+	m.SetRedirectURIs([]string{"https://example.com/a", "https://example.com/b"})
+	m.SetPublic(true)
+	m.SetRaw("created", "2026-01-01 00:00:00.000Z")
+	m.SetRaw("updated", "2026-01-02 00:00:00.000Z")
+
+	if v := m.CollectionRef(); v != "test_collection" {
+		t.Fatalf("Expected collectionRef %q, got %q", "test_collection", v)
+	}
+	if v := m.Name(); v != "test_name" {
+		t.Fatalf("Expected name %q, got %q", "test_name", v)
+	}
+	if v := m.RedirectURIs(); len(v) != 2 {
+		t.Fatalf("Expected 2 redirectURIs, got %v", v)
+	}
+	if v := m.Public(); !v {
+		t.Fatal("Expected public to be true")
+	}
+	if v := m.Created().String(); v != "2026-01-01 00:00:00.000Z" {
+		t.Fatalf("Expected created %q, got %q", "2026-01-01 00:00:00.000Z", v)
+	}
+	if v := m.Updated().String(); v != "2026-01-02 00:00:00.000Z" {
+		t.Fatalf("Expected updated %q, got %q", "2026-01-02 00:00:00.000Z", v)
+	}
+}
+
+func TestOIDCClientHasRedirectURI(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewOIDCClient(app)
+	m.SetRedirectURIs([]string{"https://example.com/callback"})
+
+	scenarios := []struct {
+		uri      string
+		expected bool
+	}{
+		{"", false},
+		{"https://example.com", false},
+		{"https://example.com/callback/", false},
+		{"https://example.com/callback?a=1", false},
+		{"https://example.com/callback", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.uri, func(t *testing.T) {
+			if v := m.HasRedirectURI(s.uri); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestOIDCClientValidateSecret(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := core.NewOIDCClient(app)
+	m.SetCollectionRef(users.Id)
+	m.SetName("test")
+	m.SetRedirectURIs([]string{"https://example.com/callback"})
+	m.SetSecret("1234567890123456")
+	if err := app.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	// reload to ensure that the secret hash is used
+	m, err = app.FindOIDCClient(users, m.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.ValidateSecret("") {
+		t.Fatal("Expected empty secret to fail")
+	}
+	if m.ValidateSecret("invalid") {
+		t.Fatal("Expected invalid secret to fail")
+	}
+	if !m.ValidateSecret("1234567890123456") {
+		t.Fatal("Expected valid secret to succeed")
+	}
+
+	m.SetPublic(true)
+	if m.ValidateSecret("1234567890123456") {
+		t.Fatal("Expected public client secret validation to always fail")
+	}
+}
+
+func TestOIDCClientValidateHook(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	demo1, err := app.FindCollectionByNameOrId("demo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name        string
+		client      func() *core.OIDCClient
+		expectError bool
+	}{
+		{
+			"non-auth collection",
+			func() *core.OIDCClient {
+				m := core.NewOIDCClient(app)
+				m.SetCollectionRef(demo1.Id)
+				m.SetName("test")
+				m.SetRedirectURIs([]string{"https://example.com/callback"})
+				m.SetPublic(true)
+				return m
+			},
+			true,
+		},
+		{
+			"missing redirect URIs",
+			func() *core.OIDCClient {
+				m := core.NewOIDCClient(app)
+				m.SetCollectionRef(users.Id)
+				m.SetName("test")
+				m.SetPublic(true)
+				return m
+			},
+			true,
+		},
+		{
+			"invalid redirect URI",
+			func() *core.OIDCClient {
+				m := core.NewOIDCClient(app)
+				m.SetCollectionRef(users.Id)
+				m.SetName("test")
+				m.SetRedirectURIs([]string{"https://example.com/callback", "invalid"})
+				m.SetPublic(true)
+				return m
+			},
+			true,
+		},
+		{
+			"confidential client without secret",
+			func() *core.OIDCClient {
+				m := core.NewOIDCClient(app)
+				m.SetCollectionRef(users.Id)
+				m.SetName("test")
+				m.SetRedirectURIs([]string{"https://example.com/callback"})
+				return m
+			},
+			true,
+		},
+		{
+			"confidential client with secret",
+			func() *core.OIDCClient {
+				m := core.NewOIDCClient(app)
+				m.SetCollectionRef(users.Id)
+				m.SetName("test")
+				m.SetRedirectURIs([]string{"https://example.com/callback"})
+				m.SetSecret("1234567890123456")
+				return m
+			},
+			false,
+		},
+		{
+			"public client without secret",
+			func() *core.OIDCClient {
+				m := core.NewOIDCClient(app)
+				m.SetCollectionRef(users.Id)
+				m.SetName("test")
+				m.SetRedirectURIs([]string{"https://example.com/callback"})
+				m.SetPublic(true)
+				return m
+			},
+			false,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			err := app.Validate(s.client())
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+		})
+	}
+}
+
+func TestOIDCClientDeleteCascade(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := core.NewOIDCClient(app)
+	client.SetCollectionRef(user.Collection().Id)
+	client.SetName("test")
+	client.SetRedirectURIs([]string{"https://example.com/callback"})
+	client.SetPublic(true)
+	if err := app.Save(client); err != nil {
+		t.Fatal(err)
+	}
+
+	consent := core.NewOIDCConsent(app)
+	consent.SetCollectionRef(user.Collection().Id)
+	consent.SetRecordRef(user.Id)
+	consent.SetClientRef(client.Id)
+	consent.SetScopes([]string{core.OIDCScopeOpenId})
+	if err := app.Save(consent); err != nil {
+		t.Fatal(err)
+	}
+
+	token := core.NewOIDCToken(app)
+	token.SetCollectionRef(user.Collection().Id)
+	token.SetRecordRef(user.Id)
+	token.SetClientRef(client.Id)
+	token.SetKind(core.OIDCTokenKindRefresh)
+	token.RefreshValue()
+	token.SetExpires(types.NowDateTime().Add(time.Hour))
+	if err := app.Save(token); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Delete(client); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindOIDCConsent(user, client.Id); err == nil {
+		t.Fatal("Expected the client consent to be deleted")
+	}
+
+	tokens, err := app.FindAllOIDCTokensByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 0 {
+		t.Fatalf("Expected the client tokens to be deleted, got %d", len(tokens))
+	}
+}
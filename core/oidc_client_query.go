@@ -0,0 +1,25 @@
+package core
+
+import (
+	"github.com/pocketbase/dbx"
+)
+
+// FindOIDCClient returns the OIDC client with the specified id
+// registered for the provided auth collection.
+func (app *BaseApp) FindOIDCClient(authCollection *Collection, clientId string) (*OIDCClient, error) {
+	result := &OIDCClient{}
+
+	err := app.RecordQuery(CollectionNameOIDCClients).
+		AndWhere(dbx.HashExp{
+			"id":            clientId,
+			"collectionRef": authCollection.Id,
+		}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
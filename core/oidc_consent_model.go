@@ -0,0 +1,166 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNameOIDCConsents = "_oidcConsents"
+
+var (
+	_ Model        = (*OIDCConsent)(nil)
+	_ PreValidator = (*OIDCConsent)(nil)
+	_ RecordProxy  = (*OIDCConsent)(nil)
+)
+
+// OIDCConsent defines a Record proxy for working with the oidcConsents collection
+// (aka. the scopes that an auth record has granted to an OIDC client).
+type OIDCConsent struct {
+	*Record
+}
+
+// NewOIDCConsent instantiates and returns a new blank *OIDCConsent model.
+//
+// Example usage:
+//
+//	consent := core.NewOIDCConsent(app)
+//	consent.SetCollectionRef(user.Collection().Id)
+//	consent.SetRecordRef(user.Id)
+//	consent.SetClientRef(client.Id)
+//	consent.SetScopes([]string{"openid", "email"})
+//	app.Save(consent)
+func NewOIDCConsent(app App) *OIDCConsent {
+	m := &OIDCConsent{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameOIDCConsents)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on OIDCConsent.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *OIDCConsent) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameOIDCConsents {
+		return errors.New("missing or invalid OIDCConsent ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *OIDCConsent) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *OIDCConsent) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *OIDCConsent) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *OIDCConsent) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *OIDCConsent) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *OIDCConsent) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// ClientRef returns the "clientRef" record field value.
+func (m *OIDCConsent) ClientRef() string {
+	return m.GetString("clientRef")
+}
+
+// SetClientRef updates the "clientRef" record field value.
+func (m *OIDCConsent) SetClientRef(clientId string) {
+	m.Set("clientRef", clientId)
+}
+
+// Scopes returns the "scopes" record field value.
+func (m *OIDCConsent) Scopes() []string {
+	return m.GetStringSlice("scopes")
+}
+
+// SetScopes updates the "scopes" record field value.
+func (m *OIDCConsent) SetScopes(scopes []string) {
+	m.Set("scopes", scopes)
+}
+
+// HasScopes reports whether all of the specified scopes were granted.
+func (m *OIDCConsent) HasScopes(scopes []string) bool {
+	return len(list.SubtractSlice(scopes, m.Scopes())) == 0
+}
+
+// Created returns the "created" record field value.
+func (m *OIDCConsent) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *OIDCConsent) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+func (app *BaseApp) registerOIDCConsentHooks() {
+	recordRefHooks[*OIDCConsent](app, CollectionNameOIDCConsents, CollectionTypeAuth)
+
+	// revoke the issued tokens on consent delete
+	app.OnRecordDeleteExecute(CollectionNameOIDCConsents).Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			originalApp := e.App
+			txErr := e.App.RunInTransaction(func(txApp App) error {
+				e.App = txApp
+
+				if err := e.Next(); err != nil {
+					return err
+				}
+
+				consent := &OIDCConsent{e.Record}
+
+				tokens, err := txApp.FindAllRecords(CollectionNameOIDCTokens, dbx.HashExp{
+					"collectionRef": consent.CollectionRef(),
+					"recordRef":     consent.RecordRef(),
+					"clientRef":     consent.ClientRef(),
+				})
+				if err != nil {
+					return err
+				}
+
+				for _, token := range tokens {
+					if err := txApp.Delete(token); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			e.App = originalApp
+
+			return txErr
+		},
+		Priority: 99,
+	})
+}
@@ -0,0 +1,95 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewOIDCConsent(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewOIDCConsent(app)
+
+	if m.Collection().Name != core.CollectionNameOIDCConsents {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameOIDCConsents, m.Collection().Name)
+	}
+}
+
+func TestOIDCConsentHasScopes(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewOIDCConsent(app)
+	m.SetScopes([]string{core.OIDCScopeOpenId, core.OIDCScopeEmail})
+
+	scenarios := []struct {
+		scopes   []string
+		expected bool
+	}{
+		{nil, true},
+		{[]string{core.OIDCScopeOpenId}, true},
+		{[]string{core.OIDCScopeEmail, core.OIDCScopeOpenId}, true},
+		{[]string{core.OIDCScopeOpenId, core.OIDCScopeProfile}, false},
+		{[]string{core.OIDCScopeOfflineAccess}, false},
+	}
+
+	for i, s := range scenarios {
+		if v := m.HasScopes(s.scopes); v != s.expected {
+			t.Errorf("[%d] Expected %v, got %v", i, s.expected, v)
+		}
+	}
+}
+
+func TestOIDCConsentDeleteRevokesTokens(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consent := core.NewOIDCConsent(app)
+	consent.SetCollectionRef(user.Collection().Id)
+	consent.SetRecordRef(user.Id)
+	consent.SetClientRef("client1")
+	if err := app.Save(consent); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, clientId := range []string{"client1", "client2"} {
+		token := core.NewOIDCToken(app)
+		token.SetCollectionRef(user.Collection().Id)
+		token.SetRecordRef(user.Id)
+		token.SetClientRef(clientId)
+		token.SetKind(core.OIDCTokenKindRefresh)
+		token.RefreshValue()
+		token.SetExpires(types.NowDateTime().Add(time.Hour))
+		if err := app.Save(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := app.Delete(consent); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := app.FindAllOIDCTokensByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].ClientRef() != "client2" {
+		t.Fatalf("Expected only the client2 token to remain, got %v", tokens)
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"github.com/pocketbase/dbx"
+)
+
+// FindAllOIDCConsentsByRecord returns all OIDCConsent models linked to the provided auth record.
+func (app *BaseApp) FindAllOIDCConsentsByRecord(authRecord *Record) ([]*OIDCConsent, error) {
+	result := []*OIDCConsent{}
+
+	err := app.RecordQuery(CollectionNameOIDCConsents).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindOIDCConsent returns the OIDCConsent model of the provided
+// auth record for the specified OIDC client.
+func (app *BaseApp) FindOIDCConsent(authRecord *Record, clientId string) (*OIDCConsent, error) {
+	result := &OIDCConsent{}
+
+	err := app.RecordQuery(CollectionNameOIDCConsents).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+			"clientRef":     clientId,
+		}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
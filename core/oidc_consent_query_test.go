@@ -0,0 +1,72 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestFindOIDCConsents(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, clientId := range []string{"client1", "client2"} {
+		m := core.NewOIDCConsent(app)
+		m.SetCollectionRef(user1.Collection().Id)
+		m.SetRecordRef(user1.Id)
+		m.SetClientRef(clientId)
+		if err := app.Save(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// duplicated consent
+	dup := core.NewOIDCConsent(app)
+	dup.SetCollectionRef(user1.Collection().Id)
+	dup.SetRecordRef(user1.Id)
+	dup.SetClientRef("client1")
+	if err := app.Save(dup); err == nil {
+		t.Fatal("Expected unique consent per record and client error")
+	}
+
+	consents, err := app.FindAllOIDCConsentsByRecord(user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(consents) != 2 {
+		t.Fatalf("Expected 2 user1 consents, got %d", len(consents))
+	}
+
+	if consents, _ := app.FindAllOIDCConsentsByRecord(user2); len(consents) != 0 {
+		t.Fatalf("Expected 0 user2 consents, got %d", len(consents))
+	}
+
+	if _, err := app.FindOIDCConsent(user2, "client1"); err == nil {
+		t.Fatal("Expected no user2 consent")
+	}
+
+	if _, err := app.FindOIDCConsent(user1, "missing"); err == nil {
+		t.Fatal("Expected no user1 consent for missing client")
+	}
+
+	consent, err := app.FindOIDCConsent(user1, "client2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consent.ClientRef() != "client2" || consent.RecordRef() != user1.Id {
+		t.Fatalf("Unexpected consent %v", consent)
+	}
+}
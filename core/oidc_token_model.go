@@ -0,0 +1,272 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNameOIDCTokens = "_oidcTokens"
+
+const (
+	OIDCTokenKindCode    = "code"
+	OIDCTokenKindRefresh = "refresh"
+)
+
+var (
+	_ Model        = (*OIDCToken)(nil)
+	_ PreValidator = (*OIDCToken)(nil)
+	_ RecordProxy  = (*OIDCToken)(nil)
+)
+
+// OIDCToken defines a Record proxy for working with the oidcTokens collection
+// (aka. the issued OIDC authorization codes and refresh tokens).
+//
+// Only the hash of the plain token value is stored.
+type OIDCToken struct {
+	*Record
+}
+
+// NewOIDCToken instantiates and returns a new blank *OIDCToken model.
+//
+// Example usage:
+//
+//	token := core.NewOIDCToken(app)
+//	token.SetCollectionRef(user.Collection().Id)
+//	token.SetRecordRef(user.Id)
+//	token.SetClientRef(client.Id)
+//	token.SetKind(core.OIDCTokenKindRefresh)
+//	plain := token.RefreshValue()
+//	token.SetExpires(types.NowDateTime().Add(30 * 24 * time.Hour))
+//	app.Save(token)
+func NewOIDCToken(app App) *OIDCToken {
+	m := &OIDCToken{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameOIDCTokens)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on OIDCToken.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *OIDCToken) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameOIDCTokens {
+		return errors.New("missing or invalid OIDCToken ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *OIDCToken) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *OIDCToken) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *OIDCToken) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *OIDCToken) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *OIDCToken) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *OIDCToken) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// ClientRef returns the "clientRef" record field value.
+func (m *OIDCToken) ClientRef() string {
+	return m.GetString("clientRef")
+}
+
+// SetClientRef updates the "clientRef" record field value.
+func (m *OIDCToken) SetClientRef(clientId string) {
+	m.Set("clientRef", clientId)
+}
+
+// Kind returns the "kind" record field value.
+func (m *OIDCToken) Kind() string {
+	return m.GetString("kind")
+}
+
+// SetKind updates the "kind" record field value.
+func (m *OIDCToken) SetKind(kind string) {
+	m.Set("kind", kind)
+}
+
+// TokenHash returns the "tokenHash" record field value.
+func (m *OIDCToken) TokenHash() string {
+	return m.GetString("tokenHash")
+}
+
+// RefreshValue generates a new random token value, updates
+// the "tokenHash" record field with its hash and returns the plain value.
+func (m *OIDCToken) RefreshValue() string {
+	plain := security.RandomString(50)
+
+	m.Set("tokenHash", HashOIDCToken(plain))
+
+	return plain
+}
+
+// Scopes returns the "scopes" record field value.
+func (m *OIDCToken) Scopes() []string {
+	return m.GetStringSlice("scopes")
+}
+
+// SetScopes updates the "scopes" record field value.
+func (m *OIDCToken) SetScopes(scopes []string) {
+	m.Set("scopes", scopes)
+}
+
+// RedirectURI returns the "redirectURI" record field value
+// (the authorization request redirect_uri).
+func (m *OIDCToken) RedirectURI() string {
+	return m.GetString("redirectURI")
+}
+
+// SetRedirectURI updates the "redirectURI" record field value.
+func (m *OIDCToken) SetRedirectURI(uri string) {
+	m.Set("redirectURI", uri)
+}
+
+// CodeChallenge returns the "codeChallenge" record field value
+// (the authorization request PKCE S256 code_challenge).
+func (m *OIDCToken) CodeChallenge() string {
+	return m.GetString("codeChallenge")
+}
+
+// SetCodeChallenge updates the "codeChallenge" record field value.
+func (m *OIDCToken) SetCodeChallenge(challenge string) {
+	m.Set("codeChallenge", challenge)
+}
+
+// Nonce returns the "nonce" record field value.
+func (m *OIDCToken) Nonce() string {
+	return m.GetString("nonce")
+}
+
+// SetNonce updates the "nonce" record field value.
+func (m *OIDCToken) SetNonce(nonce string) {
+	m.Set("nonce", nonce)
+}
+
+// AuthTime returns the "authTime" record field value.
+func (m *OIDCToken) AuthTime() types.DateTime {
+	return m.GetDateTime("authTime")
+}
+
+// SetAuthTime updates the "authTime" record field value.
+func (m *OIDCToken) SetAuthTime(date types.DateTime) {
+	m.Set("authTime", date)
+}
+
+// Expires returns the "expires" record field value.
+func (m *OIDCToken) Expires() types.DateTime {
+	return m.GetDateTime("expires")
+}
+
+// SetExpires updates the "expires" record field value.
+func (m *OIDCToken) SetExpires(date types.DateTime) {
+	m.Set("expires", date)
+}
+
+// Created returns the "created" record field value.
+func (m *OIDCToken) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *OIDCToken) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// HasExpired checks if the token has reached its expiration date.
+func (m *OIDCToken) HasExpired() bool {
+	return !m.Expires().Time().After(time.Now())
+}
+
+// ValidateCodeVerifier checks the PKCE code_verifier against the stored S256 code_challenge.
+//
+// Returns true if there is no stored code_challenge and the verifier is empty.
+func (m *OIDCToken) ValidateCodeVerifier(verifier string) bool {
+	challenge := m.CodeChallenge()
+	if challenge == "" {
+		return verifier == ""
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+
+	return security.Equal(base64.RawURLEncoding.EncodeToString(hash[:]), challenge)
+}
+
+// HashOIDCToken returns the hash of a plain OIDC token value.
+//
+// Note: the token values are random with high enough entropy so a regular hash is sufficient.
+func HashOIDCToken(plain string) string {
+	return security.SHA256(plain)
+}
+
+func (app *BaseApp) registerOIDCTokenHooks() {
+	recordRefHooks[*OIDCToken](app, CollectionNameOIDCTokens, CollectionTypeAuth)
+
+	// run on every hour to cleanup the expired codes and refresh tokens
+	app.Cron().Add("__pbOIDCTokensCleanup__", "0 * * * *", func() {
+		if err := app.DeleteExpiredOIDCTokens(); err != nil {
+			app.Logger().Warn("Failed to delete expired OIDC tokens", "error", err)
+		}
+	})
+
+	// revoke the issued refresh tokens on password change
+	app.OnRecordUpdate().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			err := e.Next()
+			if err != nil || !e.Record.Collection().IsAuth() {
+				return err
+			}
+
+			old := e.Record.Original().GetString(FieldNamePassword + ":hash")
+			new := e.Record.GetString(FieldNamePassword + ":hash")
+			if old != new {
+				err = e.App.DeleteAllOIDCTokensByRecord(e.Record)
+				if err != nil {
+					e.App.Logger().Warn(
+						"Failed to delete all previous OIDC tokens",
+						"error", err,
+						"recordId", e.Record.Id,
+						"collectionId", e.Record.Collection().Id,
+					)
+				}
+			}
+
+			return nil
+		},
+		Priority: 99,
+	})
+}
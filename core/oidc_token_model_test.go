@@ -0,0 +1,199 @@
+package core_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewOIDCToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewOIDCToken(app)
+
+	if m.Collection().Name != core.CollectionNameOIDCTokens {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameOIDCTokens, m.Collection().Name)
+	}
+}
+
+func TestOIDCTokenGettersAndSetters(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewOIDCToken(app)
+	m.SetCollectionRef("test_collection")
+	m.SetRecordRef("test_record")
+	m.SetClientRef("test_client")
+	m.SetKind(core.OIDCTokenKindCode)
+	m.SetScopes([]string{"a", "b"})
+	m.SetRedirectURI("https://example.com")
+	m.SetCodeChallenge("test_challenge")
+	m.SetNonce("test_nonce")
+	m.SetAuthTime(types.NowDateTime())
+	m.SetExpires(types.NowDateTime())
+	m.SetRaw("created", types.NowDateTime())
+	m.SetRaw("updated", types.NowDateTime())
+
+	if v := m.CollectionRef(); v != "test_collection" {
+		t.Fatalf("Expected collectionRef %q, got %q", "test_collection", v)
+	}
+	if v := m.RecordRef(); v != "test_record" {
+		t.Fatalf("Expected recordRef %q, got %q", "test_record", v)
+	}
+	if v := m.ClientRef(); v != "test_client" {
+		t.Fatalf("Expected clientRef %q, got %q", "test_client", v)
+	}
+	if v := m.Kind(); v != core.OIDCTokenKindCode {
+		t.Fatalf("Expected kind %q, got %q", core.OIDCTokenKindCode, v)
+	}
+	if v := m.Scopes(); len(v) != 2 || v[0] != "a" || v[1] != "b" {
+		t.Fatalf("Expected scopes %v, got %v", []string{"a", "b"}, v)
+	}
+	if v := m.RedirectURI(); v != "https://example.com" {
+		t.Fatalf("Expected redirectURI %q, got %q", "https://example.com", v)
+	}
+	if v := m.CodeChallenge(); v != "test_challenge" {
+		t.Fatalf("Expected codeChallenge %q, got %q", "test_challenge", v)
+	}
+	if v := m.Nonce(); v != "test_nonce" {
+		t.Fatalf("Expected nonce %q, got %q", "test_nonce", v)
+	}
+	if v := m.AuthTime().String(); v != m.GetDateTime("authTime").String() || v == "" {
+		t.Fatalf("Expected authTime %q, got %q", m.GetDateTime("authTime").String(), v)
+	}
+	if v := m.Expires().String(); v != m.GetDateTime("expires").String() || v == "" {
+		t.Fatalf("Expected expires %q, got %q", m.GetDateTime("expires").String(), v)
+	}
+	if v := m.Created().String(); v != m.GetDateTime("created").String() || v == "" {
+		t.Fatalf("Expected created %q, got %q", m.GetDateTime("created").String(), v)
+	}
+	if v := m.Updated().String(); v != m.GetDateTime("updated").String() || v == "" {
+		t.Fatalf("Expected updated %q, got %q", m.GetDateTime("updated").String(), v)
+	}
+
+	// token value
+	plain := m.RefreshValue()
+	if len(plain) != 50 {
+		t.Fatalf("Expected 50 chars plain token, got %q", plain)
+	}
+	if v := m.TokenHash(); v != core.HashOIDCToken(plain) || v == plain {
+		t.Fatalf("Expected tokenHash %q, got %q", core.HashOIDCToken(plain), v)
+	}
+	if plain2 := m.RefreshValue(); plain2 == plain {
+		t.Fatal("Expected a new random token value")
+	}
+}
+
+func TestOIDCTokenHasExpired(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	now := types.NowDateTime()
+
+	scenarios := []struct {
+		expires  types.DateTime
+		expected bool
+	}{
+		{types.DateTime{}, true},
+		{now.Add(-1 * time.Minute), true},
+		{now.Add(1 * time.Minute), false},
+	}
+
+	for i, s := range scenarios {
+		m := core.NewOIDCToken(app)
+		m.SetExpires(s.expires)
+
+		if v := m.HasExpired(); v != s.expected {
+			t.Errorf("[%d] Expected %v, got %v", i, s.expected, v)
+		}
+	}
+}
+
+func TestOIDCTokenValidateCodeVerifier(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	hash := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(hash[:])
+
+	scenarios := []struct {
+		name      string
+		challenge string
+		verifier  string
+		expected  bool
+	}{
+		{"no challenge + empty verifier", "", "", true},
+		{"no challenge + nonempty verifier", "", verifier, false},
+		{"challenge + empty verifier", challenge, "", false},
+		{"challenge + invalid verifier", challenge, verifier + "a", false},
+		{"challenge + plain verifier", verifier, verifier, false},
+		{"challenge + valid verifier", challenge, verifier, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			m := core.NewOIDCToken(app)
+			m.SetCodeChallenge(s.challenge)
+
+			if v := m.ValidateCodeVerifier(s.verifier); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestOIDCTokenPasswordChangeRevoke(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := core.NewOIDCToken(app)
+	token.SetCollectionRef(user.Collection().Id)
+	token.SetRecordRef(user.Id)
+	token.SetClientRef("test")
+	token.SetKind(core.OIDCTokenKindRefresh)
+	token.RefreshValue()
+	token.SetExpires(types.NowDateTime().Add(time.Hour))
+	if err := app.Save(token); err != nil {
+		t.Fatal(err)
+	}
+
+	// non-password change
+	user.Set("name", "new_name")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	if tokens, _ := app.FindAllOIDCTokensByRecord(user); len(tokens) != 1 {
+		t.Fatalf("Expected the token to remain, got %d tokens", len(tokens))
+	}
+
+	// password change
+	user.SetPassword("1234567890_new")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	if tokens, _ := app.FindAllOIDCTokensByRecord(user); len(tokens) != 0 {
+		t.Fatalf("Expected the token to be revoked, got %d tokens", len(tokens))
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// FindOIDCTokenByValue returns the OIDCToken model of the specified kind
+// matching the provided plain token value.
+//
+// Note that the returned token could be expired.
+func (app *BaseApp) FindOIDCTokenByValue(kind string, plain string) (*OIDCToken, error) {
+	result := &OIDCToken{}
+
+	err := app.RecordQuery(CollectionNameOIDCTokens).
+		AndWhere(dbx.HashExp{
+			"kind":      kind,
+			"tokenHash": HashOIDCToken(plain),
+		}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAllOIDCTokensByRecord returns all OIDCToken models linked to the provided auth record.
+func (app *BaseApp) FindAllOIDCTokensByRecord(authRecord *Record) ([]*OIDCToken, error) {
+	result := []*OIDCToken{}
+
+	err := app.RecordQuery(CollectionNameOIDCTokens).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteAllOIDCTokensByRecord deletes all OIDCToken models associated with the provided record.
+//
+// Returns a combined error with the failed deletes.
+func (app *BaseApp) DeleteAllOIDCTokensByRecord(authRecord *Record) error {
+	models, err := app.FindAllOIDCTokensByRecord(authRecord)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, m := range models {
+		if err := app.Delete(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// DeleteExpiredOIDCTokens deletes the expired OIDC authorization codes and refresh tokens.
+func (app *BaseApp) DeleteExpiredOIDCTokens() error {
+	items := []*Record{}
+
+	err := app.RecordQuery(CollectionNameOIDCTokens).
+		AndWhere(dbx.NewExp("[[expires]] <= {:now}", dbx.Params{"now": types.NowDateTime()})).
+		All(&items)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := app.Delete(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func createTestOIDCToken(t *testing.T, app core.App, user *core.Record, kind string, expires types.DateTime) (*core.OIDCToken, string) {
+	token := core.NewOIDCToken(app)
+	token.SetCollectionRef(user.Collection().Id)
+	token.SetRecordRef(user.Id)
+	token.SetClientRef("test")
+	token.SetKind(kind)
+	token.SetExpires(expires)
+	plain := token.RefreshValue()
+
+	if err := app.Save(token); err != nil {
+		t.Fatal(err)
+	}
+
+	return token, plain
+}
+
+func TestFindOIDCTokenByValue(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, codePlain := createTestOIDCToken(t, app, user, core.OIDCTokenKindCode, types.NowDateTime().Add(time.Minute))
+	refresh, refreshPlain := createTestOIDCToken(t, app, user, core.OIDCTokenKindRefresh, types.NowDateTime().Add(time.Hour))
+
+	scenarios := []struct {
+		kind     string
+		plain    string
+		expected string
+	}{
+		{core.OIDCTokenKindCode, "", ""},
+		{core.OIDCTokenKindCode, "invalid", ""},
+		{core.OIDCTokenKindCode, code.TokenHash(), ""},
+		{core.OIDCTokenKindRefresh, codePlain, ""},
+		{core.OIDCTokenKindCode, refreshPlain, ""},
+		{core.OIDCTokenKindCode, codePlain, code.Id},
+		{core.OIDCTokenKindRefresh, refreshPlain, refresh.Id},
+	}
+
+	for i, s := range scenarios {
+		result, err := app.FindOIDCTokenByValue(s.kind, s.plain)
+
+		hasErr := err != nil
+		expectErr := s.expected == ""
+		if hasErr != expectErr {
+			t.Errorf("[%d] Expected hasErr %v, got %v (%v)", i, expectErr, hasErr, err)
+			continue
+		}
+
+		if !hasErr && result.Id != s.expected {
+			t.Errorf("[%d] Expected token %q, got %q", i, s.expected, result.Id)
+		}
+	}
+}
+
+func TestFindAllAndDeleteAllOIDCTokensByRecord(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createTestOIDCToken(t, app, user1, core.OIDCTokenKindCode, types.NowDateTime().Add(time.Minute))
+	createTestOIDCToken(t, app, user1, core.OIDCTokenKindRefresh, types.NowDateTime().Add(time.Hour))
+	createTestOIDCToken(t, app, user2, core.OIDCTokenKindRefresh, types.NowDateTime().Add(time.Hour))
+
+	tokens, err := app.FindAllOIDCTokensByRecord(user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("Expected 2 user1 tokens, got %d", len(tokens))
+	}
+
+	if err := app.DeleteAllOIDCTokensByRecord(user1); err != nil {
+		t.Fatal(err)
+	}
+
+	if tokens, _ := app.FindAllOIDCTokensByRecord(user1); len(tokens) != 0 {
+		t.Fatalf("Expected 0 user1 tokens, got %d", len(tokens))
+	}
+
+	if tokens, _ := app.FindAllOIDCTokensByRecord(user2); len(tokens) != 1 {
+		t.Fatalf("Expected 1 user2 token, got %d", len(tokens))
+	}
+}
+
+func TestDeleteExpiredOIDCTokens(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createTestOIDCToken(t, app, user, core.OIDCTokenKindCode, types.NowDateTime().Add(-time.Minute))
+	createTestOIDCToken(t, app, user, core.OIDCTokenKindRefresh, types.NowDateTime().Add(-time.Hour))
+	active, _ := createTestOIDCToken(t, app, user, core.OIDCTokenKindRefresh, types.NowDateTime().Add(time.Hour))
+
+	if err := app.DeleteExpiredOIDCTokens(); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := app.FindAllOIDCTokensByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].Id != active.Id {
+		t.Fatalf("Expected only the active token %q to remain, got %v", active.Id, tokens)
+	}
+}
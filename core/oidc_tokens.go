@@ -0,0 +1,118 @@
+package core
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported OIDC provider scopes.
+const (
+	OIDCScopeOpenId        = "openid"
+	OIDCScopeProfile       = "profile"
+	OIDCScopeEmail         = "email"
+	OIDCScopeOfflineAccess = "offline_access"
+)
+
+// OIDCScopes is the list with the supported OIDC provider scopes.
+var OIDCScopes = []string{OIDCScopeOpenId, OIDCScopeProfile, OIDCScopeEmail, OIDCScopeOfflineAccess}
+
+// Custom OIDC access token claims.
+const (
+	TokenClaimClientId = "client_id"
+	TokenClaimScope    = "scope"
+)
+
+// OIDCIssuer returns the OIDC provider issuer identifier of the specified auth collection
+// (e.g. "https://example.com/api/collections/users/oidc").
+//
+// It is also the base URL of the provider endpoints.
+func OIDCIssuer(app App, authCollection *Collection) string {
+	return strings.TrimRight(app.Settings().Meta.AppURL, "/") +
+		"/api/collections/" + url.PathEscape(authCollection.Name) + "/oidc"
+}
+
+// OIDCUserClaims returns the standard OIDC user claims
+// of the auth record allowed by the specified scopes.
+//
+// The "profile" scope claims are populated only from the existing
+// "name" and "username" record fields.
+func OIDCUserClaims(authRecord *Record, scopes []string) map[string]any {
+	claims := map[string]any{
+		"sub": authRecord.Id,
+	}
+
+	if slices.Contains(scopes, OIDCScopeProfile) {
+		if authRecord.Collection().Fields.GetByName("name") != nil {
+			claims["name"] = authRecord.GetString("name")
+		}
+
+		if authRecord.Collection().Fields.GetByName("username") != nil {
+			claims["preferred_username"] = authRecord.GetString("username")
+		}
+
+		if updated := authRecord.GetDateTime("updated"); !updated.IsZero() {
+			claims["updated_at"] = updated.Time().Unix()
+		}
+	}
+
+	if slices.Contains(scopes, OIDCScopeEmail) {
+		claims["email"] = authRecord.Email()
+		claims["email_verified"] = authRecord.Verified()
+	}
+
+	return claims
+}
+
+// NewRecordOIDCAccessToken generates and returns a new OIDC access token
+// for the provided auth record.
+//
+// The access token is signed with the app asymmetric JWT keyset similar to
+// the regular auth tokens but it has its own "oidcAccess" token type and
+// additional "client_id" and "scope" claims, so it is accepted only by
+// the OIDC provider endpoints (aka. it cannot be used with the rest of the app API).
+func (app *BaseApp) NewRecordOIDCAccessToken(authRecord *Record, clientId string, scopes []string) (string, error) {
+	if !authRecord.Collection().IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	if !app.Settings().JWT.IsAsymmetric() {
+		return "", ErrMissingSigningKey
+	}
+
+	return app.newRecordAuthToken(authRecord, 0, false, jwt.MapClaims{
+		TokenClaimType:     TokenTypeOIDCAccess,
+		TokenClaimClientId: clientId,
+		TokenClaimScope:    strings.Join(scopes, " "),
+	})
+}
+
+// NewRecordOIDCIdToken generates and returns a new OIDC ID token
+// for the provided auth record signed with the app asymmetric JWT keyset.
+func (app *BaseApp) NewRecordOIDCIdToken(authRecord *Record, clientId string, nonce string, authTime time.Time, scopes []string) (string, error) {
+	collection := authRecord.Collection()
+	if !collection.IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	claims := jwt.MapClaims{
+		"iss":       OIDCIssuer(app, collection),
+		"aud":       clientId,
+		"azp":       clientId,
+		"iat":       time.Now().Unix(),
+		"auth_time": authTime.Unix(),
+	}
+
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	for k, v := range OIDCUserClaims(authRecord, scopes) {
+		claims[k] = v
+	}
+
+	return app.SignJWT(claims, collection.OIDC.IdTokenDurationTime())
+}
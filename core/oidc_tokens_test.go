@@ -0,0 +1,190 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+func TestOIDCIssuer(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Settings().Meta.AppURL = "https://example.com/"
+
+	collection := core.NewAuthCollection("test users")
+
+	expected := "https://example.com/api/collections/test%20users/oidc"
+
+	if v := core.OIDCIssuer(app, collection); v != expected {
+		t.Fatalf("Expected issuer %q, got %q", expected, v)
+	}
+}
+
+func TestOIDCUserClaims(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name     string
+		scopes   []string
+		expected []string
+	}{
+		{"openid", []string{core.OIDCScopeOpenId}, []string{"sub"}},
+		{"profile", []string{core.OIDCScopeOpenId, core.OIDCScopeProfile}, []string{"sub", "name", "preferred_username", "updated_at"}},
+		{"email", []string{core.OIDCScopeOpenId, core.OIDCScopeEmail}, []string{"sub", "email", "email_verified"}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			claims := core.OIDCUserClaims(user, s.scopes)
+
+			if len(claims) != len(s.expected) {
+				t.Fatalf("Expected claims %v, got %v", s.expected, claims)
+			}
+
+			for _, k := range s.expected {
+				if _, ok := claims[k]; !ok {
+					t.Fatalf("Missing claim %q in %v", k, claims)
+				}
+			}
+
+			if claims["sub"] != user.Id {
+				t.Fatalf("Expected sub %q, got %v", user.Id, claims["sub"])
+			}
+		})
+	}
+}
+
+func TestNewRecordOIDCAccessToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scopes := []string{core.OIDCScopeOpenId, core.OIDCScopeEmail}
+
+	// HS256
+	if _, err := app.NewRecordOIDCAccessToken(user, "test_client", scopes); err == nil {
+		t.Fatal("Expected error for non-asymmetric JWT settings")
+	}
+
+	app.Settings().JWT.Algorithm = security.AsymmetricJWTAlgorithms[0]
+
+	token, err := app.NewRecordOIDCAccessToken(user, "test_client", scopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, _ := security.ParseUnverifiedJWT(token)
+	expectedClaims := map[string]any{
+		core.TokenClaimType:         core.TokenTypeOIDCAccess,
+		core.TokenClaimId:           user.Id,
+		core.TokenClaimCollectionId: user.Collection().Id,
+		core.TokenClaimRefreshable:  false,
+		core.TokenClaimClientId:     "test_client",
+		core.TokenClaimScope:        "openid email",
+	}
+	for k, v := range expectedClaims {
+		if claims[k] != v {
+			t.Errorf("Expected claim %q with value %#v, got %#v", k, v, claims[k])
+		}
+	}
+
+	// shouldn't be accepted as regular auth token
+	if _, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth); err == nil {
+		t.Fatal("Expected the OIDC access token to be rejected as auth token")
+	}
+
+	authRecord, err := app.FindAuthRecordByToken(token, core.TokenTypeOIDCAccess)
+	if err != nil {
+		t.Fatalf("Expected valid OIDC access token, got %v", err)
+	}
+	if authRecord.Id != user.Id {
+		t.Fatalf("Expected auth record %q, got %q", user.Id, authRecord.Id)
+	}
+}
+
+func TestNewRecordOIDCIdToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Settings().Meta.AppURL = "https://example.com"
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authTime := time.Now().Add(-5 * time.Minute)
+	scopes := []string{core.OIDCScopeOpenId, core.OIDCScopeEmail}
+
+	// HS256
+	if _, err := app.NewRecordOIDCIdToken(user, "test_client", "test_nonce", authTime, scopes); err == nil {
+		t.Fatal("Expected error for non-asymmetric JWT settings")
+	}
+
+	app.Settings().JWT.Algorithm = security.AsymmetricJWTAlgorithms[0]
+
+	token, err := app.NewRecordOIDCIdToken(user, "test_client", "test_nonce", authTime, scopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := app.FindActiveJWTKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, _ := security.ParseUnverifiedJWTHeader(token)
+	if header["kid"] != key.Id {
+		t.Fatalf("Expected kid %q, got %v", key.Id, header["kid"])
+	}
+
+	claims, _ := security.ParseUnverifiedJWT(token)
+	expectedClaims := map[string]any{
+		"iss":            "https://example.com/api/collections/users/oidc",
+		"aud":            "test_client",
+		"azp":            "test_client",
+		"sub":            user.Id,
+		"nonce":          "test_nonce",
+		"auth_time":      float64(authTime.Unix()),
+		"email":          user.Email(),
+		"email_verified": user.Verified(),
+	}
+	for k, v := range expectedClaims {
+		if claims[k] != v {
+			t.Errorf("Expected claim %q with value %#v, got %#v", k, v, claims[k])
+		}
+	}
+
+	// the regular auth token claims shouldn't be included
+	if _, ok := claims[core.TokenClaimType]; ok {
+		t.Fatalf("Unexpected %q claim", core.TokenClaimType)
+	}
+
+	exp, _ := claims["exp"].(float64)
+	expectedExp := time.Now().Add(user.Collection().OIDC.IdTokenDurationTime()).Unix()
+	if diff := expectedExp - int64(exp); diff < -5 || diff > 5 {
+		t.Fatalf("Expected exp ~%d, got %d", expectedExp, int64(exp))
+	}
+}
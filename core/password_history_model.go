@@ -0,0 +1,218 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNamePasswordHistory = "_passwordHistory"
+
+var (
+	_ Model        = (*PasswordHistory)(nil)
+	_ PreValidator = (*PasswordHistory)(nil)
+	_ RecordProxy  = (*PasswordHistory)(nil)
+)
+
+// PasswordHistory defines a Record proxy for working with the passwordHistory collection.
+//
+// A PasswordHistory entry stores the bcrypt hash of a single auth record
+// password and when it was set (used by the collection [PasswordPolicyConfig]).
+type PasswordHistory struct {
+	*Record
+}
+
+// NewPasswordHistory instantiates and returns a new blank *PasswordHistory model.
+//
+// Example usage:
+//
+//	entry := core.NewPasswordHistory(app)
+//	entry.SetRecordRef(user.Id)
+//	entry.SetCollectionRef(user.Collection().Id)
+//	entry.SetHash(user.GetString("password:hash"))
+//	app.Save(entry)
+func NewPasswordHistory(app App) *PasswordHistory {
+	m := &PasswordHistory{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNamePasswordHistory)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on PasswordHistory.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *PasswordHistory) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNamePasswordHistory {
+		return errors.New("missing or invalid PasswordHistory ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *PasswordHistory) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *PasswordHistory) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *PasswordHistory) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *PasswordHistory) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *PasswordHistory) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *PasswordHistory) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// Hash returns the "hash" record field value (the bcrypt password hash).
+func (m *PasswordHistory) Hash() string {
+	return m.GetString("hash")
+}
+
+// SetHash updates the "hash" record field value.
+func (m *PasswordHistory) SetHash(hash string) {
+	m.Set("hash", hash)
+}
+
+// Created returns the "created" record field value.
+func (m *PasswordHistory) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// HasExpired checks whether the password is older than the specified maxAge.
+//
+// A zero or negative maxAge means that the password never expires.
+func (m *PasswordHistory) HasExpired(maxAge time.Duration) bool {
+	if maxAge <= 0 {
+		return false
+	}
+
+	return m.Created().Time().Add(maxAge).Before(time.Now())
+}
+
+// addPasswordHistory stores the current password hash of the provided
+// auth record and deletes its oldest history entries above the keep limit.
+func addPasswordHistory(app App, authRecord *Record, keep int) error {
+	return app.RunInTransaction(func(txApp App) error {
+		entry := NewPasswordHistory(txApp)
+		entry.SetCollectionRef(authRecord.Collection().Id)
+		entry.SetRecordRef(authRecord.Id)
+		entry.SetHash(authRecord.GetString(FieldNamePassword + ":hash"))
+		if err := txApp.Save(entry); err != nil {
+			return err
+		}
+
+		entries, err := txApp.FindAllPasswordHistoryByRecord(authRecord, 0)
+		if err != nil {
+			return err
+		}
+
+		for i := keep; i < len(entries); i++ {
+			if err := txApp.Delete(entries[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (app *BaseApp) registerPasswordHistoryHooks() {
+	recordRefHooks[*PasswordHistory](app, CollectionNamePasswordHistory, CollectionTypeAuth)
+
+	// store the new password hash on auth record create/update
+	savePasswordHistory := func(e *RecordEvent) error {
+		err := e.Next()
+		if err != nil || !e.Record.Collection().IsAuth() {
+			return err
+		}
+
+		policy := e.Record.Collection().PasswordPolicy
+		if !policy.tracksHistory() {
+			return nil
+		}
+
+		hash := e.Record.GetString(FieldNamePassword + ":hash")
+		if hash == "" || hash == e.Record.Original().GetString(FieldNamePassword+":hash") {
+			return nil // no password change
+		}
+
+		err = addPasswordHistory(e.App, e.Record, max(policy.History, 1))
+		if err != nil {
+			e.App.Logger().Warn(
+				"Failed to store the password history",
+				"error", err,
+				"recordId", e.Record.Id,
+				"collectionId", e.Record.Collection().Id,
+			)
+		}
+
+		return nil
+	}
+
+	app.OnRecordCreate().Bind(&hook.Handler[*RecordEvent]{
+		Func:     savePasswordHistory,
+		Priority: 99,
+	})
+
+	app.OnRecordUpdate().Bind(&hook.Handler[*RecordEvent]{
+		Func:     savePasswordHistory,
+		Priority: 99,
+	})
+
+	// validate the new password against the collection policy
+	app.OnRecordValidate().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			if !e.Record.Collection().IsAuth() {
+				return nil
+			}
+
+			plain := e.Record.GetString(FieldNamePassword)
+			if plain == "" {
+				return nil // no new password
+			}
+
+			err := ValidatePasswordPolicy(e.App, e.Record, plain)
+			if err != nil {
+				var validationErr validation.Error
+				if errors.As(err, &validationErr) {
+					return validation.Errors{FieldNamePassword: validationErr}
+				}
+				return err
+			}
+
+			return nil
+		},
+		Priority: 99,
+	})
+}
@@ -0,0 +1,262 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewPasswordHistory(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewPasswordHistory(app)
+
+	if m.Collection().Name != core.CollectionNamePasswordHistory {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNamePasswordHistory, m.Collection().Name)
+	}
+}
+
+func TestPasswordHistoryGettersAndSetters(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewPasswordHistory(app)
+	m.SetCollectionRef("test_collection")
+	m.SetRecordRef("test_record")
+	m.SetHash("test_hash")
+	m.SetRaw("created", types.NowDateTime())
+
+	if v := m.CollectionRef(); v != "test_collection" {
+		t.Fatalf("Expected collectionRef %q, got %q", "test_collection", v)
+	}
+	if v := m.RecordRef(); v != "test_record" {
+		t.Fatalf("Expected recordRef %q, got %q", "test_record", v)
+	}
+	if v := m.Hash(); v != "test_hash" {
+		t.Fatalf("Expected hash %q, got %q", "test_hash", v)
+	}
+	if v := m.Created().String(); v != m.GetDateTime("created").String() || v == "" {
+		t.Fatalf("Expected created %q, got %q", m.GetDateTime("created").String(), v)
+	}
+}
+
+func TestPasswordHistoryHasExpired(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewPasswordHistory(app)
+	m.SetRaw("created", types.NowDateTime().Add(-1*time.Hour))
+
+	scenarios := []struct {
+		maxAge   time.Duration
+		expected bool
+	}{
+		{0, false},
+		{-1 * time.Minute, false},
+		{2 * time.Hour, false},
+		{30 * time.Minute, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.maxAge.String(), func(t *testing.T) {
+			if v := m.HasExpired(s.maxAge); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestPasswordHistoryPreValidate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("no proxy record", func(t *testing.T) {
+		m := &core.PasswordHistory{}
+
+		if err := app.Validate(m); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("non-PasswordHistory collection", func(t *testing.T) {
+		m := &core.PasswordHistory{}
+		m.SetProxyRecord(core.NewRecord(core.NewBaseCollection("invalid")))
+		m.SetCollectionRef(user.Collection().Id)
+		m.SetRecordRef(user.Id)
+		m.SetHash("test")
+
+		if err := app.Validate(m); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("PasswordHistory collection", func(t *testing.T) {
+		m := core.NewPasswordHistory(app)
+		m.SetCollectionRef(user.Collection().Id)
+		m.SetRecordRef(user.Id)
+		m.SetHash("test")
+
+		if err := app.Validate(m); err != nil {
+			t.Fatalf("Expected nil validation error, got %v", err)
+		}
+	})
+}
+
+func TestPasswordHistoryValidateHook(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	demo1, err := app.FindRecordById("demo1", "84nmscqy84lsi1t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name         string
+		entry        func() *core.PasswordHistory
+		expectErrors []string
+	}{
+		{
+			"empty",
+			func() *core.PasswordHistory {
+				return core.NewPasswordHistory(app)
+			},
+			[]string{"collectionRef", "recordRef", "hash"},
+		},
+		{
+			"non-auth collection",
+			func() *core.PasswordHistory {
+				m := core.NewPasswordHistory(app)
+				m.SetCollectionRef(demo1.Collection().Id)
+				m.SetRecordRef(demo1.Id)
+				m.SetHash("test")
+				return m
+			},
+			[]string{"collectionRef"},
+		},
+		{
+			"missing record id",
+			func() *core.PasswordHistory {
+				m := core.NewPasswordHistory(app)
+				m.SetCollectionRef(user.Collection().Id)
+				m.SetRecordRef("missing")
+				m.SetHash("test")
+				return m
+			},
+			[]string{"recordRef"},
+		},
+		{
+			"valid ref",
+			func() *core.PasswordHistory {
+				m := core.NewPasswordHistory(app)
+				m.SetCollectionRef(user.Collection().Id)
+				m.SetRecordRef(user.Id)
+				m.SetHash("test")
+				return m
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			errs := app.Validate(s.entry())
+			tests.TestValidationErrors(t, errs, s.expectErrors)
+		})
+	}
+}
+
+func TestPasswordHistorySaveHooks(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindAuthRecordByEmail(users, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// disabled policy
+	user.SetPassword("new_password_1")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := app.FindAllPasswordHistoryByRecord(user, 0); len(entries) != 0 {
+		t.Fatalf("Expected no history entries with disabled policy, got %d", len(entries))
+	}
+
+	users.PasswordPolicy.History = 2
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err = app.FindAuthRecordByEmail(users, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no password change
+	user.SetEmailVisibility(true)
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := app.FindAllPasswordHistoryByRecord(user, 0); len(entries) != 0 {
+		t.Fatalf("Expected no history entries without password change, got %d", len(entries))
+	}
+
+	for i, pass := range []string{"new_password_2", "new_password_3", "new_password_4"} {
+		user.SetPassword(pass)
+		if err := app.Save(user); err != nil {
+			t.Fatalf("[%d] Failed to save user: %v", i, err)
+		}
+	}
+
+	entries, err := app.FindAllPasswordHistoryByRecord(user, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected %d history entries, got %d", 2, len(entries))
+	}
+	if entries[0].Hash() != user.GetString("password:hash") {
+		t.Fatalf("Expected the newest entry to be the current password hash, got %q", entries[0].Hash())
+	}
+
+	// delete the related record
+	if err := app.Delete(user); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := app.FindAllPasswordHistoryByRecord(user, 0); len(entries) != 0 {
+		t.Fatalf("Expected the history entries to be deleted with the record, got %d", len(entries))
+	}
+}
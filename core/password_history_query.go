@@ -0,0 +1,52 @@
+package core
+
+import (
+	"github.com/pocketbase/dbx"
+)
+
+// FindAllPasswordHistoryByRecord returns the PasswordHistory models
+// linked to the provided auth record (the newest first).
+//
+// Set limit to 0 or negative value to return all entries.
+func (app *BaseApp) FindAllPasswordHistoryByRecord(authRecord *Record, limit int) ([]*PasswordHistory, error) {
+	result := []*PasswordHistory{}
+
+	query := app.RecordQuery(CollectionNamePasswordHistory).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("created DESC", "rowid DESC")
+
+	if limit > 0 {
+		query.Limit(int64(limit))
+	}
+
+	err := query.All(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindLatestPasswordHistoryByRecord returns the newest PasswordHistory
+// model linked to the provided auth record (aka. its last password change).
+func (app *BaseApp) FindLatestPasswordHistoryByRecord(authRecord *Record) (*PasswordHistory, error) {
+	result := &PasswordHistory{}
+
+	err := app.RecordQuery(CollectionNamePasswordHistory).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("created DESC", "rowid DESC").
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
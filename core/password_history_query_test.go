@@ -0,0 +1,93 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestFindPasswordHistory(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createEntry := func(record *core.Record, hash string, created types.DateTime) {
+		m := core.NewPasswordHistory(app)
+		m.SetCollectionRef(record.Collection().Id)
+		m.SetRecordRef(record.Id)
+		m.SetHash(hash)
+		m.SetRaw("created", created)
+		if err := app.Save(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	createEntry(user1, "hash1", types.NowDateTime().Add(-3*time.Hour))
+	createEntry(user1, "hash3", types.NowDateTime().Add(-1*time.Hour))
+	createEntry(user1, "hash2", types.NowDateTime().Add(-2*time.Hour))
+	createEntry(user2, "hash4", types.NowDateTime())
+
+	t.Run("all", func(t *testing.T) {
+		entries, err := app.FindAllPasswordHistoryByRecord(user1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"hash3", "hash2", "hash1"}
+		if len(entries) != len(expected) {
+			t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+		}
+		for i, hash := range expected {
+			if entries[i].Hash() != hash {
+				t.Fatalf("Expected entry %d to be %q, got %q", i, hash, entries[i].Hash())
+			}
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		entries, err := app.FindAllPasswordHistoryByRecord(user1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(entries) != 2 || entries[0].Hash() != "hash3" || entries[1].Hash() != "hash2" {
+			t.Fatalf("Expected the 2 newest entries, got %v", entries)
+		}
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		entry, err := app.FindLatestPasswordHistoryByRecord(user1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if entry.Hash() != "hash3" {
+			t.Fatalf("Expected the latest entry hash %q, got %q", "hash3", entry.Hash())
+		}
+	})
+
+	t.Run("latest (no history)", func(t *testing.T) {
+		superuser, err := app.FindAuthRecordByEmail(core.CollectionNameSuperusers, "test@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := app.FindLatestPasswordHistoryByRecord(superuser); err == nil {
+			t.Fatal("Expected error for record without history")
+		}
+	})
+}
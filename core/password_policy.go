@@ -0,0 +1,113 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"unicode"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ValidatePasswordPolicy checks whether the plain password satisfies
+// the collection PasswordPolicy of the provided auth record.
+//
+// Policy violations are returned as [validation.Error].
+//
+// Note that the regular password field constraints (min, max, pattern)
+// are not checked here since they are part of the field validators.
+func ValidatePasswordPolicy(app App, authRecord *Record, password string) error {
+	policy := authRecord.Collection().PasswordPolicy
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+
+	if policy.RequireLowercase && !hasLower {
+		return validation.NewError("validation_password_lowercase", "Must contain at least one lowercase letter")
+	}
+
+	if policy.RequireUppercase && !hasUpper {
+		return validation.NewError("validation_password_uppercase", "Must contain at least one uppercase letter")
+	}
+
+	if policy.RequireDigit && !hasDigit {
+		return validation.NewError("validation_password_digit", "Must contain at least one digit")
+	}
+
+	if policy.RequireSymbol && !hasSymbol {
+		return validation.NewError("validation_password_symbol", "Must contain at least one symbol")
+	}
+
+	if policy.History > 0 && !authRecord.IsNew() {
+		reused, err := isPreviousPassword(app, authRecord, password, policy.History)
+		if err != nil {
+			return err
+		}
+		if reused {
+			return validation.NewError(
+				"validation_password_reused",
+				fmt.Sprintf("Must be different from the last %d password(s)", policy.History),
+			)
+		}
+	}
+
+	if policy.CheckBreached {
+		breached, err := security.IsBreachedPassword(filepath.Join(app.DataDir(), LocalBreachedPasswordsFileName), password)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+
+			app.Logger().Warn(
+				"Missing breached passwords file, the check is skipped",
+				"file", LocalBreachedPasswordsFileName,
+				"collectionId", authRecord.Collection().Id,
+			)
+		} else if breached {
+			return validation.NewError(
+				"validation_password_breached",
+				"The password was found in a list of breached passwords, please choose a different one",
+			)
+		}
+	}
+
+	return nil
+}
+
+// isPreviousPassword checks whether the plain password matches the
+// current record password or any of its last n history entries.
+func isPreviousPassword(app App, authRecord *Record, password string, n int) (bool, error) {
+	hashes := []string{authRecord.Original().GetString(FieldNamePassword + ":hash")}
+
+	entries, err := app.FindAllPasswordHistoryByRecord(authRecord, n)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if entry.Hash() != hashes[0] {
+			hashes = append(hashes, entry.Hash())
+		}
+	}
+
+	for _, hash := range hashes {
+		if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
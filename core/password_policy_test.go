@@ -0,0 +1,143 @@
+package core_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestValidatePasswordPolicy(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name         string
+		policy       core.PasswordPolicyConfig
+		breachedFile bool
+		password     string
+		expectedCode string
+	}{
+		{"zero policy", core.PasswordPolicyConfig{}, false, "123456", ""},
+		{"missing lowercase", core.PasswordPolicyConfig{RequireLowercase: true}, false, "ABC123!", "validation_password_lowercase"},
+		{"with lowercase", core.PasswordPolicyConfig{RequireLowercase: true}, false, "abc", ""},
+		{"missing uppercase", core.PasswordPolicyConfig{RequireUppercase: true}, false, "abc123!", "validation_password_uppercase"},
+		{"with uppercase", core.PasswordPolicyConfig{RequireUppercase: true}, false, "aBc", ""},
+		{"missing digit", core.PasswordPolicyConfig{RequireDigit: true}, false, "abcABC!", "validation_password_digit"},
+		{"with digit", core.PasswordPolicyConfig{RequireDigit: true}, false, "abc1", ""},
+		{"missing symbol", core.PasswordPolicyConfig{RequireSymbol: true}, false, "abcABC123", "validation_password_symbol"},
+		{"with symbol", core.PasswordPolicyConfig{RequireSymbol: true}, false, "abc ABC", ""},
+		{"reused current password", core.PasswordPolicyConfig{History: 1}, false, "1234567890", "validation_password_reused"},
+		{"reused history password", core.PasswordPolicyConfig{History: 2}, false, "old_password", "validation_password_reused"},
+		{"history password outside of the limit", core.PasswordPolicyConfig{History: 1}, false, "old_password", ""},
+		{"breached password (missing file)", core.PasswordPolicyConfig{CheckBreached: true}, false, "breached_password", ""},
+		{"breached password", core.PasswordPolicyConfig{CheckBreached: true}, true, "breached_password", "validation_password_breached"},
+		{"non-breached password", core.PasswordPolicyConfig{CheckBreached: true}, true, "a9r!Kp2-Zz", ""},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			app, _ := tests.NewTestApp()
+			defer app.Cleanup()
+
+			user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// old history entries
+			for _, pass := range []string{"old_password", "current"} {
+				hash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.MinCost)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				entry := core.NewPasswordHistory(app)
+				entry.SetCollectionRef(user.Collection().Id)
+				entry.SetRecordRef(user.Id)
+				entry.SetHash(string(hash))
+				if err := app.Save(entry); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if s.breachedFile {
+				hashes := []string{
+					sha1Hex("another_password") + ":1",
+					sha1Hex("breached_password") + ":10",
+				}
+				if hashes[0] > hashes[1] {
+					hashes[0], hashes[1] = hashes[1], hashes[0]
+				}
+
+				err := os.WriteFile(
+					filepath.Join(app.DataDir(), core.LocalBreachedPasswordsFileName),
+					[]byte(strings.Join(hashes, "\n")),
+					0644,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			user.Collection().PasswordPolicy = s.policy
+
+			err = core.ValidatePasswordPolicy(app, user, s.password)
+
+			var code string
+			if err != nil {
+				if codeErr, ok := err.(interface{ Code() string }); ok {
+					code = codeErr.Code()
+				} else {
+					t.Fatalf("Expected validation error, got %v", err)
+				}
+			}
+
+			if code != s.expectedCode {
+				t.Fatalf("Expected error code %q, got %q (%v)", s.expectedCode, code, err)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidateHook(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users.PasswordPolicy.RequireDigit = true
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindAuthRecordByEmail(users, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no new password
+	if err := app.Validate(user); err != nil {
+		t.Fatalf("Expected no error without a new password, got %v", err)
+	}
+
+	user.SetPassword("no_digits_password")
+	tests.TestValidationErrors(t, app.Validate(user), []string{"password"})
+
+	user.SetPassword("with_digits_123")
+	tests.TestValidationErrors(t, app.Validate(user), []string{})
+}
+
+func sha1Hex(text string) string {
+	sum := sha1.Sum([]byte(text))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
@@ -110,10 +110,20 @@ func NewRecordFieldResolver(
 		r.staticRequestInfo["auth"] = nil
 		if r.requestInfo.Auth != nil {
 			authClone := r.requestInfo.Auth.Clone()
-			r.staticRequestInfo["auth"] = authClone.
+			authExport := authClone.
 				Unhide(authClone.Collection().Fields.FieldNames()...).
 				IgnoreEmailVisibility(true).
 				PublicExport()
+
+			if r.requestInfo.APIKey != nil {
+				authExport[requestAuthAPIKeyProp] = map[string]any{
+					FieldNameId: r.requestInfo.APIKey.Id,
+					"name":      r.requestInfo.APIKey.Name(),
+					"scopes":    r.requestInfo.APIKey.Scopes(),
+				}
+			}
+
+			r.staticRequestInfo["auth"] = authExport
 		}
 	}
 
@@ -32,6 +32,10 @@ var plainRequestAuthFields = map[string]struct{}{
 	"@request.auth." + FieldNameVerified:        {},
 }
 
+// requestAuthAPIKeyProp is the name of the virtual @request.auth
+// prop with the details of the API key used for the authentication.
+const requestAuthAPIKeyProp = "apiKey"
+
 // parseAndRun starts a new one-off RecordFieldResolver.Resolve execution.
 func parseAndRun(fieldName string, resolver *RecordFieldResolver) (*search.ResolverResult, error) {
 	r := &runner{
@@ -202,6 +206,13 @@ func (r *runner) processRequestAuthField() (*search.ResolverResult, error) {
 		return &search.ResolverResult{Identifier: "NULL"}, nil
 	}
 
+	// API key scope field
+	// (note: it is resolved even if there is no API key to allow checks like "@request.auth.apiKey.id = ''")
+	// ---
+	if r.activeProps[2] == requestAuthAPIKeyProp {
+		return r.resolver.resolveStaticRequestField(r.activeProps[1:]...)
+	}
+
 	// plain auth field
 	// ---
 	if _, ok := plainRequestAuthFields[r.fieldName]; ok {
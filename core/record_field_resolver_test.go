@@ -934,3 +934,68 @@ func TestRecordFieldResolverResolveStaticRequestInfoFields(t *testing.T) {
 		t.Fatalf("Expected the original authRecord email to not be exported, got %q", v)
 	}
 }
+
+func TestRecordFieldResolverResolveRequestAuthAPIKeyFields(t *testing.T) {
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection, err := app.FindCollectionByNameOrId("demo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authRecord, err := app.FindRecordById("users", "4q1xlclmfloku33")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKey := core.NewAPIKey(app)
+	apiKey.Id = "apikey000000001"
+	apiKey.SetName("test")
+	apiKey.SetScopes([]string{"demo1:list", "demo1:view"})
+
+	scenarios := []struct {
+		name             string
+		apiKey           *core.APIKey
+		fieldName        string
+		expectParamValue string // encoded json
+	}{
+		{"without API key", nil, "@request.auth.apiKey.id", ``},
+		{"without API key (scopes)", nil, "@request.auth.apiKey.scopes", ``},
+		{"with API key (id)", apiKey, "@request.auth.apiKey.id", `"apikey000000001"`},
+		{"with API key (name)", apiKey, "@request.auth.apiKey.name", `"test"`},
+		{"with API key (scopes)", apiKey, "@request.auth.apiKey.scopes", `"[\"demo1:list\",\"demo1:view\"]"`},
+		{"with API key (missing)", apiKey, "@request.auth.apiKey.missing", ``},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			requestInfo := &core.RequestInfo{Auth: authRecord, APIKey: s.apiKey}
+
+			r := core.NewRecordFieldResolver(app, collection, requestInfo, true)
+
+			result, err := r.Resolve(s.fieldName)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.expectParamValue == "" {
+				if len(result.Params) != 0 || result.Identifier != "NULL" {
+					t.Fatalf("Expected NULL identifier without params, got %q (%v)", result.Identifier, result.Params)
+				}
+				return
+			}
+
+			if len(result.Params) != 1 {
+				t.Fatalf("Expected 1 placeholder parameter for %v, got %v", result.Identifier, result.Params)
+			}
+
+			for _, v := range result.Params {
+				encoded, _ := json.Marshal(v)
+				if string(encoded) != s.expectParamValue {
+					t.Fatalf("Expected param value %s, got %s", s.expectParamValue, encoded)
+				}
+			}
+		})
+	}
+}
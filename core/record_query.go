@@ -465,7 +465,7 @@ func (app *BaseApp) CountRecords(collectionModelOrIdentifier any, exprs ...dbx.E
 }
 
 // FindAuthRecordByToken finds the auth record associated with the provided JWT
-// (auth, file, verifyEmail, changeEmail, passwordReset types).
+// (auth, file, verifyEmail, changeEmail, passwordReset, oidcAccess types).
 //
 // Optionally specify a list of validTypes to check tokens only from those types.
 //
@@ -502,6 +502,26 @@ func (app *BaseApp) FindAuthRecordByToken(token string, validTypes ...string) (*
 		return nil, errors.New("the token is not associated to an auth collection record")
 	}
 
+	// asymmetric auth or OIDC access token
+	if tokenType == TokenTypeAuth || tokenType == TokenTypeOIDCAccess {
+		header, err := security.ParseUnverifiedJWTHeader(token)
+		if err != nil {
+			return nil, err
+		}
+
+		alg, _ := header["alg"].(string)
+		if alg != JWTAlgorithmHS256 {
+			kid, _ := header["kid"].(string)
+
+			err = app.verifyAsymmetricAuthToken(record, token, alg, kid)
+			if err != nil {
+				return nil, err
+			}
+
+			return record, nil
+		}
+	}
+
 	var baseTokenKey string
 	switch tokenType {
 	case TokenTypeAuth:
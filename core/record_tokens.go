@@ -1,7 +1,11 @@
 package core
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,6 +19,9 @@ const (
 	TokenTypeVerification  = "verification"
 	TokenTypePasswordReset = "passwordReset"
 	TokenTypeEmailChange   = "emailChange"
+	TokenTypeWebAuthn      = "webauthn"
+	TokenTypeTOTPSetup     = "totpSetup"
+	TokenTypeOIDCAccess    = "oidcAccess"
 )
 
 // List with commonly used record token claims
@@ -25,6 +32,10 @@ const (
 	TokenClaimEmail        = "email"
 	TokenClaimNewEmail     = "newEmail"
 	TokenClaimRefreshable  = "refreshable"
+	TokenClaimChallenge    = "challenge"
+	TokenClaimSecret       = "secret"
+	TokenClaimKeyHash      = "keyHash"
+	TokenClaimSessionId    = "sessionId"
 )
 
 // Common token related errors
@@ -40,15 +51,15 @@ var (
 //
 // Zero or negative duration will fallback to the duration from the auth collection settings.
 func (m *Record) NewStaticAuthToken(duration time.Duration) (string, error) {
-	return m.newAuthToken(duration, false)
+	return m.newAuthToken(duration, false, nil)
 }
 
 // NewAuthToken generates and returns a new record authentication token.
 func (m *Record) NewAuthToken() (string, error) {
-	return m.newAuthToken(0, true)
+	return m.newAuthToken(0, true, nil)
 }
 
-func (m *Record) newAuthToken(duration time.Duration, refreshable bool) (string, error) {
+func (m *Record) newAuthToken(duration time.Duration, refreshable bool, extraClaims jwt.MapClaims) (string, error) {
 	if !m.Collection().IsAuth() {
 		return "", ErrNotAuthRecord
 	}
@@ -65,6 +76,8 @@ func (m *Record) newAuthToken(duration time.Duration, refreshable bool) (string,
 		TokenClaimRefreshable:  refreshable,
 	}
 
+	maps.Copy(claims, extraClaims)
+
 	if duration <= 0 {
 		duration = m.Collection().AuthToken.DurationTime()
 	}
@@ -163,3 +176,173 @@ func (m *Record) NewFileToken() (string, error) {
 		m.Collection().FileToken.DurationTime(),
 	)
 }
+
+// -------------------------------------------------------------------
+
+// NewRecordAuthToken generates and returns a new record authentication token
+// signed according to the app JWT settings.
+//
+// With the default HS256 settings algorithm it is the same as [Record.NewAuthToken].
+func (app *BaseApp) NewRecordAuthToken(authRecord *Record) (string, error) {
+	return app.newRecordAuthToken(authRecord, 0, true, nil)
+}
+
+// NewRecordStaticAuthToken generates and returns a new static record authentication token
+// signed according to the app JWT settings.
+//
+// With the default HS256 settings algorithm it is the same as [Record.NewStaticAuthToken].
+func (app *BaseApp) NewRecordStaticAuthToken(authRecord *Record, duration time.Duration) (string, error) {
+	return app.newRecordAuthToken(authRecord, duration, false, nil)
+}
+
+// NewRecordSessionAuthToken generates and returns a new short-lived
+// (non-refreshable) session access token signed according to the app JWT settings.
+//
+// The token duration is the auth collection sessions access token duration
+// and the session id is stored in the "sessionId" token claim.
+func (app *BaseApp) NewRecordSessionAuthToken(authRecord *Record, sessionId string) (string, error) {
+	if !authRecord.Collection().IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	return app.newRecordAuthToken(
+		authRecord,
+		authRecord.Collection().Sessions.AccessTokenDurationTime(),
+		false,
+		jwt.MapClaims{TokenClaimSessionId: sessionId},
+	)
+}
+
+func (app *BaseApp) newRecordAuthToken(authRecord *Record, duration time.Duration, refreshable bool, extraClaims jwt.MapClaims) (string, error) {
+	config := app.Settings().JWT
+
+	if !config.IsAsymmetric() {
+		return authRecord.newAuthToken(duration, refreshable, extraClaims)
+	}
+
+	if !authRecord.Collection().IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	claims := jwt.MapClaims{
+		TokenClaimType:         TokenTypeAuth,
+		TokenClaimId:           authRecord.Id,
+		TokenClaimCollectionId: authRecord.Collection().Id,
+		TokenClaimRefreshable:  refreshable,
+		TokenClaimKeyHash:      authRecord.authTokenKeyHash(),
+		"sub":                  authRecord.Id,
+		"iat":                  time.Now().Unix(),
+	}
+
+	if config.Issuer != "" {
+		claims["iss"] = config.Issuer
+	}
+
+	if len(config.Audience) > 0 {
+		claims["aud"] = config.Audience
+	}
+
+	maps.Copy(claims, extraClaims)
+
+	if duration <= 0 {
+		duration = authRecord.Collection().AuthToken.DurationTime()
+	}
+
+	return app.SignJWT(claims, duration)
+}
+
+// SignJWT generates and returns a new JWT with the provided claims
+// signed with the active asymmetric JWT signing key.
+//
+// The signing key is generated if missing or if the JWT settings algorithm was changed.
+//
+// Returns an error if the JWT settings algorithm is not asymmetric.
+func (app *BaseApp) SignJWT(claims jwt.MapClaims, duration time.Duration) (string, error) {
+	config := app.Settings().JWT
+
+	if !config.IsAsymmetric() {
+		return "", fmt.Errorf("%q is not an asymmetric JWT algorithm", config.Algorithm)
+	}
+
+	key, err := app.FindActiveJWTKey()
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	// lazily generate the first (or after algorithm change) signing key
+	if key == nil || key.Algorithm() != config.Algorithm {
+		key, err = app.RotateJWTKeys()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	signer, err := key.Signer()
+	if err != nil {
+		return "", errors.Join(ErrMissingSigningKey, err)
+	}
+
+	return security.NewAsymmetricJWT(claims, key.Algorithm(), key.Id, signer, duration)
+}
+
+// verifyAsymmetricAuthToken verifies the signature and claims of
+// an asymmetric auth token issued for the provided auth record.
+func (app *BaseApp) verifyAsymmetricAuthToken(authRecord *Record, token string, alg string, kid string) error {
+	if kid == "" {
+		return errors.New("missing token kid header")
+	}
+
+	key, err := app.FindJWTKeyById(kid)
+	if err != nil {
+		return fmt.Errorf("unknown token kid %q: %w", kid, err)
+	}
+
+	config := app.Settings().JWT
+
+	if key.Algorithm() != alg {
+		return fmt.Errorf("token algorithm %q doesn't match the key algorithm", alg)
+	}
+
+	if key.HasExpired(config.OverlapDuration()) {
+		return errors.New("the token signing key has expired")
+	}
+
+	verifier, err := key.Verifier()
+	if err != nil {
+		return err
+	}
+
+	claims, err := security.ParseAsymmetricJWT(token, alg, verifier)
+	if err != nil {
+		return err
+	}
+
+	// ensures that the token is invalidated on password or tokenKey change
+	// (similar to the HS256 tokens signing key)
+	keyHash, _ := claims[TokenClaimKeyHash].(string)
+	if keyHash == "" || !security.Equal(keyHash, authRecord.authTokenKeyHash()) {
+		return errors.New("invalid token key hash")
+	}
+
+	if config.Issuer != "" {
+		if iss, _ := claims.GetIssuer(); iss != config.Issuer {
+			return errors.New("invalid token issuer")
+		}
+	}
+
+	if len(config.Audience) > 0 {
+		aud, _ := claims.GetAudience()
+
+		if !slices.ContainsFunc(config.Audience, func(a string) bool { return slices.Contains(aud, a) }) {
+			return errors.New("invalid token audience")
+		}
+	}
+
+	return nil
+}
+
+// authTokenKeyHash returns a hash of the record auth tokens signing key
+// that is embedded in the asymmetric auth tokens.
+func (m *Record) authTokenKeyHash() string {
+	return security.HS256(m.Id, m.TokenKey()+m.Collection().AuthToken.Secret)[:32]
+}
@@ -174,3 +174,148 @@ func testRecordToken(
 		}
 	})
 }
+
+func TestNewRecordAuthToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	testRecordToken(t, core.TokenTypeAuth, func(record *core.Record) (string, error) {
+		return app.NewRecordAuthToken(record)
+	}, map[string]any{
+		core.TokenClaimRefreshable: true,
+	})
+}
+
+func TestNewRecordStaticAuthToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	testRecordToken(t, core.TokenTypeAuth, func(record *core.Record) (string, error) {
+		return app.NewRecordStaticAuthToken(record, 0)
+	}, map[string]any{
+		core.TokenClaimRefreshable: false,
+	})
+}
+
+func TestNewRecordSessionAuthToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	testRecordToken(t, core.TokenTypeAuth, func(record *core.Record) (string, error) {
+		return app.NewRecordSessionAuthToken(record, "test_session")
+	}, map[string]any{
+		core.TokenClaimRefreshable: false,
+		core.TokenClaimSessionId:   "test_session",
+	})
+}
+
+func TestNewRecordAuthTokenAsymmetric(t *testing.T) {
+	t.Parallel()
+
+	for _, alg := range security.AsymmetricJWTAlgorithms {
+		t.Run(alg, func(t *testing.T) {
+			app, _ := tests.NewTestApp()
+			defer app.Cleanup()
+
+			app.Settings().JWT.Algorithm = alg
+			app.Settings().JWT.OverlapDays = 1
+			app.Settings().JWT.Issuer = "https://example.com"
+			app.Settings().JWT.Audience = []string{"a", "b"}
+
+			user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := app.NewRecordAuthToken(user)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := app.FindActiveJWTKey()
+			if err != nil {
+				t.Fatalf("Expected a lazily generated signing key, got %v", err)
+			}
+
+			header, _ := security.ParseUnverifiedJWTHeader(token)
+			if header["alg"] != alg || header["kid"] != key.Id {
+				t.Fatalf("Unexpected token header %v", header)
+			}
+
+			claims, _ := security.ParseUnverifiedJWT(token)
+			expectedClaims := map[string]any{
+				core.TokenClaimType:         core.TokenTypeAuth,
+				core.TokenClaimId:           user.Id,
+				core.TokenClaimCollectionId: user.Collection().Id,
+				core.TokenClaimRefreshable:  true,
+				"sub":                       user.Id,
+				"iss":                       "https://example.com",
+			}
+			for k, v := range expectedClaims {
+				if claims[k] != v {
+					t.Errorf("Expected claim %q with value %#v, got %#v", k, v, claims[k])
+				}
+			}
+
+			tokenRecord, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth)
+			if err != nil || tokenRecord.Id != user.Id {
+				t.Fatalf("Expected auth record %q, got %v (%v)", user.Id, tokenRecord, err)
+			}
+
+			// the HS256 tokens should be still accepted
+			legacyToken, err := user.NewAuthToken()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := app.FindAuthRecordByToken(legacyToken, core.TokenTypeAuth); err != nil {
+				t.Fatalf("Expected the HS256 token to be valid, got %v", err)
+			}
+
+			// the retired key should be accepted until the overlap expires
+			if _, err := app.RotateJWTKeys(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth); err != nil {
+				t.Fatalf("Expected the token to be valid after rotation, got %v", err)
+			}
+
+			app.Settings().JWT.OverlapDays = 0
+			if _, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth); err == nil {
+				t.Fatal("Expected the token to be invalid after the overlap expired")
+			}
+			app.Settings().JWT.OverlapDays = 1
+
+			// issuer mismatch
+			app.Settings().JWT.Issuer = "https://example2.com"
+			if _, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth); err == nil {
+				t.Fatal("Expected issuer mismatch error")
+			}
+			app.Settings().JWT.Issuer = "https://example.com"
+
+			// audience mismatch
+			app.Settings().JWT.Audience = []string{"c"}
+			if _, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth); err == nil {
+				t.Fatal("Expected audience mismatch error")
+			}
+			app.Settings().JWT.Audience = []string{"b", "c"}
+			if _, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth); err != nil {
+				t.Fatalf("Expected audience match, got %v", err)
+			}
+
+			// tokenKey change
+			user.RefreshTokenKey()
+			if err := app.Save(user); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth); err == nil {
+				t.Fatal("Expected the token to be invalidated after tokenKey change")
+			}
+		})
+	}
+}
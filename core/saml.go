@@ -0,0 +1,35 @@
+package core
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/tools/saml"
+)
+
+// SAMLBaseURL returns the base URL of the SAML service provider endpoints
+// of the specified auth collection (e.g. "https://example.com/api/collections/users/saml").
+func SAMLBaseURL(app App, authCollection *Collection) string {
+	return strings.TrimRight(app.Settings().Meta.AppURL, "/") +
+		"/api/collections/" + url.PathEscape(authCollection.Name) + "/saml"
+}
+
+// NewSAMLServiceProvider initializes a new SAML service provider
+// from the specified auth collection options.
+//
+// The default entity id is the SP metadata URL and the
+// AssertionConsumerService is "{SAMLBaseURL}/acs".
+func NewSAMLServiceProvider(app App, authCollection *Collection) (*saml.ServiceProvider, error) {
+	if !authCollection.IsAuth() {
+		return nil, ErrNotAuthRecord
+	}
+
+	if !authCollection.SAML.Enabled {
+		return nil, errors.New("the collection SAML auth is not enabled")
+	}
+
+	baseURL := SAMLBaseURL(app, authCollection)
+
+	return authCollection.SAML.ServiceProvider(baseURL+"/metadata", baseURL+"/acs")
+}
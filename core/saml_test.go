@@ -0,0 +1,69 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestSAMLBaseURL(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Settings().Meta.AppURL = "https://example.com/"
+
+	collection := core.NewAuthCollection("test users")
+
+	result := core.SAMLBaseURL(app, collection)
+
+	expected := "https://example.com/api/collections/test%20users/saml"
+	if result != expected {
+		t.Fatalf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestNewSAMLServiceProvider(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Settings().Meta.AppURL = "https://example.com"
+
+	t.Run("non-auth collection", func(t *testing.T) {
+		_, err := core.NewSAMLServiceProvider(app, core.NewBaseCollection("test"))
+		if !errors.Is(err, core.ErrNotAuthRecord) {
+			t.Fatalf("Expected ErrNotAuthRecord, got %v", err)
+		}
+	})
+
+	t.Run("disabled SAML", func(t *testing.T) {
+		if _, err := core.NewSAMLServiceProvider(app, core.NewAuthCollection("test")); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("enabled SAML", func(t *testing.T) {
+		collection, err := newTestSAMLAuthCollection()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sp, err := core.NewSAMLServiceProvider(app, collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sp.EntityId != "https://example.com/api/collections/new_auth/saml/metadata" {
+			t.Fatalf("Unexpected entity id %q", sp.EntityId)
+		}
+
+		if sp.ACSURL != "https://example.com/api/collections/new_auth/saml/acs" {
+			t.Fatalf("Unexpected ACS url %q", sp.ACSURL)
+		}
+	})
+}
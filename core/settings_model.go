@@ -129,6 +129,8 @@ type settings struct {
 	Batch        BatchConfig        `form:"batch" json:"batch"`
 	Logs         LogsConfig         `form:"logs" json:"logs"`
 	AI           AISettings        `form:"ai" json:"ai"`
+	JWT          JWTConfig          `form:"jwt" json:"jwt"`
+	Import       ImportConfig       `form:"import" json:"import"`
 }
 
 // Settings defines the PocketBase app settings.
@@ -187,6 +189,10 @@ func newDefaultSettings() *Settings {
 				Model:       "gpt-4o-mini",
 				Temperature: 0.1,
 			},
+			JWT: JWTConfig{
+				Algorithm:   JWTAlgorithmHS256,
+				OverlapDays: 14,
+			},
 		},
 	}
 }
@@ -297,6 +303,8 @@ func (s *Settings) PostValidate(ctx context.Context, app App) error {
 		validation.Field(&s.RateLimits),
 		validation.Field(&s.TrustedProxy),
 		validation.Field(&s.AI),
+		validation.Field(&s.JWT),
+		validation.Field(&s.Import),
 	)
 }
 
@@ -453,6 +461,23 @@ func (c BatchConfig) Validate() error {
 
 // -------------------------------------------------------------------
 
+type ImportConfig struct {
+	// MaxFileSize is the maximum allowed size in bytes of a single
+	// uploaded background import job file.
+	//
+	// If not set, fallbacks to max ~1GB.
+	MaxFileSize int64 `form:"maxFileSize" json:"maxFileSize"`
+}
+
+// Validate makes ImportConfig validatable by implementing [validation.Validatable] interface.
+func (c ImportConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxFileSize, validation.Min(0)),
+	)
+}
+
+// -------------------------------------------------------------------
+
 type BackupsConfig struct {
 	// Cron is a cron expression to schedule auto backups, eg. "* * * * *".
 	//
@@ -106,7 +106,7 @@ func TestSettingsMarshalJSON(t *testing.T) {
 	}
 	rawStr := string(raw)
 
-	expected := `{"smtp":{"enabled":false,"port":0,"host":"","username":"abc","authMethod":"","tls":false,"localName":""},"backups":{"cron":"","cronMaxKeep":0,"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false}},"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false},"meta":{"appName":"test123","appURL":"","senderName":"","senderAddress":"","hideControls":false},"rateLimits":{"rules":[],"enabled":false},"trustedProxy":{"headers":[],"useLeftmostIP":false},"batch":{"enabled":false,"maxRequests":0,"timeout":0,"maxBodySize":0},"logs":{"maxDays":0,"minLevel":0,"logIP":false,"logAuthId":false},"ai":{"enabled":false,"provider":"","baseUrl":"","model":"","temperature":0}}`
+	expected := `{"smtp":{"enabled":false,"port":0,"host":"","username":"abc","authMethod":"","tls":false,"localName":""},"backups":{"cron":"","cronMaxKeep":0,"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false}},"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false},"meta":{"appName":"test123","appURL":"","senderName":"","senderAddress":"","hideControls":false},"rateLimits":{"rules":[],"enabled":false},"trustedProxy":{"headers":[],"useLeftmostIP":false},"batch":{"enabled":false,"maxRequests":0,"timeout":0,"maxBodySize":0},"logs":{"maxDays":0,"minLevel":0,"logIP":false,"logAuthId":false},"ai":{"enabled":false,"provider":"","baseUrl":"","model":"","temperature":0},"jwt":{"algorithm":"","issuer":"","audience":null,"rotationDays":0,"overlapDays":0},"import":{"maxFileSize":0}}`
 
 	if rawStr != expected {
 		t.Fatalf("Expected\n%v\ngot\n%v", expected, rawStr)
@@ -133,6 +133,7 @@ func TestSettingsValidate(t *testing.T) {
 	s.Batch.Enabled = true
 	s.Batch.MaxRequests = -1
 	s.Batch.Timeout = -1
+	s.Import.MaxFileSize = -1
 	s.RateLimits.Enabled = true
 	s.RateLimits.Rules = nil
 
@@ -150,6 +151,7 @@ func TestSettingsValidate(t *testing.T) {
 		`"backups":{`,
 		`"batch":{`,
 		`"rateLimits":{`,
+		`"import":{`,
 	}
 
 	errBytes, _ := json.Marshal(err)
@@ -430,6 +432,38 @@ func TestBackupsConfigValidate(t *testing.T) {
 	}
 }
 
+func TestImportConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.ImportConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.ImportConfig{},
+			[]string{},
+		},
+		{
+			"invalid data (negative value)",
+			core.ImportConfig{MaxFileSize: -1},
+			[]string{"maxFileSize"},
+		},
+		{
+			"valid data",
+			core.ImportConfig{MaxFileSize: 100},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
 func TestBatchConfigValidate(t *testing.T) {
 	scenarios := []struct {
 		name           string
@@ -485,6 +519,62 @@ func TestBatchConfigValidate(t *testing.T) {
 	}
 }
 
+func TestJWTConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.JWTConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.JWTConfig{},
+			[]string{},
+		},
+		{
+			"zero value (asymmetric)",
+			core.JWTConfig{Algorithm: "ES256"},
+			[]string{"overlapDays"},
+		},
+		{
+			"invalid data",
+			core.JWTConfig{
+				Algorithm:    "HS512",
+				Issuer:       strings.Repeat("a", 256),
+				Audience:     []string{"a", ""},
+				RotationDays: -1,
+				OverlapDays:  366,
+			},
+			[]string{"algorithm", "issuer", "audience", "rotationDays", "overlapDays"},
+		},
+		{
+			"valid data (symmetric)",
+			core.JWTConfig{
+				Algorithm: "HS256",
+			},
+			[]string{},
+		},
+		{
+			"valid data (asymmetric)",
+			core.JWTConfig{
+				Algorithm:    "EdDSA",
+				Issuer:       "https://example.com",
+				Audience:     []string{"a", "b"},
+				RotationDays: 30,
+				OverlapDays:  14,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
 func TestRateLimitsConfigValidate(t *testing.T) {
 	scenarios := []struct {
 		name           string
@@ -0,0 +1,399 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/totp"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const CollectionNameTOTPs = "_totps"
+
+// TOTPRecoveryCodeAlphabet is the alphabet of the generated TOTP recovery codes.
+const TOTPRecoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+
+// ErrTOTPCodeUsed is returned by [SaveTOTPUse] when the verified code
+// (or recovery code) was already used by another request.
+var ErrTOTPCodeUsed = errors.New("the TOTP code or recovery code is already used")
+
+var (
+	_ Model        = (*TOTP)(nil)
+	_ PreValidator = (*TOTP)(nil)
+	_ RecordProxy  = (*TOTP)(nil)
+)
+
+// TOTP defines a Record proxy for working with the totps collection.
+type TOTP struct {
+	*Record
+}
+
+// NewTOTP instantiates and returns a new blank *TOTP model.
+//
+// Example usage:
+//
+//	t := core.NewTOTP(app)
+//	t.SetRecordRef(user.Id)
+//	t.SetCollectionRef(user.Collection().Id)
+//	t.SetPlainSecret(user.Collection(), totp.NewSecret())
+//	app.Save(t)
+func NewTOTP(app App) *TOTP {
+	m := &TOTP{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameTOTPs)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on TOTP.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *TOTP) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameTOTPs {
+		return errors.New("missing or invalid TOTP ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *TOTP) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *TOTP) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *TOTP) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *TOTP) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *TOTP) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *TOTP) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// Secret returns the encrypted "secret" record field value.
+func (m *TOTP) Secret() string {
+	return m.GetString("secret")
+}
+
+// SetSecret updates the encrypted "secret" record field value.
+func (m *TOTP) SetSecret(encryptedSecret string) {
+	m.Set("secret", encryptedSecret)
+}
+
+// PlainSecret decrypts and returns the base32 TOTP secret
+// using the TOTP key of the specified auth collection.
+func (m *TOTP) PlainSecret(authCollection *Collection) (string, error) {
+	return DecryptTOTPSecret(authCollection, m.Secret())
+}
+
+// SetPlainSecret encrypts the base32 TOTP secret with the TOTP key
+// of the specified auth collection and updates the "secret" record field value.
+func (m *TOTP) SetPlainSecret(authCollection *Collection, secret string) error {
+	encrypted, err := EncryptTOTPSecret(authCollection, secret)
+	if err != nil {
+		return err
+	}
+
+	m.SetSecret(encrypted)
+
+	return nil
+}
+
+// LastStep returns the "lastStep" record field value
+// (aka. the time step of the last accepted code).
+func (m *TOTP) LastStep() int64 {
+	return int64(m.GetInt("lastStep"))
+}
+
+// SetLastStep updates the "lastStep" record field value.
+func (m *TOTP) SetLastStep(step int64) {
+	m.Set("lastStep", step)
+}
+
+// RecoveryCodes returns the "recoveryCodes" record field value
+// (aka. the list with the hashes of the unused recovery codes).
+func (m *TOTP) RecoveryCodes() []string {
+	codes := []string{}
+
+	_ = m.UnmarshalJSONField("recoveryCodes", &codes)
+
+	return codes
+}
+
+// SetRecoveryCodes updates the "recoveryCodes" record field value
+// with the hashes of the specified plain recovery codes.
+func (m *TOTP) SetRecoveryCodes(plainCodes []string) {
+	hashes := make([]string, len(plainCodes))
+
+	for i, code := range plainCodes {
+		hashes[i] = hashTOTPRecoveryCode(code)
+	}
+
+	m.Set("recoveryCodes", hashes)
+}
+
+// LastUsed returns the "lastUsed" record field value.
+func (m *TOTP) LastUsed() types.DateTime {
+	return m.GetDateTime("lastUsed")
+}
+
+// SetLastUsed updates the "lastUsed" record field value.
+func (m *TOTP) SetLastUsed(date types.DateTime) {
+	m.Set("lastUsed", date)
+}
+
+// Created returns the "created" record field value.
+func (m *TOTP) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *TOTP) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// ValidateCode checks whether code is a valid TOTP code for the current time.
+//
+// To prevent replays, the codes from time steps equal or older than
+// the last accepted one are rejected.
+//
+// On success the "lastStep" field is updated (the caller is responsible
+// to persist the change with [SaveTOTPUse] for already stored enrollments).
+func (m *TOTP) ValidateCode(authCollection *Collection, code string) bool {
+	secret, err := m.PlainSecret(authCollection)
+	if err != nil {
+		return false
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), authCollection.TOTP.Options())
+	if !ok || step <= m.LastStep() {
+		return false
+	}
+
+	m.SetLastStep(step)
+
+	return true
+}
+
+// UseRecoveryCode checks whether code is one of the unused recovery codes.
+//
+// On success the matched code is removed from the "recoveryCodes" field
+// (the caller is responsible to persist the change with [SaveTOTPUse]).
+func (m *TOTP) UseRecoveryCode(code string) bool {
+	hash := hashTOTPRecoveryCode(code)
+	hashes := m.RecoveryCodes()
+
+	match := -1
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			match = i
+		}
+	}
+
+	if match < 0 {
+		return false
+	}
+
+	m.Set("recoveryCodes", append(hashes[:match], hashes[match+1:]...))
+
+	return true
+}
+
+// SaveTOTPUse persists the state changes of a successful [TOTP.ValidateCode]
+// or [TOTP.UseRecoveryCode] call (and the "lastUsed" field) of an already
+// stored TOTP enrollment.
+//
+// The changes are saved with a conditional update so that each code
+// is accepted only once, even by concurrent requests:
+//   - the new "lastStep" is stored only if the stored one is older
+//   - the used recovery code is removed only if it is still stored
+//
+// Returns [ErrTOTPCodeUsed] if no row was updated.
+func SaveTOTPUse(app App, m *TOTP) error {
+	original := &TOTP{Record: m.Original()}
+
+	now := types.NowDateTime()
+
+	params := dbx.Params{"updated": now}
+	if lastUsed := m.LastUsed(); !lastUsed.IsZero() {
+		params["lastUsed"] = lastUsed
+	}
+
+	where := []dbx.Expression{dbx.HashExp{"id": m.Id}}
+
+	if step := m.LastStep(); step != original.LastStep() {
+		params["lastStep"] = step
+		where = append(where, dbx.NewExp("[[lastStep]] < {:step}", dbx.Params{"step": step}))
+	}
+
+	used := list.SubtractSlice(original.RecoveryCodes(), m.RecoveryCodes())
+	if len(used) > 0 {
+		placeholders := make([]string, len(used))
+		usedParams := dbx.Params{}
+		for i, hash := range used {
+			name := fmt.Sprintf("used%d", i)
+			placeholders[i] = "{:" + name + "}"
+			usedParams[name] = hash
+
+			where = append(where, dbx.NewExp(
+				"EXISTS (SELECT 1 FROM json_each([[recoveryCodes]]) WHERE [[value]] = {:"+name+"})",
+				dbx.Params{name: hash},
+			))
+		}
+
+		params["recoveryCodes"] = dbx.NewExp(
+			"(SELECT json_group_array([[value]]) FROM json_each([[recoveryCodes]]) WHERE [[value]] NOT IN ("+strings.Join(placeholders, ", ")+"))",
+			usedParams,
+		)
+	}
+
+	if len(where) == 1 {
+		return ErrTOTPCodeUsed // nothing to claim
+	}
+
+	result, err := app.NonconcurrentDB().Update(CollectionNameTOTPs, params, dbx.And(where...)).Execute()
+	if err != nil {
+		return err
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated != 1 {
+		return ErrTOTPCodeUsed
+	}
+
+	m.SetRaw("updated", now)
+
+	return nil
+}
+
+func (app *BaseApp) registerTOTPHooks() {
+	recordRefHooks[*TOTP](app, CollectionNameTOTPs, CollectionTypeAuth)
+}
+
+// -------------------------------------------------------------------
+
+// NewTOTPRecoveryCodes generates the specified number of random
+// recovery codes in the format "xxxxx-xxxxx".
+func NewTOTPRecoveryCodes(total int) []string {
+	codes := make([]string, total)
+
+	for i := range codes {
+		code := security.RandomStringWithAlphabet(10, TOTPRecoveryCodeAlphabet)
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+
+	return codes
+}
+
+// hashTOTPRecoveryCode normalizes and hashes the specified plain recovery code.
+//
+// Note: the recovery codes are random with high enough entropy so a regular hash is sufficient.
+func hashTOTPRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+
+	return security.SHA256(code)
+}
+
+// EncryptTOTPSecret encrypts the base32 TOTP secret with
+// the TOTP key of the specified auth collection.
+func EncryptTOTPSecret(authCollection *Collection, secret string) (string, error) {
+	if !authCollection.IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	return security.Encrypt([]byte(secret), authCollection.TOTP.Secret)
+}
+
+// DecryptTOTPSecret decrypts the encrypted TOTP secret with
+// the TOTP key of the specified auth collection.
+func DecryptTOTPSecret(authCollection *Collection, encryptedSecret string) (string, error) {
+	if !authCollection.IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	secret, err := security.Decrypt(encryptedSecret, authCollection.TOTP.Secret)
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
+
+// NewTOTPSetupToken generates and returns a new short-lived TOTP
+// enrollment setup token that holds the encrypted pending secret.
+func (m *Record) NewTOTPSetupToken(encryptedSecret string) (string, error) {
+	if !m.Collection().IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	key := m.TokenKey() + m.Collection().AuthToken.Secret
+	if key == "" {
+		return "", ErrMissingSigningKey
+	}
+
+	return security.NewJWT(
+		jwt.MapClaims{
+			TokenClaimType:         TokenTypeTOTPSetup,
+			TokenClaimId:           m.Id,
+			TokenClaimCollectionId: m.Collection().Id,
+			TokenClaimSecret:       encryptedSecret,
+		},
+		key,
+		m.Collection().TOTP.DurationTime(),
+	)
+}
+
+// ParseTOTPSetupToken verifies the TOTP enrollment setup token
+// issued for the current auth record and returns its encrypted secret.
+func (m *Record) ParseTOTPSetupToken(token string) (string, error) {
+	if !m.Collection().IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	claims, err := security.ParseJWT(token, m.TokenKey()+m.Collection().AuthToken.Secret)
+	if err != nil {
+		return "", err
+	}
+
+	if claims[TokenClaimType] != TokenTypeTOTPSetup ||
+		claims[TokenClaimId] != m.Id ||
+		claims[TokenClaimCollectionId] != m.Collection().Id {
+		return "", errors.New("invalid TOTP setup token type, record or collection")
+	}
+
+	secret, _ := claims[TokenClaimSecret].(string)
+	if secret == "" {
+		return "", errors.New("missing TOTP setup token secret")
+	}
+
+	return secret, nil
+}
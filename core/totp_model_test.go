@@ -0,0 +1,388 @@
+package core_test
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/totp"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestNewTOTP(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewTOTP(app)
+
+	if m.Collection().Name != core.CollectionNameTOTPs {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameTOTPs, m.Collection().Name)
+	}
+}
+
+func TestTOTPGettersAndSetters(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := core.NewTOTP(app)
+	m.SetCollectionRef("test_collection")
+	m.SetRecordRef("test_record")
+	m.SetLastStep(123)
+	m.SetLastUsed(types.NowDateTime())
+	m.SetRaw("created", types.NowDateTime())
+	m.SetRaw("updated", types.NowDateTime())
+
+	if v := m.CollectionRef(); v != "test_collection" {
+		t.Fatalf("Expected collectionRef %q, got %q", "test_collection", v)
+	}
+	if v := m.RecordRef(); v != "test_record" {
+		t.Fatalf("Expected recordRef %q, got %q", "test_record", v)
+	}
+	if v := m.LastStep(); v != 123 {
+		t.Fatalf("Expected lastStep %d, got %d", 123, v)
+	}
+	if v := m.LastUsed().String(); v != m.GetDateTime("lastUsed").String() || v == "" {
+		t.Fatalf("Expected lastUsed %q, got %q", m.GetDateTime("lastUsed").String(), v)
+	}
+	if v := m.Created().String(); v != m.GetDateTime("created").String() || v == "" {
+		t.Fatalf("Expected created %q, got %q", m.GetDateTime("created").String(), v)
+	}
+	if v := m.Updated().String(); v != m.GetDateTime("updated").String() || v == "" {
+		t.Fatalf("Expected updated %q, got %q", m.GetDateTime("updated").String(), v)
+	}
+
+	// secret
+	if err := m.SetPlainSecret(users, "ABC"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Secret() == "" || m.Secret() == "ABC" {
+		t.Fatalf("Expected the secret to be encrypted, got %q", m.Secret())
+	}
+	if v, err := m.PlainSecret(users); err != nil || v != "ABC" {
+		t.Fatalf("Expected plain secret %q, got %q (%v)", "ABC", v, err)
+	}
+
+	// recovery codes
+	if v := m.RecoveryCodes(); len(v) != 0 {
+		t.Fatalf("Expected no recovery codes, got %v", v)
+	}
+	m.SetRecoveryCodes([]string{"abc", "def"})
+	codes := m.RecoveryCodes()
+	if len(codes) != 2 || slices.Contains(codes, "abc") || slices.Contains(codes, "def") {
+		t.Fatalf("Expected 2 hashed recovery codes, got %v", codes)
+	}
+}
+
+func TestTOTPPreValidate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	totpsCol, err := app.FindCollectionByNameOrId(core.CollectionNameTOTPs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("no proxy record", func(t *testing.T) {
+		m := &core.TOTP{}
+
+		if err := app.Validate(m); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("non-TOTP collection", func(t *testing.T) {
+		m := &core.TOTP{}
+		m.SetProxyRecord(core.NewRecord(core.NewBaseCollection("invalid")))
+		m.SetRecordRef(user.Id)
+		m.SetCollectionRef(user.Collection().Id)
+		m.SetSecret("test")
+
+		if err := app.Validate(m); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("TOTP collection", func(t *testing.T) {
+		m := &core.TOTP{}
+		m.SetProxyRecord(core.NewRecord(totpsCol))
+		m.SetRecordRef(user.Id)
+		m.SetCollectionRef(user.Collection().Id)
+		m.SetSecret("test")
+
+		if err := app.Validate(m); err != nil {
+			t.Fatalf("Expected nil validation error, got %v", err)
+		}
+	})
+}
+
+func TestTOTPValidateCode(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users.TOTP.Skew = 1
+
+	secret := totp.NewSecret()
+	opts := users.TOTP.Options()
+
+	m := core.NewTOTP(app)
+	if err := m.SetPlainSecret(users, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	current, _ := totp.Code(secret, time.Now(), opts)
+	prev, _ := totp.Code(secret, time.Now().Add(-time.Duration(users.TOTP.Period)*time.Second), opts)
+
+	if m.ValidateCode(users, "000000x") {
+		t.Fatal("Expected invalid code to fail")
+	}
+
+	if !m.ValidateCode(users, current) {
+		t.Fatal("Expected the current code to be valid")
+	}
+
+	if m.LastStep() != totp.Step(time.Now(), opts) {
+		t.Fatalf("Expected lastStep %d, got %d", totp.Step(time.Now(), opts), m.LastStep())
+	}
+
+	if m.ValidateCode(users, current) {
+		t.Fatal("Expected the already used code to be rejected")
+	}
+
+	if m.ValidateCode(users, prev) {
+		t.Fatal("Expected the older step code to be rejected")
+	}
+
+	// different collection key
+	clients, err := app.FindCollectionByNameOrId("clients")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, _ := totp.Code(secret, time.Now().Add(time.Duration(users.TOTP.Period)*time.Second), opts)
+	if m.ValidateCode(clients, next) {
+		t.Fatal("Expected the code to be rejected with different collection key")
+	}
+}
+
+func TestTOTPUseRecoveryCode(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	m := core.NewTOTP(app)
+	m.SetRecoveryCodes([]string{"abcde-fghij", "klmno-pqrst"})
+
+	if m.UseRecoveryCode("missing") {
+		t.Fatal("Expected missing recovery code to fail")
+	}
+
+	// normalized
+	if !m.UseRecoveryCode("ABCDE FGHIJ") {
+		t.Fatal("Expected the recovery code to be valid")
+	}
+
+	if m.UseRecoveryCode("abcde-fghij") {
+		t.Fatal("Expected the used recovery code to be rejected")
+	}
+
+	if v := m.RecoveryCodes(); len(v) != 1 {
+		t.Fatalf("Expected 1 remaining recovery code, got %v", v)
+	}
+
+	if !m.UseRecoveryCode("klmnopqrst") {
+		t.Fatal("Expected the second recovery code to be valid")
+	}
+}
+
+func TestSaveTOTPUse(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindAuthRecordByEmail(users, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := totp.NewSecret()
+
+	enrollment := core.NewTOTP(app)
+	enrollment.SetCollectionRef(users.Id)
+	enrollment.SetRecordRef(user.Id)
+	if err := enrollment.SetPlainSecret(users, secret); err != nil {
+		t.Fatal(err)
+	}
+	enrollment.SetRecoveryCodes([]string{"abcde-fghij", "klmno-pqrst"})
+	if err := app.Save(enrollment); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate concurrent requests by loading the same enrollment twice
+	load := func() *core.TOTP {
+		m, err := app.FindTOTPByRecord(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	t.Run("without state change", func(t *testing.T) {
+		if err := core.SaveTOTPUse(app, load()); !errors.Is(err, core.ErrTOTPCodeUsed) {
+			t.Fatalf("Expected ErrTOTPCodeUsed, got %v", err)
+		}
+	})
+
+	t.Run("code", func(t *testing.T) {
+		code, _ := totp.Code(secret, time.Now(), users.TOTP.Options())
+
+		m1 := load()
+		m2 := load()
+
+		if !m1.ValidateCode(users, code) || !m2.ValidateCode(users, code) {
+			t.Fatal("Expected the code to be valid for both loaded enrollments")
+		}
+
+		m1.SetLastUsed(types.NowDateTime())
+		if err := core.SaveTOTPUse(app, m1); err != nil {
+			t.Fatalf("Expected the first use to succeed, got %v", err)
+		}
+
+		if err := core.SaveTOTPUse(app, m2); !errors.Is(err, core.ErrTOTPCodeUsed) {
+			t.Fatalf("Expected ErrTOTPCodeUsed for the second use, got %v", err)
+		}
+
+		stored := load()
+		if stored.LastStep() != m1.LastStep() {
+			t.Fatalf("Expected lastStep %d, got %d", m1.LastStep(), stored.LastStep())
+		}
+		if stored.LastUsed().IsZero() {
+			t.Fatal("Expected lastUsed to be updated")
+		}
+		if v := stored.RecoveryCodes(); len(v) != 2 {
+			t.Fatalf("Expected the recovery codes to be unchanged, got %v", v)
+		}
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		m1 := load()
+		m2 := load()
+		m3 := load()
+
+		if !m1.UseRecoveryCode("abcde-fghij") || !m2.UseRecoveryCode("abcde-fghij") || !m3.UseRecoveryCode("klmno-pqrst") {
+			t.Fatal("Expected the recovery codes to be valid for all loaded enrollments")
+		}
+
+		if err := core.SaveTOTPUse(app, m1); err != nil {
+			t.Fatalf("Expected the first use to succeed, got %v", err)
+		}
+
+		if err := core.SaveTOTPUse(app, m2); !errors.Is(err, core.ErrTOTPCodeUsed) {
+			t.Fatalf("Expected ErrTOTPCodeUsed for the second use, got %v", err)
+		}
+
+		// a different code loaded before the first use should still succeed
+		// without restoring the already used one
+		if err := core.SaveTOTPUse(app, m3); err != nil {
+			t.Fatalf("Expected the other recovery code use to succeed, got %v", err)
+		}
+
+		if v := load().RecoveryCodes(); len(v) != 0 {
+			t.Fatalf("Expected no remaining recovery codes, got %v", v)
+		}
+	})
+}
+
+func TestNewTOTPRecoveryCodes(t *testing.T) {
+	t.Parallel()
+
+	codes := core.NewTOTPRecoveryCodes(5)
+	if len(codes) != 5 {
+		t.Fatalf("Expected 5 codes, got %d", len(codes))
+	}
+
+	pattern := regexp.MustCompile(`^[` + core.TOTPRecoveryCodeAlphabet + `]{5}-[` + core.TOTPRecoveryCodeAlphabet + `]{5}$`)
+	for _, code := range codes {
+		if !pattern.MatchString(code) {
+			t.Fatalf("Invalid recovery code format %q", code)
+		}
+	}
+
+	if len(core.NewTOTPRecoveryCodes(0)) != 0 {
+		t.Fatal("Expected no codes")
+	}
+}
+
+func TestNewAndParseTOTPSetupToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := user1.NewTOTPSetupToken("test_secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := user2.ParseTOTPSetupToken(token); err == nil {
+		t.Fatal("Expected different record error")
+	}
+
+	secret, err := user1.ParseTOTPSetupToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret != "test_secret" {
+		t.Fatalf("Expected secret %q, got %q", "test_secret", secret)
+	}
+
+	// auth tokens must not be accepted as setup tokens
+	authToken, err := user1.NewAuthToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := user1.ParseTOTPSetupToken(authToken); err == nil {
+		t.Fatal("Expected auth token parse error")
+	}
+}
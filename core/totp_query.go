@@ -0,0 +1,25 @@
+package core
+
+import (
+	"github.com/pocketbase/dbx"
+)
+
+// FindTOTPByRecord returns the TOTP model linked to the provided auth record
+// (aka. the record TOTP enrollment).
+func (app *BaseApp) FindTOTPByRecord(authRecord *Record) (*TOTP, error) {
+	result := &TOTP{}
+
+	err := app.RecordQuery(CollectionNameTOTPs).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
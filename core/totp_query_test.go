@@ -0,0 +1,63 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestFindTOTPByRecord(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := core.NewTOTP(app)
+	m.Id = "user1totp000000"
+	m.SetCollectionRef(user1.Collection().Id)
+	m.SetRecordRef(user1.Id)
+	m.SetSecret("test")
+	if err := app.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	// duplicated enrollment
+	dup := core.NewTOTP(app)
+	dup.SetCollectionRef(user1.Collection().Id)
+	dup.SetRecordRef(user1.Id)
+	dup.SetSecret("test")
+	if err := app.Save(dup); err == nil {
+		t.Fatal("Expected unique enrollment per record error")
+	}
+
+	if _, err := app.FindTOTPByRecord(user2); err == nil {
+		t.Fatal("Expected no TOTP for user2")
+	}
+
+	result, err := app.FindTOTPByRecord(user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Id != m.Id {
+		t.Fatalf("Expected TOTP %q, got %q", m.Id, result.Id)
+	}
+
+	// the enrollment should be deleted together with its auth record
+	if err := app.Delete(user1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.FindTOTPByRecord(user1); err == nil {
+		t.Fatal("Expected the TOTP to be deleted with its auth record")
+	}
+}
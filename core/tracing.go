@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/tracing"
+)
+
+var (
+	_ hook.HandlerTracer = (*RequestEvent)(nil)
+	_ hook.HandlerTracer = (*ModelEvent)(nil)
+	_ hook.HandlerTracer = (*RecordEvent)(nil)
+	_ hook.HandlerTracer = (*CollectionEvent)(nil)
+)
+
+// TraceHandler implements the [hook.HandlerTracer] interface method.
+func (e *RequestEvent) TraceHandler(event hook.Resolver, handlerId string) func(err error) {
+	if e.Request == nil {
+		return nil
+	}
+
+	return traceHookHandler(e.App, e.Request.Context(), event, handlerId)
+}
+
+// TraceHandler implements the [hook.HandlerTracer] interface method.
+func (e *ModelEvent) TraceHandler(event hook.Resolver, handlerId string) func(err error) {
+	return traceHookHandler(e.App, e.Context, event, handlerId)
+}
+
+// TraceHandler implements the [hook.HandlerTracer] interface method.
+func (e *RecordEvent) TraceHandler(event hook.Resolver, handlerId string) func(err error) {
+	return traceHookHandler(e.App, e.Context, event, handlerId)
+}
+
+// TraceHandler implements the [hook.HandlerTracer] interface method.
+func (e *CollectionEvent) TraceHandler(event hook.Resolver, handlerId string) func(err error) {
+	return traceHookHandler(e.App, e.Context, event, handlerId)
+}
+
+// traceHookHandler starts a new hook handler span (if the app has tracing enabled)
+// and returns a function that ends it with the handler result.
+func traceHookHandler(app App, ctx context.Context, event hook.Resolver, handlerId string) func(err error) {
+	if app == nil || app.Tracer() == nil {
+		return nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	eventName := fmt.Sprintf("%T", event)
+	if i := strings.LastIndex(eventName, "."); i >= 0 {
+		eventName = eventName[i+1:]
+	}
+
+	_, span := app.Tracer().Start(ctx, "hook "+eventName, tracing.WithAttributes(map[string]any{
+		"hook.event":      eventName,
+		"hook.handler.id": handlerId,
+	}))
+
+	return func(err error) {
+		span.RecordError(err)
+		span.End()
+	}
+}
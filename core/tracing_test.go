@@ -0,0 +1,126 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/tracing"
+)
+
+func TestTracingDisabled(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	if app.Tracer() != nil {
+		t.Fatal("Expected nil tracer by default")
+	}
+
+	event := new(core.RecordEvent)
+	event.App = app
+	event.Context = context.Background()
+
+	if end := event.TraceHandler(event, "test"); end != nil {
+		t.Fatal("Expected nil TraceHandler end func")
+	}
+}
+
+func TestTracingEnabled(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracing.NewInMemoryExporter()
+	tracer := tracing.New(tracing.Config{Exporter: exporter})
+
+	app, err := tests.NewTestAppWithConfig(core.BaseAppConfig{Tracer: tracer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	if app.Tracer() != tracer {
+		t.Fatal("Expected the configured tracer to be returned")
+	}
+
+	exporter.Reset()
+
+	app.OnRecordCreate("demo1").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "test_handler",
+		Func: func(e *core.RecordEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+			return errors.New("test_hook_error")
+		},
+	})
+
+	ctx, root := tracer.Start(context.Background(), "root")
+
+	collection, err := app.FindCollectionByNameOrId("demo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("text", "'secret'")
+	if err := app.SaveWithContext(ctx, record); err == nil || err.Error() != "test_hook_error" {
+		t.Fatalf("Expected test_hook_error, got %v", err)
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys.Exists("missing")
+	fsys.Close()
+
+	root.End()
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// hook spans
+	hookSpans := exporter.FindByName("hook RecordEvent")
+	var handlerSpan *tracing.Span
+	for _, s := range hookSpans {
+		if s.Attributes()["hook.handler.id"] == "test_handler" {
+			handlerSpan = s
+			break
+		}
+	}
+	if handlerSpan == nil {
+		t.Fatalf("Missing test_handler span in %d hook spans", len(hookSpans))
+	}
+	if handlerSpan.TraceID() != root.TraceID() {
+		t.Fatalf("Expected hook span trace id %s, got %s", root.TraceID(), handlerSpan.TraceID())
+	}
+	if code, msg := handlerSpan.Status(); code != tracing.StatusError || msg != "test_hook_error" {
+		t.Fatalf("Expected hook span error status, got %d %q", code, msg)
+	}
+
+	// db spans
+	dbSpans := exporter.FindByName("db INSERT")
+	if len(dbSpans) == 0 {
+		t.Fatal("Expected at least one db INSERT span")
+	}
+	for _, s := range dbSpans {
+		attrs := s.Attributes()
+		if attrs["db.system"] != "sqlite" {
+			t.Fatalf("Expected db.system sqlite, got %v", attrs["db.system"])
+		}
+		if query, _ := attrs["db.query.text"].(string); strings.Contains(query, "secret") {
+			t.Fatalf("Expected the query literals to be sanitized, got %q", query)
+		}
+	}
+
+	// filesystem spans
+	if total := len(exporter.FindByName("filesystem exists")); total != 1 {
+		t.Fatalf("Expected 1 filesystem exists span, got %d", total)
+	}
+}
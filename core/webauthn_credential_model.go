@@ -0,0 +1,312 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/pocketbase/pocketbase/tools/webauthn"
+)
+
+const CollectionNameWebAuthnCredentials = "_webauthnCredentials"
+
+var (
+	_ Model        = (*WebAuthnCredential)(nil)
+	_ PreValidator = (*WebAuthnCredential)(nil)
+	_ RecordProxy  = (*WebAuthnCredential)(nil)
+)
+
+// WebAuthnCredential defines a Record proxy for working with the webauthnCredentials collection.
+type WebAuthnCredential struct {
+	*Record
+}
+
+// NewWebAuthnCredential instantiates and returns a new blank *WebAuthnCredential model.
+//
+// Example usage:
+//
+//	credential := core.NewWebAuthnCredential(app)
+//	credential.SetRecordRef(user.Id)
+//	credential.SetCollectionRef(user.Collection().Id)
+//	credential.SetCredentialId("...")
+//	credential.SetPublicKey("...")
+//	app.Save(credential)
+func NewWebAuthnCredential(app App) *WebAuthnCredential {
+	m := &WebAuthnCredential{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameWebAuthnCredentials)
+	if err != nil {
+		// this is just to make tests easier since it is a system collection and it is expected to be always accessible
+		// (note: the loaded record is further checked on WebAuthnCredential.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *WebAuthnCredential) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameWebAuthnCredentials {
+		return errors.New("missing or invalid WebAuthnCredential ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *WebAuthnCredential) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *WebAuthnCredential) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *WebAuthnCredential) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *WebAuthnCredential) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *WebAuthnCredential) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *WebAuthnCredential) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// Name returns the "name" record field value.
+func (m *WebAuthnCredential) Name() string {
+	return m.GetString("name")
+}
+
+// SetName updates the "name" record field value.
+func (m *WebAuthnCredential) SetName(name string) {
+	m.Set("name", name)
+}
+
+// CredentialId returns the base64url encoded "credentialId" record field value.
+func (m *WebAuthnCredential) CredentialId() string {
+	return m.GetString("credentialId")
+}
+
+// SetCredentialId updates the base64url encoded "credentialId" record field value.
+func (m *WebAuthnCredential) SetCredentialId(credentialId string) {
+	m.Set("credentialId", credentialId)
+}
+
+// PublicKey returns the base64url encoded COSE "publicKey" record field value.
+func (m *WebAuthnCredential) PublicKey() string {
+	return m.GetString("publicKey")
+}
+
+// SetPublicKey updates the base64url encoded COSE "publicKey" record field value.
+func (m *WebAuthnCredential) SetPublicKey(publicKey string) {
+	m.Set("publicKey", publicKey)
+}
+
+// SignCount returns the "signCount" record field value.
+func (m *WebAuthnCredential) SignCount() uint32 {
+	return uint32(m.GetInt("signCount"))
+}
+
+// SetSignCount updates the "signCount" record field value.
+func (m *WebAuthnCredential) SetSignCount(signCount uint32) {
+	m.Set("signCount", signCount)
+}
+
+// Transports returns the "transports" record field value.
+func (m *WebAuthnCredential) Transports() []string {
+	transports := []string{}
+
+	_ = m.UnmarshalJSONField("transports", &transports)
+
+	return transports
+}
+
+// SetTransports updates the "transports" record field value.
+func (m *WebAuthnCredential) SetTransports(transports []string) {
+	if transports == nil {
+		transports = []string{}
+	}
+
+	m.Set("transports", transports)
+}
+
+// AAGUID returns the "aaguid" record field value.
+func (m *WebAuthnCredential) AAGUID() string {
+	return m.GetString("aaguid")
+}
+
+// SetAAGUID updates the "aaguid" record field value.
+func (m *WebAuthnCredential) SetAAGUID(aaguid string) {
+	m.Set("aaguid", aaguid)
+}
+
+// LastUsed returns the "lastUsed" record field value.
+func (m *WebAuthnCredential) LastUsed() types.DateTime {
+	return m.GetDateTime("lastUsed")
+}
+
+// SetLastUsed updates the "lastUsed" record field value.
+func (m *WebAuthnCredential) SetLastUsed(date types.DateTime) {
+	m.Set("lastUsed", date)
+}
+
+// Created returns the "created" record field value.
+func (m *WebAuthnCredential) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *WebAuthnCredential) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// Descriptor returns the credential descriptor used in the WebAuthn ceremonies options.
+func (m *WebAuthnCredential) Descriptor() webauthn.CredentialDescriptor {
+	id, _ := webauthn.DecodeBase64(m.CredentialId())
+
+	return webauthn.CredentialDescriptor{
+		Type:       webauthn.CredentialType,
+		Id:         id,
+		Transports: m.Transports(),
+	}
+}
+
+func (app *BaseApp) registerWebAuthnCredentialHooks() {
+	recordRefHooks[*WebAuthnCredential](app, CollectionNameWebAuthnCredentials, CollectionTypeAuth)
+}
+
+// -------------------------------------------------------------------
+
+// WebAuthnRelyingParty returns the WebAuthn relying party configuration
+// of the specified auth collection.
+//
+// The empty collection WebAuthn RPId and Origins fallback to
+// the app URL setting host and origin.
+func WebAuthnRelyingParty(app App, collection *Collection) webauthn.RelyingParty {
+	rp := webauthn.RelyingParty{
+		Id:               collection.WebAuthn.RPId,
+		Name:             collection.WebAuthn.RPName,
+		Origins:          collection.WebAuthn.Origins,
+		UserVerification: collection.WebAuthn.UserVerification,
+	}
+
+	if rp.Name == "" {
+		rp.Name = app.Settings().Meta.AppName
+	}
+
+	if rp.Id == "" || len(rp.Origins) == 0 {
+		appURL, err := url.Parse(app.Settings().Meta.AppURL)
+		if err == nil && appURL.Host != "" {
+			if rp.Id == "" {
+				rp.Id = appURL.Hostname()
+			}
+			if len(rp.Origins) == 0 {
+				rp.Origins = []string{appURL.Scheme + "://" + appURL.Host}
+			}
+		}
+	}
+
+	return rp
+}
+
+// NewWebAuthnToken generates and returns a new short-lived WebAuthn ceremony token
+// that holds the base64url encoded ceremony challenge.
+//
+// The challenge is also stored as [AuthChallenge] for the token lifetime
+// and must be consumed with [ConsumeWebAuthnChallenge] on use.
+//
+// recordId is the id of the auth record that the registration ceremony is for
+// (leave it empty for the authentication ceremony).
+func NewWebAuthnToken(app App, collection *Collection, challenge string, recordId string) (string, error) {
+	if !collection.IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	key := collection.AuthToken.Secret
+	if key == "" {
+		return "", ErrMissingSigningKey
+	}
+
+	claims := jwt.MapClaims{
+		TokenClaimType:         TokenTypeWebAuthn,
+		TokenClaimCollectionId: collection.Id,
+		TokenClaimChallenge:    challenge,
+	}
+
+	if recordId != "" {
+		claims[TokenClaimId] = recordId
+	}
+
+	duration := collection.WebAuthn.DurationTime()
+
+	token, err := security.NewJWT(claims, key, duration)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = CreateAuthChallenge(app, collection, AuthChallengeKindWebAuthn, challenge, nil, duration)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ConsumeWebAuthnChallenge removes the issued challenge of the specified
+// auth collection and reports whether it was still available.
+//
+// Only the first of the concurrent calls with the same challenge succeeds
+// (even across multiple app nodes), so a ceremony token (and its signed
+// assertion) can't be replayed.
+func ConsumeWebAuthnChallenge(app App, collection *Collection, challenge string) bool {
+	if challenge == "" {
+		return false
+	}
+
+	_, err := ConsumeAuthChallenge(app, collection, AuthChallengeKindWebAuthn, challenge)
+
+	return err == nil
+}
+
+// ParseWebAuthnToken verifies the WebAuthn ceremony token issued for the
+// specified auth collection and returns its challenge and record id claims.
+func ParseWebAuthnToken(collection *Collection, token string) (challenge string, recordId string, err error) {
+	if !collection.IsAuth() {
+		return "", "", ErrNotAuthRecord
+	}
+
+	claims, err := security.ParseJWT(token, collection.AuthToken.Secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	if claims[TokenClaimType] != TokenTypeWebAuthn || claims[TokenClaimCollectionId] != collection.Id {
+		return "", "", errors.New("invalid WebAuthn token type or collection")
+	}
+
+	challenge, _ = claims[TokenClaimChallenge].(string)
+	if challenge == "" {
+		return "", "", errors.New("missing WebAuthn token challenge")
+	}
+
+	recordId, _ = claims[TokenClaimId].(string)
+
+	return challenge, recordId, nil
+}
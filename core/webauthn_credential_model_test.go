@@ -0,0 +1,263 @@
+package core_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/webauthn"
+)
+
+func TestNewWebAuthnCredential(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	credential := core.NewWebAuthnCredential(app)
+
+	if credential.Collection().Name != core.CollectionNameWebAuthnCredentials {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameWebAuthnCredentials, credential.Collection().Name)
+	}
+}
+
+func TestWebAuthnCredentialGettersAndSetters(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	credential := core.NewWebAuthnCredential(app)
+	credential.SetCollectionRef("test_collection")
+	credential.SetRecordRef("test_record")
+	credential.SetName("test_name")
+	credential.SetCredentialId("AQID")
+	credential.SetPublicKey("test_key")
+	credential.SetSignCount(123)
+	credential.SetAAGUID("test_aaguid")
+
+	if v := credential.CollectionRef(); v != "test_collection" {
+		t.Fatalf("Expected collectionRef %q, got %q", "test_collection", v)
+	}
+	if v := credential.RecordRef(); v != "test_record" {
+		t.Fatalf("Expected recordRef %q, got %q", "test_record", v)
+	}
+	if v := credential.Name(); v != "test_name" {
+		t.Fatalf("Expected name %q, got %q", "test_name", v)
+	}
+	if v := credential.CredentialId(); v != "AQID" {
+		t.Fatalf("Expected credentialId %q, got %q", "AQID", v)
+	}
+	if v := credential.PublicKey(); v != "test_key" {
+		t.Fatalf("Expected publicKey %q, got %q", "test_key", v)
+	}
+	if v := credential.SignCount(); v != 123 {
+		t.Fatalf("Expected signCount %d, got %d", 123, v)
+	}
+	if v := credential.AAGUID(); v != "test_aaguid" {
+		t.Fatalf("Expected aaguid %q, got %q", "test_aaguid", v)
+	}
+
+	// transports
+	if v := credential.Transports(); len(v) != 0 {
+		t.Fatalf("Expected no transports, got %v", v)
+	}
+	credential.SetTransports([]string{"usb", "internal"})
+	if v := credential.Transports(); !slices.Equal(v, []string{"usb", "internal"}) {
+		t.Fatalf("Expected transports %v, got %v", []string{"usb", "internal"}, v)
+	}
+
+	// descriptor
+	descriptor := credential.Descriptor()
+	if descriptor.Type != webauthn.CredentialType {
+		t.Fatalf("Expected descriptor type %q, got %q", webauthn.CredentialType, descriptor.Type)
+	}
+	if string(descriptor.Id) != "\x01\x02\x03" {
+		t.Fatalf("Expected descriptor id %v, got %v", []byte{1, 2, 3}, descriptor.Id)
+	}
+	if !slices.Equal(descriptor.Transports, []string{"usb", "internal"}) {
+		t.Fatalf("Expected descriptor transports %v, got %v", []string{"usb", "internal"}, descriptor.Transports)
+	}
+}
+
+func TestWebAuthnCredentialPreValidate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	credentialsCol, err := app.FindCollectionByNameOrId(core.CollectionNameWebAuthnCredentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("no proxy record", func(t *testing.T) {
+		credential := &core.WebAuthnCredential{}
+
+		if err := app.Validate(credential); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("non-WebAuthnCredential collection", func(t *testing.T) {
+		credential := &core.WebAuthnCredential{}
+		credential.SetProxyRecord(core.NewRecord(core.NewBaseCollection("invalid")))
+		credential.SetRecordRef(user.Id)
+		credential.SetCollectionRef(user.Collection().Id)
+		credential.SetCredentialId("test")
+		credential.SetPublicKey("test")
+
+		if err := app.Validate(credential); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("WebAuthnCredential collection", func(t *testing.T) {
+		credential := &core.WebAuthnCredential{}
+		credential.SetProxyRecord(core.NewRecord(credentialsCol))
+		credential.SetRecordRef(user.Id)
+		credential.SetCollectionRef(user.Collection().Id)
+		credential.SetCredentialId("test")
+		credential.SetPublicKey("test")
+
+		if err := app.Validate(credential); err != nil {
+			t.Fatalf("Expected nil validation error, got %v", err)
+		}
+	})
+}
+
+func TestWebAuthnRelyingParty(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Settings().Meta.AppName = "test_app"
+	app.Settings().Meta.AppURL = "https://example.com:8090/abc"
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("app settings fallback", func(t *testing.T) {
+		rp := core.WebAuthnRelyingParty(app, users)
+
+		if rp.Id != "example.com" {
+			t.Fatalf("Expected rp id %q, got %q", "example.com", rp.Id)
+		}
+		if rp.Name != "test_app" {
+			t.Fatalf("Expected rp name %q, got %q", "test_app", rp.Name)
+		}
+		if !slices.Equal(rp.Origins, []string{"https://example.com:8090"}) {
+			t.Fatalf("Expected rp origins %v, got %v", []string{"https://example.com:8090"}, rp.Origins)
+		}
+	})
+
+	t.Run("collection options", func(t *testing.T) {
+		col, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		col.WebAuthn.RPId = "demo.com"
+		col.WebAuthn.RPName = "demo"
+		col.WebAuthn.Origins = []string{"https://demo.com", "https://app.demo.com"}
+		col.WebAuthn.UserVerification = webauthn.UserVerificationRequired
+
+		rp := core.WebAuthnRelyingParty(app, col)
+
+		if rp.Id != "demo.com" {
+			t.Fatalf("Expected rp id %q, got %q", "demo.com", rp.Id)
+		}
+		if rp.Name != "demo" {
+			t.Fatalf("Expected rp name %q, got %q", "demo", rp.Name)
+		}
+		if !slices.Equal(rp.Origins, col.WebAuthn.Origins) {
+			t.Fatalf("Expected rp origins %v, got %v", col.WebAuthn.Origins, rp.Origins)
+		}
+		if rp.UserVerification != webauthn.UserVerificationRequired {
+			t.Fatalf("Expected rp user verification %q, got %q", webauthn.UserVerificationRequired, rp.UserVerification)
+		}
+	})
+}
+
+func TestNewAndParseWebAuthnToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, err := app.FindCollectionByNameOrId("clients")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	demo1, err := app.FindCollectionByNameOrId("demo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := core.NewWebAuthnToken(app, demo1, "abc", ""); err == nil {
+		t.Fatal("Expected non-auth collection error")
+	}
+
+	token, err := core.NewWebAuthnToken(app, users, "abc", "test_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := core.ParseWebAuthnToken(clients, token); err == nil {
+		t.Fatal("Expected different collection error")
+	}
+
+	challenge, recordId, err := core.ParseWebAuthnToken(users, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if challenge != "abc" {
+		t.Fatalf("Expected challenge %q, got %q", "abc", challenge)
+	}
+
+	if recordId != "test_id" {
+		t.Fatalf("Expected recordId %q, got %q", "test_id", recordId)
+	}
+
+	// the issued challenge is single-use
+	if core.ConsumeWebAuthnChallenge(app, clients, "abc") {
+		t.Fatal("Expected the challenge of a different collection to be rejected")
+	}
+	if !core.ConsumeWebAuthnChallenge(app, users, "abc") {
+		t.Fatal("Expected the issued challenge to be consumed")
+	}
+	if core.ConsumeWebAuthnChallenge(app, users, "abc") {
+		t.Fatal("Expected the already consumed challenge to be rejected")
+	}
+	if core.ConsumeWebAuthnChallenge(app, users, "missing") {
+		t.Fatal("Expected the not issued challenge to be rejected")
+	}
+
+	// auth tokens must not be accepted as WebAuthn tokens
+	user, err := app.FindAuthRecordByEmail(users, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authToken, err := user.NewAuthToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := core.ParseWebAuthnToken(users, authToken); err == nil {
+		t.Fatal("Expected auth token parse error")
+	}
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/pocketbase/dbx"
+)
+
+// FindAllWebAuthnCredentialsByRecord returns all WebAuthnCredential models
+// linked to the provided auth record.
+func (app *BaseApp) FindAllWebAuthnCredentialsByRecord(authRecord *Record) ([]*WebAuthnCredential, error) {
+	result := []*WebAuthnCredential{}
+
+	err := app.RecordQuery(CollectionNameWebAuthnCredentials).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindWebAuthnCredentialByCredentialId returns a single WebAuthnCredential model
+// by its auth collection and base64url encoded credential id.
+func (app *BaseApp) FindWebAuthnCredentialByCredentialId(collection *Collection, credentialId string) (*WebAuthnCredential, error) {
+	result := &WebAuthnCredential{}
+
+	err := app.RecordQuery(CollectionNameWebAuthnCredentials).
+		AndWhere(dbx.HashExp{
+			"collectionRef": collection.Id,
+			"credentialId":  credentialId,
+		}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteAllWebAuthnCredentialsByRecord deletes all WebAuthnCredential models associated with the provided record.
+//
+// Returns a combined error with the failed deletes.
+func (app *BaseApp) DeleteAllWebAuthnCredentialsByRecord(authRecord *Record) error {
+	models, err := app.FindAllWebAuthnCredentialsByRecord(authRecord)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, m := range models {
+		if err := app.Delete(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
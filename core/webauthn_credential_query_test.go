@@ -0,0 +1,163 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func stubWebAuthnCredentials(t testing.TB, app core.App) (*core.Record, *core.Record) {
+	user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, credentialId := range []string{"user1_0", "user1_1", "user2_0"} {
+		owner := user1
+		if i == 2 {
+			owner = user2
+		}
+
+		credential := core.NewWebAuthnCredential(app)
+		credential.Id = strings.ReplaceAll(credentialId, "_", "") + "webauthn0"
+		credential.SetCollectionRef(owner.Collection().Id)
+		credential.SetRecordRef(owner.Id)
+		credential.SetCredentialId(credentialId)
+		credential.SetPublicKey("test")
+		credential.SetRaw("created", "2024-01-0"+string(rune('1'+i))+" 00:00:00.000Z")
+		if err := app.Save(credential); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return user1, user2
+}
+
+func TestFindAllWebAuthnCredentialsByRecord(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user1, user2 := stubWebAuthnCredentials(t, app)
+
+	demo1, err := app.FindRecordById("demo1", "84nmscqy84lsi1t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		record   *core.Record
+		expected []string
+	}{
+		{demo1, nil},
+		{user1, []string{"user11webauthn0", "user10webauthn0"}},
+		{user2, []string{"user20webauthn0"}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.record.Collection().Name+"_"+s.record.Id, func(t *testing.T) {
+			result, err := app.FindAllWebAuthnCredentialsByRecord(s.record)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result) != len(s.expected) {
+				t.Fatalf("Expected total credentials %d, got %d", len(s.expected), len(result))
+			}
+
+			for i, id := range s.expected {
+				if result[i].Id != id {
+					t.Errorf("[%d] Expected id %q, got %q", i, id, result[i].Id)
+				}
+			}
+		})
+	}
+}
+
+func TestFindWebAuthnCredentialByCredentialId(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	stubWebAuthnCredentials(t, app)
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, err := app.FindCollectionByNameOrId("clients")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		collection   *core.Collection
+		credentialId string
+		expected     string
+	}{
+		{users, "", ""},
+		{users, "missing", ""},
+		{clients, "user1_0", ""},
+		{users, "user1_0", "user10webauthn0"},
+		{users, "user2_0", "user20webauthn0"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.collection.Name+"_"+s.credentialId, func(t *testing.T) {
+			result, err := app.FindWebAuthnCredentialByCredentialId(s.collection, s.credentialId)
+
+			hasErr := err != nil
+			expectErr := s.expected == ""
+			if hasErr != expectErr {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", expectErr, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if result.Id != s.expected {
+				t.Fatalf("Expected id %q, got %q", s.expected, result.Id)
+			}
+		})
+	}
+}
+
+func TestDeleteAllWebAuthnCredentialsByRecord(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user1, user2 := stubWebAuthnCredentials(t, app)
+
+	if err := app.DeleteAllWebAuthnCredentialsByRecord(user1); err != nil {
+		t.Fatal(err)
+	}
+
+	result1, err := app.FindAllWebAuthnCredentialsByRecord(user1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result1) != 0 {
+		t.Fatalf("Expected no user1 credentials, got %d", len(result1))
+	}
+
+	result2, err := app.FindAllWebAuthnCredentialsByRecord(user2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result2) != 1 {
+		t.Fatalf("Expected 1 user2 credential, got %d", len(result2))
+	}
+}
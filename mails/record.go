@@ -48,6 +48,48 @@ func SendRecordAuthAlert(app core.App, authRecord *core.Record, info string) err
 	})
 }
 
+// SendRecordLockoutAlert sends a temporary lockout alert to the specified auth record
+// using the collection [core.AuthAlertConfig.LockoutTemplate].
+//
+// It triggers the same OnMailerRecordAuthAlertSend hook as [SendRecordAuthAlert]
+// with an additional "lockout" meta flag.
+func SendRecordLockoutAlert(app core.App, authRecord *core.Record, info string) error {
+	mailClient := app.NewMailClient()
+
+	info = html.EscapeString(info)
+
+	subject, body, err := resolveEmailTemplate(app, authRecord, authRecord.Collection().AuthAlert.LockoutTemplate(), map[string]any{
+		core.EmailPlaceholderAlertInfo: info,
+	})
+	if err != nil {
+		return err
+	}
+
+	message := &mailer.Message{
+		From: mail.Address{
+			Name:    app.Settings().Meta.SenderName,
+			Address: app.Settings().Meta.SenderAddress,
+		},
+		To:      []mail.Address{{Address: authRecord.Email()}},
+		Subject: subject,
+		HTML:    body,
+	}
+
+	event := new(core.MailerRecordEvent)
+	event.App = app
+	event.Mailer = mailClient
+	event.Message = message
+	event.Record = authRecord
+	event.Meta = map[string]any{
+		"info":    info,
+		"lockout": true,
+	}
+
+	return app.OnMailerRecordAuthAlertSend().Trigger(event, func(e *core.MailerRecordEvent) error {
+		return e.Mailer.Send(e.Message)
+	})
+}
+
 // SendRecordOTP sends OTP email to the specified auth record.
 //
 // This method will also update the "sentTo" field of the related OTP record to the mail sent To address (if the OTP exists and not already assigned).
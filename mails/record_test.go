@@ -45,6 +45,41 @@ func TestSendRecordAuthAlert(t *testing.T) {
 	}
 }
 
+func TestSendRecordLockoutAlert(t *testing.T) {
+	t.Parallel()
+
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	info := "<p>test_info</p>"
+
+	user, _ := testApp.FindFirstRecordByData("users", "email", "test@example.com")
+
+	err := mails.SendRecordLockoutAlert(testApp, user, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testApp.TestMailer.TotalSend() != 1 {
+		t.Fatalf("Expected one email to be sent, got %d", testApp.TestMailer.TotalSend())
+	}
+
+	if subject := testApp.TestMailer.LastMessage().Subject; subject != "Your account was temporarily locked" {
+		t.Fatalf("Expected the default lockout subject, got %q", subject)
+	}
+
+	expectedParts := []string{
+		"Your " + testApp.Settings().Meta.AppName + " account was temporarily locked",
+		"If this wasn't you",
+		html.EscapeString(info),
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(testApp.TestMailer.LastMessage().HTML, part) {
+			t.Fatalf("Couldn't find %s \nin\n %s", part, testApp.TestMailer.LastMessage().HTML)
+		}
+	}
+}
+
 func TestSendRecordPasswordReset(t *testing.T) {
 	t.Parallel()
 
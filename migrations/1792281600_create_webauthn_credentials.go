@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		col := core.NewBaseCollection(core.CollectionNameWebAuthnCredentials)
+		col.System = true
+
+		ownerRule := "@request.auth.id != '' && recordRef = @request.auth.id && collectionRef = @request.auth.collectionId"
+		col.ListRule = types.Pointer(ownerRule)
+		col.ViewRule = types.Pointer(ownerRule)
+		col.DeleteRule = types.Pointer(ownerRule)
+
+		col.Fields.Add(&core.TextField{
+			Name:     "collectionRef",
+			System:   true,
+			Required: true,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:     "recordRef",
+			System:   true,
+			Required: true,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:   "name",
+			System: true,
+			Max:    255,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:     "credentialId",
+			System:   true,
+			Required: true,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:     "publicKey",
+			System:   true,
+			Required: true,
+		})
+		col.Fields.Add(&core.NumberField{
+			Name:    "signCount",
+			System:  true,
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+		col.Fields.Add(&core.JSONField{
+			Name:   "transports",
+			System: true,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:   "aaguid",
+			System: true,
+		})
+		col.Fields.Add(&core.DateField{
+			Name:   "lastUsed",
+			System: true,
+		})
+		col.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			System:   true,
+			OnCreate: true,
+		})
+		col.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			System:   true,
+			OnCreate: true,
+			OnUpdate: true,
+		})
+		col.AddIndex("idx_webauthnCredentials_collectionRef_recordRef", false, "collectionRef, recordRef", "")
+		col.AddIndex("idx_webauthnCredentials_collectionRef_credentialId", true, "collectionRef, credentialId", "")
+
+		if err := txApp.Save(col); err != nil {
+			return err
+		}
+
+		// init the default WebAuthn options of the existing auth collections
+		collections, err := txApp.FindAllCollections(core.CollectionTypeAuth)
+		if err != nil {
+			return err
+		}
+
+		defaults := core.NewAuthCollection("up").WebAuthn
+
+		for _, c := range collections {
+			if c.WebAuthn.Duration > 0 {
+				continue
+			}
+
+			c.WebAuthn.Duration = defaults.Duration
+			c.WebAuthn.UserVerification = defaults.UserVerification
+
+			if err := txApp.Save(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(txApp core.App) error {
+		// note: system collections cannot be deleted with the regular app.Delete
+		_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": core.CollectionNameWebAuthnCredentials}).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = txApp.DB().DropTable(core.CollectionNameWebAuthnCredentials).Execute()
+
+		return err
+	})
+}
@@ -0,0 +1,105 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		col := core.NewBaseCollection(core.CollectionNameTOTPs)
+		col.System = true
+
+		ownerRule := "@request.auth.id != '' && recordRef = @request.auth.id && collectionRef = @request.auth.collectionId"
+		col.ListRule = types.Pointer(ownerRule)
+		col.ViewRule = types.Pointer(ownerRule)
+
+		col.Fields.Add(&core.TextField{
+			Name:     "collectionRef",
+			System:   true,
+			Required: true,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:     "recordRef",
+			System:   true,
+			Required: true,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:     "secret",
+			System:   true,
+			Hidden:   true,
+			Required: true,
+		})
+		col.Fields.Add(&core.JSONField{
+			Name:   "recoveryCodes",
+			System: true,
+			Hidden: true,
+		})
+		col.Fields.Add(&core.NumberField{
+			Name:    "lastStep",
+			System:  true,
+			Hidden:  true,
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+		col.Fields.Add(&core.DateField{
+			Name:   "lastUsed",
+			System: true,
+		})
+		col.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			System:   true,
+			OnCreate: true,
+		})
+		col.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			System:   true,
+			OnCreate: true,
+			OnUpdate: true,
+		})
+		col.AddIndex("idx_totps_collectionRef_recordRef", true, "collectionRef, recordRef", "")
+
+		if err := txApp.Save(col); err != nil {
+			return err
+		}
+
+		// init the default TOTP options of the existing auth collections
+		collections, err := txApp.FindAllCollections(core.CollectionTypeAuth)
+		if err != nil {
+			return err
+		}
+
+		defaults := core.NewAuthCollection("up").TOTP
+
+		for _, c := range collections {
+			if c.TOTP.Duration > 0 {
+				continue
+			}
+
+			c.TOTP.Duration = defaults.Duration
+			c.TOTP.Digits = defaults.Digits
+			c.TOTP.Period = defaults.Period
+			c.TOTP.Skew = defaults.Skew
+			c.TOTP.RecoveryCodes = defaults.RecoveryCodes
+			c.TOTP.Secret = security.RandomString(32)
+
+			if err := txApp.Save(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(txApp core.App) error {
+		// note: system collections cannot be deleted with the regular app.Delete
+		_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": core.CollectionNameTOTPs}).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = txApp.DB().DropTable(core.CollectionNameTOTPs).Execute()
+
+		return err
+	})
+}
@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		col := core.NewBaseCollection(core.CollectionNameJWTKeys)
+		col.System = true
+
+		// note: superusers only
+		col.ListRule = nil
+		col.ViewRule = nil
+
+		col.Fields.Add(&core.SelectField{
+			Name:      "algorithm",
+			System:    true,
+			Required:  true,
+			MaxSelect: 1,
+			Values:    security.AsymmetricJWTAlgorithms,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:     "privateKey",
+			System:   true,
+			Hidden:   true,
+			Required: true,
+		})
+		col.Fields.Add(&core.TextField{
+			Name:     "publicKey",
+			System:   true,
+			Required: true,
+		})
+		col.Fields.Add(&core.DateField{
+			Name:   "retired",
+			System: true,
+		})
+		col.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			System:   true,
+			OnCreate: true,
+		})
+		col.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			System:   true,
+			OnCreate: true,
+			OnUpdate: true,
+		})
+		col.AddIndex("idx_jwtKeys_retired", false, "retired", "")
+
+		return txApp.Save(col)
+	}, func(txApp core.App) error {
+		// note: system collections cannot be deleted with the regular app.Delete
+		_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": core.CollectionNameJWTKeys}).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = txApp.DB().DropTable(core.CollectionNameJWTKeys).Execute()
+
+		return err
+	})
+}
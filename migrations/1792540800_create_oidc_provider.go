@@ -0,0 +1,243 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		if err := createOIDCClientsCollection(txApp); err != nil {
+			return err
+		}
+
+		if err := createOIDCConsentsCollection(txApp); err != nil {
+			return err
+		}
+
+		if err := createOIDCTokensCollection(txApp); err != nil {
+			return err
+		}
+
+		// init the default OIDC options of the existing auth collections
+		collections, err := txApp.FindAllCollections(core.CollectionTypeAuth)
+		if err != nil {
+			return err
+		}
+
+		defaults := core.NewAuthCollection("up").OIDC
+
+		for _, c := range collections {
+			if c.OIDC.CodeDuration > 0 {
+				continue
+			}
+
+			c.OIDC.CodeDuration = defaults.CodeDuration
+			c.OIDC.IdTokenDuration = defaults.IdTokenDuration
+			c.OIDC.RefreshTokenDuration = defaults.RefreshTokenDuration
+
+			if err := txApp.Save(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(txApp core.App) error {
+		names := []string{
+			core.CollectionNameOIDCTokens,
+			core.CollectionNameOIDCConsents,
+			core.CollectionNameOIDCClients,
+		}
+
+		for _, name := range names {
+			// note: system collections cannot be deleted with the regular app.Delete
+			_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": name}).Execute()
+			if err != nil {
+				return err
+			}
+
+			_, err = txApp.DB().DropTable(name).Execute()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func createOIDCClientsCollection(txApp core.App) error {
+	col := core.NewBaseCollection(core.CollectionNameOIDCClients)
+	col.System = true
+
+	// note: superusers only
+	col.ListRule = nil
+	col.ViewRule = nil
+
+	col.Fields.Add(&core.TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "name",
+		System:   true,
+		Required: true,
+		Max:      255,
+	})
+	col.Fields.Add(&core.PasswordField{
+		Name:   "secret",
+		System: true,
+		Hidden: true,
+		Min:    16,
+	})
+	col.Fields.Add(&core.JSONField{
+		Name:     "redirectURIs",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.BoolField{
+		Name:   "public",
+		System: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_oidcClients_collectionRef", false, "collectionRef", "")
+
+	return txApp.Save(col)
+}
+
+func createOIDCConsentsCollection(txApp core.App) error {
+	col := core.NewBaseCollection(core.CollectionNameOIDCConsents)
+	col.System = true
+
+	ownerRule := "@request.auth.id != '' && recordRef = @request.auth.id && collectionRef = @request.auth.collectionId"
+	col.ListRule = types.Pointer(ownerRule)
+	col.ViewRule = types.Pointer(ownerRule)
+	col.DeleteRule = types.Pointer(ownerRule)
+
+	col.Fields.Add(&core.TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "clientRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.JSONField{
+		Name:   "scopes",
+		System: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_oidcConsents_collectionRef_recordRef_clientRef", true, "collectionRef, recordRef, clientRef", "")
+
+	return txApp.Save(col)
+}
+
+func createOIDCTokensCollection(txApp core.App) error {
+	col := core.NewBaseCollection(core.CollectionNameOIDCTokens)
+	col.System = true
+
+	// note: superusers only
+	col.ListRule = nil
+	col.ViewRule = nil
+
+	col.Fields.Add(&core.TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "clientRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.SelectField{
+		Name:      "kind",
+		System:    true,
+		Required:  true,
+		MaxSelect: 1,
+		Values:    []string{core.OIDCTokenKindCode, core.OIDCTokenKindRefresh},
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "tokenHash",
+		System:   true,
+		Hidden:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.JSONField{
+		Name:   "scopes",
+		System: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "redirectURI",
+		System: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "codeChallenge",
+		System: true,
+		Hidden: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "nonce",
+		System: true,
+		Hidden: true,
+	})
+	col.Fields.Add(&core.DateField{
+		Name:   "authTime",
+		System: true,
+	})
+	col.Fields.Add(&core.DateField{
+		Name:     "expires",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_oidcTokens_tokenHash", true, "tokenHash", "")
+	col.AddIndex("idx_oidcTokens_collectionRef_recordRef", false, "collectionRef, recordRef", "")
+	col.AddIndex("idx_oidcTokens_expires", false, "expires", "")
+
+	return txApp.Save(col)
+}
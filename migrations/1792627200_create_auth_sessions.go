@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		if err := createAuthSessionsCollection(txApp); err != nil {
+			return err
+		}
+
+		// init the default sessions options of the existing auth collections
+		collections, err := txApp.FindAllCollections(core.CollectionTypeAuth)
+		if err != nil {
+			return err
+		}
+
+		defaults := core.NewAuthCollection("up").Sessions
+
+		for _, c := range collections {
+			if c.Sessions.AccessTokenDuration > 0 {
+				continue
+			}
+
+			c.Sessions.AccessTokenDuration = defaults.AccessTokenDuration
+			c.Sessions.RefreshTokenDuration = defaults.RefreshTokenDuration
+
+			if err := txApp.Save(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(txApp core.App) error {
+		// note: system collections cannot be deleted with the regular app.Delete
+		_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": core.CollectionNameAuthSessions}).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = txApp.DB().DropTable(core.CollectionNameAuthSessions).Execute()
+
+		return err
+	})
+}
+
+func createAuthSessionsCollection(txApp core.App) error {
+	col := core.NewBaseCollection(core.CollectionNameAuthSessions)
+	col.System = true
+
+	ownerRule := "@request.auth.id != '' && recordRef = @request.auth.id && collectionRef = @request.auth.collectionId"
+	col.ListRule = types.Pointer(ownerRule)
+	col.ViewRule = types.Pointer(ownerRule)
+	col.DeleteRule = types.Pointer(ownerRule)
+
+	col.Fields.Add(&core.TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "originRef",
+		System: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "tokenHash",
+		System:   true,
+		Hidden:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "userAgent",
+		System: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "ip",
+		System: true,
+	})
+	col.Fields.Add(&core.DateField{
+		Name:   "lastUsed",
+		System: true,
+	})
+	col.Fields.Add(&core.DateField{
+		Name:     "expires",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_authSessions_tokenHash", true, "tokenHash", "")
+	col.AddIndex("idx_authSessions_collectionRef_recordRef", false, "collectionRef, recordRef", "")
+	col.AddIndex("idx_authSessions_originRef", false, "originRef", "")
+	col.AddIndex("idx_authSessions_expires", false, "expires", "")
+
+	return txApp.Save(col)
+}
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		return createAPIKeysCollection(txApp)
+	}, func(txApp core.App) error {
+		// note: system collections cannot be deleted with the regular app.Delete
+		_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": core.CollectionNameAPIKeys}).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = txApp.DB().DropTable(core.CollectionNameAPIKeys).Execute()
+
+		return err
+	})
+}
+
+func createAPIKeysCollection(txApp core.App) error {
+	col := core.NewBaseCollection(core.CollectionNameAPIKeys)
+	col.System = true
+
+	// note: the keys are created only with the dedicated API endpoint
+	ownerRule := "@request.auth.id != '' && recordRef = @request.auth.id && collectionRef = @request.auth.collectionId"
+	col.ListRule = types.Pointer(ownerRule)
+	col.ViewRule = types.Pointer(ownerRule)
+	col.DeleteRule = types.Pointer(ownerRule)
+
+	col.Fields.Add(&core.TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "name",
+		System:   true,
+		Required: true,
+		Max:      100,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "keyHash",
+		System:   true,
+		Hidden:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "hint",
+		System: true,
+	})
+	col.Fields.Add(&core.JSONField{
+		Name:     "scopes",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.DateField{
+		Name:   "expires",
+		System: true,
+	})
+	col.Fields.Add(&core.DateField{
+		Name:   "lastUsed",
+		System: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "lastUsedIP",
+		System: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_apiKeys_keyHash", true, "keyHash", "")
+	col.AddIndex("idx_apiKeys_collectionRef_recordRef", false, "collectionRef, recordRef", "")
+
+	return txApp.Save(col)
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		return createAuthLockoutsCollection(txApp)
+	}, func(txApp core.App) error {
+		// note: system collections cannot be deleted with the regular app.Delete
+		_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": core.CollectionNameAuthLockouts}).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = txApp.DB().DropTable(core.CollectionNameAuthLockouts).Execute()
+
+		return err
+	})
+}
+
+func createAuthLockoutsCollection(txApp core.App) error {
+	col := core.NewBaseCollection(core.CollectionNameAuthLockouts)
+	col.System = true
+
+	// note: superusers only
+
+	col.Fields.Add(&core.TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.SelectField{
+		Name:      "type",
+		System:    true,
+		Required:  true,
+		MaxSelect: 1,
+		Values:    []string{core.AuthLockoutTypeIdentity, core.AuthLockoutTypeIP},
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "subject",
+		System:   true,
+		Required: true,
+		Max:      255,
+	})
+	col.Fields.Add(&core.NumberField{
+		Name:    "failures",
+		System:  true,
+		OnlyInt: true,
+	})
+	col.Fields.Add(&core.NumberField{
+		Name:    "lockouts",
+		System:  true,
+		OnlyInt: true,
+	})
+	col.Fields.Add(&core.DateField{
+		Name:   "lastFailure",
+		System: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:   "lastIP",
+		System: true,
+	})
+	col.Fields.Add(&core.DateField{
+		Name:   "lockedUntil",
+		System: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_authLockouts_collectionRef_type_subject", true, "collectionRef, type, subject", "")
+
+	return txApp.Save(col)
+}
@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		return createAuthChallengesCollection(txApp)
+	}, func(txApp core.App) error {
+		// note: system collections cannot be deleted with the regular app.Delete
+		_, err := txApp.DB().Delete("_collections", dbx.HashExp{"name": core.CollectionNameAuthChallenges}).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = txApp.DB().DropTable(core.CollectionNameAuthChallenges).Execute()
+
+		return err
+	})
+}
+
+func createAuthChallengesCollection(txApp core.App) error {
+	col := core.NewBaseCollection(core.CollectionNameAuthChallenges)
+	col.System = true
+
+	// note: superusers only
+
+	col.Fields.Add(&core.TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "kind",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.TextField{
+		Name:     "hash",
+		System:   true,
+		Hidden:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.JSONField{
+		Name:   "data",
+		System: true,
+		Hidden: true,
+	})
+	col.Fields.Add(&core.DateField{
+		Name:     "expires",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&core.AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.AddIndex("idx_authChallenges_collectionRef_kind_hash", true, "collectionRef, kind, hash", "")
+	col.AddIndex("idx_authChallenges_expires", false, "expires", "")
+
+	return txApp.Save(col)
+}
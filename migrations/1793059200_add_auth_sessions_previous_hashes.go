@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// add new auth sessions previousHashes json field (if not already)
+func init() {
+	core.SystemMigrations.Register(func(txApp core.App) error {
+		sessionsCollection, err := txApp.FindCollectionByNameOrId(core.CollectionNameAuthSessions)
+		if err != nil {
+			return err
+		}
+
+		field := sessionsCollection.Fields.GetByName("previousHashes")
+		if field != nil {
+			return nil // already exists
+		}
+
+		sessionsCollection.Fields.Add(&core.JSONField{
+			Name:   "previousHashes",
+			System: true,
+			Hidden: true,
+		})
+
+		return txApp.Save(sessionsCollection)
+	}, func(txApp core.App) error {
+		sessionsCollection, err := txApp.FindCollectionByNameOrId(core.CollectionNameAuthSessions)
+		if err != nil {
+			return err
+		}
+
+		sessionsCollection.Fields.RemoveByName("previousHashes")
+
+		return txApp.Save(sessionsCollection)
+	})
+}
@@ -27,12 +27,20 @@ func TestAutomigrateCollectionCreate(t *testing.T) {
 /// <reference path="../pb_data/types.d.ts" />
 migrate((app) => {
   const collection = new Collection({
+    "apiKeys": {
+      "enabled": false,
+      "maxDuration": 0
+    },
     "authAlert": {
       "emailTemplate": {
         "body": "<p>Hello,</p>\n<p>We noticed a login to your {APP_NAME} account from a new location:</p>\n<p><em>{ALERT_INFO}</em></p>\n<p><strong>If this wasn't you, you should immediately change your {APP_NAME} account password to revoke access from all other locations.</strong></p>\n<p>If this was you, you may disregard this email.</p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
         "subject": "Login from a new location"
       },
-      "enabled": true
+      "enabled": true,
+      "lockoutEmailTemplate": {
+        "body": "<p>Hello,</p>\n<p>Your {APP_NAME} account was temporarily locked due to too many failed login attempts:</p>\n<p><em>{ALERT_INFO}</em></p>\n<p><strong>If this wasn't you, someone may be trying to guess your password. Consider changing your {APP_NAME} account password.</strong></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
+        "subject": "Your account was temporarily locked"
+      }
     },
     "authRule": "",
     "authToken": {
@@ -128,7 +136,29 @@ migrate((app) => {
       "CREATE UNIQUE INDEX ` + "`" + `idx_tokenKey_@TEST_RANDOM` + "`" + ` ON ` + "`" + `new_name` + "`" + ` (` + "`" + `tokenKey` + "`" + `)",
       "CREATE UNIQUE INDEX ` + "`" + `idx_email_@TEST_RANDOM` + "`" + ` ON ` + "`" + `new_name` + "`" + ` (` + "`" + `email` + "`" + `) WHERE ` + "`" + `email` + "`" + ` != ''"
     ],
+    "ldap": {
+      "baseDN": "",
+      "bindDN": "",
+      "enabled": false,
+      "groupAttribute": "memberOf",
+      "groupRoles": {},
+      "idAttribute": "",
+      "insecureSkipVerify": false,
+      "mappedFields": {},
+      "rolesField": "",
+      "startTLS": false,
+      "timeout": 10,
+      "url": "",
+      "userFilter": "(uid={username})"
+    },
     "listRule": "@request.auth.id != '' && 1 > 0 || 'backtick` + "`" + `test' = 0",
+    "lockout": {
+      "duration": 300,
+      "enabled": false,
+      "maxDuration": 86400,
+      "maxFailures": 5,
+      "maxIPFailures": 20
+    },
     "manageRule": "1 != 2",
     "mfa": {
       "duration": 1800,
@@ -145,6 +175,13 @@ migrate((app) => {
         "username": ""
       }
     },
+    "oidc": {
+      "codeDuration": 60,
+      "enabled": false,
+      "idTokenDuration": 3600,
+      "loginURL": "",
+      "refreshTokenDuration": 2592000
+    },
     "otp": {
       "duration": 180,
       "emailTemplate": {
@@ -160,6 +197,15 @@ migrate((app) => {
         "email"
       ]
     },
+    "passwordPolicy": {
+      "checkBreached": false,
+      "history": 0,
+      "maxAge": 0,
+      "requireDigit": false,
+      "requireLowercase": false,
+      "requireSymbol": false,
+      "requireUppercase": false
+    },
     "passwordResetToken": {
       "duration": 1800
     },
@@ -167,7 +213,43 @@ migrate((app) => {
       "body": "<p>Hello,</p>\n<p>Click on the button below to reset your password.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-password-reset/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Reset password</a>\n</p>\n<p><i>If you didn't ask to reset your password, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
       "subject": "Reset your {APP_NAME} password"
     },
+    "saml": {
+      "allowIdPInitiated": false,
+      "attributes": {
+        "avatarURL": "",
+        "email": "email",
+        "name": "name",
+        "username": "username"
+      },
+      "certificate": "",
+      "duration": 300,
+      "enabled": false,
+      "entityId": "",
+      "idAttribute": "",
+      "idpMetadata": "",
+      "mappedFields": {
+        "avatarURL": "",
+        "id": "",
+        "name": "",
+        "username": ""
+      },
+      "redirectURLs": []
+    },
+    "sessions": {
+      "accessTokenDuration": 900,
+      "enabled": false,
+      "refreshTokenDuration": 2592000
+    },
     "system": true,
+    "totp": {
+      "digits": 6,
+      "duration": 600,
+      "enabled": false,
+      "issuer": "",
+      "period": 30,
+      "recoveryCodes": 10,
+      "skew": 1
+    },
     "type": "auth",
     "updateRule": null,
     "verificationTemplate": {
@@ -177,7 +259,15 @@ migrate((app) => {
     "verificationToken": {
       "duration": 259200
     },
-    "viewRule": "id = \"1\""
+    "viewRule": "id = \"1\"",
+    "webauthn": {
+      "duration": 180,
+      "enabled": false,
+      "origins": null,
+      "rpId": "",
+      "rpName": "",
+      "userVerification": "preferred"
+    }
   });
 
   return app.save(collection);
@@ -203,12 +293,20 @@ import (
 func init() {
 	m.Register(func(app core.App) error {
 		jsonData := ` + "`" + `{
+			"apiKeys": {
+				"enabled": false,
+				"maxDuration": 0
+			},
 			"authAlert": {
 				"emailTemplate": {
 					"body": "<p>Hello,</p>\n<p>We noticed a login to your {APP_NAME} account from a new location:</p>\n<p><em>{ALERT_INFO}</em></p>\n<p><strong>If this wasn't you, you should immediately change your {APP_NAME} account password to revoke access from all other locations.</strong></p>\n<p>If this was you, you may disregard this email.</p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
 					"subject": "Login from a new location"
 				},
-				"enabled": true
+				"enabled": true,
+				"lockoutEmailTemplate": {
+					"body": "<p>Hello,</p>\n<p>Your {APP_NAME} account was temporarily locked due to too many failed login attempts:</p>\n<p><em>{ALERT_INFO}</em></p>\n<p><strong>If this wasn't you, someone may be trying to guess your password. Consider changing your {APP_NAME} account password.</strong></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
+					"subject": "Your account was temporarily locked"
+				}
 			},
 			"authRule": "",
 			"authToken": {
@@ -304,7 +402,29 @@ func init() {
 				"CREATE UNIQUE INDEX ` + "` + \"`\" + `" + `idx_tokenKey_@TEST_RANDOM` + "` + \"`\" + `" + ` ON ` + "` + \"`\" + `" + `new_name` + "` + \"`\" + `" + ` (` + "` + \"`\" + `" + `tokenKey` + "` + \"`\" + `" + `)",
 				"CREATE UNIQUE INDEX ` + "` + \"`\" + `" + `idx_email_@TEST_RANDOM` + "` + \"`\" + `" + ` ON ` + "` + \"`\" + `" + `new_name` + "` + \"`\" + `" + ` (` + "` + \"`\" + `" + `email` + "` + \"`\" + `" + `) WHERE ` + "` + \"`\" + `" + `email` + "` + \"`\" + `" + ` != ''"
 			],
+			"ldap": {
+				"baseDN": "",
+				"bindDN": "",
+				"enabled": false,
+				"groupAttribute": "memberOf",
+				"groupRoles": {},
+				"idAttribute": "",
+				"insecureSkipVerify": false,
+				"mappedFields": {},
+				"rolesField": "",
+				"startTLS": false,
+				"timeout": 10,
+				"url": "",
+				"userFilter": "(uid={username})"
+			},
 			"listRule": "@request.auth.id != '' && 1 > 0 || 'backtick` + "` + \"`\" + `" + `test' = 0",
+			"lockout": {
+				"duration": 300,
+				"enabled": false,
+				"maxDuration": 86400,
+				"maxFailures": 5,
+				"maxIPFailures": 20
+			},
 			"manageRule": "1 != 2",
 			"mfa": {
 				"duration": 1800,
@@ -321,6 +441,13 @@ func init() {
 					"username": ""
 				}
 			},
+			"oidc": {
+				"codeDuration": 60,
+				"enabled": false,
+				"idTokenDuration": 3600,
+				"loginURL": "",
+				"refreshTokenDuration": 2592000
+			},
 			"otp": {
 				"duration": 180,
 				"emailTemplate": {
@@ -336,6 +463,15 @@ func init() {
 					"email"
 				]
 			},
+			"passwordPolicy": {
+				"checkBreached": false,
+				"history": 0,
+				"maxAge": 0,
+				"requireDigit": false,
+				"requireLowercase": false,
+				"requireSymbol": false,
+				"requireUppercase": false
+			},
 			"passwordResetToken": {
 				"duration": 1800
 			},
@@ -343,7 +479,43 @@ func init() {
 				"body": "<p>Hello,</p>\n<p>Click on the button below to reset your password.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-password-reset/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Reset password</a>\n</p>\n<p><i>If you didn't ask to reset your password, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
 				"subject": "Reset your {APP_NAME} password"
 			},
+			"saml": {
+				"allowIdPInitiated": false,
+				"attributes": {
+					"avatarURL": "",
+					"email": "email",
+					"name": "name",
+					"username": "username"
+				},
+				"certificate": "",
+				"duration": 300,
+				"enabled": false,
+				"entityId": "",
+				"idAttribute": "",
+				"idpMetadata": "",
+				"mappedFields": {
+					"avatarURL": "",
+					"id": "",
+					"name": "",
+					"username": ""
+				},
+				"redirectURLs": []
+			},
+			"sessions": {
+				"accessTokenDuration": 900,
+				"enabled": false,
+				"refreshTokenDuration": 2592000
+			},
 			"system": true,
+			"totp": {
+				"digits": 6,
+				"duration": 600,
+				"enabled": false,
+				"issuer": "",
+				"period": 30,
+				"recoveryCodes": 10,
+				"skew": 1
+			},
 			"type": "auth",
 			"updateRule": null,
 			"verificationTemplate": {
@@ -353,7 +525,15 @@ func init() {
 			"verificationToken": {
 				"duration": 259200
 			},
-			"viewRule": "id = \"1\""
+			"viewRule": "id = \"1\"",
+			"webauthn": {
+				"duration": 180,
+				"enabled": false,
+				"origins": null,
+				"rpId": "",
+				"rpName": "",
+				"userVerification": "preferred"
+			}
 		}` + "`" + `
 
 		collection := &core.Collection{}
@@ -468,12 +648,20 @@ migrate((app) => {
   return app.delete(collection);
 }, (app) => {
   const collection = new Collection({
+    "apiKeys": {
+      "enabled": false,
+      "maxDuration": 0
+    },
     "authAlert": {
       "emailTemplate": {
         "body": "<p>Hello,</p>\n<p>We noticed a login to your {APP_NAME} account from a new location:</p>\n<p><em>{ALERT_INFO}</em></p>\n<p><strong>If this wasn't you, you should immediately change your {APP_NAME} account password to revoke access from all other locations.</strong></p>\n<p>If this was you, you may disregard this email.</p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
         "subject": "Login from a new location"
       },
-      "enabled": true
+      "enabled": true,
+      "lockoutEmailTemplate": {
+        "body": "<p>Hello,</p>\n<p>Your {APP_NAME} account was temporarily locked due to too many failed login attempts:</p>\n<p><em>{ALERT_INFO}</em></p>\n<p><strong>If this wasn't you, someone may be trying to guess your password. Consider changing your {APP_NAME} account password.</strong></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
+        "subject": "Your account was temporarily locked"
+      }
     },
     "authRule": "",
     "authToken": {
@@ -569,7 +757,29 @@ migrate((app) => {
       "CREATE UNIQUE INDEX ` + "`" + `idx_tokenKey_@TEST_RANDOM` + "`" + ` ON ` + "`" + `test123` + "`" + ` (` + "`" + `tokenKey` + "`" + `)",
       "CREATE UNIQUE INDEX ` + "`" + `idx_email_@TEST_RANDOM` + "`" + ` ON ` + "`" + `test123` + "`" + ` (` + "`" + `email` + "`" + `) WHERE ` + "`" + `email` + "`" + ` != ''"
     ],
+    "ldap": {
+      "baseDN": "",
+      "bindDN": "",
+      "enabled": false,
+      "groupAttribute": "memberOf",
+      "groupRoles": {},
+      "idAttribute": "",
+      "insecureSkipVerify": false,
+      "mappedFields": {},
+      "rolesField": "",
+      "startTLS": false,
+      "timeout": 10,
+      "url": "",
+      "userFilter": "(uid={username})"
+    },
     "listRule": "@request.auth.id != '' && 1 > 0 || 'backtick` + "`" + `test' = 0",
+    "lockout": {
+      "duration": 300,
+      "enabled": false,
+      "maxDuration": 86400,
+      "maxFailures": 5,
+      "maxIPFailures": 20
+    },
     "manageRule": "1 != 2",
     "mfa": {
       "duration": 1800,
@@ -586,6 +796,13 @@ migrate((app) => {
         "username": ""
       }
     },
+    "oidc": {
+      "codeDuration": 60,
+      "enabled": false,
+      "idTokenDuration": 3600,
+      "loginURL": "",
+      "refreshTokenDuration": 2592000
+    },
     "otp": {
       "duration": 180,
       "emailTemplate": {
@@ -601,6 +818,15 @@ migrate((app) => {
         "email"
       ]
     },
+    "passwordPolicy": {
+      "checkBreached": false,
+      "history": 0,
+      "maxAge": 0,
+      "requireDigit": false,
+      "requireLowercase": false,
+      "requireSymbol": false,
+      "requireUppercase": false
+    },
     "passwordResetToken": {
       "duration": 1800
     },
@@ -608,7 +834,43 @@ migrate((app) => {
       "body": "<p>Hello,</p>\n<p>Click on the button below to reset your password.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-password-reset/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Reset password</a>\n</p>\n<p><i>If you didn't ask to reset your password, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
       "subject": "Reset your {APP_NAME} password"
     },
+    "saml": {
+      "allowIdPInitiated": false,
+      "attributes": {
+        "avatarURL": "",
+        "email": "email",
+        "name": "name",
+        "username": "username"
+      },
+      "certificate": "",
+      "duration": 300,
+      "enabled": false,
+      "entityId": "",
+      "idAttribute": "",
+      "idpMetadata": "",
+      "mappedFields": {
+        "avatarURL": "",
+        "id": "",
+        "name": "",
+        "username": ""
+      },
+      "redirectURLs": []
+    },
+    "sessions": {
+      "accessTokenDuration": 900,
+      "enabled": false,
+      "refreshTokenDuration": 2592000
+    },
     "system": false,
+    "totp": {
+      "digits": 6,
+      "duration": 600,
+      "enabled": false,
+      "issuer": "",
+      "period": 30,
+      "recoveryCodes": 10,
+      "skew": 1
+    },
     "type": "auth",
     "updateRule": null,
     "verificationTemplate": {
@@ -618,7 +880,15 @@ migrate((app) => {
     "verificationToken": {
       "duration": 259200
     },
-    "viewRule": "id = \"1\""
+    "viewRule": "id = \"1\"",
+    "webauthn": {
+      "duration": 180,
+      "enabled": false,
+      "origins": null,
+      "rpId": "",
+      "rpName": "",
+      "userVerification": "preferred"
+    }
   });
 
   return app.save(collection);
@@ -647,12 +917,20 @@ func init() {
 		return app.Delete(collection)
 	}, func(app core.App) error {
 		jsonData := ` + "`" + `{
+			"apiKeys": {
+				"enabled": false,
+				"maxDuration": 0
+			},
 			"authAlert": {
 				"emailTemplate": {
 					"body": "<p>Hello,</p>\n<p>We noticed a login to your {APP_NAME} account from a new location:</p>\n<p><em>{ALERT_INFO}</em></p>\n<p><strong>If this wasn't you, you should immediately change your {APP_NAME} account password to revoke access from all other locations.</strong></p>\n<p>If this was you, you may disregard this email.</p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
 					"subject": "Login from a new location"
 				},
-				"enabled": true
+				"enabled": true,
+				"lockoutEmailTemplate": {
+					"body": "<p>Hello,</p>\n<p>Your {APP_NAME} account was temporarily locked due to too many failed login attempts:</p>\n<p><em>{ALERT_INFO}</em></p>\n<p><strong>If this wasn't you, someone may be trying to guess your password. Consider changing your {APP_NAME} account password.</strong></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
+					"subject": "Your account was temporarily locked"
+				}
 			},
 			"authRule": "",
 			"authToken": {
@@ -748,7 +1026,29 @@ func init() {
 				"CREATE UNIQUE INDEX ` + "` + \"`\" + `" + `idx_tokenKey_@TEST_RANDOM` + "` + \"`\" + `" + ` ON ` + "` + \"`\" + `" + `test123` + "` + \"`\" + `" + ` (` + "` + \"`\" + `" + `tokenKey` + "` + \"`\" + `" + `)",
 				"CREATE UNIQUE INDEX ` + "` + \"`\" + `" + `idx_email_@TEST_RANDOM` + "` + \"`\" + `" + ` ON ` + "` + \"`\" + `" + `test123` + "` + \"`\" + `" + ` (` + "` + \"`\" + `" + `email` + "` + \"`\" + `" + `) WHERE ` + "` + \"`\" + `" + `email` + "` + \"`\" + `" + ` != ''"
 			],
+			"ldap": {
+				"baseDN": "",
+				"bindDN": "",
+				"enabled": false,
+				"groupAttribute": "memberOf",
+				"groupRoles": {},
+				"idAttribute": "",
+				"insecureSkipVerify": false,
+				"mappedFields": {},
+				"rolesField": "",
+				"startTLS": false,
+				"timeout": 10,
+				"url": "",
+				"userFilter": "(uid={username})"
+			},
 			"listRule": "@request.auth.id != '' && 1 > 0 || 'backtick` + "` + \"`\" + `" + `test' = 0",
+			"lockout": {
+				"duration": 300,
+				"enabled": false,
+				"maxDuration": 86400,
+				"maxFailures": 5,
+				"maxIPFailures": 20
+			},
 			"manageRule": "1 != 2",
 			"mfa": {
 				"duration": 1800,
@@ -765,6 +1065,13 @@ func init() {
 					"username": ""
 				}
 			},
+			"oidc": {
+				"codeDuration": 60,
+				"enabled": false,
+				"idTokenDuration": 3600,
+				"loginURL": "",
+				"refreshTokenDuration": 2592000
+			},
 			"otp": {
 				"duration": 180,
 				"emailTemplate": {
@@ -780,6 +1087,15 @@ func init() {
 					"email"
 				]
 			},
+			"passwordPolicy": {
+				"checkBreached": false,
+				"history": 0,
+				"maxAge": 0,
+				"requireDigit": false,
+				"requireLowercase": false,
+				"requireSymbol": false,
+				"requireUppercase": false
+			},
 			"passwordResetToken": {
 				"duration": 1800
 			},
@@ -787,7 +1103,43 @@ func init() {
 				"body": "<p>Hello,</p>\n<p>Click on the button below to reset your password.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-password-reset/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Reset password</a>\n</p>\n<p><i>If you didn't ask to reset your password, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
 				"subject": "Reset your {APP_NAME} password"
 			},
+			"saml": {
+				"allowIdPInitiated": false,
+				"attributes": {
+					"avatarURL": "",
+					"email": "email",
+					"name": "name",
+					"username": "username"
+				},
+				"certificate": "",
+				"duration": 300,
+				"enabled": false,
+				"entityId": "",
+				"idAttribute": "",
+				"idpMetadata": "",
+				"mappedFields": {
+					"avatarURL": "",
+					"id": "",
+					"name": "",
+					"username": ""
+				},
+				"redirectURLs": []
+			},
+			"sessions": {
+				"accessTokenDuration": 900,
+				"enabled": false,
+				"refreshTokenDuration": 2592000
+			},
 			"system": false,
+			"totp": {
+				"digits": 6,
+				"duration": 600,
+				"enabled": false,
+				"issuer": "",
+				"period": 30,
+				"recoveryCodes": 10,
+				"skew": 1
+			},
 			"type": "auth",
 			"updateRule": null,
 			"verificationTemplate": {
@@ -797,7 +1149,15 @@ func init() {
 			"verificationToken": {
 				"duration": 259200
 			},
-			"viewRule": "id = \"1\""
+			"viewRule": "id = \"1\"",
+			"webauthn": {
+				"duration": 180,
+				"enabled": false,
+				"origins": null,
+				"rpId": "",
+				"rpName": "",
+				"userVerification": "preferred"
+			}
 		}` + "`" + `
 
 		collection := &core.Collection{}
@@ -16,6 +16,7 @@ import (
 	"github.com/pocketbase/pocketbase/tools/list"
 	"github.com/pocketbase/pocketbase/tools/osutils"
 	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/tracing"
 	"github.com/spf13/cobra"
 
 	_ "github.com/pocketbase/pocketbase/migrations"
@@ -60,6 +61,10 @@ type Config struct {
 	AuxMaxOpenConns  int                // default to core.DefaultAuxMaxOpenConns
 	AuxMaxIdleConns  int                // default to core.DefaultAuxMaxIdleConns
 	DBConnect        core.DBConnectFunc // default to core.dbConnect
+
+	// optional spans tracer
+	// (if not set, it is initialized from the standard OTEL_EXPORTER_OTLP_* env variables)
+	Tracer *tracing.Tracer
 }
 
 // New creates a new PocketBase instance with the default configuration.
@@ -124,6 +129,10 @@ func NewWithConfig(config Config) *PocketBase {
 	// (errors are ignored, since the full flags parsing happens on Execute())
 	pb.eagerParseFlags(&config)
 
+	if config.Tracer == nil {
+		config.Tracer = tracing.NewFromEnv(executableName)
+	}
+
 	// initialize the app instance
 	pb.App = core.NewBaseApp(core.BaseAppConfig{
 		IsDev:            pb.devFlag,
@@ -135,6 +144,7 @@ func NewWithConfig(config Config) *PocketBase {
 		AuxMaxOpenConns:  config.AuxMaxOpenConns,
 		AuxMaxIdleConns:  config.AuxMaxIdleConns,
 		DBConnect:        config.DBConnect,
+		Tracer:           config.Tracer,
 	})
 
 	// hide the default help command (allow only `--help` flag)
@@ -162,11 +172,12 @@ func NewWithConfig(config Config) *PocketBase {
 }
 
 // Start starts the application, aka. registers the default system
-// commands (serve, superuser, version) and executes pb.RootCmd.
+// commands (serve, superuser, import, version) and executes pb.RootCmd.
 func (pb *PocketBase) Start() error {
 	// register system commands
 	pb.RootCmd.AddCommand(cmd.NewSuperuserCommand(pb))
 	pb.RootCmd.AddCommand(cmd.NewServeCommand(pb, !pb.hideStartBanner))
+	pb.RootCmd.AddCommand(cmd.NewImportCommand(pb))
 
 	return pb.Execute()
 }
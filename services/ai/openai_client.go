@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/tracing"
 )
 
 const (
@@ -34,7 +35,8 @@ func NewOpenAIClient(settings core.AISettings) *OpenAIClient {
 	return &OpenAIClient{
 		settings: settings,
 		client: &http.Client{
-			Timeout: DefaultTimeout,
+			Timeout:   DefaultTimeout,
+			Transport: tracing.NewTransport(nil),
 		},
 	}
 }
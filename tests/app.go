@@ -573,6 +573,22 @@ func NewTestAppWithConfig(config core.BaseAppConfig) (*TestApp, error) {
 		Priority: -99999,
 	})
 
+	t.OnRealtimePublishRequest().Bind(&hook.Handler[*core.RealtimePublishRequestEvent]{
+		Func: func(e *core.RealtimePublishRequestEvent) error {
+			t.registerEventCall("OnRealtimePublishRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRealtimePresenceChange().Bind(&hook.Handler[*core.RealtimePresenceEvent]{
+		Func: func(e *core.RealtimePresenceEvent) error {
+			t.registerEventCall("OnRealtimePresenceChange")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
 	t.OnSettingsListRequest().Bind(&hook.Handler[*core.SettingsListRequestEvent]{
 		Func: func(e *core.SettingsListRequestEvent) error {
 			t.registerEventCall("OnSettingsListRequest")
@@ -709,6 +725,78 @@ func NewTestAppWithConfig(config core.BaseAppConfig) (*TestApp, error) {
 		Priority: -99999,
 	})
 
+	t.OnRecordWebAuthnRegisterRequest().Bind(&hook.Handler[*core.RecordWebAuthnRegisterRequestEvent]{
+		Func: func(e *core.RecordWebAuthnRegisterRequestEvent) error {
+			t.registerEventCall("OnRecordWebAuthnRegisterRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRecordAuthWithWebAuthnRequest().Bind(&hook.Handler[*core.RecordAuthWithWebAuthnRequestEvent]{
+		Func: func(e *core.RecordAuthWithWebAuthnRequestEvent) error {
+			t.registerEventCall("OnRecordAuthWithWebAuthnRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRecordAuthWithTOTPRequest().Bind(&hook.Handler[*core.RecordAuthWithTOTPRequestEvent]{
+		Func: func(e *core.RecordAuthWithTOTPRequestEvent) error {
+			t.registerEventCall("OnRecordAuthWithTOTPRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRecordAuthWithLDAPRequest().Bind(&hook.Handler[*core.RecordAuthWithLDAPRequestEvent]{
+		Func: func(e *core.RecordAuthWithLDAPRequestEvent) error {
+			t.registerEventCall("OnRecordAuthWithLDAPRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRecordAuthWithSAMLRequest().Bind(&hook.Handler[*core.RecordAuthWithSAMLRequestEvent]{
+		Func: func(e *core.RecordAuthWithSAMLRequestEvent) error {
+			t.registerEventCall("OnRecordAuthWithSAMLRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRecordOIDCAuthorizeRequest().Bind(&hook.Handler[*core.RecordOIDCAuthorizeRequestEvent]{
+		Func: func(e *core.RecordOIDCAuthorizeRequestEvent) error {
+			t.registerEventCall("OnRecordOIDCAuthorizeRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRecordOIDCTokenRequest().Bind(&hook.Handler[*core.RecordOIDCTokenRequestEvent]{
+		Func: func(e *core.RecordOIDCTokenRequestEvent) error {
+			t.registerEventCall("OnRecordOIDCTokenRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRecordAuthWithRefreshTokenRequest().Bind(&hook.Handler[*core.RecordAuthWithRefreshTokenRequestEvent]{
+		Func: func(e *core.RecordAuthWithRefreshTokenRequestEvent) error {
+			t.registerEventCall("OnRecordAuthWithRefreshTokenRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRecordCreateAPIKeyRequest().Bind(&hook.Handler[*core.RecordCreateAPIKeyRequestEvent]{
+		Func: func(e *core.RecordCreateAPIKeyRequestEvent) error {
+			t.registerEventCall("OnRecordCreateAPIKeyRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
 	t.OnRecordsListRequest().Bind(&hook.Handler[*core.RecordsListRequestEvent]{
 		Func: func(e *core.RecordsListRequestEvent) error {
 			t.registerEventCall("OnRecordsListRequest")
@@ -0,0 +1,481 @@
+package tests
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/ldap"
+)
+
+// TestLDAPEntry is a single TestLDAPServer directory entry.
+type TestLDAPEntry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// TestLDAPServer is a minimal in-process LDAPv3 server that could be
+// used in the LDAP authentication related tests.
+//
+// It supports simple bind, search (with the standard filters), StartTLS and unbind.
+type TestLDAPServer struct {
+	mux       sync.Mutex
+	listener  net.Listener
+	tlsConfig *tls.Config
+	entries   []*TestLDAPEntry
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+	binds     []string
+	useTLS    bool
+
+	// AllowAnonymousSearch specifies whether unauthenticated
+	// connections are allowed to search.
+	AllowAnonymousSearch bool
+}
+
+// NewTestLDAPServer starts a new TestLDAPServer on a random local port.
+//
+// If useTLS is true, the server accepts only TLS connections ("ldaps://"),
+// otherwise plain connections with optional StartTLS upgrade ("ldap://").
+//
+// The server uses a self-signed certificate so the clients should
+// skip the certificate verification.
+func NewTestLDAPServer(useTLS bool) (*TestLDAPServer, error) {
+	tlsConfig, err := newTestLDAPTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var listener net.Listener
+	if useTLS {
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	} else {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &TestLDAPServer{
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		conns:     map[net.Conn]struct{}{},
+		useTLS:    useTLS,
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// URL returns the server url (e.g. "ldap://127.0.0.1:12345").
+func (s *TestLDAPServer) URL() string {
+	if s.useTLS {
+		return "ldaps://" + s.listener.Addr().String()
+	}
+
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// AddEntry registers a new directory entry.
+//
+// Leave password empty to disallow binding with the entry DN.
+func (s *TestLDAPServer) AddEntry(dn string, password string, attributes map[string][]string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if attributes == nil {
+		attributes = map[string][]string{}
+	}
+
+	s.entries = append(s.entries, &TestLDAPEntry{
+		DN:         dn,
+		Password:   password,
+		Attributes: attributes,
+	})
+}
+
+// Binds returns the DNs of all successful (non-anonymous) binds.
+func (s *TestLDAPServer) Binds() []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return append([]string{}, s.binds...)
+}
+
+// Close stops the server and closes all active connections.
+func (s *TestLDAPServer) Close() error {
+	err := s.listener.Close()
+
+	s.mux.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mux.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+func (s *TestLDAPServer) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mux.Lock()
+		s.conns[conn] = struct{}{}
+		s.mux.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+func (s *TestLDAPServer) handleConn(conn net.Conn) {
+	defer func() {
+		s.mux.Lock()
+		delete(s.conns, conn)
+		s.mux.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+
+	var isBound bool
+
+	for {
+		msg, err := ldap.ReadPacket(reader)
+		if err != nil || len(msg.Children) < 2 {
+			return
+		}
+
+		messageId := msg.Child(0).Int()
+		op := msg.Child(1)
+
+		write := func(response *ldap.Packet) error {
+			_, err := conn.Write(ldap.NewMessage(messageId, response).Bytes())
+			return err
+		}
+
+		switch {
+		case op.Is(ldap.ClassApplication, ldap.ApplicationBindRequest):
+			dn := op.Child(1).String()
+			password := op.Child(2).String()
+
+			code := ldap.ResultInvalidCredentials
+			if dn == "" && password == "" {
+				code = ldap.ResultSuccess // anonymous bind
+			} else if entry := s.findEntry(dn); entry != nil && entry.Password != "" && entry.Password == password {
+				code = ldap.ResultSuccess
+			}
+
+			isBound = code == ldap.ResultSuccess && dn != ""
+			if isBound {
+				s.mux.Lock()
+				s.binds = append(s.binds, dn)
+				s.mux.Unlock()
+			}
+
+			if write(ldap.NewResult(ldap.ApplicationBindResponse, code, "", "")) != nil {
+				return
+			}
+		case op.Is(ldap.ClassApplication, ldap.ApplicationSearchRequest):
+			if !isBound && !s.AllowAnonymousSearch {
+				if write(ldap.NewResult(ldap.ApplicationSearchResultDone, ldap.ResultInsufficientAccess, "", "anonymous search is not allowed")) != nil {
+					return
+				}
+				continue
+			}
+
+			if s.handleSearch(op, write) != nil {
+				return
+			}
+		case op.Is(ldap.ClassApplication, ldap.ApplicationExtendedRequest):
+			if op.Child(0).String() != ldap.OIDStartTLS || s.useTLS {
+				if write(ldap.NewResult(ldap.ApplicationExtendedResponse, ldap.ResultProtocolError, "", "unsupported extended operation")) != nil {
+					return
+				}
+				continue
+			}
+
+			if write(ldap.NewResult(ldap.ApplicationExtendedResponse, ldap.ResultSuccess, "", "")) != nil {
+				return
+			}
+
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+
+			s.mux.Lock()
+			delete(s.conns, conn)
+			s.conns[tlsConn] = struct{}{}
+			s.mux.Unlock()
+
+			conn = tlsConn
+			reader = bufio.NewReader(conn)
+
+			// reset the previous authentication state
+			isBound = false
+		case op.Is(ldap.ClassApplication, ldap.ApplicationUnbindRequest):
+			return
+		default:
+			return
+		}
+	}
+}
+
+func (s *TestLDAPServer) handleSearch(op *ldap.Packet, write func(*ldap.Packet) error) error {
+	baseDN := normalizeTestDN(op.Child(0).String())
+	scope := int(op.Child(1).Int())
+	sizeLimit := int(op.Child(3).Int())
+	filter := op.Child(6)
+
+	requested := []string{}
+	for _, attr := range op.Child(7).Children {
+		requested = append(requested, attr.String())
+	}
+
+	s.mux.Lock()
+	entries := make([]*TestLDAPEntry, len(s.entries))
+	copy(entries, s.entries)
+	s.mux.Unlock()
+
+	var sent int
+	for _, entry := range entries {
+		if !testDNInScope(normalizeTestDN(entry.DN), baseDN, scope) || !matchTestLDAPFilter(entry, filter) {
+			continue
+		}
+
+		if sizeLimit > 0 && sent >= sizeLimit {
+			return write(ldap.NewResult(ldap.ApplicationSearchResultDone, ldap.ResultSizeLimitExceeded, "", ""))
+		}
+
+		attributes := ldap.NewSequence()
+		for name, values := range entry.Attributes {
+			if !isTestAttributeRequested(name, requested) {
+				continue
+			}
+
+			set := ldap.NewSet()
+			for _, v := range values {
+				set.Children = append(set.Children, ldap.NewOctetString(v))
+			}
+
+			attributes.Children = append(attributes.Children, ldap.NewSequence(ldap.NewOctetString(name), set))
+		}
+
+		err := write(ldap.NewConstructed(
+			ldap.ClassApplication,
+			ldap.ApplicationSearchResultEntry,
+			ldap.NewOctetString(entry.DN),
+			attributes,
+		))
+		if err != nil {
+			return err
+		}
+
+		sent++
+	}
+
+	return write(ldap.NewResult(ldap.ApplicationSearchResultDone, ldap.ResultSuccess, "", ""))
+}
+
+func (s *TestLDAPServer) findEntry(dn string) *TestLDAPEntry {
+	dn = normalizeTestDN(dn)
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, entry := range s.entries {
+		if normalizeTestDN(entry.DN) == dn {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+func normalizeTestDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func testDNInScope(dn string, baseDN string, scope int) bool {
+	if baseDN == "" {
+		return scope != ldap.ScopeBaseObject || dn == ""
+	}
+
+	switch scope {
+	case ldap.ScopeBaseObject:
+		return dn == baseDN
+	case ldap.ScopeSingleLevel:
+		rdn, found := strings.CutSuffix(dn, ","+baseDN)
+		return found && !strings.Contains(rdn, ",")
+	default:
+		return dn == baseDN || strings.HasSuffix(dn, ","+baseDN)
+	}
+}
+
+func isTestAttributeRequested(name string, requested []string) bool {
+	if len(requested) == 0 {
+		return true
+	}
+
+	for _, r := range requested {
+		if r == "*" || strings.EqualFold(r, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func testEntryValues(entry *TestLDAPEntry, attr string) []string {
+	if strings.EqualFold(attr, "dn") || strings.EqualFold(attr, "distinguishedName") {
+		return []string{entry.DN}
+	}
+
+	for name, values := range entry.Attributes {
+		if strings.EqualFold(name, attr) {
+			return values
+		}
+	}
+
+	return nil
+}
+
+func matchTestLDAPFilter(entry *TestLDAPEntry, filter *ldap.Packet) bool {
+	if filter == nil || filter.Class != ldap.ClassContext {
+		return false
+	}
+
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchTestLDAPFilter(entry, child) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matchTestLDAPFilter(entry, child) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !matchTestLDAPFilter(entry, filter.Child(0))
+	case ldap.FilterPresent:
+		return len(testEntryValues(entry, string(filter.Value))) > 0
+	case ldap.FilterEqualityMatch, ldap.FilterApproxMatch, ldap.FilterGreaterOrEqual, ldap.FilterLessOrEqual:
+		expected := strings.ToLower(filter.Child(1).String())
+		for _, v := range testEntryValues(entry, filter.Child(0).String()) {
+			v = strings.ToLower(v)
+			switch filter.Tag {
+			case ldap.FilterGreaterOrEqual:
+				if v >= expected {
+					return true
+				}
+			case ldap.FilterLessOrEqual:
+				if v <= expected {
+					return true
+				}
+			default:
+				if v == expected {
+					return true
+				}
+			}
+		}
+		return false
+	case ldap.FilterSubstrings:
+		for _, v := range testEntryValues(entry, filter.Child(0).String()) {
+			if matchTestLDAPSubstrings(strings.ToLower(v), filter.Child(1)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return false
+}
+
+func matchTestLDAPSubstrings(value string, substrings *ldap.Packet) bool {
+	for _, part := range substrings.Children {
+		p := strings.ToLower(part.String())
+
+		switch part.Tag {
+		case ldap.FilterSubstringInitial:
+			if !strings.HasPrefix(value, p) {
+				return false
+			}
+			value = value[len(p):]
+		case ldap.FilterSubstringFinal:
+			if !strings.HasSuffix(value, p) {
+				return false
+			}
+			value = value[:len(value)-len(p)]
+		default:
+			idx := strings.Index(value, p)
+			if idx < 0 {
+				return false
+			}
+			value = value[idx+len(p):]
+		}
+	}
+
+	return true
+}
+
+func newTestLDAPTLSConfig() (*tls.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,248 @@
+package tests
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/saml"
+)
+
+// TestSAMLIdP is a minimal SAML identity provider
+// that could be used to generate signed responses
+// in the SAML related tests.
+type TestSAMLIdP struct {
+	// EntityId is the identity provider entity id.
+	EntityId string
+
+	// SSOURL is the HTTP-Redirect SingleSignOnService url.
+	SSOURL string
+
+	Key         *rsa.PrivateKey
+	Certificate *x509.Certificate
+}
+
+// NewTestSAMLIdP creates a new TestSAMLIdP with a random RSA key
+// and a self-signed certificate.
+func NewTestSAMLIdP(entityId string) (*TestSAMLIdP, error) {
+	key, cert, err := newTestRSACertificate(entityId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TestSAMLIdP{
+		EntityId:    entityId,
+		SSOURL:      "https://idp.example.com/sso",
+		Key:         key,
+		Certificate: cert,
+	}, nil
+}
+
+// NewTestSAMLKeyPair generates a new PEM encoded RSA private key
+// and self-signed certificate (e.g. for the SAML service provider config).
+func NewTestSAMLKeyPair(commonName string) (certPEM string, keyPEM string, err error) {
+	key, cert, err := newTestRSACertificate(commonName)
+	if err != nil {
+		return "", "", err
+	}
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	return certPEM, keyPEM, nil
+}
+
+// Metadata returns the identity provider metadata XML document.
+func (idp *TestSAMLIdP) Metadata() string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<md:EntityDescriptor xmlns:md="` + saml.NamespaceMetadata + `" entityID="` + idp.EntityId + `">` +
+		`<md:IDPSSODescriptor protocolSupportEnumeration="` + saml.NamespaceProtocol + `">` +
+		`<md:KeyDescriptor use="signing">` +
+		`<ds:KeyInfo xmlns:ds="` + saml.NamespaceDSig + `"><ds:X509Data><ds:X509Certificate>` +
+		base64.StdEncoding.EncodeToString(idp.Certificate.Raw) +
+		`</ds:X509Certificate></ds:X509Data></ds:KeyInfo>` +
+		`</md:KeyDescriptor>` +
+		`<md:SingleSignOnService Binding="` + saml.BindingHTTPPost + `" Location="` + idp.SSOURL + `"/>` +
+		`<md:SingleSignOnService Binding="` + saml.BindingHTTPRedirect + `" Location="` + idp.SSOURL + `"/>` +
+		`</md:IDPSSODescriptor>` +
+		`</md:EntityDescriptor>`
+}
+
+// TestSAMLResponse defines the options of a single TestSAMLIdP response.
+type TestSAMLResponse struct {
+	// ACSURL is the response Destination and the subject confirmation Recipient.
+	ACSURL string
+
+	// Audience is the SP entity id.
+	Audience string
+
+	// InResponseTo is the related AuthnRequest id (leave it empty for IdP-initiated responses).
+	InResponseTo string
+
+	// AssertionId is the assertion id (random if empty).
+	AssertionId string
+
+	NameID       string
+	NameIDFormat string
+	SessionIndex string
+
+	// Attributes are the assertion attribute values (indexed by their Name).
+	Attributes map[string][]string
+
+	// IssueInstant is the response and assertion issue time (defaults to now).
+	IssueInstant time.Time
+
+	// Duration is the assertion validity duration (defaults to 5min).
+	Duration time.Duration
+
+	// Status is the response status code (defaults to success).
+	Status string
+
+	// Issuer is the response and assertion issuer (defaults to the IdP entity id).
+	Issuer string
+
+	// SignResponse and SignAssertion specify which elements to sign
+	// (if both are false the response is left unsigned).
+	SignResponse  bool
+	SignAssertion bool
+
+	// SigningKey is an optional signing key that overwrites the IdP one.
+	SigningKey crypto.Signer
+}
+
+// NewResponse generates and returns a new base64 encoded SAMLResponse
+// (HTTP-POST binding) with the specified options.
+func (idp *TestSAMLIdP) NewResponse(opts TestSAMLResponse) (string, error) {
+	if opts.IssueInstant.IsZero() {
+		opts.IssueInstant = time.Now()
+	}
+	if opts.Duration == 0 {
+		opts.Duration = 5 * time.Minute
+	}
+	if opts.Status == "" {
+		opts.Status = saml.StatusSuccess
+	}
+	if opts.Issuer == "" {
+		opts.Issuer = idp.EntityId
+	}
+	if opts.AssertionId == "" {
+		opts.AssertionId = saml.NewRequestId()
+	}
+	if opts.NameIDFormat == "" {
+		opts.NameIDFormat = saml.NameIDFormatPersistent
+	}
+
+	var signingKey crypto.Signer = idp.Key
+	if opts.SigningKey != nil {
+		signingKey = opts.SigningKey
+	}
+
+	issueInstant := opts.IssueInstant.UTC().Format(time.RFC3339)
+	notBefore := opts.IssueInstant.Add(-1 * time.Minute).UTC().Format(time.RFC3339)
+	notOnOrAfter := opts.IssueInstant.Add(opts.Duration).UTC().Format(time.RFC3339)
+
+	var inResponseTo string
+	if opts.InResponseTo != "" {
+		inResponseTo = ` InResponseTo="` + escapeTestXML(opts.InResponseTo) + `"`
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(`<samlp:Response xmlns:samlp="` + saml.NamespaceProtocol + `" xmlns:saml="` + saml.NamespaceAssertion + `"`)
+	sb.WriteString(` ID="` + saml.NewRequestId() + `" Version="2.0" IssueInstant="` + issueInstant + `"`)
+	sb.WriteString(` Destination="` + escapeTestXML(opts.ACSURL) + `"` + inResponseTo + `>`)
+	sb.WriteString(`<saml:Issuer>` + escapeTestXML(opts.Issuer) + `</saml:Issuer>`)
+	sb.WriteString(`<samlp:Status><samlp:StatusCode Value="` + escapeTestXML(opts.Status) + `"/></samlp:Status>`)
+
+	// assertion
+	sb.WriteString(`<saml:Assertion ID="` + escapeTestXML(opts.AssertionId) + `" Version="2.0" IssueInstant="` + issueInstant + `">`)
+	sb.WriteString(`<saml:Issuer>` + escapeTestXML(opts.Issuer) + `</saml:Issuer>`)
+	sb.WriteString(`<saml:Subject>`)
+	sb.WriteString(`<saml:NameID Format="` + escapeTestXML(opts.NameIDFormat) + `">` + escapeTestXML(opts.NameID) + `</saml:NameID>`)
+	sb.WriteString(`<saml:SubjectConfirmation Method="` + saml.SubjectConfirmationBearer + `">`)
+	sb.WriteString(`<saml:SubjectConfirmationData NotOnOrAfter="` + notOnOrAfter + `" Recipient="` + escapeTestXML(opts.ACSURL) + `"` + inResponseTo + `/>`)
+	sb.WriteString(`</saml:SubjectConfirmation>`)
+	sb.WriteString(`</saml:Subject>`)
+	sb.WriteString(`<saml:Conditions NotBefore="` + notBefore + `" NotOnOrAfter="` + notOnOrAfter + `">`)
+	sb.WriteString(`<saml:AudienceRestriction><saml:Audience>` + escapeTestXML(opts.Audience) + `</saml:Audience></saml:AudienceRestriction>`)
+	sb.WriteString(`</saml:Conditions>`)
+	sb.WriteString(`<saml:AuthnStatement AuthnInstant="` + issueInstant + `" SessionIndex="` + escapeTestXML(opts.SessionIndex) + `">`)
+	sb.WriteString(`<saml:AuthnContext><saml:AuthnContextClassRef>urn:oasis:names:tc:SAML:2.0:ac:classes:PasswordProtectedTransport</saml:AuthnContextClassRef></saml:AuthnContext>`)
+	sb.WriteString(`</saml:AuthnStatement>`)
+	if len(opts.Attributes) > 0 {
+		sb.WriteString(`<saml:AttributeStatement>`)
+		for name, values := range opts.Attributes {
+			sb.WriteString(`<saml:Attribute Name="` + escapeTestXML(name) + `" NameFormat="urn:oasis:names:tc:SAML:2.0:attrname-format:basic">`)
+			for _, v := range values {
+				sb.WriteString(`<saml:AttributeValue xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="xs:string">` + escapeTestXML(v) + `</saml:AttributeValue>`)
+			}
+			sb.WriteString(`</saml:Attribute>`)
+		}
+		sb.WriteString(`</saml:AttributeStatement>`)
+	}
+	sb.WriteString(`</saml:Assertion>`)
+
+	sb.WriteString(`</samlp:Response>`)
+
+	response, err := saml.ParseXML([]byte(sb.String()))
+	if err != nil {
+		return "", err
+	}
+
+	if opts.SignAssertion {
+		err = saml.SignElement(response.Element(saml.NamespaceAssertion, "Assertion"), signingKey, idp.Certificate)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if opts.SignResponse {
+		err = saml.SignElement(response, signingKey, idp.Certificate)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(response.Bytes()), nil
+}
+
+func newTestRSACertificate(commonName string) (*rsa.PrivateKey, *x509.Certificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, cert, nil
+}
+
+func escapeTestXML(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
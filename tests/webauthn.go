@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+
+	"github.com/pocketbase/pocketbase/tools/webauthn"
+)
+
+// TestAuthenticator is a software WebAuthn authenticator (ES256)
+// that could be used to generate registration and assertion
+// responses in the WebAuthn related tests.
+type TestAuthenticator struct {
+	mux sync.Mutex
+	key *ecdsa.PrivateKey
+	id  []byte
+
+	// RPId is the relying party id used for the rpIdHash.
+	RPId string
+
+	// Origin is the client data origin.
+	Origin string
+
+	// AAGUID is the authenticator attestation guid (16 bytes).
+	AAGUID []byte
+
+	// SignCount is the authenticator signature counter
+	// (it is incremented with every assertion if nonzero).
+	SignCount uint32
+
+	// UserVerified indicates whether to set the UV flag.
+	UserVerified bool
+}
+
+// NewTestAuthenticator creates a new TestAuthenticator with a random
+// ES256 key and credential id.
+func NewTestAuthenticator(rpId string, origin string) (*TestAuthenticator, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &TestAuthenticator{
+		key:          key,
+		id:           id,
+		RPId:         rpId,
+		Origin:       origin,
+		AAGUID:       make([]byte, 16),
+		UserVerified: true,
+	}, nil
+}
+
+// CredentialId returns the raw credential id.
+func (a *TestAuthenticator) CredentialId() []byte {
+	return a.id
+}
+
+// PublicKey returns the CBOR encoded COSE_Key of the authenticator.
+func (a *TestAuthenticator) PublicKey() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+
+	// {1: 2 (EC2), 3: -7 (ES256), -1: 1 (P-256), -2: x, -3: y}
+	result := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21}
+	result = appendCBORBytes(result, x)
+	result = append(result, 0x22)
+	result = appendCBORBytes(result, y)
+
+	return result
+}
+
+// RegistrationResponse returns a new serialized registration
+// response for the specified challenge.
+func (a *TestAuthenticator) RegistrationResponse(challenge []byte) ([]byte, error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	clientData, err := a.clientData(webauthn.ClientDataTypeCreate, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	authData := a.authData(webauthn.FlagAttestedData)
+	authData = append(authData, a.AAGUID...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.id)))
+	authData = append(authData, a.id...)
+	authData = append(authData, a.PublicKey()...)
+
+	// {"fmt": "none", "attStmt": {}, "authData": authData}
+	attestation := []byte{0xa3}
+	attestation = appendCBORText(attestation, "fmt")
+	attestation = appendCBORText(attestation, "none")
+	attestation = appendCBORText(attestation, "attStmt")
+	attestation = append(attestation, 0xa0)
+	attestation = appendCBORText(attestation, "authData")
+	attestation = appendCBORBytes(attestation, authData)
+
+	return json.Marshal(map[string]any{
+		"id":    webauthn.URLEncodedBase64(a.id).String(),
+		"rawId": webauthn.URLEncodedBase64(a.id),
+		"type":  webauthn.CredentialType,
+		"response": map[string]any{
+			"clientDataJSON":    webauthn.URLEncodedBase64(clientData),
+			"attestationObject": webauthn.URLEncodedBase64(attestation),
+			"transports":        []string{"internal"},
+		},
+	})
+}
+
+// AssertionResponse returns a new serialized assertion response
+// for the specified challenge and (optional) user handle.
+func (a *TestAuthenticator) AssertionResponse(challenge []byte, userHandle []byte) ([]byte, error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if a.SignCount > 0 {
+		a.SignCount++
+	}
+
+	clientData, err := a.clientData(webauthn.ClientDataTypeGet, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	authData := a.authData(0)
+
+	clientDataHash := sha256.Sum256(clientData)
+	signed := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, signed[:])
+	if err != nil {
+		return nil, err
+	}
+
+	response := map[string]any{
+		"clientDataJSON":    webauthn.URLEncodedBase64(clientData),
+		"authenticatorData": webauthn.URLEncodedBase64(authData),
+		"signature":         webauthn.URLEncodedBase64(signature),
+	}
+	if len(userHandle) > 0 {
+		response["userHandle"] = webauthn.URLEncodedBase64(userHandle)
+	}
+
+	return json.Marshal(map[string]any{
+		"id":       webauthn.URLEncodedBase64(a.id).String(),
+		"rawId":    webauthn.URLEncodedBase64(a.id),
+		"type":     webauthn.CredentialType,
+		"response": response,
+	})
+}
+
+func (a *TestAuthenticator) clientData(typ string, challenge []byte) ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"type":      typ,
+		"challenge": webauthn.URLEncodedBase64(challenge).String(),
+		"origin":    a.Origin,
+	})
+}
+
+func (a *TestAuthenticator) authData(extraFlags byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(a.RPId))
+
+	flags := webauthn.FlagUserPresent | extraFlags
+	if a.UserVerified {
+		flags |= webauthn.FlagUserVerified
+	}
+
+	result := append([]byte{}, rpIdHash[:]...)
+	result = append(result, flags)
+	result = binary.BigEndian.AppendUint32(result, a.SignCount)
+
+	return result
+}
+
+func appendCBORBytes(dst []byte, data []byte) []byte {
+	return append(appendCBORHeader(dst, 2, len(data)), data...)
+}
+
+func appendCBORText(dst []byte, text string) []byte {
+	return append(appendCBORHeader(dst, 3, len(text)), text...)
+}
+
+func appendCBORHeader(dst []byte, major byte, length int) []byte {
+	switch {
+	case length < 24:
+		return append(dst, major<<5|byte(length))
+	case length <= 0xff:
+		return append(dst, major<<5|24, byte(length))
+	default:
+		return binary.BigEndian.AppendUint16(append(dst, major<<5|25), uint16(length))
+	}
+}
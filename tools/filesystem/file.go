@@ -99,6 +99,31 @@ func NewFileFromMultipart(mh *multipart.FileHeader) (*File, error) {
 //
 //	file, err := filesystem.NewFileFromURL(ctx, "https://example.com/image.png")
 func NewFileFromURL(ctx context.Context, url string) (*File, error) {
+	return NewFileFromURLWithOptions(ctx, url, URLFileOptions{})
+}
+
+// URLFileOptions defines the download limits of [NewFileFromURLWithOptions].
+type URLFileOptions struct {
+	// MaxSize is the max allowed size of the downloaded file in bytes (<= 0 means no limit).
+	MaxSize int64
+
+	// MimeTypes is an optional list with the allowed file mime types
+	// detected from the downloaded content (eg. "image/png", "application/pdf").
+	MimeTypes []string
+}
+
+// NewFileFromURLWithOptions is similar to [NewFileFromURL] but allows
+// limiting the downloaded file size and mime type.
+//
+// The download is aborted as soon as options.MaxSize is exceeded.
+//
+// Example
+//
+//	file, err := filesystem.NewFileFromURLWithOptions(ctx, "https://example.com/image.png", filesystem.URLFileOptions{
+//		MaxSize:   5 << 20,
+//		MimeTypes: []string{"image/png", "image/jpeg"},
+//	})
+func NewFileFromURLWithOptions(ctx context.Context, url string, options URLFileOptions) (*File, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -114,12 +139,43 @@ func NewFileFromURL(ctx context.Context, url string) (*File, error) {
 		return nil, fmt.Errorf("failed to download url %s (%d)", url, res.StatusCode)
 	}
 
+	var body io.Reader = res.Body
+
+	if options.MaxSize > 0 {
+		if res.ContentLength > options.MaxSize {
+			return nil, fmt.Errorf("the url %s file size exceeds the %d bytes limit", url, options.MaxSize)
+		}
+
+		// read one extra byte to detect responses without or with invalid Content-Length
+		body = io.LimitReader(res.Body, options.MaxSize+1)
+	}
+
 	var buf bytes.Buffer
 
-	if _, err = io.Copy(&buf, res.Body); err != nil {
+	if _, err = io.Copy(&buf, body); err != nil {
 		return nil, err
 	}
 
+	if options.MaxSize > 0 && int64(buf.Len()) > options.MaxSize {
+		return nil, fmt.Errorf("the url %s file size exceeds the %d bytes limit", url, options.MaxSize)
+	}
+
+	if len(options.MimeTypes) > 0 {
+		mt := mimetype.Detect(buf.Bytes())
+
+		var valid bool
+		for _, t := range options.MimeTypes {
+			if mt.Is(t) {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return nil, fmt.Errorf("the url %s file mime type %s must be one of: %s", url, mt.String(), strings.Join(options.MimeTypes, ", "))
+		}
+	}
+
 	return NewFileFromBytes(buf.Bytes(), path.Base(url))
 }
 
@@ -202,6 +202,61 @@ func TestNewFileFromURLTimeout(t *testing.T) {
 	}
 }
 
+func TestNewFileFromURLWithOptions(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Write(png)
+		case "/chunked.txt":
+			// no Content-Length
+			w.(http.Flusher).Flush()
+			fmt.Fprint(w, "0123456789")
+		default:
+			fmt.Fprint(w, "test")
+		}
+	}))
+	defer srv.Close()
+
+	scenarios := []struct {
+		name        string
+		url         string
+		options     filesystem.URLFileOptions
+		expectError bool
+	}{
+		{"no limits", "/test.txt", filesystem.URLFileOptions{}, false},
+		{"within the size limit", "/test.txt", filesystem.URLFileOptions{MaxSize: 4}, false},
+		{"exceeding Content-Length", "/test.txt", filesystem.URLFileOptions{MaxSize: 3}, true},
+		{"exceeding chunked size", "/chunked.txt", filesystem.URLFileOptions{MaxSize: 9}, true},
+		{"within chunked size", "/chunked.txt", filesystem.URLFileOptions{MaxSize: 10}, false},
+		{"allowed mime type", "/image.png", filesystem.URLFileOptions{MimeTypes: []string{"image/jpeg", "image/png"}}, false},
+		{"disallowed mime type", "/test.txt", filesystem.URLFileOptions{MimeTypes: []string{"image/png"}}, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			f, err := filesystem.NewFileFromURLWithOptions(context.Background(), srv.URL+s.url, s.options)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				if f != nil {
+					t.Fatalf("Expected nil file, got %v", f)
+				}
+				return
+			}
+
+			if f == nil || f.Size == 0 {
+				t.Fatalf("Expected non-empty file, got %v", f)
+			}
+		})
+	}
+}
+
 func TestFileNameNormalizations(t *testing.T) {
 	scenarios := []struct {
 		name    string
@@ -23,6 +23,7 @@ import (
 	"github.com/pocketbase/pocketbase/tools/filesystem/internal/s3blob"
 	"github.com/pocketbase/pocketbase/tools/filesystem/internal/s3blob/s3"
 	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/tracing"
 
 	// explicit webp decoder because disintegration/imaging does not support webp
 	_ "golang.org/x/image/webp"
@@ -36,6 +37,7 @@ const metadataOriginalName = "original-filename"
 type System struct {
 	ctx    context.Context
 	bucket *blob.Bucket
+	tracer *tracing.Tracer
 }
 
 // NewS3 initializes an S3 filesystem instance.
@@ -94,20 +96,62 @@ func (s *System) SetContext(ctx context.Context) {
 	s.ctx = ctx
 }
 
+// SetTracer assigns an optional tracer used to record the filesystem operations spans.
+//
+// If not set, the spans are recorded only if the filesystem context
+// has an associated tracer (see [tracing.TracerFromContext]).
+func (s *System) SetTracer(tracer *tracing.Tracer) {
+	s.tracer = tracer
+}
+
+// trace starts a new filesystem operation span (if there is an associated tracer)
+// and returns a function that ends it with the operation error.
+func (s *System) trace(operation string, fileKey string) func(errPtr *error) {
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tracer := s.tracer
+	if tracer == nil {
+		tracer = tracing.TracerFromContext(ctx)
+	}
+
+	_, span := tracer.Start(ctx, "filesystem "+operation, tracing.WithAttributes(map[string]any{
+		"file.key": fileKey,
+	}))
+
+	return func(errPtr *error) {
+		if span == nil {
+			return
+		}
+
+		if errPtr != nil {
+			span.RecordError(*errPtr)
+		}
+
+		span.End()
+	}
+}
+
 // Close releases any resources used for the related filesystem.
 func (s *System) Close() error {
 	return s.bucket.Close()
 }
 
 // Exists checks if file with fileKey path exists or not.
-func (s *System) Exists(fileKey string) (bool, error) {
+func (s *System) Exists(fileKey string) (exists bool, err error) {
+	defer s.trace("exists", fileKey)(&err)
+
 	return s.bucket.Exists(s.ctx, fileKey)
 }
 
 // Attributes returns the attributes for the file with fileKey path.
 //
 // If the file doesn't exist it returns ErrNotFound.
-func (s *System) Attributes(fileKey string) (*blob.Attributes, error) {
+func (s *System) Attributes(fileKey string) (attrs *blob.Attributes, err error) {
+	defer s.trace("attributes", fileKey)(&err)
+
 	return s.bucket.Attributes(s.ctx, fileKey)
 }
 
@@ -116,7 +160,9 @@ func (s *System) Attributes(fileKey string) (*blob.Attributes, error) {
 // NB! Make sure to call Close() on the file after you are done working with it.
 //
 // If the file doesn't exist returns ErrNotFound.
-func (s *System) GetReader(fileKey string) (*blob.Reader, error) {
+func (s *System) GetReader(fileKey string) (reader *blob.Reader, err error) {
+	defer s.trace("getReader", fileKey)(&err)
+
 	return s.bucket.NewReader(s.ctx, fileKey)
 }
 
@@ -170,12 +216,16 @@ func (s *System) GetReuploadableFile(fileKey string, preserveName bool) (*File,
 // If srcKey file doesn't exist, it returns ErrNotFound.
 //
 // If dstKey file already exists, it is overwritten.
-func (s *System) Copy(srcKey, dstKey string) error {
+func (s *System) Copy(srcKey, dstKey string) (err error) {
+	defer s.trace("copy", dstKey)(&err)
+
 	return s.bucket.Copy(s.ctx, dstKey, srcKey)
 }
 
 // List returns a flat list with info for all files under the specified prefix.
-func (s *System) List(prefix string) ([]*blob.ListObject, error) {
+func (s *System) List(prefix string) (result []*blob.ListObject, err error) {
+	defer s.trace("list", prefix)(&err)
+
 	files := []*blob.ListObject{}
 
 	iter := s.bucket.List(&blob.ListOptions{
@@ -197,7 +247,9 @@ func (s *System) List(prefix string) ([]*blob.ListObject, error) {
 }
 
 // Upload writes content into the fileKey location.
-func (s *System) Upload(content []byte, fileKey string) error {
+func (s *System) Upload(content []byte, fileKey string) (err error) {
+	defer s.trace("upload", fileKey)(&err)
+
 	opts := &blob.WriterOptions{
 		ContentType: mimetype.Detect(content).String(),
 	}
@@ -215,7 +267,9 @@ func (s *System) Upload(content []byte, fileKey string) error {
 }
 
 // UploadFile uploads the provided File to the fileKey location.
-func (s *System) UploadFile(file *File, fileKey string) error {
+func (s *System) UploadFile(file *File, fileKey string) (err error) {
+	defer s.trace("upload", fileKey)(&err)
+
 	f, err := file.Reader.Open()
 	if err != nil {
 		return err
@@ -257,7 +311,9 @@ func (s *System) UploadFile(file *File, fileKey string) error {
 }
 
 // UploadMultipart uploads the provided multipart file to the fileKey location.
-func (s *System) UploadMultipart(fh *multipart.FileHeader, fileKey string) error {
+func (s *System) UploadMultipart(fh *multipart.FileHeader, fileKey string) (err error) {
+	defer s.trace("upload", fileKey)(&err)
+
 	f, err := fh.Open()
 	if err != nil {
 		return err
@@ -302,7 +358,9 @@ func (s *System) UploadMultipart(fh *multipart.FileHeader, fileKey string) error
 // Delete deletes stored file at fileKey location.
 //
 // If the file doesn't exist returns ErrNotFound.
-func (s *System) Delete(fileKey string) error {
+func (s *System) Delete(fileKey string) (err error) {
+	defer s.trace("delete", fileKey)(&err)
+
 	return s.bucket.Delete(s.ctx, fileKey)
 }
 
@@ -428,7 +486,9 @@ const forceAttachmentParam = "download"
 //
 // Internally this method uses [http.ServeContent] so Range requests,
 // If-Match, If-Unmodified-Since, etc. headers are handled transparently.
-func (s *System) Serve(res http.ResponseWriter, req *http.Request, fileKey string, name string) error {
+func (s *System) Serve(res http.ResponseWriter, req *http.Request, fileKey string, name string) (err error) {
+	defer s.trace("serve", fileKey)(&err)
+
 	br, readErr := s.GetReader(fileKey)
 	if readErr != nil {
 		return readErr
@@ -486,7 +546,9 @@ var ThumbSizeRegex = regexp.MustCompile(`^(\d+)x(\d+)(t|b|f)?$`)
 // - WxHt (eg. 300x100t) - resize and crop to WxH viewbox (from top)
 // - WxHb (eg. 300x100b) - resize and crop to WxH viewbox (from bottom)
 // - WxHf (eg. 300x100f) - fit inside a WxH viewbox (without cropping)
-func (s *System) CreateThumb(originalKey string, thumbKey, thumbSize string) error {
+func (s *System) CreateThumb(originalKey string, thumbKey, thumbSize string) (err error) {
+	defer s.trace("createThumb", thumbKey)(&err)
+
 	sizeParts := ThumbSizeRegex.FindStringSubmatch(thumbSize)
 	if len(sizeParts) != 4 {
 		return errors.New("thumb size must be in WxH, WxHt, WxHb or WxHf format")
@@ -31,6 +31,20 @@ type Handler[T Resolver] struct {
 	Priority int
 }
 
+// HandlerTracer defines an optional interface that hook events could implement
+// in order to trace the execution of each individual hook handler.
+type HandlerTracer interface {
+	// TraceHandler is called right before the handler execution and
+	// returns a function that is invoked with the handler result.
+	//
+	// event is the triggered event (it could be different from the method receiver
+	// in case of embedded struct) and handlerId is the id of the handler
+	// to execute (empty for the one off handlers).
+	//
+	// It could return nil if the handler shouldn't be traced.
+	TraceHandler(event Resolver, handlerId string) func(err error)
+}
+
 // Hook defines a generic concurrent safe structure for managing event hooks.
 //
 // When using custom event it must embed the base [hook.Event].
@@ -152,21 +166,43 @@ func (h *Hook[T]) Length() int {
 // NB! Each hook handler must call event.Next() in order the hook chain to proceed.
 func (h *Hook[T]) Trigger(event T, oneOffHandlerFuncs ...func(T) error) error {
 	h.mu.RLock()
-	handlers := make([]func(T) error, 0, len(h.handlers)+len(oneOffHandlerFuncs))
-	for _, handler := range h.handlers {
-		handlers = append(handlers, handler.Func)
-	}
-	handlers = append(handlers, oneOffHandlerFuncs...)
+	handlers := make([]*Handler[T], len(h.handlers))
+	copy(handlers, h.handlers)
 	h.mu.RUnlock()
 
+	total := len(handlers) + len(oneOffHandlerFuncs)
+
+	tracer, _ := any(event).(HandlerTracer)
+
 	event.setNextFunc(nil) // reset in case the event is being reused
 
-	for i := len(handlers) - 1; i >= 0; i-- {
+	for i := total - 1; i >= 0; i-- {
 		i := i
 		old := event.nextFunc()
 		event.setNextFunc(func() error {
 			event.setNextFunc(old)
-			return handlers[i](event)
+
+			var fn func(T) error
+			if i < len(handlers) {
+				fn = handlers[i].Func
+			} else {
+				fn = oneOffHandlerFuncs[i-len(handlers)]
+			}
+
+			if tracer != nil {
+				var id string // empty for the one off handlers
+				if i < len(handlers) {
+					id = handlers[i].Id
+				}
+
+				if end := tracer.TraceHandler(event, id); end != nil {
+					err := fn(event)
+					end(err)
+					return err
+				}
+			}
+
+			return fn(event)
 		})
 	}
 
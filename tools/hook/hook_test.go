@@ -160,3 +160,49 @@ func TestHookTriggerErrorPropagation(t *testing.T) {
 		})
 	}
 }
+
+type tracedEvent struct {
+	Event
+	traced []string
+}
+
+func (e *tracedEvent) TraceHandler(event Resolver, handlerId string) func(err error) {
+	e.traced = append(e.traced, "start_"+handlerId)
+
+	return func(err error) {
+		var errStr string
+		if err != nil {
+			errStr = "_" + err.Error()
+		}
+		e.traced = append(e.traced, "end_"+handlerId+errStr)
+	}
+}
+
+func TestHookTriggerHandlerTracer(t *testing.T) {
+	h := Hook[*tracedEvent]{}
+
+	h.Bind(&Handler[*tracedEvent]{
+		Id:   "a",
+		Func: func(e *tracedEvent) error { return e.Next() },
+	})
+	h.Bind(&Handler[*tracedEvent]{
+		Id:   "b",
+		Func: func(e *tracedEvent) error { e.Next(); return errors.New("test") },
+	})
+
+	event := &tracedEvent{}
+
+	h.Trigger(event, func(e *tracedEvent) error { return e.Next() })
+
+	expected := []string{"start_a", "start_b", "start_", "end_", "end_b_test", "end_a_test"}
+
+	if len(event.traced) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, event.traced)
+	}
+
+	for i, v := range expected {
+		if event.traced[i] != v {
+			t.Fatalf("Expected %v, got %v", expected, event.traced)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// BER identifier classes.
+const (
+	ClassUniversal   byte = 0x00
+	ClassApplication byte = 0x40
+	ClassContext     byte = 0x80
+)
+
+// BER universal tags used by the LDAP messages.
+const (
+	TagBoolean     = 0x01
+	TagInteger     = 0x02
+	TagOctetString = 0x04
+	TagNull        = 0x05
+	TagEnumerated  = 0x0a
+	TagSequence    = 0x10
+	TagSet         = 0x11
+)
+
+const (
+	// berMaxDepth limits the nesting of the decoded constructed packets.
+	berMaxDepth = 32
+
+	// berMaxLength limits the size of a single decoded packet.
+	berMaxLength = 16 << 20 // 16MB
+)
+
+var errBERTruncated = errors.New("ber: unexpected end of data")
+
+// Packet is a single BER encoded data element.
+//
+// Only the low tag numbers (< 31) and the definite length form are
+// supported since these are the only ones used by the LDAPv3 messages.
+type Packet struct {
+	Class       byte
+	Constructed bool
+	Tag         int
+
+	// Value is the content of a primitive packet.
+	Value []byte
+
+	// Children are the nested elements of a constructed packet.
+	Children []*Packet
+}
+
+// NewPacket creates a new primitive packet with the specified identifier and value.
+func NewPacket(class byte, tag int, value []byte) *Packet {
+	return &Packet{Class: class, Tag: tag, Value: value}
+}
+
+// NewConstructed creates a new constructed packet with the specified identifier and children.
+func NewConstructed(class byte, tag int, children ...*Packet) *Packet {
+	return &Packet{Class: class, Constructed: true, Tag: tag, Children: children}
+}
+
+// NewSequence creates a new universal SEQUENCE packet.
+func NewSequence(children ...*Packet) *Packet {
+	return NewConstructed(ClassUniversal, TagSequence, children...)
+}
+
+// NewSet creates a new universal SET packet.
+func NewSet(children ...*Packet) *Packet {
+	return NewConstructed(ClassUniversal, TagSet, children...)
+}
+
+// NewOctetString creates a new universal OCTET STRING packet.
+func NewOctetString(value string) *Packet {
+	return NewPacket(ClassUniversal, TagOctetString, []byte(value))
+}
+
+// NewInteger creates a new universal INTEGER packet.
+func NewInteger(value int64) *Packet {
+	return NewPacket(ClassUniversal, TagInteger, encodeInt(value))
+}
+
+// NewEnumerated creates a new universal ENUMERATED packet.
+func NewEnumerated(value int64) *Packet {
+	return NewPacket(ClassUniversal, TagEnumerated, encodeInt(value))
+}
+
+// NewBoolean creates a new universal BOOLEAN packet.
+func NewBoolean(value bool) *Packet {
+	if value {
+		return NewPacket(ClassUniversal, TagBoolean, []byte{0xff})
+	}
+	return NewPacket(ClassUniversal, TagBoolean, []byte{0x00})
+}
+
+// Is checks whether the packet has the specified class and tag.
+func (p *Packet) Is(class byte, tag int) bool {
+	return p != nil && p.Class == class && p.Tag == tag
+}
+
+// Child returns the i-th child of the packet or nil if it doesn't exist.
+func (p *Packet) Child(i int) *Packet {
+	if p == nil || i < 0 || i >= len(p.Children) {
+		return nil
+	}
+	return p.Children[i]
+}
+
+// String returns the primitive packet value as string.
+func (p *Packet) String() string {
+	if p == nil {
+		return ""
+	}
+	return string(p.Value)
+}
+
+// Int returns the primitive packet value as two's complement integer
+// (aka. INTEGER and ENUMERATED).
+func (p *Packet) Int() int64 {
+	if p == nil || len(p.Value) == 0 || len(p.Value) > 8 {
+		return 0
+	}
+
+	// sign extend
+	var v int64
+	if p.Value[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, b := range p.Value {
+		v = v<<8 | int64(b)
+	}
+
+	return v
+}
+
+// Bool returns the primitive packet value as boolean.
+func (p *Packet) Bool() bool {
+	return p != nil && len(p.Value) > 0 && p.Value[0] != 0
+}
+
+// Bytes returns the BER encoding of the packet.
+func (p *Packet) Bytes() []byte {
+	content := p.Value
+	if p.Constructed {
+		content = nil
+		for _, child := range p.Children {
+			content = append(content, child.Bytes()...)
+		}
+	}
+
+	identifier := p.Class | byte(p.Tag&0x1f)
+	if p.Constructed {
+		identifier |= 0x20
+	}
+
+	result := make([]byte, 0, len(content)+6)
+	result = append(result, identifier)
+	result = appendLength(result, len(content))
+	result = append(result, content...)
+
+	return result
+}
+
+// DecodePacket decodes a single BER packet from data.
+//
+// Returns an error if data contains trailing bytes after the packet.
+func DecodePacket(data []byte) (*Packet, error) {
+	p, rest, err := decodePacket(data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, errors.New("ber: trailing data after the packet")
+	}
+
+	return p, nil
+}
+
+// ReadPacket reads and decodes a single BER packet from r.
+func ReadPacket(r *bufio.Reader) (*Packet, error) {
+	identifier, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	header := []byte{identifier}
+
+	first, err := r.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	header = append(header, first)
+
+	length := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, errors.New("ber: unsupported length encoding")
+		}
+
+		length = 0
+		for i := 0; i < n; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			header = append(header, b)
+			length = length<<8 | int(b)
+		}
+	}
+
+	if length > berMaxLength {
+		return nil, fmt.Errorf("ber: packet length %d exceeds the max allowed", length)
+	}
+
+	data := make([]byte, len(header)+length)
+	copy(data, header)
+	if _, err := io.ReadFull(r, data[len(header):]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return DecodePacket(data)
+}
+
+func decodePacket(data []byte, depth int) (*Packet, []byte, error) {
+	if depth > berMaxDepth {
+		return nil, nil, errors.New("ber: max nesting depth reached")
+	}
+
+	if len(data) < 2 {
+		return nil, nil, errBERTruncated
+	}
+
+	identifier := data[0]
+	if identifier&0x1f == 0x1f {
+		return nil, nil, errors.New("ber: high tag numbers are not supported")
+	}
+
+	p := &Packet{
+		Class:       identifier & 0xc0,
+		Constructed: identifier&0x20 != 0,
+		Tag:         int(identifier & 0x1f),
+	}
+
+	length := int(data[1])
+	data = data[2:]
+
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 {
+			return nil, nil, errors.New("ber: unsupported length encoding")
+		}
+		if len(data) < n {
+			return nil, nil, errBERTruncated
+		}
+
+		length = 0
+		for _, b := range data[:n] {
+			length = length<<8 | int(b)
+		}
+		data = data[n:]
+	}
+
+	if length < 0 || length > len(data) {
+		return nil, nil, errBERTruncated
+	}
+
+	content, rest := data[:length], data[length:]
+
+	if !p.Constructed {
+		p.Value = content
+		return p, rest, nil
+	}
+
+	for len(content) > 0 {
+		child, childRest, err := decodePacket(content, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.Children = append(p.Children, child)
+		content = childRest
+	}
+
+	return p, rest, nil
+}
+
+func appendLength(dst []byte, length int) []byte {
+	if length < 0x80 {
+		return append(dst, byte(length))
+	}
+
+	var buf []byte
+	for l := length; l > 0; l >>= 8 {
+		buf = append([]byte{byte(l)}, buf...)
+	}
+
+	dst = append(dst, 0x80|byte(len(buf)))
+
+	return append(dst, buf...)
+}
+
+func encodeInt(v int64) []byte {
+	result := []byte{byte(v)}
+
+	for {
+		next := v >> 8
+		// stop when the remaining bytes are only sign extension
+		if (next == 0 && result[0]&0x80 == 0) || (next == -1 && result[0]&0x80 != 0) {
+			break
+		}
+		v = next
+		result = append([]byte{byte(v)}, result...)
+	}
+
+	return result
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
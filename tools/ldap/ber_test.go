@@ -0,0 +1,123 @@
+package ldap_test
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/ldap"
+)
+
+func TestPacketRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	original := ldap.NewSequence(
+		ldap.NewInteger(5),
+		ldap.NewInteger(-129),
+		ldap.NewEnumerated(300),
+		ldap.NewBoolean(true),
+		ldap.NewOctetString(strings.Repeat("a", 200)), // long form length
+		ldap.NewConstructed(ldap.ClassApplication, 3, ldap.NewOctetString("nested")),
+		ldap.NewPacket(ldap.ClassContext, 7, []byte("objectClass")),
+	)
+
+	decoded, err := ldap.DecodePacket(original.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decoded.Bytes(), original.Bytes()) {
+		t.Fatalf("Expected the re-encoded packet to match the original one")
+	}
+
+	if v := decoded.Child(0).Int(); v != 5 {
+		t.Fatalf("Expected integer 5, got %d", v)
+	}
+
+	if v := decoded.Child(1).Int(); v != -129 {
+		t.Fatalf("Expected integer -129, got %d", v)
+	}
+
+	if v := decoded.Child(2).Int(); v != 300 {
+		t.Fatalf("Expected enumerated 300, got %d", v)
+	}
+
+	if !decoded.Child(3).Bool() {
+		t.Fatalf("Expected boolean true")
+	}
+
+	if v := decoded.Child(4).String(); len(v) != 200 {
+		t.Fatalf("Expected 200 chars string, got %d", len(v))
+	}
+
+	if !decoded.Child(5).Is(ldap.ClassApplication, 3) || !decoded.Child(5).Constructed {
+		t.Fatalf("Expected constructed application 3 packet, got %#v", decoded.Child(5))
+	}
+
+	if v := decoded.Child(5).Child(0).String(); v != "nested" {
+		t.Fatalf("Expected nested value, got %q", v)
+	}
+
+	if !decoded.Child(6).Is(ldap.ClassContext, 7) || decoded.Child(6).String() != "objectClass" {
+		t.Fatalf("Invalid context packet %#v", decoded.Child(6))
+	}
+
+	if decoded.Child(7) != nil {
+		t.Fatalf("Expected nil for missing child")
+	}
+}
+
+func TestDecodePacketErrors(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated header", []byte{0x30}},
+		{"truncated content", []byte{0x04, 0x05, 'a'}},
+		{"trailing data", []byte{0x04, 0x01, 'a', 'b'}},
+		{"high tag number", []byte{0x1f, 0x01, 0x00}},
+		{"indefinite length", []byte{0x30, 0x80, 0x00, 0x00}},
+		{"invalid child", []byte{0x30, 0x02, 0x04, 0x05}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			if _, err := ldap.DecodePacket(s.data); err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestReadPacket(t *testing.T) {
+	t.Parallel()
+
+	first := ldap.NewSequence(ldap.NewInteger(1), ldap.NewOctetString(strings.Repeat("b", 300)))
+	second := ldap.NewSequence(ldap.NewInteger(2))
+
+	r := bufio.NewReader(bytes.NewReader(append(first.Bytes(), second.Bytes()...)))
+
+	p1, err := ldap.ReadPacket(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1.Child(0).Int() != 1 || len(p1.Child(1).String()) != 300 {
+		t.Fatalf("Invalid first packet %#v", p1)
+	}
+
+	p2, err := ldap.ReadPacket(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p2.Child(0).Int() != 2 {
+		t.Fatalf("Invalid second packet %#v", p2)
+	}
+
+	if _, err := ldap.ReadPacket(r); err == nil {
+		t.Fatal("Expected EOF error")
+	}
+}
@@ -0,0 +1,303 @@
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the default dial and operation timeout.
+const DefaultTimeout = 10 * time.Second
+
+// Conn is a single LDAP client connection.
+//
+// The operations are executed sequentially (one at a time).
+type Conn struct {
+	mux       sync.Mutex
+	conn      net.Conn
+	reader    *bufio.Reader
+	timeout   time.Duration
+	messageId int64
+	isTLS     bool
+}
+
+// Dial connects to the LDAP server at the specified URL.
+//
+// Supported URL schemes are "ldap" (default port 389) and "ldaps"
+// (default port 636). tlsConfig is used for the "ldaps" connections
+// (if nil, a default config with the URL host as ServerName is used).
+//
+// The timeout is applied to the dial and to each following operation
+// (if zero or negative, [DefaultTimeout] is used).
+func Dial(rawURL string, tlsConfig *tls.Config, timeout time.Duration) (*Conn, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid url: %w", err)
+	}
+
+	port := u.Port()
+
+	var useTLS bool
+	switch u.Scheme {
+	case "ldap":
+		if port == "" {
+			port = "389"
+		}
+	case "ldaps":
+		useTLS = true
+		if port == "" {
+			port = "636"
+		}
+	default:
+		return nil, errors.New("ldap: unsupported url scheme " + u.Scheme)
+	}
+
+	if u.Hostname() == "" {
+		return nil, errors.New("ldap: missing url host")
+	}
+
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var netConn net.Conn
+	if useTLS {
+		netConn, err = tls.DialWithDialer(dialer, "tcp", addr, prepareTLSConfig(tlsConfig, u.Hostname()))
+	} else {
+		netConn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConn(netConn, timeout, useTLS), nil
+}
+
+// NewConn creates a new LDAP client from an already established connection.
+func NewConn(netConn net.Conn, timeout time.Duration, isTLS bool) *Conn {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Conn{
+		conn:    netConn,
+		reader:  bufio.NewReader(netConn),
+		timeout: timeout,
+		isTLS:   isTLS,
+	}
+}
+
+// IsTLS reports whether the connection is encrypted (ldaps or after StartTLS).
+func (c *Conn) IsTLS() bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.isTLS
+}
+
+// StartTLS upgrades the current plain connection to TLS with the StartTLS extended operation.
+//
+// If tlsConfig is nil, a default config with the remote host as ServerName is used.
+func (c *Conn) StartTLS(tlsConfig *tls.Config) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.isTLS {
+		return errors.New("ldap: the connection is already encrypted")
+	}
+
+	op := NewConstructed(
+		ClassApplication,
+		ApplicationExtendedRequest,
+		NewPacket(ClassContext, 0, []byte(OIDStartTLS)),
+	)
+
+	response, err := c.roundTrip(op, ApplicationExtendedResponse)
+	if err != nil {
+		return err
+	}
+
+	if err := ParseResult(response); err != nil {
+		return err
+	}
+
+	host, _, _ := net.SplitHostPort(c.conn.RemoteAddr().String())
+
+	tlsConn := tls.Client(c.conn, prepareTLSConfig(tlsConfig, host))
+	tlsConn.SetDeadline(time.Now().Add(c.timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	c.isTLS = true
+
+	return nil
+}
+
+// Bind performs a simple bind operation with the specified DN and password.
+//
+// An empty password is rejected to prevent accidental unauthenticated
+// binds (RFC 4513 section 5.1.2) which most servers treat as success.
+func (c *Conn) Bind(dn string, password string) error {
+	if password == "" {
+		return ErrInvalidCredentials
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	response, err := c.roundTrip(newBindRequest(dn, password), ApplicationBindResponse)
+	if err != nil {
+		return err
+	}
+
+	return ParseResult(response)
+}
+
+// Search performs a search operation and returns the found entries.
+//
+// Search result references are ignored.
+func (c *Conn) Search(req *SearchRequest) ([]*Entry, error) {
+	filter, err := CompileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	messageId, err := c.send(newSearchRequest(req, filter))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*Entry{}
+
+	for {
+		op, err := c.receive(messageId)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case op.Is(ClassApplication, ApplicationSearchResultEntry):
+			entry, err := parseSearchEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		case op.Is(ClassApplication, ApplicationSearchResultReference):
+			// ignore
+		case op.Is(ClassApplication, ApplicationSearchResultDone):
+			return entries, ParseResult(op)
+		default:
+			return nil, fmt.Errorf("ldap: unexpected search response tag %d", op.Tag)
+		}
+	}
+}
+
+// Close sends an unbind request (ignoring its errors) and closes the connection.
+func (c *Conn) Close() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	_, _ = c.send(NewPacket(ClassApplication, ApplicationUnbindRequest, nil))
+
+	return c.conn.Close()
+}
+
+// roundTrip sends op and reads its single response with the expected application tag.
+func (c *Conn) roundTrip(op *Packet, expectedTag int) (*Packet, error) {
+	messageId, err := c.send(op)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.receive(messageId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Is(ClassApplication, expectedTag) {
+		return nil, fmt.Errorf("ldap: unexpected response tag %d", response.Tag)
+	}
+
+	return response, nil
+}
+
+func (c *Conn) send(op *Packet) (int64, error) {
+	c.messageId++
+
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+
+	if _, err := c.conn.Write(NewMessage(c.messageId, op).Bytes()); err != nil {
+		return 0, err
+	}
+
+	return c.messageId, nil
+}
+
+// receive reads the next protocol operation for the specified message id.
+func (c *Conn) receive(messageId int64) (*Packet, error) {
+	for {
+		if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+			return nil, err
+		}
+
+		msg, err := ReadPacket(c.reader)
+		if err != nil {
+			return nil, err
+		}
+
+		if !msg.Is(ClassUniversal, TagSequence) || len(msg.Children) < 2 {
+			return nil, errors.New("ldap: invalid message envelope")
+		}
+
+		id := msg.Child(0).Int()
+
+		// unsolicited notification (e.g. notice of disconnection)
+		if id == 0 {
+			if err := ParseResult(msg.Child(1)); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("ldap: unexpected unsolicited notification")
+		}
+
+		if id != messageId {
+			continue // stale response
+		}
+
+		return msg.Child(1), nil
+	}
+}
+
+func prepareTLSConfig(config *tls.Config, host string) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+
+	return config
+}
@@ -0,0 +1,270 @@
+package ldap_test
+
+import (
+	"crypto/tls"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/ldap"
+)
+
+func newTestServer(t *testing.T, useTLS bool) *tests.TestLDAPServer {
+	server, err := tests.NewTestLDAPServer(useTLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	server.AddEntry("cn=admin,dc=example,dc=com", "admin_pass", nil)
+	server.AddEntry("uid=john,ou=people,dc=example,dc=com", "john_pass", map[string][]string{
+		"uid":      {"john"},
+		"cn":       {"John Doe"},
+		"mail":     {"john@example.com"},
+		"memberOf": {"cn=admins,ou=groups,dc=example,dc=com", "cn=devs,ou=groups,dc=example,dc=com"},
+	})
+	server.AddEntry("uid=jane,ou=people,dc=example,dc=com", "jane_pass", map[string][]string{
+		"uid":  {"jane"},
+		"cn":   {"Jane Doe"},
+		"mail": {"jane@example.com"},
+	})
+
+	return server
+}
+
+func TestDialErrors(t *testing.T) {
+	t.Parallel()
+
+	urls := []string{
+		"",
+		"http://127.0.0.1:389",
+		"ldap://",
+		"ldap://127.0.0.1:1", // nothing listening
+	}
+
+	for _, u := range urls {
+		t.Run(u, func(t *testing.T) {
+			conn, err := ldap.Dial(u, nil, time.Second)
+			if err == nil {
+				conn.Close()
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestConnBind(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, false)
+
+	conn, err := ldap.Dial(server.URL(), nil, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	scenarios := []struct {
+		dn       string
+		password string
+		invalid  bool
+	}{
+		{"cn=admin,dc=example,dc=com", "", true}, // empty password (unauthenticated bind)
+		{"cn=admin,dc=example,dc=com", "invalid", true},
+		{"cn=missing,dc=example,dc=com", "admin_pass", true},
+		{"cn=admin,dc=example,dc=com", "admin_pass", false},
+		{"CN=Admin, DC=example, DC=com", "admin_pass", false},
+	}
+
+	for _, s := range scenarios {
+		err := conn.Bind(s.dn, s.password)
+
+		if s.invalid && !errors.Is(err, ldap.ErrInvalidCredentials) {
+			t.Fatalf("[%s:%s] Expected ErrInvalidCredentials, got %v", s.dn, s.password, err)
+		}
+
+		if !s.invalid && err != nil {
+			t.Fatalf("[%s:%s] Expected nil error, got %v", s.dn, s.password, err)
+		}
+	}
+
+	if binds := server.Binds(); len(binds) != 2 {
+		t.Fatalf("Expected 2 successful binds, got %v", binds)
+	}
+}
+
+func TestConnSearch(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, false)
+
+	conn, err := ldap.Dial(server.URL(), nil, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// anonymous search
+	_, err = conn.Search(&ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(uid=john)"})
+	var ldapErr *ldap.Error
+	if !errors.As(err, &ldapErr) || ldapErr.ResultCode != ldap.ResultInsufficientAccess {
+		t.Fatalf("Expected insufficient access error, got %v", err)
+	}
+
+	if err := conn.Bind("cn=admin,dc=example,dc=com", "admin_pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name        string
+		req         *ldap.SearchRequest
+		expectedDNs []string
+		expectedErr int
+	}{
+		{
+			"invalid filter",
+			&ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "uid=john"},
+			nil,
+			-1,
+		},
+		{
+			"single entry",
+			&ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(&(uid=john)(mail=*))"},
+			[]string{"uid=john,ou=people,dc=example,dc=com"},
+			0,
+		},
+		{
+			"substrings match",
+			&ldap.SearchRequest{BaseDN: "ou=people,dc=example,dc=com", Scope: ldap.ScopeSingleLevel, Filter: "(cn=*doe)"},
+			[]string{"uid=john,ou=people,dc=example,dc=com", "uid=jane,ou=people,dc=example,dc=com"},
+			0,
+		},
+		{
+			"single level scope",
+			&ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeSingleLevel, Filter: "(uid=john)"},
+			[]string{},
+			0,
+		},
+		{
+			"size limit",
+			&ldap.SearchRequest{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(uid=*)", SizeLimit: 1},
+			[]string{"uid=john,ou=people,dc=example,dc=com"},
+			ldap.ResultSizeLimitExceeded,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			entries, err := conn.Search(s.req)
+
+			switch s.expectedErr {
+			case 0:
+				if err != nil {
+					t.Fatalf("Expected nil error, got %v", err)
+				}
+			case -1:
+				if err == nil {
+					t.Fatal("Expected error, got nil")
+				}
+				return
+			default:
+				var ldapErr *ldap.Error
+				if !errors.As(err, &ldapErr) || ldapErr.ResultCode != s.expectedErr {
+					t.Fatalf("Expected result code %d, got %v", s.expectedErr, err)
+				}
+			}
+
+			dns := make([]string, 0, len(entries))
+			for _, e := range entries {
+				dns = append(dns, e.DN)
+			}
+
+			if !slices.Equal(dns, s.expectedDNs) {
+				t.Fatalf("Expected entries %v, got %v", s.expectedDNs, dns)
+			}
+		})
+	}
+
+	// attributes
+	entries, err := conn.Search(&ldap.SearchRequest{
+		BaseDN:     "dc=example,dc=com",
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     "(uid=john)",
+		Attributes: []string{"cn", "memberOf"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := entries[0].Value("CN"); v != "John Doe" {
+		t.Fatalf("Expected cn John Doe, got %q", v)
+	}
+
+	if v := entries[0].Values("memberof"); len(v) != 2 {
+		t.Fatalf("Expected 2 memberOf values, got %v", v)
+	}
+
+	if v := entries[0].Value("mail"); v != "" {
+		t.Fatalf("Expected the not requested mail attribute to be missing, got %q", v)
+	}
+}
+
+func TestConnTLS(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ldaps", func(t *testing.T) {
+		server := newTestServer(t, true)
+
+		// self-signed certificate
+		if conn, err := ldap.Dial(server.URL(), nil, 5*time.Second); err == nil {
+			conn.Close()
+			t.Fatal("Expected certificate verification error")
+		}
+
+		conn, err := ldap.Dial(server.URL(), &tls.Config{InsecureSkipVerify: true}, 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		if !conn.IsTLS() {
+			t.Fatal("Expected TLS connection")
+		}
+
+		if err := conn.StartTLS(nil); err == nil {
+			t.Fatal("Expected StartTLS error for already encrypted connection")
+		}
+
+		if err := conn.Bind("uid=john,ou=people,dc=example,dc=com", "john_pass"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("StartTLS", func(t *testing.T) {
+		server := newTestServer(t, false)
+
+		conn, err := ldap.Dial(server.URL(), nil, 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		if conn.IsTLS() {
+			t.Fatal("Expected plain connection")
+		}
+
+		if err := conn.StartTLS(&tls.Config{InsecureSkipVerify: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		if !conn.IsTLS() {
+			t.Fatal("Expected TLS connection after StartTLS")
+		}
+
+		if err := conn.Bind("uid=john,ou=people,dc=example,dc=com", "john_pass"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,242 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Search filter choice tags (context-specific).
+const (
+	FilterAnd              = 0
+	FilterOr               = 1
+	FilterNot              = 2
+	FilterEqualityMatch    = 3
+	FilterSubstrings       = 4
+	FilterGreaterOrEqual   = 5
+	FilterLessOrEqual      = 6
+	FilterPresent          = 7
+	FilterApproxMatch      = 8
+	FilterSubstringInitial = 0
+	FilterSubstringAny     = 1
+	FilterSubstringFinal   = 2
+)
+
+// filterMaxDepth limits the nesting of the compiled filters.
+const filterMaxDepth = 32
+
+// EscapeFilter escapes the special filter characters of a raw
+// assertion value as defined in [RFC 4515].
+//
+// It should be used for any user submitted value that is
+// interpolated in a search filter.
+//
+// [RFC 4515]: https://datatracker.ietf.org/doc/html/rfc4515#section-3
+func EscapeFilter(value string) string {
+	var sb strings.Builder
+	sb.Grow(len(value))
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch c {
+		case '\\', '*', '(', ')', 0:
+			sb.WriteByte('\\')
+			sb.WriteString(hex.EncodeToString([]byte{c}))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
+
+// CompileFilter parses the string representation of a search
+// filter (e.g. "(&(objectClass=person)(uid=test))") and returns
+// its BER encoded form.
+//
+// Extensible match filters are not supported.
+func CompileFilter(filter string) (*Packet, error) {
+	filter = strings.TrimSpace(filter)
+
+	p, pos, err := compileFilter(filter, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if pos != len(filter) {
+		return nil, errors.New("ldap: unexpected data after the filter end")
+	}
+
+	return p, nil
+}
+
+func compileFilter(filter string, pos int, depth int) (*Packet, int, error) {
+	if depth > filterMaxDepth {
+		return nil, 0, errors.New("ldap: max filter nesting depth reached")
+	}
+
+	if pos >= len(filter) || filter[pos] != '(' {
+		return nil, 0, errors.New("ldap: filter must start with (")
+	}
+	pos++
+
+	if pos >= len(filter) {
+		return nil, 0, errors.New("ldap: unexpected filter end")
+	}
+
+	switch filter[pos] {
+	case '&', '|':
+		tag := FilterAnd
+		if filter[pos] == '|' {
+			tag = FilterOr
+		}
+		pos++
+
+		p := NewConstructed(ClassContext, tag)
+		for pos < len(filter) && filter[pos] == '(' {
+			child, next, err := compileFilter(filter, pos, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			p.Children = append(p.Children, child)
+			pos = next
+		}
+
+		if pos >= len(filter) || filter[pos] != ')' {
+			return nil, 0, errors.New("ldap: missing filter closing )")
+		}
+
+		return p, pos + 1, nil
+	case '!':
+		child, next, err := compileFilter(filter, pos+1, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if next >= len(filter) || filter[next] != ')' {
+			return nil, 0, errors.New("ldap: missing filter closing )")
+		}
+
+		return NewConstructed(ClassContext, FilterNot, child), next + 1, nil
+	default:
+		end := strings.IndexByte(filter[pos:], ')')
+		if end < 0 {
+			return nil, 0, errors.New("ldap: missing filter closing )")
+		}
+
+		p, err := compileItem(filter[pos : pos+end])
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return p, pos + end + 1, nil
+	}
+}
+
+func compileItem(item string) (*Packet, error) {
+	eq := strings.IndexByte(item, '=')
+	if eq <= 0 {
+		return nil, errors.New("ldap: invalid filter item " + item)
+	}
+
+	attr := item[:eq]
+	rawValue := item[eq+1:]
+
+	tag := FilterEqualityMatch
+	switch attr[len(attr)-1] {
+	case '>':
+		tag = FilterGreaterOrEqual
+		attr = attr[:len(attr)-1]
+	case '<':
+		tag = FilterLessOrEqual
+		attr = attr[:len(attr)-1]
+	case '~':
+		tag = FilterApproxMatch
+		attr = attr[:len(attr)-1]
+	case ':':
+		return nil, errors.New("ldap: extensible match filters are not supported")
+	}
+
+	if attr == "" || strings.ContainsAny(attr, "()*\\ ") {
+		return nil, errors.New("ldap: invalid filter attribute " + attr)
+	}
+
+	if tag == FilterEqualityMatch {
+		if rawValue == "*" {
+			return NewPacket(ClassContext, FilterPresent, []byte(attr)), nil
+		}
+
+		if strings.Contains(rawValue, "*") {
+			return compileSubstrings(attr, rawValue)
+		}
+	}
+
+	value, err := unescapeFilterValue(rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConstructed(ClassContext, tag, NewOctetString(attr), NewOctetString(value)), nil
+}
+
+func compileSubstrings(attr string, rawValue string) (*Packet, error) {
+	parts := strings.Split(rawValue, "*")
+
+	substrings := NewSequence()
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		value, err := unescapeFilterValue(part)
+		if err != nil {
+			return nil, err
+		}
+
+		tag := FilterSubstringAny
+		switch i {
+		case 0:
+			tag = FilterSubstringInitial
+		case len(parts) - 1:
+			tag = FilterSubstringFinal
+		}
+
+		substrings.Children = append(substrings.Children, NewPacket(ClassContext, tag, []byte(value)))
+	}
+
+	return NewConstructed(ClassContext, FilterSubstrings, NewOctetString(attr), substrings), nil
+}
+
+func unescapeFilterValue(value string) (string, error) {
+	if !strings.Contains(value, "\\") {
+		if strings.ContainsAny(value, "()") {
+			return "", errors.New("ldap: unescaped filter value characters")
+		}
+		return value, nil
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(value))
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch c {
+		case '(', ')':
+			return "", errors.New("ldap: unescaped filter value characters")
+		case '\\':
+			if i+2 >= len(value) {
+				return "", errors.New("ldap: invalid filter value escape sequence")
+			}
+			decoded, err := hex.DecodeString(value[i+1 : i+3])
+			if err != nil {
+				return "", errors.New("ldap: invalid filter value escape sequence")
+			}
+			sb.Write(decoded)
+			i += 2
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,132 @@
+package ldap_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/ldap"
+)
+
+func TestEscapeFilter(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		value    string
+		expected string
+	}{
+		{"", ""},
+		{"john", "john"},
+		{"*)(uid=*", `\2a\29\28uid=\2a`},
+		{`a\b`, `a\5cb`},
+		{"a\x00b", `a\00b`},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.value, func(t *testing.T) {
+			result := ldap.EscapeFilter(s.value)
+			if result != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, result)
+			}
+		})
+	}
+}
+
+func TestCompileFilter(t *testing.T) {
+	t.Parallel()
+
+	eq := func(attr, value string) *ldap.Packet {
+		return ldap.NewConstructed(ldap.ClassContext, ldap.FilterEqualityMatch, ldap.NewOctetString(attr), ldap.NewOctetString(value))
+	}
+
+	scenarios := []struct {
+		filter   string
+		expected *ldap.Packet
+	}{
+		{
+			"(uid=john)",
+			eq("uid", "john"),
+		},
+		{
+			`(cn=a\2a\28b\29)`,
+			eq("cn", "a*(b)"),
+		},
+		{
+			"(objectClass=*)",
+			ldap.NewPacket(ldap.ClassContext, ldap.FilterPresent, []byte("objectClass")),
+		},
+		{
+			"(age>=18)",
+			ldap.NewConstructed(ldap.ClassContext, ldap.FilterGreaterOrEqual, ldap.NewOctetString("age"), ldap.NewOctetString("18")),
+		},
+		{
+			"(age<=18)",
+			ldap.NewConstructed(ldap.ClassContext, ldap.FilterLessOrEqual, ldap.NewOctetString("age"), ldap.NewOctetString("18")),
+		},
+		{
+			"(cn~=jon)",
+			ldap.NewConstructed(ldap.ClassContext, ldap.FilterApproxMatch, ldap.NewOctetString("cn"), ldap.NewOctetString("jon")),
+		},
+		{
+			"(cn=a*b*c)",
+			ldap.NewConstructed(ldap.ClassContext, ldap.FilterSubstrings,
+				ldap.NewOctetString("cn"),
+				ldap.NewSequence(
+					ldap.NewPacket(ldap.ClassContext, ldap.FilterSubstringInitial, []byte("a")),
+					ldap.NewPacket(ldap.ClassContext, ldap.FilterSubstringAny, []byte("b")),
+					ldap.NewPacket(ldap.ClassContext, ldap.FilterSubstringFinal, []byte("c")),
+				),
+			),
+		},
+		{
+			" (&(objectClass=person)(|(uid=john)(!(mail=john@example.com)))) ",
+			ldap.NewConstructed(ldap.ClassContext, ldap.FilterAnd,
+				eq("objectClass", "person"),
+				ldap.NewConstructed(ldap.ClassContext, ldap.FilterOr,
+					eq("uid", "john"),
+					ldap.NewConstructed(ldap.ClassContext, ldap.FilterNot, eq("mail", "john@example.com")),
+				),
+			),
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.filter, func(t *testing.T) {
+			result, err := ldap.CompileFilter(s.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(result.Bytes(), s.expected.Bytes()) {
+				t.Fatalf("Expected\n%x\ngot\n%x", s.expected.Bytes(), result.Bytes())
+			}
+		})
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	filters := []string{
+		"",
+		"uid=john",
+		"(uid=john",
+		"(uid=john))",
+		"(=john)",
+		"(uid)",
+		"(&(uid=john)",
+		"(!(uid=john)",
+		"(uid=jo(hn)",
+		`(uid=john\2)`,
+		`(uid=john\zz)`,
+		"(uid:dn:=john)",
+		"(u id=john)",
+	}
+
+	for _, f := range filters {
+		t.Run(f, func(t *testing.T) {
+			if _, err := ldap.CompileFilter(f); err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
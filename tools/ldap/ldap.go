@@ -0,0 +1,217 @@
+// Package ldap implements a minimal LDAPv3 client ([RFC 4511]) with
+// support for simple bind, search and StartTLS.
+//
+// It covers only what is needed for authenticating users against
+// a directory server (e.g. OpenLDAP or Active Directory).
+//
+// [RFC 4511]: https://datatracker.ietf.org/doc/html/rfc4511
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LDAP protocol operation tags (application class).
+const (
+	ApplicationBindRequest           = 0
+	ApplicationBindResponse          = 1
+	ApplicationUnbindRequest         = 2
+	ApplicationSearchRequest         = 3
+	ApplicationSearchResultEntry     = 4
+	ApplicationSearchResultDone      = 5
+	ApplicationSearchResultReference = 19
+	ApplicationExtendedRequest       = 23
+	ApplicationExtendedResponse      = 24
+)
+
+// Search scopes.
+const (
+	ScopeBaseObject   = 0
+	ScopeSingleLevel  = 1
+	ScopeWholeSubtree = 2
+)
+
+// Common LDAP result codes.
+const (
+	ResultSuccess                = 0
+	ResultOperationsError        = 1
+	ResultProtocolError          = 2
+	ResultSizeLimitExceeded      = 4
+	ResultUnavailableCriticalExt = 12
+	ResultNoSuchObject           = 32
+	ResultInvalidCredentials     = 49
+	ResultInsufficientAccess     = 50
+	ResultUnwillingToPerform     = 53
+)
+
+// OIDStartTLS is the StartTLS extended operation name.
+const OIDStartTLS = "1.3.6.1.4.1.1466.20037"
+
+// ErrInvalidCredentials is returned (or matched with [errors.Is])
+// when the bind credentials are rejected by the server.
+var ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// Error is a non-success LDAP operation result.
+type Error struct {
+	ResultCode int
+	MatchedDN  string
+	Message    string
+}
+
+// Error implements the [error] interface.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ldap: result code %d", e.ResultCode)
+	}
+
+	return fmt.Sprintf("ldap: result code %d: %s", e.ResultCode, e.Message)
+}
+
+// Is reports whether the error matches target
+// (used by [errors.Is] to match [ErrInvalidCredentials]).
+func (e *Error) Is(target error) bool {
+	return target == ErrInvalidCredentials && e.ResultCode == ResultInvalidCredentials
+}
+
+// Entry is a single search result entry.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Values returns all values of the specified attribute
+// (the attribute name is case-insensitive).
+func (e *Entry) Values(name string) []string {
+	if e == nil {
+		return nil
+	}
+
+	if v, ok := e.Attributes[name]; ok {
+		return v
+	}
+
+	for attr, v := range e.Attributes {
+		if strings.EqualFold(attr, name) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// Value returns the first value of the specified attribute
+// (or empty string if the attribute is missing).
+func (e *Entry) Value(name string) string {
+	values := e.Values(name)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// SearchRequest defines the options of a single search operation.
+type SearchRequest struct {
+	BaseDN     string
+	Scope      int
+	Filter     string
+	Attributes []string
+	SizeLimit  int
+	TimeLimit  int
+}
+
+// -------------------------------------------------------------------
+
+// NewMessage wraps a protocol operation in a new LDAPMessage envelope.
+func NewMessage(messageId int64, op *Packet) *Packet {
+	return NewSequence(NewInteger(messageId), op)
+}
+
+// NewResult creates a new LDAPResult based protocol operation packet
+// (e.g. BindResponse, SearchResultDone, etc.).
+func NewResult(application int, resultCode int, matchedDN string, message string) *Packet {
+	return NewConstructed(
+		ClassApplication,
+		application,
+		NewEnumerated(int64(resultCode)),
+		NewOctetString(matchedDN),
+		NewOctetString(message),
+	)
+}
+
+// ParseResult extracts the result code, matched DN and
+// diagnostic message from an LDAPResult based packet.
+//
+// Returns nil if the result code is success.
+func ParseResult(op *Packet) error {
+	if op == nil || len(op.Children) < 3 {
+		return errors.New("ldap: invalid result packet")
+	}
+
+	code := int(op.Child(0).Int())
+	if code == ResultSuccess {
+		return nil
+	}
+
+	return &Error{
+		ResultCode: code,
+		MatchedDN:  op.Child(1).String(),
+		Message:    op.Child(2).String(),
+	}
+}
+
+func newBindRequest(dn string, password string) *Packet {
+	return NewConstructed(
+		ClassApplication,
+		ApplicationBindRequest,
+		NewInteger(3),
+		NewOctetString(dn),
+		NewPacket(ClassContext, 0, []byte(password)), // simple auth
+	)
+}
+
+func newSearchRequest(req *SearchRequest, filter *Packet) *Packet {
+	attributes := NewSequence()
+	for _, attr := range req.Attributes {
+		attributes.Children = append(attributes.Children, NewOctetString(attr))
+	}
+
+	return NewConstructed(
+		ClassApplication,
+		ApplicationSearchRequest,
+		NewOctetString(req.BaseDN),
+		NewEnumerated(int64(req.Scope)),
+		NewEnumerated(0), // never deref aliases
+		NewInteger(int64(req.SizeLimit)),
+		NewInteger(int64(req.TimeLimit)),
+		NewBoolean(false),
+		filter,
+		attributes,
+	)
+}
+
+func parseSearchEntry(op *Packet) (*Entry, error) {
+	if len(op.Children) < 2 {
+		return nil, errors.New("ldap: invalid search entry packet")
+	}
+
+	entry := &Entry{
+		DN:         op.Child(0).String(),
+		Attributes: map[string][]string{},
+	}
+
+	for _, attr := range op.Child(1).Children {
+		if len(attr.Children) < 2 {
+			return nil, errors.New("ldap: invalid search entry attribute")
+		}
+
+		name := attr.Child(0).String()
+		for _, v := range attr.Child(1).Children {
+			entry.Attributes[name] = append(entry.Attributes[name], v.String())
+		}
+	}
+
+	return entry, nil
+}
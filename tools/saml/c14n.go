@@ -0,0 +1,133 @@
+package saml
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Canonicalize serializes the element subtree with the Exclusive XML
+// Canonicalization 1.0 (omits comments) algorithm ([xml-exc-c14n]).
+//
+// inclusivePrefixes is the optional InclusiveNamespaces PrefixList
+// ("#default" stands for the default namespace).
+//
+// exclude is an optional descendant element that will be omitted from
+// the output (e.g. the enveloped signature).
+//
+// [xml-exc-c14n]: https://www.w3.org/TR/xml-exc-c14n/
+func Canonicalize(el *Element, inclusivePrefixes []string, exclude *Element) []byte {
+	inclusive := make(map[string]struct{}, len(inclusivePrefixes))
+	for _, p := range inclusivePrefixes {
+		if p == "#default" {
+			p = ""
+		}
+		inclusive[p] = struct{}{}
+	}
+
+	buf := new(bytes.Buffer)
+	canonicalize(buf, el, map[string]string{}, inclusive, exclude)
+	return buf.Bytes()
+}
+
+// canonicalize writes the canonical form of el into buf.
+//
+// rendered holds the namespace declarations that are already in
+// effect from the output ancestors.
+func canonicalize(buf *bytes.Buffer, el *Element, rendered map[string]string, inclusive map[string]struct{}, exclude *Element) {
+	// collect the visibly utilized prefixes (+ the inclusive ones)
+	prefixes := []string{el.Prefix}
+	for _, attr := range el.Attrs {
+		if attr.Prefix != "" && !slices.Contains(prefixes, attr.Prefix) {
+			prefixes = append(prefixes, attr.Prefix)
+		}
+	}
+	for p := range inclusive {
+		if !slices.Contains(prefixes, p) {
+			if _, ok := el.LookupNamespace(p); ok {
+				prefixes = append(prefixes, p)
+			}
+		}
+	}
+	slices.Sort(prefixes)
+
+	var decls []Attr
+	for _, p := range prefixes {
+		if p == "xml" {
+			continue
+		}
+
+		space, ok := el.LookupNamespace(p)
+		if !ok {
+			continue
+		}
+
+		current, isRendered := rendered[p]
+		if p == "" {
+			// empty default namespace is in effect unless overwritten
+			if current == space {
+				continue
+			}
+		} else if isRendered && current == space {
+			continue
+		}
+
+		decls = append(decls, Attr{Local: p, Value: space})
+	}
+
+	if len(decls) > 0 {
+		rendered = maps.Clone(rendered)
+		for _, decl := range decls {
+			rendered[decl.Local] = decl.Value
+		}
+	}
+
+	attrs := slices.Clone(el.Attrs)
+	slices.SortStableFunc(attrs, func(a, b Attr) int {
+		if c := strings.Compare(a.Space, b.Space); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Local, b.Local)
+	})
+
+	name := el.qname()
+
+	buf.WriteByte('<')
+	buf.WriteString(name)
+
+	for _, decl := range decls {
+		if decl.Local == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + decl.Local + `="`)
+		}
+		escapeAttr(buf, decl.Value)
+		buf.WriteByte('"')
+	}
+
+	for _, attr := range attrs {
+		buf.WriteByte(' ')
+		if attr.Prefix != "" {
+			buf.WriteString(attr.Prefix + ":")
+		}
+		buf.WriteString(attr.Local + `="`)
+		escapeAttr(buf, attr.Value)
+		buf.WriteByte('"')
+	}
+
+	buf.WriteByte('>')
+
+	for _, child := range el.Children {
+		switch c := child.(type) {
+		case *Element:
+			if c != exclude {
+				canonicalize(buf, c, rendered, inclusive, exclude)
+			}
+		case Text:
+			escapeText(buf, string(c))
+		}
+	}
+
+	buf.WriteString("</" + name + ">")
+}
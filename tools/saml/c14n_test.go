@@ -0,0 +1,160 @@
+package saml_test
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/saml"
+)
+
+func TestCanonicalize(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name      string
+		raw       string
+		path      []string // child elements local names path from the root
+		inclusive []string
+		exclude   string // the local name of a direct child to exclude
+		expected  string
+	}{
+		{
+			// https://www.w3.org/TR/xml-exc-c14n/#sec-Enveloping (first example)
+			"spec example 1",
+			"<n0:local xmlns:n0=\"foo:bar\" xmlns:n3=\"ftp://example.org\">\n  <n1:elem2 xmlns:n1=\"http://example.net\" xml:lang=\"en\">\n    <n3:stuff xmlns:n3=\"ftp://example.org\"/>\n  </n1:elem2>\n</n0:local>",
+			[]string{"elem2"},
+			nil,
+			"",
+			"<n1:elem2 xmlns:n1=\"http://example.net\" xml:lang=\"en\">\n    <n3:stuff xmlns:n3=\"ftp://example.org\"></n3:stuff>\n  </n1:elem2>",
+		},
+		{
+			// https://www.w3.org/TR/xml-exc-c14n/#sec-Enveloping (second example)
+			"spec example 2",
+			"<n2:pdu xmlns:n1=\"http://example.com\" xmlns:n2=\"http://foo.example\" xml:lang=\"fr\" xml:space=\"retain\">\n  <n1:elem2 xmlns:n1=\"http://example.net\" xml:lang=\"en\">\n    <n3:stuff xmlns:n3=\"ftp://example.org\"/>\n  </n1:elem2>\n</n2:pdu>",
+			[]string{"elem2"},
+			nil,
+			"",
+			"<n1:elem2 xmlns:n1=\"http://example.net\" xml:lang=\"en\">\n    <n3:stuff xmlns:n3=\"ftp://example.org\"></n3:stuff>\n  </n1:elem2>",
+		},
+		{
+			"inherited prefix declaration",
+			`<a:root xmlns:a="urn:a" xmlns:b="urn:b"><a:child><b:nested/></a:child></a:root>`,
+			[]string{"child"},
+			nil,
+			"",
+			`<a:child xmlns:a="urn:a"><b:nested xmlns:b="urn:b"></b:nested></a:child>`,
+		},
+		{
+			"redundant declarations",
+			`<a:root xmlns:a="urn:a"><a:child xmlns:a="urn:a" xmlns:unused="urn:unused"></a:child></a:root>`,
+			nil,
+			nil,
+			"",
+			`<a:root xmlns:a="urn:a"><a:child></a:child></a:root>`,
+		},
+		{
+			"default namespace",
+			`<root xmlns="urn:a"><child xmlns=""><nested/></child><other/></root>`,
+			nil,
+			nil,
+			"",
+			`<root xmlns="urn:a"><child xmlns=""><nested></nested></child><other></other></root>`,
+		},
+		{
+			"no default namespace undeclaration at the apex",
+			`<root xmlns="urn:a"><child xmlns=""><nested/></child></root>`,
+			[]string{"child", "nested"},
+			nil,
+			"",
+			`<nested></nested>`,
+		},
+		{
+			"attributes and namespaces order and escaping",
+			"<e b=\"2\" a=\"1&quot;&#xA;&#x9;&lt;\" xmlns:z=\"urn:z\" z:c=\"3\" xmlns:y=\"urn:y\" y:d=\"4\">t&gt;&amp;&lt;\"</e>",
+			nil,
+			nil,
+			"",
+			"<e xmlns:y=\"urn:y\" xmlns:z=\"urn:z\" a=\"1&quot;&#xA;&#x9;&lt;\" b=\"2\" y:d=\"4\" z:c=\"3\">t&gt;&amp;&lt;\"</e>",
+		},
+		{
+			"attributes sorted by namespace uri (not prefix)",
+			`<e xmlns:b="urn:1" xmlns:a="urn:2" a:x="1" b:y="2"></e>`,
+			nil,
+			nil,
+			"",
+			`<e xmlns:a="urn:2" xmlns:b="urn:1" b:y="2" a:x="1"></e>`,
+		},
+		{
+			"inclusive prefixes",
+			`<root xmlns:x="urn:x" xmlns:y="urn:y"><s/></root>`,
+			[]string{"s"},
+			[]string{"x", "missing"},
+			"",
+			`<s xmlns:x="urn:x"></s>`,
+		},
+		{
+			"inclusive default namespace",
+			`<root xmlns="urn:x"><p:s xmlns:p="urn:p"/></root>`,
+			[]string{"s"},
+			[]string{"#default"},
+			"",
+			`<p:s xmlns="urn:x" xmlns:p="urn:p"></p:s>`,
+		},
+		{
+			"excluded element",
+			`<root><a>1</a><sig><b/></sig><c>2</c></root>`,
+			nil,
+			nil,
+			"sig",
+			`<root><a>1</a><c>2</c></root>`,
+		},
+		{
+			"comments and processing instructions",
+			"<root><!-- test --><?pi test?><a>1</a></root>",
+			nil,
+			nil,
+			"",
+			`<root><a>1</a></root>`,
+		},
+		{
+			"line endings normalization",
+			"<root>a\r\nb\rc</root>",
+			nil,
+			nil,
+			"",
+			"<root>a\nb\nc</root>",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			el, err := saml.ParseXML([]byte(s.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range s.path {
+				var found *saml.Element
+				for _, child := range el.Children {
+					if c, ok := child.(*saml.Element); ok && c.Local == name {
+						found = c
+						break
+					}
+				}
+				if found == nil {
+					t.Fatalf("Missing %q element", name)
+				}
+				el = found
+			}
+
+			var exclude *saml.Element
+			if s.exclude != "" {
+				exclude = el.Element("", s.exclude)
+			}
+
+			result := string(saml.Canonicalize(el, s.inclusive, exclude))
+			if result != s.expected {
+				t.Fatalf("Expected\n%s\ngot\n%s", s.expected, result)
+			}
+		})
+	}
+}
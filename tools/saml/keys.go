@@ -0,0 +1,62 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// ParseCertificatePEM parses the first "CERTIFICATE" block of the PEM encoded data.
+func ParseCertificatePEM(data string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("saml: missing PEM certificate block")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// ParsePrivateKeyPEM parses a PEM encoded RSA or ECDSA private key
+// (PKCS #1, SEC 1 or PKCS #8).
+func ParsePrivateKeyPEM(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("saml: missing PEM private key block")
+	}
+
+	var key any
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, errors.New("saml: unsupported PEM private key type " + block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, errors.New("saml: only RSA and ECDSA private keys are supported")
+	}
+}
+
+// MatchKeyPair reports whether the certificate public key matches the private key.
+func MatchKeyPair(cert *x509.Certificate, key crypto.Signer) bool {
+	pub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+
+	return ok && pub.Equal(key.Public())
+}
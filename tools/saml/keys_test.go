@@ -0,0 +1,107 @@
+package saml_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/saml"
+)
+
+func TestParseCertificatePEM(t *testing.T) {
+	t.Parallel()
+
+	certPEM, keyPEM, err := tests.NewTestSAMLKeyPair("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name        string
+		data        string
+		expectError bool
+	}{
+		{"empty", "", true},
+		{"invalid", "invalid", true},
+		{"non-certificate block", keyPEM, true},
+		{"valid", certPEM, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			cert, err := saml.ParseCertificatePEM(s.data)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if !hasErr && cert.Subject.CommonName != "test" {
+				t.Fatalf("Expected common name %q, got %q", "test", cert.Subject.CommonName)
+			}
+		})
+	}
+}
+
+func TestParsePrivateKeyPEMAndMatchKeyPair(t *testing.T) {
+	t.Parallel()
+
+	certPEM, keyPEM, err := tests.NewTestSAMLKeyPair("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := saml.ParseCertificatePEM(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, ecCert := newTestECDSACertificate(t)
+
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}))
+
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8PEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DER}))
+
+	scenarios := []struct {
+		name          string
+		data          string
+		cert          *x509.Certificate
+		expectError   bool
+		expectedMatch bool
+	}{
+		{"empty", "", cert, true, false},
+		{"unsupported block", certPEM, cert, true, false},
+		{"PKCS #1 RSA key", keyPEM, cert, false, true},
+		{"SEC 1 ECDSA key", ecPEM, ecCert, false, true},
+		{"PKCS #8 ECDSA key", pkcs8PEM, ecCert, false, true},
+		{"mismatched key pair", ecPEM, cert, false, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			key, err := saml.ParsePrivateKeyPEM(s.data)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if match := saml.MatchKeyPair(s.cert, key); match != s.expectedMatch {
+				t.Fatalf("Expected match %v, got %v", s.expectedMatch, match)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// IdPMetadata holds the identity provider metadata
+// that is needed by the service provider.
+type IdPMetadata struct {
+	// EntityId is the identity provider entity id (aka. the assertions Issuer).
+	EntityId string
+
+	// SSOURL is the HTTP-Redirect binding SingleSignOnService location.
+	SSOURL string
+
+	// Certificates are the trusted signing certificates.
+	Certificates []*x509.Certificate
+}
+
+// ParseMetadata parses the provided identity provider metadata XML document.
+//
+// The document could be a single md:EntityDescriptor or md:EntitiesDescriptor
+// (in which case the first entity with IDPSSODescriptor is used).
+func ParseMetadata(data []byte) (*IdPMetadata, error) {
+	root, err := ParseXML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	entity := findIdPEntity(root)
+	if entity == nil {
+		return nil, errors.New("saml: missing IdP entity descriptor")
+	}
+
+	result := &IdPMetadata{
+		EntityId: entity.Attr("entityID"),
+	}
+	if result.EntityId == "" {
+		return nil, errors.New("saml: missing IdP entityID")
+	}
+
+	idp := entity.Element(NamespaceMetadata, "IDPSSODescriptor")
+
+	for _, sso := range idp.Elements(NamespaceMetadata, "SingleSignOnService") {
+		if sso.Attr("Binding") == BindingHTTPRedirect && sso.Attr("Location") != "" {
+			result.SSOURL = sso.Attr("Location")
+			break
+		}
+	}
+	if result.SSOURL == "" {
+		return nil, errors.New("saml: missing IdP HTTP-Redirect SingleSignOnService")
+	}
+
+	for _, keyDescriptor := range idp.Elements(NamespaceMetadata, "KeyDescriptor") {
+		use := keyDescriptor.Attr("use")
+		if use != "" && use != "signing" {
+			continue
+		}
+
+		x509Data := keyDescriptor.Element(NamespaceDSig, "KeyInfo").Element(NamespaceDSig, "X509Data")
+
+		for _, rawCert := range x509Data.Elements(NamespaceDSig, "X509Certificate") {
+			der, err := decodeBase64(rawCert.Text())
+			if err != nil {
+				return nil, errors.New("saml: invalid IdP certificate encoding")
+			}
+
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, errors.New("saml: invalid IdP certificate")
+			}
+
+			result.Certificates = append(result.Certificates, cert)
+		}
+	}
+	if len(result.Certificates) == 0 {
+		return nil, errors.New("saml: missing IdP signing certificate")
+	}
+
+	return result, nil
+}
+
+func findIdPEntity(el *Element) *Element {
+	switch {
+	case el.Is(NamespaceMetadata, "EntityDescriptor"):
+		if el.Element(NamespaceMetadata, "IDPSSODescriptor") != nil {
+			return el
+		}
+	case el.Is(NamespaceMetadata, "EntitiesDescriptor"):
+		for _, child := range el.Children {
+			if c, ok := child.(*Element); ok {
+				if entity := findIdPEntity(c); entity != nil {
+					return entity
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Metadata returns the service provider metadata XML document.
+func (sp *ServiceProvider) Metadata() []byte {
+	var sb strings.Builder
+
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	sb.WriteString(`<md:EntityDescriptor xmlns:md="` + NamespaceMetadata + `" entityID="` + escapeString(sp.EntityId) + `">`)
+	sb.WriteString(`<md:SPSSODescriptor AuthnRequestsSigned="true" WantAssertionsSigned="true" protocolSupportEnumeration="` + NamespaceProtocol + `">`)
+
+	if sp.Certificate != nil {
+		sb.WriteString(`<md:KeyDescriptor use="signing">`)
+		sb.WriteString(`<ds:KeyInfo xmlns:ds="` + NamespaceDSig + `"><ds:X509Data><ds:X509Certificate>`)
+		sb.WriteString(base64.StdEncoding.EncodeToString(sp.Certificate.Raw))
+		sb.WriteString(`</ds:X509Certificate></ds:X509Data></ds:KeyInfo>`)
+		sb.WriteString(`</md:KeyDescriptor>`)
+	}
+
+	sb.WriteString(`<md:AssertionConsumerService Binding="` + BindingHTTPPost + `" Location="` + escapeString(sp.ACSURL) + `" index="0" isDefault="true"></md:AssertionConsumerService>`)
+	sb.WriteString(`</md:SPSSODescriptor>`)
+	sb.WriteString(`</md:EntityDescriptor>`)
+
+	return []byte(sb.String())
+}
@@ -0,0 +1,434 @@
+// Package saml implements a minimal SAML 2.0 service provider
+// for the Web Browser SSO profile.
+//
+// It supports SP-initiated login with signed AuthnRequest (HTTP-Redirect
+// binding) and SP or IdP-initiated responses (HTTP-POST binding) with
+// signed Response and/or Assertion.
+//
+// Encrypted assertions, single logout and the SHA1 based signature
+// algorithms are not supported.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SAML namespaces, bindings and common identifiers.
+const (
+	NamespaceProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	NamespaceAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	NamespaceMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	BindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	BindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+
+	StatusSuccess = "urn:oasis:names:tc:SAML:2.0:status:Success"
+
+	NameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+	NameIDFormatEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	NameIDFormatPersistent  = "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"
+	NameIDFormatTransient   = "urn:oasis:names:tc:SAML:2.0:nameid-format:transient"
+
+	SubjectConfirmationBearer = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+)
+
+// DefaultClockSkew is the default allowed clock difference
+// between the service and the identity provider.
+const DefaultClockSkew = 3 * time.Minute
+
+// ErrEncryptedAssertion is returned for responses with encrypted assertions.
+var ErrEncryptedAssertion = errors.New("saml: encrypted assertions are not supported")
+
+// ServiceProvider defines a single SAML service provider
+// and its trusted identity provider.
+type ServiceProvider struct {
+	// EntityId is the service provider entity id
+	// (aka. the AuthnRequest Issuer and the assertions Audience).
+	EntityId string
+
+	// ACSURL is the AssertionConsumerService (HTTP-POST) url.
+	ACSURL string
+
+	// Key is the private key used to sign the AuthnRequests.
+	Key crypto.Signer
+
+	// Certificate is the public certificate of Key.
+	Certificate *x509.Certificate
+
+	// IdP is the trusted identity provider metadata.
+	IdP *IdPMetadata
+
+	// ClockSkew is the allowed clock difference (fallbacks to [DefaultClockSkew]).
+	ClockSkew time.Duration
+
+	// Now returns the current time (fallbacks to [time.Now]).
+	Now func() time.Time
+}
+
+// NewRequestId generates a new random SAML request/assertion id.
+func NewRequestId() string {
+	b := make([]byte, 20)
+	_, _ = rand.Read(b)
+
+	// the id must be a valid NCName (aka. cannot start with a digit)
+	return "_" + hex.EncodeToString(b)
+}
+
+// AuthnRequestURL returns the IdP SingleSignOnService url with a
+// signed AuthnRequest (HTTP-Redirect binding) with the specified id.
+func (sp *ServiceProvider) AuthnRequestURL(requestId string, relayState string) (string, error) {
+	if sp.IdP == nil || sp.IdP.SSOURL == "" {
+		return "", errors.New("saml: missing IdP SingleSignOnService url")
+	}
+
+	if sp.Key == nil {
+		return "", errors.New("saml: missing SP signing key")
+	}
+
+	algorithm, err := signatureAlgorithm(sp.Key)
+	if err != nil {
+		return "", err
+	}
+
+	request := `<samlp:AuthnRequest xmlns:samlp="` + NamespaceProtocol + `" xmlns:saml="` + NamespaceAssertion + `"` +
+		` ID="` + escapeString(requestId) + `"` +
+		` Version="2.0"` +
+		` IssueInstant="` + sp.now().UTC().Format(time.RFC3339) + `"` +
+		` Destination="` + escapeString(sp.IdP.SSOURL) + `"` +
+		` AssertionConsumerServiceURL="` + escapeString(sp.ACSURL) + `"` +
+		` ProtocolBinding="` + BindingHTTPPost + `">` +
+		`<saml:Issuer>` + escapeString(sp.EntityId) + `</saml:Issuer>` +
+		`<samlp:NameIDPolicy AllowCreate="true"></samlp:NameIDPolicy>` +
+		`</samlp:AuthnRequest>`
+
+	deflated := new(bytes.Buffer)
+	w, err := flate.NewWriter(deflated, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write([]byte(request)); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	// the signature is over the url encoded query params in this exact order
+	// (https://docs.oasis-open.org/security/saml/v2.0/saml-bindings-2.0-os.pdf, section 3.4.4.1)
+	query := "SAMLRequest=" + url.QueryEscape(base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		query += "&RelayState=" + url.QueryEscape(relayState)
+	}
+	query += "&SigAlg=" + url.QueryEscape(algorithm)
+
+	signature, err := signBytes(sp.Key, algorithm, []byte(query))
+	if err != nil {
+		return "", err
+	}
+
+	query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+
+	separator := "?"
+	if strings.Contains(sp.IdP.SSOURL, "?") {
+		separator = "&"
+	}
+
+	return sp.IdP.SSOURL + separator + query, nil
+}
+
+// ParseResponse decodes and validates the base64 encoded SAMLResponse
+// (HTTP-POST binding) and returns its verified assertion.
+//
+// requestId is the id of the related AuthnRequest. Leave it empty to
+// accept only unsolicited (aka. IdP-initiated) responses.
+//
+// The Response or the Assertion must be signed by one of the IdP certificates.
+func (sp *ServiceProvider) ParseResponse(encodedResponse string, requestId string) (*Assertion, error) {
+	if sp.IdP == nil {
+		return nil, errors.New("saml: missing IdP metadata")
+	}
+
+	raw, err := decodeBase64(encodedResponse)
+	if err != nil {
+		return nil, errors.New("saml: invalid response encoding")
+	}
+
+	response, err := ParseXML(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Is(NamespaceProtocol, "Response") {
+		return nil, errors.New("saml: invalid response root element")
+	}
+
+	if response.Attr("Version") != "2.0" {
+		return nil, errors.New("saml: unsupported response version")
+	}
+
+	if destination := response.Attr("Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, errors.New("saml: response destination mismatch")
+	}
+
+	if response.Attr("InResponseTo") != requestId {
+		return nil, errors.New("saml: response InResponseTo mismatch")
+	}
+
+	if issuer := response.Element(NamespaceAssertion, "Issuer"); issuer != nil && issuer.Text() != sp.IdP.EntityId {
+		return nil, errors.New("saml: response issuer mismatch")
+	}
+
+	statusCode := response.Element(NamespaceProtocol, "Status").Element(NamespaceProtocol, "StatusCode")
+	if status := statusCode.Attr("Value"); status != StatusSuccess {
+		message := response.Element(NamespaceProtocol, "Status").Element(NamespaceProtocol, "StatusMessage").Text()
+		return nil, fmt.Errorf("saml: unsuccessful response status %q: %s", status, message)
+	}
+
+	var isSigned bool
+
+	if len(response.Elements(NamespaceDSig, "Signature")) > 0 {
+		if err := VerifySignature(response, sp.IdP.Certificates); err != nil {
+			return nil, err
+		}
+		isSigned = true
+	}
+
+	if len(response.Elements(NamespaceAssertion, "EncryptedAssertion")) > 0 {
+		return nil, ErrEncryptedAssertion
+	}
+
+	// note: only the direct children of the already verified
+	// element are used to prevent signature wrapping attacks
+	assertions := response.Elements(NamespaceAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, errors.New("saml: the response must have exactly one assertion")
+	}
+	assertion := assertions[0]
+
+	if len(assertion.Elements(NamespaceDSig, "Signature")) > 0 {
+		if err := VerifySignature(assertion, sp.IdP.Certificates); err != nil {
+			return nil, err
+		}
+		isSigned = true
+	}
+
+	if !isSigned {
+		return nil, ErrMissingSignature
+	}
+
+	return sp.parseAssertion(assertion, requestId)
+}
+
+func (sp *ServiceProvider) parseAssertion(el *Element, requestId string) (*Assertion, error) {
+	now := sp.now()
+	skew := sp.ClockSkew
+	if skew <= 0 {
+		skew = DefaultClockSkew
+	}
+
+	if el.Attr("Version") != "2.0" {
+		return nil, errors.New("saml: unsupported assertion version")
+	}
+
+	result := &Assertion{
+		ID:           el.Attr("ID"),
+		InResponseTo: requestId,
+		Attributes:   map[string][]string{},
+	}
+
+	if result.ID == "" {
+		return nil, errors.New("saml: missing assertion ID")
+	}
+
+	result.Issuer = el.Element(NamespaceAssertion, "Issuer").Text()
+	if result.Issuer != sp.IdP.EntityId {
+		return nil, errors.New("saml: assertion issuer mismatch")
+	}
+
+	// subject
+	// ---
+	subject := el.Element(NamespaceAssertion, "Subject")
+
+	nameId := subject.Element(NamespaceAssertion, "NameID")
+	result.NameID = nameId.Text()
+	result.NameIDFormat = nameId.Attr("Format")
+	if result.NameID == "" {
+		return nil, errors.New("saml: missing assertion subject NameID")
+	}
+
+	var hasValidConfirmation bool
+	for _, confirmation := range subject.Elements(NamespaceAssertion, "SubjectConfirmation") {
+		if confirmation.Attr("Method") != SubjectConfirmationBearer {
+			continue
+		}
+
+		data := confirmation.Element(NamespaceAssertion, "SubjectConfirmationData")
+		if data == nil ||
+			data.Attr("Recipient") != sp.ACSURL ||
+			data.Attr("InResponseTo") != requestId {
+			continue
+		}
+
+		notOnOrAfter, err := parseTime(data.Attr("NotOnOrAfter"))
+		if err != nil || !now.Before(notOnOrAfter.Add(skew)) {
+			continue
+		}
+
+		if data.HasAttr("NotBefore") {
+			notBefore, err := parseTime(data.Attr("NotBefore"))
+			if err != nil || now.Add(skew).Before(notBefore) {
+				continue
+			}
+		}
+
+		hasValidConfirmation = true
+		result.NotOnOrAfter = notOnOrAfter
+		break
+	}
+	if !hasValidConfirmation {
+		return nil, errors.New("saml: missing or invalid assertion bearer subject confirmation")
+	}
+
+	// conditions
+	// ---
+	conditions := el.Element(NamespaceAssertion, "Conditions")
+	if conditions == nil {
+		return nil, errors.New("saml: missing assertion conditions")
+	}
+
+	if conditions.HasAttr("NotBefore") {
+		notBefore, err := parseTime(conditions.Attr("NotBefore"))
+		if err != nil || now.Add(skew).Before(notBefore) {
+			return nil, errors.New("saml: the assertion is not yet valid")
+		}
+	}
+
+	if conditions.HasAttr("NotOnOrAfter") {
+		notOnOrAfter, err := parseTime(conditions.Attr("NotOnOrAfter"))
+		if err != nil || !now.Before(notOnOrAfter.Add(skew)) {
+			return nil, errors.New("saml: the assertion has expired")
+		}
+		if notOnOrAfter.Before(result.NotOnOrAfter) {
+			result.NotOnOrAfter = notOnOrAfter
+		}
+	}
+
+	restrictions := conditions.Elements(NamespaceAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return nil, errors.New("saml: missing assertion audience restriction")
+	}
+	for _, restriction := range restrictions {
+		var hasAudience bool
+		for _, audience := range restriction.Elements(NamespaceAssertion, "Audience") {
+			if audience.Text() == sp.EntityId {
+				hasAudience = true
+				break
+			}
+		}
+		if !hasAudience {
+			return nil, errors.New("saml: assertion audience mismatch")
+		}
+	}
+
+	// authn statement
+	// ---
+	authnStatement := el.Element(NamespaceAssertion, "AuthnStatement")
+	if authnStatement == nil {
+		return nil, errors.New("saml: missing assertion authn statement")
+	}
+	result.SessionIndex = authnStatement.Attr("SessionIndex")
+
+	// attributes
+	// ---
+	for _, statement := range el.Elements(NamespaceAssertion, "AttributeStatement") {
+		for _, attr := range statement.Elements(NamespaceAssertion, "Attribute") {
+			var values []string
+			for _, v := range attr.Elements(NamespaceAssertion, "AttributeValue") {
+				values = append(values, v.Text())
+			}
+
+			if name := attr.Attr("Name"); name != "" {
+				result.Attributes[name] = append(result.Attributes[name], values...)
+			}
+
+			// register also the friendly name alias (if not already used)
+			if name := attr.Attr("FriendlyName"); name != "" && attr.Attr("Name") != name {
+				if _, exists := result.Attributes[name]; !exists {
+					result.Attributes[name] = values
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (sp *ServiceProvider) now() time.Time {
+	if sp.Now != nil {
+		return sp.Now()
+	}
+
+	return time.Now()
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// -------------------------------------------------------------------
+
+// Assertion holds the verified SAML assertion data.
+type Assertion struct {
+	// ID is the assertion id (could be used for replay detection).
+	ID string `json:"id"`
+
+	// Issuer is the identity provider entity id.
+	Issuer string `json:"issuer"`
+
+	// InResponseTo is the related AuthnRequest id (empty for IdP-initiated logins).
+	InResponseTo string `json:"inResponseTo"`
+
+	NameID       string `json:"nameId"`
+	NameIDFormat string `json:"nameIdFormat"`
+
+	// SessionIndex is the IdP session index of the authn statement.
+	SessionIndex string `json:"sessionIndex"`
+
+	// NotOnOrAfter is the time after which the assertion could no longer be used.
+	NotOnOrAfter time.Time `json:"notOnOrAfter"`
+
+	// Attributes is a map with the assertion attributes values
+	// (indexed by their Name and FriendlyName).
+	Attributes map[string][]string `json:"attributes"`
+}
+
+// Values returns all values of the specified attribute.
+func (a *Assertion) Values(name string) []string {
+	if a == nil {
+		return nil
+	}
+
+	return a.Attributes[name]
+}
+
+// Value returns the first value of the specified attribute
+// (or empty string if the attribute is missing).
+func (a *Assertion) Value(name string) string {
+	values := a.Values(name)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
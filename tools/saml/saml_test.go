@@ -0,0 +1,518 @@
+package saml_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/saml"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Parallel()
+
+	idp, err := tests.NewTestSAMLIdP("https://idp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := idp.Metadata()
+
+	scenarios := []struct {
+		name        string
+		raw         string
+		expectError bool
+	}{
+		{"invalid xml", "<test", true},
+		{"non metadata document", "<test></test>", true},
+		{
+			"missing entityID",
+			strings.Replace(valid, `entityID="https://idp.example.com"`, "", 1),
+			true,
+		},
+		{
+			"missing IDPSSODescriptor",
+			strings.ReplaceAll(valid, "IDPSSODescriptor", "SPSSODescriptor"),
+			true,
+		},
+		{
+			"missing HTTP-Redirect binding",
+			strings.Replace(valid, saml.BindingHTTPRedirect, saml.BindingHTTPPost, 1),
+			true,
+		},
+		{
+			"encryption only key",
+			strings.Replace(valid, `use="signing"`, `use="encryption"`, 1),
+			true,
+		},
+		{
+			"invalid certificate",
+			strings.Replace(valid, "<ds:X509Certificate>", "<ds:X509Certificate>AAAA", 1),
+			true,
+		},
+		{"valid entity descriptor", valid, false},
+		{
+			"key descriptor without use",
+			strings.Replace(valid, ` use="signing"`, "", 1),
+			false,
+		},
+		{
+			"entities descriptor",
+			`<md:EntitiesDescriptor xmlns:md="` + saml.NamespaceMetadata + `">` +
+				`<md:EntityDescriptor entityID="https://sp.example.com"><md:SPSSODescriptor/></md:EntityDescriptor>` +
+				strings.TrimPrefix(valid, `<?xml version="1.0" encoding="UTF-8"?>`) +
+				`</md:EntitiesDescriptor>`,
+			false,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			metadata, err := saml.ParseMetadata([]byte(s.raw))
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if metadata.EntityId != idp.EntityId {
+				t.Fatalf("Expected entity id %q, got %q", idp.EntityId, metadata.EntityId)
+			}
+
+			if metadata.SSOURL != idp.SSOURL {
+				t.Fatalf("Expected SSO url %q, got %q", idp.SSOURL, metadata.SSOURL)
+			}
+
+			if len(metadata.Certificates) != 1 || !metadata.Certificates[0].Equal(idp.Certificate) {
+				t.Fatalf("Expected the IdP certificate, got %v", metadata.Certificates)
+			}
+		})
+	}
+}
+
+func TestServiceProviderMetadata(t *testing.T) {
+	t.Parallel()
+
+	sp := newTestServiceProvider(t, nil)
+
+	root, err := saml.ParseXML(sp.Metadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !root.Is(saml.NamespaceMetadata, "EntityDescriptor") || root.Attr("entityID") != sp.EntityId {
+		t.Fatalf("Invalid metadata root element %v", root)
+	}
+
+	descriptor := root.Element(saml.NamespaceMetadata, "SPSSODescriptor")
+	if descriptor.Attr("AuthnRequestsSigned") != "true" {
+		t.Fatal("Expected AuthnRequestsSigned=true")
+	}
+
+	acs := descriptor.Element(saml.NamespaceMetadata, "AssertionConsumerService")
+	if acs.Attr("Location") != sp.ACSURL || acs.Attr("Binding") != saml.BindingHTTPPost {
+		t.Fatalf("Invalid AssertionConsumerService %v", acs.Attrs)
+	}
+
+	cert := descriptor.Element(saml.NamespaceMetadata, "KeyDescriptor").
+		Element(saml.NamespaceDSig, "KeyInfo").
+		Element(saml.NamespaceDSig, "X509Data").
+		Element(saml.NamespaceDSig, "X509Certificate").Text()
+	if cert != base64.StdEncoding.EncodeToString(sp.Certificate.Raw) {
+		t.Fatal("Expected the SP certificate to be in the metadata")
+	}
+}
+
+func TestServiceProviderAuthnRequestURL(t *testing.T) {
+	t.Parallel()
+
+	sp := newTestServiceProvider(t, nil)
+	sp.IdP.SSOURL = "https://idp.example.com/sso?tenant=1"
+
+	rawURL, err := sp.AuthnRequestURL("_test_id", "test_state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(rawURL, "https://idp.example.com/sso?tenant=1&SAMLRequest=") {
+		t.Fatalf("Unexpected url %q", rawURL)
+	}
+
+	// verify the signature
+	rawQuery := rawURL[strings.Index(rawURL, "?")+1:]
+	signedPart := rawQuery[strings.Index(rawQuery, "SAMLRequest="):strings.Index(rawQuery, "&Signature=")]
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := query.Get("RelayState"); v != "test_state" {
+		t.Fatalf("Expected RelayState %q, got %q", "test_state", v)
+	}
+
+	if v := query.Get("SigAlg"); v != saml.AlgorithmRSASHA256 {
+		t.Fatalf("Expected SigAlg %q, got %q", saml.AlgorithmRSASHA256, v)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(query.Get("Signature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashed := sha256.Sum256([]byte(signedPart))
+	if err := rsa.VerifyPKCS1v15(sp.Certificate.PublicKey.(*rsa.PublicKey), crypto.SHA256, hashed[:], signature); err != nil {
+		t.Fatalf("Invalid AuthnRequest signature: %v", err)
+	}
+
+	// inflate the request
+	deflated, err := base64.StdEncoding.DecodeString(query.Get("SAMLRequest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := saml.ParseXML(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !request.Is(saml.NamespaceProtocol, "AuthnRequest") {
+		t.Fatalf("Expected AuthnRequest, got %s", request.Local)
+	}
+
+	expectedAttrs := map[string]string{
+		"ID":                          "_test_id",
+		"Version":                     "2.0",
+		"Destination":                 sp.IdP.SSOURL,
+		"AssertionConsumerServiceURL": sp.ACSURL,
+		"ProtocolBinding":             saml.BindingHTTPPost,
+	}
+	for name, expected := range expectedAttrs {
+		if v := request.Attr(name); v != expected {
+			t.Fatalf("Expected %s %q, got %q", name, expected, v)
+		}
+	}
+
+	if v := request.Element(saml.NamespaceAssertion, "Issuer").Text(); v != sp.EntityId {
+		t.Fatalf("Expected Issuer %q, got %q", sp.EntityId, v)
+	}
+}
+
+func TestServiceProviderParseResponse(t *testing.T) {
+	t.Parallel()
+
+	idp, err := tests.NewTestSAMLIdP("https://idp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherIdP, err := tests.NewTestSAMLIdP("https://idp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp := newTestServiceProvider(t, idp)
+
+	now := time.Now()
+
+	baseOptions := func() tests.TestSAMLResponse {
+		return tests.TestSAMLResponse{
+			ACSURL:        sp.ACSURL,
+			Audience:      sp.EntityId,
+			InResponseTo:  "_request",
+			AssertionId:   "_assertion",
+			NameID:        "user123",
+			SessionIndex:  "_session",
+			SignAssertion: true,
+			Attributes: map[string][]string{
+				"email":  {"test@example.com"},
+				"groups": {"a", "b"},
+			},
+		}
+	}
+
+	scenarios := []struct {
+		name          string
+		options       func(opts *tests.TestSAMLResponse)
+		modify        func(raw string) string
+		requestId     string
+		expectedError error
+	}{
+		{
+			name:      "valid signed assertion",
+			requestId: "_request",
+		},
+		{
+			name: "valid signed response",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.SignAssertion = false
+				opts.SignResponse = true
+			},
+			requestId: "_request",
+		},
+		{
+			name: "valid signed response and assertion",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.SignResponse = true
+			},
+			requestId: "_request",
+		},
+		{
+			name: "valid unsolicited response",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.InResponseTo = ""
+			},
+			requestId: "",
+		},
+		{
+			name: "unsigned",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.SignAssertion = false
+			},
+			requestId:     "_request",
+			expectedError: saml.ErrMissingSignature,
+		},
+		{
+			name: "signed by untrusted key",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.SigningKey = otherIdP.Key
+			},
+			requestId:     "_request",
+			expectedError: saml.ErrInvalidSignature,
+		},
+		{
+			name: "modified signed assertion",
+			modify: func(raw string) string {
+				return strings.Replace(raw, "user123", "admin", 1)
+			},
+			requestId:     "_request",
+			expectedError: saml.ErrInvalidSignature,
+		},
+		{
+			name: "wrapped assertion (unsigned assertion next to the signed one)",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.SignAssertion = true
+			},
+			modify: func(raw string) string {
+				start := strings.Index(raw, "<saml:Assertion")
+				end := strings.Index(raw, "</saml:Assertion>") + len("</saml:Assertion>")
+				evil := strings.Replace(raw[start:end], "user123", "admin", 1)
+				evil = strings.Replace(evil, `ID="_assertion"`, `ID="_evil"`, 1)
+				return raw[:start] + evil + raw[start:]
+			},
+			requestId: "_request",
+		},
+		{
+			name: "unsolicited response when request is expected",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.InResponseTo = ""
+			},
+			requestId: "_request",
+		},
+		{
+			name:      "solicited response when unsolicited is expected",
+			requestId: "",
+		},
+		{
+			name:      "InResponseTo mismatch",
+			requestId: "_other",
+		},
+		{
+			name: "wrong destination",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.ACSURL = "https://other.example.com/acs"
+			},
+			requestId: "_request",
+		},
+		{
+			name: "wrong audience",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.Audience = "https://other.example.com"
+			},
+			requestId: "_request",
+		},
+		{
+			name: "wrong issuer",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.Issuer = "https://other.example.com"
+			},
+			requestId: "_request",
+		},
+		{
+			name: "expired assertion",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.IssueInstant = now.Add(-1 * time.Hour)
+			},
+			requestId: "_request",
+		},
+		{
+			name: "not yet valid assertion",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.IssueInstant = now.Add(1 * time.Hour)
+			},
+			requestId: "_request",
+		},
+		{
+			name: "expired assertion within the clock skew",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.IssueInstant = now.Add(-6 * time.Minute)
+			},
+			requestId: "_request",
+		},
+		{
+			name: "unsuccessful status",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.Status = "urn:oasis:names:tc:SAML:2.0:status:Requester"
+			},
+			requestId: "_request",
+		},
+		{
+			name: "missing NameID",
+			options: func(opts *tests.TestSAMLResponse) {
+				opts.NameID = ""
+			},
+			requestId: "_request",
+		},
+		{
+			name: "encrypted assertion",
+			modify: func(raw string) string {
+				return strings.Replace(raw, "</samlp:Status>", "</samlp:Status><saml:EncryptedAssertion></saml:EncryptedAssertion>", 1)
+			},
+			requestId:     "_request",
+			expectedError: saml.ErrEncryptedAssertion,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			opts := baseOptions()
+			if s.options != nil {
+				s.options(&opts)
+			}
+
+			encoded, err := idp.NewResponse(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.modify != nil {
+				raw, _ := base64.StdEncoding.DecodeString(encoded)
+				encoded = base64.StdEncoding.EncodeToString([]byte(s.modify(string(raw))))
+			}
+
+			assertion, err := sp.ParseResponse(encoded, s.requestId)
+
+			isValid := strings.HasPrefix(s.name, "valid") || s.name == "expired assertion within the clock skew"
+
+			if !isValid {
+				if err == nil {
+					t.Fatal("Expected error, got nil")
+				}
+				if s.expectedError != nil && !errors.Is(err, s.expectedError) {
+					t.Fatalf("Expected error %v, got %v", s.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected nil error, got %v", err)
+			}
+
+			if assertion.ID != "_assertion" {
+				t.Fatalf("Expected assertion id %q, got %q", "_assertion", assertion.ID)
+			}
+
+			if assertion.Issuer != idp.EntityId {
+				t.Fatalf("Expected issuer %q, got %q", idp.EntityId, assertion.Issuer)
+			}
+
+			if assertion.InResponseTo != s.requestId {
+				t.Fatalf("Expected InResponseTo %q, got %q", s.requestId, assertion.InResponseTo)
+			}
+
+			if assertion.NameID != "user123" || assertion.NameIDFormat != saml.NameIDFormatPersistent {
+				t.Fatalf("Unexpected NameID %q (%q)", assertion.NameID, assertion.NameIDFormat)
+			}
+
+			if assertion.SessionIndex != "_session" {
+				t.Fatalf("Expected session index %q, got %q", "_session", assertion.SessionIndex)
+			}
+
+			if v := assertion.Value("email"); v != "test@example.com" {
+				t.Fatalf("Expected email attribute %q, got %q", "test@example.com", v)
+			}
+
+			if v := strings.Join(assertion.Values("groups"), ","); v != "a,b" {
+				t.Fatalf("Expected groups attribute %q, got %q", "a,b", v)
+			}
+
+			if v := assertion.Value("missing"); v != "" {
+				t.Fatalf("Expected missing attribute to be empty, got %q", v)
+			}
+
+			if assertion.NotOnOrAfter.IsZero() {
+				t.Fatal("Expected NotOnOrAfter to be set")
+			}
+		})
+	}
+}
+
+func TestServiceProviderParseResponseInvalidEncoding(t *testing.T) {
+	t.Parallel()
+
+	sp := newTestServiceProvider(t, nil)
+
+	for _, encoded := range []string{"", "!invalid!", base64.StdEncoding.EncodeToString([]byte("<test"))} {
+		if _, err := sp.ParseResponse(encoded, ""); err == nil {
+			t.Fatalf("Expected error for %q", encoded)
+		}
+	}
+}
+
+func newTestServiceProvider(t *testing.T, idp *tests.TestSAMLIdP) *saml.ServiceProvider {
+	if idp == nil {
+		var err error
+		idp, err = tests.NewTestSAMLIdP("https://idp.example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metadata, err := saml.ParseMetadata([]byte(idp.Metadata()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reuse the IdP key generation helper for the SP key pair
+	spIdP, err := tests.NewTestSAMLIdP("https://sp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &saml.ServiceProvider{
+		EntityId:    "https://sp.example.com",
+		ACSURL:      "https://sp.example.com/acs",
+		Key:         spIdP.Key,
+		Certificate: spIdP.Certificate,
+		IdP:         metadata,
+	}
+}
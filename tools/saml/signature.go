@@ -0,0 +1,346 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+)
+
+// XML signature related namespaces and algorithm identifiers.
+const (
+	NamespaceDSig = "http://www.w3.org/2000/09/xmldsig#"
+
+	AlgorithmExcC14N         = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	AlgorithmEnvelopedSig    = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	AlgorithmSHA256          = "http://www.w3.org/2001/04/xmlenc#sha256"
+	AlgorithmSHA512          = "http://www.w3.org/2001/04/xmlenc#sha512"
+	AlgorithmRSASHA256       = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	AlgorithmRSASHA512       = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	AlgorithmECDSASHA256     = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	AlgorithmECDSASHA512     = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512"
+	namespaceExcC14NPrefixes = "http://www.w3.org/2001/10/xml-exc-c14n#"
+)
+
+// Common signature errors.
+var (
+	ErrMissingSignature = errors.New("saml: missing signature")
+	ErrInvalidSignature = errors.New("saml: invalid signature")
+)
+
+// VerifySignature verifies the enveloped XML signature of the
+// provided element with one of the trusted certificates.
+//
+// Only a single same-document reference to the element itself
+// with Exclusive XML Canonicalization is accepted. SHA1 based
+// algorithms are rejected.
+//
+// The ds:KeyInfo of the signature is ignored.
+func VerifySignature(el *Element, certs []*x509.Certificate) error {
+	signatures := el.Elements(NamespaceDSig, "Signature")
+	if len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+	if len(signatures) > 1 {
+		return errors.New("saml: multiple signatures")
+	}
+
+	signature := signatures[0]
+
+	signedInfo := signature.Element(NamespaceDSig, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("saml: missing SignedInfo")
+	}
+
+	// canonicalization method
+	// ---
+	c14nMethod := signedInfo.Element(NamespaceDSig, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.Attr("Algorithm") != AlgorithmExcC14N {
+		return errors.New("saml: unsupported signature canonicalization method")
+	}
+
+	// reference
+	// ---
+	references := signedInfo.Elements(NamespaceDSig, "Reference")
+	if len(references) != 1 {
+		return errors.New("saml: the signature must have exactly one reference")
+	}
+	reference := references[0]
+
+	id := el.Attr("ID")
+	if id == "" || reference.Attr("URI") != "#"+id {
+		return errors.New("saml: the signature reference doesn't match the signed element")
+	}
+
+	var refInclusivePrefixes []string
+	var hasC14NTransform bool
+	for _, transform := range reference.Element(NamespaceDSig, "Transforms").Elements(NamespaceDSig, "Transform") {
+		switch transform.Attr("Algorithm") {
+		case AlgorithmEnvelopedSig:
+		case AlgorithmExcC14N:
+			hasC14NTransform = true
+			refInclusivePrefixes = inclusivePrefixes(transform)
+		default:
+			return errors.New("saml: unsupported signature reference transform")
+		}
+	}
+	if !hasC14NTransform {
+		return errors.New("saml: missing signature reference canonicalization transform")
+	}
+
+	digestHash, err := digestMethodHash(reference.Element(NamespaceDSig, "DigestMethod").Attr("Algorithm"))
+	if err != nil {
+		return err
+	}
+
+	expectedDigest, err := decodeBase64(reference.Element(NamespaceDSig, "DigestValue").Text())
+	if err != nil {
+		return errors.New("saml: invalid signature digest value")
+	}
+
+	h := digestHash.New()
+	h.Write(Canonicalize(el, refInclusivePrefixes, signature))
+	if subtle.ConstantTimeCompare(h.Sum(nil), expectedDigest) != 1 {
+		return ErrInvalidSignature
+	}
+
+	// signature value
+	// ---
+	signatureValue, err := decodeBase64(signature.Element(NamespaceDSig, "SignatureValue").Text())
+	if err != nil {
+		return errors.New("saml: invalid signature value")
+	}
+
+	algorithm := signedInfo.Element(NamespaceDSig, "SignatureMethod").Attr("Algorithm")
+
+	signed := Canonicalize(signedInfo, inclusivePrefixes(c14nMethod), nil)
+
+	for _, cert := range certs {
+		if verifyBytes(cert.PublicKey, algorithm, signed, signatureValue) == nil {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// SignElement signs the provided element with an enveloped XML signature
+// (RSA-SHA256 or ECDSA-SHA256 depending on the key type).
+//
+// The signature is inserted right after the element Issuer child (if any),
+// as required by the SAML schema, and the element must have an ID attribute.
+func SignElement(el *Element, key crypto.Signer, cert *x509.Certificate) error {
+	id := el.Attr("ID")
+	if id == "" {
+		return errors.New("saml: missing element ID attribute")
+	}
+
+	algorithm, err := signatureAlgorithm(key)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(Canonicalize(el, nil, nil))
+
+	var keyInfo string
+	if cert != nil {
+		keyInfo = `<ds:KeyInfo><ds:X509Data><ds:X509Certificate>` +
+			base64.StdEncoding.EncodeToString(cert.Raw) +
+			`</ds:X509Certificate></ds:X509Data></ds:KeyInfo>`
+	}
+
+	raw := `<ds:Signature xmlns:ds="` + NamespaceDSig + `">` +
+		`<ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="` + AlgorithmExcC14N + `"></ds:CanonicalizationMethod>` +
+		`<ds:SignatureMethod Algorithm="` + algorithm + `"></ds:SignatureMethod>` +
+		`<ds:Reference URI="#` + escapeString(id) + `">` +
+		`<ds:Transforms>` +
+		`<ds:Transform Algorithm="` + AlgorithmEnvelopedSig + `"></ds:Transform>` +
+		`<ds:Transform Algorithm="` + AlgorithmExcC14N + `"></ds:Transform>` +
+		`</ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="` + AlgorithmSHA256 + `"></ds:DigestMethod>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue>` +
+		`</ds:Reference>` +
+		`</ds:SignedInfo>` +
+		`<ds:SignatureValue></ds:SignatureValue>` +
+		keyInfo +
+		`</ds:Signature>`
+
+	signature, err := ParseXML([]byte(raw))
+	if err != nil {
+		return err
+	}
+
+	// insert after the Issuer element (if any)
+	var after Node
+	for _, child := range el.Children {
+		if c, ok := child.(*Element); ok {
+			if c.Local == "Issuer" {
+				after = c
+			}
+			break
+		}
+	}
+	el.InsertAfter(signature, after)
+
+	signed := Canonicalize(signature.Element(NamespaceDSig, "SignedInfo"), nil, nil)
+
+	signatureValue, err := signBytes(key, algorithm, signed)
+	if err != nil {
+		el.RemoveChild(signature)
+		return err
+	}
+
+	signature.Element(NamespaceDSig, "SignatureValue").SetText(base64.StdEncoding.EncodeToString(signatureValue))
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+func inclusivePrefixes(el *Element) []string {
+	ns := el.Element(namespaceExcC14NPrefixes, "InclusiveNamespaces")
+	if ns == nil {
+		return nil
+	}
+
+	return strings.Fields(ns.Attr("PrefixList"))
+}
+
+func digestMethodHash(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case AlgorithmSHA256:
+		return crypto.SHA256, nil
+	case AlgorithmSHA512:
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("saml: unsupported digest method %q", algorithm)
+	}
+}
+
+// signatureAlgorithm returns the default signature algorithm identifier for the key type.
+func signatureAlgorithm(key crypto.Signer) (string, error) {
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		return AlgorithmRSASHA256, nil
+	case *ecdsa.PublicKey:
+		return AlgorithmECDSASHA256, nil
+	default:
+		return "", errors.New("saml: unsupported signing key type")
+	}
+}
+
+func signatureHash(algorithm string) (crypto.Hash, func() hash.Hash, error) {
+	switch algorithm {
+	case AlgorithmRSASHA256, AlgorithmECDSASHA256:
+		return crypto.SHA256, sha256.New, nil
+	case AlgorithmRSASHA512, AlgorithmECDSASHA512:
+		return crypto.SHA512, sha512.New, nil
+	default:
+		return 0, nil, fmt.Errorf("saml: unsupported signature method %q", algorithm)
+	}
+}
+
+// signBytes signs data with the specified algorithm.
+//
+// The ECDSA signatures are encoded as the concatenated r and s values
+// (as required by the XML signature spec).
+func signBytes(key crypto.Signer, algorithm string, data []byte) ([]byte, error) {
+	hashType, hashFunc, err := signatureHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hashFunc()
+	h.Write(data)
+	hashed := h.Sum(nil)
+
+	signature, err := key.Sign(rand.Reader, hashed, hashType)
+	if err != nil {
+		return nil, err
+	}
+
+	if pub, ok := key.Public().(*ecdsa.PublicKey); ok {
+		var parsed struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &parsed); err != nil {
+			return nil, err
+		}
+
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		raw := make([]byte, 2*size)
+		parsed.R.FillBytes(raw[:size])
+		parsed.S.FillBytes(raw[size:])
+
+		return raw, nil
+	}
+
+	return signature, nil
+}
+
+// verifyBytes verifies the signature of data with the specified algorithm.
+func verifyBytes(publicKey crypto.PublicKey, algorithm string, data []byte, signature []byte) error {
+	hashType, hashFunc, err := signatureHash(algorithm)
+	if err != nil {
+		return err
+	}
+
+	h := hashFunc()
+	h.Write(data)
+	hashed := h.Sum(nil)
+
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		if algorithm != AlgorithmRSASHA256 && algorithm != AlgorithmRSASHA512 {
+			return ErrInvalidSignature
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hashType, hashed, signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if algorithm != AlgorithmECDSASHA256 && algorithm != AlgorithmECDSASHA512 {
+			return ErrInvalidSignature
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, hashed, r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	default:
+		return errors.New("saml: unsupported public key type")
+	}
+}
+
+// decodeBase64 decodes a standard base64 string ignoring the whitespaces.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		default:
+			return r
+		}
+	}, s)
+
+	if s == "" {
+		return nil, errors.New("empty base64 value")
+	}
+
+	return base64.StdEncoding.DecodeString(s)
+}
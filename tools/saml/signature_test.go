@@ -0,0 +1,254 @@
+package saml_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/saml"
+)
+
+const testSignedXML = `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_123" Version="2.0">
+	<saml:Issuer xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">https://idp.example.com</saml:Issuer>
+	<samlp:Status>
+		<samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/>
+	</samlp:Status>
+</samlp:Response>`
+
+func TestSignAndVerifyElement(t *testing.T) {
+	t.Parallel()
+
+	idp, err := tests.NewTestSAMLIdP("https://idp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, ecCert := newTestECDSACertificate(t)
+
+	scenarios := []struct {
+		name string
+		key  crypto.Signer
+		cert *x509.Certificate
+	}{
+		{"RSA", idp.Key, idp.Certificate},
+		{"ECDSA", ecKey, ecCert},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			el, err := saml.ParseXML([]byte(testSignedXML))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := saml.SignElement(el, s.key, s.cert); err != nil {
+				t.Fatal(err)
+			}
+
+			// the signature must be right after the Issuer
+			var names []string
+			for _, child := range el.Children {
+				if c, ok := child.(*saml.Element); ok {
+					names = append(names, c.Local)
+				}
+			}
+			if strings.Join(names, ",") != "Issuer,Signature,Status" {
+				t.Fatalf("Unexpected children order %v", names)
+			}
+
+			// serialize and parse again
+			parsed, err := saml.ParseXML(el.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := saml.VerifySignature(parsed, []*x509.Certificate{idp.Certificate, s.cert}); err != nil {
+				t.Fatalf("Expected valid signature, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureErrors(t *testing.T) {
+	t.Parallel()
+
+	idp, err := tests.NewTestSAMLIdP("https://idp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherCert := newTestECDSACertificate(t)
+
+	sign := func(t *testing.T) string {
+		el, err := saml.ParseXML([]byte(testSignedXML))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := saml.SignElement(el, idp.Key, idp.Certificate); err != nil {
+			t.Fatal(err)
+		}
+
+		return string(el.Bytes())
+	}
+
+	scenarios := []struct {
+		name          string
+		modify        func(raw string) string
+		certs         []*x509.Certificate
+		expectedError error
+	}{
+		{
+			"missing signature",
+			func(raw string) string {
+				return testSignedXML
+			},
+			[]*x509.Certificate{idp.Certificate},
+			saml.ErrMissingSignature,
+		},
+		{
+			"untrusted certificate",
+			nil,
+			[]*x509.Certificate{otherCert},
+			saml.ErrInvalidSignature,
+		},
+		{
+			"no certificates",
+			nil,
+			nil,
+			saml.ErrInvalidSignature,
+		},
+		{
+			"modified content",
+			func(raw string) string {
+				return strings.Replace(raw, "status:Success", "status:Requester", 1)
+			},
+			[]*x509.Certificate{idp.Certificate},
+			saml.ErrInvalidSignature,
+		},
+		{
+			"modified whitespace",
+			func(raw string) string {
+				return strings.Replace(raw, "\n\t<samlp:Status>", "\n\t\t<samlp:Status>", 1)
+			},
+			[]*x509.Certificate{idp.Certificate},
+			saml.ErrInvalidSignature,
+		},
+		{
+			"modified ID",
+			func(raw string) string {
+				return strings.Replace(raw, `ID="_123"`, `ID="_456"`, 1)
+			},
+			[]*x509.Certificate{idp.Certificate},
+			nil, // reference mismatch
+		},
+		{
+			"modified signed info",
+			func(raw string) string {
+				return strings.Replace(raw, `<ds:DigestValue>`, `<ds:DigestValue>AAAA`, 1)
+			},
+			[]*x509.Certificate{idp.Certificate},
+			nil, // digest mismatch
+		},
+		{
+			"sha1 signature method",
+			func(raw string) string {
+				return strings.Replace(raw, saml.AlgorithmRSASHA256, "http://www.w3.org/2000/09/xmldsig#rsa-sha1", 1)
+			},
+			[]*x509.Certificate{idp.Certificate},
+			saml.ErrInvalidSignature,
+		},
+		{
+			"sha1 digest method",
+			func(raw string) string {
+				return strings.Replace(raw, saml.AlgorithmSHA256, "http://www.w3.org/2000/09/xmldsig#sha1", 1)
+			},
+			[]*x509.Certificate{idp.Certificate},
+			nil,
+		},
+		{
+			"inclusive canonicalization method",
+			func(raw string) string {
+				return strings.Replace(raw, `<ds:CanonicalizationMethod Algorithm="`+saml.AlgorithmExcC14N+`">`, `<ds:CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315">`, 1)
+			},
+			[]*x509.Certificate{idp.Certificate},
+			nil,
+		},
+		{
+			"unsupported transform",
+			func(raw string) string {
+				return strings.Replace(raw, `<ds:Transform Algorithm="`+saml.AlgorithmEnvelopedSig+`">`, `<ds:Transform Algorithm="http://www.w3.org/TR/1999/REC-xpath-19991116">`, 1)
+			},
+			[]*x509.Certificate{idp.Certificate},
+			nil,
+		},
+		{
+			"multiple signatures",
+			func(raw string) string {
+				start := strings.Index(raw, "<ds:Signature")
+				end := strings.Index(raw, "</ds:Signature>") + len("</ds:Signature>")
+				return raw[:end] + raw[start:end] + raw[end:]
+			},
+			[]*x509.Certificate{idp.Certificate},
+			nil,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			raw := sign(t)
+			if s.modify != nil {
+				raw = s.modify(raw)
+			}
+
+			el, err := saml.ParseXML([]byte(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = saml.VerifySignature(el, s.certs)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+
+			if s.expectedError != nil && !errors.Is(err, s.expectedError) {
+				t.Fatalf("Expected error %v, got %v", s.expectedError, err)
+			}
+		})
+	}
+}
+
+func newTestECDSACertificate(t *testing.T) (*ecdsa.PrivateKey, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(1 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, cert
+}
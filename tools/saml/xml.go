@@ -0,0 +1,400 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NamespaceXML is the namespace bound to the reserved "xml" prefix.
+const NamespaceXML = "http://www.w3.org/XML/1998/namespace"
+
+// maxDepth is the max allowed elements nesting of the parsed documents.
+const maxDepth = 50
+
+// Node is a single XML tree node ([*Element] or [Text]).
+type Node interface {
+	node()
+}
+
+// Text is a character data node.
+type Text string
+
+func (Text) node() {}
+
+// Attr is a single element attribute.
+type Attr struct {
+	// Space is the resolved attribute namespace URI
+	// (empty for the unprefixed attributes).
+	Space  string
+	Prefix string
+	Local  string
+	Value  string
+}
+
+// Element is a minimal namespace aware XML element
+// that preserves the original prefixes and namespace declarations
+// (required for the signatures verification).
+type Element struct {
+	// Space is the resolved element namespace URI.
+	Space  string
+	Prefix string
+	Local  string
+
+	// Decls are the namespace declarations of the element
+	// (the prefix as Local and the URI as Value, the default
+	// namespace declaration has empty Local).
+	Decls []Attr
+
+	// Attrs are the regular (non namespace declaration) attributes.
+	Attrs []Attr
+
+	Children []Node
+
+	Parent *Element
+}
+
+func (*Element) node() {}
+
+// ParseXML parses the provided XML document and returns its root element.
+//
+// Comments and processing instructions are ignored.
+// Documents with DTD (<!DOCTYPE>) are rejected.
+func ParseXML(data []byte) (*Element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var root *Element
+	var current *Element
+	var depth int
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root != nil && current == nil {
+				return nil, errors.New("saml: multiple root elements")
+			}
+
+			depth++
+			if depth > maxDepth {
+				return nil, errors.New("saml: max XML depth reached")
+			}
+
+			el := &Element{Prefix: t.Name.Space, Local: t.Name.Local, Parent: current}
+
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					el.Decls = append(el.Decls, Attr{Local: "", Value: attr.Value})
+				case attr.Name.Space == "xmlns":
+					el.Decls = append(el.Decls, Attr{Local: attr.Name.Local, Value: attr.Value})
+				default:
+					el.Attrs = append(el.Attrs, Attr{Prefix: attr.Name.Space, Local: attr.Name.Local, Value: attr.Value})
+				}
+			}
+
+			space, ok := el.LookupNamespace(el.Prefix)
+			if !ok {
+				return nil, fmt.Errorf("saml: unbound namespace prefix %q", el.Prefix)
+			}
+			el.Space = space
+
+			for i, attr := range el.Attrs {
+				if attr.Prefix == "" {
+					continue
+				}
+				space, ok := el.LookupNamespace(attr.Prefix)
+				if !ok || space == "" {
+					return nil, fmt.Errorf("saml: unbound namespace prefix %q", attr.Prefix)
+				}
+				el.Attrs[i].Space = space
+			}
+
+			if current == nil {
+				root = el
+			} else {
+				current.Children = append(current.Children, el)
+			}
+			current = el
+		case xml.EndElement:
+			if current == nil || current.Prefix != t.Name.Space || current.Local != t.Name.Local {
+				return nil, errors.New("saml: unexpected XML end element")
+			}
+			current = current.Parent
+			depth--
+		case xml.CharData:
+			if current == nil {
+				if len(bytes.TrimSpace(t)) > 0 {
+					return nil, errors.New("saml: unexpected character data outside of the root element")
+				}
+				continue
+			}
+			current.Children = append(current.Children, Text(t))
+		case xml.Directive:
+			return nil, errors.New("saml: XML directives (DTD) are not allowed")
+		}
+	}
+
+	if root == nil || current != nil {
+		return nil, errors.New("saml: incomplete XML document")
+	}
+
+	return root, nil
+}
+
+// LookupNamespace returns the namespace URI bound to the specified
+// prefix in the element scope (use empty prefix for the default namespace).
+func (e *Element) LookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return NamespaceXML, true
+	}
+
+	for el := e; el != nil; el = el.Parent {
+		for _, decl := range el.Decls {
+			if decl.Local == prefix {
+				return decl.Value, true
+			}
+		}
+	}
+
+	// no default namespace
+	if prefix == "" {
+		return "", true
+	}
+
+	return "", false
+}
+
+// Attr returns the value of the specified unqualified attribute
+// (or empty string if missing).
+func (e *Element) Attr(local string) string {
+	if e == nil {
+		return ""
+	}
+
+	for _, attr := range e.Attrs {
+		if attr.Space == "" && attr.Local == local {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+// HasAttr reports whether the element has the specified unqualified attribute.
+func (e *Element) HasAttr(local string) bool {
+	for _, attr := range e.Attrs {
+		if attr.Space == "" && attr.Local == local {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Is reports whether the element has the specified namespace and local name.
+func (e *Element) Is(space string, local string) bool {
+	return e != nil && e.Space == space && e.Local == local
+}
+
+// Elements returns all direct child elements with the specified
+// namespace and local name.
+func (e *Element) Elements(space string, local string) []*Element {
+	if e == nil {
+		return nil
+	}
+
+	var result []*Element
+
+	for _, child := range e.Children {
+		if el, ok := child.(*Element); ok && el.Is(space, local) {
+			result = append(result, el)
+		}
+	}
+
+	return result
+}
+
+// Element returns the first direct child element with the specified
+// namespace and local name (or nil if missing).
+func (e *Element) Element(space string, local string) *Element {
+	if e == nil {
+		return nil
+	}
+
+	for _, child := range e.Children {
+		if el, ok := child.(*Element); ok && el.Is(space, local) {
+			return el
+		}
+	}
+
+	return nil
+}
+
+// Text returns the trimmed concatenated direct text content of the element.
+func (e *Element) Text() string {
+	if e == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	for _, child := range e.Children {
+		if t, ok := child.(Text); ok {
+			sb.WriteString(string(t))
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// SetText replaces all child nodes of the element with a single text node.
+func (e *Element) SetText(text string) {
+	e.Children = []Node{Text(text)}
+}
+
+// InsertAfter inserts the node right after the specified child
+// (or as first child if after is nil or not found).
+func (e *Element) InsertAfter(node Node, after Node) {
+	if el, ok := node.(*Element); ok {
+		el.Parent = e
+	}
+
+	index := 0
+	if after != nil {
+		for i, child := range e.Children {
+			if child == after {
+				index = i + 1
+				break
+			}
+		}
+	}
+
+	e.Children = append(e.Children, nil)
+	copy(e.Children[index+1:], e.Children[index:])
+	e.Children[index] = node
+}
+
+// RemoveChild removes the specified child node.
+func (e *Element) RemoveChild(node Node) {
+	for i, child := range e.Children {
+		if child == node {
+			e.Children = append(e.Children[:i], e.Children[i+1:]...)
+			return
+		}
+	}
+}
+
+// Bytes serializes the element (and its subtree) as XML document
+// preserving the original prefixes and namespace declarations.
+func (e *Element) Bytes() []byte {
+	buf := new(bytes.Buffer)
+	e.write(buf)
+	return buf.Bytes()
+}
+
+func (e *Element) write(buf *bytes.Buffer) {
+	name := e.qname()
+
+	buf.WriteByte('<')
+	buf.WriteString(name)
+
+	for _, decl := range e.Decls {
+		if decl.Local == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + decl.Local + `="`)
+		}
+		escapeAttr(buf, decl.Value)
+		buf.WriteByte('"')
+	}
+
+	for _, attr := range e.Attrs {
+		buf.WriteByte(' ')
+		if attr.Prefix != "" {
+			buf.WriteString(attr.Prefix + ":")
+		}
+		buf.WriteString(attr.Local + `="`)
+		escapeAttr(buf, attr.Value)
+		buf.WriteByte('"')
+	}
+
+	buf.WriteByte('>')
+
+	for _, child := range e.Children {
+		switch c := child.(type) {
+		case *Element:
+			c.write(buf)
+		case Text:
+			escapeText(buf, string(c))
+		}
+	}
+
+	buf.WriteString("</" + name + ">")
+}
+
+func (e *Element) qname() string {
+	if e.Prefix == "" {
+		return e.Local
+	}
+
+	return e.Prefix + ":" + e.Local
+}
+
+// escapeString returns the XML escaped s.
+func escapeString(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// escapeText escapes the text node characters as defined by the canonical XML spec.
+func escapeText(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// escapeAttr escapes the attribute value characters as defined by the canonical XML spec.
+func escapeAttr(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '"':
+			buf.WriteString("&quot;")
+		case '\t':
+			buf.WriteString("&#x9;")
+		case '\n':
+			buf.WriteString("&#xA;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package saml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/saml"
+)
+
+func TestParseXML(t *testing.T) {
+	t.Parallel()
+
+	raw := `<?xml version="1.0"?>
+<!-- comment -->
+<a:root xmlns:a="urn:a" xmlns="urn:default" id="1" a:x="2">
+	<child>text &amp; more<!-- ignored --></child>
+	<a:child xmlns="">
+		<nested/>
+	</a:child>
+</a:root>`
+
+	root, err := saml.ParseXML([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !root.Is("urn:a", "root") {
+		t.Fatalf("Expected root urn:a/root, got %s/%s", root.Space, root.Local)
+	}
+
+	if v := root.Attr("id"); v != "1" {
+		t.Fatalf("Expected id attribute 1, got %q", v)
+	}
+
+	// qualified attributes are not returned by Attr
+	if root.HasAttr("x") {
+		t.Fatal("Expected the qualified x attribute to be skipped")
+	}
+
+	child := root.Element("urn:default", "child")
+	if child == nil {
+		t.Fatal("Expected urn:default/child element")
+	}
+	if v := child.Text(); v != "text & more" {
+		t.Fatalf("Expected child text %q, got %q", "text & more", v)
+	}
+
+	nested := root.Element("urn:a", "child").Element("", "nested")
+	if nested == nil {
+		t.Fatal("Expected nested element without namespace")
+	}
+
+	if v, _ := nested.LookupNamespace("a"); v != "urn:a" {
+		t.Fatalf("Expected inherited prefix a namespace, got %q", v)
+	}
+
+	if v, _ := nested.LookupNamespace("xml"); v != saml.NamespaceXML {
+		t.Fatalf("Expected xml prefix namespace, got %q", v)
+	}
+}
+
+func TestParseXMLErrors(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", ``},
+		{"invalid", `<a>`},
+		{"mismatched end element", `<a></b>`},
+		{"multiple roots", `<a></a><b></b>`},
+		{"text outside root", `<a></a>text`},
+		{"unbound element prefix", `<x:a></x:a>`},
+		{"unbound attribute prefix", `<a x:id="1"></a>`},
+		{"doctype", `<!DOCTYPE a [<!ENTITY e "test">]><a>&e;</a>`},
+		{"unknown entity", `<a>&e;</a>`},
+		{"max depth", strings.Repeat("<a>", 51) + strings.Repeat("</a>", 51)},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			_, err := saml.ParseXML([]byte(s.raw))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestElementBytes(t *testing.T) {
+	t.Parallel()
+
+	raw := `<a:root xmlns:a="urn:a" id="&quot;1&quot;"><a:child>x &lt; y</a:child><empty></empty></a:root>`
+
+	root, err := saml.ParseXML([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := string(root.Bytes()); v != raw {
+		t.Fatalf("Expected\n%s\ngot\n%s", raw, v)
+	}
+}
+
+func TestElementInsertAfterAndRemoveChild(t *testing.T) {
+	t.Parallel()
+
+	root, err := saml.ParseXML([]byte(`<root><a></a><c></c></root>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &saml.Element{Local: "b"}
+	root.InsertAfter(b, root.Element("", "a"))
+
+	first := &saml.Element{Local: "first"}
+	root.InsertAfter(first, nil)
+
+	if b.Parent != root || first.Parent != root {
+		t.Fatal("Expected the inserted elements parent to be set")
+	}
+
+	expected := `<root><first></first><a></a><b></b><c></c></root>`
+	if v := string(root.Bytes()); v != expected {
+		t.Fatalf("Expected\n%s\ngot\n%s", expected, v)
+	}
+
+	root.RemoveChild(b)
+
+	expected = `<root><first></first><a></a><c></c></root>`
+	if v := string(root.Bytes()); v != expected {
+		t.Fatalf("Expected\n%s\ngot\n%s", expected, v)
+	}
+}
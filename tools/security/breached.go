@@ -0,0 +1,127 @@
+package security
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// breachedReadChunk is the size of the window read around each probe
+// position while searching the breached passwords file.
+const breachedReadChunk = 512
+
+// IsBreachedPassword reports whether the provided plain password is
+// present in the sorted breached passwords file located at listPath.
+//
+// The file is expected to contain one uppercase or lowercase SHA1 hex
+// digest per line, optionally followed by ":COUNT" (aka. the format of the
+// "ordered by hash" Pwned Passwords dump), sorted in ascending order.
+//
+// The lookup is a binary search directly over the file so the list
+// doesn't have to be loaded in memory.
+func IsBreachedPassword(listPath string, password string) (bool, error) {
+	f, err := os.Open(listPath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	sum := sha1.Sum([]byte(password))
+	target := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	// lo always points to the start of a line
+	lo, hi := int64(0), info.Size()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start := mid
+		if mid > lo {
+			// the probe position may be in the middle of a line, so move to the next one
+			next, err := nextLineStart(f, mid-1)
+			if err != nil {
+				return false, err
+			}
+			start = next
+		}
+
+		if start >= hi {
+			// no line starts in [mid, hi)
+			hi = mid
+			continue
+		}
+
+		line, end, err := readLineAt(f, start)
+		if err != nil {
+			return false, err
+		}
+
+		key := line
+		if i := strings.IndexByte(key, ':'); i >= 0 {
+			key = key[:i]
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+
+		switch {
+		case key == target:
+			return true, nil
+		case key < target:
+			lo = end
+		default:
+			hi = start
+		}
+	}
+
+	return false, nil
+}
+
+// nextLineStart returns the offset of the first line start after pos.
+func nextLineStart(r io.ReaderAt, pos int64) (int64, error) {
+	buf := make([]byte, breachedReadChunk)
+
+	for {
+		n, err := r.ReadAt(buf, pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return pos + int64(i) + 1, nil
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return pos + int64(n), nil
+			}
+			return 0, err
+		}
+		pos += int64(n)
+	}
+}
+
+// readLineAt reads the line starting at pos and returns it (without the
+// line terminator) together with the offset of the next line start.
+func readLineAt(r io.ReaderAt, pos int64) (string, int64, error) {
+	var line []byte
+
+	buf := make([]byte, breachedReadChunk)
+
+	for {
+		n, err := r.ReadAt(buf, pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			line = append(line, buf[:i]...)
+			return strings.TrimRight(string(line), "\r"), pos + int64(i) + 1, nil
+		}
+		line = append(line, buf[:n]...)
+		pos += int64(n)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return strings.TrimRight(string(line), "\r"), pos, nil
+			}
+			return "", 0, err
+		}
+	}
+}
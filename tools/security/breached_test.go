@@ -0,0 +1,89 @@
+package security_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+func TestIsBreachedPassword(t *testing.T) {
+	breached := []string{"123456", "password", "qwerty", "letmein", "iloveyou"}
+	for i := 0; i < 300; i++ {
+		breached = append(breached, "generated_"+strconv.Itoa(i))
+	}
+
+	hashes := make([]string, 0, len(breached))
+	for i, p := range breached {
+		sum := sha1.Sum([]byte(p))
+		hash := hex.EncodeToString(sum[:])
+		if i%2 == 0 {
+			hash = strings.ToUpper(hash)
+		}
+		hashes = append(hashes, hash+":"+strconv.Itoa(i+1))
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return strings.ToUpper(hashes[i]) < strings.ToUpper(hashes[j])
+	})
+
+	dir := t.TempDir()
+
+	listPath := filepath.Join(dir, "list.txt")
+	if err := os.WriteFile(listPath, []byte(strings.Join(hashes, "\r\n")+"\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	emptyPath := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(emptyPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name        string
+		path        string
+		password    string
+		expected    bool
+		expectError bool
+	}{
+		{"missing file", filepath.Join(dir, "missing.txt"), "123456", false, true},
+		{"empty file", emptyPath, "123456", false, false},
+		{"first breached", listPath, "123456", true, false},
+		{"breached lowercase hash", listPath, "password", true, false},
+		{"generated breached", listPath, "generated_0", true, false},
+		{"generated breached (last)", listPath, "generated_299", true, false},
+		{"not breached", listPath, "a9r!Kp2-Zz", false, false},
+		{"empty password", listPath, "", false, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, err := security.IsBreachedPassword(s.path, s.password)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if result != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, result)
+			}
+		})
+	}
+
+	// every listed password must be found
+	for _, p := range breached {
+		found, err := security.IsBreachedPassword(listPath, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found {
+			t.Fatalf("Expected %q to be found", p)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported asymmetric JWT signing algorithms.
+const (
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmES256 = "ES256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// AsymmetricJWTAlgorithms is the list with the supported asymmetric JWT signing algorithms.
+var AsymmetricJWTAlgorithms = []string{JWTAlgorithmRS256, JWTAlgorithmES256, JWTAlgorithmEdDSA}
+
+// JWK defines a public JSON Web Key (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// GenerateSigningKey generates a new private key for the specified asymmetric JWT algorithm
+// (RSA 2048 for RS256, P-256 for ES256 and Ed25519 for EdDSA).
+func GenerateSigningKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case JWTAlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case JWTAlgorithmES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case JWTAlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", alg)
+	}
+}
+
+// MarshalSigningKey encodes the private key as base64 PKCS #8 DER string.
+func MarshalSigningKey(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(der), nil
+}
+
+// ParseSigningKey decodes a base64 PKCS #8 DER private key string.
+func ParseSigningKey(encoded string) (crypto.Signer, error) {
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("the private key is not a signer")
+	}
+
+	return signer, nil
+}
+
+// MarshalPublicKey encodes the public key as base64 PKIX DER string.
+func MarshalPublicKey(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(der), nil
+}
+
+// ParsePublicKey decodes a base64 PKIX DER public key string.
+func ParsePublicKey(encoded string) (crypto.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParsePKIXPublicKey(der)
+}
+
+// NewJWK returns the public JWK representation of the provided public key.
+func NewJWK(kid string, alg string, key crypto.PublicKey) (*JWK, error) {
+	jwk := &JWK{
+		Kid: kid,
+		Use: "sig",
+		Alg: alg,
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("unsupported elliptic curve")
+		}
+
+		ecdhKey, err := k.ECDH()
+		if err != nil {
+			return nil, err
+		}
+
+		// uncompressed point format: 0x04 || X || Y
+		point := ecdhKey.Bytes()
+
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1:33])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[33:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(k)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+
+	return jwk, nil
+}
+
+// NewAsymmetricJWT generates and returns new JWT signed with the
+// specified asymmetric algorithm and private key.
+//
+// The kid is stored in the token header and could be used to
+// find the verification key from a JWK set.
+func NewAsymmetricJWT(payload jwt.MapClaims, alg string, kid string, signingKey crypto.Signer, duration time.Duration) (string, error) {
+	method := jwt.GetSigningMethod(alg)
+	if method == nil || alg == "HS256" {
+		return "", fmt.Errorf("unsupported JWT algorithm %q", alg)
+	}
+
+	claims := jwt.MapClaims{
+		"exp": time.Now().Add(duration).Unix(),
+	}
+
+	for k, v := range payload {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	return token.SignedString(signingKey)
+}
+
+// ParseAsymmetricJWT verifies and parses JWT signed with the
+// specified asymmetric algorithm and returns its claims.
+func ParseAsymmetricJWT(token string, alg string, verificationKey crypto.PublicKey) (jwt.MapClaims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{alg}))
+
+	parsedToken, err := parser.Parse(token, func(t *jwt.Token) (any, error) {
+		return verificationKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
+		return claims, nil
+	}
+
+	return nil, errors.New("unable to parse token")
+}
+
+// ParseUnverifiedJWTHeader returns the header of the JWT
+// WITHOUT verifying its signature or claims.
+func ParseUnverifiedJWTHeader(token string) (map[string]any, error) {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed.Header, nil
+}
@@ -0,0 +1,156 @@
+package security_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+func TestGenerateSigningKey(t *testing.T) {
+	if _, err := security.GenerateSigningKey("HS256"); err == nil {
+		t.Fatal("Expected HS256 to be unsupported")
+	}
+
+	for _, alg := range security.AsymmetricJWTAlgorithms {
+		t.Run(alg, func(t *testing.T) {
+			key, err := security.GenerateSigningKey(alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			encodedPrivate, err := security.MarshalSigningKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsedPrivate, err := security.ParseSigningKey(encodedPrivate)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			encodedPublic, err := security.MarshalPublicKey(parsedPrivate.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsedPublic, err := security.ParsePublicKey(encodedPublic)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := security.NewAsymmetricJWT(jwt.MapClaims{"a": 123}, alg, "test_kid", parsedPrivate, 1*time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			header, err := security.ParseUnverifiedJWTHeader(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header["alg"] != alg || header["kid"] != "test_kid" {
+				t.Fatalf("Unexpected token header %v", header)
+			}
+
+			claims, err := security.ParseAsymmetricJWT(token, alg, parsedPublic)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v, _ := claims["a"].(float64); v != 123 {
+				t.Fatalf("Expected claim a to be 123, got %v", claims["a"])
+			}
+
+			// verify with another key
+			otherKey, err := security.GenerateSigningKey(alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := security.ParseAsymmetricJWT(token, alg, otherKey.Public()); err == nil {
+				t.Fatal("Expected verification with another key to fail")
+			}
+		})
+	}
+}
+
+func TestNewAsymmetricJWTExpired(t *testing.T) {
+	key, err := security.GenerateSigningKey(security.JWTAlgorithmES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := security.NewAsymmetricJWT(nil, security.JWTAlgorithmES256, "test", key, -1*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := security.ParseAsymmetricJWT(token, security.JWTAlgorithmES256, key.Public()); err == nil {
+		t.Fatal("Expected expired token error")
+	}
+}
+
+func TestParseAsymmetricJWTAlgorithmMismatch(t *testing.T) {
+	key, err := security.GenerateSigningKey(security.JWTAlgorithmRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := security.NewAsymmetricJWT(nil, security.JWTAlgorithmRS256, "test", key, 1*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := security.ParseAsymmetricJWT(token, security.JWTAlgorithmES256, key.Public()); err == nil {
+		t.Fatal("Expected algorithm mismatch error")
+	}
+}
+
+func TestNewJWK(t *testing.T) {
+	scenarios := []struct {
+		alg            string
+		expectKty      string
+		expectCrv      string
+		expectedFields []string
+	}{
+		{security.JWTAlgorithmRS256, "RSA", "", []string{`"n":"`, `"e":"AQAB"`}},
+		{security.JWTAlgorithmES256, "EC", "P-256", []string{`"x":"`, `"y":"`}},
+		{security.JWTAlgorithmEdDSA, "OKP", "Ed25519", []string{`"x":"`}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.alg, func(t *testing.T) {
+			key, err := security.GenerateSigningKey(s.alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			jwk, err := security.NewJWK("test_kid", s.alg, key.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if jwk.Kty != s.expectKty {
+				t.Fatalf("Expected kty %q, got %q", s.expectKty, jwk.Kty)
+			}
+
+			if jwk.Crv != s.expectCrv {
+				t.Fatalf("Expected crv %q, got %q", s.expectCrv, jwk.Crv)
+			}
+
+			raw, err := json.Marshal(jwk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rawStr := string(raw)
+
+			expectedFields := append([]string{`"kid":"test_kid"`, `"use":"sig"`, `"alg":"` + s.alg + `"`}, s.expectedFields...)
+			for _, f := range expectedFields {
+				if !strings.Contains(rawStr, f) {
+					t.Fatalf("Missing %s in\n%s", f, rawStr)
+				}
+			}
+		})
+	}
+}
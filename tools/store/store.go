@@ -62,6 +62,28 @@ func (s *Store[K, T]) Remove(key K) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.remove(key)
+}
+
+// GetAndRemove atomically removes a single entry from the store and
+// returns its value together with a boolean indicating whether the key existed.
+//
+// It is useful for single-use values (e.g. one-time codes and challenges)
+// where only one of the concurrent callers should succeed.
+func (s *Store[K, T]) GetAndRemove(key K) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.data[key]
+	if ok {
+		s.remove(key)
+	}
+
+	return v, ok
+}
+
+// remove deletes the key from the store data without locking.
+func (s *Store[K, T]) remove(key K) {
 	delete(s.data, key)
 	s.deleted++
 
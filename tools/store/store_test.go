@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"slices"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/pocketbase/pocketbase/tools/store"
@@ -107,6 +109,47 @@ func TestRemove(t *testing.T) {
 	}
 }
 
+func TestGetAndRemove(t *testing.T) {
+	s := store.New(map[string]int{"test": 123})
+
+	v, ok := s.GetAndRemove("test")
+	if !ok || v != 123 {
+		t.Fatalf("Expected (123, true), got (%d, %v)", v, ok)
+	}
+
+	if s.Has("test") {
+		t.Fatal("Expected test to be removed")
+	}
+
+	v, ok = s.GetAndRemove("test")
+	if ok || v != 0 {
+		t.Fatalf("Expected (0, false) for already removed key, got (%d, %v)", v, ok)
+	}
+}
+
+func TestGetAndRemoveConcurrent(t *testing.T) {
+	s := store.New(map[string]bool{"test": true})
+
+	var wg sync.WaitGroup
+	var removed atomic.Int32
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := s.GetAndRemove("test"); ok {
+				removed.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if v := removed.Load(); v != 1 {
+		t.Fatalf("Expected exactly 1 successful removal, got %d", v)
+	}
+}
+
 func TestHas(t *testing.T) {
 	s := store.New(map[string]int{"test1": 0, "test2": 1})
 
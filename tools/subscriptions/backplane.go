@@ -0,0 +1,38 @@
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// BackplaneEvent defines a single event fanned out between multiple broker nodes.
+type BackplaneEvent struct {
+	// Node is the id of the broker node that published the event.
+	Node string `json:"node"`
+
+	// Kind is an arbitrary event identifier (e.g. "create", "update", etc.).
+	Kind string `json:"kind"`
+
+	// Data is the JSON encoded event payload.
+	Data json.RawMessage `json:"data"`
+}
+
+// Backplane defines the common interface for distributing events
+// between multiple broker nodes (aka. app instances).
+//
+// The backplane only transports the events and it is up to the
+// receiving node to decide which (if any) of its clients should
+// be notified, e.g. in order to run the access checks locally.
+type Backplane interface {
+	// Publish sends the event to the other nodes.
+	Publish(ctx context.Context, event BackplaneEvent) error
+
+	// Listen starts receiving the events published by the other nodes
+	// and calls handler for each of them (sequentially, in their receive order).
+	//
+	// It blocks until ctx is done or an unrecoverable error occurs.
+	Listen(ctx context.Context, handler func(event BackplaneEvent)) error
+
+	// Close releases the backplane resources (connections, listeners, etc.).
+	Close() error
+}
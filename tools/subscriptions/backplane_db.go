@@ -0,0 +1,177 @@
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/pocketbase/dbx"
+)
+
+var _ Backplane = (*DBBackplane)(nil)
+
+// DBBackplaneConfig defines the [DBBackplane] configuration options.
+type DBBackplaneConfig struct {
+	// Table is the name of the events table (default to "_realtimeEvents").
+	//
+	// The table is created automatically if missing.
+	Table string
+
+	// PollInterval is the interval for checking for new events
+	// (default to 250ms).
+	PollInterval time.Duration
+
+	// Retention is the max age of the stored events before their
+	// deletion (default to 1min).
+	Retention time.Duration
+}
+
+// DBBackplane is a [Backplane] implementation that exchanges the events
+// through a table in a shared SQLite database (e.g. the auxiliary db
+// of multiple app instances running on the same shared volume).
+//
+// Each node polls the table for new events (in their insert order)
+// and periodically deletes the events older than the configured retention.
+type DBBackplane struct {
+	db     dbx.Builder
+	config DBBackplaneConfig
+}
+
+// NewDBBackplane creates a new shared table backplane with the provided db builder.
+func NewDBBackplane(db dbx.Builder, config DBBackplaneConfig) *DBBackplane {
+	if config.Table == "" {
+		config.Table = "_realtimeEvents"
+	}
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = 250 * time.Millisecond
+	}
+
+	if config.Retention <= 0 {
+		config.Retention = time.Minute
+	}
+
+	return &DBBackplane{
+		db:     db,
+		config: config,
+	}
+}
+
+// dbBackplaneEvent is the db row representation of a single backplane event.
+type dbBackplaneEvent struct {
+	Id      int64  `db:"id"`
+	Event   string `db:"event"`
+	Created int64  `db:"created"`
+}
+
+func (b *DBBackplane) ensureTable(ctx context.Context) error {
+	_, err := b.db.NewQuery(
+		"CREATE TABLE IF NOT EXISTS {{" + b.config.Table + "}} (" +
+			"[[id]] INTEGER PRIMARY KEY AUTOINCREMENT, " +
+			"[[event]] TEXT NOT NULL, " +
+			"[[created]] INTEGER NOT NULL" +
+			")",
+	).WithContext(ctx).Execute()
+
+	return err
+}
+
+// Publish implements [Backplane.Publish] by inserting the event in the shared table.
+func (b *DBBackplane) Publish(ctx context.Context, event BackplaneEvent) error {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	insert := func() error {
+		_, err := b.db.Insert(b.config.Table, dbx.Params{
+			"event":   string(raw),
+			"created": time.Now().UnixMilli(),
+		}).WithContext(ctx).Execute()
+
+		return err
+	}
+
+	if err := insert(); err != nil {
+		// retry in case the table is not created yet
+		if tableErr := b.ensureTable(ctx); tableErr != nil {
+			return errors.Join(err, tableErr)
+		}
+
+		return insert()
+	}
+
+	return nil
+}
+
+// Listen implements [Backplane.Listen] by polling the shared table for new events.
+//
+// Only the events inserted after the Listen call are reported.
+func (b *DBBackplane) Listen(ctx context.Context, handler func(event BackplaneEvent)) error {
+	if err := b.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	var lastId int64
+	err := b.db.Select("COALESCE(MAX([[id]]), 0)").
+		From(b.config.Table).
+		WithContext(ctx).
+		Row(&lastId)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(b.config.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			rows := []*dbBackplaneEvent{}
+
+			err := b.db.Select("id", "event", "created").
+				From(b.config.Table).
+				AndWhere(dbx.NewExp("[[id]] > {:lastId}", dbx.Params{"lastId": lastId})).
+				OrderBy("id ASC").
+				WithContext(ctx).
+				All(&rows)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				continue // try again on the next tick (e.g. in case of a busy db)
+			}
+
+			for _, row := range rows {
+				lastId = row.Id
+
+				var event BackplaneEvent
+				if err := json.Unmarshal([]byte(row.Event), &event); err != nil {
+					continue
+				}
+
+				handler(event)
+			}
+
+			if time.Since(lastCleanup) > b.config.Retention {
+				lastCleanup = time.Now()
+
+				b.db.Delete(b.config.Table, dbx.NewExp("[[created]] < {:threshold}", dbx.Params{
+					"threshold": time.Now().Add(-b.config.Retention).UnixMilli(),
+				})).WithContext(ctx).Execute()
+			}
+		}
+	}
+}
+
+// Close implements [Backplane.Close].
+//
+// It does nothing because the db connection is managed by the caller.
+func (b *DBBackplane) Close() error {
+	return nil
+}
@@ -0,0 +1,440 @@
+package subscriptions
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Backplane = (*PeerBackplane)(nil)
+
+// maxPeerEventSize is the max size of a single serialized peer event.
+const maxPeerEventSize = 16 << 20
+
+// peerNonceSize is the size of the random per-connection handshake nonce.
+const peerNonceSize = 32
+
+// PeerBackplaneConfig defines the [PeerBackplane] configuration options.
+type PeerBackplaneConfig struct {
+	// ListenAddr is the address of the current node.
+	//
+	// It could be either a TCP "host:port" or a "unix:/path/to/socket" address.
+	ListenAddr string
+
+	// Peers is a list with the addresses of the other nodes
+	// (in the same format as ListenAddr).
+	Peers []string
+
+	// Secret is the shared secret used to authenticate the peer connections
+	// and to sign the exchanged events.
+	//
+	// The secret itself is never sent - on connect the listening node sends
+	// a random nonce and the dialing node must reply with its HMAC-SHA256.
+	// Each event after that is signed with an HMAC-SHA256 of the connection
+	// nonce, its sequence number and data (see [PeerBackplane]).
+	//
+	// It is required if ListenAddr or any of the Peers is a TCP address
+	// (it could be empty only when all nodes use Unix sockets).
+	Secret string
+
+	// DialTimeout is the max duration for establishing a peer connection
+	// (default to 5s).
+	DialTimeout time.Duration
+}
+
+// PeerBackplane is a [Backplane] implementation that exchanges the events
+// directly between a static list of peers over TCP or Unix socket
+// connections (aka. a full mesh).
+//
+// The events are sent as newline delimited JSON over a single persistent
+// connection per peer, which preserves the publish order.
+// Each line has the format "<seq> <hmac> <json>", where seq is a per-connection
+// increasing sequence number and hmac is the HMAC-SHA256 (keyed with the
+// Secret) of the connection nonce, seq and json. The lines with invalid
+// signature or non-increasing seq (e.g. tampered, injected or replayed)
+// are dropped by the receiving node.
+//
+// Note that the events are only signed and not encrypted, so anyone on the
+// network path could still read them. For TCP peers outside of a trusted
+// private network the connections should be tunneled over TLS/WireGuard.
+//
+// Each peer has its own buffered send queue so that a slow or unavailable
+// peer doesn't block the publisher. Events that couldn't be delivered
+// (e.g. because the peer is down) are dropped.
+type PeerBackplane struct {
+	config PeerBackplaneConfig
+
+	mu       sync.Mutex
+	senders  map[string]*peerSender
+	listener net.Listener
+	closed   bool
+}
+
+// NewPeerBackplane creates a new peer mesh backplane from the provided config.
+//
+// The peer connections are established lazily on the first publish.
+func NewPeerBackplane(config PeerBackplaneConfig) (*PeerBackplane, error) {
+	if config.ListenAddr == "" {
+		return nil, errors.New("missing peer backplane listen address")
+	}
+
+	if config.Secret == "" {
+		for _, addr := range append([]string{config.ListenAddr}, config.Peers...) {
+			if network, _ := parsePeerAddr(addr); network != "unix" {
+				return nil, fmt.Errorf("a peer backplane secret is required for the TCP address %q", addr)
+			}
+		}
+	}
+
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+
+	return &PeerBackplane{
+		config:  config,
+		senders: map[string]*peerSender{},
+	}, nil
+}
+
+// Publish implements [Backplane.Publish] by queueing the event for all configured peers.
+//
+// It returns an error only if the backplane is closed or a peer send queue is full.
+func (p *PeerBackplane) Publish(ctx context.Context, event BackplaneEvent) error {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return errors.New("the peer backplane is closed")
+	}
+
+	var errs []error
+
+	for _, peer := range p.config.Peers {
+		sender, ok := p.senders[peer]
+		if !ok {
+			sender = newPeerSender(peer, p.config)
+			p.senders[peer] = sender
+		}
+
+		select {
+		case sender.queue <- raw:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("peer %s: %w", peer, ctx.Err()))
+		default:
+			errs = append(errs, fmt.Errorf("peer %s: the send queue is full", peer))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Listen implements [Backplane.Listen] by accepting the connections
+// from the other peers on the configured ListenAddr.
+func (p *PeerBackplane) Listen(ctx context.Context, handler func(event BackplaneEvent)) error {
+	network, address := parsePeerAddr(p.config.ListenAddr)
+
+	// remove leftover socket file from a previous run
+	if network == "unix" {
+		if info, err := os.Stat(address); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(address)
+		}
+	}
+
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, network, address)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		listener.Close()
+		return errors.New("the peer backplane is closed")
+	}
+	p.listener = listener
+	p.mu.Unlock()
+
+	// serialize the handler calls from the different peer connections
+	var handlerMu sync.Mutex
+	serialHandler := func(event BackplaneEvent) {
+		handlerMu.Lock()
+		defer handlerMu.Unlock()
+
+		handler(event)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := context.AfterFunc(ctx, func() {
+		listener.Close()
+	})
+	defer stop()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			p.mu.Lock()
+			closed := p.closed
+			p.mu.Unlock()
+			if closed {
+				return nil
+			}
+
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.serveConn(ctx, conn, serialHandler)
+		}()
+	}
+}
+
+func (p *PeerBackplane) serveConn(ctx context.Context, conn net.Conn, handler func(event BackplaneEvent)) {
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxPeerEventSize)
+
+	// authenticate the peer with a challenge-response handshake
+	// (the first line from the peer must be the HMAC of the sent nonce)
+	nonce := make([]byte, peerNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return
+	}
+	hexNonce := hex.EncodeToString(nonce)
+
+	conn.SetDeadline(time.Now().Add(p.config.DialTimeout))
+	if _, err := conn.Write([]byte(hexNonce + "\n")); err != nil {
+		return
+	}
+	if !scanner.Scan() || !hmac.Equal(scanner.Bytes(), []byte(peerHandshakeMAC(p.config.Secret, hexNonce))) {
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	var lastSeq uint64
+
+	for scanner.Scan() {
+		seq, data, ok := p.verifyPeerLine(hexNonce, lastSeq, scanner.Bytes())
+		if !ok {
+			continue // tampered, replayed or unsigned line
+		}
+		lastSeq = seq
+
+		var event BackplaneEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			continue
+		}
+
+		handler(event)
+	}
+}
+
+// verifyPeerLine verifies the signature of a single "<seq> <hmac> <json>"
+// event line and returns its sequence number and json data.
+//
+// The line seq must be greater than lastSeq.
+func (p *PeerBackplane) verifyPeerLine(nonce string, lastSeq uint64, line []byte) (uint64, []byte, bool) {
+	rawSeq, rest, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return 0, nil, false
+	}
+
+	mac, data, ok := bytes.Cut(rest, []byte(" "))
+	if !ok {
+		return 0, nil, false
+	}
+
+	seq, err := strconv.ParseUint(string(rawSeq), 10, 64)
+	if err != nil || seq <= lastSeq {
+		return 0, nil, false
+	}
+
+	if !hmac.Equal(mac, []byte(peerEventMAC(p.config.Secret, nonce, seq, data))) {
+		return 0, nil, false
+	}
+
+	return seq, data, true
+}
+
+// Close implements [Backplane.Close] by closing the listener
+// and all peer connections (the pending events are discarded).
+func (p *PeerBackplane) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+
+	var errs []error
+
+	if p.listener != nil {
+		if err := p.listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+
+	for peer, sender := range p.senders {
+		sender.close()
+		delete(p.senders, peer)
+	}
+
+	return errors.Join(errs...)
+}
+
+// peerHandshakeMAC returns the hex encoded HMAC-SHA256 of the handshake nonce.
+func peerHandshakeMAC(secret string, nonce string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(nonce))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// peerEventMAC returns the hex encoded HMAC-SHA256 of a single event line
+// bound to the connection handshake nonce and the event sequence number.
+func peerEventMAC(secret string, nonce string, seq uint64, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(nonce + "\n" + strconv.FormatUint(seq, 10) + "\n"))
+	mac.Write(data)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parsePeerAddr splits the peer address into its network and address parts.
+func parsePeerAddr(addr string) (string, string) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return "unix", path
+	}
+
+	return "tcp", addr
+}
+
+// peerSenderQueueSize is the max number of pending events per peer.
+const peerSenderQueueSize = 1000
+
+// peerSender delivers the queued events to a single peer.
+type peerSender struct {
+	addr   string
+	config PeerBackplaneConfig
+	queue  chan []byte
+	done   chan struct{}
+	conn   net.Conn
+	nonce  string // the handshake nonce of the current connection
+	seq    uint64 // the last sent event sequence number of the current connection
+}
+
+func newPeerSender(addr string, config PeerBackplaneConfig) *peerSender {
+	s := &peerSender{
+		addr:   addr,
+		config: config,
+		queue:  make(chan []byte, peerSenderQueueSize),
+		done:   make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+func (s *peerSender) run() {
+	defer func() {
+		if s.conn != nil {
+			s.conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case raw := <-s.queue:
+			if err := s.write(raw); err != nil {
+				// retry with a fresh connection in case the old one was dropped
+				s.write(raw)
+			}
+		}
+	}
+}
+
+func (s *peerSender) write(raw []byte) error {
+	if s.conn == nil {
+		network, address := parsePeerAddr(s.addr)
+
+		conn, err := net.DialTimeout(network, address, s.config.DialTimeout)
+		if err != nil {
+			return err
+		}
+
+		// reply to the listener nonce
+		conn.SetDeadline(time.Now().Add(s.config.DialTimeout))
+		nonce, err := bufio.NewReaderSize(conn, 2*peerNonceSize+1).ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		mac := peerHandshakeMAC(s.config.Secret, strings.TrimSuffix(nonce, "\n"))
+		if _, err := conn.Write([]byte(mac + "\n")); err != nil {
+			conn.Close()
+			return err
+		}
+		conn.SetDeadline(time.Time{})
+
+		s.conn = conn
+		s.nonce = strings.TrimSuffix(nonce, "\n")
+		s.seq = 0
+	}
+
+	s.seq++
+
+	line := make([]byte, 0, len(raw)+96)
+	line = strconv.AppendUint(line, s.seq, 10)
+	line = append(line, ' ')
+	line = append(line, peerEventMAC(s.config.Secret, s.nonce, s.seq, raw)...)
+	line = append(line, ' ')
+	line = append(line, raw...)
+	line = append(line, '\n')
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.config.DialTimeout))
+
+	if _, err := s.conn.Write(line); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+func (s *peerSender) close() {
+	close(s.done)
+}
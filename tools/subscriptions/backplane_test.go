@@ -0,0 +1,448 @@
+package subscriptions_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	_ "modernc.org/sqlite"
+)
+
+// memoryBackplane is a minimal in-memory backplane shared between multiple brokers.
+type memoryBackplane struct {
+	mu       sync.Mutex
+	handlers []func(event subscriptions.BackplaneEvent)
+}
+
+func (m *memoryBackplane) Publish(ctx context.Context, event subscriptions.BackplaneEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, h := range m.handlers {
+		h(event)
+	}
+
+	return nil
+}
+
+func (m *memoryBackplane) Listen(ctx context.Context, handler func(event subscriptions.BackplaneEvent)) error {
+	m.mu.Lock()
+	m.handlers = append(m.handlers, handler)
+	m.mu.Unlock()
+
+	<-ctx.Done()
+
+	return nil
+}
+
+func (m *memoryBackplane) Close() error {
+	return nil
+}
+
+// collectEvents starts listening on the broker in a separate goroutine
+// and returns a channel with the received events.
+func collectEvents(t *testing.T, listen func(ctx context.Context, handler func(event subscriptions.BackplaneEvent)) error) <-chan subscriptions.BackplaneEvent {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events := make(chan subscriptions.BackplaneEvent, 10)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		err := listen(ctx, func(event subscriptions.BackplaneEvent) {
+			events <- event
+		})
+		if err != nil {
+			t.Errorf("Listen error: %v", err)
+		}
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return events
+}
+
+func expectEvent(t *testing.T, events <-chan subscriptions.BackplaneEvent, kind string, data string) {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		if event.Kind != kind || string(event.Data) != data {
+			t.Fatalf("Expected event %q with data %s, got %q with data %s", kind, data, event.Kind, event.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected event %q, got none", kind)
+	}
+}
+
+func expectNoEvent(t *testing.T, events <-chan subscriptions.BackplaneEvent) {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		t.Fatalf("Expected no event, got %q", event.Kind)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestBrokerBackplane(t *testing.T) {
+	t.Parallel()
+
+	backplane := &memoryBackplane{}
+
+	b1 := subscriptions.NewBroker()
+	b2 := subscriptions.NewBroker()
+
+	if b1.NodeId() == "" || b1.NodeId() == b2.NodeId() {
+		t.Fatalf("Expected unique broker node ids, got %q and %q", b1.NodeId(), b2.NodeId())
+	}
+
+	// no backplane
+	if err := b1.Publish(context.Background(), "test", 1); err != nil {
+		t.Fatalf("Expected nil publish error without backplane, got %v", err)
+	}
+	if err := b1.Listen(context.Background(), nil); err != nil {
+		t.Fatalf("Expected nil listen error without backplane, got %v", err)
+	}
+
+	b1.SetBackplane(backplane)
+	b2.SetBackplane(backplane)
+
+	if b1.Backplane() != backplane {
+		t.Fatal("Expected the backplane to be set")
+	}
+
+	events1 := collectEvents(t, b1.Listen)
+	events2 := collectEvents(t, b2.Listen)
+
+	// wait for the listeners registration
+	for i := 0; i < 100; i++ {
+		backplane.mu.Lock()
+		total := len(backplane.handlers)
+		backplane.mu.Unlock()
+		if total == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := b1.Publish(context.Background(), "test", map[string]any{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectEvent(t, events2, "test", `{"a":1}`)
+	expectNoEvent(t, events1) // own events are skipped
+}
+
+func TestPeerBackplane(t *testing.T) {
+	t.Parallel()
+
+	if _, err := subscriptions.NewPeerBackplane(subscriptions.PeerBackplaneConfig{}); err == nil {
+		t.Fatal("Expected missing listen address error")
+	}
+
+	secretChecks := []struct {
+		name        string
+		config      subscriptions.PeerBackplaneConfig
+		expectError bool
+	}{
+		{
+			"TCP listen address without secret",
+			subscriptions.PeerBackplaneConfig{ListenAddr: "127.0.0.1:0"},
+			true,
+		},
+		{
+			"TCP peer address without secret",
+			subscriptions.PeerBackplaneConfig{ListenAddr: "unix:/tmp/a.sock", Peers: []string{"127.0.0.1:8091"}},
+			true,
+		},
+		{
+			"TCP addresses with secret",
+			subscriptions.PeerBackplaneConfig{ListenAddr: "127.0.0.1:0", Peers: []string{"127.0.0.1:8091"}, Secret: "test"},
+			false,
+		},
+		{
+			"Unix sockets without secret",
+			subscriptions.PeerBackplaneConfig{ListenAddr: "unix:/tmp/a.sock", Peers: []string{"unix:/tmp/b.sock"}},
+			false,
+		},
+	}
+	for _, s := range secretChecks {
+		_, err := subscriptions.NewPeerBackplane(s.config)
+		if hasErr := err != nil; hasErr != s.expectError {
+			t.Fatalf("[%s] Expected hasErr %v, got %v (%v)", s.name, s.expectError, hasErr, err)
+		}
+	}
+
+	dir := t.TempDir()
+	addr1 := "unix:" + filepath.Join(dir, "node1.sock")
+	addr2 := "unix:" + filepath.Join(dir, "node2.sock")
+	addr3 := "unix:" + filepath.Join(dir, "node3.sock")
+
+	node1, err := subscriptions.NewPeerBackplane(subscriptions.PeerBackplaneConfig{
+		ListenAddr: addr1,
+		Peers:      []string{addr2, addr3},
+		Secret:     "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node1.Close()
+
+	node2, err := subscriptions.NewPeerBackplane(subscriptions.PeerBackplaneConfig{
+		ListenAddr: addr2,
+		Peers:      []string{addr1},
+		Secret:     "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node2.Close()
+
+	// different secret
+	node3, err := subscriptions.NewPeerBackplane(subscriptions.PeerBackplaneConfig{
+		ListenAddr: addr3,
+		Peers:      []string{addr1},
+		Secret:     "invalid",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node3.Close()
+
+	events1 := collectEvents(t, node1.Listen)
+	events2 := collectEvents(t, node2.Listen)
+	events3 := collectEvents(t, node3.Listen)
+
+	// wait for the listeners to start
+	time.Sleep(100 * time.Millisecond)
+
+	for i, data := range []string{`1`, `2`, `3`} {
+		event := subscriptions.BackplaneEvent{Node: "node1", Kind: "test", Data: []byte(data)}
+		if err := node1.Publish(context.Background(), event); err != nil {
+			t.Fatalf("[%d] publish error: %v", i, err)
+		}
+	}
+
+	// preserve the publish order
+	expectEvent(t, events2, "test", `1`)
+	expectEvent(t, events2, "test", `2`)
+	expectEvent(t, events2, "test", `3`)
+
+	if err := node2.Publish(context.Background(), subscriptions.BackplaneEvent{Node: "node2", Kind: "reply", Data: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events1, "reply", `{}`)
+
+	// secret mismatch in both directions
+	if err := node3.Publish(context.Background(), subscriptions.BackplaneEvent{Node: "node3", Kind: "test", Data: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	expectNoEvent(t, events1)
+	expectNoEvent(t, events3)
+
+	// closed
+	node2.Close()
+	if err := node2.Publish(context.Background(), subscriptions.BackplaneEvent{Kind: "test"}); err == nil {
+		t.Fatal("Expected publish error after close")
+	}
+}
+
+func TestPeerBackplaneHandshake(t *testing.T) {
+	t.Parallel()
+
+	addr := filepath.Join(t.TempDir(), "fake.sock")
+
+	listener, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	node, err := subscriptions.NewPeerBackplane(subscriptions.PeerBackplaneConfig{
+		ListenAddr: "unix:" + filepath.Join(t.TempDir(), "node.sock"),
+		Peers:      []string{"unix:" + addr},
+		Secret:     "test_secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if err := node.Publish(context.Background(), subscriptions.BackplaneEvent{Kind: "test", Data: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	nonce := "test_nonce"
+	if _, err := conn.Write([]byte(nonce + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	reply, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(reply, "test_secret") {
+		t.Fatal("Expected the secret to not be sent")
+	}
+
+	mac := hmac.New(sha256.New, []byte("test_secret"))
+	mac.Write([]byte(nonce))
+	expected := hex.EncodeToString(mac.Sum(nil)) + "\n"
+	if reply != expected {
+		t.Fatalf("Expected nonce HMAC %q, got %q", expected, reply)
+	}
+
+	event, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(event, `"kind":"test"`) {
+		t.Fatalf("Expected the published event after the handshake, got %q", event)
+	}
+}
+
+func TestPeerBackplaneSignedEvents(t *testing.T) {
+	t.Parallel()
+
+	addr := filepath.Join(t.TempDir(), "node.sock")
+
+	node, err := subscriptions.NewPeerBackplane(subscriptions.PeerBackplaneConfig{
+		ListenAddr: "unix:" + addr,
+		Secret:     "test_secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	events := collectEvents(t, node.Listen)
+
+	// wait for the listener to start
+	time.Sleep(100 * time.Millisecond)
+
+	// dial as a peer with a valid handshake
+	conn, err := net.Dial("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	nonce, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce = strings.TrimSuffix(nonce, "\n")
+
+	sign := func(seq string, data string) string {
+		mac := hmac.New(sha256.New, []byte("test_secret"))
+		mac.Write([]byte(nonce + "\n" + seq + "\n" + data))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	send := func(line string) {
+		t.Helper()
+
+		if _, err := conn.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handshakeMAC := hmac.New(sha256.New, []byte("test_secret"))
+	handshakeMAC.Write([]byte(nonce))
+	send(hex.EncodeToString(handshakeMAC.Sum(nil)))
+
+	valid1 := `{"node":"peer","kind":"test","data":1}`
+	valid2 := `{"node":"peer","kind":"test","data":2}`
+	forged := `{"node":"peer","kind":"forged","data":1}`
+
+	send("1 " + sign("1", valid1) + " " + valid1)
+	expectEvent(t, events, "test", `1`)
+
+	// tampered data
+	send("2 " + sign("2", valid2) + " " + forged)
+	expectNoEvent(t, events)
+
+	// replayed line
+	send("1 " + sign("1", valid1) + " " + valid1)
+	expectNoEvent(t, events)
+
+	// signed with a different secret
+	wrongMAC := hmac.New(sha256.New, []byte("invalid"))
+	wrongMAC.Write([]byte(nonce + "\n2\n" + forged))
+	send("2 " + hex.EncodeToString(wrongMAC.Sum(nil)) + " " + forged)
+	expectNoEvent(t, events)
+
+	// unsigned line
+	send(forged)
+	expectNoEvent(t, events)
+
+	// the connection is still usable for the valid lines
+	send("2 " + sign("2", valid2) + " " + valid2)
+	expectEvent(t, events, "test", `2`)
+}
+
+func TestDBBackplane(t *testing.T) {
+	t.Parallel()
+
+	sqlDB, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "shared.db")+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	db := dbx.NewFromDB(sqlDB, "sqlite")
+
+	config := subscriptions.DBBackplaneConfig{PollInterval: 10 * time.Millisecond}
+
+	node1 := subscriptions.NewDBBackplane(db, config)
+	node2 := subscriptions.NewDBBackplane(db, config)
+
+	// publish before the table creation
+	if err := node1.Publish(context.Background(), subscriptions.BackplaneEvent{Kind: "old", Data: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := collectEvents(t, node2.Listen)
+
+	// wait for the listener to start
+	time.Sleep(100 * time.Millisecond)
+
+	for i, data := range []string{`1`, `2`, `3`} {
+		if err := node1.Publish(context.Background(), subscriptions.BackplaneEvent{Kind: "test", Data: []byte(data)}); err != nil {
+			t.Fatalf("[%d] publish error: %v", i, err)
+		}
+	}
+
+	// the events before the listen call are skipped
+	expectEvent(t, events, "test", `1`)
+	expectEvent(t, events, "test", `2`)
+	expectEvent(t, events, "test", `3`)
+	expectNoEvent(t, events)
+}
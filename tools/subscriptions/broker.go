@@ -1,24 +1,117 @@
 package subscriptions
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/security"
 	"github.com/pocketbase/pocketbase/tools/store"
 )
 
 // Broker defines a struct for managing subscriptions clients.
 type Broker struct {
-	store *store.Store[string, Client]
+	store        *store.Store[string, Client]
+	nodeId       string
+	backplane    Backplane
+	history      *History
+	channelRules []ChannelRules
+	mu           sync.RWMutex
 }
 
 // NewBroker initializes and returns a new Broker instance.
 func NewBroker() *Broker {
 	return &Broker{
-		store: store.New[string, Client](nil),
+		store:   store.New[string, Client](nil),
+		nodeId:  security.RandomString(15),
+		history: NewHistory(DefaultHistorySize),
 	}
 }
 
+// NodeId returns the unique broker node identifier used to
+// distinguish its own events when a backplane is set.
+func (b *Broker) NodeId() string {
+	return b.nodeId
+}
+
+// SetBackplane sets (or unsets if nil) the broker backplane
+// used for fanning out events to other broker nodes.
+func (b *Broker) SetBackplane(backplane Backplane) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.backplane = backplane
+}
+
+// Backplane returns the current broker backplane (if any).
+func (b *Broker) Backplane() Backplane {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.backplane
+}
+
+// SetHistory sets (or unsets if nil) the broker events history
+// used for replaying the missed events to reconnected clients.
+func (b *Broker) SetHistory(history *History) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.history = history
+}
+
+// History returns the current broker events history (if any).
+func (b *Broker) History() *History {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.history
+}
+
+// Publish sends a new event with the specified kind and JSON serialized
+// data to the other broker nodes.
+//
+// This method does nothing if the broker doesn't have a backplane.
+func (b *Broker) Publish(ctx context.Context, kind string, data any) error {
+	backplane := b.Backplane()
+	if backplane == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return backplane.Publish(ctx, BackplaneEvent{
+		Node: b.nodeId,
+		Kind: kind,
+		Data: raw,
+	})
+}
+
+// Listen starts receiving the backplane events published by the
+// other broker nodes (the events from the current node are skipped).
+//
+// It blocks until ctx is done or an unrecoverable error occurs
+// and returns immediately if the broker doesn't have a backplane.
+func (b *Broker) Listen(ctx context.Context, handler func(event BackplaneEvent)) error {
+	backplane := b.Backplane()
+	if backplane == nil {
+		return nil
+	}
+
+	return backplane.Listen(ctx, func(event BackplaneEvent) {
+		if event.Node == b.nodeId {
+			return
+		}
+
+		handler(event)
+	})
+}
+
 // Clients returns a shallow copy of all registered clients indexed
 // with their connection id.
 func (b *Broker) Clients() map[string]Client {
@@ -132,3 +132,22 @@ func TestUnregister(t *testing.T) {
 		t.Fatalf("Expected client with id %s, got error %v", clientB.Id(), err)
 	}
 }
+
+func TestBrokerHistory(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	if b.History() == nil {
+		t.Fatal("Expected default history to be set")
+	}
+
+	h := subscriptions.NewHistory(1)
+	b.SetHistory(h)
+	if b.History() != h {
+		t.Fatal("Expected the new history to be set")
+	}
+
+	b.SetHistory(nil)
+	if b.History() != nil {
+		t.Fatal("Expected the history to be unset")
+	}
+}
@@ -0,0 +1,66 @@
+package subscriptions
+
+import (
+	"slices"
+	"strings"
+)
+
+// ChannelRules defines the access rules of the custom channels
+// with name starting with Prefix.
+//
+// Similar to the collection API rules, a nil rule means that only
+// superusers are allowed and an empty string rule means that everyone is allowed.
+type ChannelRules struct {
+	// Prefix is the channel name prefix the rules apply to (e.g. "documents/").
+	Prefix string `json:"prefix"`
+
+	// ListenRule controls who could receive the channel messages
+	// and list the channel presence members.
+	ListenRule *string `json:"listenRule"`
+
+	// PublishRule controls who could publish messages to the channel.
+	PublishRule *string `json:"publishRule"`
+
+	// PresenceRule controls who could join the channel presence.
+	PresenceRule *string `json:"presenceRule"`
+}
+
+// SetChannelRules replaces the broker custom channel rules.
+func (b *Broker) SetChannelRules(rules ...ChannelRules) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.channelRules = slices.Clone(rules)
+}
+
+// ChannelRules returns a shallow copy of the registered custom channel rules.
+func (b *Broker) ChannelRules() []ChannelRules {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return slices.Clone(b.channelRules)
+}
+
+// FindChannelRules returns the channel rules with the longest prefix matching the channel name.
+//
+// Returns false if there are no rules for the channel (aka. the channel is not allowed).
+func (b *Broker) FindChannelRules(channel string) (ChannelRules, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var found ChannelRules
+	var ok bool
+
+	for _, rules := range b.channelRules {
+		if !strings.HasPrefix(channel, rules.Prefix) {
+			continue
+		}
+
+		if !ok || len(rules.Prefix) > len(found.Prefix) {
+			found = rules
+			ok = true
+		}
+	}
+
+	return found, ok
+}
@@ -0,0 +1,66 @@
+package subscriptions_test
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+func TestBrokerChannelRules(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	if total := len(b.ChannelRules()); total != 0 {
+		t.Fatalf("Expected no channel rules, got %d", total)
+	}
+
+	rule := "@request.auth.id != ''"
+
+	b.SetChannelRules(
+		subscriptions.ChannelRules{Prefix: "docs/"},
+		subscriptions.ChannelRules{Prefix: "docs/private/", ListenRule: &rule},
+		subscriptions.ChannelRules{Prefix: ""},
+	)
+
+	if total := len(b.ChannelRules()); total != 3 {
+		t.Fatalf("Expected 3 channel rules, got %d", total)
+	}
+
+	b.SetChannelRules(
+		subscriptions.ChannelRules{Prefix: "docs/"},
+		subscriptions.ChannelRules{Prefix: "docs/private/", ListenRule: &rule},
+	)
+
+	scenarios := []struct {
+		channel        string
+		expectedOk     bool
+		expectedPrefix string
+	}{
+		{"", false, ""},
+		{"missing", false, ""},
+		{"docs", false, ""},
+		{"docs/", true, "docs/"},
+		{"docs/abc", true, "docs/"},
+		{"docs/private", true, "docs/"},
+		{"docs/private/abc", true, "docs/private/"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.channel, func(t *testing.T) {
+			rules, ok := b.FindChannelRules(s.channel)
+
+			if ok != s.expectedOk {
+				t.Fatalf("Expected ok %v, got %v", s.expectedOk, ok)
+			}
+
+			if rules.Prefix != s.expectedPrefix {
+				t.Fatalf("Expected prefix %q, got %q", s.expectedPrefix, rules.Prefix)
+			}
+		})
+	}
+
+	// check if it is a shallow copy
+	b.ChannelRules()[0].Prefix = "changed/"
+	if _, ok := b.FindChannelRules("changed/abc"); ok {
+		t.Fatal("Expected the channel rules to not be modified")
+	}
+}
@@ -0,0 +1,175 @@
+package subscriptions
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the default max number of events kept per topic.
+const DefaultHistorySize = 100
+
+// HistoryEvent defines a single buffered history event.
+type HistoryEvent struct {
+	// Id is the monotonic event id (unique for the current History instance).
+	Id uint64
+
+	// Topic is the name of the topic the event was pushed to.
+	Topic string
+
+	// Data is the arbitrary event payload.
+	Data any
+}
+
+// historyRing is a fixed size circular buffer of topic events.
+type historyRing struct {
+	events []HistoryEvent
+	start  int
+	count  int
+
+	// evictedId is the id of the last overwritten event (if any)
+	evictedId uint64
+}
+
+func (r *historyRing) push(event HistoryEvent) {
+	if r.count < len(r.events) {
+		r.events[(r.start+r.count)%len(r.events)] = event
+		r.count++
+		return
+	}
+
+	r.evictedId = r.events[r.start].Id
+	r.events[r.start] = event
+	r.start = (r.start + 1) % len(r.events)
+}
+
+// History is a bounded per-topic ring buffer of recent events
+// used for replaying the missed events to reconnected clients.
+//
+// The event ids are monotonic across all topics of the same History
+// instance and start from the instance creation unix time (in microseconds)
+// so that the ids from a previous process run could be detected as stale.
+type History struct {
+	topics map[string]*historyRing
+	size   int
+	baseId uint64
+	lastId uint64
+	mu     sync.RWMutex
+}
+
+// NewHistory creates a new History instance that keeps at most
+// size events per topic (fallbacks to DefaultHistorySize if size <= 0).
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+
+	baseId := uint64(time.Now().UnixMicro())
+
+	return &History{
+		topics: map[string]*historyRing{},
+		size:   size,
+		baseId: baseId,
+		lastId: baseId,
+	}
+}
+
+// Size returns the max number of events kept per topic.
+func (h *History) Size() int {
+	return h.size
+}
+
+// LastId returns the id of the last pushed event
+// (or the history base id if there are no events yet).
+func (h *History) LastId() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.lastId
+}
+
+// Push appends a new event to the topic buffer, evicting the oldest
+// topic event if the buffer is full, and returns the new event id.
+func (h *History) Push(topic string, data any) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastId++
+
+	ring, ok := h.topics[topic]
+	if !ok {
+		ring = &historyRing{events: make([]HistoryEvent, h.size)}
+		h.topics[topic] = ring
+	}
+
+	ring.push(HistoryEvent{Id: h.lastId, Topic: topic, Data: data})
+
+	return h.lastId
+}
+
+// Since returns the buffered events of the specified topics with id
+// greater than lastId, sorted by their id.
+//
+// gap reports whether some of the events after lastId are no longer available,
+// either because they were evicted from the topic buffer or because lastId
+// is not from the current History instance (e.g. after a restart).
+func (h *History) Since(lastId uint64, topics ...string) (events []HistoryEvent, gap bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if lastId < h.baseId || lastId > h.lastId {
+		return nil, true
+	}
+
+	for i, topic := range topics {
+		if slices.Contains(topics[:i], topic) {
+			continue // duplicated topic
+		}
+
+		ring, ok := h.topics[topic]
+		if !ok {
+			continue
+		}
+
+		if ring.evictedId > lastId {
+			gap = true
+		}
+
+		for i := 0; i < ring.count; i++ {
+			event := ring.events[(ring.start+i)%len(ring.events)]
+			if event.Id > lastId {
+				events = append(events, event)
+			}
+		}
+	}
+
+	slices.SortFunc(events, func(a, b HistoryEvent) int {
+		if a.Id < b.Id {
+			return -1
+		}
+		if a.Id > b.Id {
+			return 1
+		}
+		return 0
+	})
+
+	return events, gap
+}
+
+// ParseHistoryId parses a string event id (e.g. the Last-Event-ID header value).
+//
+// Returns false if the value is not a valid history event id.
+func ParseHistoryId(raw string) (uint64, bool) {
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+
+	return id, true
+}
+
+// FormatHistoryId returns the string representation of a history event id.
+func FormatHistoryId(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
@@ -0,0 +1,155 @@
+package subscriptions_test
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+func TestNewHistory(t *testing.T) {
+	scenarios := []struct {
+		size     int
+		expected int
+	}{
+		{-1, subscriptions.DefaultHistorySize},
+		{0, subscriptions.DefaultHistorySize},
+		{5, 5},
+	}
+
+	for _, s := range scenarios {
+		h := subscriptions.NewHistory(s.size)
+
+		if h.Size() != s.expected {
+			t.Fatalf("[%d] Expected size %d, got %d", s.size, s.expected, h.Size())
+		}
+
+		if h.LastId() == 0 {
+			t.Fatalf("[%d] Expected non-zero base id", s.size)
+		}
+	}
+}
+
+func TestHistoryPushAndSince(t *testing.T) {
+	h := subscriptions.NewHistory(2)
+
+	baseId := h.LastId()
+
+	a1 := h.Push("a", "a1")
+	b1 := h.Push("b", "b1")
+	a2 := h.Push("a", "a2")
+
+	if a1 != baseId+1 || b1 != baseId+2 || a2 != baseId+3 {
+		t.Fatalf("Expected monotonic ids after %d, got %d, %d, %d", baseId, a1, b1, a2)
+	}
+
+	if h.LastId() != a2 {
+		t.Fatalf("Expected last id %d, got %d", a2, h.LastId())
+	}
+
+	scenarios := []struct {
+		name         string
+		lastId       uint64
+		topics       []string
+		expectedData []string
+		expectedGap  bool
+	}{
+		{"no topics", baseId, nil, nil, false},
+		{"missing topic", baseId, []string{"missing"}, nil, false},
+		{"single topic", baseId, []string{"a"}, []string{"a1", "a2"}, false},
+		{"multiple topics (sorted by id)", baseId, []string{"a", "b"}, []string{"a1", "b1", "a2"}, false},
+		{"duplicated topics", baseId, []string{"b", "b"}, []string{"b1"}, false},
+		{"after a specific id", a1, []string{"a", "b"}, []string{"b1", "a2"}, false},
+		{"up to date", a2, []string{"a", "b"}, nil, false},
+		{"stale id", baseId - 1, []string{"a"}, nil, true},
+		{"future id", a2 + 1, []string{"a"}, nil, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			events, gap := h.Since(s.lastId, s.topics...)
+
+			if gap != s.expectedGap {
+				t.Fatalf("Expected gap %v, got %v", s.expectedGap, gap)
+			}
+
+			if len(events) != len(s.expectedData) {
+				t.Fatalf("Expected %d events, got %d (%v)", len(s.expectedData), len(events), events)
+			}
+
+			for i, event := range events {
+				if event.Data != s.expectedData[i] {
+					t.Fatalf("Expected event %d data %q, got %v", i, s.expectedData[i], event.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestHistoryEviction(t *testing.T) {
+	h := subscriptions.NewHistory(2)
+
+	baseId := h.LastId()
+
+	h.Push("a", "a1")
+	a2 := h.Push("a", "a2")
+	h.Push("a", "a3")
+	h.Push("b", "b1")
+
+	// a1 was evicted
+	events, gap := h.Since(baseId, "a")
+	if !gap {
+		t.Fatal("Expected gap after the topic buffer was exceeded")
+	}
+	if len(events) != 2 || events[0].Data != "a2" || events[1].Data != "a3" {
+		t.Fatalf("Expected the last 2 topic events, got %v", events)
+	}
+
+	// a1 was evicted but it was already received
+	events, gap = h.Since(baseId+1, "a")
+	if gap {
+		t.Fatal("Expected no gap")
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %v", events)
+	}
+
+	// the other topics are not affected
+	events, gap = h.Since(baseId, "b")
+	if gap {
+		t.Fatal("Expected no gap for the other topic")
+	}
+	if len(events) != 1 || events[0].Data != "b1" {
+		t.Fatalf("Expected the b1 event, got %v", events)
+	}
+
+	events, _ = h.Since(a2, "a")
+	if len(events) != 1 || events[0].Data != "a3" {
+		t.Fatalf("Expected the a3 event, got %v", events)
+	}
+}
+
+func TestParseHistoryId(t *testing.T) {
+	scenarios := []struct {
+		raw        string
+		expectedId uint64
+		expectedOk bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"-1", 0, false},
+		{"abc", 0, false},
+		{"1.5", 0, false},
+		{"123", 123, true},
+		{subscriptions.FormatHistoryId(456), 456, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.raw, func(t *testing.T) {
+			id, ok := subscriptions.ParseHistoryId(s.raw)
+
+			if ok != s.expectedOk || id != s.expectedId {
+				t.Fatalf("Expected (%d, %v), got (%d, %v)", s.expectedId, s.expectedOk, id, ok)
+			}
+		})
+	}
+}
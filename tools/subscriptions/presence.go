@@ -0,0 +1,114 @@
+package subscriptions
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// presenceKey is the name of the client store key that holds its presence state.
+const presenceKey = "@presence"
+
+// presenceMu guards the read-modify-write of the clients presence state.
+var presenceMu sync.Mutex
+
+// PresenceMember defines the presence state of a single client in a channel.
+type PresenceMember struct {
+	ClientId string         `json:"clientId"`
+	Channel  string         `json:"channel"`
+	Auth     map[string]any `json:"auth"`
+	Meta     map[string]any `json:"meta"`
+	JoinedAt time.Time      `json:"joinedAt"`
+	LastSeen time.Time      `json:"lastSeen"`
+}
+
+// IsStale checks whether the member hasn't been seen for longer than timeout.
+func (m *PresenceMember) IsStale(timeout time.Duration) bool {
+	return time.Since(m.LastSeen) > timeout
+}
+
+// ClientPresence returns a shallow copy of the client presence
+// state indexed with the joined channel names.
+func ClientPresence(client Client) map[string]PresenceMember {
+	state, _ := client.Get(presenceKey).(map[string]PresenceMember)
+
+	return maps.Clone(state)
+}
+
+// SetClientPresence adds or replaces the client presence state for member.Channel.
+func SetClientPresence(client Client, member PresenceMember) {
+	presenceMu.Lock()
+	defer presenceMu.Unlock()
+
+	state, _ := client.Get(presenceKey).(map[string]PresenceMember)
+	state = maps.Clone(state)
+	if state == nil {
+		state = map[string]PresenceMember{}
+	}
+
+	state[member.Channel] = member
+
+	client.Set(presenceKey, state)
+}
+
+// UnsetClientPresence removes the client presence state for the specified channels
+// (or for all channels if none is specified) and returns the removed members.
+func UnsetClientPresence(client Client, channels ...string) []PresenceMember {
+	presenceMu.Lock()
+	defer presenceMu.Unlock()
+
+	state, _ := client.Get(presenceKey).(map[string]PresenceMember)
+	if len(state) == 0 {
+		return nil
+	}
+
+	state = maps.Clone(state)
+
+	var removed []PresenceMember
+	for channel, member := range state {
+		if len(channels) > 0 && !slices.Contains(channels, channel) {
+			continue
+		}
+
+		removed = append(removed, member)
+		delete(state, channel)
+	}
+
+	if len(state) == 0 {
+		client.Unset(presenceKey)
+	} else {
+		client.Set(presenceKey, state)
+	}
+
+	return removed
+}
+
+// PresenceMembers returns the presence members of the specified channel
+// from all registered clients, sorted by their join time.
+//
+// The members that are not seen for longer than timeout are skipped (if timeout > 0).
+func (b *Broker) PresenceMembers(channel string, timeout time.Duration) []PresenceMember {
+	var members []PresenceMember
+
+	for _, client := range b.store.Values() {
+		state, _ := client.Get(presenceKey).(map[string]PresenceMember)
+
+		member, ok := state[channel]
+		if !ok || (timeout > 0 && member.IsStale(timeout)) {
+			continue
+		}
+
+		members = append(members, member)
+	}
+
+	slices.SortFunc(members, func(a, b PresenceMember) int {
+		if c := a.JoinedAt.Compare(b.JoinedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ClientId, b.ClientId)
+	})
+
+	return members
+}
@@ -0,0 +1,115 @@
+package subscriptions_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+func TestClientPresence(t *testing.T) {
+	client := subscriptions.NewDefaultClient()
+
+	if total := len(subscriptions.ClientPresence(client)); total != 0 {
+		t.Fatalf("Expected no presence state, got %d", total)
+	}
+
+	subscriptions.SetClientPresence(client, subscriptions.PresenceMember{ClientId: client.Id(), Channel: "a"})
+	subscriptions.SetClientPresence(client, subscriptions.PresenceMember{ClientId: client.Id(), Channel: "b"})
+	subscriptions.SetClientPresence(client, subscriptions.PresenceMember{ClientId: client.Id(), Channel: "c", Meta: map[string]any{"test": 1}})
+
+	presence := subscriptions.ClientPresence(client)
+	if len(presence) != 3 {
+		t.Fatalf("Expected 3 channels, got %v", presence)
+	}
+	if presence["c"].Meta["test"] != 1 {
+		t.Fatalf("Expected channel c meta to be set, got %v", presence["c"].Meta)
+	}
+
+	// check if it is a shallow copy
+	delete(presence, "a")
+	if total := len(subscriptions.ClientPresence(client)); total != 3 {
+		t.Fatalf("Expected the state to not be modified, got %d channels", total)
+	}
+
+	removed := subscriptions.UnsetClientPresence(client, "a", "missing")
+	if len(removed) != 1 || removed[0].Channel != "a" {
+		t.Fatalf("Expected only channel a to be removed, got %v", removed)
+	}
+
+	removed = subscriptions.UnsetClientPresence(client)
+	if len(removed) != 2 {
+		t.Fatalf("Expected the remaining 2 channels to be removed, got %v", removed)
+	}
+
+	if total := len(subscriptions.ClientPresence(client)); total != 0 {
+		t.Fatalf("Expected no presence state, got %d", total)
+	}
+
+	if removed := subscriptions.UnsetClientPresence(client); len(removed) != 0 {
+		t.Fatalf("Expected nothing to be removed, got %v", removed)
+	}
+}
+
+func TestPresenceMemberIsStale(t *testing.T) {
+	member := subscriptions.PresenceMember{LastSeen: time.Now().Add(-time.Minute)}
+
+	if member.IsStale(2 * time.Minute) {
+		t.Fatal("Expected non-stale member")
+	}
+
+	if !member.IsStale(30 * time.Second) {
+		t.Fatal("Expected stale member")
+	}
+}
+
+func TestBrokerPresenceMembers(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	now := time.Now()
+
+	c1 := subscriptions.NewDefaultClient()
+	subscriptions.SetClientPresence(c1, subscriptions.PresenceMember{ClientId: c1.Id(), Channel: "a", JoinedAt: now, LastSeen: now})
+	b.Register(c1)
+
+	c2 := subscriptions.NewDefaultClient()
+	subscriptions.SetClientPresence(c2, subscriptions.PresenceMember{ClientId: c2.Id(), Channel: "a", JoinedAt: now.Add(-time.Hour), LastSeen: now})
+	subscriptions.SetClientPresence(c2, subscriptions.PresenceMember{ClientId: c2.Id(), Channel: "b", JoinedAt: now, LastSeen: now})
+	b.Register(c2)
+
+	c3 := subscriptions.NewDefaultClient()
+	subscriptions.SetClientPresence(c3, subscriptions.PresenceMember{ClientId: c3.Id(), Channel: "a", JoinedAt: now.Add(time.Second), LastSeen: now.Add(-time.Hour)})
+	b.Register(c3)
+
+	// not registered
+	c4 := subscriptions.NewDefaultClient()
+	subscriptions.SetClientPresence(c4, subscriptions.PresenceMember{ClientId: c4.Id(), Channel: "a", JoinedAt: now, LastSeen: now})
+
+	scenarios := []struct {
+		name     string
+		channel  string
+		timeout  time.Duration
+		expected []string
+	}{
+		{"missing channel", "missing", 0, nil},
+		{"without timeout", "a", 0, []string{c2.Id(), c1.Id(), c3.Id()}},
+		{"with timeout", "a", time.Minute, []string{c2.Id(), c1.Id()}},
+		{"other channel", "b", time.Minute, []string{c2.Id()}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			members := b.PresenceMembers(s.channel, s.timeout)
+
+			if len(members) != len(s.expected) {
+				t.Fatalf("Expected %d members, got %d", len(s.expected), len(members))
+			}
+
+			for i, member := range members {
+				if member.ClientId != s.expected[i] {
+					t.Fatalf("Expected member %d to be %q, got %q", i, s.expected[i], member.ClientId)
+				}
+			}
+		})
+	}
+}
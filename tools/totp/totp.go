@@ -0,0 +1,183 @@
+// Package totp implements the RFC 6238 time-based one-time passwords
+// compatible with the common authenticator apps (HMAC-SHA1).
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultDigits is the default number of the generated code digits.
+	DefaultDigits = 6
+
+	// DefaultPeriod is the default code time step in seconds.
+	DefaultPeriod int64 = 30
+
+	// SecretSize is the size in bytes of the generated secrets (RFC 4226 recommends 160 bits).
+	SecretSize = 20
+)
+
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Options defines the codes generation and verification options.
+type Options struct {
+	// Digits is the number of the code digits (6 or 8, default to [DefaultDigits]).
+	Digits int
+
+	// Period is the time step in seconds (default to [DefaultPeriod]).
+	Period int64
+
+	// Skew is the number of the allowed time steps before and after the
+	// current one to compensate for clock drift (aka. the drift window).
+	Skew int
+}
+
+func (o Options) digits() int {
+	if o.Digits <= 0 {
+		return DefaultDigits
+	}
+	return o.Digits
+}
+
+func (o Options) period() int64 {
+	if o.Period <= 0 {
+		return DefaultPeriod
+	}
+	return o.Period
+}
+
+// NewSecret generates a new random base32 encoded (unpadded) secret.
+func NewSecret() string {
+	secret := make([]byte, SecretSize)
+
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+
+	return base32Encoding.EncodeToString(secret)
+}
+
+// decodeSecret decodes the base32 secret ignoring the case, spaces and padding.
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+
+	key, err := base32Encoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("totp: invalid secret: %w", err)
+	}
+
+	if len(key) == 0 {
+		return nil, errors.New("totp: empty secret")
+	}
+
+	return key, nil
+}
+
+// Step returns the time step (aka. counter) of the specified time.
+func Step(t time.Time, opts Options) int64 {
+	return t.Unix() / opts.period()
+}
+
+// CodeAt generates the code for the specified time step.
+func CodeAt(secret string, step int64, opts Options) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hotp(key, step, opts.digits()), nil
+}
+
+// Code generates the code for the specified time.
+func Code(secret string, t time.Time, opts Options) (string, error) {
+	return CodeAt(secret, Step(t, opts), opts)
+}
+
+// Validate checks whether code is valid for the specified time
+// within the configured drift window.
+//
+// On success returns the matched time step that could be stored
+// and used to reject the already used codes.
+func Validate(secret string, code string, t time.Time, opts Options) (int64, bool) {
+	digits := opts.digits()
+
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != digits {
+		return 0, false
+	}
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := Step(t, opts)
+
+	for i := -opts.Skew; i <= opts.Skew; i++ {
+		step := current + int64(i)
+		if step < 0 {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step, digits)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// ProvisioningURI returns the otpauth:// key URI that the authenticator
+// apps could import (usually rendered as QR code).
+//
+// See https://github.com/google/google-authenticator/wiki/Key-Uri-Format.
+func ProvisioningURI(secret string, issuer string, account string, opts Options) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	if issuer != "" {
+		params.Set("issuer", issuer)
+	}
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", strconv.Itoa(opts.digits()))
+	params.Set("period", strconv.FormatInt(opts.period(), 10))
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// hotp implements the RFC 4226 HOTP algorithm.
+func hotp(key []byte, counter int64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
@@ -0,0 +1,176 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/totp"
+)
+
+// RFC 6238 Appendix B test secret ("12345678901234567890")
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestNewSecret(t *testing.T) {
+	t.Parallel()
+
+	s1 := totp.NewSecret()
+	s2 := totp.NewSecret()
+
+	if s1 == s2 {
+		t.Fatal("Expected different secrets")
+	}
+
+	// 20 bytes -> 32 base32 chars
+	if len(s1) != 32 {
+		t.Fatalf("Expected secret with length 32, got %d (%q)", len(s1), s1)
+	}
+
+	if _, err := totp.Code(s1, time.Now(), totp.Options{}); err != nil {
+		t.Fatalf("Expected valid secret, got %v", err)
+	}
+}
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	// RFC 6238 Appendix B (SHA1)
+	scenarios := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.expected, func(t *testing.T) {
+			code, err := totp.Code(rfcSecret, time.Unix(s.unix, 0), totp.Options{Digits: 8})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if code != s.expected {
+				t.Fatalf("Expected code %q, got %q", s.expected, code)
+			}
+		})
+	}
+
+	t.Run("default 6 digits", func(t *testing.T) {
+		code, err := totp.Code(rfcSecret, time.Unix(59, 0), totp.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != "287082" {
+			t.Fatalf("Expected code %q, got %q", "287082", code)
+		}
+	})
+
+	t.Run("invalid secret", func(t *testing.T) {
+		if _, err := totp.Code("!@#", time.Now(), totp.Options{}); err == nil {
+			t.Fatal("Expected invalid secret error")
+		}
+	})
+
+	t.Run("lowercase secret with spaces and padding", func(t *testing.T) {
+		secret := strings.ToLower(rfcSecret[:4]) + " " + rfcSecret[4:] + "===="
+
+		code, err := totp.Code(secret, time.Unix(59, 0), totp.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != "287082" {
+			t.Fatalf("Expected code %q, got %q", "287082", code)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1111111111, 0)
+	opts := totp.Options{Skew: 1}
+
+	current, _ := totp.Code(rfcSecret, now, opts)
+	prev, _ := totp.Code(rfcSecret, now.Add(-30*time.Second), opts)
+	next, _ := totp.Code(rfcSecret, now.Add(30*time.Second), opts)
+	old, _ := totp.Code(rfcSecret, now.Add(-60*time.Second), opts)
+
+	currentStep := totp.Step(now, opts)
+
+	scenarios := []struct {
+		name         string
+		code         string
+		opts         totp.Options
+		expectedStep int64
+		expectedOk   bool
+	}{
+		{"empty", "", opts, 0, false},
+		{"invalid length", current + "1", opts, 0, false},
+		{"wrong code", "000000", opts, 0, false},
+		{"current", current, opts, currentStep, true},
+		{"current with spaces", current[:3] + " " + current[3:], opts, currentStep, true},
+		{"previous step", prev, opts, currentStep - 1, true},
+		{"next step", next, opts, currentStep + 1, true},
+		{"outside the drift window", old, opts, 0, false},
+		{"previous step without skew", prev, totp.Options{}, 0, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			step, ok := totp.Validate(rfcSecret, s.code, now, s.opts)
+
+			if ok != s.expectedOk {
+				t.Fatalf("Expected ok %v, got %v", s.expectedOk, ok)
+			}
+
+			if step != s.expectedStep {
+				t.Fatalf("Expected step %d, got %d", s.expectedStep, step)
+			}
+		})
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name     string
+		issuer   string
+		account  string
+		opts     totp.Options
+		expected string
+	}{
+		{
+			"without issuer",
+			"",
+			"test@example.com",
+			totp.Options{},
+			"otpauth://totp/test@example.com?algorithm=SHA1&digits=6&period=30&secret=ABC",
+		},
+		{
+			"with issuer and custom options",
+			"My App",
+			"test user",
+			totp.Options{Digits: 8, Period: 60},
+			"otpauth://totp/My%20App:test%20user?algorithm=SHA1&digits=8&issuer=My+App&period=60&secret=ABC",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			uri := totp.ProvisioningURI("ABC", s.issuer, s.account, s.opts)
+
+			if uri != s.expected {
+				t.Fatalf("Expected\n%s\ngot\n%s", s.expected, uri)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+var _ Exporter = (*InMemoryExporter)(nil)
+
+// InMemoryExporter is a span exporter that stores the exported spans
+// in memory (usually used for tests and debugging).
+type InMemoryExporter struct {
+	spans []*Span
+	mu    sync.RWMutex
+}
+
+// NewInMemoryExporter creates a new empty InMemoryExporter instance.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpans implements [Exporter.ExportSpans] interface method.
+func (e *InMemoryExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, spans...)
+
+	return nil
+}
+
+// Spans returns a shallow copy of all exported spans.
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := make([]*Span, len(e.spans))
+	copy(result, e.spans)
+
+	return result
+}
+
+// FindByName returns all exported spans with the specified name.
+func (e *InMemoryExporter) FindByName(name string) []*Span {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var result []*Span
+	for _, s := range e.spans {
+		if s.Name() == name {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
+
+// Reset removes all stored spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = nil
+}
@@ -0,0 +1,41 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/tracing"
+)
+
+func TestInMemoryExporter(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracing.NewInMemoryExporter()
+
+	tracer := tracing.New(tracing.Config{Exporter: exporter})
+
+	for _, name := range []string{"a", "b", "a"} {
+		_, span := tracer.Start(context.Background(), name)
+		span.End()
+	}
+
+	tracer.Flush(context.Background())
+
+	if total := len(exporter.Spans()); total != 3 {
+		t.Fatalf("Expected 3 spans, got %d", total)
+	}
+
+	if total := len(exporter.FindByName("a")); total != 2 {
+		t.Fatalf("Expected 2 spans with name a, got %d", total)
+	}
+
+	if total := len(exporter.FindByName("missing")); total != 0 {
+		t.Fatalf("Expected 0 spans with name missing, got %d", total)
+	}
+
+	exporter.Reset()
+
+	if total := len(exporter.Spans()); total != 0 {
+		t.Fatalf("Expected 0 spans after reset, got %d", total)
+	}
+}
@@ -0,0 +1,250 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const otlpTracesPath = "/v1/traces"
+
+var _ Exporter = (*OTLPExporter)(nil)
+
+// OTLPConfig defines the [OTLPExporter] configuration options.
+type OTLPConfig struct {
+	// Endpoint is the collector base url (eg. "http://localhost:4318")
+	// or the full traces url (eg. "http://localhost:4318/v1/traces").
+	Endpoint string
+
+	// Headers specifies optional extra request headers (eg. for authorization).
+	Headers map[string]string
+
+	// ServiceName is exported as the "service.name" resource attribute.
+	ServiceName string
+
+	// HTTPClient is an optional custom http client used to send the export requests.
+	HTTPClient *http.Client
+}
+
+// OTLPExporter exports spans to an OpenTelemetry collector
+// using the OTLP/HTTP protocol with JSON encoding.
+type OTLPExporter struct {
+	client *http.Client
+	config OTLPConfig
+	url    string
+}
+
+// NewOTLPExporter creates a new OTLPExporter from the provided config.
+func NewOTLPExporter(config OTLPConfig) *OTLPExporter {
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	exportURL := strings.TrimRight(config.Endpoint, "/")
+	if !strings.HasSuffix(exportURL, otlpTracesPath) {
+		exportURL += otlpTracesPath
+	}
+
+	return &OTLPExporter{
+		client: client,
+		config: config,
+		url:    exportURL,
+	}
+}
+
+// URL returns the collector traces url where the spans are sent.
+func (e *OTLPExporter) URL() string {
+	return e.url
+}
+
+// ExportSpans implements [Exporter.ExportSpans] interface method.
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(e.payload(spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("OTLP collector responded with %d: %s", res.StatusCode, msg)
+	}
+
+	return nil
+}
+
+// payload builds an OTLP ExportTraceServiceRequest JSON object
+// (https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding).
+func (e *OTLPExporter) payload(spans []*Span) map[string]any {
+	items := make([]map[string]any, 0, len(spans))
+
+	for _, s := range spans {
+		s.mu.RLock()
+		item := map[string]any{
+			"traceId":           s.traceId.String(),
+			"spanId":            s.spanId.String(),
+			"name":              s.name,
+			"kind":              int(s.kind),
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.attributes),
+			"status": map[string]any{
+				"code":    int(s.statusCode),
+				"message": s.statusMessage,
+			},
+		}
+		if s.parentSpanId.IsValid() {
+			item["parentSpanId"] = s.parentSpanId.String()
+		}
+		s.mu.RUnlock()
+
+		items = append(items, item)
+	}
+
+	return map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": e.config.ServiceName}),
+			},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]any{"name": "github.com/pocketbase/pocketbase"},
+				"spans": items,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs map[string]any) []map[string]any {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]map[string]any, 0, len(keys))
+
+	for _, k := range keys {
+		var value map[string]any
+
+		switch v := attrs[k].(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case int:
+			value = map[string]any{"intValue": strconv.FormatInt(int64(v), 10)}
+		case int32:
+			value = map[string]any{"intValue": strconv.FormatInt(int64(v), 10)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float32:
+			value = map[string]any{"doubleValue": float64(v)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+
+		result = append(result, map[string]any{"key": k, "value": value})
+	}
+
+	return result
+}
+
+// NewFromEnv creates a new Tracer with OTLP exporter configured
+// from the standard OpenTelemetry environment variables:
+//
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT
+//	OTEL_EXPORTER_OTLP_TRACES_HEADERS or OTEL_EXPORTER_OTLP_HEADERS (eg. "api-key=123,x-tenant=abc")
+//	OTEL_SERVICE_NAME (fallbacks to defaultServiceName)
+//	OTEL_TRACES_SAMPLER_ARG (sample ratio in the range (0, 1])
+//	OTEL_SDK_DISABLED
+//
+// Returns nil if no endpoint is configured or the SDK is explicitly disabled.
+func NewFromEnv(defaultServiceName string) *Tracer {
+	if disabled, _ := strconv.ParseBool(os.Getenv("OTEL_SDK_DISABLED")); disabled {
+		return nil
+	}
+
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		return nil
+	}
+
+	rawHeaders := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")
+	if rawHeaders == "" {
+		rawHeaders = os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	ratio, _ := strconv.ParseFloat(os.Getenv("OTEL_TRACES_SAMPLER_ARG"), 64)
+
+	return New(Config{
+		SampleRatio: ratio,
+		Exporter: NewOTLPExporter(OTLPConfig{
+			Endpoint:    endpoint,
+			Headers:     parseEnvHeaders(rawHeaders),
+			ServiceName: serviceName,
+		}),
+	})
+}
+
+// parseEnvHeaders parses a comma separated list of url encoded key=value pairs.
+func parseEnvHeaders(raw string) map[string]string {
+	result := map[string]string{}
+
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = unescaped
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
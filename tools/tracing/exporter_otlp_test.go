@@ -0,0 +1,182 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/tracing"
+)
+
+func TestOTLPExporterURL(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		endpoint string
+		expected string
+	}{
+		{"http://localhost:4318", "http://localhost:4318/v1/traces"},
+		{"http://localhost:4318/", "http://localhost:4318/v1/traces"},
+		{"http://localhost:4318/v1/traces", "http://localhost:4318/v1/traces"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.endpoint, func(t *testing.T) {
+			exporter := tracing.NewOTLPExporter(tracing.OTLPConfig{Endpoint: s.endpoint})
+			if exporter.URL() != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, exporter.URL())
+			}
+		})
+	}
+}
+
+func TestOTLPExporterExportSpans(t *testing.T) {
+	t.Parallel()
+
+	var body []byte
+	var headers http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := tracing.NewOTLPExporter(tracing.OTLPConfig{
+		Endpoint:    server.URL,
+		ServiceName: "test_service",
+		Headers:     map[string]string{"x-api-key": "123"},
+	})
+
+	tracer := tracing.New(tracing.Config{Exporter: exporter})
+
+	ctx, root := tracer.Start(context.Background(), "root", tracing.WithKind(tracing.SpanKindServer))
+	_, child := tracer.Start(ctx, "child", tracing.WithAttributes(map[string]any{
+		"str":   "a",
+		"int":   1,
+		"float": 1.5,
+		"bool":  true,
+	}))
+	child.RecordError(errors.New("test_error"))
+	child.End()
+	root.End()
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := headers.Get("x-api-key"); v != "123" {
+		t.Fatalf("Expected x-api-key header 123, got %q", v)
+	}
+
+	if v := headers.Get("Content-Type"); v != "application/json" {
+		t.Fatalf("Expected application/json content type, got %q", v)
+	}
+
+	raw := string(body)
+
+	expectedParts := []string{
+		`"resourceSpans":[`,
+		`{"key":"service.name","value":{"stringValue":"test_service"}}`,
+		`"traceId":"` + root.TraceID().String() + `"`,
+		`"spanId":"` + child.SpanID().String() + `"`,
+		`"parentSpanId":"` + root.SpanID().String() + `"`,
+		`"name":"child"`,
+		`"name":"root"`,
+		`"kind":2`,
+		`{"key":"bool","value":{"boolValue":true}}`,
+		`{"key":"float","value":{"doubleValue":1.5}}`,
+		`{"key":"int","value":{"intValue":"1"}}`,
+		`{"key":"str","value":{"stringValue":"a"}}`,
+		`"status":{"code":2,"message":"test_error"}`,
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(raw, part) {
+			t.Errorf("Missing %s in\n%s", part, raw)
+		}
+	}
+
+	// ensure that it is a valid json
+	if !json.Valid(body) {
+		t.Fatalf("Invalid json body %s", raw)
+	}
+}
+
+func TestOTLPExporterExportSpansFailure(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid payload"))
+	}))
+	defer server.Close()
+
+	tracer := tracing.New(tracing.Config{
+		Exporter: tracing.NewOTLPExporter(tracing.OTLPConfig{Endpoint: server.URL}),
+	})
+
+	_, span := tracer.Start(context.Background(), "test")
+	span.End()
+
+	err := tracer.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid payload") {
+		t.Fatalf("Expected collector error, got %v", err)
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("OTEL_SDK_DISABLED", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	if tracer := tracing.NewFromEnv("test"); tracer != nil {
+		t.Fatal("Expected nil tracer without configured endpoint")
+	}
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic abc=" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Basic%20abc%3D, invalid")
+	t.Setenv("OTEL_SERVICE_NAME", "env_service")
+
+	tracer := tracing.NewFromEnv("test")
+	if tracer == nil {
+		t.Fatal("Expected non-nil tracer")
+	}
+
+	_, span := tracer.Start(context.Background(), "test")
+	span.End()
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(body), `"stringValue":"env_service"`) {
+		t.Fatalf("Expected env_service resource attribute, got %s", body)
+	}
+
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+
+	if tracer := tracing.NewFromEnv("test"); tracer != nil {
+		t.Fatal("Expected nil tracer when the SDK is disabled")
+	}
+}
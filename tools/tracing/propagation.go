@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceParentHeader is the W3C Trace Context propagation header name.
+const TraceParentHeader = "traceparent"
+
+// Inject writes the W3C traceparent header of the current ctx span (if any) into h.
+func Inject(ctx context.Context, h http.Header) {
+	span := SpanFromContext(ctx)
+	if span == nil || !span.traceId.IsValid() {
+		return
+	}
+
+	flags := "00"
+	if span.sampled {
+		flags = "01"
+	}
+
+	h.Set(TraceParentHeader, "00-"+span.traceId.String()+"-"+span.spanId.String()+"-"+flags)
+}
+
+// Extract parses the W3C traceparent header from h and returns a copy of ctx
+// holding the remote span so that the next started span continues the same trace.
+//
+// Returns the unmodified ctx if the header is missing or invalid.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	remote, ok := parseTraceParent(h.Get(TraceParentHeader))
+	if !ok {
+		return ctx
+	}
+
+	return ContextWithSpan(ctx, remote)
+}
+
+// parseTraceParent parses a version 00 traceparent header value
+// (eg. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01").
+func parseTraceParent(value string) (*Span, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return nil, false
+	}
+
+	span := &Span{ended: true}
+
+	if _, err := hex.Decode(span.traceId[:], []byte(parts[1])); err != nil || !span.traceId.IsValid() {
+		return nil, false
+	}
+
+	if _, err := hex.Decode(span.spanId[:], []byte(parts[2])); err != nil || !span.spanId.IsValid() {
+		return nil, false
+	}
+
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return nil, false
+	}
+	span.sampled = flags[0]&0x01 == 0x01
+
+	return span, true
+}
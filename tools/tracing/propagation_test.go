@@ -0,0 +1,93 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/tracing"
+)
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		header      string
+		expectValid bool
+		expectTrace string
+		expectSpan  string
+		expectFlag  bool
+	}{
+		{"", false, "", "", false},
+		{"invalid", false, "", "", false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, "", "", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, "", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, "", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", false, "", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.header, func(t *testing.T) {
+			h := http.Header{}
+			h.Set(tracing.TraceParentHeader, s.header)
+
+			span := tracing.SpanFromContext(tracing.Extract(context.Background(), h))
+
+			if (span != nil) != s.expectValid {
+				t.Fatalf("Expected valid %v, got %v", s.expectValid, span != nil)
+			}
+
+			if !s.expectValid {
+				return
+			}
+
+			if span.TraceID().String() != s.expectTrace {
+				t.Fatalf("Expected trace id %q, got %q", s.expectTrace, span.TraceID())
+			}
+
+			if span.SpanID().String() != s.expectSpan {
+				t.Fatalf("Expected span id %q, got %q", s.expectSpan, span.SpanID())
+			}
+
+			if span.IsSampled() != s.expectFlag {
+				t.Fatalf("Expected sampled %v, got %v", s.expectFlag, span.IsSampled())
+			}
+		})
+	}
+}
+
+func TestExtractAndInject(t *testing.T) {
+	t.Parallel()
+
+	tracer := tracing.New(tracing.Config{Exporter: tracing.NewInMemoryExporter()})
+
+	h := http.Header{}
+	h.Set(tracing.TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx, span := tracer.Start(tracing.Extract(context.Background(), h), "test")
+
+	if span.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("Expected the remote trace id to be continued, got %s", span.TraceID())
+	}
+
+	if span.ParentSpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("Expected the remote span as parent, got %s", span.ParentSpanID())
+	}
+
+	out := http.Header{}
+	tracing.Inject(ctx, out)
+
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + span.SpanID().String() + "-01"
+	if v := out.Get(tracing.TraceParentHeader); v != expected {
+		t.Fatalf("Expected traceparent %q, got %q", expected, v)
+	}
+
+	// no span
+	out = http.Header{}
+	tracing.Inject(context.Background(), out)
+	if v := out.Get(tracing.TraceParentHeader); v != "" {
+		t.Fatalf("Expected empty traceparent, got %q", v)
+	}
+}
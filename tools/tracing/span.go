@@ -0,0 +1,279 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"maps"
+	"sync"
+	"time"
+)
+
+// TraceID defines a W3C compatible 16 bytes trace identifier.
+type TraceID [16]byte
+
+// IsValid reports whether the trace id is non-zero.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String returns the lowercase hex representation of the trace id.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID defines a W3C compatible 8 bytes span identifier.
+type SpanID [8]byte
+
+// IsValid reports whether the span id is non-zero.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// String returns the lowercase hex representation of the span id.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanKind describes the relationship between the span and its parent
+// (the values match the OTLP SpanKind enum).
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode defines the span status (the values match the OTLP StatusCode enum).
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOk    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Span represents a single timed operation within a trace.
+//
+// All Span methods are safe to be called on a nil Span (they are no-op).
+type Span struct {
+	tracer        *Tracer
+	attributes    map[string]any
+	start         time.Time
+	end           time.Time
+	name          string
+	statusMessage string
+	kind          SpanKind
+	statusCode    StatusCode
+	traceId       TraceID
+	spanId        SpanID
+	parentSpanId  SpanID
+	sampled       bool
+	ended         bool
+	mu            sync.RWMutex
+}
+
+// TraceID returns the id of the trace the span belongs to.
+func (s *Span) TraceID() TraceID {
+	if s == nil {
+		return TraceID{}
+	}
+
+	return s.traceId
+}
+
+// SpanID returns the span identifier.
+func (s *Span) SpanID() SpanID {
+	if s == nil {
+		return SpanID{}
+	}
+
+	return s.spanId
+}
+
+// ParentSpanID returns the parent span identifier (if any).
+func (s *Span) ParentSpanID() SpanID {
+	if s == nil {
+		return SpanID{}
+	}
+
+	return s.parentSpanId
+}
+
+// Name returns the span name.
+func (s *Span) Name() string {
+	if s == nil {
+		return ""
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.name
+}
+
+// SetName updates the span name (eg. once the matched route pattern is known).
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+
+// Kind returns the span kind.
+func (s *Span) Kind() SpanKind {
+	if s == nil {
+		return 0
+	}
+
+	return s.kind
+}
+
+// IsSampled reports whether the span will be exported once ended.
+func (s *Span) IsSampled() bool {
+	if s == nil {
+		return false
+	}
+
+	return s.sampled
+}
+
+// StartTime returns the span start time.
+func (s *Span) StartTime() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+
+	return s.start
+}
+
+// EndTime returns the span end time (zero if not ended yet).
+func (s *Span) EndTime() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.end
+}
+
+// Duration returns the span duration (zero if not ended yet).
+func (s *Span) Duration() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.ended {
+		return 0
+	}
+
+	return s.end.Sub(s.start)
+}
+
+// Attributes returns a shallow copy of the span attributes.
+func (s *Span) Attributes() map[string]any {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.attributes)
+}
+
+// SetAttribute sets a single span attribute.
+//
+// Supported value types are string, bool, all int and float variants
+// (any other value is stringified on export).
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil || !s.sampled {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	if s.attributes == nil {
+		s.attributes = map[string]any{}
+	}
+	s.attributes[key] = value
+}
+
+// Status returns the span status code and message.
+func (s *Span) Status() (StatusCode, string) {
+	if s == nil {
+		return StatusUnset, ""
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.statusCode, s.statusMessage
+}
+
+// SetStatus updates the span status.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil || !s.sampled {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.statusCode = code
+	s.statusMessage = message
+}
+
+// RecordError marks the span as failed with the provided error.
+//
+// It does nothing if err is nil.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End completes the span and queues it for export.
+//
+// Calling End more than once has no effect.
+func (s *Span) End() {
+	s.EndAt(time.Now())
+}
+
+// EndAt is similar to [Span.End] but with explicit end time.
+func (s *Span) EndAt(t time.Time) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = t
+	s.mu.Unlock()
+
+	if s.sampled && s.tracer != nil {
+		s.tracer.enqueue(s)
+	}
+}
@@ -0,0 +1,316 @@
+// Package tracing implements a minimal OpenTelemetry compatible tracer
+// with OTLP/HTTP (JSON) and in-memory span exporters.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 5 * time.Second
+	DefaultMaxQueueSize  = 4096
+)
+
+// Exporter defines a common interface for exporting the ended spans.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []*Span) error
+}
+
+// Config defines the [Tracer] configuration options.
+type Config struct {
+	// Exporter is the exporter used to export the ended spans (required).
+	Exporter Exporter
+
+	// ErrorHandler is an optional function that is called when the spans export fails.
+	//
+	// If not set, the error is printed with the std logger.
+	ErrorHandler func(err error)
+
+	// SampleRatio specifies the ratio of the root traces to record
+	// in the range (0, 1]. Zero or negative values fallback to 1 (aka. record everything).
+	//
+	// Child spans always inherit the sampling decision of their parent.
+	SampleRatio float64
+
+	// BatchSize specifies the number of ended spans that triggers an export
+	// (default to [DefaultBatchSize]).
+	BatchSize int
+
+	// FlushInterval specifies the max duration an ended span could wait
+	// in the queue before being exported (default to [DefaultFlushInterval]).
+	FlushInterval time.Duration
+
+	// MaxQueueSize specifies the max number of queued spans waiting to be exported.
+	// New spans are dropped once the limit is reached (default to [DefaultMaxQueueSize]).
+	MaxQueueSize int
+}
+
+// Tracer creates spans and batches them for export.
+//
+// All Tracer methods are safe to be called on a nil Tracer
+// (no spans are created in this case).
+type Tracer struct {
+	config Config
+	timer  *time.Timer
+	queue  []*Span
+	mu     sync.Mutex
+}
+
+// New creates a new Tracer instance from the provided config.
+func New(config Config) *Tracer {
+	if config.SampleRatio <= 0 || config.SampleRatio > 1 {
+		config.SampleRatio = 1
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+
+	if config.MaxQueueSize <= 0 {
+		config.MaxQueueSize = DefaultMaxQueueSize
+	}
+
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(err error) {
+			log.Println("[tracing] failed to export spans:", err)
+		}
+	}
+
+	return &Tracer{config: config}
+}
+
+// SpanOption defines a single [Tracer.Start] option.
+type SpanOption func(s *Span)
+
+// WithKind sets the kind of the new span (default to [SpanKindInternal]).
+func WithKind(kind SpanKind) SpanOption {
+	return func(s *Span) {
+		s.kind = kind
+	}
+}
+
+// WithStartTime sets an explicit start time of the new span
+// (useful when the span is recorded after the operation completes).
+func WithStartTime(t time.Time) SpanOption {
+	return func(s *Span) {
+		s.start = t
+	}
+}
+
+// WithAttributes sets the initial attributes of the new span.
+func WithAttributes(attrs map[string]any) SpanOption {
+	return func(s *Span) {
+		if !s.sampled || len(attrs) == 0 {
+			return
+		}
+
+		if s.attributes == nil {
+			s.attributes = make(map[string]any, len(attrs))
+		}
+
+		for k, v := range attrs {
+			s.attributes[k] = v
+		}
+	}
+}
+
+// Start creates a new span and returns a context that holds it.
+//
+// If ctx already contains a span (local or remote), the new span
+// is created as its child and inherits its sampling decision.
+//
+// Returns the unmodified ctx and nil span if the tracer is nil.
+func (t *Tracer) Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: t,
+		name:   name,
+		kind:   SpanKindInternal,
+		spanId: newSpanId(),
+	}
+
+	if parent := SpanFromContext(ctx); parent != nil && parent.traceId.IsValid() {
+		span.traceId = parent.traceId
+		span.parentSpanId = parent.spanId
+		span.sampled = parent.sampled
+	} else {
+		span.traceId = newTraceId()
+		span.sampled = t.shouldSample(span.traceId)
+	}
+
+	for _, opt := range opts {
+		opt(span)
+	}
+
+	if span.start.IsZero() {
+		span.start = time.Now()
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Flush exports all queued spans.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	spans := t.queue
+	t.queue = nil
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.mu.Unlock()
+
+	return t.export(ctx, spans)
+}
+
+func (t *Tracer) export(ctx context.Context, spans []*Span) error {
+	if len(spans) == 0 || t.config.Exporter == nil {
+		return nil
+	}
+
+	return t.config.Exporter.ExportSpans(ctx, spans)
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.queue) >= t.config.MaxQueueSize {
+		return // drop
+	}
+
+	t.queue = append(t.queue, span)
+
+	if len(t.queue) >= t.config.BatchSize {
+		batch := t.queue
+		t.queue = nil
+		if t.timer != nil {
+			t.timer.Stop()
+			t.timer = nil
+		}
+
+		go t.exportAsync(batch)
+
+		return
+	}
+
+	if t.timer == nil {
+		t.timer = time.AfterFunc(t.config.FlushInterval, func() {
+			if err := t.Flush(context.Background()); err != nil {
+				t.config.ErrorHandler(err)
+			}
+		})
+	}
+}
+
+func (t *Tracer) exportAsync(spans []*Span) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := t.export(ctx, spans); err != nil {
+		t.config.ErrorHandler(err)
+	}
+}
+
+// shouldSample implements a deterministic trace id ratio based sampler
+// (similar to the OpenTelemetry TraceIDRatioBased one).
+func (t *Tracer) shouldSample(traceId TraceID) bool {
+	if t.config.SampleRatio >= 1 {
+		return true
+	}
+
+	bound := uint64(t.config.SampleRatio * math.MaxUint64)
+
+	return binary.BigEndian.Uint64(traceId[8:]) < bound
+}
+
+// -------------------------------------------------------------------
+
+type ctxKey int
+
+const (
+	ctxKeySpan ctxKey = iota
+	ctxKeyTracer
+)
+
+// ContextWithSpan returns a copy of ctx holding the provided span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, ctxKeySpan, span)
+}
+
+// SpanFromContext returns the current span stored in ctx (if any).
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+
+	span, _ := ctx.Value(ctxKeySpan).(*Span)
+
+	return span
+}
+
+// ContextWithTracer returns a copy of ctx holding the provided tracer
+// so that it could be later resolved with [TracerFromContext].
+func ContextWithTracer(ctx context.Context, tracer *Tracer) context.Context {
+	return context.WithValue(ctx, ctxKeyTracer, tracer)
+}
+
+// TracerFromContext returns the tracer of the current ctx span
+// or the one explicitly assigned with [ContextWithTracer].
+//
+// Returns nil if no tracer was found.
+func TracerFromContext(ctx context.Context) *Tracer {
+	if ctx == nil {
+		return nil
+	}
+
+	if span := SpanFromContext(ctx); span != nil && span.tracer != nil {
+		return span.tracer
+	}
+
+	tracer, _ := ctx.Value(ctxKeyTracer).(*Tracer)
+
+	return tracer
+}
+
+// Start is a shorthand for TracerFromContext(ctx).Start(ctx, name, opts...).
+//
+// It is no-op (returns the unmodified ctx and nil span) if there is
+// no tracer associated with ctx.
+func Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	return TracerFromContext(ctx).Start(ctx, name, opts...)
+}
+
+// -------------------------------------------------------------------
+
+func newTraceId() (id TraceID) {
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanId() (id SpanID) {
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,290 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/tracing"
+)
+
+func TestNilTracer(t *testing.T) {
+	t.Parallel()
+
+	var tracer *tracing.Tracer
+
+	ctx := context.Background()
+
+	newCtx, span := tracer.Start(ctx, "test")
+	if span != nil {
+		t.Fatalf("Expected nil span, got %v", span)
+	}
+
+	if newCtx != ctx {
+		t.Fatal("Expected the original context to be returned")
+	}
+
+	// nil span methods should be no-op
+	span.SetAttribute("a", 1)
+	span.RecordError(errors.New("test"))
+	span.End()
+
+	if err := tracer.Flush(ctx); err != nil {
+		t.Fatalf("Expected nil flush error, got %v", err)
+	}
+}
+
+func TestTracerStartAndFlush(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracing.NewInMemoryExporter()
+
+	tracer := tracing.New(tracing.Config{Exporter: exporter})
+
+	ctx, root := tracer.Start(context.Background(), "root", tracing.WithKind(tracing.SpanKindServer))
+	if root == nil {
+		t.Fatal("Expected non-nil root span")
+	}
+
+	if tracing.SpanFromContext(ctx) != root {
+		t.Fatal("Expected the root span to be stored in the returned context")
+	}
+
+	if tracing.TracerFromContext(ctx) != tracer {
+		t.Fatal("Expected the tracer to be resolvable from the span context")
+	}
+
+	_, child := tracing.Start(ctx, "child", tracing.WithAttributes(map[string]any{"a": 1}))
+	child.SetAttribute("b", "test")
+	child.RecordError(errors.New("child_error"))
+	child.End()
+	root.End()
+
+	if total := len(exporter.Spans()); total != 0 {
+		t.Fatalf("Expected no exported spans before flush, got %d", total)
+	}
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 exported spans, got %d", len(spans))
+	}
+
+	if spans[0].Name() != "child" || spans[1].Name() != "root" {
+		t.Fatalf("Expected [child, root] spans, got [%s, %s]", spans[0].Name(), spans[1].Name())
+	}
+
+	if child.TraceID() != root.TraceID() {
+		t.Fatalf("Expected child trace id %s, got %s", root.TraceID(), child.TraceID())
+	}
+
+	if child.ParentSpanID() != root.SpanID() {
+		t.Fatalf("Expected child parent span id %s, got %s", root.SpanID(), child.ParentSpanID())
+	}
+
+	if root.ParentSpanID().IsValid() {
+		t.Fatalf("Expected root span without parent, got %s", root.ParentSpanID())
+	}
+
+	if root.Kind() != tracing.SpanKindServer {
+		t.Fatalf("Expected root span kind %d, got %d", tracing.SpanKindServer, root.Kind())
+	}
+
+	if child.Kind() != tracing.SpanKindInternal {
+		t.Fatalf("Expected child span kind %d, got %d", tracing.SpanKindInternal, child.Kind())
+	}
+
+	attrs := child.Attributes()
+	if len(attrs) != 2 || attrs["a"] != 1 || attrs["b"] != "test" {
+		t.Fatalf("Unexpected child attributes %v", attrs)
+	}
+
+	code, msg := child.Status()
+	if code != tracing.StatusError || msg != "child_error" {
+		t.Fatalf("Expected child error status, got %d %q", code, msg)
+	}
+
+	if root.EndTime().IsZero() || root.Duration() < 0 {
+		t.Fatalf("Expected the root span to be ended")
+	}
+}
+
+func TestTracerSpanEndOnlyOnce(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracing.NewInMemoryExporter()
+
+	tracer := tracing.New(tracing.Config{Exporter: exporter})
+
+	_, span := tracer.Start(context.Background(), "test")
+	span.End()
+	span.End()
+	span.SetAttribute("after_end", true)
+
+	tracer.Flush(context.Background())
+
+	if total := len(exporter.Spans()); total != 1 {
+		t.Fatalf("Expected 1 exported span, got %d", total)
+	}
+
+	if _, ok := span.Attributes()["after_end"]; ok {
+		t.Fatal("Expected attributes to be ignored after End")
+	}
+}
+
+func TestTracerBatchSize(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracing.NewInMemoryExporter()
+
+	tracer := tracing.New(tracing.Config{Exporter: exporter, BatchSize: 2, FlushInterval: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		_, span := tracer.Start(context.Background(), "test")
+		span.End()
+	}
+
+	// the batch is exported in a separate goroutine
+	for i := 0; i < 100 && len(exporter.Spans()) != 2; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if total := len(exporter.Spans()); total != 2 {
+		t.Fatalf("Expected 2 exported spans, got %d", total)
+	}
+}
+
+func TestTracerFlushInterval(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracing.NewInMemoryExporter()
+
+	tracer := tracing.New(tracing.Config{Exporter: exporter, FlushInterval: 10 * time.Millisecond})
+
+	_, span := tracer.Start(context.Background(), "test")
+	span.End()
+
+	for i := 0; i < 100 && len(exporter.Spans()) != 1; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if total := len(exporter.Spans()); total != 1 {
+		t.Fatalf("Expected 1 exported span, got %d", total)
+	}
+}
+
+func TestTracerMaxQueueSize(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracing.NewInMemoryExporter()
+
+	tracer := tracing.New(tracing.Config{Exporter: exporter, MaxQueueSize: 2, FlushInterval: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		_, span := tracer.Start(context.Background(), "test")
+		span.End()
+	}
+
+	tracer.Flush(context.Background())
+
+	if total := len(exporter.Spans()); total != 2 {
+		t.Fatalf("Expected 2 exported spans, got %d", total)
+	}
+}
+
+func TestTracerSampleRatio(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracing.NewInMemoryExporter()
+
+	tracer := tracing.New(tracing.Config{Exporter: exporter, SampleRatio: 0.000000001})
+
+	var sampled int
+	for i := 0; i < 100; i++ {
+		ctx, root := tracer.Start(context.Background(), "root")
+		_, child := tracer.Start(ctx, "child")
+
+		if root.IsSampled() != child.IsSampled() {
+			t.Fatal("Expected the child span to inherit the parent sampling decision")
+		}
+
+		if root.IsSampled() {
+			sampled++
+		}
+
+		child.End()
+		root.End()
+	}
+
+	tracer.Flush(context.Background())
+
+	if total := len(exporter.Spans()); total != sampled*2 {
+		t.Fatalf("Expected %d exported spans, got %d", sampled*2, total)
+	}
+
+	if sampled > 10 {
+		t.Fatalf("Expected only a few sampled traces, got %d", sampled)
+	}
+}
+
+func TestTracerExportError(t *testing.T) {
+	t.Parallel()
+
+	errCh := make(chan error, 1)
+
+	tracer := tracing.New(tracing.Config{
+		Exporter:      failingExporter{},
+		FlushInterval: 5 * time.Millisecond,
+		ErrorHandler: func(err error) {
+			errCh <- err
+		},
+	})
+
+	_, span := tracer.Start(context.Background(), "test")
+	span.End()
+
+	select {
+	case err := <-errCh:
+		if err.Error() != "export_error" {
+			t.Fatalf("Expected export_error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the error handler to be called")
+	}
+}
+
+type failingExporter struct{}
+
+func (failingExporter) ExportSpans(ctx context.Context, spans []*tracing.Span) error {
+	return errors.New("export_error")
+}
+
+func TestContextWithTracer(t *testing.T) {
+	t.Parallel()
+
+	if tracing.TracerFromContext(context.Background()) != nil {
+		t.Fatal("Expected nil tracer")
+	}
+
+	_, span := tracing.Start(context.Background(), "test")
+	if span != nil {
+		t.Fatal("Expected nil span for context without tracer")
+	}
+
+	tracer := tracing.New(tracing.Config{Exporter: tracing.NewInMemoryExporter()})
+
+	ctx := tracing.ContextWithTracer(context.Background(), tracer)
+
+	if tracing.TracerFromContext(ctx) != tracer {
+		t.Fatal("Expected the context tracer to be returned")
+	}
+
+	_, span = tracing.Start(ctx, "test")
+	if span == nil {
+		t.Fatal("Expected non-nil span")
+	}
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"net/http"
+	"strconv"
+)
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// Transport is an [http.RoundTripper] that records a client span
+// for each outgoing request and propagates the W3C traceparent header.
+//
+// Spans are created only if the request context has an associated tracer
+// (see [TracerFromContext]), otherwise the request is forwarded as it is.
+type Transport struct {
+	// Base is the underlying RoundTripper (default to [http.DefaultTransport]).
+	Base http.RoundTripper
+}
+
+// NewTransport creates a new tracing Transport wrapping base.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// NewClient is a helper that returns a shallow copy of client (or a new one if nil)
+// with its Transport wrapped in a tracing [Transport].
+func NewClient(client *http.Client) *http.Client {
+	var clone http.Client
+	if client != nil {
+		clone = *client
+	}
+
+	clone.Transport = NewTransport(clone.Transport)
+
+	return &clone
+}
+
+// RoundTrip implements the [http.RoundTripper] interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := Start(req.Context(), "HTTP "+req.Method, WithKind(SpanKindClient))
+	if span == nil {
+		return base.RoundTrip(req)
+	}
+	defer span.End()
+
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("server.address", req.URL.Hostname())
+	span.SetAttribute("url.full", redactedURL(req))
+
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+
+	res, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return res, err
+	}
+
+	span.SetAttribute("http.response.status_code", res.StatusCode)
+	if res.StatusCode >= 400 {
+		span.SetStatus(StatusError, "HTTP "+strconv.Itoa(res.StatusCode))
+	}
+
+	return res, nil
+}
+
+// redactedURL returns the request url without the query parameters
+// and user info since they could contain sensitive data (tokens, codes, etc.).
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return u.String()
+}
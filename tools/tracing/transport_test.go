@@ -0,0 +1,88 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/tracing"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	var traceparent string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get(tracing.TraceParentHeader)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	exporter := tracing.NewInMemoryExporter()
+	tracer := tracing.New(tracing.Config{Exporter: exporter})
+
+	client := tracing.NewClient(nil)
+
+	// without tracer in the request context
+	{
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/test", nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if traceparent != "" {
+			t.Fatalf("Expected no traceparent header, got %q", traceparent)
+		}
+	}
+
+	// with tracer
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	for _, path := range []string{"/test?token=secret", "/fail"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if traceparent == "" {
+			t.Fatal("Expected traceparent header to be sent")
+		}
+	}
+
+	tracer.Flush(context.Background())
+
+	spans := exporter.FindByName("HTTP GET")
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 client spans, got %d", len(spans))
+	}
+
+	for _, s := range spans {
+		if s.ParentSpanID() != parent.SpanID() {
+			t.Fatalf("Expected parent span id %s, got %s", parent.SpanID(), s.ParentSpanID())
+		}
+
+		if s.Kind() != tracing.SpanKindClient {
+			t.Fatalf("Expected client span kind, got %d", s.Kind())
+		}
+	}
+
+	if url := spans[0].Attributes()["url.full"]; url != server.URL+"/test" {
+		t.Fatalf("Expected url without query params, got %v", url)
+	}
+
+	if status := spans[0].Attributes()["http.response.status_code"]; status != 200 {
+		t.Fatalf("Expected status 200, got %v", status)
+	}
+
+	if code, _ := spans[1].Status(); code != tracing.StatusError {
+		t.Fatalf("Expected error status for the failed request, got %d", code)
+	}
+}
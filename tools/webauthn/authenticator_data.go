@@ -0,0 +1,94 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Authenticator data flags.
+const (
+	FlagUserPresent    byte = 0x01
+	FlagUserVerified   byte = 0x04
+	FlagBackupEligible byte = 0x08
+	FlagBackupState    byte = 0x10
+	FlagAttestedData   byte = 0x40
+	FlagExtensionData  byte = 0x80
+)
+
+// minAuthenticatorDataLength is the size of the rpIdHash, flags and signCount.
+const minAuthenticatorDataLength = 37
+
+// AuthenticatorData is the parsed authenticator data structure
+// returned with the registration and authentication ceremonies.
+type AuthenticatorData struct {
+	RPIdHash  []byte
+	Flags     byte
+	SignCount uint32
+
+	// the attested credential data fields
+	// (available only if the [FlagAttestedData] flag is set)
+	AAGUID       []byte
+	CredentialId []byte
+	PublicKey    []byte // the raw CBOR encoded COSE_Key
+}
+
+// HasFlag checks whether the specified flag is set.
+func (d *AuthenticatorData) HasFlag(flag byte) bool {
+	return d.Flags&flag == flag
+}
+
+// ParseAuthenticatorData parses the raw authenticator data bytes.
+func ParseAuthenticatorData(data []byte) (*AuthenticatorData, error) {
+	if len(data) < minAuthenticatorDataLength {
+		return nil, errors.New("webauthn: authenticator data is too short")
+	}
+
+	result := &AuthenticatorData{
+		RPIdHash:  append([]byte(nil), data[:32]...),
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rest := data[minAuthenticatorDataLength:]
+
+	if result.HasFlag(FlagAttestedData) {
+		// aaguid (16) + credentialIdLength (2)
+		if len(rest) < 18 {
+			return nil, errors.New("webauthn: attested credential data is too short")
+		}
+
+		result.AAGUID = append([]byte(nil), rest[:16]...)
+
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+			return nil, errors.New("webauthn: invalid credential id length")
+		}
+
+		result.CredentialId = append([]byte(nil), rest[:idLength]...)
+		rest = rest[idLength:]
+
+		// the COSE key is not length prefixed so decode it to find where it ends
+		_, afterKey, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		result.PublicKey = append([]byte(nil), rest[:len(rest)-len(afterKey)]...)
+		rest = afterKey
+	}
+
+	if result.HasFlag(FlagExtensionData) {
+		_, afterExtensions, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, err
+		}
+		rest = afterExtensions
+	}
+
+	if len(rest) > 0 {
+		return nil, errors.New("webauthn: unexpected trailing authenticator data")
+	}
+
+	return result, nil
+}
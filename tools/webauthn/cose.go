@@ -0,0 +1,148 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Supported COSE algorithm identifiers.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms lists the supported COSE algorithms in order of preference.
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters and values (RFC 9053).
+const (
+	coseKeyType      = 1
+	coseKeyAlgorithm = 3
+	coseKeyCurve     = -1
+	coseKeyX         = -2
+	coseKeyY         = -3
+	coseKeyRSAN      = -1
+	coseKeyRSAE      = -2
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// ErrInvalidSignature is returned when a signature doesn't match the public key.
+var ErrInvalidSignature = errors.New("webauthn: invalid signature")
+
+// PublicKey is a parsed COSE credential public key.
+type PublicKey struct {
+	Algorithm int64
+	Key       crypto.PublicKey
+}
+
+// ParsePublicKey parses a CBOR encoded COSE_Key.
+//
+// Only ES256 (P-256), EdDSA (Ed25519) and RS256 keys are supported.
+func ParsePublicKey(coseKey []byte) (*PublicKey, error) {
+	raw, rest, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("webauthn: unexpected trailing COSE key data")
+	}
+
+	m, ok := raw.(map[any]any)
+	if !ok {
+		return nil, errors.New("webauthn: COSE key is not a map")
+	}
+
+	kty, _ := cborMapInt(m, int64(coseKeyType))
+	alg, _ := cborMapInt(m, int64(coseKeyAlgorithm))
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256:
+		crv, _ := cborMapInt(m, int64(coseKeyCurve))
+		x, _ := cborMapBytes(m, int64(coseKeyX))
+		y, _ := cborMapBytes(m, int64(coseKeyY))
+		if crv != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("webauthn: invalid EC2 COSE key")
+		}
+
+		// validate that the point is on the curve
+		uncompressed := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(uncompressed); err != nil {
+			return nil, fmt.Errorf("webauthn: invalid EC2 COSE key: %w", err)
+		}
+
+		return &PublicKey{
+			Algorithm: alg,
+			Key: &ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			},
+		}, nil
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA:
+		crv, _ := cborMapInt(m, int64(coseKeyCurve))
+		x, _ := cborMapBytes(m, int64(coseKeyX))
+		if crv != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("webauthn: invalid OKP COSE key")
+		}
+
+		return &PublicKey{
+			Algorithm: alg,
+			Key:       ed25519.PublicKey(x),
+		}, nil
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		n, _ := cborMapBytes(m, int64(coseKeyRSAN))
+		e, _ := cborMapBytes(m, int64(coseKeyRSAE))
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("webauthn: invalid RSA COSE key")
+		}
+
+		return &PublicKey{
+			Algorithm: alg,
+			Key: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("webauthn: unsupported COSE key type %d with algorithm %d", kty, alg)
+}
+
+// Verify checks whether sig is a valid signature of data.
+func (k *PublicKey) Verify(data []byte, sig []byte) error {
+	switch key := k.Key.(type) {
+	case *ecdsa.PublicKey:
+		hash := sha256.Sum256(data)
+		if ecdsa.VerifyASN1(key, hash[:], sig) {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		hash := sha256.Sum256(data)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig) == nil {
+			return nil
+		}
+	default:
+		return fmt.Errorf("webauthn: unsupported public key type %T", k.Key)
+	}
+
+	return ErrInvalidSignature
+}
@@ -0,0 +1,87 @@
+package webauthn_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/webauthn"
+)
+
+func TestParsePublicKey(t *testing.T) {
+	t.Parallel()
+
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// {1: 1 (OKP), 3: -8 (EdDSA), -1: 6 (Ed25519), -2: x}
+	edKey := append([]byte{0xa4, 0x01, 0x01, 0x03, 0x27, 0x20, 0x06, 0x21, 0x58, 0x20}, edPub...)
+
+	// valid P-256 generator point
+	x, _ := hex.DecodeString("6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296")
+	y, _ := hex.DecodeString("4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5")
+	ecKey := append(append(append([]byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}, x...), 0x22, 0x58, 0x20), y...)
+
+	invalidY := append([]byte{}, y...)
+	invalidY[31] ^= 0xff
+	offCurveKey := append(append(append([]byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}, x...), 0x22, 0x58, 0x20), invalidY...)
+
+	scenarios := []struct {
+		name        string
+		key         []byte
+		expectedAlg int64
+		expectError bool
+	}{
+		{"empty", nil, 0, true},
+		{"non-map", []byte{0x01}, 0, true},
+		{"unsupported algorithm", []byte{0xa2, 0x01, 0x02, 0x03, 0x38, 0x22}, 0, true},
+		{"trailing data", append(append([]byte{}, edKey...), 0x00), 0, true},
+		{"EC2 point not on curve", offCurveKey, 0, true},
+		{"valid EC2", ecKey, webauthn.AlgES256, false},
+		{"valid OKP", edKey, webauthn.AlgEdDSA, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			key, err := webauthn.ParsePublicKey(s.key)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if key.Algorithm != s.expectedAlg {
+				t.Fatalf("Expected algorithm %d, got %d", s.expectedAlg, key.Algorithm)
+			}
+		})
+	}
+}
+
+func TestPublicKeyVerify(t *testing.T) {
+	t.Parallel()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := &webauthn.PublicKey{Algorithm: webauthn.AlgEdDSA, Key: pub}
+
+	data := []byte("test")
+
+	if err := key.Verify(data, ed25519.Sign(priv, data)); err != nil {
+		t.Fatalf("Expected valid signature, got %v", err)
+	}
+
+	if err := key.Verify([]byte("test2"), ed25519.Sign(priv, data)); !errors.Is(err, webauthn.ErrInvalidSignature) {
+		t.Fatalf("Expected ErrInvalidSignature, got %v", err)
+	}
+}
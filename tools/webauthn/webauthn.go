@@ -0,0 +1,479 @@
+// Package webauthn implements a minimal WebAuthn (passkeys) relying party
+// for verifying the registration and authentication ceremonies.
+//
+// Only the ES256, EdDSA and RS256 credential algorithms are supported.
+// The attestation statements are not verified (aka. all attestation
+// formats are treated as "none"), which is sufficient for the passkey
+// use case where the authenticator model is not relevant.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// User verification requirements.
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// Client data types.
+const (
+	ClientDataTypeCreate = "webauthn.create"
+	ClientDataTypeGet    = "webauthn.get"
+)
+
+// CredentialType is the only currently defined public key credential type.
+const CredentialType = "public-key"
+
+// ChallengeLength is the size in bytes of the generated challenges.
+const ChallengeLength = 32
+
+// ErrSignCountRegression is returned when the assertion signature counter
+// is not greater than the stored one, which could be a sign of a cloned authenticator.
+var ErrSignCountRegression = errors.New("webauthn: signature counter regression")
+
+// NewChallenge generates a new cryptographically random ceremony challenge.
+func NewChallenge() []byte {
+	challenge := make([]byte, ChallengeLength)
+
+	if _, err := rand.Read(challenge); err != nil {
+		panic(err)
+	}
+
+	return challenge
+}
+
+// FormatAAGUID formats the raw 16 bytes authenticator AAGUID as UUID string.
+func FormatAAGUID(aaguid []byte) string {
+	if len(aaguid) != 16 {
+		return ""
+	}
+
+	h := hex.EncodeToString(aaguid)
+
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// -------------------------------------------------------------------
+
+// URLEncodedBase64 is a byte slice that is JSON serialized
+// as unpadded base64url string (the WebAuthn JSON encoding).
+type URLEncodedBase64 []byte
+
+// String returns the unpadded base64url encoded value.
+func (b URLEncodedBase64) String() string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (b URLEncodedBase64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// UnmarshalJSON implements the [json.Unmarshaler] interface.
+//
+// For better compatibility the padded and standard base64 variants are also accepted.
+func (b *URLEncodedBase64) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+
+	decoded, err := DecodeBase64(str)
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+
+	return nil
+}
+
+// DecodeBase64 decodes a base64url string (with or without padding
+// and with fallback to the standard base64 alphabet).
+func DecodeBase64(str string) ([]byte, error) {
+	str = strings.TrimRight(str, "=")
+	str = strings.NewReplacer("+", "-", "/", "_").Replace(str)
+
+	return base64.RawURLEncoding.DecodeString(str)
+}
+
+// -------------------------------------------------------------------
+
+// RelyingParty defines the relying party (aka. the app) configuration
+// used for the ceremonies options and verification.
+type RelyingParty struct {
+	// Id is the relying party identifier (usually the app domain, e.g. "example.com").
+	Id string
+
+	// Name is the relying party human-palatable name.
+	Name string
+
+	// Origins is the list of the allowed client origins (e.g. "https://example.com").
+	Origins []string
+
+	// UserVerification is the user verification requirement (default to "preferred").
+	UserVerification string
+}
+
+func (rp RelyingParty) userVerification() string {
+	if rp.UserVerification == "" {
+		return UserVerificationPreferred
+	}
+
+	return rp.UserVerification
+}
+
+// Credential is a verified public key credential.
+type Credential struct {
+	Id             []byte
+	PublicKey      []byte // the raw CBOR encoded COSE_Key
+	Algorithm      int64
+	SignCount      uint32
+	AAGUID         []byte
+	Transports     []string
+	UserVerified   bool
+	BackupEligible bool
+	BackupState    bool
+}
+
+// -------------------------------------------------------------------
+
+// RPEntity describes the relying party in the registration options.
+type RPEntity struct {
+	Id   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// UserEntity describes the user account in the registration options.
+type UserEntity struct {
+	Id          URLEncodedBase64 `json:"id"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"displayName"`
+}
+
+// CredentialParameter describes a single allowed credential algorithm.
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor describes an existing credential.
+type CredentialDescriptor struct {
+	Type       string           `json:"type"`
+	Id         URLEncodedBase64 `json:"id"`
+	Transports []string         `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection describes the authenticator requirements of the registration.
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// CreationOptions is the JSON serialized PublicKeyCredentialCreationOptions
+// (could be passed directly to PublicKeyCredential.parseCreationOptionsFromJSON()).
+type CreationOptions struct {
+	RP                     RPEntity               `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              URLEncodedBase64       `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions is the JSON serialized PublicKeyCredentialRequestOptions
+// (could be passed directly to PublicKeyCredential.parseRequestOptionsFromJSON()).
+type RequestOptions struct {
+	Challenge        URLEncodedBase64       `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RPId             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// CreationOptions returns the registration ceremony options for the specified user.
+//
+// The created credentials are required to be discoverable (aka. passkeys)
+// because the authentication ceremony doesn't list the allowed credentials.
+//
+// timeout is in milliseconds.
+func (rp RelyingParty) CreationOptions(challenge []byte, user UserEntity, exclude []CredentialDescriptor, timeout int64) *CreationOptions {
+	params := make([]CredentialParameter, 0, len(SupportedAlgorithms))
+	for _, alg := range SupportedAlgorithms {
+		params = append(params, CredentialParameter{Type: CredentialType, Alg: alg})
+	}
+
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+
+	return &CreationOptions{
+		RP:                 RPEntity{Id: rp.Id, Name: rp.Name},
+		User:               user,
+		Challenge:          challenge,
+		PubKeyCredParams:   params,
+		Timeout:            timeout,
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   rp.userVerification(),
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions returns the authentication ceremony options.
+//
+// Leave allow empty for discoverable credentials (aka. "usernameless" login).
+//
+// timeout is in milliseconds.
+func (rp RelyingParty) RequestOptions(challenge []byte, allow []CredentialDescriptor, timeout int64) *RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          timeout,
+		RPId:             rp.Id,
+		AllowCredentials: allow,
+		UserVerification: rp.userVerification(),
+	}
+}
+
+// -------------------------------------------------------------------
+
+// RegistrationResponse is the JSON serialized registration PublicKeyCredential
+// (aka. the result of PublicKeyCredential.toJSON()).
+type RegistrationResponse struct {
+	Id       string           `json:"id"`
+	RawId    URLEncodedBase64 `json:"rawId"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AttestationObject URLEncodedBase64 `json:"attestationObject"`
+		Transports        []string         `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON serialized authentication PublicKeyCredential
+// (aka. the result of PublicKeyCredential.toJSON()).
+type AssertionResponse struct {
+	Id       string           `json:"id"`
+	RawId    URLEncodedBase64 `json:"rawId"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AuthenticatorData URLEncodedBase64 `json:"authenticatorData"`
+		Signature         URLEncodedBase64 `json:"signature"`
+		UserHandle        URLEncodedBase64 `json:"userHandle"`
+	} `json:"response"`
+}
+
+// CredentialId returns the response raw credential id
+// (with fallback to the base64url decoded id).
+func (r *AssertionResponse) CredentialId() []byte {
+	if len(r.RawId) > 0 {
+		return r.RawId
+	}
+
+	id, _ := DecodeBase64(r.Id)
+
+	return id
+}
+
+// clientData is the parsed CollectedClientData.
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// verifyClientData parses and verifies the raw client data JSON.
+func (rp RelyingParty) verifyClientData(raw []byte, expectedType string, challenge []byte) error {
+	data := clientData{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("webauthn: invalid client data: %w", err)
+	}
+
+	if data.Type != expectedType {
+		return fmt.Errorf("webauthn: expected client data type %q, got %q", expectedType, data.Type)
+	}
+
+	receivedChallenge, err := DecodeBase64(data.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(receivedChallenge, challenge) != 1 {
+		return errors.New("webauthn: challenge mismatch")
+	}
+
+	if !slices.Contains(rp.Origins, data.Origin) {
+		return fmt.Errorf("webauthn: origin %q is not allowed", data.Origin)
+	}
+
+	if data.CrossOrigin {
+		return errors.New("webauthn: cross-origin requests are not allowed")
+	}
+
+	return nil
+}
+
+// verifyAuthenticatorData verifies the common authenticator data requirements.
+func (rp RelyingParty) verifyAuthenticatorData(authData *AuthenticatorData) error {
+	rpIdHash := sha256.Sum256([]byte(rp.Id))
+	if !bytes.Equal(authData.RPIdHash, rpIdHash[:]) {
+		return errors.New("webauthn: relying party id hash mismatch")
+	}
+
+	if !authData.HasFlag(FlagUserPresent) {
+		return errors.New("webauthn: user presence is required")
+	}
+
+	if rp.userVerification() == UserVerificationRequired && !authData.HasFlag(FlagUserVerified) {
+		return errors.New("webauthn: user verification is required")
+	}
+
+	if !authData.HasFlag(FlagBackupEligible) && authData.HasFlag(FlagBackupState) {
+		return errors.New("webauthn: invalid backup flags")
+	}
+
+	return nil
+}
+
+// VerifyRegistration verifies the registration ceremony response
+// against the issued challenge and returns the new credential.
+func (rp RelyingParty) VerifyRegistration(challenge []byte, response *RegistrationResponse) (*Credential, error) {
+	if response.Type != CredentialType {
+		return nil, fmt.Errorf("webauthn: unsupported credential type %q", response.Type)
+	}
+
+	if err := rp.verifyClientData(response.Response.ClientDataJSON, ClientDataTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, rest, err := decodeCBOR(response.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: invalid attestation object: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("webauthn: unexpected trailing attestation object data")
+	}
+
+	attestation, ok := rawAttestation.(map[any]any)
+	if !ok {
+		return nil, errors.New("webauthn: attestation object is not a map")
+	}
+
+	format, _ := attestation["fmt"].(string)
+	if format == "" {
+		return nil, errors.New("webauthn: missing attestation format")
+	}
+
+	// note: the attestation statement is not verified (see the package doc)
+	// but for consistency ensure that the "none" format doesn't have one
+	if stmt, _ := attestation["attStmt"].(map[any]any); format == "none" && len(stmt) > 0 {
+		return nil, errors.New("webauthn: unexpected none attestation statement")
+	}
+
+	rawAuthData, ok := cborMapBytes(attestation, "authData")
+	if !ok {
+		return nil, errors.New("webauthn: missing attestation authenticator data")
+	}
+
+	authData, err := ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+
+	if !authData.HasFlag(FlagAttestedData) {
+		return nil, errors.New("webauthn: missing attested credential data")
+	}
+
+	if len(response.RawId) > 0 && !bytes.Equal(response.RawId, authData.CredentialId) {
+		return nil, errors.New("webauthn: credential id mismatch")
+	}
+
+	publicKey, err := ParsePublicKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		Id:             authData.CredentialId,
+		PublicKey:      authData.PublicKey,
+		Algorithm:      publicKey.Algorithm,
+		SignCount:      authData.SignCount,
+		AAGUID:         authData.AAGUID,
+		Transports:     response.Response.Transports,
+		UserVerified:   authData.HasFlag(FlagUserVerified),
+		BackupEligible: authData.HasFlag(FlagBackupEligible),
+		BackupState:    authData.HasFlag(FlagBackupState),
+	}, nil
+}
+
+// VerifyAssertion verifies the authentication ceremony response against
+// the issued challenge and the stored credential public key and sign count.
+//
+// Returns the parsed authenticator data on success so that the caller
+// could update the stored credential sign count.
+func (rp RelyingParty) VerifyAssertion(challenge []byte, publicKey []byte, signCount uint32, response *AssertionResponse) (*AuthenticatorData, error) {
+	if response.Type != CredentialType {
+		return nil, fmt.Errorf("webauthn: unsupported credential type %q", response.Type)
+	}
+
+	if err := rp.verifyClientData(response.Response.ClientDataJSON, ClientDataTypeGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := ParseAuthenticatorData(response.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+
+	signed := make([]byte, 0, len(response.Response.AuthenticatorData)+len(clientDataHash))
+	signed = append(signed, response.Response.AuthenticatorData...)
+	signed = append(signed, clientDataHash[:]...)
+
+	if err := key.Verify(signed, response.Response.Signature); err != nil {
+		return nil, err
+	}
+
+	// the counter is optional and authenticators that don't support it always return 0
+	// (the assertions replay protection relies on the callers accepting each challenge only once)
+	if (authData.SignCount != 0 || signCount != 0) && authData.SignCount <= signCount {
+		return nil, ErrSignCountRegression
+	}
+
+	return authData, nil
+}
@@ -0,0 +1,243 @@
+package webauthn_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/webauthn"
+)
+
+func TestFormatAAGUID(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		aaguid   []byte
+		expected string
+	}{
+		{nil, ""},
+		{[]byte{1, 2, 3}, ""},
+		{make([]byte, 16), "00000000-0000-0000-0000-000000000000"},
+		{[]byte{0xad, 0xce, 0x00, 0x02, 0x35, 0xbc, 0xc6, 0x0a, 0x64, 0x8b, 0x0b, 0x25, 0xf1, 0xf0, 0x55, 0x03}, "adce0002-35bc-c60a-648b-0b25f1f05503"},
+	}
+
+	for _, s := range scenarios {
+		if v := webauthn.FormatAAGUID(s.aaguid); v != s.expected {
+			t.Errorf("Expected %q, got %q", s.expected, v)
+		}
+	}
+}
+
+func TestDecodeBase64(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		str         string
+		expected    string
+		expectError bool
+	}{
+		{"", "", false},
+		{"dGVzdA", "test", false},
+		{"dGVzdA==", "test", false},
+		{"-_8", "\xfb\xff", false},
+		{"+/8=", "\xfb\xff", false},
+		{"!@#", "", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.str, func(t *testing.T) {
+			result, err := webauthn.DecodeBase64(s.str)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if string(result) != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, result)
+			}
+		})
+	}
+}
+
+func TestRelyingPartyCreationOptions(t *testing.T) {
+	t.Parallel()
+
+	rp := webauthn.RelyingParty{Id: "example.com", Name: "test"}
+
+	options := rp.CreationOptions(
+		[]byte("abc"),
+		webauthn.UserEntity{Id: []byte("123"), Name: "test@example.com", DisplayName: "test"},
+		[]webauthn.CredentialDescriptor{{Type: webauthn.CredentialType, Id: []byte("456")}},
+		1000,
+	)
+
+	raw, err := json.Marshal(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"rp":{"id":"example.com","name":"test"},"user":{"id":"MTIz","name":"test@example.com","displayName":"test"},"challenge":"YWJj","pubKeyCredParams":[{"type":"public-key","alg":-7},{"type":"public-key","alg":-8},{"type":"public-key","alg":-257}],"timeout":1000,"excludeCredentials":[{"type":"public-key","id":"NDU2"}],"authenticatorSelection":{"residentKey":"required","requireResidentKey":true,"userVerification":"preferred"},"attestation":"none"}`
+
+	if str := string(raw); str != expected {
+		t.Fatalf("Expected\n%s\ngot\n%s", expected, str)
+	}
+}
+
+func TestRelyingPartyVerify(t *testing.T) {
+	t.Parallel()
+
+	rp := webauthn.RelyingParty{
+		Id:      "example.com",
+		Origins: []string{"https://example.com"},
+	}
+
+	challenge := webauthn.NewChallenge()
+
+	newAuthenticator := func(t *testing.T, rpId, origin string) *tests.TestAuthenticator {
+		authenticator, err := tests.NewTestAuthenticator(rpId, origin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return authenticator
+	}
+
+	register := func(t *testing.T, rp webauthn.RelyingParty, authenticator *tests.TestAuthenticator, challenge []byte) (*webauthn.Credential, error) {
+		raw, err := authenticator.RegistrationResponse(challenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response := &webauthn.RegistrationResponse{}
+		if err := json.Unmarshal(raw, response); err != nil {
+			t.Fatal(err)
+		}
+
+		return rp.VerifyRegistration(challenge, response)
+	}
+
+	assert := func(t *testing.T, rp webauthn.RelyingParty, authenticator *tests.TestAuthenticator, challenge []byte, publicKey []byte, signCount uint32) (*webauthn.AuthenticatorData, error) {
+		raw, err := authenticator.AssertionResponse(challenge, []byte("user"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response := &webauthn.AssertionResponse{}
+		if err := json.Unmarshal(raw, response); err != nil {
+			t.Fatal(err)
+		}
+
+		return rp.VerifyAssertion(challenge, publicKey, signCount, response)
+	}
+
+	t.Run("registration and assertion", func(t *testing.T) {
+		authenticator := newAuthenticator(t, "example.com", "https://example.com")
+		authenticator.SignCount = 1
+
+		credential, err := register(t, rp, authenticator, challenge)
+		if err != nil {
+			t.Fatalf("Expected successful registration, got %v", err)
+		}
+
+		if string(credential.Id) != string(authenticator.CredentialId()) {
+			t.Fatalf("Expected credential id %x, got %x", authenticator.CredentialId(), credential.Id)
+		}
+		if credential.Algorithm != webauthn.AlgES256 {
+			t.Fatalf("Expected ES256 algorithm, got %d", credential.Algorithm)
+		}
+		if credential.SignCount != 1 {
+			t.Fatalf("Expected sign count 1, got %d", credential.SignCount)
+		}
+		if !credential.UserVerified {
+			t.Fatal("Expected UserVerified to be true")
+		}
+		if len(credential.Transports) != 1 || credential.Transports[0] != "internal" {
+			t.Fatalf("Expected internal transport, got %v", credential.Transports)
+		}
+
+		authData, err := assert(t, rp, authenticator, challenge, credential.PublicKey, credential.SignCount)
+		if err != nil {
+			t.Fatalf("Expected successful assertion, got %v", err)
+		}
+		if authData.SignCount != 2 {
+			t.Fatalf("Expected sign count 2, got %d", authData.SignCount)
+		}
+
+		// same stored sign count
+		if _, err := assert(t, rp, authenticator, challenge, credential.PublicKey, 10); !errors.Is(err, webauthn.ErrSignCountRegression) {
+			t.Fatalf("Expected ErrSignCountRegression, got %v", err)
+		}
+
+		// different public key
+		other := newAuthenticator(t, "example.com", "https://example.com")
+		if _, err := assert(t, rp, authenticator, challenge, other.PublicKey(), 0); !errors.Is(err, webauthn.ErrInvalidSignature) {
+			t.Fatalf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("zero sign count", func(t *testing.T) {
+		authenticator := newAuthenticator(t, "example.com", "https://example.com")
+
+		credential, err := register(t, rp, authenticator, challenge)
+		if err != nil {
+			t.Fatalf("Expected successful registration, got %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := assert(t, rp, authenticator, challenge, credential.PublicKey, 0); err != nil {
+				t.Fatalf("[%d] Expected successful assertion, got %v", i, err)
+			}
+		}
+	})
+
+	t.Run("challenge mismatch", func(t *testing.T) {
+		authenticator := newAuthenticator(t, "example.com", "https://example.com")
+
+		raw, err := authenticator.RegistrationResponse(webauthn.NewChallenge())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response := &webauthn.RegistrationResponse{}
+		if err := json.Unmarshal(raw, response); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := rp.VerifyRegistration(challenge, response); err == nil {
+			t.Fatal("Expected challenge mismatch error")
+		}
+	})
+
+	t.Run("origin mismatch", func(t *testing.T) {
+		authenticator := newAuthenticator(t, "example.com", "https://evil.com")
+
+		if _, err := register(t, rp, authenticator, challenge); err == nil {
+			t.Fatal("Expected origin mismatch error")
+		}
+	})
+
+	t.Run("rpId mismatch", func(t *testing.T) {
+		authenticator := newAuthenticator(t, "evil.com", "https://example.com")
+
+		if _, err := register(t, rp, authenticator, challenge); err == nil {
+			t.Fatal("Expected rpId hash mismatch error")
+		}
+	})
+
+	t.Run("required user verification", func(t *testing.T) {
+		authenticator := newAuthenticator(t, "example.com", "https://example.com")
+		authenticator.UserVerified = false
+
+		if _, err := register(t, rp, authenticator, challenge); err != nil {
+			t.Fatalf("Expected successful registration with the default user verification, got %v", err)
+		}
+
+		strictRP := rp
+		strictRP.UserVerification = webauthn.UserVerificationRequired
+
+		if _, err := register(t, strictRP, authenticator, challenge); err == nil {
+			t.Fatal("Expected user verification error")
+		}
+	})
+}
@@ -0,0 +1,407 @@
+// Package websocket implements a minimal RFC 6455 WebSocket server and client
+// connection with support for fragmented messages and ping/pong control frames
+// (extensions and subprotocols are not supported).
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message and control frame types (aka. opcodes).
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+// Close status codes.
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+)
+
+// DefaultReadLimit is the default max size in bytes of a single read message.
+const DefaultReadLimit = 1 << 20
+
+// maxControlPayload is the max payload size of the control frames.
+const maxControlPayload = 125
+
+// ErrClosed is returned when writing to an already closed connection.
+var ErrClosed = errors.New("websocket: connection closed")
+
+// CloseError is returned by [Conn.ReadMessage] when the peer sends a close frame.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+// Error implements the [error] interface.
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Reason)
+}
+
+// Conn represents a single WebSocket connection.
+//
+// It is safe to call the write methods concurrently with [Conn.ReadMessage]
+// but only one goroutine should read from the connection at a time.
+type Conn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	isClient bool
+
+	readLimit    int64
+	writeTimeout time.Duration
+	pongHandler  func(data []byte)
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isClient bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+
+	return &Conn{
+		conn:      conn,
+		br:        br,
+		isClient:  isClient,
+		readLimit: DefaultReadLimit,
+	}
+}
+
+// SetReadLimit sets the max size in bytes of a single read message
+// (default to [DefaultReadLimit]).
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetWriteTimeout sets a deadline relative to the start of each frame write,
+// including the automatic pong replies (0 means no timeout).
+func (c *Conn) SetWriteTimeout(timeout time.Duration) {
+	c.writeMu.Lock()
+	c.writeTimeout = timeout
+	c.writeMu.Unlock()
+}
+
+// SetPongHandler registers a callback that is invoked for each received pong frame.
+func (c *Conn) SetPongHandler(fn func(data []byte)) {
+	c.pongHandler = fn
+}
+
+// SetReadDeadline sets the deadline for the future and the currently blocked reads.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for the future writes.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// RemoteAddr returns the remote network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage reads the next text or binary message from the connection.
+//
+// The ping frames are automatically replied with pong and the
+// pong frames are reported to the registered pong handler (if any).
+//
+// When the peer sends a close frame, it is echoed back and
+// ReadMessage returns a [*CloseError].
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var messageType int
+	var message []byte
+
+	for {
+		h, err := c.readHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if h.opcode >= CloseMessage {
+			payload, err := c.readPayload(h)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			if err := c.handleControl(h.opcode, payload); err != nil {
+				return 0, nil, err
+			}
+
+			continue
+		}
+
+		if h.opcode == continuationFrame {
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		} else {
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			messageType = h.opcode
+		}
+
+		if int64(len(message))+h.length > c.readLimit {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+
+		payload, err := c.readPayload(h)
+		if err != nil {
+			return 0, nil, err
+		}
+		message = append(message, payload...)
+
+		if h.fin {
+			break
+		}
+	}
+
+	if messageType == TextMessage && !utf8.Valid(message) {
+		return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8 text message")
+	}
+
+	return messageType, message, nil
+}
+
+func (c *Conn) handleControl(opcode int, payload []byte) error {
+	switch opcode {
+	case PingMessage:
+		err := c.writeFrame(PongMessage, payload)
+		if err != nil && !errors.Is(err, ErrClosed) {
+			return err
+		}
+	case PongMessage:
+		if c.pongHandler != nil {
+			c.pongHandler(payload)
+		}
+	case CloseMessage:
+		closeErr := &CloseError{Code: CloseNoStatusReceived}
+		if len(payload) >= 2 {
+			closeErr.Code = int(binary.BigEndian.Uint16(payload))
+			closeErr.Reason = string(payload[2:])
+		}
+
+		// echo the close status (if not already closed by us)
+		c.WriteClose(closeErr.Code, "")
+		c.conn.Close()
+
+		return closeErr
+	default:
+		return c.fail(CloseProtocolError, "unknown control frame")
+	}
+
+	return nil
+}
+
+type frameHeader struct {
+	fin    bool
+	opcode int
+	length int64
+	mask   []byte
+}
+
+func (c *Conn) readHeader() (frameHeader, error) {
+	var h frameHeader
+
+	var b [8]byte
+	if _, err := io.ReadFull(c.br, b[:2]); err != nil {
+		return h, err
+	}
+
+	h.fin = b[0]&0x80 != 0
+	h.opcode = int(b[0] & 0x0f)
+	masked := b[1]&0x80 != 0
+	h.length = int64(b[1] & 0x7f)
+
+	if b[0]&0x70 != 0 {
+		return h, c.fail(CloseProtocolError, "unsupported reserved bits")
+	}
+
+	switch h.opcode {
+	case continuationFrame, TextMessage, BinaryMessage, CloseMessage, PingMessage, PongMessage:
+	default:
+		return h, c.fail(CloseProtocolError, "unknown opcode")
+	}
+
+	// the client frames must be masked and the server ones must not
+	if masked == c.isClient {
+		return h, c.fail(CloseProtocolError, "invalid frame mask")
+	}
+
+	switch h.length {
+	case 126:
+		if _, err := io.ReadFull(c.br, b[:2]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(c.br, b[:8]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint64(b[:8]))
+		if h.length < 0 {
+			return h, c.fail(CloseProtocolError, "invalid frame length")
+		}
+	}
+
+	if h.opcode >= CloseMessage && (!h.fin || h.length > maxControlPayload) {
+		return h, c.fail(CloseProtocolError, "invalid control frame")
+	}
+
+	if masked {
+		h.mask = make([]byte, 4)
+		if _, err := io.ReadFull(c.br, h.mask); err != nil {
+			return h, err
+		}
+	}
+
+	return h, nil
+}
+
+func (c *Conn) readPayload(h frameHeader) ([]byte, error) {
+	payload := make([]byte, h.length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return nil, err
+	}
+
+	if h.mask != nil {
+		maskBytes(h.mask, payload)
+	}
+
+	return payload, nil
+}
+
+// fail sends a close frame with the specified code and returns an error with the reason.
+func (c *Conn) fail(code int, reason string) error {
+	c.WriteClose(code, reason)
+	c.conn.Close()
+
+	return &CloseError{Code: code, Reason: reason}
+}
+
+// WriteMessage writes a single text or binary message.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return errors.New("websocket: invalid message type")
+	}
+
+	return c.writeFrame(messageType, data)
+}
+
+// WritePing writes a ping control frame.
+func (c *Conn) WritePing(data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: ping payload too big")
+	}
+
+	return c.writeFrame(PingMessage, data)
+}
+
+// WriteClose writes a close control frame with the specified status code and reason.
+//
+// No other frames can be written after it.
+func (c *Conn) WriteClose(code int, reason string) error {
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+
+	err := c.writeFrame(CloseMessage, payload)
+
+	c.writeMu.Lock()
+	c.closed = true
+	c.writeMu.Unlock()
+
+	return err
+}
+
+// Close closes the underlying network connection without sending a close frame.
+func (c *Conn) Close() error {
+	c.writeMu.Lock()
+	c.closed = true
+	c.writeMu.Unlock()
+
+	return c.conn.Close()
+}
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|byte(opcode))
+
+	var maskBit byte
+	if c.isClient {
+		maskBit = 0x80
+	}
+
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	if c.isClient {
+		mask := make([]byte, 4)
+		if _, err := rand.Read(mask); err != nil {
+			return err
+		}
+		frame = append(frame, mask...)
+
+		start := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(mask, frame[start:])
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	if c.writeTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+
+	_, err := c.conn.Write(frame)
+
+	return err
+}
+
+func maskBytes(mask []byte, data []byte) {
+	for i := range data {
+		data[i] ^= mask[i%4]
+	}
+}
@@ -0,0 +1,241 @@
+package websocket_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/websocket"
+)
+
+// newEchoServer starts a test server that echoes back all received messages.
+func newEchoServer(t *testing.T, readLimit int64) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if readLimit > 0 {
+			conn.SetReadLimit(readLimit)
+		}
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if string(data) == "ping me" {
+				conn.WritePing([]byte("server ping"))
+			}
+
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func dial(t *testing.T, server *httptest.Server) *websocket.Conn {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+func TestEcho(t *testing.T) {
+	t.Parallel()
+
+	conn := dial(t, newEchoServer(t, 0))
+
+	messages := []struct {
+		messageType int
+		data        []byte
+	}{
+		{websocket.TextMessage, []byte("hello")},
+		{websocket.TextMessage, []byte("")},
+		{websocket.BinaryMessage, []byte{0, 1, 2, 255}},
+		{websocket.TextMessage, bytes.Repeat([]byte("a"), 200)},     // 16-bit length
+		{websocket.BinaryMessage, bytes.Repeat([]byte("b"), 70000)}, // 64-bit length
+		{websocket.TextMessage, []byte("unicode: ж中\U0001f600")},
+	}
+
+	for i, m := range messages {
+		if err := conn.WriteMessage(m.messageType, m.data); err != nil {
+			t.Fatalf("[%d] write error: %v", i, err)
+		}
+
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("[%d] read error: %v", i, err)
+		}
+
+		if messageType != m.messageType {
+			t.Errorf("[%d] Expected message type %d, got %d", i, m.messageType, messageType)
+		}
+
+		if !bytes.Equal(data, m.data) {
+			t.Errorf("[%d] Expected %d bytes echo, got %d", i, len(m.data), len(data))
+		}
+	}
+}
+
+func TestPingPong(t *testing.T) {
+	t.Parallel()
+
+	conn := dial(t, newEchoServer(t, 0))
+
+	pongs := make(chan string, 1)
+	conn.SetPongHandler(func(data []byte) {
+		pongs <- string(data)
+	})
+
+	// client ping -> server pong (handled while the client is reading the echo)
+	if err := conn.WritePing([]byte("client ping")); err != nil {
+		t.Fatal(err)
+	}
+
+	// server ping -> client pong (auto replied by the client read)
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("ping me")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "ping me" {
+		t.Fatalf("Expected echo message, got %q", data)
+	}
+
+	select {
+	case pong := <-pongs:
+		if pong != "client ping" {
+			t.Fatalf("Expected pong with the ping payload, got %q", pong)
+		}
+	default:
+		t.Fatal("Expected the pong handler to be called")
+	}
+
+	if err := conn.WritePing(bytes.Repeat([]byte("a"), 126)); err == nil {
+		t.Fatal("Expected too big ping payload error")
+	}
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	conn := dial(t, newEchoServer(t, 0))
+
+	if err := conn.WriteClose(websocket.CloseNormalClosure, "bye"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("late")); !errors.Is(err, websocket.ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+
+	_, _, err := conn.ReadMessage()
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormalClosure {
+		t.Fatalf("Expected echoed normal closure, got %v", err)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	t.Parallel()
+
+	conn := dial(t, newEchoServer(t, 10))
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("12345678901")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := conn.ReadMessage()
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseMessageTooBig {
+		t.Fatalf("Expected message too big close error, got %v", err)
+	}
+}
+
+func TestUpgradeInvalidRequest(t *testing.T) {
+	t.Parallel()
+
+	server := newEchoServer(t, 0)
+
+	scenarios := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{
+			"plain request",
+			nil,
+			http.StatusUpgradeRequired,
+		},
+		{
+			"unsupported version",
+			map[string]string{
+				"Connection":            "keep-alive, Upgrade",
+				"Upgrade":               "websocket",
+				"Sec-WebSocket-Version": "8",
+				"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+			},
+			http.StatusBadRequest,
+		},
+		{
+			"invalid key",
+			map[string]string{
+				"Connection":            "Upgrade",
+				"Upgrade":               "websocket",
+				"Sec-WebSocket-Version": "13",
+				"Sec-WebSocket-Key":     "invalid",
+			},
+			http.StatusBadRequest,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range s.headers {
+				req.Header.Set(k, v)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != s.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", s.expectedStatus, res.StatusCode)
+			}
+		})
+	}
+}
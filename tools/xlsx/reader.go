@@ -0,0 +1,554 @@
+// Package xlsx implements a minimal streaming reader and writer of Office Open XML
+// spreadsheets (.xlsx) that don't require loading the whole sheet in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSheetNotFound is returned when the requested sheet doesn't exist in the workbook.
+var ErrSheetNotFound = errors.New("xlsx: sheet not found")
+
+// Reader is a .xlsx workbook reader.
+type Reader struct {
+	zr            *zip.Reader
+	sheets        []sheetInfo
+	sharedStrings []string
+	dateStyles    []bool // cellXfs index -> whether it is a date format
+	date1904      bool
+}
+
+type sheetInfo struct {
+	name string
+	path string
+}
+
+// NewReader opens a .xlsx workbook from r with the specified size.
+//
+// Only the workbook metadata, the shared strings and the styles are
+// loaded on open. The sheet rows are decoded on demand with [Reader.Rows].
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: invalid archive: %w", err)
+	}
+
+	reader := &Reader{zr: zr}
+
+	if err := reader.loadWorkbook(); err != nil {
+		return nil, err
+	}
+
+	if err := reader.loadSharedStrings(); err != nil {
+		return nil, err
+	}
+
+	if err := reader.loadStyles(); err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// Sheets returns the workbook sheet names in their display order.
+func (r *Reader) Sheets() []string {
+	names := make([]string, len(r.sheets))
+	for i, s := range r.sheets {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Rows returns a streaming iterator over the rows of the specified sheet.
+//
+// If sheet is empty, the first workbook sheet is used.
+// The sheet name lookup is case-insensitive.
+//
+// The returned Rows must be closed after use.
+func (r *Reader) Rows(sheet string) (*Rows, error) {
+	if len(r.sheets) == 0 {
+		return nil, ErrSheetNotFound
+	}
+
+	info := r.sheets[0]
+	if sheet != "" {
+		var found bool
+		for _, s := range r.sheets {
+			if strings.EqualFold(s.name, sheet) {
+				info = s
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrSheetNotFound
+		}
+	}
+
+	f, err := r.open(info.path)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, ErrSheetNotFound
+	}
+
+	return &Rows{
+		reader:  r,
+		closer:  f,
+		decoder: xml.NewDecoder(f),
+	}, nil
+}
+
+// open opens the zip file with the specified name (returns nil if missing).
+func (r *Reader) open(name string) (io.ReadCloser, error) {
+	name = strings.TrimPrefix(name, "/")
+
+	for _, f := range r.zr.File {
+		if strings.EqualFold(f.Name, name) {
+			return f.Open()
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *Reader) decodeFile(name string, dest any) (bool, error) {
+	f, err := r.open(name)
+	if err != nil {
+		return false, err
+	}
+	if f == nil {
+		return false, nil
+	}
+	defer f.Close()
+
+	if err := xml.NewDecoder(f).Decode(dest); err != nil {
+		return false, fmt.Errorf("xlsx: failed to decode %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+func (r *Reader) loadWorkbook() error {
+	var workbook struct {
+		Pr struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RId  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+
+	ok, err := r.decodeFile("xl/workbook.xml", &workbook)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("xlsx: missing xl/workbook.xml")
+	}
+
+	r.date1904 = workbook.Pr.Date1904 == "1" || workbook.Pr.Date1904 == "true"
+
+	var rels struct {
+		Items []struct {
+			Id     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if _, err := r.decodeFile("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return err
+	}
+
+	targets := make(map[string]string, len(rels.Items))
+	for _, rel := range rels.Items {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.Id] = target
+	}
+
+	for i, s := range workbook.Sheets {
+		sheetPath, ok := targets[s.RId]
+		if !ok {
+			// fallback to the default sheet naming
+			sheetPath = "xl/worksheets/sheet" + strconv.Itoa(i+1) + ".xml"
+		}
+
+		r.sheets = append(r.sheets, sheetInfo{name: s.Name, path: sheetPath})
+	}
+
+	return nil
+}
+
+func (r *Reader) loadSharedStrings() error {
+	f, err := r.open("xl/sharedStrings.xml")
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return nil // no shared strings
+	}
+	defer f.Close()
+
+	decoder := xml.NewDecoder(f)
+
+	var current strings.Builder
+	var inItem, inText, inPhonetic bool
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("xlsx: failed to decode the shared strings: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				inItem = true
+				current.Reset()
+			case "rPh":
+				inPhonetic = true
+			case "t":
+				inText = inItem && !inPhonetic
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				inItem = false
+				r.sharedStrings = append(r.sharedStrings, current.String())
+			case "rPh":
+				inPhonetic = false
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+	}
+}
+
+func (r *Reader) loadStyles() error {
+	var styles struct {
+		NumFmts []struct {
+			Id   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtId int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+
+	if _, err := r.decodeFile("xl/styles.xml", &styles); err != nil {
+		return err
+	}
+
+	customDates := map[int]bool{}
+	for _, f := range styles.NumFmts {
+		customDates[f.Id] = isDateFormatCode(f.Code)
+	}
+
+	r.dateStyles = make([]bool, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		if isDate, ok := customDates[xf.NumFmtId]; ok {
+			r.dateStyles[i] = isDate
+		} else {
+			r.dateStyles[i] = isBuiltinDateFormat(xf.NumFmtId)
+		}
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+// Rows is a streaming iterator over the rows of a single sheet.
+type Rows struct {
+	reader  *Reader
+	closer  io.Closer
+	decoder *xml.Decoder
+
+	// the number of the last returned row (1-based)
+	current int
+
+	// a decoded row that is ahead of current (the rows in between are empty)
+	pending    []any
+	pendingNum int
+}
+
+// Next returns the cell values of the next sheet row.
+//
+// The cell values are typed based on the cell type and style:
+// string, float64, bool, [time.Time] for date formatted numbers or nil for empty cells.
+//
+// Skipped (empty) rows are returned as empty slices so that the
+// number of Next calls always matches the sheet row number.
+//
+// It returns [io.EOF] when there are no more rows.
+func (rows *Rows) Next() ([]any, error) {
+	if rows.pending != nil {
+		rows.current++
+		if rows.current < rows.pendingNum {
+			return []any{}, nil
+		}
+
+		values := rows.pending
+		rows.pending = nil
+		return values, nil
+	}
+
+	num, values, err := rows.decodeRow()
+	if err != nil {
+		return nil, err
+	}
+
+	rows.current++
+	if num > rows.current {
+		rows.pending = values
+		rows.pendingNum = num
+		return []any{}, nil
+	}
+
+	return values, nil
+}
+
+// Close releases the underlying sheet reader.
+func (rows *Rows) Close() error {
+	return rows.closer.Close()
+}
+
+// decodeRow decodes the next <row> element.
+func (rows *Rows) decodeRow() (int, []any, error) {
+	for {
+		token, err := rows.decoder.Token()
+		if err == io.EOF {
+			return 0, nil, io.EOF
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("xlsx: failed to decode sheet row: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xmlRow
+		if err := rows.decoder.DecodeElement(&row, &start); err != nil {
+			return 0, nil, fmt.Errorf("xlsx: failed to decode sheet row: %w", err)
+		}
+
+		num := row.R
+		if num <= rows.current {
+			num = rows.current + 1
+		}
+
+		values := make([]any, 0, len(row.Cells))
+		for i, c := range row.Cells {
+			col := i
+			if c.R != "" {
+				if parsed, ok := columnIndex(c.R); ok {
+					col = parsed
+				}
+			}
+
+			for len(values) < col {
+				values = append(values, nil)
+			}
+
+			value, err := rows.reader.cellValue(c)
+			if err != nil {
+				return 0, nil, fmt.Errorf("xlsx: invalid cell %s value: %w", c.R, err)
+			}
+
+			if col < len(values) {
+				values[col] = value
+			} else {
+				values = append(values, value)
+			}
+		}
+
+		return num, values, nil
+	}
+}
+
+type xmlRow struct {
+	R     int       `xml:"r,attr"`
+	Cells []xmlCell `xml:"c"`
+}
+
+type xmlCell struct {
+	R         string `xml:"r,attr"`
+	T         string `xml:"t,attr"`
+	S         int    `xml:"s,attr"`
+	V         string `xml:"v"`
+	InlineStr struct {
+		T    string `xml:"t"`
+		Runs []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"is"`
+}
+
+func (r *Reader) cellValue(c xmlCell) (any, error) {
+	switch c.T {
+	case "s":
+		if c.V == "" {
+			return nil, nil
+		}
+		idx, err := strconv.Atoi(c.V)
+		if err != nil || idx < 0 || idx >= len(r.sharedStrings) {
+			return nil, errors.New("invalid shared string index")
+		}
+		return r.sharedStrings[idx], nil
+	case "inlineStr":
+		if len(c.InlineStr.Runs) > 0 {
+			var sb strings.Builder
+			for _, run := range c.InlineStr.Runs {
+				sb.WriteString(run.T)
+			}
+			return sb.String(), nil
+		}
+		return c.InlineStr.T, nil
+	case "str", "e":
+		return c.V, nil
+	case "b":
+		return c.V == "1" || strings.EqualFold(c.V, "true"), nil
+	case "d":
+		if c.V == "" {
+			return nil, nil
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, c.V); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return c.V, nil
+	default: // "n" or missing
+		if c.V == "" {
+			return nil, nil
+		}
+
+		num, err := strconv.ParseFloat(c.V, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.S >= 0 && c.S < len(r.dateStyles) && r.dateStyles[c.S] {
+			return SerialToTime(num, r.date1904), nil
+		}
+
+		return num, nil
+	}
+}
+
+// -------------------------------------------------------------------
+
+// SerialToTime converts an Excel serial date number to UTC time.
+func SerialToTime(serial float64, date1904 bool) time.Time {
+	epoch := time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	days := int(serial)
+	// round to milliseconds to avoid float noise (eg. 10:29:59.999)
+	ms := int64((serial-float64(days))*86400000 + 0.5)
+
+	return epoch.AddDate(0, 0, days).Add(time.Duration(ms) * time.Millisecond)
+}
+
+// TimeToSerial converts t to an Excel serial date number (1900 date system).
+func TimeToSerial(t time.Time) float64 {
+	epoch := time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+	return float64(t.UTC().Sub(epoch).Milliseconds()) / 86400000
+}
+
+// columnIndex returns the 0-based column index of a cell reference (eg. "C12" -> 2).
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, ch := range ref {
+		if ch >= 'A' && ch <= 'Z' {
+			col = col*26 + int(ch-'A'+1)
+			n++
+		} else if ch >= 'a' && ch <= 'z' {
+			col = col*26 + int(ch-'a'+1)
+			n++
+		} else {
+			break
+		}
+	}
+
+	if n == 0 {
+		return 0, false
+	}
+
+	return col - 1, true
+}
+
+// ColumnName returns the column letters of a 0-based column index (eg. 2 -> "C").
+func ColumnName(index int) string {
+	var name []byte
+	for index >= 0 {
+		name = append([]byte{byte('A' + index%26)}, name...)
+		index = index/26 - 1
+	}
+	return string(name)
+}
+
+// isBuiltinDateFormat reports whether the builtin number format id is a date/time format.
+func isBuiltinDateFormat(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 45 && id <= 47) || (id >= 27 && id <= 36) || (id >= 50 && id <= 58)
+}
+
+// isDateFormatCode loosely checks whether a custom number format code is a date/time format.
+func isDateFormatCode(code string) bool {
+	var inQuotes, inBrackets bool
+
+	for i := 0; i < len(code); i++ {
+		ch := code[i]
+
+		switch {
+		case ch == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+			continue
+		case ch == '\\' || ch == '_' || ch == '*':
+			i++ // skip the next escaped/padding char
+		case ch == '[':
+			inBrackets = true
+		case ch == ']':
+			inBrackets = false
+		case inBrackets:
+			continue
+		default:
+			switch ch {
+			case 'd', 'D', 'm', 'M', 'y', 'Y', 'h', 'H', 's', 'S':
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,302 @@
+package xlsx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/xlsx"
+)
+
+func TestReaderInvalidArchive(t *testing.T) {
+	data := []byte("not a zip")
+
+	_, err := xlsx.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+}
+
+func TestReaderMissingWorkbook(t *testing.T) {
+	data := buildTestWorkbook(t, map[string]string{
+		"xl/styles.xml": `<styleSheet/>`,
+	})
+
+	_, err := xlsx.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+}
+
+func TestReaderSheets(t *testing.T) {
+	data := buildTestWorkbook(t, testWorkbookFiles(false))
+
+	r, err := xlsx.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sheets := fmt.Sprint(r.Sheets())
+	if sheets != "[Data Other]" {
+		t.Fatalf("Expected sheets [Data Other], got %s", sheets)
+	}
+
+	if _, err := r.Rows("missing"); !errors.Is(err, xlsx.ErrSheetNotFound) {
+		t.Fatalf("Expected ErrSheetNotFound, got %v", err)
+	}
+}
+
+func TestReaderRows(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		sheet    string
+		date1904 bool
+		expected []string
+	}{
+		{
+			"default first sheet",
+			"",
+			false,
+			[]string{
+				`[]string{"name", "count", "created", "active", "note"}`,
+				`[]interface {}{}`,
+				`[]interface {}{"rich text", 12.5, time.Date(2024, time.December, 24, 10, 30, 0, 0, time.UTC), true, "inline"}`,
+				`[]interface {}{interface {}(nil), 3, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), false, "formula"}`,
+			},
+		},
+		{
+			"sheet by name (case-insensitive)",
+			"other",
+			false,
+			[]string{
+				`[]interface {}{"other"}`,
+			},
+		},
+		{
+			"1904 date system",
+			"",
+			true,
+			[]string{
+				`[]string{"name", "count", "created", "active", "note"}`,
+				`[]interface {}{}`,
+				`[]interface {}{"rich text", 12.5, time.Date(2028, time.December, 25, 10, 30, 0, 0, time.UTC), true, "inline"}`,
+				`[]interface {}{interface {}(nil), 3, time.Date(2028, time.January, 2, 0, 0, 0, 0, time.UTC), false, "formula"}`,
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			data := buildTestWorkbook(t, testWorkbookFiles(s.date1904))
+
+			r, err := xlsx.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := r.Rows(s.sheet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+
+			var result []string
+			for {
+				values, err := rows.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(result) == 0 && s.sheet == "" {
+					// the header row is strings only
+					header := make([]string, len(values))
+					for i, v := range values {
+						header[i], _ = v.(string)
+					}
+					result = append(result, fmt.Sprintf("%#v", header))
+					continue
+				}
+
+				result = append(result, fmt.Sprintf("%#v", values))
+			}
+
+			if len(result) != len(s.expected) {
+				t.Fatalf("Expected %d rows, got %d:\n%v", len(s.expected), len(result), result)
+			}
+
+			for i, row := range result {
+				if row != s.expected[i] {
+					t.Errorf("[%d] Expected\n%s\ngot\n%s", i, s.expected[i], row)
+				}
+			}
+		})
+	}
+}
+
+func TestSerialToTime(t *testing.T) {
+	scenarios := []struct {
+		serial   float64
+		date1904 bool
+		expected string
+	}{
+		{0, false, "1899-12-30 00:00:00 +0000 UTC"},
+		{1, false, "1899-12-31 00:00:00 +0000 UTC"},
+		{45650.4375, false, "2024-12-24 10:30:00 +0000 UTC"},
+		{45650.4375, true, "2028-12-25 10:30:00 +0000 UTC"},
+		{45650.999999999, false, "2024-12-25 00:00:00 +0000 UTC"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(fmt.Sprintf("%v_%v", s.serial, s.date1904), func(t *testing.T) {
+			result := xlsx.SerialToTime(s.serial, s.date1904).String()
+			if result != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, result)
+			}
+		})
+	}
+}
+
+func TestTimeToSerial(t *testing.T) {
+	date := time.Date(2024, time.December, 24, 10, 30, 0, 0, time.UTC)
+
+	serial := xlsx.TimeToSerial(date)
+	if serial != 45650.4375 {
+		t.Fatalf("Expected 45650.4375, got %v", serial)
+	}
+
+	if back := xlsx.SerialToTime(serial, false); !back.Equal(date) {
+		t.Fatalf("Expected %v, got %v", date, back)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	scenarios := []struct {
+		index    int
+		expected string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.expected, func(t *testing.T) {
+			result := xlsx.ColumnName(s.index)
+			if result != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, result)
+			}
+		})
+	}
+}
+
+// -------------------------------------------------------------------
+
+func testWorkbookFiles(date1904 bool) map[string]string {
+	workbookPr := ""
+	if date1904 {
+		workbookPr = `<workbookPr date1904="1"/>`
+	}
+
+	return map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+	` + workbookPr + `
+	<sheets>
+		<sheet name="Data" sheetId="1" r:id="rId2"/>
+		<sheet name="Other" sheetId="2" r:id="rId1"/>
+	</sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+	<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+	<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet1.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<si><t>name</t></si>
+	<si><t>count</t></si>
+	<si><t>created</t></si>
+	<si><t>active</t></si>
+	<si><t>note</t></si>
+	<si><r><t>rich </t></r><r><rPr><b/></rPr><t>text</t></r><rPh><t>ignored</t></rPh></si>
+	<si><t>other</t></si>
+</sst>`,
+		"xl/styles.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<numFmts count="2">
+		<numFmt numFmtId="164" formatCode="dd/mm/yyyy\ hh:mm"/>
+		<numFmt numFmtId="165" formatCode="&quot;days&quot;\ 0.00"/>
+	</numFmts>
+	<cellXfs count="4">
+		<xf numFmtId="0"/>
+		<xf numFmtId="14"/>
+		<xf numFmtId="164"/>
+		<xf numFmtId="165"/>
+	</cellXfs>
+</styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<sheetData>
+		<row r="1">
+			<c r="A1" t="s"><v>0</v></c>
+			<c r="B1" t="s"><v>1</v></c>
+			<c r="C1" t="s"><v>2</v></c>
+			<c r="D1" t="s"><v>3</v></c>
+			<c r="E1" t="s"><v>4</v></c>
+		</row>
+		<row r="3">
+			<c r="A3" t="s"><v>5</v></c>
+			<c r="B3" s="3"><v>12.5</v></c>
+			<c r="C3" s="2"><v>45650.4375</v></c>
+			<c r="D3" t="b"><v>1</v></c>
+			<c r="E3" t="inlineStr"><is><t>inline</t></is></c>
+		</row>
+		<row r="4">
+			<c r="B4"><v>3</v></c>
+			<c r="C4" s="1"><v>45292</v></c>
+			<c r="D4" t="b"><v>0</v></c>
+			<c r="E4" t="str"><f>CONCAT("for","mula")</f><v>formula</v></c>
+		</row>
+	</sheetData>
+</worksheet>`,
+		"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<sheetData>
+		<row><c t="s"><v>6</v></c></row>
+	</sheetData>
+</worksheet>`,
+	}
+}
+
+func buildTestWorkbook(t testing.TB, files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+
+	zw := zip.NewWriter(buf)
+
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
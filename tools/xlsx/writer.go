@@ -0,0 +1,265 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCellTextLength is the max number of characters allowed in a single cell.
+//
+// Longer texts are truncated by [Writer.WriteRow].
+const MaxCellTextLength = 32767
+
+// ErrWriterClosed is returned when writing to an already closed [Writer].
+var ErrWriterClosed = errors.New("xlsx: writer is closed")
+
+// date cells style index (see writerStylesXML)
+const dateStyleIndex = 1
+
+// Writer is a streaming .xlsx workbook writer with a single sheet.
+//
+// The rows are written directly to the underlying sheet archive entry
+// so only the current row is kept in memory.
+type Writer struct {
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	rows   int
+	closed bool
+}
+
+// NewWriter creates a new single sheet workbook writer.
+//
+// If sheetName is empty, "Sheet1" is used.
+//
+// The workbook is not valid until [Writer.Close] is called.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", writerContentTypesXML},
+		{"_rels/.rels", writerRelsXML},
+		{"xl/workbook.xml", fmt.Sprintf(writerWorkbookXML, escapeXMLAttr(normalizeSheetName(sheetName)))},
+		{"xl/_rels/workbook.xml.rels", writerWorkbookRelsXML},
+		{"xl/styles.xml", writerStylesXML},
+	}
+
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.WriteString(fw, f.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// the sheet must be the last archive entry so that it could be streamed
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &Writer{
+		zw:    zw,
+		sheet: bufio.NewWriter(sw),
+	}
+
+	if _, err := writer.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// WriteRow appends a single row to the sheet.
+//
+// The cell values are typed based on their Go type:
+//   - nil values are left as empty cells
+//   - bool values are written as booleans
+//   - integer and float values are written as numbers
+//   - [time.Time] values are written as date cells
+//   - all other values are written as text (using [fmt.Sprint] for non-strings)
+func (w *Writer) WriteRow(values []any) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	w.rows++
+
+	buf := w.sheet
+
+	buf.WriteString(`<row r="`)
+	buf.WriteString(strconv.Itoa(w.rows))
+	buf.WriteString(`">`)
+
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+
+		ref := ColumnName(i) + strconv.Itoa(w.rows)
+
+		switch val := v.(type) {
+		case bool:
+			b := "0"
+			if val {
+				b = "1"
+			}
+			buf.WriteString(`<c r="` + ref + `" t="b"><v>` + b + `</v></c>`)
+		case int:
+			w.writeNumber(ref, float64(val))
+		case int8:
+			w.writeNumber(ref, float64(val))
+		case int16:
+			w.writeNumber(ref, float64(val))
+		case int32:
+			w.writeNumber(ref, float64(val))
+		case int64:
+			w.writeNumber(ref, float64(val))
+		case uint:
+			w.writeNumber(ref, float64(val))
+		case uint8:
+			w.writeNumber(ref, float64(val))
+		case uint16:
+			w.writeNumber(ref, float64(val))
+		case uint32:
+			w.writeNumber(ref, float64(val))
+		case uint64:
+			w.writeNumber(ref, float64(val))
+		case float32:
+			w.writeNumber(ref, float64(val))
+		case float64:
+			w.writeNumber(ref, val)
+		case time.Time:
+			if val.IsZero() {
+				continue
+			}
+			buf.WriteString(`<c r="` + ref + `" s="` + strconv.Itoa(dateStyleIndex) + `"><v>`)
+			buf.WriteString(strconv.FormatFloat(TimeToSerial(val), 'f', -1, 64))
+			buf.WriteString(`</v></c>`)
+		case string:
+			w.writeText(ref, val)
+		default:
+			w.writeText(ref, fmt.Sprint(val))
+		}
+	}
+
+	_, err := buf.WriteString(`</row>`)
+
+	return err
+}
+
+func (w *Writer) writeNumber(ref string, n float64) {
+	// NaN and Inf are not valid cell numbers
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		w.writeText(ref, strconv.FormatFloat(n, 'f', -1, 64))
+		return
+	}
+
+	w.sheet.WriteString(`<c r="` + ref + `"><v>`)
+	w.sheet.WriteString(strconv.FormatFloat(n, 'f', -1, 64))
+	w.sheet.WriteString(`</v></c>`)
+}
+
+func (w *Writer) writeText(ref string, text string) {
+	if text == "" {
+		return
+	}
+
+	if runes := []rune(text); len(runes) > MaxCellTextLength {
+		text = string(runes[:MaxCellTextLength])
+	}
+
+	w.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+	xml.EscapeText(w.sheet, []byte(text))
+	w.sheet.WriteString(`</t></is></c>`)
+}
+
+// Close finalizes the workbook.
+//
+// It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if _, err := w.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return w.zw.Close()
+}
+
+// normalizeSheetName replaces the characters that are not allowed
+// in a sheet name and trims it to 31 characters.
+func normalizeSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+
+	if name == "" {
+		return "Sheet1"
+	}
+
+	return name
+}
+
+func escapeXMLAttr(value string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(value))
+	return sb.String()
+}
+
+const writerContentTypesXML = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const writerRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const writerWorkbookXML = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const writerWorkbookRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// note: numFmtId 22 is the builtin "m/d/yy h:mm" date format
+const writerStylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>
+</styleSheet>`
@@ -0,0 +1,90 @@
+package xlsx_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/xlsx"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	w, err := xlsx.NewWriter(buf, "Data: [2024]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := [][]any{
+		{"name", "count", "created", "active", "note"},
+		{"a <&> \"b\"", 12.5, time.Date(2024, time.December, 24, 10, 30, 0, 0, time.UTC), true, "  spaced  "},
+		{},
+		{nil, int64(3), time.Time{}, false, math.NaN()},
+		{strings.Repeat("x", xlsx.MaxCellTextLength+10), uint8(1), "", nil, []string{"a", "b"}},
+	}
+
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.WriteRow([]any{"late"}); !errors.Is(err, xlsx.ErrWriterClosed) {
+		t.Fatalf("Expected ErrWriterClosed, got %v", err)
+	}
+
+	r, err := xlsx.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sheets := fmt.Sprint(r.Sheets()); sheets != "[Data_ _2024_]" {
+		t.Fatalf("Expected sheets [Data_ _2024_], got %s", sheets)
+	}
+
+	sheetRows, err := r.Rows("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sheetRows.Close()
+
+	expected := []string{
+		`[]interface {}{"name", "count", "created", "active", "note"}`,
+		`[]interface {}{"a <&> \"b\"", 12.5, time.Date(2024, time.December, 24, 10, 30, 0, 0, time.UTC), true, "  spaced  "}`,
+		`[]interface {}{}`,
+		`[]interface {}{interface {}(nil), 3, interface {}(nil), false, "NaN"}`,
+		`[]interface {}{"` + strings.Repeat("x", xlsx.MaxCellTextLength) + `", 1, interface {}(nil), interface {}(nil), "[a b]"}`,
+	}
+
+	var result []string
+	for {
+		values, err := sheetRows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, fmt.Sprintf("%#v", values))
+	}
+
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d:\n%v", len(expected), len(result), result)
+	}
+
+	for i, row := range result {
+		if row != expected[i] {
+			t.Errorf("[%d] Expected\n%s\ngot\n%s", i, expected[i], row)
+		}
+	}
+}